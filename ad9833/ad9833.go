@@ -0,0 +1,218 @@
+// Package ad9833 provides a driver for the AD9833 programmable waveform
+// generator, a direct digital synthesizer with a sine, triangle and square
+// output.
+//
+// The AD9833 has two frequency and two phase registers. The output can be
+// switched between them at once, which the sweep uses to change the
+// frequency without glitches.
+//
+// Datasheet:
+// https://www.analog.com/media/en/technical-documentation/data-sheets/ad9833.pdf
+package ad9833 // import "tinygo.org/x/drivers/ad9833"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var errRange = errors.New("ad9833: frequency out of range")
+
+// Waveform is the shape of the output signal.
+type Waveform uint16
+
+// Output waveforms. The square wave is the MSB of the DAC on the output,
+// at the frequency or at half the frequency, at full logic level.
+const (
+	Sine       Waveform = 0
+	Triangle   Waveform = MODE
+	Square     Waveform = OPBITEN | DIV2
+	SquareHalf Waveform = OPBITEN
+)
+
+// Register selects one of the two frequency or phase registers.
+type Register uint8
+
+// Frequency and phase registers.
+const (
+	Reg0 Register = iota
+	Reg1
+)
+
+// Config contains the settings used by Configure.
+type Config struct {
+	// MasterClock is the frequency of the clock input in Hz, 25MHz if not
+	// set.
+	MasterClock uint32
+}
+
+// Device wraps an SPI connection to an AD9833 device.
+type Device struct {
+	bus     drivers.SPI
+	cs      drivers.Pin
+	mclk    uint32
+	control uint16
+	freq    Register // selected frequency register
+	buf     [2]uint8
+}
+
+// New creates a new AD9833 connection, with the FSYNC pin, which must be
+// configured as an output. The SPI bus must already be configured in mode 2,
+// at up to 40MHz.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.SPI, cs drivers.Pin) Device {
+	return Device{
+		bus:  bus,
+		cs:   cs,
+		mclk: MasterClock,
+	}
+}
+
+// Configure resets the device and starts a sine wave of 0Hz, which is a
+// constant output, from frequency and phase register 0.
+func (d *Device) Configure(cfg Config) error {
+	if cfg.MasterClock != 0 {
+		d.mclk = cfg.MasterClock
+	}
+	d.cs.High()
+
+	d.control = B28
+	if err := d.write(ctrlControl | d.control | RESET); err != nil {
+		return err
+	}
+	for _, reg := range []Register{Reg0, Reg1} {
+		if err := d.writeFrequency(reg, 0); err != nil {
+			return err
+		}
+		if err := d.SetPhase(reg, 0); err != nil {
+			return err
+		}
+	}
+	d.freq = Reg0
+	return d.write(ctrlControl | d.control)
+}
+
+// TuningWord returns the value of a frequency register for a frequency in
+// Hz. The resolution is the master clock divided by 2^28, about 0.1Hz.
+func (d *Device) TuningWord(hz uint32) uint32 {
+	// rounded to the nearest step
+	return uint32((uint64(hz)<<28 + uint64(d.mclk)/2) / uint64(d.mclk))
+}
+
+// SetFrequency sets a frequency register to a frequency in Hz, up to half
+// the master clock.
+func (d *Device) SetFrequency(reg Register, hz uint32) error {
+	if hz > d.mclk/2 {
+		return errRange
+	}
+	return d.writeFrequency(reg, d.TuningWord(hz))
+}
+
+func (d *Device) writeFrequency(reg Register, word uint32) error {
+	ctrl := uint16(ctrlFreq0)
+	if reg == Reg1 {
+		ctrl = ctrlFreq1
+	}
+	// with B28 set the register takes two writes, the lower 14 bits first
+	if err := d.write(ctrl | uint16(word&0x3FFF)); err != nil {
+		return err
+	}
+	return d.write(ctrl | uint16(word>>14&0x3FFF))
+}
+
+// SetPhase sets a phase register to a phase offset in millidegrees, from 0
+// to 360000. The resolution is 360 degrees divided by 4096.
+func (d *Device) SetPhase(reg Register, millidegrees uint32) error {
+	ctrl := uint16(ctrlPhase0)
+	if reg == Reg1 {
+		ctrl = ctrlPhase1
+	}
+	word := (uint64(millidegrees%360000)*4096 + 180000) / 360000
+	return d.write(ctrl | uint16(word&0x0FFF))
+}
+
+// SelectFrequency selects the frequency register used for the output.
+func (d *Device) SelectFrequency(reg Register) error {
+	d.freq = reg
+	return d.updateControl(FSELECT, reg == Reg1)
+}
+
+// SelectPhase selects the phase register used for the output.
+func (d *Device) SelectPhase(reg Register) error {
+	return d.updateControl(PSELECT, reg == Reg1)
+}
+
+// SetWaveform selects the output waveform.
+func (d *Device) SetWaveform(w Waveform) error {
+	d.control = d.control&^waveMask | uint16(w)
+	return d.write(ctrlControl | d.control)
+}
+
+// SetEnabled starts or stops the output. A stopped output stays at mid
+// scale, and starts at the phase set in the phase register.
+func (d *Device) SetEnabled(enabled bool) error {
+	return d.updateControl(RESET, !enabled)
+}
+
+// SetSleep powers down the internal clock, which freezes the output, and
+// the DAC, which is not needed for the square waves.
+func (d *Device) SetSleep(clock, dac bool) error {
+	d.control &^= SLEEP1 | SLEEP12
+	if clock {
+		d.control |= SLEEP1
+	}
+	if dac {
+		d.control |= SLEEP12
+	}
+	return d.write(ctrlControl | d.control)
+}
+
+// Sweep changes the output frequency from start to stop in steps of step
+// Hz, staying dwell at every frequency. Every new frequency is written to
+// the unused frequency register before switching to it, so the output
+// changes without a glitch. start may be above stop for a downward sweep.
+func (d *Device) Sweep(start, stop, step uint32, dwell time.Duration) error {
+	if step == 0 {
+		step = 1
+	}
+	f := start
+	for {
+		next := Reg1 - d.freq
+		if err := d.SetFrequency(next, f); err != nil {
+			return err
+		}
+		if err := d.SelectFrequency(next); err != nil {
+			return err
+		}
+		if f == stop {
+			return nil
+		}
+		time.Sleep(dwell)
+		switch {
+		case start < stop && stop-f > step:
+			f += step
+		case start > stop && f-stop > step:
+			f -= step
+		default:
+			f = stop
+		}
+	}
+}
+
+func (d *Device) updateControl(bit uint16, set bool) error {
+	d.control &^= bit
+	if set {
+		d.control |= bit
+	}
+	return d.write(ctrlControl | d.control)
+}
+
+func (d *Device) write(word uint16) error {
+	d.buf[0], d.buf[1] = uint8(word>>8), uint8(word)
+	d.cs.Low()
+	err := d.bus.Tx(d.buf[:], nil)
+	d.cs.High()
+	return err
+}
@@ -0,0 +1,62 @@
+package ad9833
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"tinygo.org/x/drivers/tester"
+)
+
+// words returns the 16-bit words written on the bus since the last call.
+func words(bus *tester.SPIBus) []uint16 {
+	var w []uint16
+	for _, t := range bus.Transfers {
+		w = append(w, uint16(t[0])<<8|uint16(t[1]))
+	}
+	bus.Transfers = nil
+	return w
+}
+
+func newDevice(c *qt.C) (*Device, *tester.SPIBus) {
+	bus := tester.NewSPIBus(c)
+	bus.CS = &tester.Pin{}
+	d := New(bus, bus.CS)
+	c.Assert(d.Configure(Config{}), qt.IsNil)
+	c.Assert(bus.CS.Get(), qt.IsTrue)
+	return &d, bus
+}
+
+func TestConfigure(t *testing.T) {
+	c := qt.New(t)
+	_, bus := newDevice(c)
+	c.Assert(words(bus), qt.DeepEquals, []uint16{
+		B28 | RESET,
+		0x4000, 0x4000, 0xC000,
+		0x8000, 0x8000, 0xE000,
+		B28,
+	})
+}
+
+func TestSetFrequency(t *testing.T) {
+	c := qt.New(t)
+	d, bus := newDevice(c)
+	words(bus)
+	c.Assert(d.TuningWord(1000000), qt.Equals, uint32(0xA3D70A))
+	// with B28, the lower 14 bits then the upper 14 bits
+	c.Assert(d.SetFrequency(Reg0, 1000000), qt.IsNil)
+	c.Assert(d.SetFrequency(Reg1, 1000000), qt.IsNil)
+	c.Assert(words(bus), qt.DeepEquals, []uint16{0x570A, 0x428F, 0x970A, 0x828F})
+
+	c.Assert(d.SetFrequency(Reg0, MasterClock/2+1), qt.Equals, errRange)
+}
+
+func TestSetPhase(t *testing.T) {
+	c := qt.New(t)
+	d, bus := newDevice(c)
+	words(bus)
+	for _, millidegrees := range []uint32{90000, 360000 + 90000, 360000, 359999} {
+		c.Assert(d.SetPhase(Reg0, millidegrees), qt.IsNil)
+	}
+	c.Assert(d.SetPhase(Reg1, 180000), qt.IsNil)
+	c.Assert(words(bus), qt.DeepEquals, []uint16{0xC400, 0xC400, 0xC000, 0xC000, 0xE800})
+}
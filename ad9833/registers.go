@@ -0,0 +1,28 @@
+package ad9833
+
+// Control register bits
+const (
+	B28      = 1 << 13
+	HLB      = 1 << 12
+	FSELECT  = 1 << 11
+	PSELECT  = 1 << 10
+	RESET    = 1 << 8
+	SLEEP1   = 1 << 7
+	SLEEP12  = 1 << 6
+	OPBITEN  = 1 << 5
+	DIV2     = 1 << 3
+	MODE     = 1 << 1
+	waveMask = OPBITEN | DIV2 | MODE
+)
+
+// Register selection bits, in the upper bits of every word
+const (
+	ctrlControl = 0x0000
+	ctrlFreq0   = 0x4000
+	ctrlFreq1   = 0x8000
+	ctrlPhase0  = 0xC000
+	ctrlPhase1  = 0xE000
+)
+
+// MasterClock is the frequency of the oscillator on most modules.
+const MasterClock = 25000000
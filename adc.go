@@ -0,0 +1,9 @@
+package drivers
+
+// ADC is an analog input. It is notably implemented by the machine.ADC
+// type, which must be configured first, and by the channels of analog
+// multiplexers. Get returns a value from 0 to 0xffff, scaled from the
+// resolution of the converter.
+type ADC interface {
+	Get() uint16
+}
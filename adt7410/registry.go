@@ -0,0 +1,19 @@
+package adt7410
+
+import (
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/registry"
+)
+
+func init() {
+	registry.Register(registry.Driver{
+		Name:      "adt7410",
+		Bus:       registry.I2C,
+		Addresses: []uint16{Address1, Address2, Address3, Address4},
+		Probe: func(bus drivers.I2C, address uint16) bool {
+			d := New(bus)
+			d.Address = uint8(address)
+			return d.Connected()
+		},
+	})
+}
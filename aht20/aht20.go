@@ -0,0 +1,141 @@
+// Package aht20 provides a driver for the AHT20 and AHT21 digital humidity
+// sensors by Aosong.
+//
+// Datasheet:
+// https://cdn-learn.adafruit.com/assets/assets/000/091/676/original/AHT20-datasheet-2020-4-16.pdf
+//
+package aht20 // import "tinygo.org/x/drivers/aht20"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/internal/crc8"
+)
+
+var (
+	errChecksum     = errors.New("aht20: checksum mismatch")
+	errTimeout      = errors.New("aht20: timeout waiting for the measurement")
+	errUncalibrated = errors.New("aht20: the sensor is not calibrated")
+)
+
+var (
+	_ drivers.Thermometer = &Device{}
+	_ drivers.Hygrometer  = &Device{}
+)
+
+// Device wraps an I2C connection to an AHT20 device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	buf [7]byte
+}
+
+// New creates a new AHT20 connection. The I2C bus must already be
+// configured.
+//
+// This function only creates the Device object, it does not touch the
+// device. Configure must be called before the reads.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Configure checks that the sensor loaded its calibration, and initializes
+// it otherwise. The sensor is ready 40ms after it is powered on.
+func (d *Device) Configure() error {
+	status, err := d.Status()
+	if err != nil {
+		return err
+	}
+	if status&STATUS_CALIBRATED != 0 {
+		return nil
+	}
+	d.buf[0] = CMD_INITIALIZE
+	d.buf[1] = 0x08
+	d.buf[2] = 0x00
+	if err := d.bus.Tx(d.Address, d.buf[:3], nil); err != nil {
+		return err
+	}
+	time.Sleep(10 * time.Millisecond)
+	status, err = d.Status()
+	if err != nil {
+		return err
+	}
+	if status&STATUS_CALIBRATED == 0 {
+		return errUncalibrated
+	}
+	return nil
+}
+
+// Reset sends a soft reset. It is required to call Configure afterwards.
+func (d *Device) Reset() error {
+	d.buf[0] = CMD_SOFT_RESET
+	if err := d.bus.Tx(d.Address, d.buf[:1], nil); err != nil {
+		return err
+	}
+	time.Sleep(20 * time.Millisecond)
+	return nil
+}
+
+// Status returns the status byte, whose bits are the STATUS_ constants.
+func (d *Device) Status() (byte, error) {
+	d.buf[0] = CMD_STATUS
+	if err := d.bus.Tx(d.Address, d.buf[:1], d.buf[1:2]); err != nil {
+		return 0, err
+	}
+	return d.buf[1], nil
+}
+
+// ReadTemperature returns the temperature in celsius milli degrees (°C/1000).
+func (d *Device) ReadTemperature() (int32, error) {
+	temp, _, err := d.ReadTemperatureHumidity()
+	return temp, err
+}
+
+// ReadHumidity returns the relative humidity in hundredths of a percent.
+func (d *Device) ReadHumidity() (int32, error) {
+	_, humidity, err := d.ReadTemperatureHumidity()
+	return humidity, err
+}
+
+// ReadTemperatureHumidity triggers a measurement, waits for its end, which
+// takes 80ms, and returns the temperature in celsius milli degrees and the
+// relative humidity in hundredths of a percent.
+func (d *Device) ReadTemperatureHumidity() (temp int32, humidity int32, err error) {
+	d.buf[0] = CMD_TRIGGER
+	d.buf[1] = 0x33
+	d.buf[2] = 0x00
+	if err = d.bus.Tx(d.Address, d.buf[:3], nil); err != nil {
+		return
+	}
+	start := time.Now()
+	time.Sleep(80 * time.Millisecond)
+	data := d.buf[:7]
+	for {
+		// the status byte comes first
+		if err = d.bus.Tx(d.Address, nil, data); err != nil {
+			return
+		}
+		if data[0]&STATUS_BUSY == 0 {
+			break
+		}
+		if time.Since(start) > 200*time.Millisecond {
+			return 0, 0, errTimeout
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if crc8.Sensirion(data[:6]) != data[6] {
+		return 0, 0, errChecksum
+	}
+	// 20 bits of humidity followed by 20 bits of temperature
+	rawHum := uint32(data[1])<<12 | uint32(data[2])<<4 | uint32(data[3])>>4
+	rawTemp := uint32(data[3]&0x0F)<<16 | uint32(data[4])<<8 | uint32(data[5])
+	humidity = int32(int64(rawHum) * 10000 >> 20)
+	temp = int32(int64(rawTemp)*200000>>20) - 50000
+	return temp, humidity, nil
+}
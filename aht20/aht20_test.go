@@ -0,0 +1,89 @@
+package aht20
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers/internal/crc8"
+	"tinygo.org/x/drivers/tester"
+)
+
+// sensor is a simulated AHT20, whose measurement is 25°C and 50%.
+type sensor struct {
+	calibrated bool
+	commands   []byte
+
+	// busy is the number of reads that find the measurement running
+	busy    int
+	corrupt bool
+}
+
+func (s *sensor) tx(w, r []byte) error {
+	if len(w) > 0 {
+		s.commands = append(s.commands, w[0])
+		if w[0] == CMD_INITIALIZE {
+			s.calibrated = true
+		}
+	}
+	status := byte(0x10)
+	if s.calibrated {
+		status |= STATUS_CALIBRATED
+	}
+	switch {
+	case len(w) == 1 && w[0] == CMD_STATUS:
+		r[0] = status
+	case len(w) == 0 && len(r) == 7:
+		if s.busy > 0 {
+			s.busy--
+			r[0] = status | STATUS_BUSY
+			return nil
+		}
+		// humidity 0x80000, temperature 0x60000
+		copy(r, []byte{status, 0x80, 0x00, 0x06, 0x00, 0x00, 0})
+		r[6] = crc8.Sensirion(r[:6])
+		if s.corrupt {
+			r[6] ^= 1
+		}
+	}
+	return nil
+}
+
+// bus returns an I2C bus with the sensor on it.
+func (s *sensor) bus(c *qt.C) *tester.I2CBus {
+	bus := tester.NewI2CBus(c)
+	bus.AddDevice(tester.NewI2CCommandDevice(c, Address, s.tx))
+	return bus
+}
+
+func TestConfigure(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{calibrated: true}
+	d := New(s.bus(c))
+	c.Assert(d.Configure(), qt.IsNil)
+	c.Assert(s.commands, qt.DeepEquals, []byte{CMD_STATUS})
+
+	s = &sensor{}
+	d = New(s.bus(c))
+	c.Assert(d.Configure(), qt.IsNil)
+	c.Assert(s.commands, qt.DeepEquals, []byte{CMD_STATUS, CMD_INITIALIZE, CMD_STATUS})
+}
+
+func TestRead(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{calibrated: true, busy: 2}
+	d := New(s.bus(c))
+	temp, humidity, err := d.ReadTemperatureHumidity()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(25000))
+	c.Assert(humidity, qt.Equals, int32(5000))
+	c.Assert(s.busy, qt.Equals, 0)
+
+	s.corrupt = true
+	_, err = d.ReadTemperature()
+	c.Assert(err, qt.Equals, errChecksum)
+
+	s.busy = 100
+	_, err = d.ReadHumidity()
+	c.Assert(err, qt.Equals, errTimeout)
+}
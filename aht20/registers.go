@@ -0,0 +1,16 @@
+package aht20
+
+// The I2C address of the device.
+const Address = 0x38
+
+// Commands. Names and values copied from the datasheet.
+const (
+	CMD_STATUS     = 0x71
+	CMD_INITIALIZE = 0xBE
+	CMD_TRIGGER    = 0xAC
+	CMD_SOFT_RESET = 0xBA
+
+	// Bits of the status byte.
+	STATUS_BUSY       = 0x80
+	STATUS_CALIBRATED = 0x08
+)
@@ -0,0 +1,20 @@
+package aht20
+
+import (
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/registry"
+)
+
+func init() {
+	registry.Register(registry.Driver{
+		Name:      "aht20",
+		Bus:       registry.I2C,
+		Addresses: []uint16{Address},
+		Probe: func(bus drivers.I2C, address uint16) bool {
+			d := New(bus)
+			d.Address = address
+			status, err := d.Status()
+			return err == nil && status&STATUS_CALIBRATED != 0
+		},
+	})
+}
@@ -0,0 +1,28 @@
+// Package am2320 provides a driver for the AM2320 temperature and humidity
+// sensor on I2C.
+//
+// The sensor shares its data format with the DHT sensors, so the driver is
+// the I2C transport of the dht package and returns a dht.Device: an
+// application can switch between the AM2320 and a DHT22 by changing only
+// the constructor.
+//
+// Datasheet:
+// https://cdn-shop.adafruit.com/product-files/3721/AM2320.pdf
+package am2320 // import "tinygo.org/x/drivers/am2320"
+
+import (
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/dht"
+)
+
+// Address is the I2C address of the sensor, which cannot be changed.
+const Address = dht.AddressI2C
+
+// New returns an AM2320 connected to an I2C bus. The sensor sleeps between
+// measurements and is woken up by each read, which must be at least two
+// seconds apart. The I2C bus must already be configured.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.I2C) dht.Device {
+	return dht.NewI2C(bus, dht.AM2320)
+}
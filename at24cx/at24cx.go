@@ -1,7 +1,9 @@
-// Package at24cx provides a driver for the AT24C32/64/128/256/512 2-wire serial EEPROM
+// Package at24cx provides a driver for the AT24C02/04/08/16/32/64/128/256/512
+// 2-wire serial EEPROM
 //
 // Datasheet:
 // https://www.openimpulse.com/blog/wp-content/uploads/wpsc/downloadables/24C32-Datasheet.pdf
+// https://ww1.microchip.com/downloads/en/DeviceDoc/doc0180.pdf (AT24C01A/02/04/08A/16A)
 package at24cx // import "tinygo.org/x/drivers/at24cx"
 
 import (
@@ -11,24 +13,83 @@ import (
 	"tinygo.org/x/drivers"
 )
 
-// Device wraps an I2C connection to a DS3231 device.
+var (
+	errOutOfRange = errors.New("at24cx: address out of range")
+	errTimeout    = errors.New("at24cx: write cycle did not complete")
+)
+
+// Model selects the capacity and the addressing scheme of the chip.
+type Model uint8
+
+// Supported models. The zero value is the AT24C32, found on many DS3231 RTC
+// modules.
+const (
+	AT24C32 Model = iota
+	AT24C02
+	AT24C04
+	AT24C08
+	AT24C16
+	AT24C64
+	AT24C128
+	AT24C256
+	AT24C512
+)
+
+// params returns the capacity in bytes, the page size and whether the chip
+// uses a single address byte.
+func (m Model) params() (size uint32, page uint16, short bool) {
+	switch m {
+	case AT24C02:
+		return 256, 8, true
+	case AT24C04:
+		return 512, 16, true
+	case AT24C08:
+		return 1024, 16, true
+	case AT24C16:
+		return 2048, 16, true
+	case AT24C64:
+		return 8192, 32, false
+	case AT24C128:
+		return 16384, 64, false
+	case AT24C256:
+		return 32768, 64, false
+	case AT24C512:
+		return 65536, 128, false
+	default:
+		return 4096, 32, false
+	}
+}
+
+// Device wraps an I2C connection to an AT24Cxx device.
 type Device struct {
 	bus               drivers.I2C
 	Address           uint16
 	pageSize          uint16
-	currentRAMAddress uint16
-	startRAMAddress   uint16
-	endRAMAddress     uint16
+	short             bool
+	size              uint32
+	currentRAMAddress uint32
+	startRAMAddress   uint32
+	endRAMAddress     uint32
+	buf               []byte
 }
 
 type Config struct {
+	// Model of the chip, AT24C32 if not set.
+	Model Model
+
+	// PageSize, StartRAMAddress and EndRAMAddress override the defaults of
+	// the model. The start and end addresses limit Read, Write and Seek to
+	// a part of the memory.
 	PageSize        uint16
 	StartRAMAddress uint16
 	EndRAMAddress   uint16
 }
 
-// New creates a new AT24C32/64 connection. The I2C bus must already be
-// configured.
+// New creates a new AT24Cxx connection. The I2C bus must already be
+// configured. The default address is the one used on DS3231 modules; chips
+// with all address pins low, such as most AT24C02 to AT24C16 boards, use
+// 0x50. For the AT24C04, AT24C08 and AT24C16 the low bits of the address
+// select a block of 256 bytes, so they must be zero.
 //
 // This function only creates the Device object, it does not touch the device.
 func New(bus drivers.I2C) Device {
@@ -40,103 +101,128 @@ func New(bus drivers.I2C) Device {
 
 // Configure sets up the device for communication
 func (d *Device) Configure(cfg Config) {
-	if cfg.PageSize == 0 {
-		d.pageSize = 32
-	} else {
+	size, page, short := cfg.Model.params()
+	d.size = size
+	d.short = short
+	d.pageSize = page
+	if cfg.PageSize != 0 {
 		d.pageSize = cfg.PageSize
 	}
-	if cfg.EndRAMAddress == 0 {
-		d.endRAMAddress = 4096
-	} else {
-		d.endRAMAddress = cfg.EndRAMAddress
+	d.endRAMAddress = size
+	d.startRAMAddress = uint32(cfg.StartRAMAddress)
+	if cfg.EndRAMAddress != 0 {
+		d.endRAMAddress = uint32(cfg.EndRAMAddress)
+		d.size = d.endRAMAddress - d.startRAMAddress + 1
 	}
-	d.startRAMAddress = cfg.StartRAMAddress
+	d.currentRAMAddress = d.startRAMAddress
+	d.buf = make([]byte, d.pageSize+2)
+}
+
+// Size returns the capacity of the memory in bytes, or the size of the part
+// from StartRAMAddress to EndRAMAddress when EndRAMAddress is set.
+func (d *Device) Size() int64 {
+	return int64(d.size)
 }
 
 // WriteByte writes a byte at the specified address
 func (d *Device) WriteByte(eepromAddress uint16, value uint8) error {
-	address := []uint8{
-		uint8((eepromAddress >> 8) & 0xFF),
-		uint8(eepromAddress & 0xFF),
-		value,
-	}
-	return d.bus.Tx(d.Address, address, nil)
+	_, err := d.writeAt([]byte{value}, uint32(eepromAddress))
+	return err
 }
 
 // ReadByte reads the byte at the specified address
 func (d *Device) ReadByte(eepromAddress uint16) (uint8, error) {
-	address := []uint8{
-		uint8(eepromAddress >> 8),
-		uint8(eepromAddress & 0xFF),
-	}
 	data := make([]uint8, 1)
-	err := d.bus.Tx(d.Address, address, data)
+	_, err := d.readAt(data, uint32(eepromAddress))
 	return data[0], err
 }
 
-// WriteAt writes a byte array at the specified address
+// WriteAt writes a byte array at the specified address. Data is written in
+// chunks that do not cross page boundaries and the function waits for each
+// write cycle to complete by polling the device.
 func (d *Device) WriteAt(data []byte, offset int64) (n int, err error) {
-	return d.writeAt(data, uint16(offset))
+	if offset < 0 || offset+int64(len(data)) > int64(d.size) {
+		return 0, errOutOfRange
+	}
+	return d.writeAt(data, uint32(offset))
 }
 
 // writeAt writes a byte array at the specified address
-func (d *Device) writeAt(data []byte, offset uint16) (n int, err error) {
-	values := make([]uint8, 32)
-	dataLeft := uint16(len(data))
-	d.currentRAMAddress = offset
-	offset = 0
-	var offsetPage uint16
-	var chunkLength uint16
-	for dataLeft > 0 {
-		offsetPage = d.currentRAMAddress % d.pageSize
-		if dataLeft < 30 { // The 32K/64K EEPROM is capable of 32-byte page writes and we're using 2 for the address
-			chunkLength = dataLeft
-		} else {
-			chunkLength = 30
-		}
-		if (d.pageSize - offsetPage) < chunkLength {
-			chunkLength = d.pageSize - offsetPage
+func (d *Device) writeAt(data []byte, offset uint32) (n int, err error) {
+	for n < len(data) {
+		// a page write wraps around within the page, so never cross it
+		chunk := uint32(d.pageSize) - offset%uint32(d.pageSize)
+		if left := uint32(len(data) - n); left < chunk {
+			chunk = left
 		}
-		for i := uint16(0); i < chunkLength; i++ {
-			values[2+i] = data[offset+i]
+		addr, header := d.address(offset)
+		copy(d.buf[header:], data[n:n+int(chunk)])
+		if err := d.bus.Tx(addr, d.buf[:header+int(chunk)], nil); err != nil {
+			return n, err
 		}
-		values[0] = uint8(d.currentRAMAddress >> 8)
-		values[1] = uint8(d.currentRAMAddress & 0xFF)
-		err := d.bus.Tx(d.Address, values[:chunkLength+2], nil)
-		if err != nil {
-			return 0, err
+		if err := d.waitWriteCycle(addr); err != nil {
+			return n, err
 		}
-		dataLeft -= chunkLength
-		offset += chunkLength
-		if d.endRAMAddress-chunkLength < d.currentRAMAddress {
-			d.currentRAMAddress = d.startRAMAddress + (d.currentRAMAddress+uint16(len(data)))%d.endRAMAddress
-		} else {
-			d.currentRAMAddress += chunkLength
-		}
-		time.Sleep(2 * time.Millisecond) // writing again too soon will block the device
+		n += int(chunk)
+		offset += chunk
 	}
-	return len(data), nil
+	d.advance(offset)
+	return n, nil
 }
 
-// ReadAt reads the bytes at the specified address
+// ReadAt reads the bytes at the specified address, in a single sequential
+// read.
 func (d *Device) ReadAt(data []byte, offset int64) (n int, err error) {
-	return d.readAt(data, uint16(offset))
+	if offset < 0 || offset+int64(len(data)) > int64(d.size) {
+		return 0, errOutOfRange
+	}
+	return d.readAt(data, uint32(offset))
 }
 
 // readAt reads the bytes at the specified address
-func (d *Device) readAt(data []byte, offset uint16) (n int, err error) {
-	address := []uint8{
-		uint8((offset >> 8) & 0xFF),
-		uint8(offset & 0xFF),
+func (d *Device) readAt(data []byte, offset uint32) (n int, err error) {
+	addr, header := d.address(offset)
+	err = d.bus.Tx(addr, d.buf[:header], data)
+	d.advance(offset + uint32(len(data)))
+	return len(data), err
+}
+
+// address returns the I2C address and fills the word address bytes in the
+// buffer for the given memory address. Chips with a single address byte
+// take the upper address bits in the I2C address.
+func (d *Device) address(offset uint32) (uint16, int) {
+	if d.short {
+		d.buf[0] = uint8(offset)
+		return d.Address | uint16(offset>>8)&0x07, 1
 	}
-	err = d.bus.Tx(d.Address, address, data)
+	d.buf[0] = uint8(offset >> 8)
+	d.buf[1] = uint8(offset)
+	return d.Address, 2
+}
 
-	if d.endRAMAddress-uint16(len(data)) < offset {
-		d.currentRAMAddress = d.startRAMAddress + (offset+uint16(len(data)))%d.endRAMAddress
-	} else {
-		d.currentRAMAddress = offset + uint16(len(data))
+// waitWriteCycle polls the device until it acknowledges its address again,
+// which it does not do during an internal write cycle.
+func (d *Device) waitWriteCycle(addr uint16) error {
+	deadline := time.Now().Add(20 * time.Millisecond)
+	for {
+		time.Sleep(500 * time.Microsecond)
+		// only the first address byte is sent, which does not start a write
+		if d.bus.Tx(addr, d.buf[:1], nil) == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errTimeout
+		}
 	}
-	return len(data), err
+}
+
+// advance moves the current address used by Read and Write, wrapping at the
+// end address.
+func (d *Device) advance(next uint32) {
+	if next >= d.endRAMAddress {
+		next = d.startRAMAddress + next%d.endRAMAddress
+	}
+	d.currentRAMAddress = next
 }
 
 // Seek sets the offset for the next Read or Write on SRAM to offset, interpreted
@@ -144,7 +230,7 @@ func (d *Device) readAt(data []byte, offset uint16) (n int, err error) {
 // relative to the current offset, and 2 means relative to the end.
 // returns new offset and error, if any
 func (d *Device) Seek(offset int64, whence int) (int64, error) {
-	w := uint16(0)
+	w := uint32(0)
 	switch whence {
 	case 0:
 		w = d.startRAMAddress
@@ -155,7 +241,7 @@ func (d *Device) Seek(offset int64, whence int) (int64, error) {
 	default:
 		return 0, errors.New("invalid whence")
 	}
-	d.currentRAMAddress = w + uint16(offset)
+	d.currentRAMAddress = w + uint32(offset)
 	return int64(d.currentRAMAddress), nil
 }
 
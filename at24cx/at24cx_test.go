@@ -0,0 +1,70 @@
+package at24cx
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// eeprom is a fake chip with two address bytes.
+type eeprom struct {
+	mem  []byte
+	addr uint16
+}
+
+func (e *eeprom) ReadRegister(addr uint8, r uint8, buf []byte) error {
+	return e.Tx(uint16(addr), []byte{0, r}, buf)
+}
+
+func (e *eeprom) WriteRegister(addr uint8, r uint8, buf []byte) error {
+	return e.Tx(uint16(addr), append([]byte{0, r}, buf...), nil)
+}
+
+func (e *eeprom) Tx(addr uint16, w, r []byte) error {
+	if len(w) < 2 {
+		// acknowledge polling
+		return nil
+	}
+	e.addr = uint16(w[0])<<8 | uint16(w[1])
+	for _, b := range w[2:] {
+		e.mem[e.addr] = b
+		e.addr++
+	}
+	for i := range r {
+		r[i] = e.mem[e.addr]
+		e.addr++
+	}
+	return nil
+}
+
+func TestEndRAMAddress(t *testing.T) {
+	c := qt.New(t)
+	bus := &eeprom{mem: make([]byte, 32768+1)}
+	dev := New(bus)
+	// a configuration of AT24C256 chips before Model existed
+	dev.Configure(Config{EndRAMAddress: 32768})
+	c.Assert(dev.Size(), qt.Equals, int64(32769))
+
+	data := []byte{1, 2, 3, 4}
+	n, err := dev.WriteAt(data, 20000)
+	c.Assert(err, qt.IsNil)
+	c.Assert(n, qt.Equals, len(data))
+	c.Assert(bus.mem[20000:20004], qt.DeepEquals, data)
+
+	got := make([]byte, len(data))
+	_, err = dev.ReadAt(got, 20000)
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.DeepEquals, data)
+
+	_, err = dev.ReadAt(got, 32768)
+	c.Assert(err, qt.Equals, errOutOfRange)
+}
+
+func TestModelSize(t *testing.T) {
+	c := qt.New(t)
+	dev := New(&eeprom{mem: make([]byte, 65536)})
+	dev.Configure(Config{})
+	c.Assert(dev.Size(), qt.Equals, int64(4096))
+	_, err := dev.WriteAt([]byte{1}, 4096)
+	c.Assert(err, qt.Equals, errOutOfRange)
+}
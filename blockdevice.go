@@ -0,0 +1,27 @@
+package drivers
+
+// BlockDevice is a storage device that is read and written in blocks, such
+// as an SD card or a flash memory chip. It is notably implemented by the
+// sdcard and flash drivers and is used by filesystem packages.
+type BlockDevice interface {
+	// ReadAt reads len(buf) bytes starting at the given byte offset.
+	ReadAt(buf []byte, off int64) (n int, err error)
+
+	// WriteAt writes len(buf) bytes starting at the given byte offset. Flash
+	// devices require the area to be erased first.
+	WriteAt(buf []byte, off int64) (n int, err error)
+
+	// Size returns the total size of the device in bytes.
+	Size() int64
+
+	// WriteBlockSize returns the size in bytes of the unit in which data is
+	// written. Unaligned writes work, but may be slower.
+	WriteBlockSize() int64
+
+	// EraseBlockSize returns the size in bytes of the smallest erasable
+	// area, which is the unit used by EraseBlocks.
+	EraseBlockSize() int64
+
+	// EraseBlocks erases len blocks starting at block number start.
+	EraseBlocks(start, len int64) error
+}
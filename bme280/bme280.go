@@ -1,5 +1,5 @@
 // Package bme280 provides a driver for the BME280 digital combined
-// humidity and pressure sensor by Bosch.
+// humidity and pressure sensor by Bosch, on I2C or SPI.
 //
 // Datasheet:
 // https://cdn-shop.adafruit.com/datasheets/BST-BME280_DS001-10.pdf
@@ -7,11 +7,42 @@
 package bme280
 
 import (
+	"errors"
 	"math"
+	"time"
 
 	"tinygo.org/x/drivers"
 )
 
+var errTimeout = errors.New("bme280: timeout waiting for the measurement")
+
+// Oversampling is the number of samples averaged by a measurement, which
+// lowers the noise at the cost of a longer measurement.
+type Oversampling uint8
+
+// Mode is the power mode.
+type Mode uint8
+
+// Standby is the inactive period between two measurements in normal mode.
+type Standby uint8
+
+// Filter is the coefficient of the IIR filter applied to the temperature and
+// the pressure, which smooths out short changes such as those caused by a
+// door slamming or the wind.
+type Filter uint8
+
+// Config holds the measurement settings. The zero value of a field selects
+// the default, which Configure uses for all of them: 16x oversampling of the
+// three measurements, normal mode, 0.5ms of standby and no filter.
+type Config struct {
+	Temperature Oversampling
+	Pressure    Oversampling
+	Humidity    Oversampling
+	Mode        Mode
+	Standby     Standby
+	Filter      Filter
+}
+
 // calibrationCoefficients reads at startup and stores the calibration coefficients
 type calibrationCoefficients struct {
 	t1 uint16
@@ -34,11 +65,19 @@ type calibrationCoefficients struct {
 	h6 int8
 }
 
-// Device wraps an I2C connection to a BME280 device.
+// Device wraps an I2C or SPI connection to a BME280 device.
 type Device struct {
 	bus                     drivers.I2C
 	Address                 uint16
 	calibrationCoefficients calibrationCoefficients
+	config                  Config
+
+	// SPI bus and chip select, nil on I2C
+	spi drivers.SPI
+	cs  drivers.Pin
+
+	// buffer of the SPI transfers: the register and up to 26 bytes
+	spiBuf [27]byte
 }
 
 // New creates a new BME280 connection. The I2C bus must already be
@@ -52,26 +91,47 @@ func New(bus drivers.I2C) Device {
 	}
 }
 
+// NewSPI creates a new BME280 connection on a SPI bus, in mode 0 or 3 and
+// at up to 10MHz, with the chip select pin, which must be configured as an
+// output. The bus must already be configured.
+//
+// This function only creates the Device object, it does not touch the device.
+func NewSPI(bus drivers.SPI, cs drivers.Pin) Device {
+	cs.High()
+	return Device{
+		spi: bus,
+		cs:  cs,
+	}
+}
+
 // Configure sets up the device for communication and
 // read the calibration coefficientes.
 func (d *Device) Configure() {
+	d.ConfigureWithSettings(Config{})
+}
 
+// ConfigureWithSettings reads the calibration coefficients and sets the
+// oversampling, the mode, the standby time and the filter. In forced mode
+// the sensor sleeps between the reads, which each start a measurement and
+// wait for it; in normal mode it measures continuously and the reads return
+// the last measurement.
+func (d *Device) ConfigureWithSettings(config Config) error {
 	var data [24]byte
-	err := d.bus.ReadRegister(uint8(d.Address), REG_CALIBRATION, data[:])
+	err := d.readRegister(REG_CALIBRATION, data[:])
 	if err != nil {
-		return
+		return err
 	}
 
 	var h1 [1]byte
-	err = d.bus.ReadRegister(uint8(d.Address), REG_CALIBRATION_H1, h1[:])
+	err = d.readRegister(REG_CALIBRATION_H1, h1[:])
 	if err != nil {
-		return
+		return err
 	}
 
 	var h2lsb [7]byte
-	err = d.bus.ReadRegister(uint8(d.Address), REG_CALIBRATION_H2LSB, h2lsb[:])
+	err = d.readRegister(REG_CALIBRATION_H2LSB, h2lsb[:])
 	if err != nil {
-		return
+		return err
 	}
 
 	d.calibrationCoefficients.t1 = readUintLE(data[0], data[1])
@@ -91,26 +151,65 @@ func (d *Device) Configure() {
 	d.calibrationCoefficients.h2 = readIntLE(h2lsb[0], h2lsb[1])
 	d.calibrationCoefficients.h3 = h2lsb[2]
 	d.calibrationCoefficients.h6 = int8(h2lsb[6])
-	d.calibrationCoefficients.h4 = 0 + (int16(h2lsb[3]) << 4) | (int16(h2lsb[4] & 0x0F))
-	d.calibrationCoefficients.h5 = 0 + (int16(h2lsb[5]) << 4) | (int16(h2lsb[4]) >> 4)
+	d.calibrationCoefficients.h4 = (int16(int8(h2lsb[3])) << 4) | (int16(h2lsb[4] & 0x0F))
+	d.calibrationCoefficients.h5 = (int16(int8(h2lsb[5])) << 4) | (int16(h2lsb[4]) >> 4)
 
-	d.bus.WriteRegister(uint8(d.Address), CTRL_HUMIDITY_ADDR, []byte{0x3f})
-	d.bus.WriteRegister(uint8(d.Address), CTRL_MEAS_ADDR, []byte{0xB7})
-	d.bus.WriteRegister(uint8(d.Address), CTRL_CONFIG, []byte{0x00})
+	if config.Temperature == 0 {
+		config.Temperature = SAMPLING_16X
+	}
+	if config.Pressure == 0 {
+		config.Pressure = SAMPLING_16X
+	}
+	if config.Humidity == 0 {
+		config.Humidity = SAMPLING_16X
+	}
+	if config.Mode == 0 {
+		config.Mode = MODE_NORMAL
+	}
+	d.config = config
 
+	// the standby time and the filter are written in sleep mode, and the
+	// humidity control only takes effect after a write of CTRL_MEAS
+	if err := d.writeRegister(CTRL_MEAS_ADDR, 0); err != nil {
+		return err
+	}
+	if err := d.writeRegister(CTRL_CONFIG, byte(config.Standby)<<5|byte(config.Filter)<<2); err != nil {
+		return err
+	}
+	if err := d.writeRegister(CTRL_HUMIDITY_ADDR, config.Humidity.bits()); err != nil {
+		return err
+	}
+	if config.Mode == MODE_FORCED {
+		// measurements are started by the reads
+		return nil
+	}
+	return d.writeRegister(CTRL_MEAS_ADDR, d.ctrlMeas(MODE_NORMAL))
+}
+
+// bits returns the value of the oversampling in the control registers.
+func (o Oversampling) bits() byte {
+	if o == SAMPLING_SKIPPED {
+		return 0
+	}
+	return byte(o)
+}
+
+// ctrlMeas returns the value of CTRL_MEAS with the mode.
+func (d *Device) ctrlMeas(mode Mode) byte {
+	return d.config.Temperature.bits()<<5 | d.config.Pressure.bits()<<2 | byte(mode)
 }
 
 // Connected returns whether a BME280 has been found.
 // It does a "who am I" request and checks the response.
 func (d *Device) Connected() bool {
 	data := []byte{0}
-	d.bus.ReadRegister(uint8(d.Address), WHO_AM_I, data)
+	d.readRegister(WHO_AM_I, data)
 	return data[0] == CHIP_ID
 }
 
 // Reset the device
 func (d *Device) Reset() {
-	d.bus.WriteRegister(uint8(d.Address), CMD_RESET, []byte{0xB6})
+	d.writeRegister(CMD_RESET, 0xB6)
 }
 
 // ReadTemperature returns the temperature in celsius milli degrees (°C/1000)
@@ -185,15 +284,73 @@ func readIntLE(msb byte, lsb byte) int16 {
 
 // readData does a burst read from 0xF7 to 0xF0 according to the datasheet
 // resulting in an slice with 8 bytes 0-2 = pressure / 3-5 = temperature / 6-7 = humidity
+// In forced mode, it first starts a measurement and waits for its end.
 func (d *Device) readData() (data [8]byte, err error) {
-	err = d.bus.ReadRegister(uint8(d.Address), REG_PRESSURE, data[:])
-	if err != nil {
-		println(err)
-		return
+	if d.config.Mode == MODE_FORCED {
+		if err = d.measure(); err != nil {
+			return
+		}
 	}
+	err = d.readRegister(REG_PRESSURE, data[:])
 	return
 }
 
+// measure starts a measurement in forced mode and waits for its end, which
+// takes up to 113ms with 16x oversampling.
+func (d *Device) measure() error {
+	if err := d.writeRegister(CTRL_MEAS_ADDR, d.ctrlMeas(MODE_FORCED)); err != nil {
+		return err
+	}
+	status := []byte{0}
+	start := time.Now()
+	for {
+		time.Sleep(time.Millisecond)
+		if err := d.readRegister(REG_STATUS, status); err != nil {
+			return err
+		}
+		if status[0]&STATUS_MEASURING == 0 {
+			return nil
+		}
+		if time.Since(start) > 200*time.Millisecond {
+			return errTimeout
+		}
+	}
+}
+
+// readRegister reads len(data) bytes starting at the register.
+func (d *Device) readRegister(reg uint8, data []byte) error {
+	if d.spi == nil {
+		return d.bus.ReadRegister(uint8(d.Address), reg, data)
+	}
+	// the register address, with the top bit set for a read, followed by
+	// dummy bytes while the data is received
+	buf := d.spiBuf[:len(data)+1]
+	buf[0] = reg | 0x80
+	for i := 1; i < len(buf); i++ {
+		buf[i] = 0
+	}
+	d.cs.Low()
+	err := d.spi.Tx(buf, buf)
+	d.cs.High()
+	copy(data, buf[1:])
+	return err
+}
+
+// writeRegister writes a byte to the register.
+func (d *Device) writeRegister(reg uint8, value byte) error {
+	if d.spi == nil {
+		d.spiBuf[0] = value
+		return d.bus.WriteRegister(uint8(d.Address), reg, d.spiBuf[:1])
+	}
+	// the top bit of the register address is cleared for a write
+	d.spiBuf[0] = reg & 0x7F
+	d.spiBuf[1] = value
+	d.cs.Low()
+	err := d.spi.Tx(d.spiBuf[:2], nil)
+	d.cs.High()
+	return err
+}
+
 // calculateTemp uses the data slice and applies calibrations values on it to convert the value to milli degrees
 // it also calculates the variable tFine which is used by the pressure and humidity calculation
 func (d *Device) calculateTemp(data [8]byte) (int32, int32) {
@@ -234,25 +391,21 @@ func (d *Device) calculatePressure(data [8]byte, tFine int32) int32 {
 }
 
 // calculateHumidity uses the data slice and applies calibrations values on it to convert the value to relative humidity in hundredths of a percent
+// Datasheet: 4.2.3 Compensation formulas, in 32 bit fixed point
 func (d *Device) calculateHumidity(data [8]byte, tFine int32) int32 {
-
+	c := &d.calibrationCoefficients
 	rawHumidity := convert2Bytes(data[6], data[7])
 
-	h := float32(tFine) - 76800
-
-	if h == 0 {
-		println("invalid value")
+	v := tFine - 76800
+	v = ((((rawHumidity << 14) - (int32(c.h4) << 20) - (int32(c.h5) * v)) + 16384) >> 15) *
+		(((((((v*int32(c.h6))>>10)*(((v*int32(c.h3))>>11)+32768))>>10)+2097152)*int32(c.h2) + 8192) >> 14)
+	v = v - (((((v >> 15) * (v >> 15)) >> 7) * int32(c.h1)) >> 4)
+	if v < 0 {
+		v = 0
 	}
-
-	var1 := float32(rawHumidity) - (float32(d.calibrationCoefficients.h4)*64.0 +
-		(float32(d.calibrationCoefficients.h5) / 16384.0 * h))
-
-	var2 := float32(d.calibrationCoefficients.h2) / 65536.0 *
-		(1.0 + float32(d.calibrationCoefficients.h6)/67108864.0*h*
-			(1.0+float32(d.calibrationCoefficients.h3)/67108864.0*h))
-
-	h = var1 * var2
-	h = h * (1 - float32(d.calibrationCoefficients.h1)*h/524288)
-	return int32(100 * h)
-
+	if v > 419430400 {
+		v = 419430400
+	}
+	// v>>12 is in 1/1024 of a percent
+	return (v >> 12) * 100 >> 10
 }
@@ -0,0 +1,137 @@
+package bme280
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// sensor holds the registers of a simulated BME280, with the calibration of
+// the example of the datasheet and a humidity calibration of a real sensor.
+type sensor struct {
+	regs [256]byte
+
+	// number of measurements started in forced mode
+	forced int
+}
+
+func newSensor() *sensor {
+	s := &sensor{}
+	s.regs[WHO_AM_I] = CHIP_ID
+	cal := []uint16{27504, 26435, 0xFC18, 36477, 0xD641, 3024, 2855, 140, 0xFFF9, 15500, 0xC6F8, 6000}
+	for i, v := range cal {
+		s.regs[REG_CALIBRATION+2*i] = byte(v)
+		s.regs[REG_CALIBRATION+2*i+1] = byte(v >> 8)
+	}
+	// H1 75, H2 362, H3 0, H4 321, H5 50, H6 30
+	s.regs[REG_CALIBRATION_H1] = 75
+	copy(s.regs[REG_CALIBRATION_H2LSB:], []byte{0x6A, 0x01, 0, 0x14, 0x21, 0x03, 30})
+	// raw pressure 415148, temperature 519888 and humidity 30000
+	copy(s.regs[REG_PRESSURE:], []byte{0x65, 0x5A, 0xC0, 0x7E, 0xED, 0x00, 0x75, 0x30})
+	return s
+}
+
+func (s *sensor) write(reg uint8, value byte) {
+	s.regs[reg] = value
+	if reg == CTRL_MEAS_ADDR && Mode(value&3) == MODE_FORCED {
+		s.forced++
+		// the measurement ends before the first read of the status
+		s.regs[CTRL_MEAS_ADDR] &^= 3
+	}
+}
+
+// i2cBus is the sensor on an I2C bus.
+type i2cBus struct{ *sensor }
+
+func (b i2cBus) Tx(addr uint16, w, r []byte) error { return nil }
+
+func (b i2cBus) ReadRegister(addr uint8, reg uint8, buf []byte) error {
+	copy(buf, b.regs[reg:])
+	return nil
+}
+
+func (b i2cBus) WriteRegister(addr uint8, reg uint8, buf []byte) error {
+	b.write(reg, buf[0])
+	return nil
+}
+
+// spiBus is the sensor on a SPI bus, with its chip select pin.
+type spiBus struct {
+	*sensor
+	selected bool
+}
+
+func (b *spiBus) Tx(w, r []byte) error {
+	if !b.selected {
+		return nil
+	}
+	reg := w[0]
+	if reg&0x80 != 0 {
+		copy(r[1:], b.regs[reg&0x7F|0x80:])
+		return nil
+	}
+	// writes use the register address without its top bit
+	b.write(reg|0x80, w[1])
+	return nil
+}
+
+func (b *spiBus) Transfer(c byte) (byte, error) { return 0, nil }
+
+func (b *spiBus) Get() bool      { return !b.selected }
+func (b *spiBus) Set(level bool) { b.selected = !level }
+func (b *spiBus) High()          { b.Set(true) }
+func (b *spiBus) Low()           { b.Set(false) }
+
+func check(c *qt.C, d *Device) {
+	c.Assert(d.Connected(), qt.IsTrue)
+	temperature, err := d.ReadTemperature()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temperature, qt.Equals, int32(25080))
+	pressure, err := d.ReadPressure()
+	c.Assert(err, qt.IsNil)
+	c.Assert(pressure, qt.Equals, int32(100653000))
+	// the floating point formula of the datasheet gives 52.15%
+	humidity, err := d.ReadHumidity()
+	c.Assert(err, qt.IsNil)
+	c.Assert(humidity, qt.Equals, int32(5214))
+}
+
+func TestI2C(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(i2cBus{s})
+	d.Configure()
+	c.Assert(s.regs[CTRL_HUMIDITY_ADDR], qt.Equals, byte(0x05))
+	c.Assert(s.regs[CTRL_MEAS_ADDR], qt.Equals, byte(0xB7))
+	c.Assert(s.regs[CTRL_CONFIG], qt.Equals, byte(0x00))
+	check(c, &d)
+}
+
+func TestSPI(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	bus := &spiBus{sensor: s}
+	d := NewSPI(bus, bus)
+	c.Assert(d.ConfigureWithSettings(Config{
+		Pressure: SAMPLING_4X,
+		Humidity: SAMPLING_SKIPPED,
+		Standby:  STANDBY_125MS,
+		Filter:   FILTER_16X,
+	}), qt.IsNil)
+	c.Assert(s.regs[CTRL_HUMIDITY_ADDR], qt.Equals, byte(0x00))
+	c.Assert(s.regs[CTRL_MEAS_ADDR], qt.Equals, byte(0xAF))
+	c.Assert(s.regs[CTRL_CONFIG], qt.Equals, byte(0x50))
+	c.Assert(bus.selected, qt.IsFalse)
+	check(c, &d)
+}
+
+func TestForcedMode(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(i2cBus{s})
+	c.Assert(d.ConfigureWithSettings(Config{Mode: MODE_FORCED}), qt.IsNil)
+	c.Assert(s.forced, qt.Equals, 0)
+	check(c, &d)
+	c.Assert(s.forced, qt.Equals, 3)
+	c.Assert(s.regs[CTRL_MEAS_ADDR], qt.Equals, byte(0xB4))
+}
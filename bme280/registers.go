@@ -15,9 +15,48 @@ const (
 	REG_CALIBRATION_H1    = 0xA1
 	REG_CALIBRATION_H2LSB = 0xE1
 	CMD_RESET             = 0xE0
+	REG_STATUS            = 0xF3
 
 	WHO_AM_I = 0xD0
 	CHIP_ID  = 0x60
+
+	// STATUS_MEASURING is set in REG_STATUS while a measurement runs.
+	STATUS_MEASURING = 0x08
+)
+
+const (
+	SAMPLING_1X Oversampling = iota + 1
+	SAMPLING_2X
+	SAMPLING_4X
+	SAMPLING_8X
+	SAMPLING_16X
+
+	// SAMPLING_SKIPPED turns a measurement off.
+	SAMPLING_SKIPPED Oversampling = 0xFF
+)
+
+const (
+	MODE_FORCED Mode = 0x01
+	MODE_NORMAL Mode = 0x03
+)
+
+const (
+	STANDBY_0_5MS Standby = iota
+	STANDBY_62_5MS
+	STANDBY_125MS
+	STANDBY_250MS
+	STANDBY_500MS
+	STANDBY_1000MS
+	STANDBY_10MS
+	STANDBY_20MS
+)
+
+const (
+	FILTER_OFF Filter = iota
+	FILTER_2X
+	FILTER_4X
+	FILTER_8X
+	FILTER_16X
 )
 
 const (
@@ -0,0 +1,19 @@
+package bme280
+
+import (
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/registry"
+)
+
+func init() {
+	registry.Register(registry.Driver{
+		Name:      "bme280",
+		Bus:       registry.I2C,
+		Addresses: []uint16{Address, 0x77},
+		Probe: func(bus drivers.I2C, address uint16) bool {
+			d := New(bus)
+			d.Address = address
+			return d.Connected()
+		},
+	})
+}
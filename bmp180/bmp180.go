@@ -32,9 +32,14 @@ type calibrationCoefficients struct {
 
 // Device wraps an I2C connection to a BMP180 device.
 type Device struct {
-	bus                     drivers.I2C
-	Address                 uint16
-	mode                    OversamplingMode
+	bus     drivers.I2C
+	Address uint16
+
+	// Mode is the oversampling of the pressure measurement, from
+	// ULTRALOWPOWER, which takes 4.5ms, to ULTRAHIGHRESOLUTION, the
+	// default, which takes 25.5ms and has the lowest noise.
+	Mode OversamplingMode
+
 	calibrationCoefficients calibrationCoefficients
 }
 
@@ -47,7 +52,7 @@ func New(bus drivers.I2C) Device {
 	return Device{
 		bus:     bus,
 		Address: Address,
-		mode:    ULTRAHIGHRESOLUTION,
+		Mode:    ULTRAHIGHRESOLUTION,
 	}
 }
 
@@ -61,11 +66,11 @@ func (d *Device) Connected() bool {
 
 // Configure sets up the device for communication and
 // read the calibration coefficients.
-func (d *Device) Configure() {
+func (d *Device) Configure() error {
 	data := make([]byte, 22)
 	err := d.bus.ReadRegister(uint8(d.Address), AC1_MSB, data)
 	if err != nil {
-		return
+		return err
 	}
 	d.calibrationCoefficients.ac1 = readInt(data[0], data[1])
 	d.calibrationCoefficients.ac2 = readInt(data[2], data[3])
@@ -78,6 +83,7 @@ func (d *Device) Configure() {
 	d.calibrationCoefficients.mb = readInt(data[16], data[17])
 	d.calibrationCoefficients.mc = readInt(data[18], data[19])
 	d.calibrationCoefficients.md = readInt(data[20], data[21])
+	return nil
 }
 
 // ReadTemperature returns the temperature in celsius milli degrees (°C/1000).
@@ -97,7 +103,11 @@ func (d *Device) ReadPressure() (pressure int32, err error) {
 	if err != nil {
 		return
 	}
-	rawPressure, err := d.rawPressure(d.mode)
+	mode := d.Mode
+	if mode > ULTRAHIGHRESOLUTION {
+		mode = ULTRAHIGHRESOLUTION
+	}
+	rawPressure, err := d.rawPressure(mode)
 	if err != nil {
 		return
 	}
@@ -106,12 +116,12 @@ func (d *Device) ReadPressure() (pressure int32, err error) {
 	x1 := (int32(d.calibrationCoefficients.b2) * (b6 * b6 >> 12)) >> 11
 	x2 := (int32(d.calibrationCoefficients.ac2) * b6) >> 11
 	x3 := x1 + x2
-	b3 := (((int32(d.calibrationCoefficients.ac1)*4 + x3) << uint(d.mode)) + 2) >> 2
+	b3 := (((int32(d.calibrationCoefficients.ac1)*4 + x3) << uint(mode)) + 2) >> 2
 	x1 = (int32(d.calibrationCoefficients.ac3) * b6) >> 13
 	x2 = (int32(d.calibrationCoefficients.b1) * ((b6 * b6) >> 12)) >> 16
 	x3 = ((x1 + x2) + 2) >> 2
 	b4 := (uint32(d.calibrationCoefficients.ac4) * uint32(x3+32768)) >> 15
-	b7 := uint32(rawPressure-b3) * (50000 >> uint(d.mode))
+	b7 := uint32(rawPressure-b3) * (50000 >> uint(mode))
 	var p int32
 	if b7 < 0x80000000 {
 		p = int32((b7 << 1) / b4)
@@ -126,10 +136,13 @@ func (d *Device) ReadPressure() (pressure int32, err error) {
 
 // rawTemp returns the sensor's raw values of the temperature
 func (d *Device) rawTemp() (int32, error) {
-	d.bus.WriteRegister(uint8(d.Address), REG_CTRL, []byte{CMD_TEMP})
+	err := d.bus.WriteRegister(uint8(d.Address), REG_CTRL, []byte{CMD_TEMP})
+	if err != nil {
+		return 0, err
+	}
 	time.Sleep(5 * time.Millisecond)
 	data := make([]byte, 2)
-	err := d.bus.ReadRegister(uint8(d.Address), REG_TEMP_MSB, data)
+	err = d.bus.ReadRegister(uint8(d.Address), REG_TEMP_MSB, data)
 	if err != nil {
 		return 0, err
 	}
@@ -145,10 +158,13 @@ func (d *Device) calculateB5(rawTemp int32) int32 {
 
 // rawPressure returns the sensor's raw values of the pressure
 func (d *Device) rawPressure(mode OversamplingMode) (int32, error) {
-	d.bus.WriteRegister(uint8(d.Address), REG_CTRL, []byte{CMD_PRESSURE + byte(mode<<6)})
+	err := d.bus.WriteRegister(uint8(d.Address), REG_CTRL, []byte{CMD_PRESSURE + byte(mode<<6)})
+	if err != nil {
+		return 0, err
+	}
 	time.Sleep(pauseForReading(mode))
 	data := make([]byte, 3)
-	err := d.bus.ReadRegister(uint8(d.Address), REG_PRESSURE_MSB, data)
+	err = d.bus.ReadRegister(uint8(d.Address), REG_PRESSURE_MSB, data)
 	if err != nil {
 		return 0, err
 	}
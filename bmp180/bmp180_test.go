@@ -0,0 +1,81 @@
+package bmp180
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// sensor is a simulated BMP180 with the calibration and the measurements of
+// the example of the datasheet.
+type sensor struct {
+	regs [256]byte
+
+	// shifted raw pressure of each oversampling mode
+	pressure [4]uint32
+}
+
+func newSensor() *sensor {
+	s := &sensor{}
+	s.regs[WHO_AM_I] = CHIP_ID
+	copy(s.regs[AC1_MSB:], []byte{
+		0x01, 0x98, 0xFF, 0xB8, 0xC7, 0xD1, 0x7F, 0xE5, 0x7F, 0xF5, 0x5A,
+		0x71, 0x18, 0x2E, 0x00, 0x04, 0x80, 0x00, 0xDD, 0xF9, 0x0B, 0x34,
+	})
+	for mode := range s.pressure {
+		s.pressure[mode] = 23843 << uint(mode) << (8 - uint(mode))
+	}
+	return s
+}
+
+func (s *sensor) Tx(addr uint16, w, r []byte) error { return nil }
+
+func (s *sensor) ReadRegister(addr uint8, reg uint8, buf []byte) error {
+	copy(buf, s.regs[reg:])
+	return nil
+}
+
+func (s *sensor) WriteRegister(addr uint8, reg uint8, buf []byte) error {
+	if reg != REG_CTRL {
+		return nil
+	}
+	s.regs[REG_CTRL] = buf[0]
+	if buf[0] == CMD_TEMP {
+		// UT 27898
+		s.regs[REG_TEMP_MSB] = 0x6C
+		s.regs[REG_TEMP_MSB+1] = 0xFA
+		return nil
+	}
+	up := s.pressure[buf[0]>>6]
+	s.regs[REG_PRESSURE_MSB] = byte(up >> 16)
+	s.regs[REG_PRESSURE_MSB+1] = byte(up >> 8)
+	s.regs[REG_PRESSURE_MSB+2] = byte(up)
+	return nil
+}
+
+func TestRead(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s)
+	c.Assert(d.Connected(), qt.IsTrue)
+	c.Assert(d.Configure(), qt.IsNil)
+	c.Assert(d.calibrationCoefficients.mb, qt.Equals, int16(-32768))
+
+	temp, err := d.ReadTemperature()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(15000))
+
+	d.Mode = ULTRALOWPOWER
+	pressure, err := d.ReadPressure()
+	c.Assert(err, qt.IsNil)
+	c.Assert(pressure, qt.Equals, int32(69964000))
+	c.Assert(s.regs[REG_CTRL], qt.Equals, byte(CMD_PRESSURE))
+
+	// the same pressure, sampled 8 times, within the rounding of the
+	// integer formulas
+	d.Mode = ULTRAHIGHRESOLUTION
+	pressure, err = d.ReadPressure()
+	c.Assert(err, qt.IsNil)
+	c.Assert(pressure, qt.Equals, int32(69963000))
+	c.Assert(s.regs[REG_CTRL], qt.Equals, byte(CMD_PRESSURE|0xC0))
+}
@@ -0,0 +1,490 @@
+// Package bmp388 provides a driver for the BMP388 and BMP390 barometric
+// pressure sensors by Bosch, on I2C. Their resolution of about 2 pascals,
+// or 20cm of altitude, suits altimeters and drones; the FIFO stores up to 73
+// measurements so that the readings need not be timed by the
+// microcontroller.
+//
+// Datasheets:
+// https://www.bosch-sensortec.com/media/boschsensortec/downloads/datasheets/bst-bmp388-ds001.pdf
+// https://www.bosch-sensortec.com/media/boschsensortec/downloads/datasheets/bst-bmp390-ds002.pdf
+package bmp388 // import "tinygo.org/x/drivers/bmp388"
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	errConfig  = errors.New("bmp388: the measurements do not fit in the output data rate")
+	errTimeout = errors.New("bmp388: timeout waiting for the measurement")
+	errFrame   = errors.New("bmp388: invalid FIFO frame")
+)
+
+var (
+	_ drivers.Thermometer = &Device{}
+	_ drivers.Barometer   = &Device{}
+)
+
+// Oversampling is the number of samples averaged by a measurement, which
+// lowers the noise at the cost of a longer measurement.
+type Oversampling uint8
+
+// Mode is the power mode.
+type Mode uint8
+
+// ODR is the output data rate in normal mode.
+type ODR uint8
+
+// Filter is the coefficient of the IIR filter applied to the temperature and
+// the pressure, which smooths out short changes such as those caused by a
+// door slamming or the wind.
+type Filter uint8
+
+// Config holds the measurement settings. The zero value of a field selects
+// the default: no oversampling, normal mode at 200Hz and no filter.
+//
+// In normal mode the measurements must fit in the period of the output data
+// rate, otherwise Configure returns an error: with 8x oversampling of the
+// pressure and 1x of the temperature, for instance, the fastest rate is 50Hz.
+type Config struct {
+	Temperature Oversampling
+	Pressure    Oversampling
+	Mode        Mode
+	ODR         ODR
+	Filter      Filter
+}
+
+// FIFOConfig holds the settings of the FIFO.
+type FIFOConfig struct {
+	// Pressure and Temperature select the measurements stored in the FIFO,
+	// which is disabled when both are false.
+	Pressure    bool
+	Temperature bool
+
+	// Time appends the sensor time to the frames, read by FIFOTime.
+	Time bool
+
+	// StopOnFull stops storing the measurements when the FIFO is full,
+	// instead of overwriting the oldest ones.
+	StopOnFull bool
+
+	// Subsampling stores one of every 2^Subsampling measurements, from 0
+	// to 7.
+	Subsampling uint8
+
+	// Filtered stores the measurements after the IIR filter.
+	Filtered bool
+
+	// Watermark is the number of bytes in the FIFO at which the INT pin is
+	// raised, 0 to not use the interrupt. A frame of both measurements
+	// takes 7 bytes.
+	Watermark uint16
+}
+
+// Sample is a measurement read from the FIFO.
+type Sample struct {
+	// Temperature in milli degrees Celsius.
+	Temperature int32
+
+	// Pressure in milli-pascals.
+	Pressure int32
+
+	// HasTemperature and HasPressure tell which measurements the frame
+	// holds.
+	HasTemperature bool
+	HasPressure    bool
+}
+
+type calibrationCoefficients struct {
+	t1  uint16
+	t2  uint16
+	t3  int8
+	p1  int16
+	p2  int16
+	p3  int8
+	p4  int8
+	p5  uint16
+	p6  uint16
+	p7  int8
+	p8  int8
+	p9  int16
+	p10 int8
+	p11 int8
+}
+
+// Device wraps an I2C connection to a BMP388 or BMP390 device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	// SeaLevelPressure is the pressure at sea level in milli-pascals, used
+	// by ReadAltitude. It is the standard pressure if not set; the one of
+	// the local weather report gives a more accurate altitude.
+	SeaLevelPressure int32
+
+	calibrationCoefficients calibrationCoefficients
+	config                  Config
+
+	// linearized temperature of the last measurement, used to compensate
+	// the pressure
+	tLin int64
+
+	// sensor time of the last FIFO read
+	fifoTime uint32
+
+	buf [21]byte
+}
+
+// New creates a new BMP388 or BMP390 connection. The I2C bus must already
+// be configured.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Connected returns whether a BMP388 or a BMP390 has been found.
+// It does a "who am I" request and checks the response.
+func (d *Device) Connected() bool {
+	if d.readRegister(REG_CHIP_ID, d.buf[:1]) != nil {
+		return false
+	}
+	return d.buf[0] == CHIP_ID_BMP388 || d.buf[0] == CHIP_ID_BMP390
+}
+
+// Reset restores the power-on settings. It is required to call Configure
+// afterwards.
+func (d *Device) Reset() error {
+	return d.writeRegister(REG_CMD, CMD_RESET)
+}
+
+// Configure reads the calibration coefficients and sets the oversampling,
+// the mode, the output data rate and the filter. In forced mode the sensor
+// sleeps between the reads, which each start a measurement and wait for it;
+// in normal mode it measures continuously and the reads return the last
+// measurement.
+func (d *Device) Configure(config Config) error {
+	data := d.buf[:21]
+	if err := d.readRegister(REG_CALIBRATION, data); err != nil {
+		return err
+	}
+	c := &d.calibrationCoefficients
+	c.t1 = uint16(data[0]) | uint16(data[1])<<8
+	c.t2 = uint16(data[2]) | uint16(data[3])<<8
+	c.t3 = int8(data[4])
+	c.p1 = int16(uint16(data[5]) | uint16(data[6])<<8)
+	c.p2 = int16(uint16(data[7]) | uint16(data[8])<<8)
+	c.p3 = int8(data[9])
+	c.p4 = int8(data[10])
+	c.p5 = uint16(data[11]) | uint16(data[12])<<8
+	c.p6 = uint16(data[13]) | uint16(data[14])<<8
+	c.p7 = int8(data[15])
+	c.p8 = int8(data[16])
+	c.p9 = int16(uint16(data[17]) | uint16(data[18])<<8)
+	c.p10 = int8(data[19])
+	c.p11 = int8(data[20])
+
+	if config.Mode == 0 {
+		config.Mode = MODE_NORMAL
+	}
+	d.config = config
+
+	// the settings are written in sleep mode
+	if err := d.writeRegister(REG_PWR_CTRL, 0); err != nil {
+		return err
+	}
+	if err := d.writeRegister(REG_OSR, byte(config.Temperature)<<3|byte(config.Pressure)); err != nil {
+		return err
+	}
+	if err := d.writeRegister(REG_ODR, byte(config.ODR)); err != nil {
+		return err
+	}
+	if err := d.writeRegister(REG_CONFIG, byte(config.Filter)<<1); err != nil {
+		return err
+	}
+	if config.Mode == MODE_FORCED {
+		// measurements are started by the reads
+		return nil
+	}
+	if err := d.writeRegister(REG_PWR_CTRL, d.pwrCtrl(MODE_NORMAL)); err != nil {
+		return err
+	}
+	if err := d.readRegister(REG_ERR, d.buf[:1]); err != nil {
+		return err
+	}
+	if d.buf[0]&ERR_CONF != 0 {
+		return errConfig
+	}
+	return nil
+}
+
+// pwrCtrl returns the value of REG_PWR_CTRL with both measurements enabled
+// in the mode.
+func (d *Device) pwrCtrl(mode Mode) byte {
+	return PWR_PRESS_EN | PWR_TEMP_EN | byte(mode)<<4
+}
+
+// ReadTemperature returns the temperature in celsius milli degrees (°C/1000).
+func (d *Device) ReadTemperature() (int32, error) {
+	if err := d.readData(); err != nil {
+		return 0, err
+	}
+	return d.temperature(raw24(d.buf[3:6])), nil
+}
+
+// ReadPressure returns the pressure in milli-pascals.
+func (d *Device) ReadPressure() (int32, error) {
+	if err := d.readData(); err != nil {
+		return 0, err
+	}
+	d.temperature(raw24(d.buf[3:6]))
+	return d.pressure(raw24(d.buf[0:3])), nil
+}
+
+// ReadAltitude returns the altitude in millimeters, from the pressure and
+// SeaLevelPressure.
+func (d *Device) ReadAltitude() (int32, error) {
+	pressure, err := d.ReadPressure()
+	if err != nil {
+		return 0, err
+	}
+	seaLevel := d.SeaLevelPressure
+	if seaLevel == 0 {
+		seaLevel = SEALEVEL_PRESSURE
+	}
+	return Altitude(pressure, seaLevel), nil
+}
+
+// Altitude returns the altitude in millimeters at which the pressure is
+// measured, given the pressure at sea level, both in milli-pascals. It uses
+// the barometric formula of the standard atmosphere, which holds up to 11km.
+func Altitude(pressure, seaLevel int32) int32 {
+	return int32(44330000 * (1 - math.Pow(float64(pressure)/float64(seaLevel), 1/5.255)))
+}
+
+// readData reads the raw pressure and temperature into d.buf[0:6]. In
+// forced mode, it first starts a measurement and waits for its end.
+func (d *Device) readData() error {
+	if d.config.Mode == MODE_FORCED {
+		if err := d.measure(); err != nil {
+			return err
+		}
+	}
+	return d.readRegister(REG_DATA, d.buf[:6])
+}
+
+// measure starts a measurement in forced mode and waits for its end, which
+// takes up to 131ms with 32x oversampling.
+func (d *Device) measure() error {
+	if err := d.writeRegister(REG_PWR_CTRL, d.pwrCtrl(MODE_FORCED)); err != nil {
+		return err
+	}
+	start := time.Now()
+	for {
+		time.Sleep(time.Millisecond)
+		if err := d.readRegister(REG_STATUS, d.buf[:1]); err != nil {
+			return err
+		}
+		if d.buf[0]&(STATUS_DRDY_PRESS|STATUS_DRDY_TEMP) == STATUS_DRDY_PRESS|STATUS_DRDY_TEMP {
+			return nil
+		}
+		if time.Since(start) > 200*time.Millisecond {
+			return errTimeout
+		}
+	}
+}
+
+// ConfigureFIFO sets up the FIFO, which stores the measurements made in
+// normal mode.
+func (d *Device) ConfigureFIFO(config FIFOConfig) error {
+	d.buf[0] = byte(config.Watermark)
+	d.buf[1] = byte(config.Watermark>>8) & 1
+	if err := d.bus.WriteRegister(uint8(d.Address), REG_FIFO_WTM, d.buf[:2]); err != nil {
+		return err
+	}
+	config2 := config.Subsampling & 7
+	if config.Filtered {
+		config2 |= FIFO_FILTERED
+	}
+	if err := d.writeRegister(REG_FIFO_CONFIG_2, config2); err != nil {
+		return err
+	}
+	if err := d.readRegister(REG_INT_CTRL, d.buf[:1]); err != nil {
+		return err
+	}
+	intCtrl := d.buf[0] &^ INT_FWTM_EN
+	if config.Watermark != 0 {
+		intCtrl |= INT_FWTM_EN
+	}
+	if err := d.writeRegister(REG_INT_CTRL, intCtrl); err != nil {
+		return err
+	}
+	var config1 byte
+	if config.Pressure {
+		config1 |= FIFO_PRESS_EN
+	}
+	if config.Temperature {
+		config1 |= FIFO_TEMP_EN
+	}
+	if config1 != 0 {
+		config1 |= FIFO_MODE
+	}
+	if config.Time {
+		config1 |= FIFO_TIME_EN
+	}
+	if config.StopOnFull {
+		config1 |= FIFO_STOP_ON_FULL
+	}
+	return d.writeRegister(REG_FIFO_CONFIG_1, config1)
+}
+
+// FIFOLength returns the number of bytes in the FIFO.
+func (d *Device) FIFOLength() (int, error) {
+	if err := d.readRegister(REG_FIFO_LENGTH, d.buf[:2]); err != nil {
+		return 0, err
+	}
+	return int(d.buf[0]) | int(d.buf[1]&1)<<8, nil
+}
+
+// FlushFIFO empties the FIFO.
+func (d *Device) FlushFIFO() error {
+	return d.writeRegister(REG_CMD, CMD_FIFO_FLUSH)
+}
+
+// ReadFIFO reads the measurements stored in the FIFO into samples, oldest
+// first, and returns their number. The measurements that do not fit in
+// samples stay in the FIFO. The pressure of a frame without temperature is
+// compensated with the last temperature read.
+func (d *Device) ReadFIFO(samples []Sample) (int, error) {
+	length, err := d.FIFOLength()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for n < len(samples) && length > 0 {
+		if err := d.readRegister(REG_FIFO_DATA, d.buf[:1]); err != nil {
+			return n, err
+		}
+		header := d.buf[0]
+		length--
+		var size int
+		switch header {
+		case frameTempPress:
+			size = 6
+		case frameTemp, framePress, frameTime:
+			size = 3
+		case frameConfig, frameConfigError:
+			size = 1
+		case frameEmpty:
+			return n, nil
+		default:
+			return n, errFrame
+		}
+		if size > length {
+			return n, errFrame
+		}
+		if err := d.readRegister(REG_FIFO_DATA, d.buf[:size]); err != nil {
+			return n, err
+		}
+		length -= size
+		switch header {
+		case frameTempPress:
+			// the temperature comes first
+			samples[n] = Sample{
+				Temperature:    d.temperature(raw24(d.buf[0:3])),
+				Pressure:       d.pressure(raw24(d.buf[3:6])),
+				HasTemperature: true,
+				HasPressure:    true,
+			}
+			n++
+		case frameTemp:
+			samples[n] = Sample{Temperature: d.temperature(raw24(d.buf[0:3])), HasTemperature: true}
+			n++
+		case framePress:
+			samples[n] = Sample{Pressure: d.pressure(raw24(d.buf[0:3])), HasPressure: true}
+			n++
+		case frameTime:
+			d.fifoTime = raw24(d.buf[0:3])
+		}
+	}
+	return n, nil
+}
+
+// FIFOTime returns the sensor time read from the FIFO by the last call to
+// ReadFIFO, when FIFOConfig.Time is set. It is the time of the last frame,
+// and counts ticks of 39.0625µs on 24 bits.
+func (d *Device) FIFOTime() uint32 {
+	return d.fifoTime
+}
+
+// readRegister reads len(data) bytes starting at the register.
+func (d *Device) readRegister(reg uint8, data []byte) error {
+	return d.bus.ReadRegister(uint8(d.Address), reg, data)
+}
+
+// writeRegister writes a byte to the register.
+func (d *Device) writeRegister(reg uint8, value byte) error {
+	d.buf[20] = value
+	return d.bus.WriteRegister(uint8(d.Address), reg, d.buf[20:21])
+}
+
+// raw24 converts three little endian bytes to a raw measurement.
+func raw24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+// temperature compensates the raw temperature and returns it in milli
+// degrees Celsius. It keeps the linearized temperature, in 1/65536 of a
+// degree, to compensate the pressure. The integer formulas are those of the
+// sensor API of Bosch.
+func (d *Device) temperature(raw uint32) int32 {
+	c := &d.calibrationCoefficients
+	v1 := int64(raw) - int64(c.t1)<<8
+	v2 := int64(c.t2) * v1
+	v3 := v1 * v1 * int64(c.t3)
+	d.tLin = (v2*262144 + v3) / 4294967296
+	return int32(d.tLin * 1000 >> 16)
+}
+
+// pressure compensates the raw pressure with the last temperature and
+// returns it in milli-pascals.
+func (d *Device) pressure(raw uint32) int32 {
+	c := &d.calibrationCoefficients
+	t := d.tLin
+	p := int64(raw)
+
+	v1 := t * t
+	v2 := v1 / 64
+	v3 := v2 * t / 256
+	v4 := int64(c.p8) * v3 / 32
+	v5 := int64(c.p7) * v1 * 16
+	v6 := int64(c.p6) * t * 4194304
+	offset := int64(c.p5)*140737488355328 + v4 + v5 + v6
+
+	v2 = int64(c.p4) * v3 / 32
+	v4 = int64(c.p3) * v1 * 4
+	v5 = (int64(c.p2) - 16384) * t * 2097152
+	sensitivity := (int64(c.p1)-16384)*70368744177664 + v2 + v4 + v5
+
+	v1 = sensitivity / 16777216 * p
+	v2 = int64(c.p10) * t
+	v3 = v2 + 65536*int64(c.p9)
+	v4 = v3 * p / 8192
+	// divided by 10 then multiplied by 10 to avoid an overflow
+	v5 = v4 * (p / 10) / 512 * 10
+	v6 = p * p
+	v2 = int64(c.p11) * v6 / 65536
+	v3 = v2 * p / 128
+	v4 = offset/4 + v1 + v5 + v3
+	if v4 < 0 {
+		return 0
+	}
+	// in hundredths of a pascal
+	return int32(uint64(v4)*25/1099511627776) * 10
+}
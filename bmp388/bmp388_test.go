@@ -0,0 +1,217 @@
+package bmp388
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// sensor holds the registers of a simulated BMP388 on an I2C bus. The
+// expected values are those of the floating point formulas of the datasheet
+// with its calibration.
+type sensor struct {
+	regs [256]byte
+	fifo []byte
+
+	// number of measurements started in forced mode
+	forced int
+}
+
+// raw temperatures and pressures, little endian
+var (
+	rawTemp1  = []byte{0xE0, 0xA5, 0x7E} // 8300000: 24.2817°C
+	rawPress1 = []byte{0x80, 0x8D, 0x5B} // 6000000: 103248.00Pa
+	rawTemp2  = []byte{0x00, 0x12, 0x7A} // 8000000: 18.8433°C
+	rawPress2 = []byte{0xC0, 0xCF, 0x6A} // 7000000: 83726.36Pa
+)
+
+func newSensor() *sensor {
+	s := &sensor{}
+	s.regs[REG_CHIP_ID] = CHIP_ID_BMP388
+	copy(s.regs[REG_CALIBRATION:], []byte{
+		0x3D, 0x6A, 0x48, 0x4C, 0xF9, 0x10, 0xFA, 0xA0, 0xF5, 0x23, 0x01,
+		0xAB, 0x61, 0x9C, 0x76, 0x03, 0xFA, 0x1E, 0xF4, 0x08, 0xC4,
+	})
+	copy(s.regs[REG_DATA:], rawPress1)
+	copy(s.regs[REG_DATA+3:], rawTemp1)
+	return s
+}
+
+func (s *sensor) Tx(addr uint16, w, r []byte) error { return nil }
+
+func (s *sensor) ReadRegister(addr uint8, reg uint8, buf []byte) error {
+	switch reg {
+	case REG_FIFO_LENGTH:
+		buf[0] = byte(len(s.fifo))
+		buf[1] = byte(len(s.fifo) >> 8)
+	case REG_FIFO_DATA:
+		for i := range buf {
+			if len(s.fifo) == 0 {
+				buf[i] = frameEmpty
+				continue
+			}
+			buf[i] = s.fifo[0]
+			s.fifo = s.fifo[1:]
+		}
+	default:
+		copy(buf, s.regs[reg:])
+	}
+	return nil
+}
+
+func (s *sensor) WriteRegister(addr uint8, reg uint8, buf []byte) error {
+	copy(s.regs[reg:], buf)
+	switch {
+	case reg == REG_PWR_CTRL && buf[0]>>4 == byte(MODE_FORCED):
+		s.forced++
+		// the measurement ends before the first read of the status
+		s.regs[REG_PWR_CTRL] &^= 0x30
+		s.regs[REG_STATUS] = STATUS_DRDY_PRESS | STATUS_DRDY_TEMP
+	case reg == REG_CMD && buf[0] == CMD_FIFO_FLUSH:
+		s.fifo = nil
+	}
+	return nil
+}
+
+func TestConfigure(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s)
+	c.Assert(d.Connected(), qt.IsTrue)
+	err := d.Configure(Config{
+		Temperature: SAMPLING_2X,
+		Pressure:    SAMPLING_8X,
+		ODR:         ODR_25HZ,
+		Filter:      FILTER_3,
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(s.regs[REG_OSR], qt.Equals, byte(0x0B))
+	c.Assert(s.regs[REG_ODR], qt.Equals, byte(0x03))
+	c.Assert(s.regs[REG_CONFIG], qt.Equals, byte(0x04))
+	c.Assert(s.regs[REG_PWR_CTRL], qt.Equals, byte(0x33))
+
+	s.regs[REG_ERR] = ERR_CONF
+	c.Assert(d.Configure(Config{}), qt.Equals, errConfig)
+
+	s.regs[REG_CHIP_ID] = CHIP_ID_BMP390
+	c.Assert(d.Connected(), qt.IsTrue)
+	s.regs[REG_CHIP_ID] = 0x58
+	c.Assert(d.Connected(), qt.IsFalse)
+}
+
+func TestRead(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s)
+	c.Assert(d.Configure(Config{}), qt.IsNil)
+
+	temp, err := d.ReadTemperature()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(24281))
+	pressure, err := d.ReadPressure()
+	c.Assert(err, qt.IsNil)
+	c.Assert(pressure, qt.Equals, int32(103248000))
+	c.Assert(s.forced, qt.Equals, 0)
+
+	copy(s.regs[REG_DATA:], rawPress2)
+	copy(s.regs[REG_DATA+3:], rawTemp2)
+	pressure, err = d.ReadPressure()
+	c.Assert(err, qt.IsNil)
+	c.Assert(pressure, qt.Equals, int32(83726360))
+}
+
+func TestForced(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s)
+	c.Assert(d.Configure(Config{Mode: MODE_FORCED}), qt.IsNil)
+	c.Assert(s.regs[REG_PWR_CTRL], qt.Equals, byte(0))
+
+	pressure, err := d.ReadPressure()
+	c.Assert(err, qt.IsNil)
+	c.Assert(pressure, qt.Equals, int32(103248000))
+	c.Assert(s.forced, qt.Equals, 1)
+}
+
+func TestAltitude(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(Altitude(SEALEVEL_PRESSURE, SEALEVEL_PRESSURE), qt.Equals, int32(0))
+	// 1000m in the standard atmosphere
+	c.Assert(Altitude(89874600, SEALEVEL_PRESSURE), qt.Equals, int32(1000144))
+
+	s := newSensor()
+	d := New(s)
+	c.Assert(d.Configure(Config{}), qt.IsNil)
+	alt, err := d.ReadAltitude()
+	c.Assert(err, qt.IsNil)
+	c.Assert(alt, qt.Equals, Altitude(103248000, SEALEVEL_PRESSURE))
+	c.Assert(alt < 0, qt.IsTrue)
+
+	d.SeaLevelPressure = 103248000
+	alt, err = d.ReadAltitude()
+	c.Assert(err, qt.IsNil)
+	c.Assert(alt, qt.Equals, int32(0))
+}
+
+func TestFIFO(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s)
+	c.Assert(d.Configure(Config{}), qt.IsNil)
+	err := d.ConfigureFIFO(FIFOConfig{
+		Pressure:    true,
+		Temperature: true,
+		Time:        true,
+		Subsampling: 2,
+		Filtered:    true,
+		Watermark:   300,
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(s.regs[REG_FIFO_CONFIG_1], qt.Equals, byte(0x1D))
+	c.Assert(s.regs[REG_FIFO_CONFIG_2], qt.Equals, byte(0x0A))
+	c.Assert(s.regs[REG_FIFO_WTM:REG_FIFO_WTM+2], qt.DeepEquals, []byte{0x2C, 0x01})
+	c.Assert(s.regs[REG_INT_CTRL], qt.Equals, byte(INT_FWTM_EN))
+
+	var fifo []byte
+	fifo = append(append(append(fifo, frameTempPress), rawTemp1...), rawPress1...)
+	fifo = append(fifo, frameConfig, 0)
+	fifo = append(append(fifo, frameTemp), rawTemp2...)
+	// compensated with the temperature of the previous frame
+	fifo = append(append(fifo, framePress), rawPress2...)
+	fifo = append(fifo, frameTime, 0x56, 0x34, 0x12)
+	s.fifo = fifo
+
+	length, err := d.FIFOLength()
+	c.Assert(err, qt.IsNil)
+	c.Assert(length, qt.Equals, 21)
+
+	samples := make([]Sample, 2)
+	n, err := d.ReadFIFO(samples)
+	c.Assert(err, qt.IsNil)
+	c.Assert(n, qt.Equals, 2)
+	c.Assert(samples, qt.DeepEquals, []Sample{
+		{Temperature: 24281, Pressure: 103248000, HasTemperature: true, HasPressure: true},
+		{Temperature: 18843, HasTemperature: true},
+	})
+	c.Assert(s.fifo, qt.HasLen, 8)
+
+	n, err = d.ReadFIFO(samples)
+	c.Assert(err, qt.IsNil)
+	c.Assert(n, qt.Equals, 1)
+	c.Assert(samples[0], qt.Equals, Sample{Pressure: 83726360, HasPressure: true})
+	c.Assert(d.FIFOTime(), qt.Equals, uint32(0x123456))
+
+	n, err = d.ReadFIFO(samples)
+	c.Assert(err, qt.IsNil)
+	c.Assert(n, qt.Equals, 0)
+
+	s.fifo = []byte{0x12, 0, 0}
+	_, err = d.ReadFIFO(samples)
+	c.Assert(err, qt.Equals, errFrame)
+	c.Assert(d.FlushFIFO(), qt.IsNil)
+	c.Assert(s.fifo, qt.HasLen, 0)
+
+	c.Assert(d.ConfigureFIFO(FIFOConfig{}), qt.IsNil)
+	c.Assert(s.regs[REG_FIFO_CONFIG_1], qt.Equals, byte(0))
+	c.Assert(s.regs[REG_INT_CTRL], qt.Equals, byte(0))
+}
@@ -0,0 +1,124 @@
+package bmp388
+
+// The I2C address of the device with SDO high. It is 0x76 with SDO low.
+const Address = 0x77
+
+// Registers. Names and addresses copied from the datasheet.
+const (
+	REG_CHIP_ID       = 0x00
+	REG_ERR           = 0x02
+	REG_STATUS        = 0x03
+	REG_DATA          = 0x04
+	REG_FIFO_LENGTH   = 0x12
+	REG_FIFO_DATA     = 0x14
+	REG_FIFO_WTM      = 0x15
+	REG_FIFO_CONFIG_1 = 0x17
+	REG_FIFO_CONFIG_2 = 0x18
+	REG_INT_CTRL      = 0x19
+	REG_PWR_CTRL      = 0x1B
+	REG_OSR           = 0x1C
+	REG_ODR           = 0x1D
+	REG_CONFIG        = 0x1F
+	REG_CALIBRATION   = 0x31
+	REG_CMD           = 0x7E
+
+	// Chip identifiers.
+	CHIP_ID_BMP388 = 0x50
+	CHIP_ID_BMP390 = 0x60
+
+	// Commands of REG_CMD.
+	CMD_FIFO_FLUSH = 0xB0
+	CMD_RESET      = 0xB6
+
+	// ERR_CONF is set in REG_ERR when the measurements do not fit in the
+	// period of the output data rate.
+	ERR_CONF = 0x04
+
+	// STATUS_DRDY_PRESS and STATUS_DRDY_TEMP are set in REG_STATUS when a
+	// measurement is ready.
+	STATUS_DRDY_PRESS = 0x20
+	STATUS_DRDY_TEMP  = 0x40
+
+	// Bits of REG_PWR_CTRL, along with the mode in bits 5:4.
+	PWR_PRESS_EN = 0x01
+	PWR_TEMP_EN  = 0x02
+
+	// Bits of REG_FIFO_CONFIG_1.
+	FIFO_MODE         = 0x01
+	FIFO_STOP_ON_FULL = 0x02
+	FIFO_TIME_EN      = 0x04
+	FIFO_PRESS_EN     = 0x08
+	FIFO_TEMP_EN      = 0x10
+
+	// FIFO_FILTERED selects the filtered data in REG_FIFO_CONFIG_2.
+	FIFO_FILTERED = 0x08
+
+	// INT_FWTM_EN raises the INT pin when the FIFO reaches its watermark.
+	INT_FWTM_EN = 0x08
+
+	// Size of the FIFO in bytes.
+	FIFO_SIZE = 512
+)
+
+// Headers of the FIFO frames.
+const (
+	frameTempPress   = 0x94
+	frameTemp        = 0x90
+	framePress       = 0x84
+	frameTime        = 0xA0
+	frameEmpty       = 0x80
+	frameConfigError = 0x44
+	frameConfig      = 0x48
+)
+
+const (
+	SAMPLING_1X Oversampling = iota
+	SAMPLING_2X
+	SAMPLING_4X
+	SAMPLING_8X
+	SAMPLING_16X
+	SAMPLING_32X
+)
+
+const (
+	MODE_FORCED Mode = 0x01
+	MODE_NORMAL Mode = 0x03
+)
+
+// Output data rates in normal mode, from 200Hz down by halves.
+const (
+	ODR_200HZ ODR = iota
+	ODR_100HZ
+	ODR_50HZ
+	ODR_25HZ
+	ODR_12_5HZ
+	ODR_6_25HZ
+	ODR_3_1HZ
+	ODR_1_5HZ
+	ODR_0_78HZ
+	ODR_0_39HZ
+	ODR_0_2HZ
+	ODR_0_1HZ
+	ODR_0_05HZ
+	ODR_0_02HZ
+	ODR_0_01HZ
+	ODR_0_006HZ
+	ODR_0_003HZ
+	ODR_0_0015HZ
+)
+
+// Coefficients of the IIR filter.
+const (
+	FILTER_OFF Filter = iota
+	FILTER_1
+	FILTER_3
+	FILTER_7
+	FILTER_15
+	FILTER_31
+	FILTER_63
+	FILTER_127
+)
+
+// SEALEVEL_PRESSURE is the standard pressure at sea level in milli-pascals,
+// used by ReadAltitude when Device.SeaLevelPressure is not set.
+const SEALEVEL_PRESSURE = 101325000
@@ -0,0 +1,263 @@
+// Package button provides debounced push buttons with click, double click,
+// long press and hold-repeat events.
+//
+// Buttons are polled by calling Update regularly, every few milliseconds.
+// They can be connected to GPIO pins or to any other input, such as a pin
+// of an I/O expander. Events are passed to a callback, or queued until they
+// are read with Next.
+package button // import "tinygo.org/x/drivers/button"
+
+import (
+	"time"
+)
+
+// Input is a digital input. machine.Pin implements it; inputs on I/O
+// expanders can be wrapped in an InputFunc.
+type Input interface {
+	Get() bool
+}
+
+// InputFunc adapts a function to the Input interface.
+type InputFunc func() bool
+
+// Get calls f.
+func (f InputFunc) Get() bool {
+	return f()
+}
+
+// EventType is the kind of a button event.
+type EventType uint8
+
+// Button event types.
+const (
+	// Press and Release are sent for every debounced change of the button.
+	Press EventType = iota
+	Release
+
+	// Click is a short press. When double clicks are enabled, it is sent
+	// when no second click followed within the DoubleClickTime.
+	Click
+	DoubleClick
+
+	// LongPress is sent once when the button is held for the LongPressTime.
+	// The release after a long press is not a click.
+	LongPress
+
+	// Repeat is sent while the button is held, after the RepeatDelay.
+	Repeat
+)
+
+// Event is a button event. Button is the index of the input passed to New.
+type Event struct {
+	Button int
+	Type   EventType
+}
+
+// Pull selects the pull resistor of GPIO inputs.
+type Pull uint8
+
+// Pull resistor settings. PullDefault uses a pull-up for active low buttons
+// and a pull-down for active high buttons.
+const (
+	PullDefault Pull = iota
+	PullNone
+	PullUp
+	PullDown
+)
+
+// Config contains the settings used by Configure.
+type Config struct {
+	// ActiveHigh is set for buttons that read high when pressed. Buttons
+	// are active low by default, connecting the input to ground.
+	ActiveHigh bool
+
+	// Pull is the pull resistor configured on machine.Pin inputs. Other
+	// inputs are not configured.
+	Pull Pull
+
+	// DebounceTime an input must be stable before a change is accepted,
+	// 20ms if not set.
+	DebounceTime time.Duration
+
+	// LongPressTime is 800ms and DoubleClickTime 300ms if not set. A
+	// negative value disables the event. With double clicks disabled,
+	// clicks are sent on release without delay.
+	LongPressTime   time.Duration
+	DoubleClickTime time.Duration
+
+	// RepeatDelay is the time a button is held before it repeats, and
+	// RepeatInterval the time between repeat events, 100ms if not set.
+	// Buttons do not repeat if RepeatDelay is zero.
+	RepeatDelay    time.Duration
+	RepeatInterval time.Duration
+
+	// OnEvent is called from Update for every event. If it is not set,
+	// events are queued and read with Next.
+	OnEvent func(Event)
+}
+
+type button struct {
+	input   Input
+	raw     bool // state at the last update
+	changed time.Time
+	pressed bool // debounced state
+	since   time.Time
+	clicks  uint8
+	long    bool      // a long press was sent for the current press
+	repeat  time.Time // time of the next repeat, zero for no repeat
+}
+
+// Device is a group of buttons sharing the same configuration.
+type Device struct {
+	buttons []button
+	cfg     Config
+	now     func() time.Time
+
+	queue      [16]Event
+	head, size int
+	dropped    int
+}
+
+// New returns a group of buttons on the given inputs.
+func New(inputs ...Input) *Device {
+	d := &Device{
+		buttons: make([]button, len(inputs)),
+		now:     time.Now,
+	}
+	for i, in := range inputs {
+		d.buttons[i].input = in
+	}
+	return d
+}
+
+// Configure sets up the inputs and the timing of the events.
+func (d *Device) Configure(cfg Config) {
+	if cfg.DebounceTime == 0 {
+		cfg.DebounceTime = 20 * time.Millisecond
+	}
+	if cfg.LongPressTime == 0 {
+		cfg.LongPressTime = 800 * time.Millisecond
+	}
+	if cfg.DoubleClickTime == 0 {
+		cfg.DoubleClickTime = 300 * time.Millisecond
+	}
+	if cfg.RepeatInterval == 0 {
+		cfg.RepeatInterval = 100 * time.Millisecond
+	}
+	d.cfg = cfg
+
+	pull := cfg.Pull
+	if pull == PullDefault {
+		pull = PullUp
+		if cfg.ActiveHigh {
+			pull = PullDown
+		}
+	}
+	now := d.now()
+	for i := range d.buttons {
+		b := &d.buttons[i]
+		configureInput(b.input, pull)
+		b.raw = b.input.Get() == cfg.ActiveHigh
+		b.pressed = b.raw
+		b.changed, b.since = now, now
+		// a button held at startup does not send events until released
+		b.long = b.pressed
+		b.repeat = time.Time{}
+	}
+}
+
+// Update reads the inputs and generates the events. It must be called
+// regularly, much more often than the debounce time.
+func (d *Device) Update() {
+	now := d.now()
+	for i := range d.buttons {
+		b := &d.buttons[i]
+		if raw := b.input.Get() == d.cfg.ActiveHigh; raw != b.raw {
+			b.raw, b.changed = raw, now
+		}
+		if b.raw != b.pressed && now.Sub(b.changed) >= d.cfg.DebounceTime {
+			b.pressed, b.since = b.raw, now
+			if b.pressed {
+				d.pressed(i, b, now)
+			} else {
+				d.released(i, b)
+			}
+		}
+		if b.pressed {
+			if !b.long && d.cfg.LongPressTime > 0 && now.Sub(b.since) >= d.cfg.LongPressTime {
+				b.long = true
+				if b.clicks > 0 {
+					d.send(i, Click)
+					b.clicks = 0
+				}
+				d.send(i, LongPress)
+			}
+			if d.cfg.RepeatDelay > 0 && !b.repeat.IsZero() && !now.Before(b.repeat) {
+				d.send(i, Repeat)
+				b.repeat = b.repeat.Add(d.cfg.RepeatInterval)
+			}
+		} else if b.clicks > 0 && now.Sub(b.since) >= d.cfg.DoubleClickTime {
+			d.send(i, Click)
+			b.clicks = 0
+		}
+	}
+}
+
+func (d *Device) pressed(i int, b *button, now time.Time) {
+	b.long = false
+	b.repeat = now.Add(d.cfg.RepeatDelay)
+	d.send(i, Press)
+}
+
+func (d *Device) released(i int, b *button) {
+	d.send(i, Release)
+	if b.long {
+		return
+	}
+	b.clicks++
+	switch {
+	case d.cfg.DoubleClickTime < 0:
+		d.send(i, Click)
+		b.clicks = 0
+	case b.clicks == 2:
+		d.send(i, DoubleClick)
+		b.clicks = 0
+	}
+}
+
+func (d *Device) send(i int, t EventType) {
+	e := Event{Button: i, Type: t}
+	if d.cfg.OnEvent != nil {
+		d.cfg.OnEvent(e)
+		return
+	}
+	if d.size == len(d.queue) {
+		d.dropped++
+		return
+	}
+	d.queue[(d.head+d.size)%len(d.queue)] = e
+	d.size++
+}
+
+// Next returns the oldest queued event. It returns false if there are no
+// events.
+func (d *Device) Next() (Event, bool) {
+	if d.size == 0 {
+		return Event{}, false
+	}
+	e := d.queue[d.head]
+	d.head = (d.head + 1) % len(d.queue)
+	d.size--
+	return e, true
+}
+
+// Dropped returns the number of events that were lost because the queue
+// was full.
+func (d *Device) Dropped() int {
+	return d.dropped
+}
+
+// Pressed returns the debounced state of a button.
+func (d *Device) Pressed(i int) bool {
+	return d.buttons[i].pressed
+}
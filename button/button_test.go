@@ -0,0 +1,159 @@
+package button
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+type fakeInput struct {
+	level bool
+}
+
+func (in *fakeInput) Get() bool {
+	return in.level
+}
+
+// sim steps a button group through time in 5ms updates.
+type sim struct {
+	c     *qt.C
+	d     *Device
+	in    []*fakeInput
+	clock time.Time
+}
+
+func newSim(c *qt.C, cfg Config, n int) *sim {
+	s := &sim{c: c, clock: time.Unix(0, 0)}
+	inputs := make([]Input, n)
+	for i := range inputs {
+		in := &fakeInput{level: !cfg.ActiveHigh}
+		s.in = append(s.in, in)
+		inputs[i] = in
+	}
+	s.d = New(inputs...)
+	s.d.now = func() time.Time { return s.clock }
+	s.d.Configure(cfg)
+	return s
+}
+
+func (s *sim) run(d time.Duration) {
+	for end := s.clock.Add(d); s.clock.Before(end); s.clock = s.clock.Add(5 * time.Millisecond) {
+		s.d.Update()
+	}
+}
+
+func (s *sim) set(i int, pressed bool) {
+	s.in[i].level = pressed
+}
+
+func (s *sim) events() []EventType {
+	var types []EventType
+	for {
+		e, ok := s.d.Next()
+		if !ok {
+			return types
+		}
+		types = append(types, e.Type)
+	}
+}
+
+func TestClick(t *testing.T) {
+	c := qt.New(t)
+	s := newSim(c, Config{ActiveHigh: true}, 1)
+
+	// bounce while pressing
+	for i := 0; i < 3; i++ {
+		s.set(0, true)
+		s.run(5 * time.Millisecond)
+		s.set(0, false)
+		s.run(5 * time.Millisecond)
+	}
+	s.set(0, true)
+	s.run(100 * time.Millisecond)
+	c.Assert(s.d.Pressed(0), qt.IsTrue)
+	s.set(0, false)
+	s.run(100 * time.Millisecond)
+	c.Assert(s.events(), qt.DeepEquals, []EventType{Press, Release})
+
+	// the click is sent after the double click time
+	s.run(300 * time.Millisecond)
+	c.Assert(s.events(), qt.DeepEquals, []EventType{Click})
+}
+
+func TestDoubleClick(t *testing.T) {
+	c := qt.New(t)
+	s := newSim(c, Config{ActiveHigh: true}, 2)
+	for i := 0; i < 2; i++ {
+		s.set(1, true)
+		s.run(80 * time.Millisecond)
+		s.set(1, false)
+		s.run(80 * time.Millisecond)
+	}
+	s.run(time.Second)
+	c.Assert(s.events(), qt.DeepEquals, []EventType{Press, Release, Press, Release, DoubleClick})
+	c.Assert(s.d.Pressed(0), qt.IsFalse)
+}
+
+func TestLongPressRepeat(t *testing.T) {
+	c := qt.New(t)
+	var got []Event
+	s := newSim(c, Config{
+		DoubleClickTime: -1,
+		RepeatDelay:     500 * time.Millisecond,
+		OnEvent:         func(e Event) { got = append(got, e) },
+	}, 1)
+
+	// active low
+	s.set(0, false)
+	s.run(720 * time.Millisecond)
+	c.Assert(got, qt.DeepEquals, []Event{{0, Press}, {0, Repeat}, {0, Repeat}})
+	got = nil
+	s.run(150 * time.Millisecond)
+	c.Assert(got, qt.DeepEquals, []Event{{0, Repeat}, {0, LongPress}, {0, Repeat}})
+	got = nil
+	s.set(0, true)
+	s.run(100 * time.Millisecond)
+	c.Assert(got, qt.DeepEquals, []Event{{0, Release}})
+
+	// clicks are sent immediately with double clicks disabled
+	got = nil
+	s.set(0, false)
+	s.run(50 * time.Millisecond)
+	s.set(0, true)
+	s.run(50 * time.Millisecond)
+	c.Assert(got, qt.DeepEquals, []Event{{0, Press}, {0, Release}, {0, Click}})
+}
+
+func TestHeldAtStartup(t *testing.T) {
+	c := qt.New(t)
+	in := &fakeInput{level: true}
+	clock := time.Unix(0, 0)
+	d := New(in)
+	d.now = func() time.Time { return clock }
+	d.Configure(Config{ActiveHigh: true, RepeatDelay: 100 * time.Millisecond})
+	for i := 0; i < 400; i++ {
+		clock = clock.Add(5 * time.Millisecond)
+		d.Update()
+	}
+	_, ok := d.Next()
+	c.Assert(ok, qt.IsFalse)
+
+	in.level = false
+	for i := 0; i < 20; i++ {
+		clock = clock.Add(5 * time.Millisecond)
+		d.Update()
+	}
+	e, ok := d.Next()
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(e, qt.Equals, Event{0, Release})
+}
+
+func TestQueueFull(t *testing.T) {
+	c := qt.New(t)
+	s := newSim(c, Config{ActiveHigh: true, RepeatDelay: 10 * time.Millisecond, RepeatInterval: 10 * time.Millisecond}, 1)
+	s.set(0, true)
+	s.run(time.Second)
+	c.Assert(len(s.events()), qt.Equals, 16)
+	c.Assert(s.d.Dropped() > 0, qt.IsTrue)
+}
@@ -0,0 +1,8 @@
+// +build !tinygo
+
+package button
+
+// The inputs of the host tests report the levels set by the tests, so
+// there is no pull resistor to enable.
+
+func configureInput(in Input, pull Pull) {}
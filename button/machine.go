@@ -0,0 +1,21 @@
+// +build tinygo
+
+package button
+
+import "machine"
+
+// configureInput sets up the pull resistor of the inputs that are GPIO pins.
+func configureInput(in Input, pull Pull) {
+	pin, ok := in.(machine.Pin)
+	if !ok {
+		return
+	}
+	mode := machine.PinInput
+	switch pull {
+	case PullUp:
+		mode = machine.PinInputPullup
+	case PullDown:
+		mode = machine.PinInputPulldown
+	}
+	pin.Configure(machine.PinConfig{Mode: mode})
+}
@@ -0,0 +1,72 @@
+// Package calibration saves the calibrations of the drivers to flash or
+// EEPROM and restores them at boot, so that a sensor is calibrated once
+// rather than after every power cycle. It stores the calibration structs
+// that implement encoding.BinaryMarshaler and encoding.BinaryUnmarshaler,
+// like those of the dht, drv2605 and touch packages or the baseline of the
+// sgp30 package, in a kvstore.Store:
+//
+//	var cal dht.Calibration
+//	err := calibration.Load(store, "dht", 1, &cal)
+//	if err != nil {
+//		// not calibrated yet, or calibrated by an older firmware
+//		cal = measureCalibration()
+//		calibration.Save(store, "dht", 1, cal)
+//	}
+//	sensor.SetCalibration(cal)
+//
+// Each calibration is stored with a version, which the program increments
+// when the meaning of the calibration changes, so that Load does not return
+// a calibration from an older firmware. The records of the kvstore carry a
+// CRC, and a record damaged or written partially by a power loss is never
+// returned.
+package calibration // import "tinygo.org/x/drivers/calibration"
+
+import (
+	"encoding"
+	"errors"
+
+	"tinygo.org/x/drivers/kvstore"
+)
+
+// ErrVersion is returned by Load when the calibration was saved with
+// another version.
+var ErrVersion = errors.New("calibration: saved with another version")
+
+// prefix of the keys, to share the store with other settings
+const prefix = "cal."
+
+// Save stores the calibration under the name with the version.
+func Save(store *kvstore.Store, name string, version uint8, c encoding.BinaryMarshaler) error {
+	data, err := c.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	record := make([]byte, 1+len(data))
+	record[0] = version
+	copy(record[1:], data)
+	return store.Set(prefix+name, record)
+}
+
+// Load restores the calibration saved under the name. It returns
+// kvstore.ErrNotFound when there is none and ErrVersion when it was saved
+// with another version, and c is then not modified.
+func Load(store *kvstore.Store, name string, version uint8, c encoding.BinaryUnmarshaler) error {
+	n, err := store.Len(prefix + name)
+	if err != nil {
+		return err
+	}
+	record := make([]byte, n)
+	if _, err := store.Get(prefix+name, record); err != nil {
+		return err
+	}
+	if n == 0 || record[0] != version {
+		return ErrVersion
+	}
+	return c.UnmarshalBinary(record[1:])
+}
+
+// Delete removes the calibration saved under the name, to calibrate the
+// device again at the next boot.
+func Delete(store *kvstore.Store, name string) error {
+	return store.Delete(prefix + name)
+}
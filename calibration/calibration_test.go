@@ -0,0 +1,82 @@
+package calibration
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers/dht"
+	"tinygo.org/x/drivers/drv2605"
+	"tinygo.org/x/drivers/kvstore"
+	"tinygo.org/x/drivers/sgp30"
+	"tinygo.org/x/drivers/touch"
+)
+
+type ram []byte
+
+func (r ram) ReadAt(buf []byte, off int64) (int, error)  { return copy(buf, r[off:]), nil }
+func (r ram) WriteAt(buf []byte, off int64) (int, error) { return copy(r[off:], buf), nil }
+func (r ram) Size() int64                                { return int64(len(r)) }
+
+func open(c *qt.C, dev ram) *kvstore.Store {
+	store, err := kvstore.Open(kvstore.EEPROM(dev, 256))
+	c.Assert(err, qt.IsNil)
+	return store
+}
+
+func TestSaveLoad(t *testing.T) {
+	c := qt.New(t)
+	dev := make(ram, 1024)
+	store := open(c, dev)
+
+	var cal dht.Calibration
+	c.Assert(Load(store, "dht", 1, &cal), qt.Equals, kvstore.ErrNotFound)
+
+	saved := dht.Calibration{TemperatureOffset: -12, HumidityGain: 1030, HumidityOffset: 25}
+	c.Assert(Save(store, "dht", 1, saved), qt.IsNil)
+	c.Assert(Save(store, "drv2605", 1, drv2605.Calibration{Compensation: 12, BackEMF: 140, BackEMFGain: 2}), qt.IsNil)
+
+	// after a reboot
+	store = open(c, dev)
+	c.Assert(Load(store, "dht", 1, &cal), qt.IsNil)
+	c.Assert(cal, qt.Equals, saved)
+	var haptic drv2605.Calibration
+	c.Assert(Load(store, "drv2605", 1, &haptic), qt.IsNil)
+	c.Assert(haptic, qt.Equals, drv2605.Calibration{Compensation: 12, BackEMF: 140, BackEMFGain: 2})
+
+	// a newer firmware does not use the old calibration
+	cal = dht.Calibration{}
+	c.Assert(Load(store, "dht", 2, &cal), qt.Equals, ErrVersion)
+	c.Assert(cal, qt.Equals, dht.Calibration{})
+
+	c.Assert(Delete(store, "dht"), qt.IsNil)
+	c.Assert(Load(store, "dht", 1, &cal), qt.Equals, kvstore.ErrNotFound)
+}
+
+func TestBaseline(t *testing.T) {
+	c := qt.New(t)
+	store := open(c, make(ram, 1024))
+	saved := sgp30.Baseline{ECO2: 0x8973, TVOC: 0x8AAE}
+	c.Assert(Save(store, "sgp30", 1, saved), qt.IsNil)
+
+	var baseline sgp30.Baseline
+	c.Assert(Load(store, "sgp30", 1, &baseline), qt.IsNil)
+	c.Assert(baseline, qt.Equals, saved)
+}
+
+func TestTouch(t *testing.T) {
+	c := qt.New(t)
+	store := open(c, make(ram, 1024))
+	cal, err := touch.NewCalibration(
+		[3]touch.Point{{X: 3900, Y: 300}, {X: 300, Y: 3800}, {X: 3800, Y: 3700}},
+		[3]touch.Point{{X: 10, Y: 10}, {X: 310, Y: 230}, {X: 10, Y: 230}},
+	)
+	c.Assert(err, qt.IsNil)
+	c.Assert(Save(store, "touch", 1, cal), qt.IsNil)
+
+	var loaded touch.Calibration
+	c.Assert(Load(store, "touch", 1, &loaded), qt.IsNil)
+	p := touch.Point{X: 2000, Y: 2000}
+	c.Assert(loaded.Transform(p), qt.Equals, cal.Transform(p))
+	c.Assert(loaded.Transform(p), qt.Not(qt.Equals), p)
+}
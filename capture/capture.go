@@ -0,0 +1,137 @@
+// Package capture defines a text format for the signals received by the
+// drivers, so that a trace recorded on a board can be attached to a bug
+// report and replayed by the tests of the driver on the host. It also
+// generates random variations of the traces, to check that the decoders
+// never panic and return their errors on damaged data.
+//
+// A file holds one or more captures. Each starts with a driver line, and is
+// followed by lines of parameters and of data; blank lines and the lines
+// starting with # are ignored:
+//
+//	# DHT22 on a Feather M4, checksum errors in the sun
+//	driver dht
+//	device DHT22
+//	bytes 02 8c 01 5f ee
+//	widths 50 26 50 26 50 26 50 26 50 26 50 26 50 70 50 26
+//	widths 50 70 50 26 50 26 50 26 50 70 50 70 50 26 50 26
+//
+// The bytes lines hold bytes in hexadecimal, such as a frame or the data
+// read on a bus, and the widths lines hold the widths of the pulses of a
+// signal, alternately low and high, in units that depend on the driver. The
+// lines of the same kind are concatenated. Any other line is a parameter,
+// a name followed by its value.
+package capture // import "tinygo.org/x/drivers/capture"
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var errNoDriver = errors.New("capture: data before the driver line")
+
+// Capture is a trace of the signals received by a driver.
+type Capture struct {
+	Driver string
+
+	// Params holds the parameters, such as the device type.
+	Params map[string]string
+
+	Bytes  []byte
+	Widths []uint16
+}
+
+// Parse reads the captures of a file.
+func Parse(r io.Reader) ([]Capture, error) {
+	var captures []Capture
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		name, values := fields[0], fields[1:]
+		if name == "driver" {
+			if len(values) != 1 {
+				return nil, lineError(line, "the driver line needs a name")
+			}
+			captures = append(captures, Capture{Driver: values[0], Params: map[string]string{}})
+			continue
+		}
+		if len(captures) == 0 {
+			return nil, errNoDriver
+		}
+		c := &captures[len(captures)-1]
+		switch name {
+		case "bytes":
+			for _, v := range values {
+				b, err := strconv.ParseUint(v, 16, 8)
+				if err != nil {
+					return nil, lineError(line, "invalid byte "+v)
+				}
+				c.Bytes = append(c.Bytes, byte(b))
+			}
+		case "widths":
+			for _, v := range values {
+				w, err := strconv.ParseUint(v, 10, 16)
+				if err != nil {
+					return nil, lineError(line, "invalid width "+v)
+				}
+				c.Widths = append(c.Widths, uint16(w))
+			}
+		default:
+			c.Params[name] = strings.Join(values, " ")
+		}
+	}
+	return captures, scanner.Err()
+}
+
+func lineError(line int, msg string) error {
+	return errors.New("capture: line " + strconv.Itoa(line) + ": " + msg)
+}
+
+// WriteTo writes the capture in the format read by Parse, with its
+// parameters sorted by name.
+func (c Capture) WriteTo(w io.Writer) (int64, error) {
+	var b []byte
+	b = append(b, "driver "...)
+	b = append(b, c.Driver...)
+	b = append(b, '\n')
+	names := make([]string, 0, len(c.Params))
+	for name := range c.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b = append(b, name...)
+		b = append(b, ' ')
+		b = append(b, c.Params[name]...)
+		b = append(b, '\n')
+	}
+	for i, v := range c.Bytes {
+		if i%16 == 0 {
+			b = append(b, "bytes"...)
+		}
+		b = append(b, ' ', "0123456789abcdef"[v>>4], "0123456789abcdef"[v&15])
+		if i%16 == 15 || i == len(c.Bytes)-1 {
+			b = append(b, '\n')
+		}
+	}
+	for i, v := range c.Widths {
+		if i%16 == 0 {
+			b = append(b, "widths"...)
+		}
+		b = append(b, ' ')
+		b = strconv.AppendUint(b, uint64(v), 10)
+		if i%16 == 15 || i == len(c.Widths)-1 {
+			b = append(b, '\n')
+		}
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
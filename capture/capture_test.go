@@ -0,0 +1,96 @@
+package capture
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+const file = `# two captures
+driver dht
+device DHT22
+temperature 35.1
+bytes 02 8C 01 5f
+bytes ee
+widths 50 26 50 70
+
+driver am2320
+bytes 03 04 01 f4 00 fa
+`
+
+func TestParse(t *testing.T) {
+	c := qt.New(t)
+	captures, err := Parse(strings.NewReader(file))
+	c.Assert(err, qt.IsNil)
+	c.Assert(captures, qt.DeepEquals, []Capture{
+		{
+			Driver: "dht",
+			Params: map[string]string{"device": "DHT22", "temperature": "35.1"},
+			Bytes:  []byte{0x02, 0x8C, 0x01, 0x5F, 0xEE},
+			Widths: []uint16{50, 26, 50, 70},
+		},
+		{
+			Driver: "am2320",
+			Params: map[string]string{},
+			Bytes:  []byte{0x03, 0x04, 0x01, 0xF4, 0x00, 0xFA},
+		},
+	})
+
+	for _, bad := range []string{
+		"device DHT22\n",
+		"driver\n",
+		"driver dht\nbytes 100\n",
+		"driver dht\nwidths -1\n",
+	} {
+		_, err := Parse(strings.NewReader(bad))
+		c.Check(err, qt.Not(qt.IsNil), qt.Commentf("%q", bad))
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	c := qt.New(t)
+	in := Capture{
+		Driver: "dht",
+		Params: map[string]string{"units": "us", "device": "DHT11"},
+		Bytes:  make([]byte, 20),
+		Widths: make([]uint16, 17),
+	}
+	var buf bytes.Buffer
+	_, err := in.WriteTo(&buf)
+	c.Assert(err, qt.IsNil)
+	lines := strings.Split(buf.String(), "\n")
+	c.Assert(lines[:3], qt.DeepEquals, []string{"driver dht", "device DHT11", "units us"})
+	c.Assert(lines, qt.HasLen, 8)
+
+	out, err := Parse(&buf)
+	c.Assert(err, qt.IsNil)
+	c.Assert(out, qt.DeepEquals, []Capture{in})
+}
+
+func TestFuzzer(t *testing.T) {
+	c := qt.New(t)
+	data := []byte{1, 2, 3, 4, 5}
+	widths := []uint16{80, 80, 50, 26, 50, 70}
+	f := NewFuzzer(1)
+	changedBytes, changedWidths := 0, 0
+	for i := 0; i < 1000; i++ {
+		if !bytes.Equal(f.Bytes(data), data) {
+			changedBytes++
+		}
+		if w := f.Widths(widths); len(w) != len(widths) || w[0] != 80 || w[len(w)-1] != 70 {
+			changedWidths++
+		}
+	}
+	c.Assert(data, qt.DeepEquals, []byte{1, 2, 3, 4, 5})
+	c.Assert(widths, qt.DeepEquals, []uint16{80, 80, 50, 26, 50, 70})
+	c.Assert(changedBytes > 500, qt.IsTrue)
+	c.Assert(changedWidths > 500, qt.IsTrue)
+
+	// the variations are reproducible
+	a, b := NewFuzzer(42), NewFuzzer(42)
+	for i := 0; i < 100; i++ {
+		c.Assert(a.Widths(widths), qt.DeepEquals, b.Widths(widths))
+	}
+}
@@ -0,0 +1,105 @@
+package capture
+
+// Fuzzer derives random variations of captured data, which are likely to
+// reach the error paths of the decoders: truncated or extended data,
+// flipped bits, and pulses that are stretched, shortened, dropped or
+// split. It is deterministic for a given seed, so a failing variation can
+// be reproduced.
+type Fuzzer struct {
+	state uint64
+}
+
+// NewFuzzer returns a fuzzer with the seed.
+func NewFuzzer(seed uint64) *Fuzzer {
+	return &Fuzzer{state: seed | 1}
+}
+
+// next returns a random number with a xorshift generator.
+func (f *Fuzzer) next() uint64 {
+	f.state ^= f.state << 13
+	f.state ^= f.state >> 7
+	f.state ^= f.state << 17
+	return f.state
+}
+
+func (f *Fuzzer) intn(n int) int {
+	return int(f.next() % uint64(n))
+}
+
+// Bytes returns a variation of data, which is not modified.
+func (f *Fuzzer) Bytes(data []byte) []byte {
+	b := append([]byte(nil), data...)
+	switch f.intn(4) {
+	case 0:
+		// truncated
+		if len(b) > 0 {
+			b = b[:f.intn(len(b))]
+		}
+	case 1:
+		// extended
+		for n := f.intn(8) + 1; n > 0; n-- {
+			b = append(b, byte(f.next()))
+		}
+	case 2:
+		// random
+		for i := range b {
+			b[i] = byte(f.next())
+		}
+	}
+	// flipped bits
+	for n := f.intn(4); n > 0 && len(b) > 0; n-- {
+		b[f.intn(len(b))] ^= 1 << uint(f.intn(8))
+	}
+	return b
+}
+
+// Widths returns a variation of the widths of a signal, which are not
+// modified.
+func (f *Fuzzer) Widths(widths []uint16) []uint16 {
+	w := append([]uint16(nil), widths...)
+	switch f.intn(6) {
+	case 0:
+		// truncated
+		if len(w) > 0 {
+			w = w[:f.intn(len(w))]
+		}
+	case 1:
+		// a pulse dropped
+		if len(w) > 0 {
+			i := f.intn(len(w))
+			w = append(w[:i], w[i+1:]...)
+		}
+	case 2:
+		// a pulse split by a glitch
+		if len(w) > 0 {
+			i := f.intn(len(w))
+			half := w[i] / 2
+			split := []uint16{half, 1, w[i] - half}
+			w = append(w[:i], append(split, w[i+1:]...)...)
+		}
+	case 3:
+		// a different clock
+		scale := uint64(f.intn(400) + 1)
+		for i := range w {
+			w[i] = uint16(min(uint64(w[i])*scale/100, 0xFFFF))
+		}
+	case 4:
+		// random
+		for i := range w {
+			w[i] = uint16(f.next())
+		}
+	}
+	// jitter
+	for n := f.intn(8); n > 0 && len(w) > 0; n-- {
+		i := f.intn(len(w))
+		w[i] = uint16(min(uint64(w[i])+uint64(f.intn(64)), 0xFFFF))
+	}
+	return w
+}
+
+func min(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,289 @@
+// Package ccs811 provides a driver for the CCS811 digital gas sensor by ams,
+// which measures the total volatile organic compounds (TVOC) and derives an
+// equivalent CO2 concentration (eCO2) from them.
+//
+// The sensor needs to run for 48 hours before its first use, and for 20
+// minutes before its measurements are accurate each time it starts. It
+// adjusts its baseline, the resistance of clean air, while it runs: the
+// baseline can be saved and restored to skip the adjustment after a power
+// cycle. The nWAKE pin must be held low during the transfers.
+//
+// Datasheet:
+// https://www.sciosense.com/wp-content/uploads/documents/SC-001232-DS-2-CCS811B-Datasheet-Revision-2.pdf
+//
+package ccs811 // import "tinygo.org/x/drivers/ccs811"
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	errNotFound      = errors.New("ccs811: device not found")
+	errNoApplication = errors.New("ccs811: no valid application firmware")
+	errBoot          = errors.New("ccs811: the application did not start")
+)
+
+var _ drivers.PowerManaged = &Device{}
+
+// DriveMode is the rate of the measurements.
+type DriveMode uint8
+
+// Config holds the settings of the sensor.
+type Config struct {
+	// Mode is the rate of the measurements. When lowering it, the sensor
+	// must be idle for 10 minutes first.
+	Mode DriveMode
+
+	// DataReadyInterrupt pulls the nINT pin low when a measurement is ready,
+	// until it is read.
+	DataReadyInterrupt bool
+}
+
+// Error is the content of the ERROR_ID register, a set of ERROR_ bits. It is
+// returned when the sensor reports an error.
+type Error uint8
+
+var errorNames = [...]string{
+	"invalid register write",
+	"invalid register read",
+	"invalid drive mode",
+	"sensor resistance out of range",
+	"heater current out of range",
+	"heater voltage incorrect",
+}
+
+func (e Error) Error() string {
+	var names []string
+	for i, name := range errorNames {
+		if e&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "ccs811: unknown error"
+	}
+	return "ccs811: " + strings.Join(names, ", ")
+}
+
+// Device wraps an I2C connection to a CCS811 device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	mode byte // MEAS_MODE set by Configure
+
+	buf [8]byte
+}
+
+// New creates a new CCS811 connection. The I2C bus must already be
+// configured, and must support clock stretching.
+//
+// This function only creates the Device object, it does not touch the
+// device.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Connected returns whether a CCS811 has been found.
+func (d *Device) Connected() bool {
+	if err := d.readRegister(REG_HW_ID, d.buf[:1]); err != nil {
+		return false
+	}
+	return d.buf[0] == HW_ID
+}
+
+// Configure starts the application firmware of the sensor, which boots in
+// its boot loader, and then sets the drive mode.
+func (d *Device) Configure(config Config) error {
+	if !d.Connected() {
+		return errNotFound
+	}
+	status, err := d.Status()
+	if err != nil {
+		return err
+	}
+	if status&STATUS_APP_VALID == 0 {
+		return errNoApplication
+	}
+	if status&STATUS_FW_MODE == 0 {
+		d.buf[0] = REG_APP_START
+		if err := d.bus.Tx(d.Address, d.buf[:1], nil); err != nil {
+			return err
+		}
+		time.Sleep(time.Millisecond)
+		if status, err = d.Status(); err != nil {
+			return err
+		}
+		if status&STATUS_FW_MODE == 0 {
+			return errBoot
+		}
+	}
+	mode := byte(config.Mode&7) << 4
+	if config.DataReadyInterrupt {
+		mode |= MEAS_MODE_INT_DATARDY
+	}
+	if err := d.writeRegister(REG_MEAS_MODE, mode); err != nil {
+		return err
+	}
+	d.mode = mode
+	return d.checkError()
+}
+
+// Sleep stops the measurements by selecting the idle drive mode. The
+// current is lowest when the nWAKE pin is also released.
+func (d *Device) Sleep() error {
+	if err := d.writeRegister(REG_MEAS_MODE, 0); err != nil {
+		return err
+	}
+	return d.checkError()
+}
+
+// Wake restores the drive mode set by Configure.
+func (d *Device) Wake() error {
+	if err := d.writeRegister(REG_MEAS_MODE, d.mode); err != nil {
+		return err
+	}
+	return d.checkError()
+}
+
+// SupplyCurrent returns the currents of the datasheet at 1.8V: 19µA in
+// sleep, and while measuring the average current of the drive mode set by
+// Configure.
+func (d *Device) SupplyCurrent() (sleeping, active uint32) {
+	switch DriveMode(d.mode >> 4 & 7) {
+	case MODE_IDLE:
+		return 19, 19
+	case MODE_10S:
+		return 19, 3900
+	case MODE_60S:
+		return 19, 700
+	default:
+		return 19, 26000
+	}
+}
+
+// Reset sends a software reset, after which the sensor is in its boot
+// loader. It is required to call Configure afterwards.
+func (d *Device) Reset() error {
+	d.buf[0] = 0x11
+	d.buf[1] = 0xE5
+	d.buf[2] = 0x72
+	d.buf[3] = 0x8A
+	if err := d.bus.WriteRegister(uint8(d.Address), REG_SW_RESET, d.buf[:4]); err != nil {
+		return err
+	}
+	time.Sleep(2 * time.Millisecond)
+	return nil
+}
+
+// Status returns the STATUS register, whose bits are the STATUS_ constants.
+func (d *Device) Status() (byte, error) {
+	if err := d.readRegister(REG_STATUS, d.buf[:1]); err != nil {
+		return 0, err
+	}
+	return d.buf[0], nil
+}
+
+// DataReady returns whether a new measurement is ready.
+func (d *Device) DataReady() (bool, error) {
+	status, err := d.Status()
+	return status&STATUS_DATA_READY != 0, err
+}
+
+// ReadECO2TVOC returns the last measurement: the equivalent CO2
+// concentration in ppm, from 400ppm to 8192ppm, and the TVOC concentration
+// in ppb, from 0ppb to 1187ppb. It returns an Error when the sensor reports
+// one.
+func (d *Device) ReadECO2TVOC() (eco2 uint16, tvoc uint16, err error) {
+	data := d.buf[:6]
+	if err = d.readRegister(REG_ALG_RESULT_DATA, data); err != nil {
+		return
+	}
+	// the status and the error ID follow the measurement
+	if data[4]&STATUS_ERROR != 0 {
+		return 0, 0, d.checkError()
+	}
+	return uint16(data[0])<<8 | uint16(data[1]), uint16(data[2])<<8 | uint16(data[3]), nil
+}
+
+// ErrorID reads and clears the ERROR_ID register.
+func (d *Device) ErrorID() (Error, error) {
+	if err := d.readRegister(REG_ERROR_ID, d.buf[:1]); err != nil {
+		return 0, err
+	}
+	return Error(d.buf[0]), nil
+}
+
+// SetEnvironmentalData sets the relative humidity, in hundredths of a
+// percent, and the temperature, in celsius milli degrees, of the air, which
+// the sensor compensates for. They are the units of another sensor such as a
+// bme280 or a dht, and default to 50% and 25°C.
+func (d *Device) SetEnvironmentalData(humidity int32, temp int32) error {
+	// both in 1/512, the temperature with an offset of 25°C
+	h := humidity * 512 / 100
+	t := (temp + 25000) * 512 / 1000
+	switch {
+	case h < 0:
+		h = 0
+	case h > 100*512:
+		h = 100 * 512
+	}
+	switch {
+	case t < 0:
+		t = 0
+	case t > 0xFFFF:
+		t = 0xFFFF
+	}
+	d.buf[0] = byte(h >> 8)
+	d.buf[1] = byte(h)
+	d.buf[2] = byte(t >> 8)
+	d.buf[3] = byte(t)
+	return d.bus.WriteRegister(uint8(d.Address), REG_ENV_DATA, d.buf[:4])
+}
+
+// Baseline returns the current baseline, to restore it with SetBaseline
+// after a power cycle. It is specific to each sensor and should be saved
+// after the sensor has run for a while in clean air.
+func (d *Device) Baseline() (uint16, error) {
+	if err := d.readRegister(REG_BASELINE, d.buf[:2]); err != nil {
+		return 0, err
+	}
+	return uint16(d.buf[0])<<8 | uint16(d.buf[1]), nil
+}
+
+// SetBaseline restores a baseline returned by Baseline. It should be done
+// after the 20 minutes the sensor needs to warm up.
+func (d *Device) SetBaseline(baseline uint16) error {
+	d.buf[0] = byte(baseline >> 8)
+	d.buf[1] = byte(baseline)
+	return d.bus.WriteRegister(uint8(d.Address), REG_BASELINE, d.buf[:2])
+}
+
+// checkError returns the error reported by the sensor, if any.
+func (d *Device) checkError() error {
+	status, err := d.Status()
+	if err != nil || status&STATUS_ERROR == 0 {
+		return err
+	}
+	id, err := d.ErrorID()
+	if err != nil {
+		return err
+	}
+	return id
+}
+
+func (d *Device) readRegister(reg uint8, data []byte) error {
+	return d.bus.ReadRegister(uint8(d.Address), reg, data)
+}
+
+func (d *Device) writeRegister(reg uint8, value byte) error {
+	d.buf[7] = value
+	return d.bus.WriteRegister(uint8(d.Address), reg, d.buf[7:8])
+}
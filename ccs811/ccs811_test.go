@@ -0,0 +1,165 @@
+package ccs811
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// sensor holds the registers of a simulated CCS811 on an I2C bus, which
+// starts in its boot loader.
+type sensor struct {
+	regs    [256]byte
+	errorID byte
+	started bool
+	writes  map[uint8][]byte
+}
+
+func newSensor() *sensor {
+	s := &sensor{writes: map[uint8][]byte{}}
+	s.regs[REG_HW_ID] = HW_ID
+	s.regs[REG_STATUS] = STATUS_APP_VALID
+	// 500ppm and 15ppb
+	copy(s.regs[REG_ALG_RESULT_DATA:], []byte{0x01, 0xF4, 0x00, 0x0F})
+	copy(s.regs[REG_BASELINE:], []byte{0x84, 0xB9})
+	return s
+}
+
+func (s *sensor) fault(e Error) {
+	s.errorID = byte(e)
+	s.regs[REG_STATUS] |= STATUS_ERROR
+}
+
+func (s *sensor) Tx(addr uint16, w, r []byte) error {
+	if len(w) == 1 && w[0] == REG_APP_START {
+		s.started = true
+		s.regs[REG_STATUS] |= STATUS_FW_MODE
+	}
+	return nil
+}
+
+func (s *sensor) ReadRegister(addr uint8, reg uint8, buf []byte) error {
+	switch reg {
+	case REG_ALG_RESULT_DATA:
+		s.regs[REG_ALG_RESULT_DATA+4] = s.regs[REG_STATUS]
+		s.regs[REG_ALG_RESULT_DATA+5] = s.errorID
+		copy(buf, s.regs[reg:])
+	case REG_ERROR_ID:
+		buf[0] = s.errorID
+		s.errorID = 0
+		s.regs[REG_STATUS] &^= STATUS_ERROR
+	default:
+		copy(buf, s.regs[reg:])
+	}
+	return nil
+}
+
+func (s *sensor) WriteRegister(addr uint8, reg uint8, buf []byte) error {
+	s.writes[reg] = append([]byte(nil), buf...)
+	switch reg {
+	case REG_MEAS_MODE:
+		if buf[0]>>4 > byte(MODE_250MS) {
+			s.fault(ERROR_MEASMODE_INVALID)
+		}
+	case REG_BASELINE:
+		copy(s.regs[reg:], buf)
+	}
+	return nil
+}
+
+func TestConfigure(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s)
+	c.Assert(d.Connected(), qt.IsTrue)
+	c.Assert(d.Configure(Config{Mode: MODE_1S, DataReadyInterrupt: true}), qt.IsNil)
+	c.Assert(s.started, qt.IsTrue)
+	c.Assert(s.writes[REG_MEAS_MODE], qt.DeepEquals, []byte{0x18})
+
+	// the application is already running
+	s.started = false
+	c.Assert(d.Configure(Config{Mode: MODE_60S}), qt.IsNil)
+	c.Assert(s.started, qt.IsFalse)
+	c.Assert(s.writes[REG_MEAS_MODE], qt.DeepEquals, []byte{0x30})
+
+	err := d.Configure(Config{Mode: 5})
+	c.Assert(err, qt.Equals, ERROR_MEASMODE_INVALID)
+	c.Assert(err, qt.ErrorMatches, "ccs811: invalid drive mode")
+
+	s = newSensor()
+	s.regs[REG_STATUS] = 0
+	d = New(s)
+	c.Assert(d.Configure(Config{Mode: MODE_1S}), qt.Equals, errNoApplication)
+
+	s.regs[REG_HW_ID] = 0
+	c.Assert(d.Configure(Config{Mode: MODE_1S}), qt.Equals, errNotFound)
+}
+
+func TestRead(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s)
+	c.Assert(d.Configure(Config{Mode: MODE_1S}), qt.IsNil)
+
+	ready, err := d.DataReady()
+	c.Assert(err, qt.IsNil)
+	c.Assert(ready, qt.IsFalse)
+
+	eco2, tvoc, err := d.ReadECO2TVOC()
+	c.Assert(err, qt.IsNil)
+	c.Assert(eco2, qt.Equals, uint16(500))
+	c.Assert(tvoc, qt.Equals, uint16(15))
+
+	s.fault(ERROR_HEATER_FAULT | ERROR_HEATER_SUPPLY)
+	_, _, err = d.ReadECO2TVOC()
+	c.Assert(err, qt.Equals, ERROR_HEATER_FAULT|ERROR_HEATER_SUPPLY)
+	c.Assert(err, qt.ErrorMatches, "ccs811: heater current out of range, heater voltage incorrect")
+
+	// the error is cleared
+	_, _, err = d.ReadECO2TVOC()
+	c.Assert(err, qt.IsNil)
+}
+
+func TestEnvironmentalData(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s)
+	// examples of the datasheet
+	c.Assert(d.SetEnvironmentalData(5000, 25000), qt.IsNil)
+	c.Assert(s.writes[REG_ENV_DATA], qt.DeepEquals, []byte{0x64, 0x00, 0x64, 0x00})
+	c.Assert(d.SetEnvironmentalData(4850, 27500), qt.IsNil)
+	c.Assert(s.writes[REG_ENV_DATA], qt.DeepEquals, []byte{0x61, 0x00, 0x69, 0x00})
+
+	// out of range
+	c.Assert(d.SetEnvironmentalData(-100, -30000), qt.IsNil)
+	c.Assert(s.writes[REG_ENV_DATA], qt.DeepEquals, []byte{0x00, 0x00, 0x00, 0x00})
+}
+
+func TestBaseline(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s)
+	baseline, err := d.Baseline()
+	c.Assert(err, qt.IsNil)
+	c.Assert(baseline, qt.Equals, uint16(0x84B9))
+
+	c.Assert(d.SetBaseline(0x1234), qt.IsNil)
+	c.Assert(s.writes[REG_BASELINE], qt.DeepEquals, []byte{0x12, 0x34})
+	baseline, err = d.Baseline()
+	c.Assert(err, qt.IsNil)
+	c.Assert(baseline, qt.Equals, uint16(0x1234))
+}
+
+func TestSleep(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s)
+	c.Assert(d.Configure(Config{Mode: MODE_10S}), qt.IsNil)
+	_, active := d.SupplyCurrent()
+	c.Assert(active, qt.Equals, uint32(3900))
+
+	c.Assert(d.Sleep(), qt.IsNil)
+	c.Assert(s.writes[REG_MEAS_MODE], qt.DeepEquals, []byte{0x00})
+	c.Assert(d.Wake(), qt.IsNil)
+	c.Assert(s.writes[REG_MEAS_MODE], qt.DeepEquals, []byte{0x20})
+}
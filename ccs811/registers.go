@@ -0,0 +1,58 @@
+package ccs811
+
+// The I2C address of the device with ADDR low. It is 0x5B with ADDR high.
+const Address = 0x5A
+
+// Registers. Names and addresses copied from the datasheet.
+const (
+	REG_STATUS          = 0x00
+	REG_MEAS_MODE       = 0x01
+	REG_ALG_RESULT_DATA = 0x02
+	REG_RAW_DATA        = 0x03
+	REG_ENV_DATA        = 0x05
+	REG_THRESHOLDS      = 0x10
+	REG_BASELINE        = 0x11
+	REG_HW_ID           = 0x20
+	REG_HW_VERSION      = 0x21
+	REG_FW_BOOT_VERSION = 0x23
+	REG_FW_APP_VERSION  = 0x24
+	REG_ERROR_ID        = 0xE0
+	REG_APP_START       = 0xF4
+	REG_SW_RESET        = 0xFF
+)
+
+// The value of the HW_ID register.
+const HW_ID = 0x81
+
+// Bits of the STATUS register.
+const (
+	STATUS_ERROR      = 0x01
+	STATUS_DATA_READY = 0x08
+	STATUS_APP_VALID  = 0x10
+	STATUS_FW_MODE    = 0x80
+)
+
+// Drive modes, the rates of the measurements.
+const (
+	MODE_IDLE  DriveMode = 0 // no measurement
+	MODE_1S    DriveMode = 1 // every second
+	MODE_10S   DriveMode = 2 // every 10 seconds
+	MODE_60S   DriveMode = 3 // every 60 seconds
+	MODE_250MS DriveMode = 4 // raw data only, every 250ms
+)
+
+// Bits of the MEAS_MODE register, besides the drive mode.
+const (
+	MEAS_MODE_INT_DATARDY = 0x08
+	MEAS_MODE_INT_THRESH  = 0x04
+)
+
+// Bits of the ERROR_ID register.
+const (
+	ERROR_WRITE_REG_INVALID Error = 0x01
+	ERROR_READ_REG_INVALID  Error = 0x02
+	ERROR_MEASMODE_INVALID  Error = 0x04
+	ERROR_MAX_RESISTANCE    Error = 0x08
+	ERROR_HEATER_FAULT      Error = 0x10
+	ERROR_HEATER_SUPPLY     Error = 0x20
+)
@@ -0,0 +1,116 @@
+// Package cd74hc4067 provides a driver for the CD74HC4067 16-channel and
+// 74HC4051 8-channel analog multiplexers.
+//
+// The common pin of the multiplexer is connected to an analog input, so
+// every channel can be read as an ADC of its own.
+//
+// Datasheets:
+// https://www.ti.com/lit/ds/symlink/cd74hc4067.pdf
+// https://www.ti.com/lit/ds/symlink/cd74hc4051.pdf
+package cd74hc4067 // import "tinygo.org/x/drivers/cd74hc4067"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var errChannel = errors.New("cd74hc4067: invalid channel")
+
+// Config contains the settings used by Configure.
+type Config struct {
+	// Settling is the time to wait after switching to another channel
+	// before reading it, 10µs if not set. Sources with a high impedance
+	// need more time to charge the sample capacitor of the ADC.
+	Settling time.Duration
+}
+
+// Device is an analog multiplexer.
+type Device struct {
+	input    drivers.ADC
+	enable   drivers.Pin
+	selects  []drivers.Pin
+	selected int
+	settling time.Duration
+}
+
+// New returns a multiplexer with its common pin connected to input. There
+// are four select pins, S0 to S3, for the 4067 and three for the 4051. The
+// active low enable pin may be nil if it is connected to ground. Pins of
+// the microcontroller are configured as outputs by Configure, other pins
+// must already be outputs.
+func New(input drivers.ADC, enable drivers.Pin, selects ...drivers.Pin) *Device {
+	return &Device{
+		input:    input,
+		enable:   enable,
+		selects:  selects,
+		selected: -1,
+	}
+}
+
+// Configure sets up the pins and enables the multiplexer.
+func (d *Device) Configure(cfg Config) {
+	d.settling = cfg.Settling
+	if d.settling == 0 {
+		d.settling = 10 * time.Microsecond
+	}
+	for _, pin := range d.selects {
+		configureOutput(pin)
+	}
+	if d.enable != nil {
+		configureOutput(d.enable)
+	}
+	d.SetEnabled(true)
+}
+
+// SetEnabled connects or disconnects all channels from the common pin.
+func (d *Device) SetEnabled(enabled bool) {
+	if d.enable != nil {
+		d.enable.Set(!enabled)
+	}
+}
+
+// Channels returns the number of channels.
+func (d *Device) Channels() int {
+	return 1 << uint(len(d.selects))
+}
+
+// Select connects a channel to the common pin and waits for the input to
+// settle.
+func (d *Device) Select(channel int) {
+	if channel < 0 || channel >= d.Channels() {
+		panic(errChannel)
+	}
+	if channel == d.selected {
+		return
+	}
+	for i, pin := range d.selects {
+		pin.Set(channel&(1<<uint(i)) != 0)
+	}
+	d.selected = channel
+	time.Sleep(d.settling)
+}
+
+// Channel returns a channel of the multiplexer as an ADC.
+func (d *Device) Channel(n int) Channel {
+	if n < 0 || n >= d.Channels() {
+		panic(errChannel)
+	}
+	return Channel{d: d, n: n}
+}
+
+var _ drivers.ADC = Channel{}
+
+// Channel is a single channel of the multiplexer. It implements
+// drivers.ADC.
+type Channel struct {
+	d *Device
+	n int
+}
+
+// Get selects the channel and reads the input.
+func (c Channel) Get() uint16 {
+	c.d.Select(c.n)
+	return c.d.input.Get()
+}
@@ -0,0 +1,82 @@
+package cd74hc4067
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/tester"
+)
+
+// fakeADC reads the channel number set on the select pins, times 1000.
+type fakeADC struct {
+	selects []*tester.Pin
+	reads   int
+}
+
+func (a *fakeADC) Get() uint16 {
+	a.reads++
+	channel := 0
+	for i, pin := range a.selects {
+		if pin.Get() {
+			channel |= 1 << uint(i)
+		}
+	}
+	return uint16(channel * 1000)
+}
+
+func newMux(c *qt.C, n int) (*Device, *fakeADC, *tester.Pin) {
+	adc := &fakeADC{}
+	pins := make([]drivers.Pin, n)
+	for i := range pins {
+		adc.selects = append(adc.selects, &tester.Pin{})
+		pins[i] = adc.selects[i]
+	}
+	enable := &tester.Pin{}
+	enable.High()
+	d := New(adc, enable, pins...)
+	d.Configure(Config{Settling: time.Nanosecond})
+	return d, adc, enable
+}
+
+func TestSelect(t *testing.T) {
+	c := qt.New(t)
+	d, adc, enable := newMux(c, 4)
+	c.Assert(d.Channels(), qt.Equals, 16)
+	c.Assert(enable.Get(), qt.IsFalse)
+
+	for channel := 0; channel < d.Channels(); channel++ {
+		d.Select(channel)
+		for i, pin := range adc.selects {
+			c.Assert(pin.Get(), qt.Equals, channel&(1<<uint(i)) != 0, qt.Commentf("channel %d, S%d", channel, i))
+		}
+	}
+
+	d.SetEnabled(false)
+	c.Assert(enable.Get(), qt.IsTrue)
+	d.SetEnabled(true)
+	c.Assert(enable.Get(), qt.IsFalse)
+}
+
+func TestChannel(t *testing.T) {
+	c := qt.New(t)
+	d, adc, _ := newMux(c, 3)
+	c.Assert(d.Channels(), qt.Equals, 8)
+	c.Assert(d.Channel(5).Get(), qt.Equals, uint16(5000))
+	c.Assert(d.Channel(2).Get(), qt.Equals, uint16(2000))
+	c.Assert(d.Channel(2).Get(), qt.Equals, uint16(2000))
+	c.Assert(adc.reads, qt.Equals, 3)
+
+	c.Assert(func() { d.Channel(8) }, qt.PanicMatches, "cd74hc4067: invalid channel")
+	c.Assert(func() { d.Select(-1) }, qt.PanicMatches, "cd74hc4067: invalid channel")
+}
+
+func TestNoEnable(t *testing.T) {
+	c := qt.New(t)
+	d := New(&fakeADC{}, nil, &tester.Pin{}, &tester.Pin{})
+	d.Configure(Config{})
+	d.SetEnabled(false)
+	c.Assert(d.Channels(), qt.Equals, 4)
+}
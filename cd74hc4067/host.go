@@ -0,0 +1,10 @@
+// +build !tinygo
+
+package cd74hc4067
+
+import "tinygo.org/x/drivers"
+
+// The select pins of the host tests record their level without a mode to
+// set.
+
+func configureOutput(pin drivers.Pin) {}
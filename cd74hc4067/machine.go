@@ -0,0 +1,16 @@
+// +build tinygo
+
+package cd74hc4067
+
+import (
+	"machine"
+
+	"tinygo.org/x/drivers"
+)
+
+// configureOutput makes a select or enable pin an output.
+func configureOutput(pin drivers.Pin) {
+	if pin, ok := pin.(machine.Pin); ok {
+		pin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	}
+}
@@ -0,0 +1,275 @@
+// Package datalogger samples sensors at a regular interval and writes the
+// records to any io.Writer, such as a UART, a file on an SD card or a flash
+// chip, either as CSV or in a compact binary framing.
+//
+// The sensors are added as columns, through the interfaces of the drivers
+// package, and the logger is run by a scheduler.Scheduler with the other
+// tasks of the program:
+//
+//	log := datalogger.New(uart, datalogger.Config{Interval: time.Minute})
+//	log.AddThermometer("temperature", bme)
+//	log.AddHygrometer("humidity", bme)
+//	log.AddBarometer("pressure", bme)
+//
+//	s := scheduler.New()
+//	s.Add(log.Task())
+//	s.Run()
+//
+// The values are written in the units of the interfaces: milli-degrees
+// Celsius, hundredths of a percent, milli-pascals and micro-gravity.
+package datalogger // import "tinygo.org/x/drivers/datalogger"
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/internal/crc8"
+	"tinygo.org/x/drivers/scheduler"
+)
+
+// ErrRead is returned by Sample when one of the sensors could not be read.
+// The record is written anyway, without the values that could not be read.
+var ErrRead = errors.New("datalogger: a sensor could not be read")
+
+// Format is the encoding of the records.
+type Format uint8
+
+// Formats of the records.
+const (
+	// CSV writes a header line with the names of the columns, then a line
+	// per record: the time in milliseconds and the values, empty when the
+	// sensor could not be read.
+	CSV Format = iota
+
+	// Binary writes a frame per record: the sync byte 0xA5, the length of
+	// the rest of the frame, the time in milliseconds as a little endian
+	// uint32, a bitmap of the values that could be read, the values as
+	// little endian int32, and a CRC-8 of the frame after the length, with
+	// the polynomial x^8 + x^2 + x + 1. A frame holds at most 60 columns.
+	Binary
+)
+
+// Sync is the first byte of the binary frames.
+const Sync = 0xA5
+
+// Config holds the settings of a logger.
+type Config struct {
+	Format Format
+
+	// Interval is the time between two records, 1 minute if not set.
+	Interval time.Duration
+
+	// BufferSize is the number of bytes kept before they are written, to
+	// write to a flash chip or an SD card by blocks. With 0 each record is
+	// written at once.
+	BufferSize int
+
+	// FlushRecords, when set, flushes the buffer every FlushRecords
+	// records, so that no more records are lost at a power failure. The
+	// buffer is otherwise flushed when it is full and by Flush.
+	FlushRecords int
+}
+
+// column is a value of the records.
+type column struct {
+	name string
+	read func() (int32, error)
+}
+
+// Logger writes records of the values of sensors.
+type Logger struct {
+	w       io.Writer
+	config  Config
+	columns []column
+	start   time.Time
+
+	buf     []byte
+	record  []byte
+	values  []int32
+	valid   []bool
+	pending int
+	started bool
+
+	now func() time.Time
+}
+
+// New returns a logger writing to w.
+func New(w io.Writer, config Config) *Logger {
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	return &Logger{
+		w:      w,
+		config: config,
+		start:  time.Now(),
+		buf:    make([]byte, 0, config.BufferSize),
+		now:    time.Now,
+	}
+}
+
+// Add adds a column whose value is returned by read. The columns must be
+// added before the first record.
+func (l *Logger) Add(name string, read func() (int32, error)) {
+	l.columns = append(l.columns, column{name: name, read: read})
+}
+
+// AddThermometer adds a column with the temperature of a sensor.
+func (l *Logger) AddThermometer(name string, t drivers.Thermometer) {
+	l.Add(name, t.ReadTemperature)
+}
+
+// AddHygrometer adds a column with the humidity of a sensor.
+func (l *Logger) AddHygrometer(name string, h drivers.Hygrometer) {
+	l.Add(name, h.ReadHumidity)
+}
+
+// AddBarometer adds a column with the pressure of a sensor.
+func (l *Logger) AddBarometer(name string, b drivers.Barometer) {
+	l.Add(name, b.ReadPressure)
+}
+
+// AddAccelerometer adds three columns with the acceleration of a sensor on
+// each axis, whose names end with _x, _y and _z. The sensor is read once per
+// record.
+func (l *Logger) AddAccelerometer(name string, a drivers.Accelerometer) {
+	var y, z int32
+	var err error
+	l.Add(name+"_x", func() (int32, error) {
+		var x int32
+		x, y, z, err = a.ReadAcceleration()
+		return x, err
+	})
+	l.Add(name+"_y", func() (int32, error) { return y, err })
+	l.Add(name+"_z", func() (int32, error) { return z, err })
+}
+
+// Task returns a task that samples the sensors at the interval of the
+// configuration.
+func (l *Logger) Task() scheduler.Task {
+	return scheduler.Task{Name: "datalogger", Poll: l.Sample, Interval: l.config.Interval}
+}
+
+// Sample reads the sensors and writes a record. It returns ErrRead when a
+// sensor could not be read, or the error of the writer.
+func (l *Logger) Sample() error {
+	if !l.started {
+		l.started = true
+		l.values = make([]int32, len(l.columns))
+		l.valid = make([]bool, len(l.columns))
+		if l.config.Format == CSV {
+			l.record = l.header(l.record[:0])
+			if err := l.write(l.record); err != nil {
+				return err
+			}
+		}
+	}
+	ms := uint32(l.now().Sub(l.start) / time.Millisecond)
+	var readErr error
+	for i, c := range l.columns {
+		v, err := c.read()
+		l.values[i], l.valid[i] = v, err == nil
+		if err != nil {
+			readErr = ErrRead
+		}
+	}
+	if l.config.Format == CSV {
+		l.record = l.csv(l.record[:0], ms)
+	} else {
+		l.record = l.frame(l.record[:0], ms)
+	}
+	if err := l.write(l.record); err != nil {
+		return err
+	}
+	l.pending++
+	if l.config.FlushRecords > 0 && l.pending >= l.config.FlushRecords {
+		if err := l.Flush(); err != nil {
+			return err
+		}
+	}
+	return readErr
+}
+
+// Flush writes the buffered records, then flushes the writer if it has a
+// Flush or a Sync method, as bufio.Writer and os.File do.
+func (l *Logger) Flush() error {
+	l.pending = 0
+	if len(l.buf) > 0 {
+		_, err := l.w.Write(l.buf)
+		l.buf = l.buf[:0]
+		if err != nil {
+			return err
+		}
+	}
+	switch w := l.w.(type) {
+	case interface{ Flush() error }:
+		return w.Flush()
+	case interface{ Sync() error }:
+		return w.Sync()
+	}
+	return nil
+}
+
+// write buffers b, and writes the buffer when b does not fit.
+func (l *Logger) write(b []byte) error {
+	if len(l.buf)+len(b) > cap(l.buf) {
+		if len(l.buf) > 0 {
+			_, err := l.w.Write(l.buf)
+			l.buf = l.buf[:0]
+			if err != nil {
+				return err
+			}
+		}
+		if len(b) > cap(l.buf) {
+			_, err := l.w.Write(b)
+			return err
+		}
+	}
+	l.buf = append(l.buf, b...)
+	return nil
+}
+
+func (l *Logger) header(b []byte) []byte {
+	b = append(b, "time"...)
+	for _, c := range l.columns {
+		b = append(b, ',')
+		b = append(b, c.name...)
+	}
+	return append(b, '\n')
+}
+
+func (l *Logger) csv(b []byte, ms uint32) []byte {
+	b = strconv.AppendUint(b, uint64(ms), 10)
+	for i, v := range l.values {
+		b = append(b, ',')
+		if l.valid[i] {
+			b = strconv.AppendInt(b, int64(v), 10)
+		}
+	}
+	return append(b, '\n')
+}
+
+func (l *Logger) frame(b []byte, ms uint32) []byte {
+	b = append(b, Sync, 0)
+	b = appendUint32(b, ms)
+	for i := 0; i < len(l.valid); i += 8 {
+		var bits byte
+		for j := 0; j < 8 && i+j < len(l.valid); j++ {
+			if l.valid[i+j] {
+				bits |= 1 << uint(j)
+			}
+		}
+		b = append(b, bits)
+	}
+	for _, v := range l.values {
+		b = appendUint32(b, uint32(v))
+	}
+	b[1] = byte(len(b) - 1)
+	return append(b, crc8.Update(0, crc8.PolySMBus, b[2:]...))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
@@ -0,0 +1,124 @@
+package datalogger
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers/internal/crc8"
+)
+
+type sensor struct {
+	temperature int32
+	err         error
+}
+
+func (s *sensor) ReadTemperature() (int32, error) { return s.temperature, s.err }
+func (s *sensor) ReadHumidity() (int32, error)    { return 4520, s.err }
+func (s *sensor) ReadPressure() (int32, error)    { return 101325000, nil }
+
+func (s *sensor) ReadAcceleration() (x, y, z int32, err error) {
+	return 1000, -2000, 1000000, nil
+}
+
+// writer records the writes and the flushes.
+type writer struct {
+	bytes.Buffer
+	writes, flushes int
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(b)
+}
+
+func (w *writer) Flush() error {
+	w.flushes++
+	return nil
+}
+
+func newLogger(w *writer, config Config) (*Logger, *time.Time) {
+	l := New(w, config)
+	now := l.start
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestCSV(t *testing.T) {
+	c := qt.New(t)
+	w := &writer{}
+	l, now := newLogger(w, Config{})
+	s := &sensor{temperature: 21375}
+	l.AddThermometer("temperature", s)
+	l.AddHygrometer("humidity", s)
+	l.AddBarometer("pressure", s)
+	l.AddAccelerometer("accel", s)
+
+	c.Assert(l.Sample(), qt.IsNil)
+	*now = now.Add(1500 * time.Millisecond)
+	s.err = errors.New("no answer")
+	c.Assert(l.Sample(), qt.Equals, ErrRead)
+	c.Assert(w.String(), qt.Equals, "time,temperature,humidity,pressure,accel_x,accel_y,accel_z\n"+
+		"0,21375,4520,101325000,1000,-2000,1000000\n"+
+		"1500,,,101325000,1000,-2000,1000000\n")
+}
+
+func TestBinary(t *testing.T) {
+	c := qt.New(t)
+	w := &writer{}
+	l, now := newLogger(w, Config{Format: Binary})
+	s := &sensor{temperature: -1500, err: errors.New("no answer")}
+	l.Add("temperature", func() (int32, error) { return s.temperature, nil })
+	l.AddHygrometer("humidity", s)
+	*now = now.Add(258 * time.Millisecond)
+
+	c.Assert(l.Sample(), qt.Equals, ErrRead)
+	frame := w.Bytes()
+	c.Assert(frame[:len(frame)-1], qt.DeepEquals, []byte{
+		Sync, 14,
+		2, 1, 0, 0, // time
+		0x01,                   // only the temperature is valid
+		0x24, 0xFA, 0xFF, 0xFF, // -1500
+		0xA8, 0x11, 0, 0, // 4520
+	})
+	c.Assert(crc8.Update(0, crc8.PolySMBus, frame[2:]...), qt.Equals, byte(0))
+}
+
+func TestBuffering(t *testing.T) {
+	c := qt.New(t)
+	w := &writer{}
+	l, _ := newLogger(w, Config{BufferSize: 64, FlushRecords: 4})
+	l.Add("value", func() (int32, error) { return 1234567, nil })
+
+	// the header and the records of 10 bytes are kept until the buffer is
+	// full
+	for i := 0; i < 3; i++ {
+		c.Assert(l.Sample(), qt.IsNil)
+	}
+	c.Assert(w.writes, qt.Equals, 0)
+	c.Assert(l.Sample(), qt.IsNil)
+	c.Assert(w.writes, qt.Equals, 1)
+	c.Assert(w.flushes, qt.Equals, 1)
+	c.Assert(w.String(), qt.Equals, "time,value\n0,1234567\n0,1234567\n0,1234567\n0,1234567\n")
+
+	l.config.FlushRecords = 0
+	for i := 0; i < 7; i++ {
+		c.Assert(l.Sample(), qt.IsNil)
+	}
+	c.Assert(w.writes, qt.Equals, 2)
+	c.Assert(l.Flush(), qt.IsNil)
+	c.Assert(w.writes, qt.Equals, 3)
+	c.Assert(w.flushes, qt.Equals, 2)
+	c.Assert(bytes.Count(w.Bytes(), []byte("\n")), qt.Equals, 12)
+}
+
+func TestTask(t *testing.T) {
+	c := qt.New(t)
+	l := New(&writer{}, Config{})
+	task := l.Task()
+	c.Assert(task.Interval, qt.Equals, time.Minute)
+	c.Assert(task.Poll(), qt.IsNil)
+}
@@ -0,0 +1,105 @@
+// +build tinygo
+
+package dht
+
+import (
+	"machine"
+	"strconv"
+	"time"
+)
+
+// ArrayError is returned by Array.ReadAll when some of the sensors failed.
+type ArrayError struct {
+	// Errors holds the error of each sensor, nil for the sensors that were
+	// read successfully.
+	Errors []error
+}
+
+func (e *ArrayError) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e.Errors {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return "dht: " + strconv.Itoa(failed) + " of " + strconv.Itoa(len(e.Errors)) + " sensors failed: " + first.Error()
+}
+
+// Array is a group of sensors of the same type, each on its own pin. It
+// makes sure that a sensor is not read again before its minimum interval
+// between measurements has passed.
+type Array struct {
+	sensors []Device
+	last    []time.Time
+	errs    []error
+	next    int
+}
+
+// NewArray returns a group of sensors connected to the pins.
+func NewArray(deviceType DeviceType, pins ...machine.Pin) *Array {
+	a := &Array{
+		sensors: make([]Device, len(pins)),
+		last:    make([]time.Time, len(pins)),
+		errs:    make([]error, len(pins)),
+	}
+	for i, p := range pins {
+		a.sensors[i] = New(p, deviceType)
+	}
+	return a
+}
+
+// Len returns the number of sensors.
+func (a *Array) Len() int {
+	return len(a.sensors)
+}
+
+// Sensor returns a sensor of the group, to access its last measurements.
+// Calling its ReadMeasurements directly bypasses the interval between
+// measurements.
+func (a *Array) Sensor(i int) Device {
+	return a.sensors[i]
+}
+
+// ReadAll reads every sensor, waiting when needed for its minimum interval
+// since the previous read. It returns an *ArrayError when some of the
+// sensors failed; the measurements of the others are updated.
+func (a *Array) ReadAll() error {
+	failed := false
+	for i := range a.sensors {
+		if wait := minInterval - time.Since(a.last[i]); wait > 0 {
+			time.Sleep(wait)
+		}
+		a.errs[i] = a.read(i)
+		if a.errs[i] != nil {
+			failed = true
+		}
+	}
+	if failed {
+		return &ArrayError{Errors: append([]error(nil), a.errs...)}
+	}
+	return nil
+}
+
+// ReadNext reads the sensors one after the other, one per call, so that the
+// reads are staggered and the other work of the program is never blocked
+// for long. It returns the index of the sensor that was read, or -1 when
+// the next sensor was read too recently, in which case the caller should
+// try again later.
+func (a *Array) ReadNext() (int, error) {
+	if len(a.sensors) == 0 || time.Since(a.last[a.next]) < minInterval {
+		return -1, nil
+	}
+	i := a.next
+	a.next = (a.next + 1) % len(a.sensors)
+	return i, a.read(i)
+}
+
+func (a *Array) read(i int) error {
+	err := a.sensors[i].ReadMeasurements()
+	a.last[i] = time.Now()
+	return err
+}
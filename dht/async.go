@@ -0,0 +1,41 @@
+package dht
+
+import "tinygo.org/x/drivers/units"
+
+// Measurements is the result of a measurement started by StartMeasurement.
+type Measurements struct {
+	Temperature int16
+	Humidity    uint16
+	Err         error
+}
+
+// MilliCelsius returns the temperature, which is in tenths of a degree
+// Celsius in the Temperature field.
+func (m Measurements) MilliCelsius() units.MilliCelsius {
+	return units.MilliCelsius(m.Temperature) * 100
+}
+
+// MilliRH returns the relative humidity, which is in tenths of a percent in
+// the Humidity field.
+func (m Measurements) MilliRH() units.MilliRH {
+	return units.MilliRH(m.Humidity) * 100
+}
+
+// StartMeasurement runs ReadMeasurements in a new goroutine and returns a
+// channel that receives its result, so that the caller can do other work
+// during the conversion. Only the reception of the bits keeps the CPU busy
+// for a few milliseconds, unless the device was created with NewInterrupt
+// or NewI2C, which wait without blocking other goroutines. A new
+// measurement started before the previous one completes runs after it.
+func StartMeasurement(d Device) <-chan Measurements {
+	result := make(chan Measurements, 1)
+	go func() {
+		err := d.ReadMeasurements()
+		result <- Measurements{
+			Temperature: d.Temperature(),
+			Humidity:    d.Humidity(),
+			Err:         err,
+		}
+	}()
+	return result
+}
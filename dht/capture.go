@@ -0,0 +1,38 @@
+package dht
+
+import (
+	"strconv"
+
+	"tinygo.org/x/drivers/capture"
+)
+
+var deviceNames = [...]string{
+	DHT11:               "DHT11",
+	DHT22:               "DHT22",
+	DHT12:               "DHT12",
+	AM2320:              "AM2320",
+	DHT11Extended:       "DHT11Extended",
+	DHT22SignBit:        "DHT22SignBit",
+	DHT22TwosComplement: "DHT22TwosComplement",
+}
+
+func (d DeviceType) String() string {
+	if int(d) < len(deviceNames) && deviceNames[d] != "" {
+		return deviceNames[d]
+	}
+	return "DeviceType(" + strconv.Itoa(int(d)) + ")"
+}
+
+// NewCapture returns the data received by the last read of the sensor, even
+// if it failed, to attach it to a bug report: the frame, and for the
+// single-wire protocol the widths of the low and high pulses of the 40 bits,
+// as returned by LastTimings. The tests of this package replay such
+// captures, stored in the testdata directory.
+func NewCapture(d Device, deviceType DeviceType) capture.Capture {
+	return capture.Capture{
+		Driver: "dht",
+		Params: map[string]string{"device": deviceType.String()},
+		Bytes:  d.LastRawFrame(),
+		Widths: d.LastTimings(),
+	}
+}
@@ -3,14 +3,35 @@ package dht
 import (
 	"encoding/binary"
 	"errors"
-	"machine"
 	"time"
 )
 
 type DeviceType uint8
 
 func (d DeviceType) extractData(buf []byte) (temp int16, hum uint16) {
-	if d == DHT11 {
+	switch d {
+	case DHT12:
+		temp = 10*int16(buf[2]) + int16(buf[3]&0x7f)
+		if buf[3]&0x80 > 0 {
+			temp = -temp
+		}
+		hum = 10*uint16(buf[0]) + uint16(buf[1])
+	case DHT11Extended:
+		hum = 10*uint16(buf[0]) + uint16(buf[1])
+		temp = 10*int16(buf[2]) + int16(buf[3]&0x0f)
+		if buf[3]&0x80 > 0 {
+			temp = -temp
+		}
+	case DHT22TwosComplement:
+		hum = binary.BigEndian.Uint16(buf[0:2])
+		temp = int16(binary.BigEndian.Uint16(buf[2:4]))
+	case AM2320, DHT22SignBit:
+		hum = binary.BigEndian.Uint16(buf[0:2])
+		temp = int16(binary.BigEndian.Uint16(buf[2:4]) & 0x7fff)
+		if buf[2]&0x80 > 0 {
+			temp = -temp
+		}
+	case DHT11:
 		temp = int16(buf[2])
 		if buf[3]&0x80 > 0 {
 			temp = -1 - temp
@@ -18,7 +39,7 @@ func (d DeviceType) extractData(buf []byte) (temp int16, hum uint16) {
 		temp *= 10
 		temp += int16(buf[3] & 0x0f)
 		hum = 10*uint16(buf[0]) + uint16(buf[1])
-	} else {
+	default:
 		hum = binary.LittleEndian.Uint16(buf[0:2])
 		temp = int16(buf[3])<<8 + int16(buf[2]&0x7f)
 		if buf[2]&0x80 > 0 {
@@ -28,42 +49,192 @@ func (d DeviceType) extractData(buf []byte) (temp int16, hum uint16) {
 	return
 }
 
+// Timings are the durations of the start of a measurement on the
+// single-wire bus.
+type Timings struct {
+	// StartLow is how long the host pulls the line low to start a
+	// measurement.
+	StartLow time.Duration
+
+	// StartTimeout is how long the line is released before the start
+	// signal when it was left low, for the sensor to wake up.
+	StartTimeout time.Duration
+}
+
+// Timings returns the timings given by the datasheet of the sensor: a start
+// signal of at least 18ms for the DHT11 and the DHT12, and of 1 to 10ms for
+// the DHT22 and the AM2320.
+func (d DeviceType) Timings() Timings {
+	switch d {
+	case DHT11, DHT11Extended, DHT12:
+		return Timings{StartLow: 20 * time.Millisecond, StartTimeout: 200 * time.Millisecond}
+	default:
+		return Timings{StartLow: 1100 * time.Microsecond, StartTimeout: 200 * time.Millisecond}
+	}
+}
+
+// Options sets up a sensor when it is created. The zero value gives the
+// same sensor as New, and new fields keep this property, so that the
+// constructors taking Options do not change when features are added.
+type Options struct {
+	// StartLow and StartTimeout override the timings of the DeviceType, to
+	// tune a clone that does not follow the datasheet. They are ignored by
+	// the I2C sensors.
+	StartLow     time.Duration
+	StartTimeout time.Duration
+
+	// Calibration is the correction set with SetCalibration.
+	Calibration Calibration
+
+	// Policy, when set, makes the constructor return the sensor wrapped by
+	// WithPolicy, such as &UpdatePolicy{MaxRetries: 3} for retries.
+	Policy *UpdatePolicy
+}
+
+// Option changes the Options of a sensor, for the constructors taking a list
+// of options such as New.
+type Option func(*Options)
+
+// WithCalibration sets the correction of the measurements.
+func WithCalibration(c Calibration) Option {
+	return func(o *Options) {
+		o.Calibration = c
+	}
+}
+
+// WithUpdatePolicy makes the constructor return the sensor wrapped by
+// WithPolicy with the policy.
+func WithUpdatePolicy(policy UpdatePolicy) Option {
+	return func(o *Options) {
+		o.Policy = &policy
+	}
+}
+
+// WithRetries makes the constructor return the sensor wrapped by WithPolicy,
+// retrying a failed read n times. It keeps the other settings of a policy
+// given by WithUpdatePolicy.
+func WithRetries(n int) Option {
+	return func(o *Options) {
+		policy := UpdatePolicy{}
+		if o.Policy != nil {
+			policy = *o.Policy
+		}
+		policy.MaxRetries = n
+		o.Policy = &policy
+	}
+}
+
+// WithTimings overrides the start signal timings of the DeviceType.
+func WithTimings(startLow, startTimeout time.Duration) Option {
+	return func(o *Options) {
+		o.StartLow = startLow
+		o.StartTimeout = startTimeout
+	}
+}
+
+// with returns the options changed by the list of options.
+func (o Options) with(opts []Option) Options {
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// apply sets up a new sensor with the options.
+func (o Options) apply(d Device) Device {
+	d.SetCalibration(o.Calibration)
+	if o.Policy != nil {
+		return WithPolicy(d, *o.Policy)
+	}
+	return d
+}
+
+// timings returns the timings of the device type with the overrides.
+func (o Options) timings(deviceType DeviceType) Timings {
+	t := deviceType.Timings()
+	if o.StartLow != 0 {
+		t.StartLow = o.StartLow
+	}
+	if o.StartTimeout != 0 {
+		t.StartTimeout = o.StartTimeout
+	}
+	return t
+}
+
 type TemperatureScale uint8
 
 func (t TemperatureScale) convertToFloat(temp int16) float32 {
-	if t == C {
+	switch t {
+	case C:
 		return float32(temp) / 10
-	} else {
+	case K:
+		return float32(temp)/10 + 273.15
+	default:
 		// Fahrenheit
 		return float32(temp)*(9.0/50.) + 32.
 	}
 }
 
+// convertToMilli converts a temperature in tenths of a degree Celsius to
+// milli-degrees of the scale, with integer arithmetic only.
+func (t TemperatureScale) convertToMilli(temp int16) int32 {
+	switch t {
+	case C:
+		return int32(temp) * 100
+	case K:
+		return int32(temp)*100 + 273150
+	default:
+		// Fahrenheit
+		return int32(temp)*180 + 32000
+	}
+}
+
 const (
-	startTimeout = time.Millisecond * 200
-	startingLow  = time.Millisecond * 20
+	pulseTimeout = time.Microsecond * 200
 
-	DHT11 DeviceType = iota
+	// minInterval is the minimum time between two measurements of a
+	// sensor. The DHT11 allows one per second, the other sensors one every
+	// two seconds.
+	minInterval = time.Second * 2
+)
+
+// The values of the device types and of the temperature scales are those of
+// the first releases of the package, where they followed two other
+// constants, so that stored values keep their meaning.
+const (
+	DHT11 DeviceType = iota + 2
 	DHT22
+	// DHT12 and AM2320 speak both the single-wire protocol and I2C, see
+	// NewI2C.
+	DHT12
+	AM2320
 
-	C TemperatureScale = iota
+	// Decodings of clones and revisions that send the data differently.
+	// DHT11Extended sends the decimals of the humidity in byte 1 and those
+	// of the temperature in byte 3, whose top bit is the sign.
+	// DHT22SignBit follows the datasheet of the DHT22, with big endian
+	// values and the sign in the top bit of the temperature, and
+	// DHT22TwosComplement sends negative temperatures in two's complement.
+	DHT11Extended
+	DHT22SignBit
+	DHT22TwosComplement
+)
+
+const (
+	C TemperatureScale = iota + 4
 	F
+	K
 )
 
 var (
-	timeout uint16
+	// The causes of the failed reads. The errors returned by the devices
+	// are of type *Error and wrap them, so they can be checked with
+	// errors.Is.
+	ErrChecksum = errors.New("checksum mismatch")
+	ErrNoSignal = errors.New("no signal")
+	ErrNoData   = errors.New("no data")
 
-	checksumError = errors.New("checksum mismatch")
-	noSignalError = errors.New("no signal")
-	noDataError   = errors.New("no data")
+	// ErrDeadlineExceeded is returned by ReadMeasurementsWithTimeout when
+	// the read did not complete in time.
+	ErrDeadlineExceeded = errors.New("deadline exceeded")
 )
-
-func init() {
-	timeout = cyclesPerMillisecond()
-}
-
-func cyclesPerMillisecond() uint16 {
-	freq := machine.CPUFrequency()
-	freq /= 1000
-	return uint16(freq)
-}
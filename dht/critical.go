@@ -0,0 +1,14 @@
+package dht
+
+// CriticalSection protects the reception of the bits by the devices that
+// time them with a busy loop, during about 5ms, from the interrupts that
+// would make the pulses look longer than they are.
+type CriticalSection interface {
+	Enter()
+	Exit()
+}
+
+// Critical is the critical section used by all devices. By default it
+// disables all interrupts. It can be replaced, for example to only disable
+// the interrupts of a busy peripheral, before the first read.
+var Critical CriticalSection = defaultCritical()
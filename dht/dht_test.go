@@ -0,0 +1,522 @@
+package dht
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/capture"
+	"tinygo.org/x/drivers/units"
+)
+
+// replayPin plays back the signal of a sensor, given as the widths in
+// microseconds of alternating low and high levels from the release of the
+// line by the host. Each call of Get takes one microsecond. After the last
+// width the line stays high.
+type replayPin struct {
+	widths []uint16
+	input  bool
+	level  bool
+	clock  int
+}
+
+func (p *replayPin) SetOutput() { p.input = false }
+func (p *replayPin) SetInput()  { p.input, p.clock = true, 0 }
+
+func (p *replayPin) Set(high bool) { p.level = high }
+func (p *replayPin) High()         { p.level = true }
+func (p *replayPin) Low()          { p.level = false }
+
+func (p *replayPin) Get() bool {
+	if !p.input {
+		return p.level
+	}
+	p.clock++
+	// the line is pulled up for 20µs before the sensor answers
+	t := p.clock - 20
+	if t < 0 {
+		return true
+	}
+	low := true
+	for _, w := range p.widths {
+		if t < int(w) {
+			return !low
+		}
+		t -= int(w)
+		low = !low
+	}
+	return true
+}
+
+// signal returns the widths of the answer of a sensor sending the frame.
+func signal(frame []byte) []uint16 {
+	widths := []uint16{80, 80}
+	for _, b := range frame {
+		for i := 7; i >= 0; i-- {
+			high := uint16(26)
+			if b&(1<<uint(i)) != 0 {
+				high = 70
+			}
+			widths = append(widths, 50, high)
+		}
+	}
+	return append(widths, 50)
+}
+
+func TestRead(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		widths      []uint16
+		err         error
+		stage       Stage
+		temperature int16
+		humidity    uint16
+	}{{
+		name:        "valid",
+		widths:      signal([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEE}),
+		temperature: 351,
+		humidity:    652,
+	}, {
+		name:        "negative",
+		widths:      signal([]byte{0x02, 0x8C, 0x80, 0x65, 0x73}),
+		temperature: -101,
+		humidity:    652,
+	}, {
+		name:   "checksum",
+		widths: signal([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEF}),
+		err:    ErrChecksum,
+		stage:  StageChecksum,
+	}, {
+		name:   "no signal",
+		widths: nil,
+		err:    ErrNoSignal,
+		stage:  StageHandshake,
+	}, {
+		name:   "truncated",
+		widths: signal([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEE})[:42],
+		err:    ErrNoData,
+		stage:  StageData,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := qt.New(t)
+			p := &replayPin{widths: tc.widths, level: true}
+			d := NewPin(p, DHT22SignBit, Options{})
+			err := d.ReadMeasurements()
+			if tc.err != nil {
+				c.Assert(errors.Is(err, tc.err), qt.IsTrue, qt.Commentf("error: %v", err))
+				var e *Error
+				c.Assert(errors.As(err, &e), qt.IsTrue)
+				c.Assert(e.Stage, qt.Equals, tc.stage)
+				c.Assert(e.Attempt, qt.Equals, 1)
+				c.Assert(d.Stats().Reads, qt.Equals, uint32(0))
+				return
+			}
+			c.Assert(err, qt.IsNil)
+			c.Assert(d.Temperature(), qt.Equals, tc.temperature)
+			c.Assert(d.Humidity(), qt.Equals, tc.humidity)
+			c.Assert(d.Stats().Reads, qt.Equals, uint32(1))
+			timings := d.LastTimings()
+			c.Assert(timings, qt.HasLen, 80)
+			for i, w := range tc.widths[2:82] {
+				// the count of the loop is off by one at each edge
+				c.Assert(int(timings[i])-int(w) <= 1 && int(w)-int(timings[i]) <= 1, qt.IsTrue, qt.Commentf("pulse %d: %d", i, timings[i]))
+			}
+		})
+	}
+}
+
+// slowPin is a replayPin whose input is sampled slowly, so that the answer
+// of the sensor lasts longer than a read timeout.
+type slowPin struct {
+	replayPin
+}
+
+func (p *slowPin) Get() bool {
+	if p.input {
+		time.Sleep(10 * time.Microsecond)
+	}
+	return p.replayPin.Get()
+}
+
+func TestReadTimeout(t *testing.T) {
+	c := qt.New(t)
+	p := &slowPin{replayPin{widths: signal([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEE}), level: true}}
+	d := NewPin(p, DHT22SignBit, Options{})
+	// the deadline passes while the bits are received
+	err := d.ReadMeasurementsWithTimeout(5 * time.Millisecond)
+	c.Assert(errors.Is(err, ErrDeadlineExceeded), qt.IsTrue, qt.Commentf("error: %v", err))
+}
+
+func TestTimeoutPerDevice(t *testing.T) {
+	c := qt.New(t)
+	widths := signal([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEE})
+	short := NewPin(&replayPin{widths: widths, level: true}, DHT22SignBit, Options{})
+	d := NewPin(&replayPin{widths: widths, level: true}, DHT22SignBit, Options{})
+	// a loop count shorter than the pulses of one sensor does not change
+	// the other
+	short.(*device).timeout = 60
+	short.(*device).calibrated = true
+	c.Assert(errors.Is(short.ReadMeasurements(), ErrNoSignal), qt.IsTrue)
+	c.Assert(d.ReadMeasurements(), qt.IsNil)
+}
+
+func TestExtractData(t *testing.T) {
+	c := qt.New(t)
+	for _, tc := range []struct {
+		deviceType  DeviceType
+		frame       []byte
+		temperature int16
+		humidity    uint16
+	}{
+		{DHT11Extended, []byte{45, 5, 23, 7}, 237, 455},
+		{DHT11Extended, []byte{45, 5, 3, 0x82}, -32, 455},
+		{DHT12, []byte{55, 3, 22, 0x85}, -225, 553},
+		{DHT22SignBit, []byte{0x01, 0xF4, 0x80, 0x65}, -101, 500},
+		{DHT22TwosComplement, []byte{0x01, 0xF4, 0xFF, 0x9B}, -101, 500},
+		{AM2320, []byte{0x01, 0xF4, 0x00, 0xFA}, 250, 500},
+	} {
+		temperature, humidity := tc.deviceType.extractData(tc.frame)
+		c.Assert(temperature, qt.Equals, tc.temperature, qt.Commentf("%v", tc))
+		c.Assert(humidity, qt.Equals, tc.humidity, qt.Commentf("%v", tc))
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(isValid([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEE}), qt.IsTrue)
+	c.Assert(isValid([]byte{0x02, 0x8C, 0x01, 0x5F, 0xED}), qt.IsFalse)
+	// the sum is truncated to a byte
+	c.Assert(isValid([]byte{0xFF, 0xFF, 0x01, 0x01, 0x00}), qt.IsTrue)
+	c.Assert(crc16([]byte("123456789")), qt.Equals, uint16(0x4B37))
+}
+
+// sequenceDevice returns a measurement of the list at each read, or fails
+// the read when the list is exhausted.
+type sequenceDevice struct {
+	readings
+	measurements []Measurements
+}
+
+func (d *sequenceDevice) ReadMeasurements() error {
+	return d.ReadMeasurementsWithTimeout(0)
+}
+
+func (d *sequenceDevice) ReadMeasurementsWithTimeout(timeout time.Duration) error {
+	if len(d.measurements) == 0 {
+		return wrapError(ErrNoSignal)
+	}
+	m := d.measurements[0]
+	d.measurements = d.measurements[1:]
+	d.set(m.Temperature, m.Humidity)
+	return nil
+}
+
+func (d *sequenceDevice) ReadTemperature() (int32, error) {
+	return readTemperature(d)
+}
+
+func (d *sequenceDevice) ReadHumidity() (int32, error) {
+	return readHumidity(d)
+}
+
+func TestMedian(t *testing.T) {
+	c := qt.New(t)
+	d := &sequenceDevice{measurements: []Measurements{
+		{Temperature: 215, Humidity: 480},
+		{Temperature: 1650, Humidity: 482},
+		{Temperature: 216, Humidity: 0},
+	}}
+	m := WithPolicy(d, UpdatePolicy{Median: 3, RetryDelay: time.Nanosecond})
+	c.Assert(m.ReadMeasurements(), qt.IsNil)
+	c.Assert(m.Temperature(), qt.Equals, int16(216))
+	c.Assert(m.Humidity(), qt.Equals, uint16(480))
+	// the list is exhausted
+	c.Assert(errors.Is(m.ReadMeasurements(), ErrNoSignal), qt.IsTrue)
+	c.Assert(median([]int32{4, 1, 3, 2}), qt.Equals, int32(2))
+}
+
+func TestTimings(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(DHT11.Timings().StartLow, qt.Equals, 20*time.Millisecond)
+	c.Assert(DHT22.Timings().StartLow, qt.Equals, 1100*time.Microsecond)
+	timings := Options{StartLow: 5 * time.Millisecond}.timings(DHT22)
+	c.Assert(timings, qt.Equals, Timings{StartLow: 5 * time.Millisecond, StartTimeout: 200 * time.Millisecond})
+}
+
+func TestSensorInterfaces(t *testing.T) {
+	c := qt.New(t)
+	d := &sequenceDevice{measurements: []Measurements{
+		{Temperature: -15, Humidity: 482},
+		{Temperature: 216, Humidity: 480},
+	}}
+	var thermometer drivers.Thermometer = WithPolicy(d, UpdatePolicy{})
+	temperature, err := thermometer.ReadTemperature()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temperature, qt.Equals, int32(-1500))
+	var hygrometer drivers.Hygrometer = d
+	humidity, err := hygrometer.ReadHumidity()
+	c.Assert(err, qt.IsNil)
+	c.Assert(humidity, qt.Equals, int32(4800))
+}
+
+func TestUnits(t *testing.T) {
+	c := qt.New(t)
+	d := &sequenceDevice{measurements: []Measurements{
+		{Temperature: 216, Humidity: 482},
+	}}
+	temperature, humidity, err := ReadUnits(d)
+	c.Assert(err, qt.IsNil)
+	c.Assert(temperature.String(), qt.Equals, "21.6°C")
+	c.Assert(humidity.String(), qt.Equals, "48.2%RH")
+
+	m := Measurements{Temperature: -15, Humidity: 480}
+	c.Assert(m.MilliCelsius(), qt.Equals, units.MilliCelsius(-1500))
+	c.Assert(m.MilliRH(), qt.Equals, units.MilliRH(48000))
+}
+
+func TestPollTask(t *testing.T) {
+	c := qt.New(t)
+	d := &sequenceDevice{measurements: []Measurements{{Temperature: 216, Humidity: 482}}}
+	task := PollTask(d)
+	c.Assert(task.Interval, qt.Equals, 2*time.Second)
+	c.Assert(task.Poll(), qt.IsNil)
+	c.Assert(d.Temperature(), qt.Equals, int16(216))
+}
+
+func TestTrace(t *testing.T) {
+	c := qt.New(t)
+	var events []string
+	var args [][]int
+	drivers.Trace = func(level, driver, event string, a ...int) {
+		c.Assert(driver, qt.Equals, "dht")
+		events = append(events, level+" "+event)
+		args = append(args, a)
+	}
+	defer func() { drivers.Trace = nil }()
+
+	p := &replayPin{widths: signal([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEE}), level: true}
+	d := NewPin(p, DHT22SignBit, Options{})
+	c.Assert(d.ReadMeasurements(), qt.IsNil)
+	p.widths = nil
+	c.Assert(d.ReadMeasurements(), qt.Not(qt.IsNil))
+	c.Assert(events, qt.DeepEquals, []string{"debug start", "debug read", "debug start", "error error"})
+	c.Assert(args[1], qt.DeepEquals, []int{351, 652})
+	c.Assert(args[3], qt.DeepEquals, []int{int(StageHandshake)})
+}
+
+func TestOptions(t *testing.T) {
+	c := qt.New(t)
+	p := &replayPin{widths: signal([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEE}), level: true}
+	d := NewPin(p, DHT22SignBit, Options{
+		Calibration: Calibration{TemperatureOffset: -11},
+		Policy:      &UpdatePolicy{MaxRetries: 3},
+	})
+	_, ok := d.(*ManagedDevice)
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(d.ReadMeasurements(), qt.IsNil)
+	c.Assert(d.Temperature(), qt.Equals, int16(340))
+}
+
+func TestConstants(t *testing.T) {
+	c := qt.New(t)
+	// the values of the first releases
+	c.Assert(DHT11, qt.Equals, DeviceType(2))
+	c.Assert(DHT22, qt.Equals, DeviceType(3))
+	c.Assert(C, qt.Equals, TemperatureScale(4))
+	c.Assert(F, qt.Equals, TemperatureScale(5))
+	c.Assert(DeviceType(0).String(), qt.Equals, "DeviceType(0)")
+	c.Assert(DHT22TwosComplement.String(), qt.Equals, "DHT22TwosComplement")
+}
+
+func TestOptionFuncs(t *testing.T) {
+	c := qt.New(t)
+	d := NewI2C(dht12Bus{}, DHT12,
+		WithUpdatePolicy(UpdatePolicy{Median: 3}),
+		WithRetries(2),
+	)
+	m, ok := d.(*ManagedDevice)
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(m.policy.Median, qt.Equals, 3)
+	c.Assert(m.policy.MaxRetries, qt.Equals, 2)
+
+	d = NewI2C(dht12Bus{}, DHT12, WithCalibration(Calibration{TemperatureOffset: -11}))
+	c.Assert(d.ReadMeasurements(), qt.IsNil)
+	c.Assert(d.Temperature(), qt.Equals, int16(214))
+
+	o := Options{}.with([]Option{WithTimings(time.Millisecond, time.Second)})
+	c.Assert(o.timings(DHT11), qt.Equals, Timings{StartLow: time.Millisecond, StartTimeout: time.Second})
+}
+
+// dht12Bus answers the reads of a DHT12 with a fixed frame.
+type dht12Bus struct{}
+
+func (dht12Bus) Tx(addr uint16, w, r []byte) error {
+	copy(r, []byte{0x37, 0x03, 0x16, 0x05, 0x55})
+	return nil
+}
+
+func (dht12Bus) ReadRegister(addr uint8, r uint8, buf []byte) error  { return nil }
+func (dht12Bus) WriteRegister(addr uint8, r uint8, buf []byte) error { return nil }
+
+func TestReadAllocations(t *testing.T) {
+	c := qt.New(t)
+	p := &replayPin{widths: signal([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEE}), level: true}
+	for name, d := range map[string]Device{
+		"pin":     NewPin(p, DHT22SignBit, Options{StartLow: time.Nanosecond, StartTimeout: time.Nanosecond}),
+		"i2c":     NewI2C(dht12Bus{}, DHT12),
+		"managed": WithPolicy(NewI2C(dht12Bus{}, DHT12), UpdatePolicy{Smoothing: MovingAverage, Median: 3, RetryDelay: time.Nanosecond}),
+	} {
+		allocs := testing.AllocsPerRun(10, func() {
+			if err := d.ReadMeasurements(); err != nil {
+				t.Fatal(err)
+			}
+			d.TemperatureMilli(C)
+			d.Humidity()
+		})
+		c.Assert(allocs, qt.Equals, float64(0), qt.Commentf("%s", name))
+	}
+}
+
+func BenchmarkRead(b *testing.B) {
+	p := &replayPin{widths: signal([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEE}), level: true}
+	d := NewPin(p, DHT22SignBit, Options{StartLow: time.Nanosecond, StartTimeout: time.Nanosecond})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := d.ReadMeasurements(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadI2C(b *testing.B) {
+	d := NewI2C(dht12Bus{}, DHT12)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := d.ReadMeasurements(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkExtractData(b *testing.B) {
+	signals := signal([]byte{0x02, 0x8C, 0x01, 0x5F, 0xEE})[2:82]
+	d := &device{measurements: DHT22SignBit}
+	var buf [5]byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		d.extractData(signals, buf[:])
+		d.measurements.extractData(buf[:])
+	}
+}
+
+// readCaptures reads the captures of the testdata directory.
+func readCaptures(c *qt.C) map[string]capture.Capture {
+	files, err := filepath.Glob("testdata/*.capture")
+	c.Assert(err, qt.IsNil)
+	c.Assert(files, qt.Not(qt.HasLen), 0)
+	captures := map[string]capture.Capture{}
+	for _, file := range files {
+		f, err := os.Open(file)
+		c.Assert(err, qt.IsNil)
+		parsed, err := capture.Parse(f)
+		f.Close()
+		c.Assert(err, qt.IsNil, qt.Commentf("%s", file))
+		for i, cp := range parsed {
+			captures[file+":"+strconv.Itoa(i)] = cp
+		}
+	}
+	return captures
+}
+
+func deviceType(c *qt.C, name string) DeviceType {
+	for d, n := range deviceNames {
+		if n != "" && n == name {
+			return DeviceType(d)
+		}
+	}
+	c.Fatalf("unknown device %q", name)
+	return 0
+}
+
+// replay reads a sensor sending the pulses of the 40 bits of a capture.
+func replay(widths []uint16, deviceType DeviceType) (Device, error) {
+	signal := append([]uint16{80, 80}, widths...)
+	p := &replayPin{widths: append(signal, 50), level: true}
+	d := NewPin(p, deviceType, Options{StartLow: time.Nanosecond, StartTimeout: time.Nanosecond})
+	return d, d.ReadMeasurements()
+}
+
+func TestCaptures(t *testing.T) {
+	c := qt.New(t)
+	for name, cp := range readCaptures(c) {
+		d, err := replay(cp.Widths, deviceType(c, cp.Params["device"]))
+		if want, ok := cp.Params["error"]; ok {
+			c.Check(err, qt.ErrorMatches, ".*"+want+".*", qt.Commentf("%s", name))
+			continue
+		}
+		c.Assert(err, qt.IsNil, qt.Commentf("%s", name))
+		c.Check(strconv.Itoa(int(d.Temperature())), qt.Equals, cp.Params["temperature"], qt.Commentf("%s", name))
+		c.Check(strconv.Itoa(int(d.Humidity())), qt.Equals, cp.Params["humidity"], qt.Commentf("%s", name))
+
+		// the capture of the read gives back the frame and the widths
+		again := NewCapture(d, deviceType(c, cp.Params["device"]))
+		c.Check(again.Bytes, qt.DeepEquals, cp.Bytes, qt.Commentf("%s", name))
+		c.Check(again.Widths, qt.HasLen, 80)
+	}
+}
+
+var errShortAnswer = errors.New("short answer")
+
+// am2320Bus answers the reads of an AM2320 with a frame.
+type am2320Bus struct {
+	frame []byte
+}
+
+func (b am2320Bus) Tx(addr uint16, w, r []byte) error {
+	if len(r) > len(b.frame) {
+		return errShortAnswer
+	}
+	copy(r, b.frame)
+	return nil
+}
+
+func (am2320Bus) ReadRegister(addr uint8, r uint8, buf []byte) error  { return nil }
+func (am2320Bus) WriteRegister(addr uint8, r uint8, buf []byte) error { return nil }
+
+// TestFuzz replays damaged variations of the captures, which must be
+// rejected with the errors of the package rather than panic.
+func TestFuzz(t *testing.T) {
+	c := qt.New(t)
+	// a new seed on every run, reported by the failures
+	seed := uint64(time.Now().UnixNano())
+	f := capture.NewFuzzer(seed)
+	for name, cp := range readCaptures(c) {
+		dt := deviceType(c, cp.Params["device"])
+		for i := 0; i < 50; i++ {
+			widths := f.Widths(cp.Widths)
+			_, err := replay(widths, dt)
+			if err != nil && !errors.Is(err, ErrChecksum) && !errors.Is(err, ErrNoData) && !errors.Is(err, ErrNoSignal) {
+				c.Fatalf("seed %d, %s: unexpected error %v for the widths %v", seed, name, err, widths)
+			}
+		}
+	}
+
+	// AM2320 answer: function code, length, 65.2%, 35.1°C and the CRC
+	frame := []byte{0x03, 0x04, 0x02, 0x8C, 0x01, 0x5F}
+	crc := crc16(frame)
+	frame = append(frame, byte(crc), byte(crc>>8))
+	c.Assert(NewI2C(am2320Bus{frame}, AM2320).ReadMeasurements(), qt.IsNil)
+	for i := 0; i < 50; i++ {
+		b := f.Bytes(frame)
+		err := NewI2C(am2320Bus{b}, AM2320).ReadMeasurements()
+		if err != nil && !errors.Is(err, ErrChecksum) && !errors.Is(err, ErrNoData) && !errors.Is(err, errShortAnswer) {
+			c.Fatalf("seed %d: unexpected error %v for the frame % x", seed, err, b)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package dht
+
+import "strconv"
+
+// Stage is the step of a read that failed.
+type Stage uint8
+
+// Stages of a read.
+const (
+	// StageHandshake is the start signal and the response of the sensor.
+	StageHandshake Stage = iota
+
+	// StageData is the reception of the bits.
+	StageData
+
+	// StageChecksum is the verification of the received frame.
+	StageChecksum
+
+	// StageBus is a transfer on the I2C bus.
+	StageBus
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageHandshake:
+		return "handshake"
+	case StageData:
+		return "data"
+	case StageChecksum:
+		return "checksum"
+	default:
+		return "bus"
+	}
+}
+
+// Error is the error returned by a failed read. It wraps the cause, such as
+// ErrChecksum or an error of the I2C bus, which can be checked with
+// errors.Is.
+type Error struct {
+	Err   error
+	Stage Stage
+
+	// Attempt is the number of reads done before the error, more than one
+	// when the read was retried by a ManagedDevice.
+	Attempt int
+}
+
+func (e *Error) Error() string {
+	s := "dht: " + e.Stage.String() + ": " + e.Err.Error()
+	if e.Attempt > 1 {
+		s += " (attempt " + strconv.Itoa(e.Attempt) + ")"
+	}
+	return s
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// wrapError returns the error of a read, with the stage deduced from its
+// cause.
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	stage := StageBus
+	switch err {
+	case ErrNoSignal:
+		stage = StageHandshake
+	case ErrNoData, ErrDeadlineExceeded:
+		stage = StageData
+	case ErrChecksum:
+		stage = StageChecksum
+	}
+	return &Error{Err: err, Stage: stage, Attempt: 1}
+}
+
+// cause returns the error wrapped by an *Error.
+func cause(err error) error {
+	if e, ok := err.(*Error); ok {
+		return e.Err
+	}
+	return err
+}
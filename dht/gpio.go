@@ -0,0 +1,31 @@
+package dht
+
+import (
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+// NewPin returns a sensor using the single-wire protocol on any pin whose
+// direction can be changed, such as a pin of an I/O expander fast enough
+// for the timings of the protocol. New does the same with a machine.Pin.
+func NewPin(p drivers.IOPin, deviceType DeviceType, options Options) Device {
+	return options.apply(&device{
+		pin:          p,
+		measurements: deviceType,
+		timings:      options.timings(deviceType),
+		timeout:      cyclesPerMillisecond(),
+	})
+}
+
+// receiver receives the pulses of the 40 bits without the busy loop, as the
+// devices created with NewInterrupt do.
+type receiver interface {
+	receive(signals []uint16, deadline time.Time) error
+}
+
+// frameReceiver receives the five bytes of the frame with a peripheral that
+// decodes the bits by itself, such as the PIO of the RP2040.
+type frameReceiver interface {
+	receiveFrame(frame []byte, deadline time.Time) error
+}
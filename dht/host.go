@@ -0,0 +1,23 @@
+// +build !tinygo
+
+package dht
+
+// The pins of the host tests replay recorded frames at the pace of the
+// reads, so no interrupt can make the driver miss an edge.
+
+// The loops are not calibrated, as the calls of Get of the pins of the tests
+// last one microsecond each.
+const calibrateLoops = false
+
+func cyclesPerMillisecond() uint16 {
+	return 1000
+}
+
+func defaultCritical() CriticalSection {
+	return noCritical{}
+}
+
+type noCritical struct{}
+
+func (noCritical) Enter() {}
+func (noCritical) Exit()  {}
@@ -0,0 +1,116 @@
+package dht
+
+import (
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+// AddressI2C is the I2C address of the DHT12 and the AM2320.
+const AddressI2C = 0x5C
+
+type i2cDevice struct {
+	bus     drivers.I2C
+	address uint16
+
+	measurements DeviceType
+
+	// buffers of the transfers, guarded by measuring
+	cmd [3]byte
+	buf [8]byte
+
+	readings
+}
+
+// NewI2C returns a DHT12 or AM2320 sensor connected to an I2C bus, with the
+// same interface as the sensors using the single-wire protocol, set up with
+// the options. The I2C bus must already be configured.
+//
+// This function only creates the Device object, it does not touch the device.
+func NewI2C(bus drivers.I2C, deviceType DeviceType, opts ...Option) Device {
+	return NewI2CWithOptions(bus, deviceType, Options{}.with(opts))
+}
+
+// NewI2CWithOptions returns a sensor like NewI2C, set up with the options.
+func NewI2CWithOptions(bus drivers.I2C, deviceType DeviceType, options Options) Device {
+	return options.apply(&i2cDevice{
+		bus:          bus,
+		address:      AddressI2C,
+		measurements: deviceType,
+	})
+}
+
+func (t *i2cDevice) ReadMeasurements() error {
+	return t.ReadMeasurementsWithTimeout(0)
+}
+
+func (t *i2cDevice) ReadMeasurementsWithTimeout(timeout time.Duration) error {
+	deadline := deadlineAfter(timeout)
+	t.measuring.Lock()
+	defer t.measuring.Unlock()
+	if drivers.Trace != nil {
+		drivers.Trace(drivers.TraceDebug, "dht", "start", int(t.measurements))
+	}
+	var err error
+	switch {
+	case expired(deadline):
+		err = ErrDeadlineExceeded
+	case t.measurements == AM2320:
+		err = t.readAM2320()
+	default:
+		err = t.readDHT12()
+	}
+	t.record(err)
+	return wrapError(err)
+}
+
+func (t *i2cDevice) ReadTemperature() (int32, error) {
+	return readTemperature(t)
+}
+
+func (t *i2cDevice) ReadHumidity() (int32, error) {
+	return readHumidity(t)
+}
+
+func (t *i2cDevice) readDHT12() error {
+	buf := t.buf[:5]
+	defer t.debug(buf, nil)
+	// the measurements start at register 0
+	t.cmd[0] = 0
+	if err := t.bus.Tx(t.address, t.cmd[:1], buf); err != nil {
+		return err
+	}
+	if !isValid(buf) {
+		return ErrChecksum
+	}
+	t.set(t.measurements.extractData(buf))
+	return nil
+}
+
+// readAM2320 wakes the sensor up, which does not acknowledge its address
+// while asleep, and reads the four measurement registers with the Modbus
+// style read command.
+func (t *i2cDevice) readAM2320() error {
+	// function code, length, four bytes of data and the CRC, low byte first
+	buf := t.buf[:]
+	defer t.debug(buf, nil)
+	t.cmd[0] = 0
+	t.bus.Tx(t.address, t.cmd[:1], nil)
+	time.Sleep(time.Millisecond)
+	t.cmd = [3]byte{0x03, 0x00, 0x04}
+	if err := t.bus.Tx(t.address, t.cmd[:], nil); err != nil {
+		return err
+	}
+	time.Sleep(2 * time.Millisecond)
+	if err := t.bus.Tx(t.address, nil, buf); err != nil {
+		return err
+	}
+	if buf[0] != 0x03 || buf[1] != 0x04 {
+		return ErrNoData
+	}
+	if crc16(buf[:6]) != uint16(buf[6])|uint16(buf[7])<<8 {
+		return ErrChecksum
+	}
+	t.set(t.measurements.extractData(buf[2:6]))
+	return nil
+}
@@ -0,0 +1,73 @@
+// +build tinygo
+
+package dht
+
+import (
+	"machine"
+	"runtime/interrupt"
+)
+
+// New returns a sensor using the single-wire protocol on the pin, set up
+// with the options.
+func New(p machine.Pin, deviceType DeviceType, opts ...Option) Device {
+	return NewWithOptions(p, deviceType, Options{}.with(opts))
+}
+
+// NewWithOptions returns a sensor like New, set up with the options.
+func NewWithOptions(p machine.Pin, deviceType DeviceType, options Options) Device {
+	return NewPin(machinePin(p), deviceType, options)
+}
+
+// machinePin implements drivers.IOPin.
+type machinePin machine.Pin
+
+func (p machinePin) Get() bool {
+	return machine.Pin(p).Get()
+}
+
+func (p machinePin) Set(high bool) {
+	machine.Pin(p).Set(high)
+}
+
+func (p machinePin) High() {
+	machine.Pin(p).High()
+}
+
+func (p machinePin) Low() {
+	machine.Pin(p).Low()
+}
+
+func (p machinePin) SetOutput() {
+	machine.Pin(p).Configure(machine.PinConfig{Mode: machine.PinOutput})
+}
+
+func (p machinePin) SetInput() {
+	machine.Pin(p).Configure(machine.PinConfig{Mode: machine.PinInput})
+}
+
+// calibrateLoops enables the calibration of the loops of expectChange
+// against the system clock.
+const calibrateLoops = true
+
+func cyclesPerMillisecond() uint16 {
+	freq := machine.CPUFrequency()
+	freq /= 1000
+	return uint16(freq)
+}
+
+func defaultCritical() CriticalSection {
+	return &interruptSection{}
+}
+
+// interruptSection disables all interrupts and restores them on exit.
+type interruptSection struct {
+	state interrupt.State
+}
+
+func (s *interruptSection) Enter() {
+	s.state = interrupt.Disable()
+}
+
+func (s *interruptSection) Exit() {
+	interrupt.Restore(s.state)
+}
@@ -0,0 +1,118 @@
+// +build rp2040
+
+package dht
+
+import (
+	"device/rp"
+	"machine"
+	"runtime/volatile"
+	"time"
+	"unsafe"
+)
+
+// pioProgram samples the bits of the sensor at 1MHz. After the response of
+// the sensor, each bit starts with a low level of 50µs followed by a high
+// level of 26µs for a 0 or 70µs for a 1, so the line is sampled 40µs after
+// the rising edge. The bits are shifted in MSB first and pushed to the RX
+// FIFO one byte at a time.
+var pioProgram = [...]uint16{
+	0x2020, // wait 0 pin 0        ; response, low
+	0x20A0, // wait 1 pin 0        ; response, high
+	0x2020, // wait 0 pin 0        ; low level of the first bit
+	0x3FA0, // wait 1 pin 0 [31]   ; rising edge of a bit
+	0xA742, // nop [7]
+	0x4001, // in pins, 1
+	0x2020, // wait 0 pin 0
+	0x0003, // jmp 3
+}
+
+// Register fields of the PIO and the reset controller.
+const (
+	resetPIO1 = 1 << 11
+
+	pioSM0Enable   = 1 << 0
+	pioSM0Restart  = 1 << 4
+	pioSM0ClkdivRS = 1 << 8
+	pioSM0RXEmpty  = 1 << 8
+
+	shiftJoinRX    = 1 << 31
+	shiftPushByte  = 8 << 20
+	shiftAutoPush  = 1 << 16
+	execWrapTopPos = 12
+	pinInBasePos   = 15
+)
+
+// NewPIO returns a sensor using the single-wire protocol on the pin, whose
+// frame is received by state machine 0 of PIO1 of the RP2040. The CPU only
+// sends the start signal and reads the five bytes, so interrupts are not
+// disabled and other goroutines run during the reception.
+func NewPIO(p machine.Pin, deviceType DeviceType, opts ...Option) Device {
+	options := Options{}.with(opts)
+	timings := options.timings(deviceType)
+	return options.apply(&device{
+		pin:          machinePin(p),
+		measurements: deviceType,
+		timings:      timings,
+		frames:       &pioReceiver{p: p, startLow: timings.StartLow},
+	})
+}
+
+// pioReceiver implements frameReceiver with the PIO.
+type pioReceiver struct {
+	p        machine.Pin
+	startLow time.Duration
+}
+
+func (r *pioReceiver) receiveFrame(frame []byte, deadline time.Time) error {
+	pio := rp.PIO1
+	rp.RESETS.RESET.ClearBits(resetPIO1)
+	for !rp.RESETS.RESET_DONE.HasBits(resetPIO1) {
+	}
+
+	pio.CTRL.ClearBits(pioSM0Enable)
+	mem := (*[32]volatile.Register32)(unsafe.Pointer(&pio.INSTR_MEM0))
+	for i, instr := range pioProgram {
+		mem[i].Set(uint32(instr))
+	}
+	pio.SM0_CLKDIV.Set(machine.CPUFrequency() / 1000000 << 16)
+	pio.SM0_EXECCTRL.Set(uint32(len(pioProgram)-1) << execWrapTopPos)
+	// changing the join of the FIFOs also clears them
+	pio.SM0_SHIFTCTRL.Set(shiftJoinRX | shiftPushByte | shiftAutoPush)
+	pio.SM0_PINCTRL.Set(uint32(r.p) << pinInBasePos)
+	pio.CTRL.SetBits(pioSM0Restart | pioSM0ClkdivRS)
+	// jmp 0
+	pio.SM0_INSTR.Set(0x0000)
+
+	initiateCommunication(machinePin(r.p), r.startLow)
+	pio.CTRL.SetBits(pioSM0Enable)
+
+	// a transmission lasts at most 5ms
+	end := time.Now().Add(10 * time.Millisecond)
+	if !deadline.IsZero() && deadline.Before(end) {
+		end = deadline
+	}
+	n := 0
+	for n < len(frame) {
+		if pio.FSTAT.Get()&pioSM0RXEmpty == 0 {
+			frame[n] = uint8(pio.RXF0.Get())
+			n++
+			continue
+		}
+		if time.Now().After(end) {
+			break
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+	pio.CTRL.ClearBits(pioSM0Enable)
+
+	switch {
+	case n == len(frame):
+		return nil
+	case expired(deadline):
+		return ErrDeadlineExceeded
+	case n == 0:
+		return ErrNoSignal
+	default:
+		return ErrNoData
+	}
+}
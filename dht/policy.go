@@ -0,0 +1,473 @@
+package dht
+
+import (
+	"sync"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+// Smoothing selects the filter applied to the measurements by WithPolicy.
+type Smoothing uint8
+
+// Smoothing filters.
+const (
+	// NoSmoothing returns the last measurement.
+	NoSmoothing Smoothing = iota
+
+	// MovingAverage returns the mean of the last Window measurements.
+	MovingAverage
+
+	// ExponentialAverage weights the measurements like a moving average of
+	// Window measurements, with more weight on the recent ones and without
+	// the memory of the last measurements.
+	ExponentialAverage
+)
+
+// maxWindow is the largest window of the moving average.
+const maxWindow = 32
+
+// maxMedian is the largest number of conversions of a median read.
+const maxMedian = 9
+
+// UpdatePolicy sets how a device returned by WithPolicy handles the
+// occasional failed reads of the sensors and the noise of the measurements.
+type UpdatePolicy struct {
+	// MaxRetries is the number of times a read that failed with a checksum
+	// mismatch or a timing error is retried before the error is returned.
+	MaxRetries int
+
+	// RetryDelay is the time to wait before a retry, 2s if not set, which
+	// is the minimum interval between two measurements of the DHT22.
+	RetryDelay time.Duration
+
+	// Smoothing is the filter applied to the temperature and the humidity.
+	Smoothing Smoothing
+
+	// Window is the number of measurements averaged by the filter, 4 if not
+	// set and at most 32.
+	Window int
+
+	// Median is the number of conversions done by each read, at most 9.
+	// The read returns their median, which discards the occasional spikes
+	// of a single conversion. The conversions are RetryDelay apart, and
+	// with 0 or 1 a read is a single conversion.
+	Median int
+
+	// TrackStatistics enables the Statistics of the device.
+	TrackStatistics bool
+
+	// UpdateTime is the age below which the last measurements are reused
+	// by ReadMeasurements instead of reading the sensor again. With 0 every
+	// call reads the sensor.
+	UpdateTime time.Duration
+
+	// UpdateAutomatically makes the accessors, such as Temperature, call
+	// ReadMeasurements first, so that the values are at most UpdateTime
+	// old. Errors are then only visible through Stats.
+	UpdateAutomatically bool
+
+	// Background starts a goroutine that reads the sensor every UpdateTime,
+	// or every 2s if not set, until Stop is called. The accessors then
+	// never wait for the sensor, and UpdateAutomatically is ignored.
+	Background bool
+}
+
+// ManagedDevice applies an UpdatePolicy to a device. It implements Device.
+type ManagedDevice struct {
+	Device
+	policy UpdatePolicy
+
+	mu          sync.Mutex
+	temperature filter
+	humidity    filter
+	statistics  Statistics
+	last        Measurements
+	sums        [2]int64
+	valid       bool
+	stopped     bool
+
+	observer         func(Measurements)
+	notified         bool
+	lastNotified     Measurements
+	temperatureDelta int16
+	humidityDelta    uint16
+}
+
+// Statistics summarizes the measurements since the creation of a
+// ManagedDevice or the last call to its Reset method, when the policy sets
+// TrackStatistics. The units are those of Device.Temperature and
+// Device.Humidity, and the values are not smoothed.
+type Statistics struct {
+	Samples uint32
+
+	MinTemperature, MaxTemperature, MeanTemperature int16
+	MinHumidity, MaxHumidity, MeanHumidity          uint16
+}
+
+// WithPolicy returns a device that applies the policy to d. Its
+// ReadMeasurements transparently retries the reads that fail with a checksum
+// mismatch or a timing error; other errors, such as those of an I2C bus,
+// are returned at once. The temperature and the humidity are filtered when
+// the policy sets a smoothing filter, and the measurements are cached for
+// the UpdateTime of the policy.
+func WithPolicy(d Device, policy UpdatePolicy) *ManagedDevice {
+	if policy.RetryDelay == 0 {
+		policy.RetryDelay = 2 * time.Second
+	}
+	switch {
+	case policy.Window <= 0:
+		policy.Window = 4
+	case policy.Window > maxWindow:
+		policy.Window = maxWindow
+	}
+	if policy.Median > maxMedian {
+		policy.Median = maxMedian
+	}
+	t := &ManagedDevice{Device: d, policy: policy}
+	if policy.Background {
+		go t.poll()
+	}
+	return t
+}
+
+// poll reads the sensor in the background until Stop is called.
+func (t *ManagedDevice) poll() {
+	interval := t.policy.UpdateTime
+	if interval <= 0 {
+		interval = minInterval
+	}
+	for {
+		t.mu.Lock()
+		stopped := t.stopped
+		t.mu.Unlock()
+		if stopped {
+			return
+		}
+		// errors are counted in the stats of the device
+		t.update(0)
+		time.Sleep(interval)
+	}
+}
+
+// Stop ends the background reads started by the Background policy.
+func (t *ManagedDevice) Stop() {
+	t.mu.Lock()
+	t.stopped = true
+	t.mu.Unlock()
+}
+
+func (t *ManagedDevice) ReadMeasurements() error {
+	return t.ReadMeasurementsWithTimeout(0)
+}
+
+// ReadMeasurementsWithTimeout bounds the time taken by the read and its
+// retries. A retry is not started when its delay would pass the deadline.
+func (t *ManagedDevice) ReadMeasurementsWithTimeout(timeout time.Duration) error {
+	if t.fresh() {
+		return nil
+	}
+	return t.update(timeout)
+}
+
+// ForceUpdate reads the sensor even if the last measurements are younger
+// than the UpdateTime of the policy.
+func (t *ManagedDevice) ForceUpdate() error {
+	return t.update(0)
+}
+
+// Invalidate discards the last measurements, so that the next call to
+// ReadMeasurements reads the sensor. The accessors keep returning the last
+// values until then.
+func (t *ManagedDevice) Invalidate() {
+	t.mu.Lock()
+	t.valid = false
+	t.mu.Unlock()
+}
+
+// automaticUpdate reads the sensor before an access when the policy asks
+// for it.
+func (t *ManagedDevice) automaticUpdate() {
+	if t.policy.UpdateAutomatically && !t.policy.Background {
+		t.ReadMeasurements()
+	}
+}
+
+// fresh returns whether the last measurements can be reused.
+func (t *ManagedDevice) fresh() bool {
+	t.mu.Lock()
+	valid := t.valid
+	t.mu.Unlock()
+	return valid && t.policy.UpdateTime > 0 && time.Since(t.Device.LastUpdate()) < t.policy.UpdateTime
+}
+
+func (t *ManagedDevice) update(timeout time.Duration) error {
+	deadline := deadlineAfter(timeout)
+	temperature, humidity, err := t.read(deadline)
+	if err == nil && t.policy.Median > 1 {
+		temperature, humidity, err = t.median(temperature, humidity, deadline)
+	}
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.temperature.add(int32(temperature), t.policy)
+	t.humidity.add(int32(humidity), t.policy)
+	if t.policy.TrackStatistics {
+		t.track(temperature, humidity)
+	}
+	t.valid = true
+	t.last = Measurements{Temperature: temperature, Humidity: humidity}
+	if t.policy.Smoothing != NoSmoothing {
+		temperature = int16(t.temperature.value(t.policy))
+		humidity = uint16(t.humidity.value(t.policy))
+	}
+	notify := t.changed(temperature, humidity)
+	t.mu.Unlock()
+	if notify != nil {
+		notify(Measurements{Temperature: temperature, Humidity: humidity})
+	}
+	return nil
+}
+
+// read does a conversion, retried as set by the policy.
+func (t *ManagedDevice) read(deadline time.Time) (int16, uint16, error) {
+	for i := 0; ; i++ {
+		remaining := time.Duration(0)
+		if !deadline.IsZero() {
+			if remaining = time.Until(deadline); remaining <= 0 {
+				return 0, 0, wrapError(ErrDeadlineExceeded)
+			}
+		}
+		err := t.Device.ReadMeasurementsWithTimeout(remaining)
+		if err == nil {
+			return t.Device.Temperature(), t.Device.Humidity(), nil
+		}
+		if e, ok := err.(*Error); ok {
+			e.Attempt = i + 1
+		}
+		if i >= t.policy.MaxRetries || !retryable(err) {
+			return 0, 0, err
+		}
+		if !deadline.IsZero() && time.Now().Add(t.policy.RetryDelay).After(deadline) {
+			return 0, 0, err
+		}
+		if r, ok := t.Device.(interface{ recordRetry() }); ok {
+			r.recordRetry()
+		}
+		if drivers.Trace != nil {
+			drivers.Trace(drivers.TraceInfo, "dht", "retry", i+1)
+		}
+		time.Sleep(t.policy.RetryDelay)
+	}
+}
+
+// median does the remaining conversions of a median read, after the first
+// one, and returns the median of all of them.
+func (t *ManagedDevice) median(temperature int16, humidity uint16, deadline time.Time) (int16, uint16, error) {
+	var temperatures, humidities [maxMedian]int32
+	temperatures[0], humidities[0] = int32(temperature), int32(humidity)
+	for i := 1; i < t.policy.Median; i++ {
+		if !deadline.IsZero() && time.Now().Add(t.policy.RetryDelay).After(deadline) {
+			return 0, 0, wrapError(ErrDeadlineExceeded)
+		}
+		time.Sleep(t.policy.RetryDelay)
+		temperature, humidity, err := t.read(deadline)
+		if err != nil {
+			return 0, 0, err
+		}
+		temperatures[i], humidities[i] = int32(temperature), int32(humidity)
+	}
+	n := t.policy.Median
+	return int16(median(temperatures[:n])), uint16(median(humidities[:n])), nil
+}
+
+func (t *ManagedDevice) ReadTemperature() (int32, error) {
+	return readTemperature(t)
+}
+
+func (t *ManagedDevice) ReadHumidity() (int32, error) {
+	return readHumidity(t)
+}
+
+func (t *ManagedDevice) Temperature() int16 {
+	t.automaticUpdate()
+	if t.policy.Smoothing == NoSmoothing && t.policy.Median <= 1 {
+		return t.Device.Temperature()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.policy.Smoothing == NoSmoothing {
+		return t.last.Temperature
+	}
+	return int16(t.temperature.value(t.policy))
+}
+
+func (t *ManagedDevice) TemperatureFloat(scale TemperatureScale) float32 {
+	return scale.convertToFloat(t.Temperature())
+}
+
+func (t *ManagedDevice) TemperatureMilli(scale TemperatureScale) int32 {
+	return scale.convertToMilli(t.Temperature())
+}
+
+func (t *ManagedDevice) Humidity() uint16 {
+	t.automaticUpdate()
+	if t.policy.Smoothing == NoSmoothing && t.policy.Median <= 1 {
+		return t.Device.Humidity()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.policy.Smoothing == NoSmoothing {
+		return t.last.Humidity
+	}
+	return uint16(t.humidity.value(t.policy))
+}
+
+func (t *ManagedDevice) HumidityFloat() float32 {
+	return float32(t.Humidity()) / 10.
+}
+
+// Observe sets a function called after a successful read when the
+// temperature or the humidity differs from the values of the previous call
+// by more than the deltas, in the units of Temperature and Humidity. The
+// first read after Observe always calls it. The values are smoothed when
+// the policy sets a filter. A nil function stops the notifications.
+func (t *ManagedDevice) Observe(temperatureDelta int16, humidityDelta uint16, f func(Measurements)) {
+	t.mu.Lock()
+	t.observer = f
+	t.notified = false
+	t.temperatureDelta = temperatureDelta
+	t.humidityDelta = humidityDelta
+	t.mu.Unlock()
+}
+
+// changed returns the observer to call for new measurements, if any. The
+// lock must be held.
+func (t *ManagedDevice) changed(temperature int16, humidity uint16) func(Measurements) {
+	if t.observer == nil {
+		return nil
+	}
+	if t.notified {
+		dt := int32(temperature) - int32(t.lastNotified.Temperature)
+		dh := int32(humidity) - int32(t.lastNotified.Humidity)
+		if dt < 0 {
+			dt = -dt
+		}
+		if dh < 0 {
+			dh = -dh
+		}
+		if dt <= int32(t.temperatureDelta) && dh <= int32(t.humidityDelta) {
+			return nil
+		}
+	}
+	t.notified = true
+	t.lastNotified = Measurements{Temperature: temperature, Humidity: humidity}
+	return t.observer
+}
+
+// Statistics returns the minimum, maximum and mean of the measurements
+// since the last Reset.
+func (t *ManagedDevice) Statistics() Statistics {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.statistics
+}
+
+// Reset clears the statistics.
+func (t *ManagedDevice) Reset() {
+	t.mu.Lock()
+	t.statistics = Statistics{}
+	t.sums = [2]int64{}
+	t.mu.Unlock()
+}
+
+func (t *ManagedDevice) track(temperature int16, humidity uint16) {
+	s := &t.statistics
+	if s.Samples == 0 || temperature < s.MinTemperature {
+		s.MinTemperature = temperature
+	}
+	if s.Samples == 0 || temperature > s.MaxTemperature {
+		s.MaxTemperature = temperature
+	}
+	if s.Samples == 0 || humidity < s.MinHumidity {
+		s.MinHumidity = humidity
+	}
+	if s.Samples == 0 || humidity > s.MaxHumidity {
+		s.MaxHumidity = humidity
+	}
+	s.Samples++
+	t.sums[0] += int64(temperature)
+	t.sums[1] += int64(humidity)
+	s.MeanTemperature = int16(t.sums[0] / int64(s.Samples))
+	s.MeanHumidity = uint16(t.sums[1] / int64(s.Samples))
+}
+
+// retryable returns whether a read that failed with err may succeed when
+// tried again.
+func retryable(err error) bool {
+	switch cause(err) {
+	case ErrChecksum, ErrNoData, ErrNoSignal:
+		return true
+	}
+	return false
+}
+
+// median sorts the values and returns the middle one, or the mean of the
+// two middle ones for an even count.
+func median(values []int32) int32 {
+	for i := 1; i < len(values); i++ {
+		for j := i; j > 0 && values[j] < values[j-1]; j-- {
+			values[j], values[j-1] = values[j-1], values[j]
+		}
+	}
+	n := len(values)
+	if n%2 == 0 {
+		return (values[n/2-1] + values[n/2]) / 2
+	}
+	return values[n/2]
+}
+
+// filter smooths a series of measurements.
+type filter struct {
+	samples [maxWindow]int32
+	sum     int32
+	n       int
+	next    int
+
+	// average of the exponential filter, scaled by 256
+	average int32
+}
+
+func (f *filter) add(v int32, policy UpdatePolicy) {
+	switch policy.Smoothing {
+	case MovingAverage:
+		if f.n == policy.Window {
+			f.sum -= f.samples[f.next]
+		} else {
+			f.n++
+		}
+		f.samples[f.next] = v
+		f.sum += v
+		f.next = (f.next + 1) % policy.Window
+	case ExponentialAverage:
+		if f.n == 0 {
+			f.average = v << 8
+			f.n = 1
+		} else {
+			// alpha = 2 / (window + 1)
+			f.average += (v<<8 - f.average) * 2 / int32(policy.Window+1)
+		}
+	}
+}
+
+func (f *filter) value(policy UpdatePolicy) int32 {
+	switch {
+	case f.n == 0:
+		return 0
+	case policy.Smoothing == MovingAverage:
+		return f.sum / int32(f.n)
+	default:
+		return f.average >> 8
+	}
+}
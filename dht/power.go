@@ -0,0 +1,141 @@
+// +build tinygo
+
+package dht
+
+import (
+	"machine"
+	"sync"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+// warmUp is the time a sensor needs after power up before it can be read.
+// The datasheet of the DHT22 asks for one second, more than the StartTimeout
+// used to wake up a sensor that stays powered.
+const warmUp = time.Second
+
+// powerOff is how long Reset keeps the sensor switched off.
+const powerOff = 100 * time.Millisecond
+
+var _ drivers.PowerManaged = &PoweredDevice{}
+
+// PoweredDevice is a sensor powered by a GPIO pin, so that it can be
+// switched off between measurements. It implements Device and
+// drivers.PowerManaged.
+type PoweredDevice struct {
+	Device
+	deviceType  DeviceType
+	data, power machine.Pin
+	mu          sync.Mutex
+	on          bool
+	poweredAt   time.Time
+}
+
+// NewPowered returns a sensor using the single-wire protocol on the data
+// pin, powered by the power pin, set up with the options. The sensor starts
+// powered down.
+func NewPowered(data, power machine.Pin, deviceType DeviceType, opts ...Option) *PoweredDevice {
+	power.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	d := &PoweredDevice{
+		Device:     New(data, deviceType, opts...),
+		deviceType: deviceType,
+		data:       data,
+		power:      power,
+	}
+	d.PowerDown()
+	return d
+}
+
+// PowerUp switches the sensor on. The next read waits for the end of its
+// warm-up time.
+func (d *PoweredDevice) PowerUp() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.on {
+		return
+	}
+	d.data.Configure(machine.PinConfig{Mode: machine.PinInput})
+	d.power.High()
+	d.on = true
+	d.poweredAt = time.Now()
+}
+
+// PowerDown switches the sensor off. The data pin is driven low, so that the
+// sensor is not powered through it.
+func (d *PoweredDevice) PowerDown() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.power.Low()
+	d.data.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	d.data.Low()
+	d.on = false
+}
+
+func (d *PoweredDevice) ReadMeasurements() error {
+	return d.ReadMeasurementsWithTimeout(0)
+}
+
+// ReadMeasurementsWithTimeout powers the sensor up if needed and waits for
+// its warm-up time before reading it. The wait counts in the timeout.
+func (d *PoweredDevice) ReadMeasurementsWithTimeout(timeout time.Duration) error {
+	deadline := deadlineAfter(timeout)
+	d.PowerUp()
+	d.mu.Lock()
+	wait := warmUp - time.Since(d.poweredAt)
+	d.mu.Unlock()
+	if wait > 0 {
+		if !deadline.IsZero() && time.Now().Add(wait).After(deadline) {
+			return wrapError(ErrDeadlineExceeded)
+		}
+		time.Sleep(wait)
+	}
+	remaining := time.Duration(0)
+	if !deadline.IsZero() {
+		if remaining = time.Until(deadline); remaining <= 0 {
+			return wrapError(ErrDeadlineExceeded)
+		}
+	}
+	return d.Device.ReadMeasurementsWithTimeout(remaining)
+}
+
+func (d *PoweredDevice) ReadTemperature() (int32, error) {
+	return readTemperature(d)
+}
+
+func (d *PoweredDevice) ReadHumidity() (int32, error) {
+	return readHumidity(d)
+}
+
+// Sleep switches the sensor off, like PowerDown.
+func (d *PoweredDevice) Sleep() error {
+	d.PowerDown()
+	return nil
+}
+
+// Wake switches the sensor on, like PowerUp.
+func (d *PoweredDevice) Wake() error {
+	d.PowerUp()
+	return nil
+}
+
+// Reset switches the sensor off and on again. The next read waits for the
+// warm-up time.
+func (d *PoweredDevice) Reset() error {
+	d.PowerDown()
+	time.Sleep(powerOff)
+	d.PowerUp()
+	return nil
+}
+
+// SupplyCurrent returns no current while the sensor is switched off, and
+// the largest current of the datasheet while it is measuring: 2.5mA for the
+// DHT11 and 1.5mA for the other sensors.
+func (d *PoweredDevice) SupplyCurrent() (sleeping, active uint32) {
+	switch d.deviceType {
+	case DHT11, DHT11Extended:
+		return 0, 2500
+	default:
+		return 0, 1500
+	}
+}
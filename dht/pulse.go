@@ -0,0 +1,82 @@
+// +build tinygo
+
+package dht
+
+import (
+	"machine"
+	"time"
+)
+
+// edgeTimer measures the pulses sent by the sensor in microseconds with a
+// pin change interrupt, so that the decoding does not depend on the speed
+// of the CPU.
+type edgeTimer struct {
+	p        machine.Pin
+	startLow time.Duration
+	last     time.Time
+	count    int
+	widths   [84]uint16
+}
+
+// NewInterrupt returns a sensor using the single-wire protocol like New,
+// which times the pulses of the sensor with a pin change interrupt and the
+// system clock instead of a busy loop. The pin must support interrupts.
+func NewInterrupt(p machine.Pin, deviceType DeviceType, opts ...Option) Device {
+	options := Options{}.with(opts)
+	timings := options.timings(deviceType)
+	return options.apply(&device{
+		pin:          machinePin(p),
+		measurements: deviceType,
+		timings:      timings,
+		edges:        &edgeTimer{p: p, startLow: timings.StartLow},
+	})
+}
+
+// receive starts a measurement and fills signals with the widths of the low
+// and high pulses of the 40 bits, as receiveSignals does with loop counts.
+func (e *edgeTimer) receive(signals []uint16, deadline time.Time) error {
+	p := e.p
+	e.count = 0
+	p.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	p.Low()
+	time.Sleep(e.startLow)
+	p.High()
+	p.Configure(machine.PinConfig{Mode: machine.PinInput})
+	e.last = time.Now()
+	if err := p.SetInterrupt(machine.PinToggle, e.edge); err != nil {
+		return err
+	}
+	// a transmission lasts at most 5ms
+	end := time.Now().Add(10 * time.Millisecond)
+	if !deadline.IsZero() && deadline.Before(end) {
+		end = deadline
+	}
+	for e.count < len(e.widths) && time.Now().Before(end) {
+		time.Sleep(100 * time.Microsecond)
+	}
+	p.SetInterrupt(0, nil)
+
+	switch {
+	case e.count < len(e.widths) && expired(deadline):
+		return ErrDeadlineExceeded
+	case e.count < 3:
+		return ErrNoSignal
+	case e.count < len(e.widths):
+		return ErrNoData
+	}
+	// the first edges are the response of the sensor, low then high for
+	// 80µs each, and the last one is the release of the line
+	copy(signals, e.widths[3:83])
+	return nil
+}
+
+// edge is the interrupt handler, which stores the time since the previous
+// edge.
+func (e *edgeTimer) edge(machine.Pin) {
+	now := time.Now()
+	if e.count < len(e.widths) {
+		e.widths[e.count] = uint16(now.Sub(e.last) / time.Microsecond)
+	}
+	e.last = now
+	e.count++
+}
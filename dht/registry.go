@@ -0,0 +1,12 @@
+package dht
+
+import "tinygo.org/x/drivers/registry"
+
+func init() {
+	// the I2C sensors are not scanned: the AM2320 only answers after a
+	// wake-up and the DHT12 has no identification register
+	registry.Register(registry.Driver{
+		Name: "dht",
+		Bus:  registry.GPIO,
+	})
+}
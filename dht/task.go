@@ -0,0 +1,10 @@
+package dht
+
+import "tinygo.org/x/drivers/scheduler"
+
+// PollTask returns a task that reads the sensor at the minimum interval
+// between two measurements, for a scheduler.Scheduler. The accessors of the
+// device then return the last values read.
+func PollTask(d Device) scheduler.Task {
+	return scheduler.Task{Name: "dht", Poll: d.ReadMeasurements, Interval: minInterval}
+}
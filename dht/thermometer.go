@@ -1,93 +1,341 @@
 package dht
 
 import (
-	"machine"
+	"errors"
+	"sync"
 	"time"
+
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/units"
 )
 
 type device struct {
-	pin machine.Pin
+	pin drivers.IOPin
 
 	measurements DeviceType
+	timings      Timings
+
+	// edges is set when the pulses are timed with a pin change interrupt
+	// instead of a busy loop.
+	edges receiver
+
+	// frames is set when a peripheral receives the bytes of the frame.
+	frames frameReceiver
+
+	// timeout is the number of iterations of expectChange that last
+	// pulseTimeout, the longest wait for an edge of the signal, and
+	// calibrated is set once it has been measured.
+	timeout    uint16
+	calibrated bool
+
+	// buffers of read, guarded by measuring, which would escape to the heap
+	// as local variables
+	buf     [5]byte
+	signals [80]uint16
+
+	readings
+}
 
+// readings holds the last measurements of a sensor, for all transports.
+// mu guards the values, which can be read while a measurement is running,
+// and measuring serializes the measurements.
+type readings struct {
+	mu          sync.Mutex
+	measuring   sync.Mutex
 	temperature int16
 	humidity    uint16
+	lastUpdate  time.Time
+	stats       Stats
+	calibration Calibration
+
+	// raw data of the last read, for debugging
+	frame    [8]byte
+	frameLen int
+	timings  [80]uint16
+	timed    bool
+}
+
+// debug keeps a copy of the data received by the last read.
+func (t *readings) debug(frame []byte, timings []uint16) {
+	t.mu.Lock()
+	t.frameLen = copy(t.frame[:], frame)
+	t.timed = timings != nil
+	copy(t.timings[:], timings)
+	t.mu.Unlock()
+}
+
+func (t *readings) LastRawFrame() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]byte(nil), t.frame[:t.frameLen]...)
 }
 
-func (t *device) Temperature() int16 {
-	return t.temperature
+func (t *readings) LastTimings() []uint16 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.timed {
+		return nil
+	}
+	return append([]uint16(nil), t.timings[:]...)
+}
+
+// Calibration corrects the measurements of a sensor against a reference
+// instrument. The zero value leaves them unchanged.
+type Calibration struct {
+	// TemperatureOffset is added to the temperature, in tenths of a degree
+	// Celsius.
+	TemperatureOffset int16
+
+	// HumidityGain multiplies the humidity, in thousandths: 1000 or 0 for
+	// no change.
+	HumidityGain uint16
+
+	// HumidityOffset is added to the humidity after the gain, in tenths of
+	// a percent. The result is limited to 0-100%.
+	HumidityOffset int16
 }
 
-func (t *device) TemperatureFloat(scale TemperatureScale) float32 {
-	return scale.convertToFloat(t.temperature)
+var errCalibrationData = errors.New("invalid calibration data")
+
+// MarshalBinary encodes the calibration in 6 bytes, to store it with the
+// calibration package.
+func (c Calibration) MarshalBinary() ([]byte, error) {
+	return []byte{
+		byte(c.TemperatureOffset), byte(c.TemperatureOffset >> 8),
+		byte(c.HumidityGain), byte(c.HumidityGain >> 8),
+		byte(c.HumidityOffset), byte(c.HumidityOffset >> 8),
+	}, nil
 }
 
-func (t *device) Humidity() uint16 {
-	return t.humidity
+// UnmarshalBinary decodes a calibration encoded by MarshalBinary.
+func (c *Calibration) UnmarshalBinary(data []byte) error {
+	if len(data) != 6 {
+		return errCalibrationData
+	}
+	c.TemperatureOffset = int16(data[0]) | int16(data[1])<<8
+	c.HumidityGain = uint16(data[2]) | uint16(data[3])<<8
+	c.HumidityOffset = int16(data[4]) | int16(data[5])<<8
+	return nil
 }
 
-func (t *device) HumidityFloat() float32 {
-	return float32(t.humidity) / 10.
+func (t *readings) SetCalibration(calibration Calibration) {
+	t.mu.Lock()
+	t.calibration = calibration
+	t.mu.Unlock()
 }
 
-func initiateCommunication(p machine.Pin) {
+// Stats counts the results of the reads of a sensor since it was created.
+type Stats struct {
+	// Reads is the number of successful reads.
+	Reads uint32
+
+	// ChecksumErrors is the number of reads with a checksum mismatch.
+	ChecksumErrors uint32
+
+	// Timeouts is the number of reads where the sensor did not answer or
+	// stopped sending in the middle of the data.
+	Timeouts uint32
+
+	// Errors is the number of reads that failed for other reasons, such as
+	// errors on an I2C bus.
+	Errors uint32
+
+	// Retries is the number of reads retried by WithPolicy.
+	Retries uint32
+}
+
+// record counts the result of a read, and traces it.
+func (t *readings) record(err error) {
+	t.mu.Lock()
+	switch err {
+	case nil:
+		t.stats.Reads++
+	case ErrChecksum:
+		t.stats.ChecksumErrors++
+	case ErrNoSignal, ErrNoData, ErrDeadlineExceeded:
+		t.stats.Timeouts++
+	default:
+		t.stats.Errors++
+	}
+	temperature, humidity := t.temperature, t.humidity
+	t.mu.Unlock()
+	if drivers.Trace != nil {
+		if err == nil {
+			drivers.Trace(drivers.TraceDebug, "dht", "read", int(temperature), int(humidity))
+		} else {
+			drivers.Trace(drivers.TraceError, "dht", "error", int(wrapError(err).(*Error).Stage))
+		}
+	}
+}
+
+func (t *readings) recordRetry() {
+	t.mu.Lock()
+	t.stats.Retries++
+	t.mu.Unlock()
+}
+
+func (t *readings) Stats() Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
+
+func (t *readings) set(temperature int16, humidity uint16) {
+	t.mu.Lock()
+	t.temperature, t.humidity = temperature, humidity
+	t.lastUpdate = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *readings) LastUpdate() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lastUpdate
+}
+
+func (t *readings) Temperature() int16 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.temperature + t.calibration.TemperatureOffset
+}
+
+func (t *readings) TemperatureFloat(scale TemperatureScale) float32 {
+	return scale.convertToFloat(t.Temperature())
+}
+
+func (t *readings) TemperatureMilli(scale TemperatureScale) int32 {
+	return scale.convertToMilli(t.Temperature())
+}
+
+func (t *readings) Humidity() uint16 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.calibration == (Calibration{}) {
+		return t.humidity
+	}
+	h := int32(t.humidity)
+	if gain := t.calibration.HumidityGain; gain != 0 {
+		h = h * int32(gain) / 1000
+	}
+	h += int32(t.calibration.HumidityOffset)
+	switch {
+	case h < 0:
+		h = 0
+	case h > 1000:
+		h = 1000
+	}
+	return uint16(h)
+}
+
+func (t *readings) HumidityFloat() float32 {
+	return float32(t.Humidity()) / 10.
+}
+
+func initiateCommunication(p drivers.IOPin, startLow time.Duration) {
 	// Send low signal to the device
-	p.Configure(machine.PinConfig{Mode: machine.PinOutput})
-	p.Low()
-	time.Sleep(startingLow)
+	p.SetOutput()
+	p.Set(false)
+	time.Sleep(startLow)
 	// Set pin to high and wait for reply
-	p.High()
-	p.Configure(machine.PinConfig{Mode: machine.PinInput})
+	p.Set(true)
+	p.SetInput()
 }
 
 func (t *device) ReadMeasurements() error {
+	return t.ReadMeasurementsWithTimeout(0)
+}
+
+func (t *device) ReadMeasurementsWithTimeout(timeout time.Duration) error {
+	deadline := deadlineAfter(timeout)
+	t.measuring.Lock()
+	defer t.measuring.Unlock()
+	if drivers.Trace != nil {
+		drivers.Trace(drivers.TraceDebug, "dht", "start", int(t.measurements))
+	}
 	// initial waiting
-	state := powerUp(t.pin)
+	state := powerUp(t.pin, t.timings.StartTimeout)
 	defer t.pin.Set(state)
-	return t.read()
+	if t.edges == nil && t.frames == nil {
+		t.calibrate()
+	}
+	err := ErrDeadlineExceeded
+	if !expired(deadline) {
+		err = t.read(deadline)
+	}
+	t.record(err)
+	return wrapError(err)
 }
 
-func (t *device) read() error {
+func (t *device) read(deadline time.Time) error {
 	// initialize loop variables
-	bufferData := [5]byte{}
-	buf := bufferData[:]
-	signalsData := [80]uint16{}
-	signals := signalsData[:]
+	t.buf = [5]byte{}
+	buf := t.buf[:]
+	signals := t.signals[:]
 
-	initiateCommunication(t.pin)
-	err := waitForDataTransmission(t.pin)
-	if err != nil {
-		return err
+	switch {
+	case t.frames != nil:
+		defer t.debug(buf, nil)
+		if err := t.frames.receiveFrame(buf, deadline); err != nil {
+			return err
+		}
+	case t.edges != nil:
+		defer t.debug(buf, signals)
+		if err := t.edges.receive(signals, deadline); err != nil {
+			return err
+		}
+		if err := t.extractData(signals, buf); err != nil {
+			return err
+		}
+	default:
+		defer t.debug(buf, signals)
+		initiateCommunication(t.pin, t.timings.StartLow)
+		err := t.waitForDataTransmission(deadline)
+		if err != nil {
+			return err
+		}
+		if err := t.receiveSignals(signals, deadline); err != nil {
+			return err
+		}
+		if err := t.extractData(signals, buf); err != nil {
+			return err
+		}
 	}
-	t.receiveSignals(signals)
 
-	err = t.extractData(signals[:], buf)
-	if err != nil {
-		return err
-	}
 	if !isValid(buf[:]) {
-		return checksumError
+		return ErrChecksum
 	}
 
-	t.temperature, t.humidity = t.measurements.extractData(buf)
+	t.set(t.measurements.extractData(buf))
 	return nil
 }
 
-func (t *device) receiveSignals(result []uint16) {
-	i := uint8(0)
-	machine.UART1.Interrupt.Disable()
-	defer machine.UART1.Interrupt.Enable()
-	for ; i < 40; i++ {
-		result[i*2] = expectChange(t.pin, false)
-		result[i*2+1] = expectChange(t.pin, true)
+// receiveSignals times the pulses of the 40 bits. It stops at the first
+// pulse that does not end, as the line is then stuck, and checks the
+// deadline between the bytes, where a late sample does not change the bits.
+func (t *device) receiveSignals(result []uint16, deadline time.Time) error {
+	for i := range result {
+		result[i] = 0
 	}
+	Critical.Enter()
+	defer Critical.Exit()
+	for i := uint8(0); i < 40; i++ {
+		if i%8 == 0 && expired(deadline) {
+			return ErrDeadlineExceeded
+		}
+		result[i*2] = expectChange(t.pin, false, t.timeout)
+		result[i*2+1] = expectChange(t.pin, true, t.timeout)
+		if result[i*2] == t.timeout || result[i*2+1] == t.timeout {
+			return ErrNoData
+		}
+	}
+	return nil
 }
 func (t *device) extractData(signals []uint16, buf []uint8) error {
 	for i := uint8(0); i < 40; i++ {
 		lowCycle := signals[i*2]
 		highCycle := signals[i*2+1]
-		if lowCycle == timeout || highCycle == timeout {
-			return noDataError
+		if t.edges == nil && (lowCycle == t.timeout || highCycle == t.timeout) {
+			return ErrNoData
 		}
 		byteN := i >> 3
 		buf[byteN] <<= 1
@@ -98,35 +346,105 @@ func (t *device) extractData(signals []uint16, buf []uint8) error {
 	return nil
 }
 
-func waitForDataTransmission(p machine.Pin) error {
-	// wait for thermometer to pull down
-	if expectChange(p, true) == timeout {
-		return noSignalError
-	}
-	//wait for thermometer to pull up
-	if expectChange(p, false) == timeout {
-		return noSignalError
-	}
-	// wait for thermometer to pull down and start sending the data
-	if expectChange(p, true) == timeout {
-		return noSignalError
+func (t *device) waitForDataTransmission(deadline time.Time) error {
+	// wait for thermometer to pull down, then up, then down to start
+	// sending the data
+	for _, level := range [3]bool{true, false, true} {
+		if expired(deadline) {
+			return ErrDeadlineExceeded
+		}
+		if expectChange(t.pin, level, t.timeout) == t.timeout {
+			return ErrNoSignal
+		}
 	}
 	return nil
 }
 
+// Device is a temperature and humidity sensor. It is safe for concurrent
+// use: the last measurements can be read while ReadMeasurements runs in
+// another goroutine, and concurrent measurements are run one after the
+// other.
+//
+// It implements drivers.Thermometer and drivers.Hygrometer, whose methods
+// call ReadMeasurements and return the new values.
+//
+// A successful read does not allocate memory, so that the sensors can be
+// read in a loop on microcontrollers with a few kilobytes of RAM. Only the
+// errors of the failed reads are allocated.
 type Device interface {
+	drivers.Thermometer
+	drivers.Hygrometer
+
 	ReadMeasurements() error
+
+	// ReadMeasurementsWithTimeout is like ReadMeasurements, but returns
+	// ErrDeadlineExceeded when the read cannot complete within the timeout.
+	// A timeout of 0 means no limit.
+	ReadMeasurementsWithTimeout(timeout time.Duration) error
+
 	Temperature() int16
 	TemperatureFloat(scale TemperatureScale) float32
+
+	// TemperatureMilli returns the temperature in milli-degrees of the
+	// scale, without floating point arithmetic.
+	TemperatureMilli(scale TemperatureScale) int32
+
 	Humidity() uint16
 	HumidityFloat() float32
+
+	// LastUpdate returns the time of the last successful read, or the zero
+	// time before the first one.
+	LastUpdate() time.Time
+
+	// Stats returns the number of successful and failed reads, to detect a
+	// degrading sensor or bad wiring.
+	Stats() Stats
+
+	// SetCalibration sets the correction applied to the values returned by
+	// the other methods.
+	SetCalibration(calibration Calibration)
+
+	// LastRawFrame returns the bytes received by the last read, even if it
+	// failed: the five bytes of the frame, checksum included, or the eight
+	// bytes of the answer of an AM2320 on I2C.
+	LastRawFrame() []byte
+
+	// LastTimings returns the widths of the low and high pulse of each of
+	// the 40 bits of the last read, in iterations of the busy loop, or in
+	// microseconds for the devices created with NewInterrupt. It returns
+	// nil for the I2C devices.
+	LastTimings() []uint16
+}
+
+// readTemperature implements drivers.Thermometer for the devices.
+func readTemperature(d Device) (int32, error) {
+	if err := d.ReadMeasurements(); err != nil {
+		return 0, err
+	}
+	return d.TemperatureMilli(C), nil
 }
 
-func New(p machine.Pin, deviceType DeviceType) Device {
-	return &device{
-		pin:          p,
-		measurements: deviceType,
-		temperature:  0,
-		humidity:     0,
+// readHumidity implements drivers.Hygrometer for the devices.
+func readHumidity(d Device) (int32, error) {
+	if err := d.ReadMeasurements(); err != nil {
+		return 0, err
 	}
+	return int32(d.Humidity()) * 10, nil
+}
+
+// ReadUnits reads the sensor and returns the temperature and the humidity
+// with their units, like the other sensors through the units package.
+func ReadUnits(d Device) (units.MilliCelsius, units.MilliRH, error) {
+	if err := d.ReadMeasurements(); err != nil {
+		return 0, 0, err
+	}
+	return units.MilliCelsius(d.TemperatureMilli(C)), units.MilliRH(d.Humidity()) * 100, nil
+}
+
+func (t *device) ReadTemperature() (int32, error) {
+	return readTemperature(t)
+}
+
+func (t *device) ReadHumidity() (int32, error) {
+	return readHumidity(t)
 }
@@ -1,27 +1,78 @@
 package dht
 
 import (
-	"machine"
 	"time"
+
+	"tinygo.org/x/drivers"
 )
 
 // Check if the pin is disabled
-func powerUp(p machine.Pin) bool {
+func powerUp(p drivers.IOPin, startTimeout time.Duration) bool {
 	state := p.Get()
 	if !state {
-		p.High()
+		p.Set(true)
 		time.Sleep(startTimeout)
 	}
 	return state
 }
 
-func expectChange(p machine.Pin, oldState bool) uint16 {
+// calibrationLoops is the number of iterations of expectChange timed by
+// calibrate.
+const calibrationLoops = 0x4000
+
+// calibrate measures the speed of expectChange with the system clock and
+// sets the timeout of the device to the number of iterations that last
+// pulseTimeout, so that it does not depend on the CPU and the compiler. The
+// pin must be idle. The estimate from the CPU frequency is kept when the
+// clock is too coarse or the pin changes during the measurement.
+func (t *device) calibrate() {
+	if t.calibrated || !calibrateLoops {
+		return
+	}
+	start := time.Now()
+	n := expectChange(t.pin, t.pin.Get(), calibrationLoops)
+	elapsed := time.Since(start)
+	if n != calibrationLoops {
+		// try again on the next read
+		return
+	}
+	t.calibrated = true
+	if elapsed <= 0 {
+		return
+	}
+	loops := int64(calibrationLoops) * int64(pulseTimeout) / int64(elapsed)
+	switch {
+	case loops < 1:
+		loops = 1
+	case loops > 0xFFFF:
+		loops = 0xFFFF
+	}
+	t.timeout = uint16(loops)
+}
+
+// expectChange waits for the level of the pin to change, and returns the
+// number of iterations, or limit when it did not change.
+func expectChange(p drivers.IOPin, oldState bool, limit uint16) uint16 {
 	counter := uint16(0)
-	for ; p.Get() == oldState && counter != timeout; counter++ {
+	for ; p.Get() == oldState && counter != limit; counter++ {
 	}
 	return counter
 }
 
+// deadlineAfter returns the deadline of a read with a timeout, or the zero
+// time for no timeout.
+func deadlineAfter(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(timeout)
+}
+
+// expired returns whether a deadline set by deadlineAfter has passed.
+func expired(deadline time.Time) bool {
+	return !deadline.IsZero() && time.Now().After(deadline)
+}
+
 func checksum(buf []uint8) uint8 {
 	return buf[4]
 }
@@ -32,3 +83,19 @@ func computeChecksum(buf []uint8) uint8 {
 func isValid(buf []uint8) bool {
 	return checksum(buf) == computeChecksum(buf)
 }
+
+// crc16 computes the Modbus CRC used by the AM2320 on I2C.
+func crc16(buf []uint8) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range buf {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,386 @@
+// Package drv2605 provides a driver for the DRV2605L haptic motor controller
+// by Texas Instruments. It supports both ERM and LRA actuators, playback of
+// the licensed effect libraries stored in the device ROM, real-time playback
+// and the auto-calibration routine.
+//
+// Datasheet:
+// https://www.ti.com/lit/ds/symlink/drv2605l.pdf
+package drv2605 // import "tinygo.org/x/drivers/drv2605"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	errInvalidSlot       = errors.New("drv2605: invalid waveform sequence slot")
+	errCalibrationFailed = errors.New("drv2605: auto-calibration failed")
+	errTimeout           = errors.New("drv2605: timeout waiting for GO bit")
+	errCalibrationData   = errors.New("drv2605: invalid calibration data")
+)
+
+// Device wraps an I2C connection to a DRV2605 device.
+type Device struct {
+	bus      drivers.I2C
+	Address  uint8
+	actuator Actuator
+	buf      [1]byte
+}
+
+// Config contains the settings used by Configure.
+type Config struct {
+	// Actuator is the type of motor connected to the outputs, ERM by default.
+	Actuator Actuator
+
+	// Library is the effect library played by PlayEffect and SetSequence.
+	// When left at LibraryEmpty, LibraryERMA is used for an ERM and
+	// LibraryLRA for an LRA.
+	Library Library
+
+	// RatedVoltage and OverdriveClamp are written unmodified to the
+	// RATED_VOLTAGE and OD_CLAMP registers when non-zero. Use
+	// RatedVoltageERM, RatedVoltageLRA and OverdriveClampVoltage to compute
+	// them from the actuator datasheet.
+	RatedVoltage   uint8
+	OverdriveClamp uint8
+
+	// OpenLoop disables the back-EMF feedback loop, which is needed for
+	// some actuators that are too small to produce a usable back-EMF.
+	OpenLoop bool
+}
+
+// Calibration holds the results of the auto-calibration routine. They can
+// be stored and written back with SetCalibration on the next boot instead of
+// running the calibration again.
+type Calibration struct {
+	Compensation uint8
+	BackEMF      uint8
+	BackEMFGain  uint8
+}
+
+// MarshalBinary encodes the calibration in 3 bytes, to store it with the
+// calibration package.
+func (c Calibration) MarshalBinary() ([]byte, error) {
+	return []byte{c.Compensation, c.BackEMF, c.BackEMFGain}, nil
+}
+
+// UnmarshalBinary decodes a calibration encoded by MarshalBinary.
+func (c *Calibration) UnmarshalBinary(data []byte) error {
+	if len(data) != 3 {
+		return errCalibrationData
+	}
+	c.Compensation, c.BackEMF, c.BackEMFGain = data[0], data[1], data[2]
+	return nil
+}
+
+// New creates a new DRV2605 connection. The I2C bus must already be
+// configured.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Connected returns whether a DRV2604/DRV2605 has been found.
+func (d *Device) Connected() bool {
+	id, err := d.DeviceID()
+	if err != nil {
+		return false
+	}
+	switch id {
+	case DeviceIDDRV2604, DeviceIDDRV2605, DeviceIDDRV2604L, DeviceIDDRV2605L:
+		return true
+	}
+	return false
+}
+
+// DeviceID returns the device ID stored in the upper bits of the STATUS
+// register, see the DeviceID constants.
+func (d *Device) DeviceID() (uint8, error) {
+	status, err := d.read(REG_STATUS)
+	return status >> 5, err
+}
+
+// Configure takes the device out of standby and sets it up for the connected
+// actuator. After Configure the device is in internal trigger mode, ready
+// for PlayEffect or SetSequence.
+func (d *Device) Configure(cfg Config) error {
+	d.actuator = cfg.Actuator
+	if err := d.write(REG_MODE, uint8(ModeInternalTrigger)); err != nil {
+		return err
+	}
+	// no real-time value, no overdrive offset and no sustain/brake offsets
+	for _, reg := range []uint8{REG_RTP_INPUT, REG_OVERDRIVE, REG_SUSTAINPOS, REG_SUSTAINNEG, REG_BREAK} {
+		if err := d.write(reg, 0); err != nil {
+			return err
+		}
+	}
+	if cfg.RatedVoltage != 0 {
+		if err := d.write(REG_RATEDV, cfg.RatedVoltage); err != nil {
+			return err
+		}
+	}
+	if cfg.OverdriveClamp != 0 {
+		if err := d.write(REG_CLAMPV, cfg.OverdriveClamp); err != nil {
+			return err
+		}
+	}
+
+	feedback, err := d.read(REG_FEEDBACK)
+	if err != nil {
+		return err
+	}
+	if d.actuator == LRA {
+		feedback |= FEEDBACK_N_ERM_LRA
+	} else {
+		feedback &^= FEEDBACK_N_ERM_LRA
+	}
+	if err := d.write(REG_FEEDBACK, feedback); err != nil {
+		return err
+	}
+
+	control3, err := d.read(REG_CONTROL3)
+	if err != nil {
+		return err
+	}
+	control3 &^= CONTROL3_ERM_OPEN_LOOP | CONTROL3_LRA_OPEN_LOOP
+	if cfg.OpenLoop {
+		if d.actuator == LRA {
+			control3 |= CONTROL3_LRA_OPEN_LOOP
+		} else {
+			control3 |= CONTROL3_ERM_OPEN_LOOP
+		}
+	}
+	if err := d.write(REG_CONTROL3, control3); err != nil {
+		return err
+	}
+
+	library := cfg.Library
+	if library == LibraryEmpty {
+		library = LibraryERMA
+		if d.actuator == LRA {
+			library = LibraryLRA
+		}
+	}
+	return d.SetLibrary(library)
+}
+
+// SetMode changes the operating mode. It also takes the device out of
+// standby.
+func (d *Device) SetMode(mode Mode) error {
+	return d.write(REG_MODE, uint8(mode)&0x07)
+}
+
+// SetStandby puts the device in (or takes it out of) the low-power standby
+// state. The current mode is preserved.
+func (d *Device) SetStandby(standby bool) error {
+	mode, err := d.read(REG_MODE)
+	if err != nil {
+		return err
+	}
+	if standby {
+		mode |= MODE_STANDBY
+	} else {
+		mode &^= MODE_STANDBY
+	}
+	return d.write(REG_MODE, mode)
+}
+
+// Reset performs a device reset, after which all registers have their
+// default values and Configure must be called again.
+func (d *Device) Reset() error {
+	err := d.write(REG_MODE, MODE_DEV_RESET)
+	time.Sleep(time.Millisecond)
+	return err
+}
+
+// SetLibrary selects the ROM effect library.
+func (d *Device) SetLibrary(library Library) error {
+	return d.write(REG_LIBRARY, uint8(library)&0x07)
+}
+
+// SetWaveform sets one of the eight waveform sequencer slots. An effect of 0
+// ends the sequence, values with the top bit set are delays in units of
+// 10ms.
+func (d *Device) SetWaveform(slot uint8, effect uint8) error {
+	if slot >= WAVESEQ_MAX_STEPS {
+		return errInvalidSlot
+	}
+	return d.write(REG_WAVESEQ1+slot, effect)
+}
+
+// SetSequence loads up to eight effects into the waveform sequencer. Unused
+// slots are cleared so the sequence ends after the last given effect.
+func (d *Device) SetSequence(effects ...uint8) error {
+	if len(effects) > WAVESEQ_MAX_STEPS {
+		return errInvalidSlot
+	}
+	for slot := uint8(0); slot < WAVESEQ_MAX_STEPS; slot++ {
+		var effect uint8
+		if int(slot) < len(effects) {
+			effect = effects[slot]
+		}
+		if err := d.SetWaveform(slot, effect); err != nil {
+			return err
+		}
+		if effect == 0 {
+			break
+		}
+	}
+	return nil
+}
+
+// PlayEffect plays a single effect from the selected library.
+func (d *Device) PlayEffect(effect uint8) error {
+	if err := d.SetSequence(effect); err != nil {
+		return err
+	}
+	return d.Go()
+}
+
+// Go starts playback of the waveform sequence.
+func (d *Device) Go() error {
+	return d.write(REG_GO, 1)
+}
+
+// Stop stops playback of the waveform sequence.
+func (d *Device) Stop() error {
+	return d.write(REG_GO, 0)
+}
+
+// IsPlaying returns whether the waveform sequence (or the calibration or
+// diagnostic routine) is still running.
+func (d *Device) IsPlaying() (bool, error) {
+	g, err := d.read(REG_GO)
+	return g&0x01 != 0, err
+}
+
+// SetRealTimeValue sets the amplitude used in real-time playback mode
+// (ModeRealTime). The value is signed: with an ERM in closed loop negative
+// values brake the motor, with an LRA the sign is ignored.
+func (d *Device) SetRealTimeValue(value int8) error {
+	return d.write(REG_RTP_INPUT, uint8(value))
+}
+
+// AutoCalibrate runs the auto-calibration routine for the configured
+// actuator and returns its results. The actuator must be mounted as it will
+// be used, since the results depend on its load. The device is left in
+// internal trigger mode.
+func (d *Device) AutoCalibrate() (cal Calibration, err error) {
+	if err = d.SetMode(ModeAutoCalibration); err != nil {
+		return
+	}
+	if err = d.Go(); err != nil {
+		return
+	}
+	if err = d.waitForGo(2 * time.Second); err != nil {
+		return
+	}
+	status, err := d.read(REG_STATUS)
+	if err != nil {
+		return
+	}
+	if status&STATUS_DIAG_RESULT != 0 {
+		err = errCalibrationFailed
+		return
+	}
+	if cal.Compensation, err = d.read(REG_AUTOCALCOMP); err != nil {
+		return
+	}
+	if cal.BackEMF, err = d.read(REG_AUTOCALEMP); err != nil {
+		return
+	}
+	feedback, err := d.read(REG_FEEDBACK)
+	if err != nil {
+		return
+	}
+	cal.BackEMFGain = feedback & 0x03
+	err = d.SetMode(ModeInternalTrigger)
+	return
+}
+
+// SetCalibration writes previously obtained calibration results back to the
+// device.
+func (d *Device) SetCalibration(cal Calibration) error {
+	if err := d.write(REG_AUTOCALCOMP, cal.Compensation); err != nil {
+		return err
+	}
+	if err := d.write(REG_AUTOCALEMP, cal.BackEMF); err != nil {
+		return err
+	}
+	feedback, err := d.read(REG_FEEDBACK)
+	if err != nil {
+		return err
+	}
+	feedback = feedback&^0x03 | cal.BackEMFGain&0x03
+	return d.write(REG_FEEDBACK, feedback)
+}
+
+// ReadSupplyVoltage returns the supply voltage sampled during the last
+// playback in millivolts.
+func (d *Device) ReadSupplyVoltage() (int32, error) {
+	v, err := d.read(REG_VBAT)
+	return int32(v) * 5600 / 255, err
+}
+
+// RatedVoltageERM converts the rated (average) voltage of an ERM in
+// millivolts to the value of the RATED_VOLTAGE register.
+func RatedVoltageERM(millivolts int32) uint8 {
+	return clampRegister(millivolts * 1000 / 21590)
+}
+
+// RatedVoltageLRA converts the rated (RMS) voltage of an LRA in millivolts
+// to the value of the RATED_VOLTAGE register. The small correction for the
+// sample time from the datasheet is ignored.
+func RatedVoltageLRA(millivolts int32) uint8 {
+	return clampRegister(millivolts * 1000 / 20710)
+}
+
+// OverdriveClampVoltage converts the maximum allowed voltage in millivolts
+// to the value of the OD_CLAMP register.
+func OverdriveClampVoltage(millivolts int32) uint8 {
+	return clampRegister(millivolts * 1000 / 21960)
+}
+
+func clampRegister(v int32) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 0xFF {
+		return 0xFF
+	}
+	return uint8(v)
+}
+
+// waitForGo waits until the device clears the GO bit.
+func (d *Device) waitForGo(timeout time.Duration) error {
+	start := time.Now()
+	for {
+		playing, err := d.IsPlaying()
+		if err != nil {
+			return err
+		}
+		if !playing {
+			return nil
+		}
+		if time.Since(start) > timeout {
+			return errTimeout
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (d *Device) read(reg uint8) (uint8, error) {
+	err := d.bus.ReadRegister(d.Address, reg, d.buf[:])
+	return d.buf[0], err
+}
+
+func (d *Device) write(reg, value uint8) error {
+	d.buf[0] = value
+	return d.bus.WriteRegister(d.Address, reg, d.buf[:])
+}
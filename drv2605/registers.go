@@ -0,0 +1,114 @@
+package drv2605
+
+// The I2C address which this device listens to.
+const Address = 0x5A
+
+// Registers. Names and addresses copied from the datasheet.
+const (
+	REG_STATUS        = 0x00
+	REG_MODE          = 0x01
+	REG_RTP_INPUT     = 0x02
+	REG_LIBRARY       = 0x03
+	REG_WAVESEQ1      = 0x04
+	REG_WAVESEQ2      = 0x05
+	REG_WAVESEQ3      = 0x06
+	REG_WAVESEQ4      = 0x07
+	REG_WAVESEQ5      = 0x08
+	REG_WAVESEQ6      = 0x09
+	REG_WAVESEQ7      = 0x0A
+	REG_WAVESEQ8      = 0x0B
+	REG_GO            = 0x0C
+	REG_OVERDRIVE     = 0x0D
+	REG_SUSTAINPOS    = 0x0E
+	REG_SUSTAINNEG    = 0x0F
+	REG_BREAK         = 0x10
+	REG_AUDIOCTRL     = 0x11
+	REG_AUDIOLVL      = 0x12
+	REG_AUDIOMAX      = 0x13
+	REG_AUDIOOUTMIN   = 0x14
+	REG_AUDIOOUTMAX   = 0x15
+	REG_RATEDV        = 0x16
+	REG_CLAMPV        = 0x17
+	REG_AUTOCALCOMP   = 0x18
+	REG_AUTOCALEMP    = 0x19
+	REG_FEEDBACK      = 0x1A
+	REG_CONTROL1      = 0x1B
+	REG_CONTROL2      = 0x1C
+	REG_CONTROL3      = 0x1D
+	REG_CONTROL4      = 0x1E
+	REG_VBAT          = 0x21
+	REG_LRARESON      = 0x22
+	WAVESEQ_MAX_STEPS = 8
+)
+
+// Bits in the MODE register.
+const (
+	MODE_STANDBY   = 0x40
+	MODE_DEV_RESET = 0x80
+)
+
+// Bits in the STATUS register.
+const (
+	STATUS_OC_DETECT   = 0x01
+	STATUS_OVER_TEMP   = 0x02
+	STATUS_DIAG_RESULT = 0x08
+)
+
+// Bits in the FEEDBACK register.
+const (
+	FEEDBACK_N_ERM_LRA = 0x80
+)
+
+// Bits in the CONTROL3 register.
+const (
+	CONTROL3_LRA_OPEN_LOOP   = 0x01
+	CONTROL3_DATA_FORMAT_RTP = 0x08
+	CONTROL3_ERM_OPEN_LOOP   = 0x20
+)
+
+// Device IDs as reported in bits 7:5 of the STATUS register.
+const (
+	DeviceIDDRV2604  = 4
+	DeviceIDDRV2605  = 3
+	DeviceIDDRV2604L = 6
+	DeviceIDDRV2605L = 7
+)
+
+// Mode is the operating mode of the device.
+type Mode uint8
+
+const (
+	ModeInternalTrigger Mode = 0
+	ModeExternalEdge    Mode = 1
+	ModeExternalLevel   Mode = 2
+	ModePWMAnalog       Mode = 3
+	ModeAudioToVibe     Mode = 4
+	ModeRealTime        Mode = 5
+	ModeDiagnostics     Mode = 6
+	ModeAutoCalibration Mode = 7
+)
+
+// Library selects one of the effect libraries stored in the device ROM.
+type Library uint8
+
+const (
+	LibraryEmpty Library = 0
+	LibraryERMA  Library = 1
+	LibraryERMB  Library = 2
+	LibraryERMC  Library = 3
+	LibraryERMD  Library = 4
+	LibraryERME  Library = 5
+	LibraryLRA   Library = 6
+	LibraryERMF  Library = 7
+)
+
+// Actuator is the type of motor connected to the driver.
+type Actuator uint8
+
+const (
+	// ERM is an eccentric rotating mass motor.
+	ERM Actuator = iota
+
+	// LRA is a linear resonant actuator.
+	LRA
+)
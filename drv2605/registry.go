@@ -0,0 +1,19 @@
+package drv2605
+
+import (
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/registry"
+)
+
+func init() {
+	registry.Register(registry.Driver{
+		Name:      "drv2605",
+		Bus:       registry.I2C,
+		Addresses: []uint16{Address},
+		Probe: func(bus drivers.I2C, address uint16) bool {
+			d := New(bus)
+			d.Address = uint8(address)
+			return d.Connected()
+		},
+	})
+}
@@ -2,7 +2,6 @@
 //
 // Datasheet:
 // https://datasheets.maximintegrated.com/en/ds/DS1307.pdf
-//
 package ds1307 // import "tinygo.org/x/drivers/ds1307"
 
 import (
@@ -19,6 +18,8 @@ type Device struct {
 	AddressSRAM uint8
 }
 
+var _ drivers.RTC = &Device{}
+
 // New creates a new DS1307 connection. I2C bus must be already configured.
 func New(bus drivers.I2C) Device {
 	return Device{bus: bus,
@@ -27,7 +28,8 @@ func New(bus drivers.I2C) Device {
 	}
 }
 
-// SetTime sets the time and date
+// SetTime sets the time and date. This also clears the clock halt bit, which
+// starts the oscillator.
 func (d *Device) SetTime(t time.Time) error {
 	data := make([]byte, 8)
 	data[0] = uint8(TimeDate)
@@ -42,8 +44,21 @@ func (d *Device) SetTime(t time.Time) error {
 	return err
 }
 
+// IsTimeValid returns whether the time has been set. On first power-up,
+// and after the backup battery ran out, the clock halt bit is set and the
+// oscillator does not run until the time is set with SetTime.
+func (d *Device) IsTimeValid() bool {
+	return d.IsOscillatorRunning()
+}
+
 // Time returns the time and date
 func (d *Device) Time() (time.Time, error) {
+	return d.ReadTime()
+}
+
+// ReadTime returns the time and date. It is the same as Time, but
+// implements drivers.RTC.
+func (d *Device) ReadTime() (time.Time, error) {
 	data := make([]byte, 8)
 	err := d.bus.ReadRegister(d.Address, uint8(TimeDate), data)
 	if err != nil {
@@ -114,8 +129,43 @@ func (d *Device) Read(data []uint8) (n int, err error) {
 	return len(data), nil
 }
 
+// Size returns the size of the battery-backed NVRAM in bytes.
+func (d *Device) Size() int64 {
+	return SRAMSize
+}
+
+// WriteAt writes data to the NVRAM, where offset 0 is the first byte of the
+// NVRAM.
+func (d *Device) WriteAt(data []byte, offset int64) (n int, err error) {
+	if offset < 0 || offset+int64(len(data)) > SRAMSize {
+		return 0, errors.New("writing outside of SRAM")
+	}
+	buffer := make([]byte, len(data)+1)
+	buffer[0] = uint8(SRAMBeginAddres + offset)
+	copy(buffer[1:], data)
+	err = d.bus.Tx(uint16(d.Address), buffer, nil)
+	if err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// ReadAt reads len(data) bytes from the NVRAM, where offset 0 is the first
+// byte of the NVRAM.
+func (d *Device) ReadAt(data []byte, offset int64) (n int, err error) {
+	if offset < 0 || offset+int64(len(data)) > SRAMSize {
+		return 0, errors.New("EOF")
+	}
+	err = d.bus.ReadRegister(d.Address, uint8(SRAMBeginAddres+offset), data)
+	if err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
 // SetOscillatorFrequency sets output oscillator frequency
-// Available modes: SQW_OFF, SQW_1HZ, SQW_4KHZ, SQW_8KHZ, SQW_32KHZ
+// Available modes: SQW_OFF, SQW_1HZ, SQW_4KHZ, SQW_8KHZ, SQW_32KHZ. With
+// SQW_OFF the pin is driven low, with SQW_OFF_HIGH it is released high.
 func (d *Device) SetOscillatorFrequency(sqw uint8) error {
 	data := []byte{uint8(Control), sqw}
 	err := d.bus.Tx(uint16(d.Address), data, nil)
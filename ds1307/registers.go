@@ -8,12 +8,14 @@ const (
 	CH              = 0x7
 	SRAMBeginAddres = 0x8
 	SRAMEndAddress  = 0x3F
+	SRAMSize        = SRAMEndAddress - SRAMBeginAddres + 1
 )
 
 const (
-	SQW_OFF   = 0x0
-	SQW_1HZ   = 0x10
-	SQW_4KHZ  = 0x11
-	SQW_8KHZ  = 0x12
-	SQW_32KHZ = 0x13
+	SQW_OFF      = 0x0
+	SQW_OFF_HIGH = 0x80
+	SQW_1HZ      = 0x10
+	SQW_4KHZ     = 0x11
+	SQW_8KHZ     = 0x12
+	SQW_32KHZ    = 0x13
 )
@@ -0,0 +1,263 @@
+// Package ds18b20 provides a driver for the DS18B20 digital thermometer by
+// Maxim, on a 1-Wire bus of the onewire package.
+//
+// Several sensors can share the bus, each addressed by its ROM code, which
+// onewire.Bus.Search finds. A sensor can also be powered by the bus alone, in
+// parasite power mode, with its VDD pin grounded: the bus is then driven
+// high during the conversions and the copies to EEPROM.
+//
+// Datasheet:
+// https://www.analog.com/media/en/technical-documentation/data-sheets/DS18B20.pdf
+//
+package ds18b20 // import "tinygo.org/x/drivers/ds18b20"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/onewire"
+)
+
+var errBusy = errors.New("ds18b20: conversion in progress")
+
+var _ drivers.Thermometer = &Device{}
+
+// Bus is a 1-Wire bus. It is implemented by the onewire.Bus type.
+type Bus interface {
+	Select(rom onewire.ROM) error
+	Skip() error
+	WriteByte(c byte) error
+	ReadByte() (byte, error)
+	ReadBit() (bool, error)
+	Read(data []byte) (int, error)
+	StrongPullUp(on bool) error
+}
+
+// Resolution is the resolution of the temperature: a higher one takes a
+// longer conversion.
+type Resolution uint8
+
+// Config holds the settings of the sensor. The zero value selects the
+// highest resolution.
+type Config struct {
+	Resolution Resolution
+}
+
+// Device wraps a 1-Wire connection to a DS18B20 device.
+type Device struct {
+	bus Bus
+
+	// ROM is the ROM code of the sensor. When it is zero, the sensor must be
+	// the only device on the bus.
+	ROM onewire.ROM
+
+	resolution Resolution
+	parasite   bool
+
+	// start of the conversion powered by the bus, zero otherwise
+	pulledUp time.Time
+
+	buf [9]byte
+}
+
+// New creates a new DS18B20 connection to the sensor with the ROM code, or to
+// the only device on the bus for a zero ROM code.
+//
+// This function only creates the Device object, it does not touch the
+// device.
+func New(bus Bus, rom onewire.ROM) Device {
+	return Device{
+		bus: bus,
+		ROM: rom,
+	}
+}
+
+// Configure sets the resolution, keeping the alarm limits, and finds whether
+// the sensor is in parasite power mode. The settings are lost at power off,
+// unless they are saved with Save.
+func (d *Device) Configure(config Config) error {
+	if err := d.command(CMD_READ_POWER_SUPPLY); err != nil {
+		return err
+	}
+	// a sensor in parasite power mode pulls the bus low
+	powered, err := d.bus.ReadBit()
+	if err != nil {
+		return err
+	}
+	d.parasite = !powered
+	high, low, err := d.AlarmLimits()
+	if err != nil {
+		return err
+	}
+	if err := d.writeScratchpad(high, low, config.Resolution); err != nil {
+		return err
+	}
+	d.resolution = config.Resolution
+	return nil
+}
+
+// Parasite returns whether Configure found the sensor in parasite power mode.
+func (d *Device) Parasite() bool {
+	return d.parasite
+}
+
+// ConversionTime returns the duration of a conversion at the resolution.
+func (d *Device) ConversionTime() time.Duration {
+	return 750 * time.Millisecond >> d.resolution
+}
+
+// StartConversion starts a conversion of the temperature and returns without
+// waiting, to read it with ReadConverted after ConversionTime. In parasite
+// power mode, the bus is driven high during the conversion and must not be
+// used by others until ReadConverted.
+func (d *Device) StartConversion() error {
+	if err := d.command(CMD_CONVERT_T); err != nil {
+		return err
+	}
+	if d.parasite {
+		if err := d.bus.StrongPullUp(true); err != nil {
+			return err
+		}
+		d.pulledUp = time.Now()
+	}
+	return nil
+}
+
+// Ready returns whether the conversion has ended. In parasite power mode,
+// the sensor cannot tell, Ready returns whether ConversionTime has elapsed.
+func (d *Device) Ready() (bool, error) {
+	if !d.pulledUp.IsZero() {
+		return time.Since(d.pulledUp) >= d.ConversionTime(), nil
+	}
+	// the sensor sends 0 until the end of the conversion
+	return d.bus.ReadBit()
+}
+
+// ReadConverted returns the temperature of the last conversion in celsius
+// milli degrees (°C/1000). In parasite power mode, it waits for the end of
+// the conversion and then releases the bus.
+func (d *Device) ReadConverted() (int32, error) {
+	if err := d.release(); err != nil {
+		return 0, err
+	}
+	if err := d.readScratchpad(); err != nil {
+		return 0, err
+	}
+	raw := int16(d.buf[1])<<8 | int16(d.buf[0])
+	// the low bits are undefined at the lower resolutions
+	raw &^= 1<<d.resolution - 1
+	return int32(raw) * 125 / 2, nil
+}
+
+// ReadTemperature converts the temperature, waits for the end of the
+// conversion and returns the temperature in celsius milli degrees.
+func (d *Device) ReadTemperature() (int32, error) {
+	if err := d.StartConversion(); err != nil {
+		return 0, err
+	}
+	if d.parasite {
+		return d.ReadConverted()
+	}
+	time.Sleep(d.ConversionTime())
+	for {
+		ready, err := d.Ready()
+		if err != nil {
+			return 0, err
+		}
+		if ready {
+			return d.ReadConverted()
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// AlarmLimits returns the alarm limits in degrees Celsius: the sensor is in
+// alarm, and found by onewire.Bus.SearchAlarm, when a conversion is above
+// the high limit or below the low limit.
+func (d *Device) AlarmLimits() (high, low int8, err error) {
+	if err = d.readScratchpad(); err != nil {
+		return
+	}
+	return int8(d.buf[2]), int8(d.buf[3]), nil
+}
+
+// SetAlarmLimits sets the alarm limits in degrees Celsius.
+func (d *Device) SetAlarmLimits(high, low int8) error {
+	return d.writeScratchpad(high, low, d.resolution)
+}
+
+// Save copies the alarm limits and the resolution to the EEPROM of the
+// sensor, which restores them at power on.
+func (d *Device) Save() error {
+	if err := d.command(CMD_COPY_SCRATCHPAD); err != nil {
+		return err
+	}
+	if d.parasite {
+		if err := d.bus.StrongPullUp(true); err != nil {
+			return err
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if d.parasite {
+		return d.bus.StrongPullUp(false)
+	}
+	return nil
+}
+
+// release waits for the end of a conversion powered by the bus and releases
+// the bus.
+func (d *Device) release() error {
+	if d.pulledUp.IsZero() {
+		return nil
+	}
+	if wait := d.ConversionTime() - time.Since(d.pulledUp); wait > 0 {
+		time.Sleep(wait)
+	}
+	d.pulledUp = time.Time{}
+	return d.bus.StrongPullUp(false)
+}
+
+// command addresses the sensor and sends a function command.
+func (d *Device) command(cmd byte) error {
+	if !d.pulledUp.IsZero() {
+		return errBusy
+	}
+	var err error
+	if d.ROM == (onewire.ROM{}) {
+		err = d.bus.Skip()
+	} else {
+		err = d.bus.Select(d.ROM)
+	}
+	if err != nil {
+		return err
+	}
+	return d.bus.WriteByte(cmd)
+}
+
+// readScratchpad reads the 9 bytes of the scratchpad and checks their CRC.
+func (d *Device) readScratchpad() error {
+	if err := d.command(CMD_READ_SCRATCHPAD); err != nil {
+		return err
+	}
+	if _, err := d.bus.Read(d.buf[:]); err != nil {
+		return err
+	}
+	if onewire.CRC8(d.buf[:]) != 0 {
+		return onewire.ErrCRC
+	}
+	return nil
+}
+
+// writeScratchpad writes the alarm limits and the configuration register.
+func (d *Device) writeScratchpad(high, low int8, resolution Resolution) error {
+	if err := d.command(CMD_WRITE_SCRATCHPAD); err != nil {
+		return err
+	}
+	for _, b := range [3]byte{byte(high), byte(low), byte(3-resolution&3)<<5 | 0x1F} {
+		if err := d.bus.WriteByte(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
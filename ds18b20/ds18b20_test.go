@@ -0,0 +1,232 @@
+package ds18b20
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers/onewire"
+)
+
+// sensor is a simulated DS18B20 alone on a 1-Wire bus, following the
+// function commands at the level of the bytes.
+type sensor struct {
+	rom        onewire.ROM
+	scratchpad [9]byte
+	eeprom     [3]byte
+	parasite   bool
+
+	selected   bool
+	cmd        byte
+	pos        int
+	converting bool
+	pulledUp   bool
+	commands   []byte
+
+	// corrupt flips a bit of the next scratchpad read
+	corrupt bool
+}
+
+func newSensor() *sensor {
+	s := &sensor{rom: onewire.ROM{Family, 0x12, 0x34, 0x56, 0x78, 0x9A, 0xBC}}
+	s.rom[7] = onewire.CRC8(s.rom[:7])
+	// values at power on
+	s.scratchpad = [9]byte{0x50, 0x05, 0x4B, 0x46, 0x7F, 0xFF, 0x0C, 0x10}
+	return s
+}
+
+// set sets the temperature in 1/16 of a degree.
+func (s *sensor) set(raw int16) {
+	s.scratchpad[0] = byte(raw)
+	s.scratchpad[1] = byte(raw >> 8)
+}
+
+func (s *sensor) Select(rom onewire.ROM) error {
+	s.selected = rom == s.rom
+	s.cmd = 0
+	return nil
+}
+
+func (s *sensor) Skip() error {
+	s.selected = true
+	s.cmd = 0
+	return nil
+}
+
+func (s *sensor) WriteByte(c byte) error {
+	if !s.selected {
+		return nil
+	}
+	if s.cmd == 0 {
+		s.cmd = c
+		s.pos = 0
+		s.commands = append(s.commands, c)
+		switch c {
+		case CMD_CONVERT_T:
+			s.converting = true
+		case CMD_READ_SCRATCHPAD:
+			s.scratchpad[8] = onewire.CRC8(s.scratchpad[:8])
+			if s.corrupt {
+				s.scratchpad[0] ^= 1
+				s.corrupt = false
+			}
+		case CMD_COPY_SCRATCHPAD:
+			copy(s.eeprom[:], s.scratchpad[2:5])
+		}
+		return nil
+	}
+	if s.cmd == CMD_WRITE_SCRATCHPAD && s.pos < 3 {
+		s.scratchpad[2+s.pos] = c
+		s.pos++
+	}
+	return nil
+}
+
+func (s *sensor) ReadByte() (byte, error) {
+	if !s.selected || s.cmd != CMD_READ_SCRATCHPAD || s.pos >= len(s.scratchpad) {
+		return 0xFF, nil
+	}
+	b := s.scratchpad[s.pos]
+	s.pos++
+	return b, nil
+}
+
+func (s *sensor) Read(data []byte) (int, error) {
+	for i := range data {
+		data[i], _ = s.ReadByte()
+	}
+	return len(data), nil
+}
+
+func (s *sensor) ReadBit() (bool, error) {
+	switch s.cmd {
+	case CMD_READ_POWER_SUPPLY:
+		return !s.parasite, nil
+	case CMD_CONVERT_T:
+		// the conversion ends after a first read slot
+		done := !s.converting
+		s.converting = false
+		return done, nil
+	}
+	return true, nil
+}
+
+func (s *sensor) StrongPullUp(on bool) error {
+	s.pulledUp = on
+	if !on {
+		s.converting = false
+	}
+	return nil
+}
+
+func TestConfigure(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s, s.rom)
+	c.Assert(d.Configure(Config{Resolution: RESOLUTION_9BIT}), qt.IsNil)
+	c.Assert(d.Parasite(), qt.IsFalse)
+	// the alarm limits are kept
+	c.Assert(s.scratchpad[2:5], qt.DeepEquals, []byte{0x4B, 0x46, 0x1F})
+	c.Assert(d.ConversionTime().Milliseconds(), qt.Equals, int64(93))
+
+	c.Assert(d.SetAlarmLimits(30, -10), qt.IsNil)
+	high, low, err := d.AlarmLimits()
+	c.Assert(err, qt.IsNil)
+	c.Assert(high, qt.Equals, int8(30))
+	c.Assert(low, qt.Equals, int8(-10))
+	c.Assert(s.scratchpad[4], qt.Equals, byte(0x1F))
+
+	c.Assert(d.Save(), qt.IsNil)
+	c.Assert(s.eeprom, qt.Equals, [3]byte{30, 0xF6, 0x1F})
+
+	// another ROM code
+	d = New(s, onewire.ROM{Family, 1})
+	c.Assert(d.Configure(Config{}), qt.Equals, onewire.ErrCRC)
+}
+
+func TestReadTemperature(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s, onewire.ROM{})
+	c.Assert(d.Configure(Config{Resolution: RESOLUTION_12BIT}), qt.IsNil)
+
+	// examples of the datasheet
+	for _, tc := range []struct {
+		raw  int16
+		temp int32
+	}{
+		{0x07D0, 125000},
+		{0x0191, 25062},
+		{0x00A2, 10125},
+		{0x0008, 500},
+		{-0x0008, -500},
+		{-0x00A2, -10125},
+		{-0x0370, -55000},
+	} {
+		s.set(tc.raw)
+		c.Assert(d.StartConversion(), qt.IsNil)
+		temp, err := d.ReadConverted()
+		c.Assert(err, qt.IsNil)
+		c.Assert(temp, qt.Equals, tc.temp, qt.Commentf("%#x", tc.raw))
+	}
+
+	// the undefined bits are ignored at the lower resolutions
+	c.Assert(d.Configure(Config{Resolution: RESOLUTION_9BIT}), qt.IsNil)
+	s.set(0x0197)
+	temp, err := d.ReadTemperature()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(25000))
+	c.Assert(s.commands[len(s.commands)-2:], qt.DeepEquals, []byte{CMD_CONVERT_T, CMD_READ_SCRATCHPAD})
+
+	s.corrupt = true
+	_, err = d.ReadTemperature()
+	c.Assert(err, qt.Equals, onewire.ErrCRC)
+}
+
+func TestAsynchronous(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s, s.rom)
+	c.Assert(d.Configure(Config{}), qt.IsNil)
+	s.set(0x0191)
+
+	c.Assert(d.StartConversion(), qt.IsNil)
+	ready, err := d.Ready()
+	c.Assert(err, qt.IsNil)
+	c.Assert(ready, qt.IsFalse)
+	ready, err = d.Ready()
+	c.Assert(err, qt.IsNil)
+	c.Assert(ready, qt.IsTrue)
+	temp, err := d.ReadConverted()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(25062))
+}
+
+func TestParasite(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	s.parasite = true
+	d := New(s, s.rom)
+	c.Assert(d.Configure(Config{Resolution: RESOLUTION_9BIT}), qt.IsNil)
+	c.Assert(d.Parasite(), qt.IsTrue)
+	s.set(0x0191)
+
+	c.Assert(d.StartConversion(), qt.IsNil)
+	c.Assert(s.pulledUp, qt.IsTrue)
+	// the bus is busy until the end of the conversion
+	_, _, err := d.AlarmLimits()
+	c.Assert(err, qt.Equals, errBusy)
+	ready, err := d.Ready()
+	c.Assert(err, qt.IsNil)
+	c.Assert(ready, qt.IsFalse)
+
+	temp, err := d.ReadConverted()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(25000))
+	c.Assert(s.pulledUp, qt.IsFalse)
+
+	temp, err = d.ReadTemperature()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(25000))
+	c.Assert(s.pulledUp, qt.IsFalse)
+}
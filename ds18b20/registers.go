@@ -0,0 +1,22 @@
+package ds18b20
+
+// The family code of the ROM codes of the DS18B20.
+const Family = 0x28
+
+// Function commands. Names copied from the datasheet.
+const (
+	CMD_CONVERT_T         = 0x44
+	CMD_WRITE_SCRATCHPAD  = 0x4E
+	CMD_READ_SCRATCHPAD   = 0xBE
+	CMD_COPY_SCRATCHPAD   = 0x48
+	CMD_RECALL_E2         = 0xB8
+	CMD_READ_POWER_SUPPLY = 0xB4
+)
+
+// Resolutions of the temperature.
+const (
+	RESOLUTION_12BIT Resolution = iota // 0.0625°C, in 750ms
+	RESOLUTION_11BIT                   // 0.125°C, in 375ms
+	RESOLUTION_10BIT                   // 0.25°C, in 187.5ms
+	RESOLUTION_9BIT                    // 0.5°C, in 93.75ms
+)
@@ -0,0 +1,10 @@
+package ds18b20
+
+import "tinygo.org/x/drivers/registry"
+
+func init() {
+	registry.Register(registry.Driver{
+		Name: "ds18b20",
+		Bus:  registry.GPIO,
+	})
+}
@@ -5,13 +5,50 @@
 package ds3231 // import "tinygo.org/x/drivers/ds3231"
 
 import (
+	"errors"
 	"time"
 
 	"tinygo.org/x/drivers"
 )
 
+var errAlarmMode = errors.New("ds3231: alarm mode not supported by alarm 2")
+
 type Mode uint8
 
+// AlarmMode selects which fields of the alarm time must match the current
+// time for an alarm to fire.
+type AlarmMode uint8
+
+// Alarm modes. Alarm 2 has no seconds register: with AlarmEvery it fires
+// once per minute and AlarmSecond is not supported.
+const (
+	// Fire every second (alarm 1) or every minute (alarm 2).
+	AlarmEvery AlarmMode = iota
+	// Seconds match, once per minute.
+	AlarmSecond
+	// Minutes and seconds match, once per hour.
+	AlarmMinute
+	// Hours, minutes and seconds match, once per day.
+	AlarmHour
+	// Day of the month and time match, once per month.
+	AlarmDate
+	// Day of the week and time match, once per week.
+	AlarmWeekday
+)
+
+// SQWFrequency is the frequency of the square-wave output on the INT/SQW pin.
+type SQWFrequency uint8
+
+// Square-wave frequencies. SQWOff routes the alarm interrupts to the pin
+// instead.
+const (
+	SQWOff SQWFrequency = iota
+	SQW1Hz
+	SQW1024Hz
+	SQW4096Hz
+	SQW8192Hz
+)
+
 // Device wraps an I2C connection to a DS3231 device.
 type Device struct {
 	bus     drivers.I2C
@@ -100,7 +137,7 @@ func (d *Device) SetTime(dt time.Time) error {
 
 	data[3] = uint8ToBCD(uint8(dt.Weekday()))
 	data[4] = uint8ToBCD(uint8(dt.Day()))
-	data[5] = uint8ToBCD(uint8(dt.Month()) | centuryFlag)
+	data[5] = uint8ToBCD(uint8(dt.Month())) | centuryFlag
 	data[6] = uint8ToBCD(year)
 
 	err = d.bus.WriteRegister(uint8(d.Address), REG_TIMEDATE, data)
@@ -143,6 +180,158 @@ func (d *Device) ReadTemperature() (int32, error) {
 	return int32(data[0])*1000 + int32((data[1]>>6)*25)*10, nil
 }
 
+// SetAlarm1 programs alarm 1. Only the fields of t selected by mode are
+// used. The alarm flag is cleared, but the interrupt must be enabled
+// separately with SetAlarmInterrupts.
+func (d *Device) SetAlarm1(t time.Time, mode AlarmMode) error {
+	data := alarmRegisters(t, mode)
+	if err := d.bus.WriteRegister(uint8(d.Address), REG_ALARMONE, data[:]); err != nil {
+		return err
+	}
+	return d.ClearAlarmFlags(AlarmFlag_Alarm1)
+}
+
+// SetAlarm2 programs alarm 2, which has a resolution of one minute: the
+// seconds of t are ignored.
+func (d *Device) SetAlarm2(t time.Time, mode AlarmMode) error {
+	if mode == AlarmSecond {
+		return errAlarmMode
+	}
+	data := alarmRegisters(t, mode)
+	if err := d.bus.WriteRegister(uint8(d.Address), REG_ALARMTWO, data[1:]); err != nil {
+		return err
+	}
+	return d.ClearAlarmFlags(AlarmFlag_Alarm2)
+}
+
+// alarmRegisters returns the seconds, minutes, hours and day registers of
+// alarm 1 for the given time, with the mask bits set for the fields that
+// are not matched. Alarm 2 uses the same layout without the seconds.
+func alarmRegisters(t time.Time, mode AlarmMode) [4]uint8 {
+	data := [4]uint8{
+		uint8ToBCD(uint8(t.Second())),
+		uint8ToBCD(uint8(t.Minute())),
+		uint8ToBCD(uint8(t.Hour())),
+		uint8ToBCD(uint8(t.Day())),
+	}
+	if mode == AlarmWeekday {
+		data[3] = uint8ToBCD(uint8(t.Weekday())) | 1<<6 // DY/DT
+	}
+	matched := int(mode)
+	if matched > len(data) {
+		matched = len(data)
+	}
+	for i := matched; i < len(data); i++ {
+		data[i] |= 1 << 7 // AxMy mask bit
+	}
+	return data
+}
+
+// SetAlarmInterrupts enables the interrupt output for the given alarms,
+// a combination of AlarmFlag_Alarm1 and AlarmFlag_Alarm2. The INT/SQW pin
+// is driven low while a flag of an enabled alarm is set, which can be used
+// to wake up the microcontroller. Enabling an alarm turns off the
+// square-wave output.
+func (d *Device) SetAlarmInterrupts(alarms uint8) error {
+	set := uint8(0)
+	if alarms&AlarmFlag_Alarm1 != 0 {
+		set |= 1 << A1IE
+	}
+	if alarms&AlarmFlag_Alarm2 != 0 {
+		set |= 1 << A2IE
+	}
+	if set != 0 {
+		set |= 1 << INTCN
+	}
+	return d.updateRegister(REG_CONTROL, 1<<A1IE|1<<A2IE, set)
+}
+
+// ReadAlarmFlags returns which alarms have fired, as a combination of
+// AlarmFlag_Alarm1 and AlarmFlag_Alarm2.
+func (d *Device) ReadAlarmFlags() (uint8, error) {
+	data := []uint8{0}
+	err := d.bus.ReadRegister(uint8(d.Address), REG_STATUS, data)
+	if err != nil {
+		return 0, err
+	}
+	return data[0] & AlarmFlag_AlarmBoth, nil
+}
+
+// ClearAlarmFlags clears the flags of the given alarms, which releases the
+// INT/SQW pin.
+func (d *Device) ClearAlarmFlags(alarms uint8) error {
+	return d.updateRegister(REG_STATUS, alarms&AlarmFlag_AlarmBoth, 0)
+}
+
+// SetSquareWave configures the square-wave output on the INT/SQW pin. Any
+// frequency other than SQWOff disables the alarm interrupt output. The
+// output keeps running on battery power only if battery is true.
+func (d *Device) SetSquareWave(freq SQWFrequency, battery bool) error {
+	if freq == SQWOff {
+		return d.updateRegister(REG_CONTROL, 1<<BBSQW, 1<<INTCN)
+	}
+	set := uint8(freq-1) << RS1
+	if battery {
+		set |= 1 << BBSQW
+	}
+	return d.updateRegister(REG_CONTROL, 1<<INTCN|1<<RS1|1<<RS2|1<<BBSQW, set)
+}
+
+// SetEnabled32K enables or disables the 32kHz output.
+func (d *Device) SetEnabled32K(enabled bool) error {
+	if enabled {
+		return d.updateRegister(REG_STATUS, 0, 1<<EN32KHZ)
+	}
+	return d.updateRegister(REG_STATUS, 1<<EN32KHZ, 0)
+}
+
+// ReadAgingOffset returns the aging offset, which trims the frequency of
+// the oscillator. One step is about 0.1ppm at 25°C; positive values slow
+// down the clock.
+func (d *Device) ReadAgingOffset() (int8, error) {
+	data := []uint8{0}
+	err := d.bus.ReadRegister(uint8(d.Address), REG_AGING, data)
+	if err != nil {
+		return 0, err
+	}
+	return int8(data[0]), nil
+}
+
+// SetAgingOffset sets the aging offset and starts a temperature conversion
+// so that it takes effect immediately.
+func (d *Device) SetAgingOffset(offset int8) error {
+	err := d.bus.WriteRegister(uint8(d.Address), REG_AGING, []uint8{uint8(offset)})
+	if err != nil {
+		return err
+	}
+	data := []uint8{0}
+	err = d.bus.ReadRegister(uint8(d.Address), REG_STATUS, data)
+	if err != nil {
+		return err
+	}
+	if data[0]&(1<<BSY) != 0 {
+		// a conversion is already running and will apply the new offset
+		return nil
+	}
+	return d.updateRegister(REG_CONTROL, 0, 1<<CONV)
+}
+
+// updateRegister clears and then sets bits in a register. The alarm flags
+// in the status register are left alone unless they are cleared.
+func (d *Device) updateRegister(reg, clear, set uint8) error {
+	data := []uint8{0}
+	err := d.bus.ReadRegister(uint8(d.Address), reg, data)
+	if err != nil {
+		return err
+	}
+	if reg == REG_STATUS {
+		// writing a 1 to an alarm flag leaves it unchanged
+		data[0] |= AlarmFlag_AlarmBoth
+	}
+	data[0] = data[0]&^clear | set
+	return d.bus.WriteRegister(uint8(d.Address), reg, data)
+}
+
 // uint8ToBCD converts a byte to BCD for the DS3231
 func uint8ToBCD(value uint8) uint8 {
 	return value + 6*(value/10)
@@ -0,0 +1,154 @@
+package ds3231
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"tinygo.org/x/drivers/tester"
+)
+
+func TestTime(t *testing.T) {
+	c := qt.New(t)
+	bus := tester.NewI2CBus(c)
+	fake := tester.NewI2CDevice(c, Address)
+	bus.AddDevice(fake)
+	dev := New(bus)
+
+	date := time.Date(2021, 12, 31, 23, 59, 58, 0, time.UTC)
+	c.Assert(dev.SetTime(date), qt.IsNil)
+	fake.AssertRegisters(c, REG_TIMEDATE, []uint8{0x58, 0x59, 0x23, 5, 0x31, 0x12, 0x21})
+	got, err := dev.ReadTime()
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.Equals, date)
+
+	// the century bit is set in the month register, after its conversion
+	date = time.Date(2110, 10, 1, 12, 0, 0, 0, time.UTC)
+	c.Assert(dev.SetTime(date), qt.IsNil)
+	fake.AssertRegisters(c, REG_TIMEDATE+5, []uint8{0x90, 0x10})
+	got, err = dev.ReadTime()
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.Equals, date)
+}
+
+func TestAlarm1(t *testing.T) {
+	c := qt.New(t)
+	bus := tester.NewI2CBus(c)
+	fake := tester.NewI2CDevice(c, Address)
+	bus.AddDevice(fake)
+	dev := New(bus)
+
+	// a Friday
+	date := time.Date(2021, 12, 31, 23, 59, 58, 0, time.UTC)
+	for _, test := range []struct {
+		mode AlarmMode
+		want []uint8
+	}{
+		// the A1M1 to A1M4 bits mask the fields that are not matched
+		{AlarmEvery, []uint8{0xD8, 0xD9, 0xA3, 0xB1}},
+		{AlarmSecond, []uint8{0x58, 0xD9, 0xA3, 0xB1}},
+		{AlarmMinute, []uint8{0x58, 0x59, 0xA3, 0xB1}},
+		{AlarmHour, []uint8{0x58, 0x59, 0x23, 0xB1}},
+		{AlarmDate, []uint8{0x58, 0x59, 0x23, 0x31}},
+		// DY/DT selects the day of the week
+		{AlarmWeekday, []uint8{0x58, 0x59, 0x23, 0x45}},
+	} {
+		fake.SetupRegister(REG_STATUS, 1<<OSF|AlarmFlag_AlarmBoth)
+		c.Assert(dev.SetAlarm1(date, test.mode), qt.IsNil)
+		fake.AssertRegisters(c, REG_ALARMONE, test.want)
+		// only the flag of alarm 1 is cleared
+		fake.AssertRegisters(c, REG_STATUS, []uint8{1<<OSF | AlarmFlag_Alarm2})
+	}
+}
+
+func TestAlarm2(t *testing.T) {
+	c := qt.New(t)
+	bus := tester.NewI2CBus(c)
+	fake := tester.NewI2CDevice(c, Address)
+	bus.AddDevice(fake)
+	dev := New(bus)
+
+	date := time.Date(2021, 12, 31, 23, 59, 58, 0, time.UTC)
+	for _, test := range []struct {
+		mode AlarmMode
+		want []uint8
+	}{
+		// without seconds, the A2M2 to A2M4 bits start at the minutes
+		{AlarmEvery, []uint8{0xD9, 0xA3, 0xB1}},
+		{AlarmMinute, []uint8{0x59, 0xA3, 0xB1}},
+		{AlarmHour, []uint8{0x59, 0x23, 0xB1}},
+		{AlarmDate, []uint8{0x59, 0x23, 0x31}},
+		{AlarmWeekday, []uint8{0x59, 0x23, 0x45}},
+	} {
+		fake.SetupRegister(REG_STATUS, AlarmFlag_AlarmBoth)
+		c.Assert(dev.SetAlarm2(date, test.mode), qt.IsNil)
+		fake.AssertRegisters(c, REG_ALARMTWO, test.want)
+		fake.AssertRegisters(c, REG_STATUS, []uint8{AlarmFlag_Alarm1})
+	}
+
+	c.Assert(dev.SetAlarm2(date, AlarmSecond), qt.Equals, errAlarmMode)
+	fake.AssertRegisters(c, REG_ALARMTWO, []uint8{0x59, 0x23, 0x45})
+	// the seconds of alarm 1 are not touched
+	fake.AssertRegisters(c, REG_ALARMONE, []uint8{0x00})
+}
+
+func TestSquareWave(t *testing.T) {
+	c := qt.New(t)
+	bus := tester.NewI2CBus(c)
+	fake := tester.NewI2CDevice(c, Address)
+	// the power-on default: interrupt output, RS2 and RS1 set
+	fake.SetupRegister(REG_CONTROL, 1<<INTCN|1<<RS2|1<<RS1)
+	bus.AddDevice(fake)
+	dev := New(bus)
+
+	c.Assert(dev.SetSquareWave(SQW1Hz, false), qt.IsNil)
+	fake.AssertRegisters(c, REG_CONTROL, []uint8{0x00})
+	c.Assert(dev.SetSquareWave(SQW1024Hz, false), qt.IsNil)
+	fake.AssertRegisters(c, REG_CONTROL, []uint8{1 << RS1})
+	c.Assert(dev.SetSquareWave(SQW8192Hz, true), qt.IsNil)
+	fake.AssertRegisters(c, REG_CONTROL, []uint8{1<<BBSQW | 1<<RS2 | 1<<RS1})
+
+	// enabling an alarm switches the pin to the interrupt output, and
+	// disabling them leaves it there
+	c.Assert(dev.SetAlarmInterrupts(AlarmFlag_AlarmBoth), qt.IsNil)
+	fake.AssertRegisters(c, REG_CONTROL, []uint8{1<<BBSQW | 1<<RS2 | 1<<RS1 | 1<<INTCN | 1<<A2IE | 1<<A1IE})
+	c.Assert(dev.SetAlarmInterrupts(AlarmFlag_Alarm2), qt.IsNil)
+	fake.AssertRegisters(c, REG_CONTROL, []uint8{1<<BBSQW | 1<<RS2 | 1<<RS1 | 1<<INTCN | 1<<A2IE})
+	c.Assert(dev.SetAlarmInterrupts(0), qt.IsNil)
+	fake.AssertRegisters(c, REG_CONTROL, []uint8{1<<BBSQW | 1<<RS2 | 1<<RS1 | 1<<INTCN})
+
+	c.Assert(dev.SetSquareWave(SQWOff, false), qt.IsNil)
+	fake.AssertRegisters(c, REG_CONTROL, []uint8{1<<RS2 | 1<<RS1 | 1<<INTCN})
+}
+
+func TestAgingOffset(t *testing.T) {
+	c := qt.New(t)
+	bus := tester.NewI2CBus(c)
+	fake := tester.NewI2CDevice(c, Address)
+	bus.AddDevice(fake)
+	dev := New(bus)
+
+	// the offset is a two's complement byte, and a conversion is started
+	// to apply it
+	c.Assert(dev.SetAgingOffset(-3), qt.IsNil)
+	fake.AssertRegisters(c, REG_AGING, []uint8{0xFD})
+	fake.AssertRegisters(c, REG_CONTROL, []uint8{1 << CONV})
+	offset, err := dev.ReadAgingOffset()
+	c.Assert(err, qt.IsNil)
+	c.Assert(offset, qt.Equals, int8(-3))
+
+	// a running conversion applies it as well
+	fake.SetupRegister(REG_CONTROL, 0)
+	fake.SetupRegister(REG_STATUS, 1<<BSY)
+	c.Assert(dev.SetAgingOffset(127), qt.IsNil)
+	fake.AssertRegisters(c, REG_AGING, []uint8{0x7F})
+	fake.AssertRegisters(c, REG_CONTROL, []uint8{0x00})
+	offset, err = dev.ReadAgingOffset()
+	c.Assert(err, qt.IsNil)
+	c.Assert(offset, qt.Equals, int8(127))
+
+	fake.SetupRegister(REG_AGING, 0x80)
+	offset, err = dev.ReadAgingOffset()
+	c.Assert(err, qt.IsNil)
+	c.Assert(offset, qt.Equals, int8(-128))
+}
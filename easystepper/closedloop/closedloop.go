@@ -0,0 +1,198 @@
+// Package closedloop combines a stepper motor driven by easystepper with a
+// position encoder on the motor shaft, so that missed steps are detected
+// and corrected and stalls are reported instead of silently losing the
+// position.
+//
+// Any encoder that reports an accumulated position in counts can be used,
+// for example a quadrature encoder. Absolute single-turn encoders such as
+// the AS5600 magnetic angle sensor can be used through MultiTurn.
+package closedloop // import "tinygo.org/x/drivers/easystepper/closedloop"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers/easystepper"
+)
+
+var (
+	// ErrStalled is returned when the encoder does not follow the steps sent
+	// to the motor.
+	ErrStalled = errors.New("closedloop: motor stalled")
+
+	// ErrPosition is returned when the target could not be reached within
+	// the tolerance after the maximum number of corrections.
+	ErrPosition = errors.New("closedloop: position not reached")
+)
+
+// Encoder reports the position of the motor shaft in encoder counts.
+type Encoder interface {
+	Position() int32
+}
+
+// Config contains the settings used by New.
+type Config struct {
+	// StepsPerRevolution of the motor, and CountsPerRevolution of the
+	// encoder mounted on its shaft.
+	StepsPerRevolution  int32
+	CountsPerRevolution int32
+
+	// Tolerance is the allowed difference in steps between the target and
+	// the measured position, 1 if zero.
+	Tolerance int32
+
+	// MaxCorrections is the number of correction moves MoveToVerified makes
+	// before giving up, 3 if zero.
+	MaxCorrections int
+
+	// CheckInterval is the number of steps between two encoder checks during
+	// a move, 8 if zero. A stall is reported when the encoder followed less
+	// than half of the steps made since the previous check.
+	CheckInterval int32
+}
+
+// Device is a stepper motor with encoder feedback.
+type Device struct {
+	motor *easystepper.Device
+	enc   Encoder
+	cfg   Config
+	zero  int32
+}
+
+// New returns a closed-loop stepper given the motor and its encoder. The
+// current position is taken as position 0.
+func New(motor *easystepper.Device, enc Encoder, cfg Config) *Device {
+	if cfg.Tolerance == 0 {
+		cfg.Tolerance = 1
+	}
+	if cfg.MaxCorrections == 0 {
+		cfg.MaxCorrections = 3
+	}
+	if cfg.CheckInterval == 0 {
+		cfg.CheckInterval = 8
+	}
+	if cfg.StepsPerRevolution == 0 || cfg.CountsPerRevolution == 0 {
+		cfg.StepsPerRevolution, cfg.CountsPerRevolution = 1, 1
+	}
+	d := &Device{
+		motor: motor,
+		enc:   enc,
+		cfg:   cfg,
+	}
+	d.SetPosition(0)
+	return d
+}
+
+// SetPosition defines the current position of the shaft as the given
+// position in steps, for example after homing against a limit switch.
+func (d *Device) SetPosition(steps int32) {
+	d.zero = d.enc.Position() - d.toCounts(steps)
+}
+
+// Position returns the measured position in steps.
+func (d *Device) Position() int32 {
+	counts := d.enc.Position() - d.zero
+	return int32(int64(counts) * int64(d.cfg.StepsPerRevolution) / int64(d.cfg.CountsPerRevolution))
+}
+
+// MoveToVerified moves to the given absolute position in steps and verifies
+// with the encoder that it has been reached. Missed steps are corrected with
+// additional moves. ErrStalled is returned as soon as the motor stops
+// following, ErrPosition when the position could not be reached after the
+// configured number of corrections.
+func (d *Device) MoveToVerified(target int32) error {
+	for attempt := 0; ; attempt++ {
+		err := d.move(target - d.Position())
+		if err != nil {
+			return err
+		}
+		if abs(target-d.Position()) <= d.cfg.Tolerance {
+			return nil
+		}
+		if attempt >= d.cfg.MaxCorrections {
+			return ErrPosition
+		}
+	}
+}
+
+// Move moves by the given number of steps relative to the measured position
+// and verifies the result, see MoveToVerified.
+func (d *Device) Move(steps int32) error {
+	return d.MoveToVerified(d.Position() + steps)
+}
+
+// move makes the given number of steps, checking the encoder for a stall at
+// every CheckInterval steps.
+func (d *Device) move(steps int32) error {
+	forward := steps > 0
+	steps = abs(steps)
+	delay := d.motor.StepDelay()
+	last := d.Position()
+	var sinceCheck int32
+	for i := int32(0); i < steps; i++ {
+		d.motor.Step(forward)
+		time.Sleep(delay)
+		sinceCheck++
+		if sinceCheck == d.cfg.CheckInterval {
+			pos := d.Position()
+			if abs(pos-last)*2 < sinceCheck {
+				return ErrStalled
+			}
+			last = pos
+			sinceCheck = 0
+		}
+	}
+	return nil
+}
+
+func (d *Device) toCounts(steps int32) int32 {
+	return int32(int64(steps) * int64(d.cfg.CountsPerRevolution) / int64(d.cfg.StepsPerRevolution))
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// MultiTurn turns an absolute single-turn angle sensor into an Encoder by
+// counting full turns. Position must be called often enough that the shaft
+// turns less than half a revolution between two calls.
+type MultiTurn struct {
+	read       func() (uint16, error)
+	resolution int32
+	last       int32
+	turns      int32
+	primed     bool
+}
+
+// NewMultiTurn returns an Encoder given a function returning the current
+// angle and the number of distinct angle values per revolution, for example
+// 4096 for the 12-bit AS5600.
+func NewMultiTurn(read func() (uint16, error), resolution uint16) *MultiTurn {
+	return &MultiTurn{
+		read:       read,
+		resolution: int32(resolution),
+	}
+}
+
+// Position implements Encoder. When the sensor cannot be read, the last
+// known position is returned.
+func (m *MultiTurn) Position() int32 {
+	raw, err := m.read()
+	if err == nil {
+		angle := int32(raw) % m.resolution
+		if m.primed {
+			delta := angle - m.last
+			if delta > m.resolution/2 {
+				m.turns--
+			} else if delta < -m.resolution/2 {
+				m.turns++
+			}
+		}
+		m.last = angle
+		m.primed = true
+	}
+	return m.turns*m.resolution + m.last
+}
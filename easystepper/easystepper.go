@@ -69,6 +69,21 @@ func (d *Device) Move(steps int32) {
 	}
 }
 
+// Step moves the motor a single step in the given direction without any
+// delay, so that it can be driven with a custom timing.
+func (d *Device) Step(forward bool) {
+	if forward {
+		d.stepMotor((d.stepNumber + 1) % 4)
+	} else {
+		d.stepMotor((d.stepNumber + 3) % 4)
+	}
+}
+
+// StepDelay returns the delay between two steps at the configured speed.
+func (d *Device) StepDelay() time.Duration {
+	return time.Duration(d.stepDelay) * time.Microsecond
+}
+
 // Off turns off all motor pins
 func (d *Device) Off() {
 	for _, pin := range d.pins {
@@ -0,0 +1,177 @@
+package effects
+
+import (
+	"image/color"
+	"time"
+)
+
+// Rainbow is a rainbow moving along the strip.
+type Rainbow struct {
+	// Period is the time the rainbow takes to move by one full cycle.
+	Period time.Duration
+
+	// Spread is the hue difference between two neighbouring pixels in
+	// degrees. Zero spreads a single rainbow over the whole strip.
+	Spread uint16
+
+	Saturation, Value uint8
+}
+
+// NewRainbow returns a rainbow at full saturation and brightness.
+func NewRainbow(period time.Duration) *Rainbow {
+	return &Rainbow{Period: period, Saturation: 255, Value: 255}
+}
+
+// Render implements Effect.
+func (r *Rainbow) Render(buf []color.RGBA, t time.Duration) {
+	offset := uint32(phase(t, r.Period)) * 360 >> 16
+	for i := range buf {
+		var hue uint32
+		if r.Spread == 0 {
+			hue = uint32(i) * 360 / uint32(len(buf))
+		} else {
+			hue = uint32(i) * uint32(r.Spread)
+		}
+		buf[i] = HSV(uint16((hue+offset)%360), r.Saturation, r.Value)
+	}
+}
+
+// Chase is a group of lit pixels running along the strip, with a fading
+// tail behind them.
+type Chase struct {
+	Color color.RGBA
+
+	// Period is the time it takes to run over the whole strip once.
+	Period time.Duration
+
+	// Width is the number of fully lit pixels, Tail the length of the fading
+	// tail.
+	Width, Tail int
+}
+
+// Render implements Effect.
+func (c *Chase) Render(buf []color.RGBA, t time.Duration) {
+	n := len(buf)
+	if n == 0 {
+		return
+	}
+	head := int(uint32(phase(t, c.Period)) * uint32(n) >> 16)
+	width := c.Width
+	if width < 1 {
+		width = 1
+	}
+	for i := range buf {
+		// distance behind the head, wrapping around the strip
+		dist := (head - i + n) % n
+		switch {
+		case dist < width:
+			buf[i] = c.Color
+		case dist < width+c.Tail:
+			buf[i] = Scale(c.Color, uint8(255-(dist-width+1)*255/(c.Tail+1)))
+		default:
+			buf[i] = color.RGBA{0, 0, 0, 0xff}
+		}
+	}
+}
+
+// Breathe slowly pulses the whole strip in a single color.
+type Breathe struct {
+	Color  color.RGBA
+	Period time.Duration
+
+	// Min is the lowest brightness (0-255) reached.
+	Min uint8
+}
+
+// Render implements Effect.
+func (b *Breathe) Render(buf []color.RGBA, t time.Duration) {
+	// parabola 4x(1-x) is a cheap approximation of a sine half-wave
+	x := uint32(phase(t, b.Period))
+	level := uint32(uint64(4*x) * uint64(0x10000-x) >> 24) // 0-256
+	if level > 255 {
+		level = 255
+	}
+	level = uint32(b.Min) + level*uint32(255-b.Min)/255
+	Fill(buf, Scale(b.Color, uint8(level)))
+}
+
+// Fire simulates flames rising along the strip, based on the well-known
+// Fire2012 algorithm.
+type Fire struct {
+	// Cooling is how much the flames cool down as they rise, 20-100.
+	Cooling uint8
+
+	// Sparking is the chance (0-255) that a new spark is ignited at the
+	// bottom in each frame.
+	Sparking uint8
+
+	heat []uint8
+	rnd  rand
+}
+
+// NewFire returns a fire effect for a strip with the given number of pixels.
+func NewFire(pixels int) *Fire {
+	return &Fire{
+		Cooling:  55,
+		Sparking: 120,
+		heat:     make([]uint8, pixels),
+	}
+}
+
+// Render implements Effect.
+func (f *Fire) Render(buf []color.RGBA, t time.Duration) {
+	heat := f.heat
+	n := len(heat)
+	if len(buf) < n {
+		n = len(buf)
+	}
+	if n == 0 {
+		return
+	}
+	heat = heat[:n]
+
+	// cool down every cell a little
+	maxCool := uint32(f.Cooling)*10/uint32(n) + 2
+	for i := range heat {
+		cool := f.rnd.next() % maxCool
+		if uint32(heat[i]) > cool {
+			heat[i] -= uint8(cool)
+		} else {
+			heat[i] = 0
+		}
+	}
+	// heat drifts up and diffuses
+	for i := n - 1; i >= 2; i-- {
+		heat[i] = uint8((uint16(heat[i-1]) + 2*uint16(heat[i-2])) / 3)
+	}
+	// randomly ignite new sparks near the bottom
+	if uint8(f.rnd.next()) < f.Sparking {
+		y := int(f.rnd.next() % 7)
+		if y >= n {
+			y = n - 1
+		}
+		spark := uint16(heat[y]) + 160 + uint16(f.rnd.next()%96)
+		if spark > 255 {
+			spark = 255
+		}
+		heat[y] = uint8(spark)
+	}
+	for i := 0; i < n; i++ {
+		buf[i] = heatColor(heat[i])
+	}
+}
+
+// heatColor maps a temperature to a black body like color going from black
+// over red and yellow to white.
+func heatColor(temp uint8) color.RGBA {
+	t := uint16(temp) * 191 / 255
+	ramp := uint8((t & 0x3f) << 2)
+	switch {
+	case t > 0x80:
+		return color.RGBA{255, 255, ramp, 0xff}
+	case t > 0x40:
+		return color.RGBA{255, ramp, 0, 0xff}
+	default:
+		return color.RGBA{ramp, 0, 0, 0xff}
+	}
+}
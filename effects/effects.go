@@ -0,0 +1,130 @@
+// Package effects provides an animation engine for addressable LED strips
+// such as WS2812 and APA102.
+//
+// An Effect renders a frame of colors for a given point in time. Effects can
+// be stacked as layers with different blend modes, and an Engine renders
+// them at a fixed frame rate, limits the total brightness to stay within a
+// power budget and writes the result to the strip. The engine is
+// non-blocking: call Update from the main loop as often as possible and it
+// only does work when a new frame is due.
+//
+//	strip := ws2812.New(machine.WS2812)
+//	engine := effects.NewEngine(&strip, 30, 60)
+//	engine.Add(effects.NewRainbow(5*time.Second), effects.Replace, 255)
+//	for {
+//		engine.Update()
+//	}
+package effects // import "tinygo.org/x/drivers/effects"
+
+import (
+	"image/color"
+	"time"
+
+	"tinygo.org/x/drivers/led"
+)
+
+// Strip is an addressable LED strip. It is implemented by the ws2812
+// driver; other drivers can be adapted with StripFunc.
+type Strip interface {
+	WriteColors(buf []color.RGBA) error
+}
+
+// StripFunc adapts a function to the Strip interface, for example for the
+// apa102 driver:
+//
+//	effects.StripFunc(func(c []color.RGBA) error {
+//		_, err := dev.WriteColors(c)
+//		return err
+//	})
+type StripFunc func(buf []color.RGBA) error
+
+// WriteColors implements Strip.
+func (f StripFunc) WriteColors(buf []color.RGBA) error {
+	return f(buf)
+}
+
+// Effect renders a frame. The buffer contains the previous contents of the
+// layer and must be completely overwritten; t is the time elapsed since the
+// engine was started.
+type Effect interface {
+	Render(buf []color.RGBA, t time.Duration)
+}
+
+// EffectFunc adapts a function to the Effect interface.
+type EffectFunc func(buf []color.RGBA, t time.Duration)
+
+// Render implements Effect.
+func (f EffectFunc) Render(buf []color.RGBA, t time.Duration) {
+	f(buf, t)
+}
+
+// HSV returns an opaque color given a hue (0-359 degrees), saturation and
+// value (both 0-255).
+func HSV(h uint16, s, v uint8) color.RGBA {
+	return led.HSV(h, s, v)
+}
+
+// Fill sets all pixels to the same color.
+func Fill(buf []color.RGBA, c color.RGBA) {
+	for i := range buf {
+		buf[i] = c
+	}
+}
+
+// Gradient fills the buffer with a linear gradient between two colors.
+func Gradient(buf []color.RGBA, from, to color.RGBA) {
+	n := len(buf) - 1
+	if n <= 0 {
+		Fill(buf, from)
+		return
+	}
+	for i := range buf {
+		buf[i] = Lerp(from, to, uint8(i*255/n))
+	}
+}
+
+// Lerp interpolates between two colors, where a frac of 0 returns a and
+// 255 returns b.
+func Lerp(a, b color.RGBA, frac uint8) color.RGBA {
+	f := int32(frac)
+	mix := func(x, y uint8) uint8 {
+		return uint8(int32(x) + (int32(y)-int32(x))*f/255)
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+}
+
+// Scale multiplies the brightness of a color with a factor of 0-255.
+func Scale(c color.RGBA, factor uint8) color.RGBA {
+	f := uint16(factor) + 1
+	return color.RGBA{
+		uint8(uint16(c.R) * f >> 8),
+		uint8(uint16(c.G) * f >> 8),
+		uint8(uint16(c.B) * f >> 8),
+		c.A,
+	}
+}
+
+// phase returns the position within a cycle of the given period as a value
+// from 0 to 0xffff.
+func phase(t, period time.Duration) uint16 {
+	if period <= 0 {
+		return 0
+	}
+	return uint16(uint64(t%period) * 0x10000 / uint64(period))
+}
+
+// rand is a small xorshift pseudo random number generator, so that effects
+// do not depend on math/rand.
+type rand uint32
+
+func (r *rand) next() uint32 {
+	x := uint32(*r)
+	if x == 0 {
+		x = 2463534242
+	}
+	x ^= x << 13
+	x ^= x >> 17
+	x ^= x << 5
+	*r = rand(x)
+	return x
+}
@@ -0,0 +1,179 @@
+package effects
+
+import (
+	"image/color"
+	"time"
+)
+
+// BlendMode determines how a layer is combined with the layers below it.
+type BlendMode uint8
+
+const (
+	// Replace draws the layer over the layers below, weighted by its
+	// opacity.
+	Replace BlendMode = iota
+
+	// Add adds the layer to the layers below, saturating at full
+	// brightness.
+	Add
+
+	// Multiply darkens the layers below with the layer, for example to
+	// apply a mask.
+	Multiply
+)
+
+type layer struct {
+	effect  Effect
+	mode    BlendMode
+	opacity uint8
+	buf     []color.RGBA
+}
+
+// Engine renders a stack of effect layers to a strip at a fixed frame rate.
+type Engine struct {
+	strip    Strip
+	pixels   []color.RGBA
+	layers   []layer
+	interval time.Duration
+	start    time.Time
+	next     time.Time
+
+	// MaxCurrent is the power budget of the strip in milliamps. Frames that
+	// would draw more are dimmed. Zero disables the limit.
+	MaxCurrent uint32
+
+	// ChannelCurrent is the current in milliamps drawn by a single color
+	// channel of one LED at full brightness, 20 for WS2812 LEDs.
+	ChannelCurrent uint32
+
+	// Brightness is the global brightness (0-255) applied to every frame.
+	Brightness uint8
+}
+
+// NewEngine returns a new engine for a strip with the given number of
+// pixels, rendering the given number of frames per second.
+func NewEngine(strip Strip, pixels int, fps int) *Engine {
+	if fps <= 0 {
+		fps = 30
+	}
+	return &Engine{
+		strip:          strip,
+		pixels:         make([]color.RGBA, pixels),
+		interval:       time.Second / time.Duration(fps),
+		ChannelCurrent: 20,
+		Brightness:     255,
+	}
+}
+
+// Add adds a layer on top of the existing ones. The layer buffer is
+// allocated here so that rendering does not allocate.
+func (e *Engine) Add(effect Effect, mode BlendMode, opacity uint8) {
+	e.layers = append(e.layers, layer{
+		effect:  effect,
+		mode:    mode,
+		opacity: opacity,
+		buf:     make([]color.RGBA, len(e.pixels)),
+	})
+}
+
+// Clear removes all layers.
+func (e *Engine) Clear() {
+	e.layers = e.layers[:0]
+}
+
+// SetOpacity changes the opacity of the layer with the given index, in the
+// order the layers were added.
+func (e *Engine) SetOpacity(index int, opacity uint8) {
+	if index >= 0 && index < len(e.layers) {
+		e.layers[index].opacity = opacity
+	}
+}
+
+// Pixels returns the last rendered frame.
+func (e *Engine) Pixels() []color.RGBA {
+	return e.pixels
+}
+
+// Update renders and writes a new frame if one is due. It returns whether a
+// frame was written, and any error returned by the strip.
+func (e *Engine) Update() (bool, error) {
+	now := time.Now()
+	if e.start.IsZero() {
+		e.start = now
+		e.next = now
+	}
+	if now.Before(e.next) {
+		return false, nil
+	}
+	e.next = e.next.Add(e.interval)
+	if now.After(e.next) {
+		// we fell behind, skip the missed frames
+		e.next = now.Add(e.interval)
+	}
+	e.Render(now.Sub(e.start))
+	return true, e.strip.WriteColors(e.pixels)
+}
+
+// Render composes all layers for the given point in time into the frame
+// buffer, without writing it to the strip.
+func (e *Engine) Render(t time.Duration) {
+	Fill(e.pixels, color.RGBA{0, 0, 0, 0xff})
+	for i := range e.layers {
+		l := &e.layers[i]
+		l.effect.Render(l.buf, t)
+		for j, c := range l.buf {
+			e.pixels[j] = blend(e.pixels[j], c, l.mode, l.opacity)
+		}
+	}
+	e.limit()
+}
+
+// limit applies the global brightness and the power budget.
+func (e *Engine) limit() {
+	scale := uint32(e.Brightness)
+	if e.MaxCurrent > 0 {
+		var sum uint32
+		for _, c := range e.pixels {
+			sum += uint32(c.R) + uint32(c.G) + uint32(c.B)
+		}
+		current := sum * scale / 255 * e.ChannelCurrent / 255
+		if current > e.MaxCurrent {
+			scale = scale * e.MaxCurrent / current
+		}
+	}
+	if scale >= 255 {
+		return
+	}
+	for i, c := range e.pixels {
+		e.pixels[i] = Scale(c, uint8(scale))
+	}
+}
+
+func blend(dst, src color.RGBA, mode BlendMode, opacity uint8) color.RGBA {
+	var out color.RGBA
+	switch mode {
+	case Add:
+		src = Scale(src, opacity)
+		out = color.RGBA{sat(dst.R, src.R), sat(dst.G, src.G), sat(dst.B, src.B), 0xff}
+	case Multiply:
+		mul := color.RGBA{
+			uint8(uint16(dst.R) * uint16(src.R) / 255),
+			uint8(uint16(dst.G) * uint16(src.G) / 255),
+			uint8(uint16(dst.B) * uint16(src.B) / 255),
+			0xff,
+		}
+		out = Lerp(dst, mul, opacity)
+	default:
+		out = Lerp(dst, src, opacity)
+	}
+	out.A = 0xff
+	return out
+}
+
+// sat adds two color channels, saturating at 255.
+func sat(a, b uint8) uint8 {
+	if s := uint16(a) + uint16(b); s < 255 {
+		return uint8(s)
+	}
+	return 255
+}
@@ -0,0 +1,225 @@
+// Package encoder provides a driver for incremental rotary encoders with
+// quadrature outputs, such as the EC11 and KY-040 found on many control
+// knobs, optionally with a push button.
+//
+// The outputs are decoded in pin interrupts by a state machine that only
+// counts complete, valid sequences of transitions, which rejects contact
+// bounce without any delays. The state machines for full and half step
+// encoders are based on the decoder by Ben Buxton:
+// https://github.com/buxtronix/arduino/tree/master/libraries/Rotary
+package encoder // import "tinygo.org/x/drivers/encoder"
+
+import (
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+// Mode sets how many counts one quadrature cycle of the outputs is worth,
+// which depends on the number of detents of the encoder.
+type Mode uint8
+
+// Decoding modes.
+const (
+	// One count per cycle, for encoders with one detent per cycle, such as
+	// most EC11 knobs.
+	FullStep Mode = iota
+
+	// Two counts per cycle, for encoders with two detents per cycle.
+	HalfStep
+
+	// Four counts per cycle, every transition is counted. For encoders
+	// without detents, such as optical motor encoders.
+	QuarterStep
+)
+
+const (
+	dirCW  = 0x10
+	dirCCW = 0x20
+)
+
+// fullStep is the state table for FullStep, indexed by the state and the
+// pin levels (A<<1 | B). It returns the next state, with a direction flag
+// when a cycle is complete.
+var fullStep = [7][4]uint8{
+	{0x0, 0x2, 0x4, 0x0},          // start
+	{0x3, 0x0, 0x1, 0x0 | dirCW},  // clockwise, final
+	{0x3, 0x2, 0x0, 0x0},          // clockwise, begin
+	{0x3, 0x2, 0x1, 0x0},          // clockwise, next
+	{0x6, 0x0, 0x4, 0x0},          // counter clockwise, begin
+	{0x6, 0x5, 0x0, 0x0 | dirCCW}, // counter clockwise, final
+	{0x6, 0x5, 0x4, 0x0},          // counter clockwise, next
+}
+
+// halfStep is the state table for HalfStep.
+var halfStep = [6][4]uint8{
+	{0x3, 0x2, 0x1, 0x0},          // start, both high
+	{0x3 | dirCCW, 0x0, 0x1, 0x0}, // counter clockwise, begin
+	{0x3 | dirCW, 0x2, 0x0, 0x0},  // clockwise, begin
+	{0x3, 0x5, 0x4, 0x0},          // start, both low
+	{0x3, 0x3, 0x4, 0x0 | dirCW},  // clockwise, begin from low
+	{0x3, 0x5, 0x3, 0x0 | dirCCW}, // counter clockwise, begin from low
+}
+
+// quarterStep returns the direction of a transition, indexed by the old
+// and new pin levels (old<<2 | new). Invalid transitions count as zero.
+var quarterStep = [16]int8{0, -1, 1, 0, 1, 0, 0, -1, -1, 0, 0, 1, 0, 1, -1, 0}
+
+// Time after which the velocity is reported as zero.
+const idleTimeout = 250 * time.Millisecond
+
+// Debounce time of the button.
+const debounceTime = 10 * time.Millisecond
+
+// Config contains the settings used by Configure. Turning clockwise, when
+// output A leads B, counts up.
+type Config struct {
+	// Mode of the decoder, FullStep if not set.
+	Mode Mode
+
+	// Reverse swaps the direction of rotation.
+	Reverse bool
+}
+
+// Device is a rotary encoder.
+type Device struct {
+	a, b, button drivers.Pin // button is nil without a push button
+	mode         Mode
+	reverse      bool
+
+	state    uint8
+	position int32
+	read     int32 // position at the last call to Delta
+
+	lastStep time.Time
+	interval time.Duration // smoothed time between counts
+	dir      int32
+
+	pressed   bool
+	clicked   bool
+	lastPress time.Time
+}
+
+// start resets the decoder to the current levels of the outputs.
+func (d *Device) start(cfg Config) {
+	d.mode = cfg.Mode
+	d.reverse = cfg.Reverse
+	d.state = d.levels()
+	if d.mode != QuarterStep {
+		d.state = 0
+		if d.mode == HalfStep && d.levels() == 0 {
+			d.state = 3
+		}
+	}
+}
+
+// levels returns the current levels of the outputs, as A<<1 | B.
+func (d *Device) levels() uint8 {
+	l := uint8(0)
+	if d.a.Get() {
+		l |= 2
+	}
+	if d.b.Get() {
+		l |= 1
+	}
+	return l
+}
+
+// Update advances the decoder with the given output levels, as A<<1 | B.
+// It is called from the pin interrupts, but can also be used to poll an
+// encoder connected in another way, for example through an I/O expander.
+func (d *Device) Update(levels uint8) {
+	var step int32
+	switch d.mode {
+	case QuarterStep:
+		step = int32(quarterStep[d.state<<2|levels])
+		d.state = levels
+	case HalfStep:
+		d.state = halfStep[d.state&0x0f][levels]
+		step = direction(d.state)
+	default:
+		d.state = fullStep[d.state&0x0f][levels]
+		step = direction(d.state)
+	}
+	if step == 0 {
+		return
+	}
+	if d.reverse {
+		step = -step
+	}
+	d.position += step
+
+	now := time.Now()
+	elapsed := now.Sub(d.lastStep)
+	if step != d.dir || elapsed > idleTimeout {
+		d.interval = idleTimeout
+	} else {
+		d.interval = (3*d.interval + elapsed) / 4
+	}
+	d.dir, d.lastStep = step, now
+}
+
+// direction returns the count of a state machine output.
+func direction(state uint8) int32 {
+	switch state & 0x30 {
+	case dirCW:
+		return 1
+	case dirCCW:
+		return -1
+	}
+	return 0
+}
+
+// Position returns the current position in counts.
+func (d *Device) Position() int32 {
+	return d.position
+}
+
+// SetPosition sets the current position.
+func (d *Device) SetPosition(position int32) {
+	d.position, d.read = position, position
+}
+
+// Delta returns the number of counts since the last call to Delta.
+func (d *Device) Delta() int32 {
+	position := d.position
+	delta := position - d.read
+	d.read = position
+	return delta
+}
+
+// Velocity returns the speed of rotation in counts per second, negative
+// when turning counter clockwise, or zero when the encoder stands still.
+// User interfaces can use it to make large changes with fast turns.
+func (d *Device) Velocity() int32 {
+	if d.interval <= 0 || time.Since(d.lastStep) > idleTimeout {
+		return 0
+	}
+	return d.dir * int32(time.Second/d.interval)
+}
+
+// buttonChange is called on every edge of the button pin.
+func (d *Device) buttonChange() {
+	now := time.Now()
+	if now.Sub(d.lastPress) < debounceTime {
+		return
+	}
+	d.lastPress = now
+	pressed := !d.button.Get()
+	if pressed && !d.pressed {
+		d.clicked = true
+	}
+	d.pressed = pressed
+}
+
+// Pressed returns whether the button is held down.
+func (d *Device) Pressed() bool {
+	return d.pressed
+}
+
+// Clicked returns whether the button was pressed since the last call.
+func (d *Device) Clicked() bool {
+	clicked := d.clicked
+	d.clicked = false
+	return clicked
+}
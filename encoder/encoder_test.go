@@ -0,0 +1,64 @@
+package encoder
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+type fakePin struct {
+	level bool
+}
+
+func (p *fakePin) Get() bool     { return p.level }
+func (p *fakePin) Set(high bool) { p.level = high }
+func (p *fakePin) High()         { p.level = true }
+func (p *fakePin) Low()          { p.level = false }
+
+// Levels of the outputs (A<<1 | B) for one quadrature cycle clockwise, A
+// leading B, from both high.
+var cycle = []uint8{1, 0, 2, 3}
+
+func TestDetents(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		mode  Mode
+		idle  uint8 // levels at power-up
+		steps []uint8
+		want  int32
+	}{
+		{"full step", FullStep, 3, cycle, 1},
+		{"full step reverse", FullStep, 3, []uint8{2, 0, 1, 3}, -1},
+		{"half step from high", HalfStep, 3, cycle[:2], 1},
+		{"half step from high, cycle", HalfStep, 3, cycle, 2},
+		{"half step from high reverse", HalfStep, 3, []uint8{2, 0}, -1},
+		{"half step from low", HalfStep, 0, cycle[2:], 1},
+		{"half step from low, cycle", HalfStep, 0, append(cycle[2:], cycle[:2]...), 2},
+		{"half step from low reverse", HalfStep, 0, []uint8{1, 3}, -1},
+		{"quarter step", QuarterStep, 3, cycle, 4},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := qt.New(t)
+			a, b := &fakePin{level: test.idle&2 != 0}, &fakePin{level: test.idle&1 != 0}
+			d := &Device{a: a, b: b}
+			d.start(Config{Mode: test.mode})
+			for _, l := range test.steps {
+				d.Update(l)
+			}
+			c.Assert(d.Position(), qt.Equals, test.want)
+		})
+	}
+}
+
+func TestBounce(t *testing.T) {
+	c := qt.New(t)
+	d := &Device{a: &fakePin{true}, b: &fakePin{true}}
+	d.start(Config{Mode: FullStep, Reverse: true})
+	// contact bounce on A before the cycle completes
+	for _, l := range []uint8{1, 3, 1, 3, 1, 0, 2, 3} {
+		d.Update(l)
+	}
+	c.Assert(d.Position(), qt.Equals, int32(-1))
+	c.Assert(d.Delta(), qt.Equals, int32(-1))
+	c.Assert(d.Delta(), qt.Equals, int32(0))
+}
@@ -0,0 +1,48 @@
+// +build tinygo
+
+package encoder
+
+import "machine"
+
+// New returns a new encoder with outputs on pins a and b, and a push
+// button on pin button, which may be machine.NoPin.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(a, b, button machine.Pin) *Device {
+	d := &Device{a: a, b: b}
+	if button != machine.NoPin {
+		d.button = button
+	}
+	return d
+}
+
+// Configure sets up the pins, with pull-up resistors, and the pin
+// interrupts.
+func (d *Device) Configure(cfg Config) error {
+	a, b := d.a.(machine.Pin), d.b.(machine.Pin)
+	button, hasButton := d.button.(machine.Pin)
+	pins := []machine.Pin{a, b}
+	if hasButton {
+		pins = append(pins, button)
+	}
+	for _, pin := range pins {
+		pin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	}
+	d.start(cfg)
+	for _, pin := range pins {
+		var err error
+		if hasButton && pin == button {
+			err = pin.SetInterrupt(machine.PinToggle, func(machine.Pin) {
+				d.buttonChange()
+			})
+		} else {
+			err = pin.SetInterrupt(machine.PinToggle, func(machine.Pin) {
+				d.Update(d.levels())
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+// Generates a 1kHz sine wave and a sweep from 100Hz to 10kHz with an
+// AD9833, with FSYNC on D5.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/ad9833"
+)
+
+func main() {
+	machine.SPI0.Configure(machine.SPIConfig{
+		Frequency: 4000000,
+		Mode:      2,
+	})
+
+	fsync := machine.D5
+	fsync.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	gen := ad9833.New(machine.SPI0, fsync)
+	if err := gen.Configure(ad9833.Config{}); err != nil {
+		println("ad9833:", err.Error())
+		return
+	}
+
+	for {
+		gen.SetWaveform(ad9833.Sine)
+		gen.SetFrequency(ad9833.Reg0, 1000)
+		gen.SelectFrequency(ad9833.Reg0)
+		time.Sleep(2 * time.Second)
+
+		gen.SetWaveform(ad9833.Triangle)
+		gen.Sweep(100, 10000, 100, 20*time.Millisecond)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"machine"
+	"strconv"
+	"time"
+
+	"tinygo.org/x/drivers/aht20"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+	sensor := aht20.New(machine.I2C0)
+
+	// the sensor is ready 40ms after power on
+	time.Sleep(40 * time.Millisecond)
+	if err := sensor.Configure(); err != nil {
+		println(err.Error())
+		return
+	}
+
+	for {
+		temp, humidity, err := sensor.ReadTemperatureHumidity()
+		if err != nil {
+			println(err.Error())
+		} else {
+			println("Temperature:", strconv.FormatFloat(float64(temp)/1000, 'f', 2, 64), "°C")
+			println("Humidity:", strconv.FormatFloat(float64(humidity)/100, 'f', 2, 64), "%")
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
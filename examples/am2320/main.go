@@ -0,0 +1,24 @@
+// Prints the temperature and the humidity measured by an AM2320.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/am2320"
+	"tinygo.org/x/drivers/dht"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+	sensor := am2320.New(machine.I2C0)
+
+	for {
+		if err := sensor.ReadMeasurements(); err != nil {
+			println("am2320:", err.Error())
+		} else {
+			println("temperature:", sensor.TemperatureFloat(dht.C), "humidity:", sensor.HumidityFloat())
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
@@ -12,6 +12,8 @@ func main() {
 	machine.I2C0.Configure(machine.I2CConfig{})
 	sensor := bmp180.New(machine.I2C0)
 	sensor.Configure()
+	// faster and noisier than the default ULTRAHIGHRESOLUTION
+	sensor.Mode = bmp180.STANDARD
 
 	connected := sensor.Connected()
 	if !connected {
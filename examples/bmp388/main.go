@@ -0,0 +1,42 @@
+package main
+
+import (
+	"machine"
+	"strconv"
+	"time"
+
+	"tinygo.org/x/drivers/bmp388"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+	sensor := bmp388.New(machine.I2C0)
+
+	if !sensor.Connected() {
+		println("BMP388 not detected")
+		return
+	}
+	err := sensor.Configure(bmp388.Config{
+		Temperature: bmp388.SAMPLING_2X,
+		Pressure:    bmp388.SAMPLING_8X,
+		ODR:         bmp388.ODR_25HZ,
+		Filter:      bmp388.FILTER_3,
+	})
+	if err != nil {
+		println(err.Error())
+		return
+	}
+	// pressure at sea level of the local weather report, in milli-pascals
+	sensor.SeaLevelPressure = 101325000
+
+	for {
+		temp, _ := sensor.ReadTemperature()
+		println("Temperature:", strconv.FormatFloat(float64(temp)/1000, 'f', 2, 64), "°C")
+		press, _ := sensor.ReadPressure()
+		println("Pressure:", strconv.FormatFloat(float64(press)/100000, 'f', 2, 64), "hPa")
+		alt, _ := sensor.ReadAltitude()
+		println("Altitude:", strconv.FormatFloat(float64(alt)/1000, 'f', 2, 64), "m")
+
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,38 @@
+// Prints the events of two push buttons connected between D2, D3 and
+// ground.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/button"
+)
+
+func main() {
+	buttons := button.New(machine.D2, machine.D3)
+	buttons.Configure(button.Config{
+		RepeatDelay: time.Second,
+	})
+
+	for {
+		buttons.Update()
+		for {
+			e, ok := buttons.Next()
+			if !ok {
+				break
+			}
+			switch e.Type {
+			case button.Click:
+				println("click", e.Button)
+			case button.DoubleClick:
+				println("double click", e.Button)
+			case button.LongPress:
+				println("long press", e.Button)
+			case button.Repeat:
+				println("repeat", e.Button)
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,50 @@
+// Reads the air quality from a CCS811, compensated with the temperature and
+// the humidity measured by a BME280 on the same bus.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/bme280"
+	"tinygo.org/x/drivers/ccs811"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+	sensor := ccs811.New(machine.I2C0)
+	if err := sensor.Configure(ccs811.Config{Mode: ccs811.MODE_1S}); err != nil {
+		println(err.Error())
+		return
+	}
+	env := bme280.New(machine.I2C0)
+	env.Configure()
+
+	for {
+		time.Sleep(time.Second)
+		temp, err := env.ReadTemperature()
+		if err != nil {
+			println(err.Error())
+			continue
+		}
+		humidity, err := env.ReadHumidity()
+		if err != nil {
+			println(err.Error())
+			continue
+		}
+		if err := sensor.SetEnvironmentalData(humidity, temp); err != nil {
+			println(err.Error())
+			continue
+		}
+		if ready, err := sensor.DataReady(); err != nil || !ready {
+			continue
+		}
+		eco2, tvoc, err := sensor.ReadECO2TVOC()
+		if err != nil {
+			println(err.Error())
+			continue
+		}
+		println("eCO2:", eco2, "ppm")
+		println("TVOC:", tvoc, "ppb")
+	}
+}
@@ -0,0 +1,26 @@
+// Reads 16 potentiometers through a CD74HC4067 connected to A0, with the
+// select pins on D2 to D5.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/cd74hc4067"
+)
+
+func main() {
+	machine.InitADC()
+	adc := machine.ADC{Pin: machine.A0}
+	adc.Configure()
+
+	mux := cd74hc4067.New(adc, nil, machine.D2, machine.D3, machine.D4, machine.D5)
+	mux.Configure(cd74hc4067.Config{})
+
+	for {
+		for i := 0; i < mux.Channels(); i++ {
+			println("channel", i, ":", mux.Channel(i).Get())
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
@@ -0,0 +1,31 @@
+// Logs the measurements of a BME280 as CSV on the UART every 10 seconds, as
+// a minimal weather station.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/bme280"
+	"tinygo.org/x/drivers/datalogger"
+	"tinygo.org/x/drivers/scheduler"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+	sensor := bme280.New(machine.I2C0)
+	sensor.Configure()
+
+	machine.UART0.Configure(machine.UARTConfig{})
+	log := datalogger.New(machine.UART0, datalogger.Config{Interval: 10 * time.Second})
+	log.AddThermometer("temperature", &sensor)
+	log.AddHygrometer("humidity", &sensor)
+	log.AddBarometer("pressure", &sensor)
+
+	s := scheduler.New()
+	s.Add(log.Task())
+	s.OnError = func(name string, err error) {
+		println(name, "error:", err.Error())
+	}
+	s.Run()
+}
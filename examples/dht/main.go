@@ -0,0 +1,34 @@
+// Prints the measurements of a DHT12 connected to I2C. The same sensor can
+// be read with the single-wire protocol on D2 by using the commented line
+// instead.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/dht"
+	"tinygo.org/x/drivers/humidity"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+	sensor := dht.NewI2C(machine.I2C0, dht.DHT12)
+	// sensor := dht.New(machine.D2, dht.DHT12)
+
+	for {
+		result := dht.StartMeasurement(sensor)
+		// other work can be done here while the sensor is read
+		m := <-result
+		if m.Err != nil {
+			println("dht:", m.Err.Error())
+		} else {
+			println("temperature:", m.Temperature, "humidity:", m.Humidity)
+			// the sensor gives tenths of degrees and of a percent
+			dewPoint := humidity.DewPoint(int32(m.Temperature)*100, int32(m.Humidity)*10)
+			println("dew point:", dewPoint/100)
+		}
+		// the sensor measures at most every two seconds
+		time.Sleep(2 * time.Second)
+	}
+}
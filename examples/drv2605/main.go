@@ -0,0 +1,37 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/drv2605"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+
+	haptic := drv2605.New(machine.I2C0)
+	if !haptic.Connected() {
+		println("DRV2605 not detected")
+		return
+	}
+
+	err := haptic.Configure(drv2605.Config{
+		Actuator: drv2605.ERM,
+		Library:  drv2605.LibraryERMA,
+	})
+	if err != nil {
+		println("could not configure DRV2605:", err.Error())
+		return
+	}
+
+	// play all effects of the library, one after another
+	for effect := uint8(1); ; effect++ {
+		if effect > 117 {
+			effect = 1
+		}
+		println("effect", effect)
+		haptic.PlayEffect(effect)
+		time.Sleep(time.Second)
+	}
+}
@@ -10,6 +10,14 @@ func main() {
 	machine.I2C0.Configure(machine.I2CConfig{})
 	rtc := ds1307.New(machine.I2C0)
 	read := make([]byte, 5)
+
+	// keep a boot counter in the last byte of the NVRAM
+	counter := []byte{0}
+	rtc.ReadAt(counter, rtc.Size()-1)
+	counter[0]++
+	rtc.WriteAt(counter, rtc.Size()-1)
+	println("boot number", counter[0])
+
 	for {
 		rtc.Seek(0, 0)
 		_, err := rtc.Write([]byte{1, 2, 3, 4, 5})
@@ -10,7 +10,10 @@ import (
 func main() {
 	machine.I2C0.Configure(machine.I2CConfig{})
 	rtc := ds1307.New(machine.I2C0)
-	rtc.SetTime(time.Date(2019, 5, 15, 20, 34, 12, 0, time.UTC))
+	if !rtc.IsTimeValid() {
+		// first power-up: the clock is halted until the time is set
+		rtc.SetTime(time.Date(2019, 5, 15, 20, 34, 12, 0, time.UTC))
+	}
 
 	for {
 		t, err := rtc.Time()
@@ -0,0 +1,64 @@
+// Reads the DS18B20 sensors connected to D2, which needs a 4.7kΩ pull-up
+// resistor. The conversions of all the sensors run at the same time.
+package main
+
+import (
+	"machine"
+	"strconv"
+	"time"
+
+	"tinygo.org/x/drivers/ds18b20"
+	"tinygo.org/x/drivers/onewire"
+)
+
+func main() {
+	bus := onewire.New(machine.D2)
+	roms := make([]onewire.ROM, 8)
+	n, err := bus.Search(roms)
+	if err != nil {
+		println("search:", err.Error())
+		return
+	}
+
+	var sensors []ds18b20.Device
+	for _, rom := range roms[:n] {
+		if rom.Family() != ds18b20.Family {
+			continue
+		}
+		sensor := ds18b20.New(bus, rom)
+		if err := sensor.Configure(ds18b20.Config{Resolution: ds18b20.RESOLUTION_12BIT}); err != nil {
+			println(err.Error())
+			continue
+		}
+		sensors = append(sensors, sensor)
+	}
+
+	for {
+		for i := range sensors {
+			if err := sensors[i].StartConversion(); err != nil {
+				println(err.Error())
+			}
+			if sensors[i].Parasite() {
+				// the bus stays powered until the end of the conversion
+				temp, err := sensors[i].ReadConverted()
+				printTemperature(i, temp, err)
+			}
+		}
+		time.Sleep(750 * time.Millisecond)
+		for i := range sensors {
+			if !sensors[i].Parasite() {
+				temp, err := sensors[i].ReadConverted()
+				printTemperature(i, temp, err)
+			}
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func printTemperature(i int, temp int32, err error) {
+	if err != nil {
+		println("sensor", i, "error:", err.Error())
+		return
+	}
+	println("sensor", i, "temperature:", strconv.FormatFloat(float64(temp)/1000, 'f', 2, 64), "°C")
+}
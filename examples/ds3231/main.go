@@ -1,4 +1,4 @@
-// Connects to an MAG3110 I2C magnetometer.
+// Connects to a DS3231 I2C real time clock.
 package main
 
 import (
@@ -30,7 +30,16 @@ func main() {
 		}
 	}
 
+	// pull the INT/SQW pin low at the start of every minute
+	rtc.SetAlarm2(time.Time{}, ds3231.AlarmEvery)
+	rtc.SetAlarmInterrupts(ds3231.AlarmFlag_Alarm2)
+
 	for {
+		if flags, _ := rtc.ReadAlarmFlags(); flags&ds3231.AlarmFlag_Alarm2 != 0 {
+			fmt.Println("Alarm: a new minute started")
+			rtc.ClearAlarmFlags(ds3231.AlarmFlag_Alarm2)
+		}
+
 		dt, err := rtc.ReadTime()
 		if err != nil {
 			fmt.Println("Error reading date:", err)
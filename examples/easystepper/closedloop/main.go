@@ -0,0 +1,45 @@
+// Moves a stepper motor back and forth, verifying the position with an
+// AS5600 magnetic angle sensor on the motor shaft.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/easystepper"
+	"tinygo.org/x/drivers/easystepper/closedloop"
+)
+
+const (
+	as5600Address  = 0x36
+	as5600RawAngle = 0x0C
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+
+	motor := easystepper.New(machine.P13, machine.P15, machine.P14, machine.P16, 200, 75)
+	motor.Configure()
+
+	buf := make([]byte, 2)
+	angle := closedloop.NewMultiTurn(func() (uint16, error) {
+		err := machine.I2C0.ReadRegister(as5600Address, as5600RawAngle, buf)
+		return uint16(buf[0]&0x0f)<<8 | uint16(buf[1]), err
+	}, 4096)
+
+	stepper := closedloop.New(&motor, angle, closedloop.Config{
+		StepsPerRevolution:  200,
+		CountsPerRevolution: 4096,
+	})
+
+	for {
+		for _, target := range []int32{400, 0} {
+			err := stepper.MoveToVerified(target)
+			if err != nil {
+				println("move failed:", err.Error())
+			}
+			println("position:", stepper.Position())
+			time.Sleep(time.Second)
+		}
+	}
+}
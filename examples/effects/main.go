@@ -0,0 +1,34 @@
+// Runs a rainbow with a white chase on top of it on a strip of 30 WS2812
+// LEDs, limited to 500mA.
+package main
+
+import (
+	"image/color"
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/effects"
+	"tinygo.org/x/drivers/ws2812"
+)
+
+func main() {
+	neo := machine.D2
+	neo.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	strip := ws2812.New(neo)
+
+	engine := effects.NewEngine(strip, 30, 50)
+	engine.MaxCurrent = 500
+	engine.Add(effects.NewRainbow(10*time.Second), effects.Replace, 255)
+	engine.Add(&effects.Chase{
+		Color:  color.RGBA{255, 255, 255, 255},
+		Period: 2 * time.Second,
+		Width:  2,
+		Tail:   6,
+	}, effects.Add, 160)
+
+	for {
+		engine.Update()
+		// other work can be done here, as Update never blocks
+		time.Sleep(time.Millisecond)
+	}
+}
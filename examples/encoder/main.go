@@ -0,0 +1,30 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/encoder"
+)
+
+func main() {
+	knob := encoder.New(machine.D2, machine.D3, machine.D4)
+	knob.Configure(encoder.Config{Mode: encoder.FullStep})
+
+	value := int32(0)
+	for {
+		if delta := knob.Delta(); delta != 0 {
+			// turning fast changes the value in larger steps
+			if v := knob.Velocity(); v > 10 || v < -10 {
+				delta *= 10
+			}
+			value += delta
+			println("value:", value)
+		}
+		if knob.Clicked() {
+			value = 0
+			println("reset")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
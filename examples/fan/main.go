@@ -0,0 +1,29 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/fan"
+)
+
+func main() {
+	machine.InitPWM()
+
+	f := fan.New(machine.PWM{Pin: machine.D9}, machine.D2)
+	err := f.Configure(fan.Config{MinDuty: 0x2000})
+	if err != nil {
+		println("could not configure fan:", err.Error())
+		return
+	}
+
+	f.SetRPM(1200)
+	for {
+		f.Update()
+		println("rpm:", f.RPM(), "duty:", f.Duty())
+		if f.Stalled() {
+			println("fan stalled!")
+		}
+		time.Sleep(time.Second)
+	}
+}
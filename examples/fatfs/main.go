@@ -0,0 +1,60 @@
+package main
+
+import (
+	"machine"
+	"strconv"
+	"time"
+
+	"tinygo.org/x/drivers/fatfs"
+	"tinygo.org/x/drivers/sdcard"
+)
+
+func main() {
+	time.Sleep(3 * time.Second)
+
+	cs := machine.D10
+	cs.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	sd := sdcard.New(machine.SPI0, cs)
+	cfg := sdcard.Config{
+		SCK: machine.SPI0_SCK_PIN,
+		SDO: machine.SPI0_SDO_PIN,
+		SDI: machine.SPI0_SDI_PIN,
+	}
+	if err := sd.Configure(cfg); err != nil {
+		println("SD card:", err.Error())
+		return
+	}
+	fs, err := fatfs.Mount(sd)
+	if err != nil {
+		println("mount:", err.Error())
+		return
+	}
+	println("FAT", fs.Type())
+
+	list, err := fs.ReadDir("/")
+	if err != nil {
+		println("readdir:", err.Error())
+		return
+	}
+	for _, info := range list {
+		if info.IsDir {
+			println(info.Name + "/")
+		} else {
+			println(info.Name, info.Size)
+		}
+	}
+
+	f, err := fs.OpenFile("log.txt", fatfs.O_WRONLY|fatfs.O_CREATE|fatfs.O_APPEND)
+	if err != nil {
+		println("open:", err.Error())
+		return
+	}
+	for i := 0; ; i++ {
+		f.Write([]byte("uptime " + strconv.Itoa(i) + "s\r\n"))
+		if i%10 == 0 {
+			// make sure the data is on the card in case power is lost
+			f.Sync()
+		}
+		time.Sleep(time.Second)
+	}
+}
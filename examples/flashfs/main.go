@@ -0,0 +1,67 @@
+package main
+
+import (
+	"machine"
+	"strconv"
+	"time"
+
+	"tinygo.org/x/drivers/flash"
+	"tinygo.org/x/drivers/flashfs"
+)
+
+func main() {
+	time.Sleep(3 * time.Second)
+
+	dev := flash.NewSPI(
+		&machine.SPI1,
+		machine.SPI1_SDO_PIN,
+		machine.SPI1_SDI_PIN,
+		machine.SPI1_SCK_PIN,
+		machine.SPI1_CS_PIN,
+	)
+	if err := dev.Configure(&flash.DeviceConfig{Identifier: flash.DefaultDeviceIdentifier}); err != nil {
+		println("flash:", err.Error())
+		return
+	}
+
+	fs, err := flashfs.Mount(dev)
+	if err == flashfs.ErrNotFormatted {
+		println("formatting")
+		if err = flashfs.Format(dev); err == nil {
+			fs, err = flashfs.Mount(dev)
+		}
+	}
+	if err != nil {
+		println("mount:", err.Error())
+		return
+	}
+
+	// count the number of boots in a small file that is replaced atomically
+	boots := 0
+	if f, err := fs.Open("boots"); err == nil {
+		var buf [10]byte
+		n, _ := f.Read(buf[:])
+		boots, _ = strconv.Atoi(string(buf[:n]))
+	}
+	boots++
+	f, err := fs.Create("boots")
+	if err == nil {
+		f.Write([]byte(strconv.Itoa(boots)))
+		err = f.Close()
+	}
+	if err != nil {
+		println("write:", err.Error())
+	}
+	println("boot number", boots)
+
+	list, err := fs.ReadDir("/")
+	if err != nil {
+		println("list:", err.Error())
+		return
+	}
+	for _, info := range list {
+		println(info.Name, info.Size)
+	}
+	free, _ := fs.Free()
+	println("free:", free, "bytes")
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"machine"
+	"strconv"
+	"time"
+
+	"tinygo.org/x/drivers/htu21d"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+	sensor := htu21d.New(machine.I2C0)
+	if err := sensor.Configure(htu21d.Config{Resolution: htu21d.RESOLUTION_RH11_T11}); err != nil {
+		println("HTU21D not detected")
+		return
+	}
+
+	for {
+		temp, humidity, err := sensor.ReadTemperatureHumidity()
+		if err != nil {
+			println(err.Error())
+		} else {
+			println("Temperature:", strconv.FormatFloat(float64(temp)/1000, 'f', 2, 64), "°C")
+			println("Humidity:", strconv.FormatFloat(float64(humidity)/100, 'f', 2, 64), "%")
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
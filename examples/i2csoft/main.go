@@ -0,0 +1,33 @@
+// Reads a BME280 connected to D2 (SCL) and D3 (SDA) through a software I2C
+// bus.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/bme280"
+	"tinygo.org/x/drivers/i2csoft"
+)
+
+func main() {
+	bus := i2csoft.New(machine.D2, machine.D3)
+	bus.Configure(i2csoft.Config{Frequency: 100000})
+	sensor := bme280.New(bus)
+	sensor.Configure()
+
+	if !sensor.Connected() {
+		println("BME280 not detected")
+		return
+	}
+
+	for {
+		temp, err := sensor.ReadTemperature()
+		if err != nil {
+			println("error:", err.Error())
+		} else {
+			println("temperature:", temp/1000, "°C")
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
@@ -0,0 +1,29 @@
+// Prints the position of an analog joystick connected to A0 and A1, with
+// the button on D2.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/joystick"
+)
+
+func main() {
+	machine.InitADC()
+
+	x := machine.ADC{Pin: machine.A0}
+	x.Configure()
+	y := machine.ADC{Pin: machine.A1}
+	y.Configure()
+	stick := joystick.New(x, y, machine.D2)
+	stick.Configure(joystick.Config{
+		Expo: 300,
+	})
+
+	for {
+		x, y := stick.Read()
+		println("x:", x, "y:", y, "button:", stick.Pressed())
+		time.Sleep(100 * time.Millisecond)
+	}
+}
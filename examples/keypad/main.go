@@ -0,0 +1,33 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/keypad"
+)
+
+func main() {
+	keys := keypad.New(
+		[]machine.Pin{machine.D2, machine.D3, machine.D4, machine.D5},
+		[]machine.Pin{machine.D6, machine.D7, machine.D8, machine.D9},
+	)
+	keys.Configure(keypad.Config{
+		RepeatDelay: 500 * time.Millisecond,
+		OnEvent: func(e keypad.Event) {
+			switch e.Type {
+			case keypad.KeyDown:
+				println("down:", string(e.Key))
+			case keypad.KeyRepeat:
+				println("repeat:", string(e.Key))
+			case keypad.KeyUp:
+				println("up:", string(e.Key))
+			}
+		},
+	})
+
+	for {
+		keys.Scan()
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/binary"
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/kvstore"
+	"tinygo.org/x/drivers/mb85rc"
+)
+
+func main() {
+	time.Sleep(3 * time.Second)
+
+	machine.I2C0.Configure(machine.I2CConfig{})
+	fram := mb85rc.New(machine.I2C0)
+	fram.Configure(mb85rc.Config{Model: mb85rc.MB85RC64})
+
+	// 8KB of FRAM used as 32 sectors of 256 bytes
+	store, err := kvstore.Open(kvstore.EEPROM(&fram, 256))
+	if err != nil {
+		println("open:", err.Error())
+		return
+	}
+
+	if !store.Has("name") {
+		store.Set("name", []byte("weather station"))
+	}
+
+	// count the number of boots
+	var buf [32]byte
+	boots := uint32(0)
+	if n, err := store.Get("boots", buf[:]); err == nil && n == 4 {
+		boots = binary.LittleEndian.Uint32(buf[:])
+	}
+	boots++
+	binary.LittleEndian.PutUint32(buf[:], boots)
+	if err := store.Set("boots", buf[:4]); err != nil {
+		println("set:", err.Error())
+	}
+
+	n, _ := store.Get("name", buf[:])
+	println(string(buf[:n]), "boot number", boots)
+	println("keys:", len(store.Keys()))
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/led"
+)
+
+func main() {
+	machine.InitPWM()
+
+	r := led.New(machine.PWM{Pin: machine.D10})
+	g := led.New(machine.PWM{Pin: machine.D11})
+	b := led.New(machine.PWM{Pin: machine.D12})
+	light := led.NewRGB(&r, &g, &b)
+	light.Configure()
+
+	go led.Run(10*time.Millisecond, &light)
+
+	for {
+		// slowly walk around the color wheel
+		for hue := uint16(0); hue < 360; hue += 30 {
+			light.FadeToHSV(hue, 255, 255, 500*time.Millisecond)
+			time.Sleep(time.Second)
+		}
+
+		// three short white flashes
+		light.Blink(led.HSV(0, 0, 255), led.Pattern{
+			On:     100 * time.Millisecond,
+			Off:    200 * time.Millisecond,
+			Repeat: 3,
+		})
+		time.Sleep(time.Second)
+	}
+}
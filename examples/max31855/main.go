@@ -0,0 +1,31 @@
+// Reads a thermocouple with a MAX31855, with CS on D5.
+package main
+
+import (
+	"machine"
+	"strconv"
+	"time"
+
+	"tinygo.org/x/drivers/max31855"
+)
+
+func main() {
+	machine.SPI0.Configure(machine.SPIConfig{
+		Frequency: 4000000,
+		Mode:      0,
+	})
+	cs := machine.D5
+	cs.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	sensor := max31855.New(machine.SPI0, cs)
+
+	for {
+		temp, coldJunction, err := sensor.Read()
+		if err != nil {
+			println(err.Error())
+		} else {
+			println("Thermocouple:", strconv.FormatFloat(float64(temp)/1000, 'f', 2, 64), "°C")
+		}
+		println("Cold junction:", strconv.FormatFloat(float64(coldJunction)/1000, 'f', 2, 64), "°C")
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/mb85rc"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+
+	fram := mb85rc.New(machine.I2C0)
+	fram.Configure(mb85rc.Config{Model: mb85rc.MB85RC256})
+
+	id, err := fram.ReadDeviceID()
+	if err != nil {
+		println("FRAM not found:", err.Error())
+		return
+	}
+	println("manufacturer", id.Manufacturer, "product", id.Product)
+
+	// keep a boot counter in the first two bytes
+	var buf [2]byte
+	fram.ReadAt(buf[:], 0)
+	boots := uint16(buf[0])<<8 | uint16(buf[1]) + 1
+	buf[0], buf[1] = uint8(boots>>8), uint8(boots)
+	fram.WriteAt(buf[:], 0)
+	println("boot number", boots)
+
+	for {
+		time.Sleep(time.Second)
+	}
+}
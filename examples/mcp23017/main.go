@@ -0,0 +1,40 @@
+// Toggles a relay on GPB0 of an MCP23017 with a button on GPA0, using the
+// button package on a pin of the expander.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/button"
+	"tinygo.org/x/drivers/mcp23017"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{Frequency: machine.TWI_FREQ_400KHZ})
+
+	expander := mcp23017.NewI2C(machine.I2C0, mcp23017.Address)
+	if err := expander.Configure(mcp23017.Config{}); err != nil {
+		println("mcp23017:", err.Error())
+		return
+	}
+
+	relay := expander.Pin(8)
+	relay.Configure(mcp23017.Output)
+	input := expander.Pin(0)
+	input.Configure(mcp23017.InputPullup)
+
+	buttons := button.New(input)
+	buttons.Configure(button.Config{DoubleClickTime: -1})
+
+	on := false
+	for {
+		buttons.Update()
+		if e, ok := buttons.Next(); ok && e.Type == button.Click {
+			on = !on
+			relay.Set(on)
+			println("relay:", on)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/mhz19"
+)
+
+func main() {
+	machine.UART1.Configure(machine.UARTConfig{BaudRate: mhz19.BaudRate})
+	sensor := mhz19.New(machine.UART1)
+
+	// the sensor stays indoors, where it never sees fresh air
+	if err := sensor.SetAutoCalibration(false); err != nil {
+		println(err.Error())
+	}
+
+	// the sensor needs 3 minutes to warm up
+	time.Sleep(3 * time.Minute)
+
+	for {
+		co2, err := sensor.ReadCO2()
+		if err != nil {
+			println(err.Error())
+		} else {
+			println("CO2:", co2, "ppm")
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
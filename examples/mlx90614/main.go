@@ -0,0 +1,41 @@
+package main
+
+import (
+	"machine"
+	"strconv"
+	"time"
+
+	"tinygo.org/x/drivers/mlx90614"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{Frequency: 100000})
+	sensor := mlx90614.New(machine.I2C0)
+
+	dual, err := sensor.DualZone()
+	if err != nil {
+		println(err.Error())
+		return
+	}
+
+	for {
+		if temp, err := sensor.ReadAmbientTemperature(); err != nil {
+			println(err.Error())
+		} else {
+			println("Ambient:", strconv.FormatFloat(float64(temp)/1000, 'f', 2, 64), "°C")
+		}
+		if temp, err := sensor.ReadTemperature(); err != nil {
+			println(err.Error())
+		} else {
+			println("Object:", strconv.FormatFloat(float64(temp)/1000, 'f', 2, 64), "°C")
+		}
+		if dual {
+			if temp, err := sensor.ReadObjectTemperature2(); err != nil {
+				println(err.Error())
+			} else {
+				println("Object 2:", strconv.FormatFloat(float64(temp)/1000, 'f', 2, 64), "°C")
+			}
+		}
+		time.Sleep(time.Second)
+	}
+}
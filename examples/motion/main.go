@@ -0,0 +1,32 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/easystepper"
+	"tinygo.org/x/drivers/motion"
+)
+
+func main() {
+	x := easystepper.New(machine.P13, machine.P15, machine.P14, machine.P16, 200, 75)
+	x.Configure()
+	y := easystepper.New(machine.P0, machine.P1, machine.P2, machine.P8, 200, 75)
+	y.Configure()
+
+	planner := motion.New(&x, &y)
+	planner.MaxSpeed = 400
+	planner.Acceleration = 800
+
+	// draw a square with a diagonal
+	for {
+		planner.MoveTo(1000, 0)
+		planner.MoveTo(1000, 1000)
+		planner.MoveTo(0, 1000)
+		planner.MoveTo(0, 0)
+		planner.MoveTo(1000, 1000)
+		planner.MoveTo(0, 0)
+		println("x", planner.Position(0), "y", planner.Position(1))
+		time.Sleep(time.Second)
+	}
+}
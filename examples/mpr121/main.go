@@ -0,0 +1,39 @@
+// Connects to an MPR121 capacitive touch sensor and prints touch events.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/mpr121"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+
+	sensor := mpr121.New(machine.I2C0)
+	err := sensor.Configure(mpr121.Config{})
+	if err != nil {
+		println("mpr121:", err.Error())
+		return
+	}
+	sensor.UseIRQ(machine.D2)
+
+	for {
+		touched, released, err := sensor.Update()
+		if err != nil {
+			println("mpr121:", err.Error())
+		}
+		for i := 0; i < 12; i++ {
+			if touched&(1<<uint(i)) != 0 {
+				filtered, _ := sensor.FilteredData(i)
+				baseline, _ := sensor.Baseline(i)
+				println("touched", i, "filtered", filtered, "baseline", baseline)
+			}
+			if released&(1<<uint(i)) != 0 {
+				println("released", i)
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,34 @@
+// Reads a Wii Nunchuk or Classic Controller.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/nunchuk"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{Frequency: machine.TWI_FREQ_100KHZ})
+
+	ctrl := nunchuk.New(machine.I2C0)
+	if err := ctrl.Configure(); err != nil {
+		println("nunchuk:", err.Error())
+		return
+	}
+
+	for {
+		if err := ctrl.Update(); err != nil {
+			println("nunchuk:", err.Error())
+		}
+		switch ctrl.Kind() {
+		case nunchuk.Nunchuk:
+			s := ctrl.Nunchuk()
+			println("joystick:", s.JoystickX, s.JoystickY, "accel:", s.AccelX, s.AccelY, s.AccelZ, "C:", s.C, "Z:", s.Z)
+		case nunchuk.ClassicController:
+			s := ctrl.Classic()
+			println("left:", s.LeftX, s.LeftY, "right:", s.RightX, s.RightY, "A:", s.Pressed(nunchuk.ButtonA))
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,30 @@
+// Lists the ROM codes of the 1-Wire devices connected to D2, which needs a
+// 4.7kΩ pull-up resistor.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/onewire"
+)
+
+func main() {
+	bus := onewire.New(machine.D2)
+	roms := make([]onewire.ROM, 8)
+
+	for {
+		n, err := bus.Search(roms)
+		if err != nil {
+			println("search:", err.Error())
+		}
+		for _, rom := range roms[:n] {
+			print("family ", rom.Family(), ":")
+			for _, b := range rom {
+				print(" ", b)
+			}
+			println()
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
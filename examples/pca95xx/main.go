@@ -0,0 +1,40 @@
+// Switches a relay on IO1_0 of a PCA9555 with a button on IO0_0, using the
+// INT pin connected to D2.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/pca95xx"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+
+	expander := pca95xx.New(machine.I2C0)
+	if err := expander.Configure(pca95xx.Config{Model: pca95xx.PCA9555}); err != nil {
+		println("pca95xx:", err.Error())
+		return
+	}
+	expander.UseInterrupt(machine.D2)
+
+	relay := expander.Pin(8)
+	relay.Configure(pca95xx.Output)
+	relay.Low()
+	button := expander.Pin(0)
+
+	for {
+		changed, err := expander.Changed()
+		if err != nil {
+			println("pca95xx:", err.Error())
+		}
+		if changed&0x0001 != 0 {
+			// the button connects the pin to ground
+			pressed := !button.Get()
+			relay.Set(pressed)
+			println("relay:", pressed)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
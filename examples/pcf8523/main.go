@@ -0,0 +1,46 @@
+// Connects to a PCF8523 I2C real time clock.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/pcf8523"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+
+	rtc := pcf8523.New(machine.I2C0)
+	rtc.Configure()
+
+	if low, _ := rtc.IsBatteryLow(); low {
+		println("replace the backup battery")
+	}
+
+	if !rtc.IsTimeValid() {
+		rtc.SetTime(time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC))
+	}
+
+	// fire every 10 seconds
+	rtc.StartTimer(10, pcf8523.Timer1Hz, true)
+
+	for {
+		printTime(&rtc)
+		if fired, _ := rtc.TimerFired(); fired {
+			println("timer fired")
+			rtc.ClearTimer()
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func printTime(rtc drivers.RTC) {
+	t, err := rtc.ReadTime()
+	if err != nil {
+		println("could not read time:", err.Error())
+		return
+	}
+	println(t.Format("2006-01-02 15:04:05"))
+}
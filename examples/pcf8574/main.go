@@ -0,0 +1,36 @@
+// Mirrors the levels of the buttons on P4 to P7 of a PCF8574 to the LEDs
+// on P0 to P3, using the INT pin connected to D2.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/pcf8574"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+
+	expander := pcf8574.New(machine.I2C0)
+	if err := expander.Configure(pcf8574.Config{}); err != nil {
+		println("pcf8574:", err.Error())
+		return
+	}
+	expander.UseInterrupt(machine.D2)
+
+	for {
+		changed, err := expander.Changed()
+		if err != nil {
+			println("pcf8574:", err.Error())
+		}
+		if changed&0xF0 != 0 {
+			for i := 0; i < 4; i++ {
+				pressed := !expander.Pin(4 + i).Get()
+				// the LEDs are connected to VCC, so they light up when low
+				expander.Pin(i).Set(!pressed)
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
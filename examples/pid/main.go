@@ -0,0 +1,34 @@
+// Keeps a heater, measured by a thermistor, at 40 °C.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/pid"
+	"tinygo.org/x/drivers/thermistor"
+)
+
+func main() {
+	machine.InitADC()
+	machine.InitPWM()
+
+	sensor := thermistor.New(machine.A0)
+	sensor.Configure()
+
+	heater := pid.NewThermal(&sensor, machine.PWM{Pin: machine.D9}, pid.Gains{
+		Kp: 8000,
+		Ki: 50,
+		Kd: 20000,
+	})
+	heater.Setpoint = 40000
+
+	for {
+		if err := heater.Update(); err != nil {
+			println("could not read temperature:", err.Error())
+		} else {
+			println("temperature:", heater.Temperature(), "duty:", heater.Duty())
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
@@ -0,0 +1,36 @@
+// Measures the particulate matter every 5 minutes with a PMS5003 or a
+// PMS7003, which sleeps between the measurements.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/pmsx003"
+)
+
+func main() {
+	machine.UART1.Configure(machine.UARTConfig{BaudRate: pmsx003.BaudRate})
+	sensor := pmsx003.New(machine.UART1)
+	if err := sensor.SetPassive(true); err != nil {
+		println(err.Error())
+		return
+	}
+
+	for {
+		// the fan runs for 30 seconds before the measurement
+		sensor.Wake()
+		time.Sleep(30 * time.Second)
+		m, err := sensor.Read()
+		if err != nil {
+			println(err.Error())
+		} else {
+			println("PM1.0:", m.Atmospheric.PM1_0, "µg/m³")
+			println("PM2.5:", m.Atmospheric.PM2_5, "µg/m³")
+			println("PM10:", m.Atmospheric.PM10, "µg/m³")
+			println("particles > 0.3µm:", m.Particles[0], "/0.1L")
+		}
+		sensor.Sleep()
+		time.Sleep(5 * time.Minute)
+	}
+}
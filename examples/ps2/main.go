@@ -0,0 +1,37 @@
+// Prints the characters typed on a PS/2 keyboard with the clock line on D2
+// and the data line on D3.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/ps2"
+)
+
+func main() {
+	kbd := ps2.New(machine.D2, machine.D3)
+	if err := kbd.Configure(); err != nil {
+		println("ps2:", err.Error())
+		return
+	}
+	if err := kbd.Reset(); err != nil {
+		println("ps2:", err.Error())
+	}
+
+	for {
+		for {
+			e, ok := kbd.Next()
+			if !ok {
+				break
+			}
+			switch {
+			case e.Char != 0:
+				print(string(e.Char))
+			case e.Pressed && e.Key == ps2.KeyF1:
+				println("\r\nF1 pressed")
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,40 @@
+// Scans the I2C bus and prints the addresses that answer, with the imported
+// drivers that identify the devices.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/registry"
+
+	_ "tinygo.org/x/drivers/adt7410"
+	_ "tinygo.org/x/drivers/bme280"
+	_ "tinygo.org/x/drivers/bmp180"
+	_ "tinygo.org/x/drivers/bmp280"
+	_ "tinygo.org/x/drivers/drv2605"
+	_ "tinygo.org/x/drivers/lis2mdl"
+	_ "tinygo.org/x/drivers/lis3dh"
+	_ "tinygo.org/x/drivers/lsm6ds3"
+	_ "tinygo.org/x/drivers/mb85rc"
+	_ "tinygo.org/x/drivers/mma8653"
+	_ "tinygo.org/x/drivers/mpu6050"
+	_ "tinygo.org/x/drivers/seesaw"
+	_ "tinygo.org/x/drivers/tmp102"
+	_ "tinygo.org/x/drivers/vl53l1x"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+
+	for {
+		results := registry.ScanAll(machine.I2C0)
+		for _, r := range results {
+			println(r.String())
+		}
+		if len(results) == 0 {
+			println("no device found")
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
@@ -0,0 +1,45 @@
+// Controls a heater and a cooler relay that must never be on at the same
+// time, with a minimum off time to protect the compressor of the cooler.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/relay"
+)
+
+func main() {
+	heater := relay.New(machine.D2)
+	heater.Configure(relay.Config{
+		ActiveLow: true,
+		MinOnTime: 10 * time.Second,
+		MaxOnTime: 30 * time.Minute,
+	})
+
+	cooler := relay.New(machine.D3)
+	cooler.Configure(relay.Config{
+		ActiveLow:  true,
+		MinOnTime:  10 * time.Second,
+		MinOffTime: 3 * time.Minute,
+		MaxOnTime:  30 * time.Minute,
+	})
+
+	stages := relay.NewGroup(&heater, &cooler)
+
+	for i := 0; ; i++ {
+		// alternate between heating, idle and cooling
+		err := stages.Select(i%3 - 1)
+		if err != nil {
+			println("could not switch:", err.Error())
+		}
+		println("active stage:", stages.Active())
+
+		for j := 0; j < 60; j++ {
+			heater.Kick()
+			cooler.Kick()
+			stages.Update()
+			time.Sleep(time.Second)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/sdcard"
+)
+
+func main() {
+	time.Sleep(3 * time.Second)
+
+	cs := machine.D10
+	cs.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	sd := sdcard.New(machine.SPI0, cs)
+	cfg := sdcard.Config{
+		SCK: machine.SPI0_SCK_PIN,
+		SDO: machine.SPI0_SDO_PIN,
+		SDI: machine.SPI0_SDI_PIN,
+	}
+	err := sd.Configure(cfg)
+	if err != nil {
+		println("SD card:", err.Error())
+		return
+	}
+	println("SDHC:", sd.SDHC())
+	println("Size:", sd.Size()/1024/1024, "MiB")
+
+	var cid [16]byte
+	if err := sd.ReadCID(cid[:]); err == nil {
+		println("Product:", string(cid[3:8]))
+	}
+
+	buf := make([]byte, sdcard.BlockSize)
+	if err := sd.ReadBlocks(buf, 0); err != nil {
+		println("read:", err.Error())
+		return
+	}
+	// a master boot record ends with the signature 0x55 0xAA
+	println("MBR signature:", buf[510] == 0x55 && buf[511] == 0xAA)
+
+	for {
+		time.Sleep(time.Second)
+	}
+}
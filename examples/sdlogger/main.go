@@ -0,0 +1,61 @@
+// Logs the value of an analog input ten times per second to CSV files on an
+// SD card.
+package main
+
+import (
+	"machine"
+	"strconv"
+	"time"
+
+	"tinygo.org/x/drivers/fatfs"
+	"tinygo.org/x/drivers/sdcard"
+	"tinygo.org/x/drivers/sdlogger"
+)
+
+func main() {
+	time.Sleep(3 * time.Second)
+
+	machine.InitADC()
+	sensor := machine.ADC{Pin: machine.A0}
+	sensor.Configure()
+
+	cs := machine.D10
+	cs.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	sd := sdcard.New(machine.SPI0, cs)
+	cfg := sdcard.Config{
+		SCK: machine.SPI0_SCK_PIN,
+		SDO: machine.SPI0_SDO_PIN,
+		SDI: machine.SPI0_SDI_PIN,
+	}
+	logger := sdlogger.New(func() (*fatfs.FS, error) {
+		if err := sd.Configure(cfg); err != nil {
+			return nil, err
+		}
+		return fatfs.Mount(sd)
+	}, sdlogger.Config{
+		Extension:   "CSV",
+		Header:      []byte("ms,value\n"),
+		MaxFileSize: 1 << 20,
+	})
+
+	// the card is only accessed from this goroutine
+	go func() {
+		for {
+			if err := logger.Update(); err != nil && err != sdlogger.ErrNoCard {
+				println("log:", err.Error())
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}()
+
+	start := time.Now()
+	var line []byte
+	for {
+		line = strconv.AppendInt(line[:0], int64(time.Since(start)/time.Millisecond), 10)
+		line = append(line, ',')
+		line = strconv.AppendUint(line, uint64(sensor.Get()), 10)
+		line = append(line, '\n')
+		logger.Write(line)
+		time.Sleep(100 * time.Millisecond)
+	}
+}
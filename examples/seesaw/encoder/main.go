@@ -0,0 +1,52 @@
+// Changes the color of the NeoPixel of the Adafruit I2C rotary encoder
+// board with the encoder, and turns it off while the button is pressed.
+package main
+
+import (
+	"image/color"
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/seesaw"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+
+	encoder := seesaw.New(machine.I2C0)
+	encoder.Address = seesaw.AddressEncoder
+	if err := encoder.Configure(); err != nil {
+		println("seesaw:", err.Error())
+		return
+	}
+	button := encoder.Pin(24)
+	button.Configure(seesaw.InputPullup)
+	encoder.ConfigureNeoPixel(6, 1)
+
+	for {
+		position, err := encoder.Position(0)
+		if err != nil {
+			println("seesaw:", err.Error())
+		}
+		c := wheel(uint8(position * 4))
+		if !button.Get() {
+			c = color.RGBA{}
+		}
+		encoder.WriteColors([]color.RGBA{c})
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// wheel returns a color of the rainbow.
+func wheel(pos uint8) color.RGBA {
+	switch {
+	case pos < 85:
+		return color.RGBA{R: 255 - pos*3, G: pos * 3}
+	case pos < 170:
+		pos -= 85
+		return color.RGBA{G: 255 - pos*3, B: pos * 3}
+	default:
+		pos -= 170
+		return color.RGBA{R: pos * 3, B: 255 - pos*3}
+	}
+}
@@ -0,0 +1,31 @@
+// Prints the moisture and the temperature measured by the Adafruit STEMMA
+// soil sensor.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/seesaw"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+
+	sensor := seesaw.New(machine.I2C0)
+	sensor.Address = seesaw.AddressSoil
+	if err := sensor.Configure(); err != nil {
+		println("seesaw:", err.Error())
+		return
+	}
+
+	for {
+		moisture, err := sensor.ReadMoisture()
+		if err != nil {
+			println("seesaw:", err.Error())
+		}
+		temp, _ := sensor.ReadTemperature()
+		println("moisture:", moisture, "temperature:", temp/1000, "C")
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,55 @@
+// Measures the air quality with an SGP30 every second, as its baseline
+// compensation requires, compensated with the humidity measured by a BME280
+// every minute, and prints it every 10 seconds.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/bme280"
+	"tinygo.org/x/drivers/scheduler"
+	"tinygo.org/x/drivers/sgp30"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+	sensor := sgp30.New(machine.I2C0)
+	if err := sensor.Configure(); err != nil {
+		println(err.Error())
+		return
+	}
+	env := bme280.New(machine.I2C0)
+	env.Configure()
+
+	s := scheduler.New()
+	s.Add(sgp30.PollTask(&sensor))
+	s.Add(scheduler.Task{
+		Name: "humidity",
+		Poll: func() error {
+			temp, err := env.ReadTemperature()
+			if err != nil {
+				return err
+			}
+			humidity, err := env.ReadHumidity()
+			if err != nil {
+				return err
+			}
+			return sensor.SetAbsoluteHumidity(sgp30.AbsoluteHumidity(humidity, temp))
+		},
+		Interval: time.Minute,
+	})
+	s.Add(scheduler.Task{
+		Name: "print",
+		Poll: func() error {
+			println("eCO2:", sensor.ECO2(), "ppm")
+			println("TVOC:", sensor.TVOC(), "ppb")
+			return nil
+		},
+		Interval: 10 * time.Second,
+	})
+	s.OnError = func(name string, err error) {
+		println(name, "error:", err.Error())
+	}
+	s.Run()
+}
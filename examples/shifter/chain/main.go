@@ -0,0 +1,24 @@
+// Reads 16 limit switches on two chained 74HC165 registers, with the latch
+// on D2, the clock on D3 and the serial output on D4.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/shifter"
+)
+
+func main() {
+	switches := shifter.NewChain(2, machine.D2, machine.D3, machine.D4)
+	switches.Configure()
+	switches.Debounce = 10 * time.Millisecond
+	switches.OnChange = func(pin int, state bool) {
+		println("switch", pin, state)
+	}
+
+	for {
+		switches.Scan()
+		time.Sleep(time.Millisecond)
+	}
+}
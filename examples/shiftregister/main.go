@@ -70,6 +70,14 @@ func main() {
 			pins[p].Low()
 		}
 		delay()
+
+		// Change several pins at once. The outputs only change when the
+		// new state is latched by Flush.
+		d.Set(0, true)
+		d.Set(7, true)
+		d.Flush()
+		delay()
+		d.WriteMask(0x00)
 	}
 }
 
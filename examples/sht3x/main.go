@@ -11,13 +11,20 @@ import (
 func main() {
 	machine.I2C0.Configure(machine.I2CConfig{})
 	sensor := sht3x.New(machine.I2C0)
+	// measure every second in the background, the reads return the last
+	// measurement
+	sensor.Configure(sht3x.Config{Rate: sht3x.PERIODIC_1MPS})
+	time.Sleep(time.Second)
 
 	for {
-		temp, humidity, _ := sensor.ReadTemperatureHumidity()
-		t := fmt.Sprintf("%.2f", float32(temp)/1000)
-		h := fmt.Sprintf("%.2f", float32(humidity)/100)
-		println("Temperature:", t, "°C")
-		println("Humidity", h, "%")
+		if err := sensor.ReadMeasurements(); err != nil {
+			println(err.Error())
+		} else {
+			t := fmt.Sprintf("%.1f", sensor.TemperatureFloat())
+			h := fmt.Sprintf("%.1f", sensor.HumidityFloat())
+			println("Temperature:", t, "°C")
+			println("Humidity", h, "%")
+		}
 		time.Sleep(2 * time.Second)
 	}
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"machine"
+	"strconv"
+	"time"
+
+	"tinygo.org/x/drivers/sht4x"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+	sensor := sht4x.New(machine.I2C0)
+
+	serial, err := sensor.SerialNumber()
+	if err != nil {
+		println("SHT4x not detected")
+		return
+	}
+	println("SHT4x serial number:", serial)
+
+	for {
+		temp, humidity, err := sensor.ReadTemperatureHumidity()
+		if err != nil {
+			println(err.Error())
+		} else {
+			println("Temperature:", strconv.FormatFloat(float64(temp)/1000, 'f', 2, 64), "°C")
+			println("Humidity:", strconv.FormatFloat(float64(humidity)/100, 'f', 2, 64), "%")
+		}
+		// dry the sensor after condensation
+		if humidity > 9500 {
+			sensor.Heat(sht4x.HEATER_200MW_1S)
+			time.Sleep(10 * time.Second)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
@@ -0,0 +1,38 @@
+// Generates 10MHz on CLK2 and a 7.1MHz quadrature signal on CLK0 and CLK1
+// with an Si5351A.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/si5351"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{Frequency: machine.TWI_FREQ_400KHZ})
+
+	clock := si5351.New(machine.I2C0)
+	if err := clock.Configure(si5351.Config{}); err != nil {
+		println("si5351:", err.Error())
+		return
+	}
+
+	if err := clock.SetQuadrature(7100000); err != nil {
+		println("si5351:", err.Error())
+	}
+	if err := clock.SetFrequency(2, si5351.PLLB, 10000000); err != nil {
+		println("si5351:", err.Error())
+	}
+	for output := 0; output < 3; output++ {
+		clock.SetDrive(output, si5351.Drive8mA)
+		clock.SetEnabled(output, true)
+	}
+
+	for {
+		a, _ := clock.Locked(si5351.PLLA)
+		b, _ := clock.Locked(si5351.PLLB)
+		println("PLL A locked:", a, "PLL B locked:", b)
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/si7021"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+	sensor := si7021.New(machine.I2C0)
+
+	sn, err := sensor.SerialNumber()
+	switch {
+	case err != nil:
+		println("no electronic ID, probably a clone")
+	case byte(sn>>24) == si7021.ID_SI7021:
+		println("Si7021, serial number", uint32(sn>>32), uint32(sn))
+	default:
+		println("device ID", byte(sn>>24))
+	}
+
+	for {
+		temp, _ := sensor.ReadTemperatureFloat()
+		humidity, _ := sensor.ReadHumidityFloat()
+		println("Temperature:", temp, "°C")
+		println("Humidity:", humidity, "%")
+		time.Sleep(2 * time.Second)
+	}
+}
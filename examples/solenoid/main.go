@@ -0,0 +1,32 @@
+// Opens an irrigation valve for ten seconds every minute.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/solenoid"
+)
+
+func main() {
+	machine.InitPWM()
+
+	valve := solenoid.New(machine.PWM{Pin: machine.D9})
+	valve.Configure(solenoid.Config{
+		PullInTime: 150 * time.Millisecond,
+		HoldDuty:   0x5000,
+		MaxOnTime:  30 * time.Second,
+	})
+
+	for {
+		println("open")
+		valve.On()
+		for start := time.Now(); time.Since(start) < 10*time.Second; {
+			valve.Update()
+			time.Sleep(10 * time.Millisecond)
+		}
+		println("close")
+		valve.Off()
+		time.Sleep(50 * time.Second)
+	}
+}
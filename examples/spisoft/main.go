@@ -0,0 +1,28 @@
+// Reads the JEDEC ID of a SPI flash chip connected to D2 (SCK), D3 (SDO),
+// D4 (SDI) and D5 (CS) through a software SPI bus.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/spisoft"
+)
+
+func main() {
+	cs := machine.D5
+	cs.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	cs.High()
+
+	bus := spisoft.New(machine.D2, machine.D3, machine.D4)
+	bus.Configure(spisoft.Config{Frequency: 1000000, Mode: 0})
+
+	id := make([]byte, 4)
+	for {
+		cs.Low()
+		bus.Tx([]byte{0x9F, 0, 0, 0}, id)
+		cs.High()
+		println("manufacturer:", id[1], "type:", id[2], "capacity:", id[3])
+		time.Sleep(2 * time.Second)
+	}
+}
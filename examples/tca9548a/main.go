@@ -0,0 +1,37 @@
+// Reads four VL53L1X distance sensors, which all have the same address, on
+// channels 0 to 3 of a TCA9548A multiplexer.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/tca9548a"
+	"tinygo.org/x/drivers/vl53l1x"
+)
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{Frequency: machine.TWI_FREQ_400KHZ})
+
+	mux := tca9548a.New(machine.I2C0)
+	if err := mux.Configure(); err != nil {
+		println("tca9548a:", err.Error())
+		return
+	}
+
+	var sensors [4]vl53l1x.Device
+	for i := range sensors {
+		sensors[i] = vl53l1x.New(mux.Channel(i))
+		if !sensors[i].Configure(true) {
+			println("sensor", i, "not found")
+		}
+		sensors[i].StartContinuous(50)
+	}
+
+	for {
+		for i := range sensors {
+			println("sensor", i, "distance:", sensors[i].Read(true), "mm")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,56 @@
+// Keeps a DS3231 real time clock in sync with a GPS receiver, using its PPS
+// output for sub-millisecond accuracy.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"tinygo.org/x/drivers/ds3231"
+	"tinygo.org/x/drivers/gps"
+	"tinygo.org/x/drivers/timesync"
+)
+
+const ppsPin = machine.D5
+
+func main() {
+	machine.I2C0.Configure(machine.I2CConfig{})
+	rtc := ds3231.New(machine.I2C0)
+	rtc.Configure()
+
+	clock := timesync.New()
+	if rtc.IsTimeValid() {
+		// the RTC provides the time until the GPS has a fix
+		clock.SyncRTC(&rtc)
+	}
+
+	ppsPin.Configure(machine.PinConfig{Mode: machine.PinInputPulldown})
+	ppsPin.SetInterrupt(machine.PinRising, func(machine.Pin) {
+		clock.PPS()
+	})
+
+	machine.UART1.Configure(machine.UARTConfig{BaudRate: 9600})
+	receiver := gps.NewUART(&machine.UART1)
+	parser := gps.NewParser()
+
+	lastUpdate := time.Duration(0)
+	for {
+		s, err := receiver.NextSentence()
+		if err != nil {
+			continue
+		}
+		fix, err := parser.Parse(s)
+		if err != nil || !fix.Valid || fix.Time.Year() < 2000 {
+			// only RMC sentences carry the date
+			continue
+		}
+		clock.SyncGPS(fix.Time)
+
+		// keep the RTC within a few milliseconds, once per hour
+		if lastUpdate == 0 || clock.Monotonic()-lastUpdate > time.Hour {
+			clock.UpdateRTC(&rtc)
+			lastUpdate = clock.Monotonic()
+		}
+		println(clock.Now().Format("2006-01-02 15:04:05.000"), "drift (ppb):", clock.Drift())
+	}
+}
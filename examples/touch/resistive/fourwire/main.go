@@ -12,13 +12,14 @@ import (
 
 var (
 	resistiveTouch = new(resistive.FourWire)
-)
 
-const (
-	Xmin = 750
-	Xmax = 325
-	Ymin = 840
-	Ymax = 240
+	// raw readings at three corners of the 240x320 display, found by
+	// printing the raw touch points. Calibrating on the device works the
+	// same way, by asking the user to touch marks on the display.
+	calibration, _ = touch.NewCalibration(
+		[3]touch.Point{{X: 750, Y: 840}, {X: 325, Y: 840}, {X: 750, Y: 240}},
+		[3]touch.Point{{X: 0, Y: 0}, {X: 240, Y: 0}, {X: 0, Y: 320}},
+	)
 )
 
 func main() {
@@ -30,6 +31,9 @@ func main() {
 		YM: machine.TOUCH_YU, // y-
 		XP: machine.TOUCH_XR, // x+
 		XM: machine.TOUCH_XL, // x-
+
+		ReadSamples: 5,
+		Median:      true,
 	})
 
 	last := touch.Point{}
@@ -41,9 +45,8 @@ func main() {
 		point := resistiveTouch.ReadTouchPoint()
 		touch := touch.Point{}
 		if point.Z>>6 > 100 {
-			touch.X = mapval(point.X>>6, Xmin, Xmax, 0, 240)
-			touch.Y = mapval(point.Y>>6, Ymin, Ymax, 0, 320)
-			touch.Z = point.Z >> 6 / 100
+			point.X, point.Y, point.Z = point.X>>6, point.Y>>6, point.Z>>6/100
+			touch = calibration.Transform(point)
 		} else {
 			touch.X = 0
 			touch.Y = 0
@@ -70,11 +73,6 @@ func main() {
 	}
 }
 
-// based on Arduino's "map" function
-func mapval(x int, inMin int, inMax int, outMin int, outMax int) int {
-	return (x-inMin)*(outMax-outMin)/(inMax-inMin) + outMin
-}
-
 func HandleTouch(touch touch.Point) {
 	println("touch point:", touch.X, touch.Y, touch.Z)
 }
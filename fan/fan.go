@@ -0,0 +1,202 @@
+// Package fan provides a driver for 4-wire PC fans with a PWM speed input
+// and a tachometer output.
+//
+// The PWM output should run at the 25 kHz required by the Intel fan
+// specification. If the PWM implementation supports it (through a
+// SetPeriod(uint64) error method) the frequency is set by Configure,
+// otherwise it has to be set up by the application.
+//
+// Speed is measured by counting tachometer pulses in a pin interrupt. Fans
+// can be driven open loop with SetDuty or closed loop with SetRPM, in which
+// case a PID controller adjusts the duty cycle to hold the requested speed.
+// Update must be called periodically in both cases.
+package fan // import "tinygo.org/x/drivers/fan"
+
+import (
+	"sync/atomic"
+	"time"
+
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/pid"
+)
+
+// period of the standard 25 kHz fan PWM in nanoseconds
+const pwmPeriod = 40000
+
+// periodSetter is implemented by PWM peripherals that can change their
+// frequency.
+type periodSetter interface {
+	SetPeriod(period uint64) error
+}
+
+// Device is a 4-wire fan.
+type Device struct {
+	pwm      drivers.PWM
+	tach     drivers.Pin
+	pulses   uint32 // updated from the tachometer interrupt
+	inverted bool
+	perRev   uint32
+	minDuty  uint16
+	stallFor time.Duration
+
+	duty       uint16
+	rpm        uint32
+	lastUpdate time.Time
+	lastPulses uint32
+	lastSpin   time.Time
+	stalled    bool
+
+	closedLoop bool
+	target     int32
+	controller *pid.Controller
+	now        func() time.Time
+}
+
+// Config contains the settings used by Configure.
+type Config struct {
+	// Inverted must be set when the PWM input of the fan is driven through
+	// an inverting transistor stage.
+	Inverted bool
+
+	// PulsesPerRevolution of the tachometer output, 2 for almost all fans.
+	PulsesPerRevolution uint32
+
+	// MinDuty is the lowest duty cycle at which the fan is expected to
+	// spin. Duty cycles below it are treated as off for stall detection.
+	MinDuty uint16
+
+	// StallTimeout is the time without tachometer pulses, while powered, after
+	// which the fan is reported as stalled. Defaults to 2 seconds.
+	StallTimeout time.Duration
+
+	// Gains of the speed controller used by SetRPM. Reasonable defaults are
+	// used when left at zero.
+	Gains pid.Gains
+}
+
+// New returns a new fan driver given the PWM output and the pin the
+// tachometer is connected to, whose falling edges are counted with a pin
+// interrupt. Use nil or machine.NoPin for 3-pin fans without a tachometer.
+func New(pwm drivers.PWM, tach drivers.Pin) Device {
+	return Device{
+		pwm:  pwm,
+		tach: tach,
+		now:  time.Now,
+	}
+}
+
+// Configure configures the PWM output and the tachometer interrupt and stops
+// the fan.
+func (d *Device) Configure(cfg Config) error {
+	d.inverted = cfg.Inverted
+	d.perRev = cfg.PulsesPerRevolution
+	if d.perRev == 0 {
+		d.perRev = 2
+	}
+	d.minDuty = cfg.MinDuty
+	d.stallFor = cfg.StallTimeout
+	if d.stallFor == 0 {
+		d.stallFor = 2 * time.Second
+	}
+	gains := cfg.Gains
+	if gains == (pid.Gains{}) {
+		// duty per rpm of error
+		gains = pid.Gains{Kp: 10000, Ki: 20000}
+	}
+	d.controller = pid.New(gains, 0, 0xffff)
+
+	d.pwm.Configure()
+	if p, ok := d.pwm.(periodSetter); ok {
+		if err := p.SetPeriod(pwmPeriod); err != nil {
+			return err
+		}
+	}
+	d.SetDuty(0)
+
+	if err := d.configureTach(); err != nil {
+		return err
+	}
+	d.lastUpdate = d.now()
+	d.lastSpin = d.lastUpdate
+	return nil
+}
+
+// SetDuty sets the duty cycle (0-0xffff) of the fan directly, leaving
+// closed-loop mode.
+func (d *Device) SetDuty(duty uint16) {
+	d.closedLoop = false
+	d.write(duty)
+}
+
+// Duty returns the duty cycle currently applied.
+func (d *Device) Duty() uint16 {
+	return d.duty
+}
+
+// SetRPM enables closed-loop mode with the given target speed. A target of
+// 0 stops the fan.
+func (d *Device) SetRPM(rpm uint32) {
+	if rpm == 0 {
+		d.SetDuty(0)
+		return
+	}
+	if !d.closedLoop {
+		d.controller.Reset()
+	}
+	d.closedLoop = true
+	d.target = int32(rpm)
+}
+
+// RPM returns the speed measured by the last call to Update.
+func (d *Device) RPM() uint32 {
+	return d.rpm
+}
+
+// Stalled returns whether the fan is powered but has not produced any
+// tachometer pulses for longer than the stall timeout.
+func (d *Device) Stalled() bool {
+	return d.stalled
+}
+
+// Update measures the fan speed, runs the speed controller in closed-loop
+// mode and performs stall detection. It should be called at a regular
+// interval of about a second; shorter intervals make the speed reading
+// less precise at low speeds.
+func (d *Device) Update() {
+	now := d.now()
+	elapsed := now.Sub(d.lastUpdate)
+	if elapsed <= 0 {
+		return
+	}
+	pulses := atomic.LoadUint32(&d.pulses)
+	count := pulses - d.lastPulses
+	d.lastPulses = pulses
+	d.lastUpdate = now
+
+	d.rpm = uint32(uint64(count) * uint64(time.Minute) / (uint64(d.perRev) * uint64(elapsed)))
+
+	if d.tach != nil {
+		if count > 0 || d.duty <= d.minDuty {
+			d.lastSpin = now
+		}
+		d.stalled = now.Sub(d.lastSpin) > d.stallFor
+	}
+
+	if d.closedLoop {
+		d.write(uint16(d.controller.Update(d.target, int32(d.rpm), elapsed)))
+	}
+}
+
+// pulse counts a falling edge of the tachometer output. It is called from
+// the pin interrupt.
+func (d *Device) pulse() {
+	atomic.AddUint32(&d.pulses, 1)
+}
+
+func (d *Device) write(duty uint16) {
+	d.duty = duty
+	if d.inverted {
+		duty = 0xffff - duty
+	}
+	d.pwm.Set(duty)
+}
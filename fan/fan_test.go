@@ -0,0 +1,142 @@
+package fan
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers/tester"
+)
+
+type fakePWM struct {
+	configured bool
+	period     uint64
+	values     []uint16
+}
+
+func (p *fakePWM) Configure() {
+	p.configured = true
+}
+
+func (p *fakePWM) Set(value uint16) {
+	p.values = append(p.values, value)
+}
+
+func (p *fakePWM) SetPeriod(period uint64) error {
+	p.period = period
+	return nil
+}
+
+// sim runs a fan whose tachometer produces the given number of pulses per
+// second, with Update called every 500ms.
+type sim struct {
+	c     *qt.C
+	d     Device
+	pwm   *fakePWM
+	clock time.Time
+}
+
+func newSim(c *qt.C, cfg Config) *sim {
+	s := &sim{c: c, pwm: &fakePWM{}, clock: time.Unix(0, 0)}
+	s.d = New(s.pwm, &tester.Pin{})
+	s.d.now = func() time.Time { return s.clock }
+	c.Assert(s.d.Configure(cfg), qt.IsNil)
+	return s
+}
+
+func (s *sim) run(d time.Duration, pulsesPerSecond int) {
+	for end := s.clock.Add(d); s.clock.Before(end); {
+		for i := 0; i < pulsesPerSecond/2; i++ {
+			s.d.pulse()
+		}
+		s.clock = s.clock.Add(500 * time.Millisecond)
+		s.d.Update()
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	c := qt.New(t)
+	s := newSim(c, Config{})
+	c.Assert(s.pwm.configured, qt.IsTrue)
+	c.Assert(s.pwm.period, qt.Equals, uint64(pwmPeriod))
+	c.Assert(s.pwm.values, qt.DeepEquals, []uint16{0})
+
+	s.d.SetDuty(0x4000)
+	c.Assert(s.d.Duty(), qt.Equals, uint16(0x4000))
+
+	s = newSim(c, Config{Inverted: true})
+	s.d.SetDuty(0x4000)
+	c.Assert(s.pwm.values, qt.DeepEquals, []uint16{0xffff, 0xbfff})
+	c.Assert(s.d.Duty(), qt.Equals, uint16(0x4000))
+}
+
+func TestRPM(t *testing.T) {
+	c := qt.New(t)
+	s := newSim(c, Config{})
+	s.d.SetDuty(0x8000)
+	s.run(time.Second, 40)
+	c.Assert(s.d.RPM(), qt.Equals, uint32(1200))
+
+	s = newSim(c, Config{PulsesPerRevolution: 4})
+	s.d.SetDuty(0x8000)
+	s.run(time.Second, 40)
+	c.Assert(s.d.RPM(), qt.Equals, uint32(600))
+}
+
+func TestStall(t *testing.T) {
+	c := qt.New(t)
+	s := newSim(c, Config{MinDuty: 0x2000})
+	s.d.SetDuty(0x8000)
+	s.run(time.Second, 40)
+	c.Assert(s.d.Stalled(), qt.IsFalse)
+
+	// powered without pulses for longer than the default 2s
+	s.run(2*time.Second, 0)
+	c.Assert(s.d.Stalled(), qt.IsFalse)
+	s.run(time.Second, 0)
+	c.Assert(s.d.Stalled(), qt.IsTrue)
+
+	s.run(time.Second, 40)
+	c.Assert(s.d.Stalled(), qt.IsFalse)
+
+	// a fan that is not expected to spin does not stall
+	s.d.SetDuty(0x2000)
+	s.run(5*time.Second, 0)
+	c.Assert(s.d.Stalled(), qt.IsFalse)
+	c.Assert(s.d.RPM(), qt.Equals, uint32(0))
+}
+
+func TestNoTachometer(t *testing.T) {
+	c := qt.New(t)
+	d := New(&fakePWM{}, nil)
+	clock := time.Unix(0, 0)
+	d.now = func() time.Time { return clock }
+	c.Assert(d.Configure(Config{}), qt.IsNil)
+	d.SetDuty(0xffff)
+	for i := 0; i < 10; i++ {
+		clock = clock.Add(time.Second)
+		d.Update()
+	}
+	c.Assert(d.Stalled(), qt.IsFalse)
+}
+
+func TestSetRPM(t *testing.T) {
+	c := qt.New(t)
+	s := newSim(c, Config{})
+	s.d.SetRPM(1200)
+	s.run(time.Second, 0)
+	duty := s.d.Duty()
+	c.Assert(duty > 0, qt.IsTrue)
+
+	// too fast: the controller slows the fan down
+	s.run(time.Second, 200)
+	c.Assert(s.d.Duty() < duty, qt.IsTrue)
+
+	s.d.SetDuty(0x1000)
+	s.run(time.Second, 0)
+	c.Assert(s.d.Duty(), qt.Equals, uint16(0x1000))
+
+	s.d.SetRPM(0)
+	c.Assert(s.d.Duty(), qt.Equals, uint16(0))
+}
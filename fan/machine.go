@@ -0,0 +1,21 @@
+// +build tinygo
+
+package fan
+
+import "machine"
+
+// configureTach sets up the tachometer pin, an open collector output, with
+// a pull-up resistor and an interrupt on its falling edges.
+func (d *Device) configureTach() error {
+	if d.tach == machine.NoPin {
+		d.tach = nil
+	}
+	pin, ok := d.tach.(machine.Pin)
+	if !ok {
+		return nil
+	}
+	pin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	return pin.SetInterrupt(machine.PinFalling, func(machine.Pin) {
+		d.pulse()
+	})
+}
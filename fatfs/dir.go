@@ -0,0 +1,451 @@
+package fatfs
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Directory entry attributes.
+const (
+	attrReadOnly  = 0x01
+	attrHidden    = 0x02
+	attrSystem    = 0x04
+	attrVolumeID  = 0x08
+	attrDirectory = 0x10
+	attrArchive   = 0x20
+	attrLongName  = 0x0F
+
+	// flags in the reserved byte used by Windows NT and Linux for names
+	// that are all lower case
+	lowerBase = 0x08
+	lowerExt  = 0x10
+
+	entryFree = 0xE5
+)
+
+// FileInfo describes a file or directory.
+type FileInfo struct {
+	Name    string
+	Size    uint32
+	IsDir   bool
+	ModTime time.Time
+}
+
+// pos is the location of a directory entry on the volume.
+type pos struct {
+	sector uint32
+	off    uint32
+}
+
+// entry is a copy of a raw directory entry.
+type entry [entrySize]byte
+
+func (e *entry) cluster() uint32 {
+	return uint32(binary.LittleEndian.Uint16(e[20:]))<<16 | uint32(binary.LittleEndian.Uint16(e[26:]))
+}
+
+func (e *entry) setCluster(c uint32) {
+	binary.LittleEndian.PutUint16(e[20:], uint16(c>>16))
+	binary.LittleEndian.PutUint16(e[26:], uint16(c))
+}
+
+func (e *entry) size() uint32 {
+	return binary.LittleEndian.Uint32(e[28:])
+}
+
+func (e *entry) isDir() bool {
+	return e[11]&attrDirectory != 0
+}
+
+func (e *entry) info() FileInfo {
+	return FileInfo{
+		Name:    displayName(e),
+		Size:    e.size(),
+		IsDir:   e.isDir(),
+		ModTime: fatTime(binary.LittleEndian.Uint16(e[24:]), binary.LittleEndian.Uint16(e[22:])),
+	}
+}
+
+// shortName converts a file name to the 11 byte space padded form used in
+// directory entries. It also returns the lower case flags to store.
+func shortName(name string) (n [11]byte, flags byte, err error) {
+	for i := range n {
+		n[i] = ' '
+	}
+	if name == "." || name == ".." {
+		copy(n[:], name)
+		return n, 0, nil
+	}
+	base, ext := name, ""
+	for i := len(name) - 1; i >= 0; i-- {
+		if name[i] == '.' {
+			base, ext = name[:i], name[i+1:]
+			break
+		}
+	}
+	if len(base) == 0 || len(base) > 8 || len(ext) > 3 {
+		return n, 0, ErrInvalidName
+	}
+	conv := func(dst []byte, s string, flag byte) error {
+		upper, lower := false, false
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case c >= 'a' && c <= 'z':
+				lower = true
+				c -= 'a' - 'A'
+			case c >= 'A' && c <= 'Z':
+				upper = true
+			case c >= '0' && c <= '9', c >= 0x80:
+			default:
+				if !validSpecial(c) {
+					return ErrInvalidName
+				}
+			}
+			dst[i] = c
+		}
+		if lower && upper {
+			// mixed case cannot be represented without a long name
+			return ErrInvalidName
+		}
+		if lower {
+			flags |= flag
+		}
+		return nil
+	}
+	if err := conv(n[:8], base, lowerBase); err != nil {
+		return n, 0, err
+	}
+	if err := conv(n[8:], ext, lowerExt); err != nil {
+		return n, 0, err
+	}
+	if n[0] == entryFree {
+		// 0xE5 as first character is stored as 0x05
+		n[0] = 0x05
+	}
+	return n, flags, nil
+}
+
+func validSpecial(c byte) bool {
+	switch c {
+	case '!', '#', '$', '%', '&', '\'', '(', ')', '-', '@', '^', '_', '`', '{', '}', '~':
+		return true
+	}
+	return false
+}
+
+// displayName returns the name of an entry as shown to the user.
+func displayName(e *entry) string {
+	var buf [12]byte
+	n := 0
+	for i := 0; i < 8 && e[i] != ' '; i++ {
+		c := e[i]
+		if i == 0 && c == 0x05 {
+			c = entryFree
+		}
+		if e[12]&lowerBase != 0 && c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		buf[n] = c
+		n++
+	}
+	if e[8] != ' ' {
+		buf[n] = '.'
+		n++
+		for i := 8; i < 11 && e[i] != ' '; i++ {
+			c := e[i]
+			if e[12]&lowerExt != 0 && c >= 'A' && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			buf[n] = c
+			n++
+		}
+	}
+	return string(buf[:n])
+}
+
+// walk calls fn with every entry of the directory starting at the given
+// cluster, 0 being the root directory, until fn returns true or the end of
+// the directory is reached. It returns the last cluster visited. fn must
+// not access the volume, as the slice points into the sector cache.
+func (fs *FS) walk(dir uint32, fn func(e []byte, p pos) bool) (uint32, error) {
+	if dir == 0 && fs.typ == FAT32 {
+		dir = fs.rootCluster
+	}
+	cluster := dir
+	for {
+		first, count := fs.rootSector, fs.rootSectors
+		if cluster != 0 {
+			first, count = fs.clusterSector(cluster), fs.sectorsPerCluster
+		}
+		for s := first; s < first+count; s++ {
+			if err := fs.load(&fs.data, s, true); err != nil {
+				return cluster, err
+			}
+			for off := uint32(0); off < sectorSize; off += entrySize {
+				if fn(fs.data.buf[off:off+entrySize], pos{s, off}) {
+					return cluster, nil
+				}
+			}
+		}
+		if cluster == 0 {
+			return 0, nil
+		}
+		next, err := fs.next(cluster)
+		if err != nil {
+			return cluster, err
+		}
+		if next == eoc {
+			return cluster, nil
+		}
+		cluster = next
+	}
+}
+
+// lookup finds the entry with the given short name in a directory.
+func (fs *FS) lookup(dir uint32, name [11]byte) (e entry, p pos, err error) {
+	found := false
+	_, err = fs.walk(dir, func(b []byte, at pos) bool {
+		if b[0] == 0 {
+			return true
+		}
+		if b[0] == entryFree || b[11]&attrLongName == attrLongName || b[11]&attrVolumeID != 0 {
+			return false
+		}
+		if string(b[:11]) == string(name[:]) {
+			copy(e[:], b)
+			p = at
+			found = true
+			return true
+		}
+		return false
+	})
+	if err == nil && !found {
+		err = ErrNotFound
+	}
+	return
+}
+
+// resolve walks a slash separated path and returns the cluster of the
+// parent directory and the short name of the last element. The root
+// directory itself cannot be resolved.
+func (fs *FS) resolve(path string) (dir uint32, name [11]byte, flags byte, err error) {
+	start := 0
+	var last string
+	for i := 0; i <= len(path); i++ {
+		if i < len(path) && path[i] != '/' {
+			continue
+		}
+		part := path[start:i]
+		start = i + 1
+		if part == "" {
+			continue
+		}
+		if last != "" {
+			// descend into the previous element
+			n, _, err := shortName(last)
+			if err != nil {
+				return 0, name, 0, err
+			}
+			e, _, err := fs.lookup(dir, n)
+			if err != nil {
+				return 0, name, 0, err
+			}
+			if !e.isDir() {
+				return 0, name, 0, ErrNotDir
+			}
+			dir = e.cluster()
+		}
+		last = part
+	}
+	if last == "" {
+		return 0, name, 0, ErrInvalidName
+	}
+	name, flags, err = shortName(last)
+	return
+}
+
+// writeEntry stores an entry at the given location.
+func (fs *FS) writeEntry(p pos, e *entry) error {
+	if err := fs.load(&fs.data, p.sector, true); err != nil {
+		return err
+	}
+	copy(fs.data.buf[p.off:], e[:])
+	fs.data.dirty = true
+	return nil
+}
+
+// create adds a new entry to a directory, growing it if needed.
+func (fs *FS) create(dir uint32, name [11]byte, flags, attr byte, cluster uint32) (entry, pos, error) {
+	var e entry
+	var p pos
+	found := false
+	last, err := fs.walk(dir, func(b []byte, at pos) bool {
+		if b[0] == 0 || b[0] == entryFree {
+			p = at
+			found = true
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return e, p, err
+	}
+	if !found {
+		if last == 0 {
+			// the FAT16 root directory has a fixed size
+			return e, p, ErrFull
+		}
+		c, err := fs.alloc(last)
+		if err != nil {
+			return e, p, err
+		}
+		if err := fs.zeroCluster(c); err != nil {
+			return e, p, err
+		}
+		p = pos{fs.clusterSector(c), 0}
+	}
+	copy(e[:11], name[:])
+	e[11] = attr
+	e[12] = flags
+	date, tim := fs.timestamp()
+	binary.LittleEndian.PutUint16(e[14:], tim)
+	binary.LittleEndian.PutUint16(e[16:], date)
+	binary.LittleEndian.PutUint16(e[18:], date)
+	binary.LittleEndian.PutUint16(e[22:], tim)
+	binary.LittleEndian.PutUint16(e[24:], date)
+	e.setCluster(cluster)
+	return e, p, fs.writeEntry(p, &e)
+}
+
+// Mkdir creates a new directory. The parent directory must exist.
+func (fs *FS) Mkdir(path string) error {
+	dir, name, flags, err := fs.resolve(path)
+	if err != nil {
+		return err
+	}
+	if _, _, err := fs.lookup(dir, name); err != ErrNotFound {
+		if err == nil {
+			return ErrExist
+		}
+		return err
+	}
+	c, err := fs.alloc(0)
+	if err != nil {
+		return err
+	}
+	if err := fs.zeroCluster(c); err != nil {
+		return err
+	}
+	e, _, err := fs.create(dir, name, flags, attrDirectory, c)
+	if err != nil {
+		fs.freeChain(c)
+		return err
+	}
+	// every directory but the root starts with the . and .. entries
+	dot := e
+	copy(dot[:11], ".          ")
+	dot[12] = 0
+	if err := fs.writeEntry(pos{fs.clusterSector(c), 0}, &dot); err != nil {
+		return err
+	}
+	dotdot := dot
+	copy(dotdot[:11], "..         ")
+	dotdot.setCluster(dir)
+	if fs.typ == FAT32 && dir == fs.rootCluster {
+		dotdot.setCluster(0)
+	}
+	return fs.writeEntry(pos{fs.clusterSector(c), entrySize}, &dotdot)
+}
+
+// Remove deletes a file or an empty directory.
+func (fs *FS) Remove(path string) error {
+	dir, name, _, err := fs.resolve(path)
+	if err != nil {
+		return err
+	}
+	e, p, err := fs.lookup(dir, name)
+	if err != nil {
+		return err
+	}
+	if e.isDir() {
+		empty := true
+		_, err := fs.walk(e.cluster(), func(b []byte, _ pos) bool {
+			if b[0] == 0 {
+				return true
+			}
+			if b[0] == entryFree || b[0] == '.' || b[11]&attrLongName == attrLongName {
+				return false
+			}
+			empty = false
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if !empty {
+			return ErrNotEmpty
+		}
+	}
+	e[0] = entryFree
+	if err := fs.writeEntry(p, &e); err != nil {
+		return err
+	}
+	return fs.freeChain(e.cluster())
+}
+
+// Stat returns information about a file or directory.
+func (fs *FS) Stat(path string) (FileInfo, error) {
+	dir, name, _, err := fs.resolve(path)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	e, _, err := fs.lookup(dir, name)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return e.info(), nil
+}
+
+// ReadDir lists the contents of a directory, without the . and .. entries.
+// Use "/" or "" for the root directory.
+func (fs *FS) ReadDir(path string) ([]FileInfo, error) {
+	var dir uint32
+	if !isRoot(path) {
+		var e entry
+		parent, name, _, err := fs.resolve(path)
+		if err == nil {
+			e, _, err = fs.lookup(parent, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !e.isDir() {
+			return nil, ErrNotDir
+		}
+		dir = e.cluster()
+	}
+	var list []FileInfo
+	_, err := fs.walk(dir, func(b []byte, _ pos) bool {
+		if b[0] == 0 {
+			return true
+		}
+		if b[0] == entryFree || b[0] == '.' || b[11]&attrLongName == attrLongName || b[11]&attrVolumeID != 0 {
+			return false
+		}
+		var e entry
+		copy(e[:], b)
+		list = append(list, e.info())
+		return false
+	})
+	return list, err
+}
+
+func isRoot(path string) bool {
+	for i := 0; i < len(path); i++ {
+		if path[i] != '/' {
+			return false
+		}
+	}
+	return true
+}
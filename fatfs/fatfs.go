@@ -0,0 +1,401 @@
+// Package fatfs implements the FAT16 and FAT32 filesystems on top of a
+// drivers.BlockDevice such as an SD card, so that files written by a
+// microcontroller can be read on a PC and vice versa.
+//
+// Only short 8.3 file names are supported. Long file name entries created
+// by other systems are skipped, so those files are visible under their short
+// alias (for example LONGFI~1.TXT). Names written in all lower case are
+// shown in lower case by Windows and Linux.
+//
+// Writes are cached: one sector of file data and one sector of the
+// allocation table are kept in memory and only written back when another
+// sector is needed or when Sync or Close is called. Call Sync regularly when
+// logging so that little data is lost on power failure.
+//
+// Specification: https://download.microsoft.com/download/1/6/1/161ba512-40e2-4cc9-843a-923143f3456c/fatgen103.doc
+package fatfs // import "tinygo.org/x/drivers/fatfs"
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	ErrUnsupported = errors.New("fatfs: not a FAT16 or FAT32 volume")
+	ErrNotFound    = errors.New("fatfs: file not found")
+	ErrExist       = errors.New("fatfs: file already exists")
+	ErrNotDir      = errors.New("fatfs: not a directory")
+	ErrIsDir       = errors.New("fatfs: is a directory")
+	ErrNotEmpty    = errors.New("fatfs: directory not empty")
+	ErrInvalidName = errors.New("fatfs: invalid 8.3 file name")
+	ErrFull        = errors.New("fatfs: no space left on volume")
+	ErrPermission  = errors.New("fatfs: operation not permitted by open flags")
+	ErrClosed      = errors.New("fatfs: file already closed")
+	ErrCorrupt     = errors.New("fatfs: corrupt allocation table")
+)
+
+// Type is the FAT variant of a volume.
+type Type uint8
+
+const (
+	FAT16 Type = 16
+	FAT32 Type = 32
+)
+
+const (
+	sectorSize = 512
+	entrySize  = 32
+
+	// eoc is the normalized end of chain marker returned by next.
+	eoc = 0x0FFFFFFF
+)
+
+// cache holds a single sector in memory.
+type cache struct {
+	sector uint32
+	valid  bool
+	dirty  bool
+	buf    [sectorSize]byte
+}
+
+// FS is a mounted FAT volume.
+type FS struct {
+	dev  drivers.BlockDevice
+	base int64 // byte offset of the volume on the device
+	typ  Type
+
+	sectorsPerCluster uint32
+	fatStart          uint32
+	fatSize           uint32
+	numFATs           uint32
+	rootSector        uint32 // FAT16 only
+	rootSectors       uint32 // FAT16 only
+	rootCluster       uint32 // FAT32 only
+	dataSector        uint32
+	clusters          uint32
+	fsInfo            uint32 // FAT32 only, 0 if absent
+
+	hint      uint32 // cluster to start searching for free clusters
+	allocated bool   // clusters have been allocated or freed since mount
+
+	data cache
+	fat  cache
+
+	// Now returns the time used for file modification times. Files are
+	// dated 1980-01-01 if it is nil, for example when the board has no
+	// real-time clock.
+	Now func() time.Time
+}
+
+// Mount opens the FAT volume on the device. The volume can either start at
+// the first sector of the device or be the first FAT partition listed in a
+// master boot record.
+func Mount(dev drivers.BlockDevice) (*FS, error) {
+	fs := &FS{dev: dev}
+	if err := fs.load(&fs.data, 0, true); err != nil {
+		return nil, err
+	}
+	if !validBootSector(fs.data.buf[:]) {
+		// look for a FAT partition in the master boot record
+		if fs.data.buf[510] != 0x55 || fs.data.buf[511] != 0xAA {
+			return nil, ErrUnsupported
+		}
+		found := false
+		for i := 0; i < 4; i++ {
+			p := fs.data.buf[446+16*i:]
+			switch p[4] {
+			case 0x04, 0x06, 0x0B, 0x0C, 0x0E:
+				fs.base = int64(binary.LittleEndian.Uint32(p[8:])) * sectorSize
+				found = true
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return nil, ErrUnsupported
+		}
+		fs.data.valid = false
+		if err := fs.load(&fs.data, 0, true); err != nil {
+			return nil, err
+		}
+		if !validBootSector(fs.data.buf[:]) {
+			return nil, ErrUnsupported
+		}
+	}
+
+	b := fs.data.buf[:]
+	fs.sectorsPerCluster = uint32(b[13])
+	reserved := uint32(binary.LittleEndian.Uint16(b[14:]))
+	fs.numFATs = uint32(b[16])
+	rootEntries := uint32(binary.LittleEndian.Uint16(b[17:]))
+	total := uint32(binary.LittleEndian.Uint16(b[19:]))
+	if total == 0 {
+		total = binary.LittleEndian.Uint32(b[32:])
+	}
+	fs.fatSize = uint32(binary.LittleEndian.Uint16(b[22:]))
+	if fs.fatSize == 0 {
+		fs.fatSize = binary.LittleEndian.Uint32(b[36:])
+	}
+	fs.fatStart = reserved
+	fs.rootSectors = (rootEntries*entrySize + sectorSize - 1) / sectorSize
+	fs.rootSector = reserved + fs.numFATs*fs.fatSize
+	fs.dataSector = fs.rootSector + fs.rootSectors
+	if total <= fs.dataSector {
+		return nil, ErrUnsupported
+	}
+	fs.clusters = (total - fs.dataSector) / fs.sectorsPerCluster
+
+	// the FAT type is determined by the number of clusters only
+	switch {
+	case fs.clusters < 4085:
+		return nil, ErrUnsupported // FAT12
+	case fs.clusters < 65525:
+		fs.typ = FAT16
+	default:
+		fs.typ = FAT32
+		fs.rootCluster = binary.LittleEndian.Uint32(b[44:])
+		fs.fsInfo = uint32(binary.LittleEndian.Uint16(b[48:]))
+	}
+	fs.hint = 2
+	return fs, nil
+}
+
+func validBootSector(b []byte) bool {
+	if b[0] != 0xEB && b[0] != 0xE9 {
+		return false
+	}
+	spc := b[13]
+	return binary.LittleEndian.Uint16(b[11:]) == sectorSize &&
+		spc != 0 && spc&(spc-1) == 0 &&
+		binary.LittleEndian.Uint16(b[14:]) != 0 &&
+		b[16] != 0
+}
+
+// Type returns whether the volume is formatted as FAT16 or FAT32.
+func (fs *FS) Type() Type {
+	return fs.typ
+}
+
+// ClusterSize returns the allocation unit of the volume in bytes.
+func (fs *FS) ClusterSize() uint32 {
+	return fs.sectorsPerCluster * sectorSize
+}
+
+// Sync writes all cached data to the device.
+func (fs *FS) Sync() error {
+	if err := fs.flush(&fs.data); err != nil {
+		return err
+	}
+	if err := fs.flush(&fs.fat); err != nil {
+		return err
+	}
+	if fs.allocated && fs.fsInfo != 0 {
+		// the free cluster count is no longer known, mark it as invalid so
+		// that it is recomputed by the next system that needs it
+		if err := fs.load(&fs.data, fs.fsInfo, true); err != nil {
+			return err
+		}
+		b := fs.data.buf[:]
+		if binary.LittleEndian.Uint32(b[0:]) == 0x41615252 {
+			binary.LittleEndian.PutUint32(b[488:], 0xFFFFFFFF)
+			binary.LittleEndian.PutUint32(b[492:], fs.hint)
+			fs.data.dirty = true
+		}
+		if err := fs.flush(&fs.data); err != nil {
+			return err
+		}
+		fs.allocated = false
+	}
+	return nil
+}
+
+// load makes the cache hold the given sector, writing back the previous
+// one if needed. If read is false the old contents are kept, for callers
+// that overwrite the entire sector.
+func (fs *FS) load(c *cache, sector uint32, read bool) error {
+	if c.valid && c.sector == sector {
+		return nil
+	}
+	if err := fs.flush(c); err != nil {
+		return err
+	}
+	c.valid = false
+	if read {
+		if _, err := fs.dev.ReadAt(c.buf[:], fs.offset(sector)); err != nil {
+			return err
+		}
+	}
+	c.sector = sector
+	c.valid = true
+	return nil
+}
+
+// flush writes a dirty cache back. Allocation table sectors are written to
+// every copy of the table.
+func (fs *FS) flush(c *cache) error {
+	if !c.valid || !c.dirty {
+		return nil
+	}
+	copies := uint32(1)
+	if c == &fs.fat {
+		copies = fs.numFATs
+	}
+	for i := uint32(0); i < copies; i++ {
+		if _, err := fs.dev.WriteAt(c.buf[:], fs.offset(c.sector+i*fs.fatSize)); err != nil {
+			return err
+		}
+	}
+	c.dirty = false
+	return nil
+}
+
+func (fs *FS) offset(sector uint32) int64 {
+	return fs.base + int64(sector)*sectorSize
+}
+
+func (fs *FS) clusterSector(cluster uint32) uint32 {
+	return fs.dataSector + (cluster-2)*fs.sectorsPerCluster
+}
+
+// fatEntry returns the sector and offset within it of the allocation table
+// entry of a cluster.
+func (fs *FS) fatEntry(cluster uint32) (uint32, uint32) {
+	off := cluster * 2
+	if fs.typ == FAT32 {
+		off = cluster * 4
+	}
+	return fs.fatStart + off/sectorSize, off % sectorSize
+}
+
+// next returns the cluster following the given one in its chain, 0 for a
+// free cluster or eoc at the end of the chain.
+func (fs *FS) next(cluster uint32) (uint32, error) {
+	if cluster < 2 || cluster >= fs.clusters+2 {
+		return 0, ErrCorrupt
+	}
+	sector, off := fs.fatEntry(cluster)
+	if err := fs.load(&fs.fat, sector, true); err != nil {
+		return 0, err
+	}
+	var v uint32
+	if fs.typ == FAT16 {
+		v = uint32(binary.LittleEndian.Uint16(fs.fat.buf[off:]))
+		if v >= 0xFFF8 {
+			return eoc, nil
+		}
+		if v == 0xFFF7 {
+			return 0, ErrCorrupt
+		}
+	} else {
+		v = binary.LittleEndian.Uint32(fs.fat.buf[off:]) & 0x0FFFFFFF
+		if v >= 0x0FFFFFF8 {
+			return eoc, nil
+		}
+		if v == 0x0FFFFFF7 {
+			return 0, ErrCorrupt
+		}
+	}
+	if v == 1 || v >= fs.clusters+2 {
+		return 0, ErrCorrupt
+	}
+	return v, nil
+}
+
+// setNext updates the allocation table entry of a cluster.
+func (fs *FS) setNext(cluster, value uint32) error {
+	sector, off := fs.fatEntry(cluster)
+	if err := fs.load(&fs.fat, sector, true); err != nil {
+		return err
+	}
+	if fs.typ == FAT16 {
+		binary.LittleEndian.PutUint16(fs.fat.buf[off:], uint16(value))
+	} else {
+		// the upper 4 bits are reserved and must be preserved
+		old := binary.LittleEndian.Uint32(fs.fat.buf[off:])
+		binary.LittleEndian.PutUint32(fs.fat.buf[off:], old&0xF0000000|value&0x0FFFFFFF)
+	}
+	fs.fat.dirty = true
+	return nil
+}
+
+// alloc allocates a free cluster and appends it to the chain ending in
+// prev, or starts a new chain if prev is 0.
+func (fs *FS) alloc(prev uint32) (uint32, error) {
+	for i := uint32(0); i < fs.clusters; i++ {
+		cluster := 2 + (fs.hint-2+i)%fs.clusters
+		v, err := fs.next(cluster)
+		if err != nil && err != ErrCorrupt {
+			return 0, err
+		}
+		if err != nil || v != 0 {
+			continue
+		}
+		if err := fs.setNext(cluster, eoc); err != nil {
+			return 0, err
+		}
+		if prev != 0 {
+			if err := fs.setNext(prev, cluster); err != nil {
+				return 0, err
+			}
+		}
+		fs.hint = cluster + 1
+		if fs.hint >= fs.clusters+2 {
+			fs.hint = 2
+		}
+		fs.allocated = true
+		return cluster, nil
+	}
+	return 0, ErrFull
+}
+
+// freeChain releases all clusters of a chain.
+func (fs *FS) freeChain(cluster uint32) error {
+	for cluster != 0 && cluster != eoc {
+		next, err := fs.next(cluster)
+		if err != nil {
+			return err
+		}
+		if err := fs.setNext(cluster, 0); err != nil {
+			return err
+		}
+		fs.allocated = true
+		cluster = next
+	}
+	return nil
+}
+
+// zeroCluster fills a cluster with zeros, as needed for new directories.
+func (fs *FS) zeroCluster(cluster uint32) error {
+	first := fs.clusterSector(cluster)
+	for s := first; s < first+fs.sectorsPerCluster; s++ {
+		if err := fs.load(&fs.data, s, false); err != nil {
+			return err
+		}
+		fs.data.buf = [sectorSize]byte{}
+		fs.data.dirty = true
+	}
+	return nil
+}
+
+// timestamp returns the current time in FAT date and time format.
+func (fs *FS) timestamp() (date, tim uint16) {
+	if fs.Now == nil {
+		return 1<<5 | 1, 0
+	}
+	t := fs.Now()
+	if t.Year() < 1980 {
+		return 1<<5 | 1, 0
+	}
+	date = uint16(t.Year()-1980)<<9 | uint16(t.Month())<<5 | uint16(t.Day())
+	tim = uint16(t.Hour())<<11 | uint16(t.Minute())<<5 | uint16(t.Second()/2)
+	return
+}
+
+func fatTime(date, tim uint16) time.Time {
+	return time.Date(int(date>>9)+1980, time.Month(date>>5&0x0F), int(date&0x1F),
+		int(tim>>11), int(tim>>5&0x3F), int(tim&0x1F)*2, 0, time.UTC)
+}
@@ -0,0 +1,250 @@
+package fatfs
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// memDevice is a sparse block device backed by memory, so that volumes
+// large enough for FAT32 can be tested.
+type memDevice struct {
+	size    int64
+	base    int64
+	sectors map[int64]*[sectorSize]byte
+	largest int // largest write
+}
+
+func newMemDevice(size int64) *memDevice {
+	return &memDevice{size: size, sectors: make(map[int64]*[sectorSize]byte)}
+}
+
+// slice returns a view of the device starting at the given byte offset.
+func (m *memDevice) slice(off int64) *memDevice {
+	return &memDevice{size: m.size - off, base: m.base + off, sectors: m.sectors}
+}
+
+func (m *memDevice) ReadAt(buf []byte, off int64) (int, error) {
+	for i := range buf {
+		a := m.base + off + int64(i)
+		if s := m.sectors[a/sectorSize]; s != nil {
+			buf[i] = s[a%sectorSize]
+		} else {
+			buf[i] = 0
+		}
+	}
+	return len(buf), nil
+}
+
+func (m *memDevice) WriteAt(buf []byte, off int64) (int, error) {
+	if len(buf) > m.largest {
+		m.largest = len(buf)
+	}
+	for i, b := range buf {
+		a := m.base + off + int64(i)
+		s := m.sectors[a/sectorSize]
+		if s == nil {
+			s = new([sectorSize]byte)
+			m.sectors[a/sectorSize] = s
+		}
+		s[a%sectorSize] = b
+	}
+	return len(buf), nil
+}
+
+func (m *memDevice) Size() int64                      { return m.size }
+func (m *memDevice) WriteBlockSize() int64            { return sectorSize }
+func (m *memDevice) EraseBlockSize() int64            { return sectorSize }
+func (m *memDevice) EraseBlocks(start, n int64) error { return nil }
+
+func newFS(c *qt.C, size int64, typ Type) (*memDevice, *FS) {
+	dev := newMemDevice(size)
+	c.Assert(Format(dev, "test"), qt.IsNil)
+	fs, err := Mount(dev)
+	c.Assert(err, qt.IsNil)
+	c.Assert(fs.Type(), qt.Equals, typ)
+	return dev, fs
+}
+
+func forEachType(t *testing.T, fn func(c *qt.C, dev *memDevice, fs *FS)) {
+	c := qt.New(t)
+	c.Run("FAT16", func(c *qt.C) {
+		dev, fs := newFS(c, 8<<20, FAT16)
+		fn(c, dev, fs)
+	})
+	c.Run("FAT32", func(c *qt.C) {
+		dev, fs := newFS(c, 600<<20, FAT32)
+		fn(c, dev, fs)
+	})
+}
+
+func TestWriteRead(t *testing.T) {
+	forEachType(t, func(c *qt.C, dev *memDevice, fs *FS) {
+		// larger than a cluster and not sector aligned
+		data := make([]byte, 3*fs.ClusterSize()+123)
+		for i := range data {
+			data[i] = byte(i * 7)
+		}
+		f, err := fs.Create("/data.bin")
+		c.Assert(err, qt.IsNil)
+		for i := 0; i < len(data); i += 100 {
+			end := i + 100
+			if end > len(data) {
+				end = len(data)
+			}
+			_, err := f.Write(data[i:end])
+			c.Assert(err, qt.IsNil)
+		}
+		c.Assert(f.Close(), qt.IsNil)
+
+		// mount again to make sure everything reached the device
+		fs, err = Mount(dev)
+		c.Assert(err, qt.IsNil)
+		f, err = fs.Open("DATA.BIN")
+		c.Assert(err, qt.IsNil)
+		c.Assert(f.Size(), qt.Equals, int64(len(data)))
+		got := make([]byte, len(data)+10)
+		n, err := io.ReadFull(f, got)
+		c.Assert(err, qt.Equals, io.ErrUnexpectedEOF)
+		c.Assert(n, qt.Equals, len(data))
+		c.Assert(bytes.Equal(got[:n], data), qt.IsTrue)
+
+		// random access
+		_, err = f.Seek(int64(fs.ClusterSize())+5, io.SeekStart)
+		c.Assert(err, qt.IsNil)
+		n, err = f.Read(got[:4])
+		c.Assert(err, qt.IsNil)
+		c.Assert(got[:n], qt.DeepEquals, data[fs.ClusterSize()+5:fs.ClusterSize()+9])
+		_, err = f.Write(got[:1])
+		c.Assert(err, qt.Equals, ErrPermission)
+	})
+}
+
+func TestAppend(t *testing.T) {
+	forEachType(t, func(c *qt.C, dev *memDevice, fs *FS) {
+		for i := 0; i < 3; i++ {
+			f, err := fs.OpenFile("log.txt", O_WRONLY|O_CREATE|O_APPEND)
+			c.Assert(err, qt.IsNil)
+			_, err = f.Write([]byte("line\n"))
+			c.Assert(err, qt.IsNil)
+			c.Assert(f.Close(), qt.IsNil)
+		}
+		info, err := fs.Stat("log.txt")
+		c.Assert(err, qt.IsNil)
+		c.Assert(info.Size, qt.Equals, uint32(15))
+		c.Assert(info.Name, qt.Equals, "log.txt")
+
+		f, err := fs.Create("log.txt")
+		c.Assert(err, qt.IsNil)
+		c.Assert(f.Size(), qt.Equals, int64(0))
+		c.Assert(f.Close(), qt.IsNil)
+	})
+}
+
+func TestDirectories(t *testing.T) {
+	forEachType(t, func(c *qt.C, dev *memDevice, fs *FS) {
+		fs.Now = func() time.Time {
+			return time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)
+		}
+		c.Assert(fs.Mkdir("logs"), qt.IsNil)
+		c.Assert(fs.Mkdir("logs"), qt.Equals, ErrExist)
+		c.Assert(fs.Mkdir("logs/2021"), qt.IsNil)
+
+		// enough files to need more than one cluster of entries
+		count := int(fs.ClusterSize()/entrySize) + 3
+		for i := 0; i < count; i++ {
+			name := []byte("logs/2021/F000.TXT")
+			name[11] += byte(i / 100)
+			name[12] += byte(i / 10 % 10)
+			name[13] += byte(i % 10)
+			f, err := fs.Create(string(name))
+			c.Assert(err, qt.IsNil)
+			c.Assert(f.Close(), qt.IsNil)
+		}
+		list, err := fs.ReadDir("/logs/2021")
+		c.Assert(err, qt.IsNil)
+		c.Assert(list, qt.HasLen, count)
+		c.Assert(list[0].Name, qt.Equals, "F000.TXT")
+		c.Assert(list[0].ModTime, qt.Equals, time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC))
+
+		list, err = fs.ReadDir("/")
+		c.Assert(err, qt.IsNil)
+		c.Assert(list, qt.HasLen, 1)
+		c.Assert(list[0].IsDir, qt.IsTrue)
+
+		c.Assert(fs.Remove("logs/2021"), qt.Equals, ErrNotEmpty)
+		_, err = fs.Open("logs/2021/nothere.txt")
+		c.Assert(err, qt.Equals, ErrNotFound)
+		_, err = fs.Create("logs/missing/file.txt")
+		c.Assert(err, qt.Equals, ErrNotFound)
+		_, err = fs.Create("a-long-file-name.txt")
+		c.Assert(err, qt.Equals, ErrInvalidName)
+	})
+}
+
+func TestRemoveFreesClusters(t *testing.T) {
+	forEachType(t, func(c *qt.C, dev *memDevice, fs *FS) {
+		data := make([]byte, 2*fs.ClusterSize())
+		f, err := fs.Create("big.bin")
+		c.Assert(err, qt.IsNil)
+		_, err = f.Write(data)
+		c.Assert(err, qt.IsNil)
+		c.Assert(f.Close(), qt.IsNil)
+		first := f.first
+
+		c.Assert(fs.Remove("big.bin"), qt.IsNil)
+		_, err = fs.Stat("big.bin")
+		c.Assert(err, qt.Equals, ErrNotFound)
+		next, err := fs.next(first)
+		c.Assert(err, qt.IsNil)
+		c.Assert(next, qt.Equals, uint32(0))
+	})
+}
+
+func TestMountPartition(t *testing.T) {
+	c := qt.New(t)
+	const start = 2048
+	dev := newMemDevice(8 << 20)
+	c.Assert(Format(dev.slice(start*sectorSize), ""), qt.IsNil)
+	// master boot record with a single FAT16 LBA partition
+	var mbr [sectorSize]byte
+	p := mbr[446:]
+	p[4] = 0x0E
+	p[8], p[9] = start&0xFF, start>>8
+	mbr[510], mbr[511] = 0x55, 0xAA
+	dev.WriteAt(mbr[:], 0)
+
+	fs, err := Mount(dev)
+	c.Assert(err, qt.IsNil)
+	c.Assert(fs.Type(), qt.Equals, FAT16)
+	f, err := fs.Create("hello.txt")
+	c.Assert(err, qt.IsNil)
+	c.Assert(f.Close(), qt.IsNil)
+	list, err := fs.ReadDir("")
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 1)
+}
+
+func TestMultiSectorWrite(t *testing.T) {
+	forEachType(t, func(c *qt.C, dev *memDevice, fs *FS) {
+		f, err := fs.Create("/data.bin")
+		c.Assert(err, qt.IsNil)
+		data := bytes.Repeat([]byte("0123456789abcdef"), 512)
+		_, err = f.Write(data[:100])
+		c.Assert(err, qt.IsNil)
+		_, err = f.Write(data[100:])
+		c.Assert(err, qt.IsNil)
+		c.Assert(f.Close(), qt.IsNil)
+		c.Assert(dev.largest >= 4*sectorSize, qt.IsTrue, qt.Commentf("largest write %d", dev.largest))
+
+		f, err = fs.Open("/data.bin")
+		c.Assert(err, qt.IsNil)
+		got := make([]byte, len(data))
+		_, err = io.ReadFull(f, got)
+		c.Assert(err, qt.IsNil)
+		c.Assert(got, qt.DeepEquals, data)
+	})
+}
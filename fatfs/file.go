@@ -0,0 +1,331 @@
+package fatfs
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Flags for OpenFile, with the same meaning as in package os.
+const (
+	O_RDONLY = 0x0
+	O_WRONLY = 0x1
+	O_RDWR   = 0x2
+	O_APPEND = 0x400
+	O_CREATE = 0x40
+	O_TRUNC  = 0x200
+	O_EXCL   = 0x80
+
+	accessMode = O_RDONLY | O_WRONLY | O_RDWR
+)
+
+var errSeek = errors.New("fatfs: seek outside of file")
+
+// File is an open file. A file must not be opened more than once at the
+// same time when it is written to.
+type File struct {
+	fs       *FS
+	flag     int
+	entry    entry
+	at       pos // location of the directory entry
+	first    uint32
+	size     uint32
+	offset   uint32
+	cluster  uint32 // cluster containing offset, 0 if not known yet
+	index    uint32 // index of cluster within the chain
+	modified bool
+	closed   bool
+}
+
+// Open opens a file for reading.
+func (fs *FS) Open(path string) (*File, error) {
+	return fs.OpenFile(path, O_RDONLY)
+}
+
+// Create creates a file for writing, truncating it if it already exists.
+func (fs *FS) Create(path string) (*File, error) {
+	return fs.OpenFile(path, O_RDWR|O_CREATE|O_TRUNC)
+}
+
+// OpenFile opens a file with the given O_ flags. The parent directory must
+// exist.
+func (fs *FS) OpenFile(path string, flag int) (*File, error) {
+	dir, name, flags, err := fs.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	e, p, err := fs.lookup(dir, name)
+	switch {
+	case err == ErrNotFound && flag&O_CREATE != 0:
+		e, p, err = fs.create(dir, name, flags, attrArchive, 0)
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case flag&(O_CREATE|O_EXCL) == O_CREATE|O_EXCL:
+		return nil, ErrExist
+	case e.isDir():
+		return nil, ErrIsDir
+	case flag&accessMode != O_RDONLY && e[11]&attrReadOnly != 0:
+		return nil, ErrPermission
+	}
+	f := &File{
+		fs:    fs,
+		flag:  flag,
+		entry: e,
+		at:    p,
+		first: e.cluster(),
+		size:  e.size(),
+	}
+	if flag&O_TRUNC != 0 && flag&accessMode != O_RDONLY && (f.first != 0 || f.size != 0) {
+		if err := fs.freeChain(f.first); err != nil {
+			return nil, err
+		}
+		f.first, f.size = 0, 0
+		f.modified = true
+	}
+	return f, nil
+}
+
+// Size returns the current size of the file in bytes.
+func (f *File) Size() int64 {
+	return int64(f.size)
+}
+
+// Stat returns information about the file.
+func (f *File) Stat() (FileInfo, error) {
+	if f.closed {
+		return FileInfo{}, ErrClosed
+	}
+	f.update()
+	return f.entry.info(), nil
+}
+
+// Read reads up to len(buf) bytes from the file. It returns io.EOF at the
+// end of the file.
+func (f *File) Read(buf []byte) (int, error) {
+	if f.closed {
+		return 0, ErrClosed
+	}
+	if f.flag&accessMode == O_WRONLY {
+		return 0, ErrPermission
+	}
+	n := 0
+	for len(buf) > 0 && f.offset < f.size {
+		sector, off, err := f.locate(false)
+		if err != nil {
+			return n, err
+		}
+		if err := f.fs.load(&f.fs.data, sector, true); err != nil {
+			return n, err
+		}
+		chunk := f.fs.data.buf[off:]
+		if remaining := f.size - f.offset; uint32(len(chunk)) > remaining {
+			chunk = chunk[:remaining]
+		}
+		c := copy(buf, chunk)
+		buf = buf[c:]
+		n += c
+		f.offset += uint32(c)
+	}
+	if n == 0 && len(buf) > 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// Write writes len(buf) bytes at the current offset, or at the end of the
+// file when it was opened with O_APPEND. Data is cached and may not reach
+// the device before Sync or Close is called.
+func (f *File) Write(buf []byte) (int, error) {
+	if f.closed {
+		return 0, ErrClosed
+	}
+	if f.flag&accessMode == O_RDONLY {
+		return 0, ErrPermission
+	}
+	if f.flag&O_APPEND != 0 {
+		f.offset = f.size
+	}
+	if uint64(f.offset)+uint64(len(buf)) > 0xFFFFFFFF {
+		return 0, ErrFull
+	}
+	n := 0
+	for len(buf) > 0 {
+		sector, off, err := f.locate(true)
+		if err != nil {
+			return n, err
+		}
+		if off == 0 && len(buf) >= 2*sectorSize {
+			c, err := f.writeSectors(sector, buf)
+			if err != nil {
+				return n, err
+			}
+			buf = buf[c:]
+			n += c
+			continue
+		}
+		// a sector that is completely overwritten or lies beyond the end of
+		// the file does not need to be read first
+		read := !(off == 0 && (len(buf) >= sectorSize || f.offset >= f.size))
+		if err := f.fs.load(&f.fs.data, sector, read); err != nil {
+			return n, err
+		}
+		c := copy(f.fs.data.buf[off:], buf)
+		f.fs.data.dirty = true
+		buf = buf[c:]
+		n += c
+		f.offset += uint32(c)
+		if f.offset > f.size {
+			f.size = f.offset
+		}
+		f.modified = true
+	}
+	return n, nil
+}
+
+// writeSectors writes whole sectors of buf directly to the device, starting
+// at the given sector at the current offset. The write continues into the
+// next clusters as long as they follow on the device, so that the device can
+// use a multi-block write.
+func (f *File) writeSectors(start uint32, buf []byte) (int, error) {
+	spc := f.fs.sectorsPerCluster
+	whole := uint32(len(buf) / sectorSize)
+	offset := f.offset
+	count := uint32(0)
+	for {
+		left := spc - (offset/sectorSize+count)%spc
+		if left > whole-count {
+			left = whole - count
+		}
+		count += left
+		if count == whole {
+			break
+		}
+		f.offset = offset + count*sectorSize
+		next, _, err := f.locate(true)
+		f.offset = offset
+		if err != nil {
+			return 0, err
+		}
+		if next != start+count {
+			break
+		}
+	}
+	if c := &f.fs.data; c.valid && c.sector >= start && c.sector < start+count {
+		c.valid = false // overwritten anyway
+	}
+	if _, err := f.fs.dev.WriteAt(buf[:count*sectorSize], f.fs.offset(start)); err != nil {
+		return 0, err
+	}
+	f.offset = offset + count*sectorSize
+	if f.offset > f.size {
+		f.size = f.offset
+	}
+	f.modified = true
+	return int(count * sectorSize), nil
+}
+
+// Seek sets the offset for the next Read or Write, relative to the origin
+// of the file for whence io.SeekStart, to the current offset for
+// io.SeekCurrent and to the end for io.SeekEnd. The offset cannot be moved
+// past the end of the file.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, ErrClosed
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += int64(f.offset)
+	case io.SeekEnd:
+		offset += int64(f.size)
+	}
+	if offset < 0 || offset > int64(f.size) {
+		return int64(f.offset), errSeek
+	}
+	f.offset = uint32(offset)
+	return offset, nil
+}
+
+// Sync updates the directory entry of the file and writes all cached data
+// to the device.
+func (f *File) Sync() error {
+	if f.closed {
+		return ErrClosed
+	}
+	if f.modified {
+		f.update()
+		f.entry[11] |= attrArchive
+		if err := f.fs.writeEntry(f.at, &f.entry); err != nil {
+			return err
+		}
+		f.modified = false
+	}
+	return f.fs.Sync()
+}
+
+// Close syncs and closes the file.
+func (f *File) Close() error {
+	if f.closed {
+		return ErrClosed
+	}
+	err := f.Sync()
+	f.closed = true
+	return err
+}
+
+// update stores the current size, first cluster and modification time in
+// the in-memory copy of the directory entry.
+func (f *File) update() {
+	if !f.modified {
+		return
+	}
+	f.entry.setCluster(f.first)
+	binary.LittleEndian.PutUint32(f.entry[28:], f.size)
+	date, tim := f.fs.timestamp()
+	binary.LittleEndian.PutUint16(f.entry[18:], date)
+	binary.LittleEndian.PutUint16(f.entry[22:], tim)
+	binary.LittleEndian.PutUint16(f.entry[24:], date)
+}
+
+// locate returns the sector and the offset within it for the current file
+// offset, following the cluster chain. When alloc is set, clusters are
+// added to the chain as needed.
+func (f *File) locate(alloc bool) (uint32, uint32, error) {
+	fs := f.fs
+	clusterSize := fs.sectorsPerCluster * sectorSize
+	if f.first == 0 {
+		if !alloc {
+			return 0, 0, ErrCorrupt
+		}
+		c, err := fs.alloc(0)
+		if err != nil {
+			return 0, 0, err
+		}
+		f.first = c
+		f.modified = true
+	}
+	want := f.offset / clusterSize
+	if f.cluster == 0 || want < f.index {
+		f.cluster, f.index = f.first, 0
+	}
+	for f.index < want {
+		next, err := fs.next(f.cluster)
+		if err != nil {
+			return 0, 0, err
+		}
+		if next == eoc {
+			if !alloc {
+				return 0, 0, ErrCorrupt
+			}
+			if next, err = fs.alloc(f.cluster); err != nil {
+				return 0, 0, err
+			}
+		}
+		f.cluster = next
+		f.index++
+	}
+	within := f.offset % clusterSize
+	return fs.clusterSector(f.cluster) + within/sectorSize, within % sectorSize, nil
+}
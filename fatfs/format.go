@@ -0,0 +1,179 @@
+package fatfs
+
+import (
+	"encoding/binary"
+
+	"tinygo.org/x/drivers"
+)
+
+// Format creates an empty FAT filesystem spanning the whole device, without
+// a partition table. Devices up to 512MB are formatted as FAT16, larger
+// ones as FAT32, with the cluster sizes recommended by Microsoft. Devices
+// smaller than about 4MB are not supported. The label is truncated to 11
+// characters.
+func Format(dev drivers.BlockDevice, label string) error {
+	size := dev.Size() / sectorSize
+	if size > 0xFFFFFFFF {
+		size = 0xFFFFFFFF
+	}
+	total := uint32(size)
+
+	typ := FAT16
+	var spc uint32
+	switch {
+	case total <= 8400:
+		return ErrUnsupported
+	case total <= 32680:
+		spc = 2
+	case total <= 262144:
+		spc = 4
+	case total <= 524288:
+		spc = 8
+	case total <= 1048576:
+		spc = 16
+	default:
+		typ = FAT32
+		switch {
+		case total <= 16777216:
+			spc = 8
+		case total <= 33554432:
+			spc = 16
+		case total <= 67108864:
+			spc = 32
+		default:
+			spc = 64
+		}
+	}
+
+	reserved, rootEntries := uint32(1), uint32(512)
+	if typ == FAT32 {
+		reserved, rootEntries = 32, 0
+	}
+	rootSectors := (rootEntries*entrySize + sectorSize - 1) / sectorSize
+	tmp1 := total - (reserved + rootSectors)
+	tmp2 := 256*spc + 2
+	if typ == FAT32 {
+		tmp2 /= 2
+	}
+	fatSize := (tmp1 + tmp2 - 1) / tmp2
+
+	var b [sectorSize]byte
+	copy(b[3:], "TINYGO  ")
+	binary.LittleEndian.PutUint16(b[11:], sectorSize)
+	b[13] = byte(spc)
+	binary.LittleEndian.PutUint16(b[14:], uint16(reserved))
+	b[16] = 2
+	binary.LittleEndian.PutUint16(b[17:], uint16(rootEntries))
+	if total < 0x10000 {
+		binary.LittleEndian.PutUint16(b[19:], uint16(total))
+	} else {
+		binary.LittleEndian.PutUint32(b[32:], total)
+	}
+	b[21] = 0xF8 // fixed disk
+	binary.LittleEndian.PutUint16(b[24:], 63)
+	binary.LittleEndian.PutUint16(b[26:], 255)
+
+	var name [11]byte
+	copy(name[:], "NO NAME    ")
+	if label != "" {
+		for i := range name {
+			name[i] = ' '
+		}
+		for i := 0; i < len(label) && i < len(name); i++ {
+			c := label[i]
+			if c >= 'a' && c <= 'z' {
+				c -= 'a' - 'A'
+			}
+			name[i] = c
+		}
+	}
+	serial := 0x54470000 ^ total
+
+	ext := b[36:]
+	if typ == FAT32 {
+		copy(b[:3], []byte{0xEB, 0x58, 0x90})
+		binary.LittleEndian.PutUint32(b[36:], fatSize)
+		binary.LittleEndian.PutUint32(b[44:], 2) // root directory cluster
+		binary.LittleEndian.PutUint16(b[48:], 1) // FSInfo sector
+		binary.LittleEndian.PutUint16(b[50:], 6) // backup boot sector
+		ext = b[64:]
+		copy(ext[18:], "FAT32   ")
+	} else {
+		copy(b[:3], []byte{0xEB, 0x3C, 0x90})
+		binary.LittleEndian.PutUint16(b[22:], uint16(fatSize))
+		copy(ext[18:], "FAT16   ")
+	}
+	ext[0] = 0x80 // drive number
+	ext[2] = 0x29 // extended boot signature
+	binary.LittleEndian.PutUint32(ext[3:], serial)
+	copy(ext[7:], name[:])
+	b[510], b[511] = 0x55, 0xAA
+
+	write := func(sector uint32, buf []byte) error {
+		_, err := dev.WriteAt(buf, int64(sector)*sectorSize)
+		return err
+	}
+	if err := write(0, b[:]); err != nil {
+		return err
+	}
+	var zero [sectorSize]byte
+	if typ == FAT32 {
+		if err := write(6, b[:]); err != nil {
+			return err
+		}
+		var info [sectorSize]byte
+		binary.LittleEndian.PutUint32(info[0:], 0x41615252)
+		binary.LittleEndian.PutUint32(info[484:], 0x61417272)
+		binary.LittleEndian.PutUint32(info[488:], 0xFFFFFFFF)
+		binary.LittleEndian.PutUint32(info[492:], 3)
+		info[510], info[511] = 0x55, 0xAA
+		if err := write(1, info[:]); err != nil {
+			return err
+		}
+		if err := write(7, info[:]); err != nil {
+			return err
+		}
+		for s := uint32(2); s < reserved; s++ {
+			if s != 6 && s != 7 {
+				if err := write(s, zero[:]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// empty allocation tables, with the media descriptor in the first
+	// entries and the root directory cluster allocated on FAT32
+	var first [sectorSize]byte
+	if typ == FAT32 {
+		binary.LittleEndian.PutUint32(first[0:], 0x0FFFFFF8)
+		binary.LittleEndian.PutUint32(first[4:], 0x0FFFFFFF)
+		binary.LittleEndian.PutUint32(first[8:], 0x0FFFFFFF)
+	} else {
+		binary.LittleEndian.PutUint16(first[0:], 0xFFF8)
+		binary.LittleEndian.PutUint16(first[2:], 0xFFFF)
+	}
+	for i := uint32(0); i < 2; i++ {
+		start := reserved + i*fatSize
+		if err := write(start, first[:]); err != nil {
+			return err
+		}
+		for s := start + 1; s < start+fatSize; s++ {
+			if err := write(s, zero[:]); err != nil {
+				return err
+			}
+		}
+	}
+
+	// empty root directory
+	root, count := reserved+2*fatSize, rootSectors
+	if typ == FAT32 {
+		count = spc
+	}
+	for s := root; s < root+count; s++ {
+		if err := write(s, zero[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -140,6 +140,9 @@ func (dev *Device) Configure(config *DeviceConfig) (err error) {
 		}
 	}
 
+	// Use the fast read command if the chip supports it
+	dev.trans.setFastRead(dev.attrs.SupportsFastRead)
+
 	// Enable Quad Mode if available
 	if dev.trans.supportQuadMode() && dev.attrs.QuadEnableBitMask > 0 {
 		// Verify that QSPI mode is enabled.
@@ -288,11 +291,20 @@ func (dev *Device) EraseBlockSize() int64 {
 // supports this. The start and len parameters are in block numbers, use
 // EraseBlockSize to map addresses to blocks.
 func (dev *Device) EraseBlocks(start, len int64) error {
-	// TODO: maybe combine sector erase operations into block erase operations
-	for i := start; i < start+len; i++ {
+	const sectorsPerBlock = BlockSize / SectorSize
+	for i := start; i < start+len; {
+		// use the faster 64KiB block erase for aligned runs of sectors
+		if i%sectorsPerBlock == 0 && start+len-i >= sectorsPerBlock {
+			if err := dev.EraseBlock(uint32(i / sectorsPerBlock)); err != nil {
+				return err
+			}
+			i += sectorsPerBlock
+			continue
+		}
 		if err := dev.EraseSector(uint32(i)); err != nil {
 			return err
 		}
+		i++
 	}
 	return nil
 }
@@ -301,7 +313,8 @@ func (dev *Device) WriteEnable() error {
 	return dev.trans.runCommand(cmdWriteEnable)
 }
 
-// EraseBlock erases a block of memory at the specified index
+// EraseBlock erases a block of memory at the specified index and waits until
+// the erase has completed.
 func (dev *Device) EraseBlock(blockNumber uint32) error {
 	if err := dev.WaitUntilReady(); err != nil {
 		return err
@@ -309,10 +322,14 @@ func (dev *Device) EraseBlock(blockNumber uint32) error {
 	if err := dev.WriteEnable(); err != nil {
 		return err
 	}
-	return dev.trans.eraseCommand(cmdEraseBlock, blockNumber*BlockSize)
+	if err := dev.trans.eraseCommand(cmdEraseBlock, blockNumber*BlockSize); err != nil {
+		return err
+	}
+	return dev.waitUntilReady(blockEraseTimeout)
 }
 
-// EraseSector erases a sector of memory at the given index
+// EraseSector erases a sector of memory at the given index and waits until
+// the erase has completed.
 func (dev *Device) EraseSector(sectorNumber uint32) error {
 	if err := dev.WaitUntilReady(); err != nil {
 		return err
@@ -320,10 +337,14 @@ func (dev *Device) EraseSector(sectorNumber uint32) error {
 	if err := dev.WriteEnable(); err != nil {
 		return err
 	}
-	return dev.trans.eraseCommand(cmdEraseSector, sectorNumber*SectorSize)
+	if err := dev.trans.eraseCommand(cmdEraseSector, sectorNumber*SectorSize); err != nil {
+		return err
+	}
+	return dev.waitUntilReady(sectorEraseTimeout)
 }
 
-// EraseChip erases the entire flash memory chip
+// EraseAll erases the entire flash memory chip and waits until the erase
+// has completed, which can take several minutes on large chips.
 func (dev *Device) EraseAll() error {
 	if err := dev.WaitUntilReady(); err != nil {
 		return err
@@ -331,7 +352,49 @@ func (dev *Device) EraseAll() error {
 	if err := dev.WriteEnable(); err != nil {
 		return err
 	}
-	return dev.trans.runCommand(cmdEraseChip)
+	if err := dev.trans.runCommand(cmdEraseChip); err != nil {
+		return err
+	}
+	return dev.waitUntilReady(chipEraseTimeout)
+}
+
+// Protection returns the block protection bits of status register 1: BP0 to
+// BP2 (or BP3), TB and SEC, masked with ProtectAll. Zero means the whole chip
+// is writable.
+func (dev *Device) Protection() (uint8, error) {
+	s, err := dev.ReadStatus()
+	return s & statusProtectMask, err
+}
+
+// SetProtection sets the block protection bits of status register 1. Which
+// area a combination of bits protects is described in the datasheet of the
+// chip; on W25Q chips ProtectAll protects the whole array and ProtectNone
+// makes it writable again. Writes and erases within a protected area are
+// silently ignored by the chip.
+func (dev *Device) SetProtection(bits uint8) error {
+	s, err := dev.ReadStatus()
+	if err != nil {
+		return err
+	}
+	s = s&^statusProtectMask | bits&statusProtectMask
+	if err := dev.WriteEnable(); err != nil {
+		return err
+	}
+	if dev.attrs.SingleStatusByte || dev.attrs.WriteStatusSplit {
+		err = dev.trans.writeCommand(cmdWriteStatus, []byte{s})
+	} else {
+		// writing only one byte would clear status register 2 on some chips,
+		// including the Quad Enable bit
+		var s2 byte
+		if s2, err = dev.ReadStatus2(); err != nil {
+			return err
+		}
+		err = dev.trans.writeCommand(cmdWriteStatus, []byte{s, s2})
+	}
+	if err != nil {
+		return err
+	}
+	return dev.WaitUntilReady()
 }
 
 // ReadStatus reads the value from status register 1 of the device
@@ -351,7 +414,11 @@ func (dev *Device) ReadStatus2() (status byte, err error) {
 // WaitUntilReady queries the status register until the device is ready for the
 // next operation.
 func (dev *Device) WaitUntilReady() error {
-	expire := time.Now().UnixNano() + int64(1*time.Second)
+	return dev.waitUntilReady(1 * time.Second)
+}
+
+func (dev *Device) waitUntilReady(timeout time.Duration) error {
+	expire := time.Now().UnixNano() + int64(timeout)
 	for s, err := dev.ReadStatus(); (s & 0x03) > 0; s, err = dev.ReadStatus() {
 		if err != nil {
 			return err
@@ -365,6 +432,7 @@ func (dev *Device) WaitUntilReady() error {
 
 const (
 	cmdRead            = 0x03 // read memory using single-bit transfer
+	cmdFastRead        = 0x0B // read memory at higher speed, with 8 dummy cycles
 	cmdQuadRead        = 0x6B // read with 1 line address, 4 line data
 	cmdReadJedecID     = 0x9F // read the JEDEC ID from the device
 	cmdPageProgram     = 0x02 // write a page of memory using single-bit transfer
@@ -382,6 +450,22 @@ const (
 	cmdEraseChip       = 0xC7 // erase the entire chip
 )
 
+const (
+	// ProtectNone disables the block protection, see SetProtection.
+	ProtectNone = 0x00
+
+	// ProtectAll protects the whole memory array on most chips, see
+	// SetProtection.
+	ProtectAll = 0x1C
+
+	statusProtectMask = 0x7C // BP0-BP2, TB (or BP3) and SEC
+
+	// maximum durations of erase operations, from the W25Q128JV datasheet
+	sectorEraseTimeout = 400 * time.Millisecond
+	blockEraseTimeout  = 2 * time.Second
+	chipEraseTimeout   = 200 * time.Second
+)
+
 type Error uint8
 
 const (
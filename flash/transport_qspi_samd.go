@@ -125,6 +125,10 @@ func (q qspiTransport) supportQuadMode() bool {
 	return true
 }
 
+func (q qspiTransport) setFastRead(enabled bool) {
+	// memory is always read with the quad output fast read command
+}
+
 func (q qspiTransport) setClockSpeed(hz uint32) error {
 	// The clock speed for the QSPI peripheral is controlled by a divider, so
 	// we can't set the requested speed exactly. Instead we will increment the
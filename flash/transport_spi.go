@@ -5,6 +5,7 @@ import "machine"
 type transport interface {
 	configure(config *DeviceConfig)
 	supportQuadMode() bool
+	setFastRead(enabled bool)
 	setClockSpeed(hz uint32) (err error)
 	runCommand(cmd byte) (err error)
 	readCommand(cmd byte, rsp []byte) (err error)
@@ -34,6 +35,8 @@ type spiTransport struct {
 	sdi machine.Pin
 	sck machine.Pin
 	ss  machine.Pin
+
+	fastRead bool
 }
 
 func (tr *spiTransport) configure(config *DeviceConfig) {
@@ -45,6 +48,10 @@ func (tr *spiTransport) configure(config *DeviceConfig) {
 	tr.ss.High()
 }
 
+func (tr *spiTransport) setFastRead(enabled bool) {
+	tr.fastRead = enabled
+}
+
 func (tr *spiTransport) setClockSpeed(hz uint32) error {
 	// TODO: un-hardcode this max speed; it is probably a sensible
 	//       default maximum for atsamd and nrf at least
@@ -109,7 +116,15 @@ func (tr *spiTransport) eraseCommand(cmd byte, address uint32) (err error) {
 
 func (tr *spiTransport) readMemory(addr uint32, rsp []byte) (err error) {
 	tr.ss.Low()
-	if err = tr.sendAddress(cmdRead, addr); err == nil {
+	if tr.fastRead {
+		// the fast read command is followed by 8 dummy clock cycles
+		if err = tr.sendAddress(cmdFastRead, addr); err == nil {
+			_, err = tr.spi.Transfer(0xFF)
+		}
+	} else {
+		err = tr.sendAddress(cmdRead, addr)
+	}
+	if err == nil {
 		err = tr.readInto(rsp)
 	}
 	tr.ss.High()
@@ -0,0 +1,394 @@
+package flashfs
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+)
+
+var errSeek = errors.New("flashfs: seek outside of file")
+
+// File is an open file.
+type File struct {
+	fs   *FS
+	path string
+
+	// The contents are inlined in the metadata, or stored in a skip-list of
+	// blocks starting at the last one.
+	ctz    bool
+	inline []byte
+	head   uint32
+	size   uint32
+
+	offset  uint32 // read offset
+	headOff uint32 // end of the data in head, when fresh
+
+	write  bool
+	fresh  bool // head was allocated since opening the file
+	dirty  bool
+	closed bool
+}
+
+// Open opens a file for reading.
+func (fs *FS) Open(path string) (*File, error) {
+	f := &File{fs: fs, path: path}
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Create starts a new, empty version of a file. The previous contents, if
+// any, stay in place until the file is synced or closed, so that a power
+// loss while writing never leaves a half written file behind.
+func (fs *FS) Create(path string) (*File, error) {
+	d, id, found, err := fs.lookup(path)
+	switch {
+	case err != nil:
+		return nil, err
+	case d == nil || found && d.entries[id].isDir():
+		return nil, ErrIsDir
+	}
+	f := &File{fs: fs, path: path, inline: []byte{}, write: true, dirty: true}
+	fs.files = append(fs.files, f)
+	return f, nil
+}
+
+// Append opens a file for appending, creating it if it does not exist.
+func (fs *FS) Append(path string) (*File, error) {
+	f := &File{fs: fs, path: path, write: true}
+	if err := f.load(); err == ErrNotFound {
+		return fs.Create(path)
+	} else if err != nil {
+		return nil, err
+	}
+	fs.files = append(fs.files, f)
+	return f, nil
+}
+
+// load reads the location of the committed contents.
+func (f *File) load() error {
+	d, id, found, err := f.fs.lookup(f.path)
+	switch {
+	case err != nil:
+		return err
+	case !found:
+		return ErrNotFound
+	case d == nil || d.entries[id].isDir():
+		return ErrIsDir
+	}
+	s := d.entries[id].structure
+	switch {
+	case s.tag.type3() == typeCTZ && len(s.data) == 8:
+		f.ctz = true
+		f.head = binary.LittleEndian.Uint32(s.data)
+		f.size = binary.LittleEndian.Uint32(s.data[4:])
+	case s.tag.type3() == typeInline:
+		f.inline = s.data
+		f.size = uint32(len(s.data))
+	default:
+		return ErrCorrupt
+	}
+	return nil
+}
+
+// Size returns the current size of the file in bytes.
+func (f *File) Size() int64 {
+	return int64(f.size)
+}
+
+// Read reads up to len(buf) bytes from the file. It returns io.EOF at the
+// end of the file.
+func (f *File) Read(buf []byte) (int, error) {
+	if f.closed {
+		return 0, ErrClosed
+	}
+	if f.write {
+		return 0, ErrPermission
+	}
+	if len(buf) > 0 && f.offset >= f.size {
+		return 0, io.EOF
+	}
+	if !f.ctz {
+		n := copy(buf, f.inline[f.offset:])
+		f.offset += uint32(n)
+		return n, nil
+	}
+	bs := f.fs.blockSize
+	n := 0
+	for len(buf) > 0 && f.offset < f.size {
+		block, off, err := f.fs.ctzFind(f.head, f.size, f.offset)
+		if err != nil {
+			return n, err
+		}
+		chunk := min(bs-off, f.size-f.offset)
+		if uint32(len(buf)) < chunk {
+			chunk = uint32(len(buf))
+		}
+		if _, err := f.fs.dev.ReadAt(buf[:chunk], int64(block)*int64(bs)+int64(off)); err != nil {
+			return n, err
+		}
+		buf = buf[chunk:]
+		n += int(chunk)
+		f.offset += chunk
+	}
+	return n, nil
+}
+
+// Seek sets the offset for the next Read. The offset cannot be moved past
+// the end of the file.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, ErrClosed
+	}
+	if f.write {
+		return 0, ErrPermission
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += int64(f.offset)
+	case io.SeekEnd:
+		offset += int64(f.size)
+	}
+	if offset < 0 || offset > int64(f.size) {
+		return int64(f.offset), errSeek
+	}
+	f.offset = uint32(offset)
+	return offset, nil
+}
+
+// Write appends data to the file. It is not visible to Open until the file
+// is synced or closed. The data is kept in memory while the file is small
+// enough to be inlined in the metadata.
+func (f *File) Write(buf []byte) (int, error) {
+	if f.closed {
+		return 0, ErrClosed
+	}
+	if !f.write {
+		return 0, ErrPermission
+	}
+	f.fs.pending = f.fs.pending[:0]
+	if !f.ctz {
+		if f.size+uint32(len(buf)) <= f.fs.inlineMax {
+			f.inline = append(f.inline, buf...)
+			f.size += uint32(len(buf))
+			f.dirty = true
+			return len(buf), nil
+		}
+		// move the contents to blocks
+		data := f.inline
+		f.ctz, f.inline, f.size, f.fresh = true, nil, 0, false
+		if _, err := f.writeBlocks(data); err != nil {
+			return 0, err
+		}
+	}
+	return f.writeBlocks(buf)
+}
+
+func (f *File) writeBlocks(buf []byte) (int, error) {
+	bs := f.fs.blockSize
+	n := 0
+	for len(buf) > 0 {
+		if !f.fresh || f.headOff == bs {
+			if err := f.extend(); err != nil {
+				return n, err
+			}
+		}
+		chunk := bs - f.headOff
+		if uint32(len(buf)) < chunk {
+			chunk = uint32(len(buf))
+		}
+		if _, err := f.fs.dev.WriteAt(buf[:chunk], int64(f.head)*int64(bs)+int64(f.headOff)); err != nil {
+			return n, err
+		}
+		buf = buf[chunk:]
+		n += int(chunk)
+		f.size += chunk
+		f.headOff += chunk
+		f.dirty = true
+	}
+	return n, nil
+}
+
+// extend allocates a new head block. When the current head is partially
+// filled with committed data, that data is copied to the new block instead
+// of writing to the committed block. Otherwise, the new block starts with
+// the pointers of the skip-list: block i points to the blocks i-2^k for
+// each 2^k dividing i.
+func (f *File) extend() error {
+	fs := f.fs
+	nb, err := fs.alloc()
+	if err != nil {
+		return err
+	}
+	if err := fs.dev.EraseBlocks(int64(nb), 1); err != nil {
+		return err
+	}
+	bs := int64(fs.blockSize)
+	if f.size == 0 {
+		f.head, f.headOff, f.fresh = nb, 0, true
+		return nil
+	}
+	used := f.size - 1
+	index := fs.ctzIndex(&used)
+	used++
+	if used != fs.blockSize {
+		var buf [64]byte
+		for i := uint32(0); i < used; i += uint32(len(buf)) {
+			chunk := buf[:min(used-i, uint32(len(buf)))]
+			if _, err := fs.dev.ReadAt(chunk, int64(f.head)*bs+int64(i)); err != nil {
+				return err
+			}
+			if _, err := fs.dev.WriteAt(chunk, int64(nb)*bs+int64(i)); err != nil {
+				return err
+			}
+		}
+		f.head, f.headOff, f.fresh = nb, used, true
+		return nil
+	}
+	index++
+	skips := uint32(bits.TrailingZeros32(index)) + 1
+	var ptr [4]byte
+	head := f.head
+	for i := uint32(0); i < skips; i++ {
+		binary.LittleEndian.PutUint32(ptr[:], head)
+		if _, err := fs.dev.WriteAt(ptr[:], int64(nb)*bs+int64(4*i)); err != nil {
+			return err
+		}
+		if i != skips-1 {
+			if _, err := fs.dev.ReadAt(ptr[:], int64(head)*bs+int64(4*i)); err != nil {
+				return err
+			}
+			head = binary.LittleEndian.Uint32(ptr[:])
+		}
+	}
+	f.head, f.headOff, f.fresh = nb, 4*skips, true
+	return nil
+}
+
+// Sync commits the data written so far.
+func (f *File) Sync() error {
+	if f.closed {
+		return ErrClosed
+	}
+	if !f.dirty {
+		return nil
+	}
+	fs := f.fs
+	if err := fs.begin(); err != nil {
+		return err
+	}
+	d, id, found, err := fs.lookup(f.path)
+	switch {
+	case err != nil:
+		return err
+	case d == nil || found && d.entries[id].isDir():
+		return ErrIsDir
+	}
+	var attrs []attr
+	if !found {
+		name := baseName(f.path)
+		attrs = append(attrs,
+			attr{mktag(typeCreate, uint16(id), 0), nil},
+			attr{mktag(typeReg, uint16(id), uint32(len(name))), []byte(name)},
+		)
+	}
+	if f.ctz {
+		s := make([]byte, 8)
+		binary.LittleEndian.PutUint32(s, f.head)
+		binary.LittleEndian.PutUint32(s[4:], f.size)
+		attrs = append(attrs, attr{mktag(typeCTZ, uint16(id), 8), s})
+	} else {
+		data := append([]byte(nil), f.inline...)
+		attrs = append(attrs, attr{mktag(typeInline, uint16(id), f.size), data})
+	}
+	if err := fs.commit(d, attrs...); err != nil {
+		return err
+	}
+	f.dirty = false
+	return nil
+}
+
+// Close syncs and closes the file. The blocks written for a file that could
+// not be committed are freed.
+func (f *File) Close() error {
+	if f.closed {
+		return ErrClosed
+	}
+	err := f.Sync()
+	if f.write {
+		files := f.fs.files[:0]
+		for _, o := range f.fs.files {
+			if o != f {
+				files = append(files, o)
+			}
+		}
+		f.fs.files = files
+	}
+	f.closed = true
+	return err
+}
+
+// ctzIndex returns the index in the skip-list of the block holding a
+// position of a file, and changes the position to the offset in that block.
+func (fs *FS) ctzIndex(off *uint32) uint32 {
+	size := *off
+	b := fs.blockSize - 2*4
+	i := size / b
+	if i == 0 {
+		return 0
+	}
+	i = (size - 4*(uint32(bits.OnesCount32(i-1))+2)) / b
+	*off = size - b*i - 4*uint32(bits.OnesCount32(i))
+	return i
+}
+
+// ctzFind returns the block and the offset in that block of a position of a
+// file, following the longest pointers from the head.
+func (fs *FS) ctzFind(head, size, pos uint32) (uint32, uint32, error) {
+	last := size - 1
+	current := fs.ctzIndex(&last)
+	target := fs.ctzIndex(&pos)
+	var ptr [4]byte
+	for current > target {
+		skip := uint32(bits.Len32(current-target)) - 1
+		if z := uint32(bits.TrailingZeros32(current)); z < skip {
+			skip = z
+		}
+		if head >= fs.count {
+			return 0, 0, ErrCorrupt
+		}
+		if _, err := fs.dev.ReadAt(ptr[:], int64(head)*int64(fs.blockSize)+int64(4*skip)); err != nil {
+			return 0, 0, err
+		}
+		head = binary.LittleEndian.Uint32(ptr[:])
+		current -= 1 << skip
+	}
+	if head >= fs.count {
+		return 0, 0, ErrCorrupt
+	}
+	return head, pos, nil
+}
+
+// markCTZ marks the blocks of a skip-list as in use.
+func (fs *FS) markCTZ(head, size uint32) error {
+	if size == 0 {
+		return nil
+	}
+	last := size - 1
+	var ptr [4]byte
+	for index := fs.ctzIndex(&last); ; index-- {
+		if head >= fs.count {
+			return ErrCorrupt
+		}
+		fs.mark(head)
+		if index == 0 {
+			return nil
+		}
+		if _, err := fs.dev.ReadAt(ptr[:], int64(head)*int64(fs.blockSize)); err != nil {
+			return err
+		}
+		head = binary.LittleEndian.Uint32(ptr[:])
+	}
+}
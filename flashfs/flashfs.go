@@ -0,0 +1,755 @@
+// Package flashfs implements the LittleFS filesystem for NOR flash memory
+// such as the W25Q and other chips supported by the flash package, intended
+// for configuration files and logs.
+//
+// The on-disk format is version 2.0 of LittleFS, which is described in its
+// SPEC.md, so that images written by the littlefs tools or by other firmware
+// can be mounted, and the other way around:
+//
+//   - The superblock and the root directory are stored in the metadata pair
+//     of blocks 0 and 1. Each directory is a list of metadata pairs, and all
+//     of them are linked in a single list for traversals.
+//   - A metadata pair is a log of commits, each one checked by a CRC, which
+//     is compacted into the other block of the pair when full. A commit is
+//     only valid once completely written, so after a power loss the
+//     filesystem always mounts with the last committed state. The operations
+//     that need several commits record their progress in the global state,
+//     and are completed before the next change.
+//   - Small files are inlined in the metadata. The data of the others is
+//     stored in skip-lists of blocks, which are copy-on-write: committed
+//     data is never overwritten, and changes become visible with an atomic
+//     commit when the file is synced or closed.
+//   - Blocks are allocated in turn over the whole chip, starting at a
+//     pseudo-random position at mount, which spreads erases evenly. The
+//     metadata pairs stay in place.
+//
+// Files can be read at any offset, but are only written sequentially:
+// Create starts a new version of a file that replaces the old one on Sync,
+// and Append adds to its end. User attributes written by other
+// implementations are kept, but cannot be accessed.
+package flashfs // import "tinygo.org/x/drivers/flashfs"
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	ErrNotFormatted = errors.New("flashfs: no valid filesystem found")
+	ErrIncompatible = errors.New("flashfs: unsupported version or geometry")
+	ErrNotFound     = errors.New("flashfs: file not found")
+	ErrExist        = errors.New("flashfs: file already exists")
+	ErrNotDir       = errors.New("flashfs: not a directory")
+	ErrIsDir        = errors.New("flashfs: is a directory")
+	ErrNotEmpty     = errors.New("flashfs: directory not empty")
+	ErrFull         = errors.New("flashfs: no space left")
+	ErrNameTooLong  = errors.New("flashfs: file name too long")
+	ErrPermission   = errors.New("flashfs: operation not permitted")
+	ErrClosed       = errors.New("flashfs: file already closed")
+	ErrCorrupt      = errors.New("flashfs: corrupt metadata")
+)
+
+const (
+	magic          = "littlefs"
+	version        = 0x00020000 // 2.0
+	nameMax        = 255
+	fileMax        = 0x7FFFFFFF
+	attrMax        = 1022
+	superblockSize = 24
+)
+
+var errStop = errors.New("stop")
+
+// FileInfo describes a file or a directory.
+type FileInfo struct {
+	Name  string
+	Size  uint32
+	IsDir bool
+}
+
+// FS is a mounted filesystem.
+type FS struct {
+	dev       drivers.BlockDevice
+	blockSize uint32
+	progSize  uint32
+	count     uint32 // number of blocks
+	nameMax   uint32
+	inlineMax uint32 // size up to which files are inlined
+	root      [2]uint32
+	buf       []byte // a block
+
+	gstate gstate // global state
+	gdisk  gstate // global state on disk
+	gdelta gstate // global state of dropped metadata pairs
+	seed   uint32
+
+	used    []byte   // bitmap of blocks in use, as of the last scan
+	cursor  uint32   // next block to try when allocating
+	pending []uint32 // blocks allocated by the current operation
+	files   []*File  // files open for writing
+}
+
+func newFS(dev drivers.BlockDevice) (*FS, error) {
+	fs := &FS{
+		dev:       dev,
+		blockSize: uint32(dev.EraseBlockSize()),
+		progSize:  uint32(dev.WriteBlockSize()),
+		nameMax:   nameMax,
+	}
+	fs.count = uint32(dev.Size() / int64(fs.blockSize))
+	if fs.progSize == 0 || fs.progSize > fs.blockSize {
+		fs.progSize = 1
+	}
+	if fs.count < 2 || fs.blockSize < 128 {
+		return nil, ErrIncompatible
+	}
+	fs.inlineMax = min(attrMax, fs.blockSize/8)
+	fs.buf = make([]byte, fs.blockSize)
+	return fs, nil
+}
+
+// Format erases the metadata blocks and writes an empty filesystem. All
+// files are lost.
+func Format(dev drivers.BlockDevice) error {
+	fs, err := newFS(dev)
+	if err != nil {
+		return err
+	}
+	if err := dev.EraseBlocks(0, 2); err != nil {
+		return err
+	}
+	sb := make([]byte, superblockSize)
+	for i, v := range [...]uint32{version, fs.blockSize, fs.count, nameMax, fileMax, attrMax} {
+		binary.LittleEndian.PutUint32(sb[4*i:], v)
+	}
+	root := &mdir{
+		pair: [2]uint32{0, 1},
+		rev:  1,
+		tail: nullPair,
+		entries: []entry{{
+			name:      attr{mktag(typeSuperblock, 0, uint32(len(magic))), []byte(magic)},
+			structure: attr{mktag(typeInline, 0, superblockSize), sb},
+		}},
+	}
+	return fs.write(root)
+}
+
+// Mount opens the filesystem on the device. ErrNotFormatted is returned if
+// the device does not contain a filesystem, in which case Format must be
+// called first.
+func Mount(dev drivers.BlockDevice) (*FS, error) {
+	fs, err := newFS(dev)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	err = fs.each(func(d *mdir) error {
+		for i := range d.entries {
+			e := &d.entries[i]
+			if e.name.tag.type3() == typeSuperblock && string(e.name.data) == magic {
+				if err := fs.superblock(e.structure); err != nil {
+					return err
+				}
+				fs.root = d.pair
+				found = true
+			}
+		}
+		fs.gstate = fs.gstate.xor(d.gstate)
+		return nil
+	})
+	if err == ErrCorrupt && !found {
+		return nil, ErrNotFormatted
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFormatted
+	}
+	if !fs.gstate.tag.valid() {
+		// orphans to look for
+		fs.gstate.tag++
+	}
+	fs.gdisk = fs.gstate
+	fs.used = make([]byte, (fs.count+7)/8)
+	fs.cursor = fs.seed % fs.count
+	if err := fs.scan(); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// superblock checks the superblock against the device and applies its
+// limits.
+func (fs *FS) superblock(s attr) error {
+	if s.tag.type3() != typeInline || len(s.data) < superblockSize {
+		return ErrCorrupt
+	}
+	var v [6]uint32
+	for i := range v {
+		v[i] = binary.LittleEndian.Uint32(s.data[4*i:])
+	}
+	if v[0]>>16 != version>>16 || v[0]&0xFFFF > 1 {
+		return ErrIncompatible
+	}
+	if v[1] != fs.blockSize || v[2] < 2 || v[2] > fs.count {
+		return ErrIncompatible
+	}
+	fs.count = v[2]
+	if v[3] != 0 && v[3] < fs.nameMax {
+		fs.nameMax = v[3]
+	}
+	if v[5] != 0 && v[5] < fs.inlineMax {
+		fs.inlineMax = v[5]
+	}
+	return nil
+}
+
+// each calls fn for every metadata pair, in the order of the list linking
+// them.
+func (fs *FS) each(fn func(d *mdir) error) error {
+	pair := [2]uint32{0, 1}
+	for n := uint32(0); pair != nullPair; n++ {
+		if n > fs.count/2 {
+			// the list loops
+			return ErrCorrupt
+		}
+		d, err := fs.fetch(pair)
+		if err != nil {
+			return err
+		}
+		if err := fn(d); err != nil {
+			if err == errStop {
+				return nil
+			}
+			return err
+		}
+		pair = d.tail
+	}
+	return nil
+}
+
+// pred returns the metadata pair whose tail is pair, or nil.
+func (fs *FS) pred(pair [2]uint32) (*mdir, error) {
+	var pred *mdir
+	err := fs.each(func(d *mdir) error {
+		if pairOverlap(d.tail, pair) {
+			pred = d
+			return errStop
+		}
+		return nil
+	})
+	return pred, err
+}
+
+// parent returns the metadata pair and the id of the directory whose first
+// metadata pair is pair, or nil.
+func (fs *FS) parent(pair [2]uint32) (*mdir, int, error) {
+	var parent *mdir
+	id := 0
+	err := fs.each(func(d *mdir) error {
+		for i := range d.entries {
+			if p, ok := d.entries[i].pair(); ok && pairOverlap(p, pair) {
+				parent, id = d, i
+				return errStop
+			}
+		}
+		return nil
+	})
+	return parent, id, err
+}
+
+// visible returns whether an entry exists, which is not the case of the
+// source of an incomplete move.
+func (fs *FS) visible(d *mdir, id int) bool {
+	return d.entries[id].visible() && !(fs.gdisk.hasMoveHere(d.pair) && id == int(fs.gdisk.tag.id()))
+}
+
+// begin starts an operation that changes the filesystem, by completing the
+// pending ones first.
+func (fs *FS) begin() error {
+	fs.pending = fs.pending[:0]
+	if fs.gdisk.hasMove() {
+		// delete the source of the move
+		d, err := fs.fetch(fs.gdisk.pair)
+		if err != nil {
+			return err
+		}
+		id := fs.gdisk.tag.id()
+		fs.gstate.tag &^= mktag(0x7FF, noID, 0)
+		fs.gstate.pair = [2]uint32{}
+		if err := fs.commit(d, attr{mktag(typeDelete, id, 0), nil}); err != nil {
+			return err
+		}
+	}
+	if fs.gstate.orphans() > 0 {
+		return fs.deorphan()
+	}
+	return nil
+}
+
+// prepOrphans changes the number of orphans in the global state, which is
+// written with the next commit.
+func (fs *FS) prepOrphans(n int) {
+	t := fs.gstate.tag + tag(n)
+	t &^= 0x80000000
+	if t.size() > 0 {
+		t |= 0x80000000
+	}
+	fs.gstate.tag = t
+}
+
+// deorphan fixes the metadata pairs left in the list, after a power loss,
+// by a removed directory, or by a directory that was being moved to another
+// metadata pair.
+func (fs *FS) deorphan() error {
+	pdir := &mdir{split: true, tail: [2]uint32{0, 1}}
+	for n := uint32(0); pdir.tail != nullPair; n++ {
+		if n > fs.count {
+			return ErrCorrupt
+		}
+		d, err := fs.fetch(pdir.tail)
+		if err != nil {
+			return err
+		}
+		if !pdir.split {
+			// first metadata pair of a directory
+			parent, id, err := fs.parent(pdir.tail)
+			if err != nil {
+				return err
+			}
+			if parent == nil {
+				if err := fs.drop(pdir, d); err != nil {
+					return err
+				}
+				continue
+			}
+			if pair, _ := parent.entries[id].pair(); !pairSync(pair, pdir.tail) {
+				if err := fs.commit(pdir, tailAttr(false, pair)); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		pdir = d
+	}
+	fs.prepOrphans(-int(fs.gstate.orphans()))
+	return nil
+}
+
+// splitPath returns the names of a path.
+func (fs *FS) splitPath(path string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(path, "/") {
+		switch name {
+		case "", ".":
+		case "..":
+			if len(names) > 0 {
+				names = names[:len(names)-1]
+			}
+		default:
+			if uint32(len(name)) > fs.nameMax {
+				return nil, ErrNameTooLong
+			}
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// lookup finds the entry of a path. When it does not exist, d is the last
+// metadata pair of its directory and id the position to insert it at. For
+// the root directory, d is nil.
+func (fs *FS) lookup(path string) (d *mdir, id int, found bool, err error) {
+	names, err := fs.splitPath(path)
+	if err != nil || len(names) == 0 {
+		return nil, 0, true, err
+	}
+	pair := fs.root
+	for i, name := range names {
+		d, id, found, err = fs.find(pair, name)
+		if err != nil || i == len(names)-1 {
+			return
+		}
+		if !found {
+			return nil, 0, false, ErrNotFound
+		}
+		var ok bool
+		if pair, ok = d.entries[id].pair(); !ok {
+			return nil, 0, false, ErrNotDir
+		}
+	}
+	return
+}
+
+// find finds a name in a directory. The entries of each metadata pair are
+// sorted, and new ones are added to the last pair.
+func (fs *FS) find(pair [2]uint32, name string) (*mdir, int, bool, error) {
+	for {
+		d, err := fs.fetch(pair)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		insert := len(d.entries)
+		for id := range d.entries {
+			if !fs.visible(d, id) {
+				continue
+			}
+			switch compareName(d.entries[id].name.data, name) {
+			case 0:
+				return d, id, true, nil
+			case 1:
+				if insert == len(d.entries) {
+					insert = id
+				}
+			}
+		}
+		if !d.split {
+			return d, insert, false, nil
+		}
+		pair = d.tail
+	}
+}
+
+// compareName compares a name on disk with another one, in the order of
+// LittleFS, which sorts the names that are prefixes of others last.
+func compareName(disk []byte, name string) int {
+	n := len(disk)
+	if len(name) < n {
+		n = len(name)
+	}
+	if c := strings.Compare(string(disk[:n]), name[:n]); c != 0 {
+		return c
+	}
+	switch {
+	case len(disk) == len(name):
+		return 0
+	case len(name) < len(disk):
+		return -1
+	}
+	return 1
+}
+
+func baseName(path string) string {
+	path = strings.TrimRight(path, "/")
+	return path[strings.LastIndexByte(path, '/')+1:]
+}
+
+// Stat returns information about a file or a directory.
+func (fs *FS) Stat(path string) (FileInfo, error) {
+	d, id, found, err := fs.lookup(path)
+	switch {
+	case err != nil:
+		return FileInfo{}, err
+	case !found:
+		return FileInfo{}, ErrNotFound
+	case d == nil:
+		return FileInfo{Name: "/", IsDir: true}, nil
+	}
+	return info(&d.entries[id]), nil
+}
+
+func info(e *entry) FileInfo {
+	return FileInfo{Name: string(e.name.data), Size: e.size(), IsDir: e.isDir()}
+}
+
+// ReadDir returns the files and directories of a directory.
+func (fs *FS) ReadDir(path string) ([]FileInfo, error) {
+	d, id, found, err := fs.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	pair := fs.root
+	if d != nil {
+		var ok bool
+		if pair, ok = d.entries[id].pair(); !ok {
+			return nil, ErrNotDir
+		}
+	}
+	var list []FileInfo
+	for {
+		d, err := fs.fetch(pair)
+		if err != nil {
+			return nil, err
+		}
+		for id := range d.entries {
+			if fs.visible(d, id) {
+				list = append(list, info(&d.entries[id]))
+			}
+		}
+		if !d.split {
+			return list, nil
+		}
+		pair = d.tail
+	}
+}
+
+// Mkdir creates a directory.
+func (fs *FS) Mkdir(path string) error {
+	if err := fs.begin(); err != nil {
+		return err
+	}
+	d, id, found, err := fs.lookup(path)
+	if err != nil {
+		return err
+	}
+	if found {
+		return ErrExist
+	}
+	// the directory follows its parent in the list of metadata pairs
+	dir := &mdir{tail: d.tail}
+	if err := fs.create(dir); err != nil {
+		return err
+	}
+	name := baseName(path)
+	pair := encodePair(dir.pair)
+	return fs.commit(d,
+		attr{mktag(typeCreate, uint16(id), 0), nil},
+		attr{mktag(typeDir, uint16(id), uint32(len(name))), []byte(name)},
+		attr{mktag(typeDirStruct, uint16(id), 8), pair},
+		tailAttr(false, dir.pair),
+	)
+}
+
+// Remove deletes a file or an empty directory.
+func (fs *FS) Remove(path string) error {
+	if err := fs.begin(); err != nil {
+		return err
+	}
+	d, id, found, err := fs.lookup(path)
+	switch {
+	case err != nil:
+		return err
+	case !found:
+		return ErrNotFound
+	case d == nil:
+		return ErrPermission
+	}
+	dir, err := fs.emptyDir(&d.entries[id])
+	if err != nil {
+		return err
+	}
+	if dir != nil {
+		// the directory is an orphan until removed from the list
+		fs.prepOrphans(1)
+	}
+	if err := fs.commit(d, attr{mktag(typeDelete, uint16(id), 0), nil}); err != nil {
+		return err
+	}
+	if dir != nil {
+		return fs.unlink(dir)
+	}
+	return nil
+}
+
+// emptyDir returns the metadata pair of an entry that is a directory, or nil
+// for a file. It returns ErrNotEmpty if the directory is not empty.
+func (fs *FS) emptyDir(e *entry) (*mdir, error) {
+	pair, ok := e.pair()
+	if !ok {
+		return nil, nil
+	}
+	dir, err := fs.fetch(pair)
+	if err != nil {
+		return nil, err
+	}
+	if len(dir.entries) > 0 || dir.split {
+		return nil, ErrNotEmpty
+	}
+	return dir, nil
+}
+
+// unlink removes the metadata pair of a removed directory from the list.
+func (fs *FS) unlink(dir *mdir) error {
+	fs.prepOrphans(-1)
+	pred, err := fs.pred(dir.pair)
+	if err != nil {
+		return err
+	}
+	if pred == nil {
+		// only write the global state
+		root, err := fs.fetch(fs.root)
+		if err != nil {
+			return err
+		}
+		return fs.commit(root)
+	}
+	return fs.drop(pred, dir)
+}
+
+// Rename renames a file or a directory, replacing any existing file, or
+// empty directory, with the new name.
+func (fs *FS) Rename(from, to string) error {
+	if err := fs.begin(); err != nil {
+		return err
+	}
+	od, oid, found, err := fs.lookup(from)
+	switch {
+	case err != nil:
+		return err
+	case !found:
+		return ErrNotFound
+	case od == nil:
+		return ErrPermission
+	}
+	nd, nid, exists, err := fs.lookup(to)
+	switch {
+	case err != nil:
+		return err
+	case nd == nil:
+		return ErrPermission
+	}
+	old := od.entries[oid]
+	if old.isDir() {
+		fromNames, _ := fs.splitPath(from)
+		toNames, _ := fs.splitPath(to)
+		if len(toNames) > len(fromNames) && strings.Join(toNames[:len(fromNames)], "/") == strings.Join(fromNames, "/") {
+			// into itself
+			return ErrPermission
+		}
+	}
+	samePair := pairOverlap(od.pair, nd.pair)
+	var attrs []attr
+	var replaced *mdir
+	if exists {
+		e := &nd.entries[nid]
+		switch {
+		case samePair && nid == oid:
+			return nil
+		case e.isDir() != old.isDir() && e.isDir():
+			return ErrIsDir
+		case e.isDir() != old.isDir():
+			return ErrNotDir
+		}
+		if replaced, err = fs.emptyDir(e); err != nil {
+			return err
+		}
+		if replaced != nil {
+			fs.prepOrphans(1)
+		}
+		attrs = append(attrs, attr{mktag(typeDelete, uint16(nid), 0), nil})
+	} else if samePair && nid <= oid {
+		oid++
+	}
+	name := baseName(to)
+	id := uint16(nid)
+	attrs = append(attrs,
+		attr{mktag(typeCreate, id, 0), nil},
+		attr{mktag(old.name.tag.type3(), id, uint32(len(name))), []byte(name)},
+	)
+	if old.structure.tag != 0 {
+		attrs = append(attrs, attr{old.structure.tag.withID(id), old.structure.data})
+	}
+	for _, a := range old.user {
+		attrs = append(attrs, attr{a.tag.withID(id), a.data})
+	}
+	if samePair {
+		attrs = append(attrs, attr{mktag(typeDelete, uint16(oid), 0), nil})
+	} else {
+		// record the move, to delete the source if power is lost before
+		fs.gstate.tag = fs.gstate.tag&^mktag(0x7FF, noID, 0) | mktag(typeDelete, uint16(oid), 0)
+		fs.gstate.pair = od.pair
+	}
+	if err := fs.commit(nd, attrs...); err != nil {
+		return err
+	}
+	if !samePair {
+		fs.gstate.tag &^= mktag(0x7FF, noID, 0)
+		fs.gstate.pair = [2]uint32{}
+		if err := fs.commit(od, attr{mktag(typeDelete, uint16(oid), 0), nil}); err != nil {
+			return err
+		}
+	}
+	if replaced != nil {
+		return fs.unlink(replaced)
+	}
+	return nil
+}
+
+// Free returns the number of bytes available for data and metadata.
+func (fs *FS) Free() (int64, error) {
+	if err := fs.scan(); err != nil {
+		return 0, err
+	}
+	var free int64
+	for b := uint32(0); b < fs.count; b++ {
+		if !fs.inUse(b) {
+			free += int64(fs.blockSize)
+		}
+	}
+	return free, nil
+}
+
+func (fs *FS) mark(block uint32) {
+	if block < fs.count {
+		fs.used[block/8] |= 1 << (block % 8)
+	}
+}
+
+func (fs *FS) inUse(block uint32) bool {
+	return fs.used[block/8]&(1<<(block%8)) != 0
+}
+
+// scan finds the blocks in use by the metadata, the committed files and the
+// files being written.
+func (fs *FS) scan() error {
+	for i := range fs.used {
+		fs.used[i] = 0
+	}
+	err := fs.each(func(d *mdir) error {
+		fs.mark(d.pair[0])
+		fs.mark(d.pair[1])
+		for i := range d.entries {
+			s := d.entries[i].structure
+			if s.tag.type3() == typeCTZ && len(s.data) == 8 {
+				if err := fs.markCTZ(binary.LittleEndian.Uint32(s.data), binary.LittleEndian.Uint32(s.data[4:])); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, f := range fs.files {
+		if f.ctz {
+			if err := fs.markCTZ(f.head, f.size); err != nil {
+				return err
+			}
+		}
+	}
+	for _, b := range fs.pending {
+		fs.mark(b)
+	}
+	return nil
+}
+
+// alloc finds a free block, starting at the allocation cursor. The blocks
+// freed since the last scan are found by a new scan once no other block is
+// free.
+func (fs *FS) alloc() (uint32, error) {
+	for scanned := false; ; scanned = true {
+		for i := uint32(0); i < fs.count; i++ {
+			b := (fs.cursor + i) % fs.count
+			if !fs.inUse(b) {
+				fs.mark(b)
+				fs.cursor = (b + 1) % fs.count
+				fs.pending = append(fs.pending, b)
+				return b, nil
+			}
+		}
+		if scanned {
+			return 0, ErrFull
+		}
+		if err := fs.scan(); err != nil {
+			return 0, err
+		}
+	}
+}
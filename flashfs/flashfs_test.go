@@ -0,0 +1,368 @@
+package flashfs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+const testBlockSize = 4096
+
+var errPowerLoss = errors.New("power loss")
+
+// norFlash emulates NOR flash: programming can only clear bits and erasing
+// sets a whole block to 0xFF. After budget writes, it simulates a power
+// loss by writing only half of the next buffer and failing from then on.
+type norFlash struct {
+	mem     []byte
+	erases  []int
+	budget  int
+	limited bool
+}
+
+func newFlash(blocks int) *norFlash {
+	f := &norFlash{mem: make([]byte, blocks*testBlockSize), erases: make([]int, blocks)}
+	for i := range f.mem {
+		f.mem[i] = 0xFF
+	}
+	return f
+}
+
+func (f *norFlash) ReadAt(buf []byte, off int64) (int, error) {
+	return copy(buf, f.mem[off:]), nil
+}
+
+func (f *norFlash) WriteAt(buf []byte, off int64) (int, error) {
+	if f.limited {
+		if f.budget == 0 {
+			buf = buf[:len(buf)/2]
+		}
+		if f.budget < 0 {
+			return 0, errPowerLoss
+		}
+		f.budget--
+	}
+	for i, b := range buf {
+		f.mem[off+int64(i)] &= b
+	}
+	if f.limited && f.budget < 0 {
+		return len(buf), errPowerLoss
+	}
+	return len(buf), nil
+}
+
+func (f *norFlash) Size() int64           { return int64(len(f.mem)) }
+func (f *norFlash) WriteBlockSize() int64 { return 256 }
+func (f *norFlash) EraseBlockSize() int64 { return testBlockSize }
+
+func (f *norFlash) EraseBlocks(start, n int64) error {
+	if f.limited && f.budget < 0 {
+		return errPowerLoss
+	}
+	for b := start; b < start+n; b++ {
+		f.erases[b]++
+		for i := b * testBlockSize; i < (b+1)*testBlockSize; i++ {
+			f.mem[i] = 0xFF
+		}
+	}
+	return nil
+}
+
+func mount(c *qt.C, dev *norFlash) *FS {
+	fs, err := Mount(dev)
+	c.Assert(err, qt.IsNil)
+	return fs
+}
+
+func writeFile(c *qt.C, fs *FS, name string, data []byte) {
+	f, err := fs.Create(name)
+	c.Assert(err, qt.IsNil)
+	_, err = f.Write(data)
+	c.Assert(err, qt.IsNil)
+	c.Assert(f.Close(), qt.IsNil)
+}
+
+func free(c *qt.C, fs *FS) int64 {
+	n, err := fs.Free()
+	c.Assert(err, qt.IsNil)
+	return n
+}
+
+func readFile(c *qt.C, fs *FS, name string) []byte {
+	f, err := fs.Open(name)
+	c.Assert(err, qt.IsNil)
+	data, err := ioutil.ReadAll(f)
+	c.Assert(err, qt.IsNil)
+	return data
+}
+
+func TestUnformatted(t *testing.T) {
+	c := qt.New(t)
+	_, err := Mount(newFlash(16))
+	c.Assert(err, qt.Equals, ErrNotFormatted)
+}
+
+func TestFormat(t *testing.T) {
+	c := qt.New(t)
+	dev := newFlash(16)
+	c.Assert(Format(dev), qt.IsNil)
+
+	// the superblock as described by the LittleFS specification
+	want := []byte{
+		0x01, 0x00, 0x00, 0x00, // revision count
+		0xf0, 0x0f, 0xff, 0xf7, // superblock name tag, xored with 0xffffffff
+		'l', 'i', 't', 't', 'l', 'e', 'f', 's',
+		0x2f, 0xe0, 0x00, 0x10, // inline struct tag, xored with the previous one
+		0x00, 0x00, 0x02, 0x00, // version 2.0
+		0x00, 0x10, 0x00, 0x00, // block size
+		0x10, 0x00, 0x00, 0x00, // block count
+		0xff, 0x00, 0x00, 0x00, // name max
+		0xff, 0xff, 0xff, 0x7f, // file max
+		0xfe, 0x03, 0x00, 0x00, // attr max
+		0x70, 0x1f, 0xfc, 0xc8, // CRC tag padding the commit to 256 bytes
+	}
+	c.Assert(dev.mem[:len(want)], qt.DeepEquals, want)
+	sum := binary.LittleEndian.Uint32(dev.mem[len(want):])
+	c.Assert(sum, qt.Equals, ^crc32.ChecksumIEEE(dev.mem[:len(want)]))
+
+	fs := mount(c, dev)
+	list, err := fs.ReadDir("/")
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 0)
+	c.Assert(free(c, fs), qt.Equals, int64(14*testBlockSize))
+}
+
+func TestCreateAppend(t *testing.T) {
+	c := qt.New(t)
+	dev := newFlash(16)
+	c.Assert(Format(dev), qt.IsNil)
+	fs := mount(c, dev)
+	c.Assert(fs.Mkdir("config"), qt.IsNil)
+
+	data := bytes.Repeat([]byte("0123456789"), 1000) // spans three blocks
+	writeFile(c, fs, "config/wifi", data)
+
+	f, err := fs.Append("config/wifi")
+	c.Assert(err, qt.IsNil)
+	_, err = f.Write([]byte("tail"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(f.Close(), qt.IsNil)
+
+	fs = mount(c, dev)
+	c.Assert(readFile(c, fs, "config/wifi"), qt.DeepEquals, append(data, "tail"...))
+	list, err := fs.ReadDir("config")
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.DeepEquals, []FileInfo{{"wifi", 10004, false}})
+	list, err = fs.ReadDir("/")
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.DeepEquals, []FileInfo{{"config", 0, true}})
+
+	f, err = fs.Open("config/wifi")
+	c.Assert(err, qt.IsNil)
+	_, err = f.Seek(8190, 0)
+	c.Assert(err, qt.IsNil)
+	var buf [6]byte
+	_, err = f.Read(buf[:])
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf[:]), qt.Equals, "012345")
+
+	// two metadata pairs and three data blocks in use
+	c.Assert(free(c, fs), qt.Equals, int64(9*testBlockSize))
+
+	c.Assert(fs.Rename("config/wifi", "wifi.old"), qt.IsNil)
+	_, err = fs.Stat("config/wifi")
+	c.Assert(err, qt.Equals, ErrNotFound)
+	info, err := fs.Stat("wifi.old")
+	c.Assert(err, qt.IsNil)
+	c.Assert(info, qt.Equals, FileInfo{"wifi.old", 10004, false})
+	c.Assert(fs.Remove("wifi.old"), qt.IsNil)
+	_, err = fs.Stat("wifi.old")
+	c.Assert(err, qt.Equals, ErrNotFound)
+	c.Assert(free(c, fs), qt.Equals, int64(12*testBlockSize))
+}
+
+func TestInline(t *testing.T) {
+	c := qt.New(t)
+	dev := newFlash(16)
+	c.Assert(Format(dev), qt.IsNil)
+	fs := mount(c, dev)
+
+	writeFile(c, fs, "small", []byte("hello"))
+	f, err := fs.Append("small")
+	c.Assert(err, qt.IsNil)
+	_, err = f.Write([]byte(" world"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(f.Close(), qt.IsNil)
+	writeFile(c, fs, "empty", nil)
+
+	// stored in the metadata only
+	c.Assert(free(c, fs), qt.Equals, int64(14*testBlockSize))
+	fs = mount(c, dev)
+	c.Assert(readFile(c, fs, "small"), qt.DeepEquals, []byte("hello world"))
+	c.Assert(readFile(c, fs, "empty"), qt.HasLen, 0)
+
+	// moved to a block once too large
+	f, err = fs.Append("small")
+	c.Assert(err, qt.IsNil)
+	_, err = f.Write(bytes.Repeat([]byte{'!'}, 600))
+	c.Assert(err, qt.IsNil)
+	c.Assert(f.Close(), qt.IsNil)
+	c.Assert(free(c, fs), qt.Equals, int64(13*testBlockSize))
+	c.Assert(readFile(c, mount(c, dev), "small"), qt.DeepEquals, append([]byte("hello world"), bytes.Repeat([]byte{'!'}, 600)...))
+}
+
+func TestDirectories(t *testing.T) {
+	c := qt.New(t)
+	dev := newFlash(64)
+	c.Assert(Format(dev), qt.IsNil)
+	fs := mount(c, dev)
+
+	c.Assert(fs.Mkdir("logs"), qt.IsNil)
+	c.Assert(fs.Mkdir("logs"), qt.Equals, ErrExist)
+	c.Assert(fs.Mkdir("logs/2024"), qt.IsNil)
+	c.Assert(fs.Mkdir("missing/dir"), qt.Equals, ErrNotFound)
+	writeFile(c, fs, "logs/2024/jan", []byte("cold"))
+	_, err := fs.Create("logs/2024/jan/x")
+	c.Assert(err, qt.Equals, ErrNotDir)
+	_, err = fs.Open("logs")
+	c.Assert(err, qt.Equals, ErrIsDir)
+	c.Assert(fs.Remove("logs"), qt.Equals, ErrNotEmpty)
+	c.Assert(fs.Rename("logs", "logs/2024/logs"), qt.Equals, ErrPermission)
+
+	// enough files for the root directory to span several metadata pairs
+	for i := 0; i < 60; i++ {
+		writeFile(c, fs, fmt.Sprintf("file%02d", i), bytes.Repeat([]byte{byte(i)}, 100))
+	}
+	fs = mount(c, dev)
+	list, err := fs.ReadDir("/")
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 61)
+	for i := 0; i < 60; i++ {
+		c.Assert(readFile(c, fs, fmt.Sprintf("file%02d", i)), qt.DeepEquals, bytes.Repeat([]byte{byte(i)}, 100))
+	}
+	for i := 0; i < 60; i++ {
+		c.Assert(fs.Remove(fmt.Sprintf("file%02d", i)), qt.IsNil)
+	}
+
+	c.Assert(fs.Rename("logs/2024", "2024"), qt.IsNil)
+	c.Assert(readFile(c, fs, "2024/jan"), qt.DeepEquals, []byte("cold"))
+	c.Assert(fs.Remove("logs"), qt.IsNil)
+	c.Assert(fs.Remove("2024/jan"), qt.IsNil)
+	c.Assert(fs.Remove("2024"), qt.IsNil)
+
+	fs = mount(c, dev)
+	list, err = fs.ReadDir("/")
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 0)
+	c.Assert(free(c, fs), qt.Equals, int64(62*testBlockSize))
+}
+
+func TestReplaceIsAtomic(t *testing.T) {
+	c := qt.New(t)
+	for budget := 0; budget < 12; budget++ {
+		dev := newFlash(8)
+		c.Assert(Format(dev), qt.IsNil)
+		fs := mount(c, dev)
+		writeFile(c, fs, "cfg", bytes.Repeat([]byte{'a'}, 5000))
+
+		// power fails at some point while writing the new version
+		dev.limited, dev.budget = true, budget
+		f, err := fs.Create("cfg")
+		c.Assert(err, qt.IsNil)
+		f.Write(bytes.Repeat([]byte{'b'}, 5000))
+		f.Close()
+		dev.limited = false
+
+		fs = mount(c, dev)
+		got := readFile(c, fs, "cfg")
+		ok := bytes.Equal(got, bytes.Repeat([]byte{'a'}, 5000)) || bytes.Equal(got, bytes.Repeat([]byte{'b'}, 5000))
+		c.Assert(ok, qt.IsTrue, qt.Commentf("budget %d", budget))
+
+		// the filesystem is still usable afterwards
+		writeFile(c, fs, "cfg", []byte("c"))
+		c.Assert(readFile(c, mount(c, dev), "cfg"), qt.DeepEquals, []byte("c"))
+	}
+}
+
+func TestMoveIsAtomic(t *testing.T) {
+	c := qt.New(t)
+	for budget := 0; budget < 4; budget++ {
+		dev := newFlash(16)
+		c.Assert(Format(dev), qt.IsNil)
+		fs := mount(c, dev)
+		c.Assert(fs.Mkdir("new"), qt.IsNil)
+		c.Assert(fs.Mkdir("old"), qt.IsNil)
+		writeFile(c, fs, "old/cfg", []byte("data"))
+
+		// power fails between the commits in both directories
+		dev.limited, dev.budget = true, budget
+		fs.Rename("old/cfg", "new/cfg")
+		dev.limited = false
+
+		fs = mount(c, dev)
+		_, errOld := fs.Stat("old/cfg")
+		_, errNew := fs.Stat("new/cfg")
+		c.Assert(errOld == nil, qt.Not(qt.Equals), errNew == nil, qt.Commentf("budget %d", budget))
+
+		// the move is completed by the next change
+		c.Assert(fs.Mkdir("other"), qt.IsNil)
+		fs = mount(c, dev)
+		if errNew == nil {
+			c.Assert(readFile(c, fs, "new/cfg"), qt.DeepEquals, []byte("data"))
+		}
+		c.Assert(fs.gdisk.zero(), qt.IsTrue)
+	}
+}
+
+func TestRemoveDirIsAtomic(t *testing.T) {
+	c := qt.New(t)
+	for budget := 0; budget < 4; budget++ {
+		dev := newFlash(16)
+		c.Assert(Format(dev), qt.IsNil)
+		fs := mount(c, dev)
+		c.Assert(fs.Mkdir("a"), qt.IsNil)
+		c.Assert(fs.Mkdir("b"), qt.IsNil)
+
+		dev.limited, dev.budget = true, budget
+		fs.Remove("a")
+		dev.limited = false
+
+		// the metadata pair of a removed directory is reused
+		fs = mount(c, dev)
+		c.Assert(fs.Mkdir("c"), qt.IsNil)
+		list, err := fs.ReadDir("/")
+		c.Assert(err, qt.IsNil)
+		want := int64(10 * testBlockSize)
+		if len(list) == 3 {
+			want -= 2 * testBlockSize
+		}
+		c.Assert(free(c, fs), qt.Equals, want, qt.Commentf("budget %d", budget))
+		c.Assert(fs.gstate.zero(), qt.IsTrue)
+	}
+}
+
+func TestWearLeveling(t *testing.T) {
+	c := qt.New(t)
+	dev := newFlash(16)
+	c.Assert(Format(dev), qt.IsNil)
+	fs := mount(c, dev)
+	data := bytes.Repeat([]byte{0}, 1000) // too large to be inlined
+	for i := 0; i < 280; i++ {
+		data[0] = byte(i)
+		writeFile(c, fs, "counter", data)
+		if i%50 == 0 {
+			fs = mount(c, dev)
+		}
+	}
+	c.Assert(readFile(c, fs, "counter")[0], qt.Equals, byte(279&0xff))
+	// 280 writes over 14 data blocks: every block is erased about 20 times
+	for b := 2; b < 16; b++ {
+		c.Assert(dev.erases[b] >= 18 && dev.erases[b] <= 22, qt.IsTrue, qt.Commentf("block %d erased %d times", b, dev.erases[b]))
+	}
+}
@@ -0,0 +1,628 @@
+package flashfs
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+)
+
+// Tag types, from the SPEC.md of LittleFS. The type is made of 3 bits for
+// the kind of tag and 8 bits specific to that kind.
+const (
+	typeName       = 0x000
+	typeReg        = 0x001
+	typeDir        = 0x002
+	typeSuperblock = 0x0ff
+	typeStruct     = 0x200
+	typeDirStruct  = 0x200
+	typeInline     = 0x201
+	typeCTZ        = 0x202
+	typeUserAttr   = 0x300
+	typeSplice     = 0x400
+	typeCreate     = 0x401
+	typeDelete     = 0x4ff
+	typeCRC        = 0x500
+	typeTail       = 0x600
+	typeSoftTail   = 0x600
+	typeHardTail   = 0x601
+	typeGlobals    = 0x700
+	typeMoveState  = 0x7ff
+)
+
+// noID is the id of the tags that do not belong to an entry.
+const noID = 0x3ff
+
+// maxEntries is the number of entries above which a metadata pair is split.
+const maxEntries = 0xff
+
+var nullPair = [2]uint32{0xFFFFFFFF, 0xFFFFFFFF}
+
+// tag is a metadata tag: a valid bit, which is 0 for valid tags, the type,
+// the id of the entry and the size of the data that follows.
+type tag uint32
+
+func mktag(typ, id uint16, size uint32) tag {
+	return tag(uint32(typ)<<20 | uint32(id)<<10 | size)
+}
+
+func (t tag) valid() bool   { return t&0x80000000 == 0 }
+func (t tag) type1() uint16 { return uint16(t>>20) & 0x700 }
+func (t tag) type2() uint16 { return uint16(t>>20) & 0x780 }
+func (t tag) type3() uint16 { return uint16(t>>20) & 0x7FF }
+func (t tag) chunk() uint8  { return uint8(t >> 20) }
+func (t tag) id() uint16    { return uint16(t>>10) & 0x3FF }
+func (t tag) size() uint32  { return uint32(t) & 0x3FF }
+
+// deleted returns whether the tag deletes an attribute, in which case it has
+// no data.
+func (t tag) deleted() bool { return t.size() == 0x3FF }
+
+// dsize returns the size of the tag and its data on disk.
+func (t tag) dsize() uint32 {
+	if t.deleted() {
+		return 4
+	}
+	return 4 + t.size()
+}
+
+// withID returns the tag for another entry.
+func (t tag) withID(id uint16) tag {
+	return t&^mktag(0, noID, 0) | mktag(0, id, 0)
+}
+
+// attr is a tag with its data.
+type attr struct {
+	tag  tag
+	data []byte
+}
+
+// entry is a file or a directory of a metadata pair, or the superblock.
+type entry struct {
+	name      attr // typeReg, typeDir or typeSuperblock
+	structure attr
+	user      []attr
+}
+
+func (e *entry) isDir() bool {
+	return e.name.tag.type3() == typeDir
+}
+
+// visible returns whether the entry is a file or a directory.
+func (e *entry) visible() bool {
+	t := e.name.tag.type3()
+	return t == typeReg || t == typeDir
+}
+
+func (e *entry) size() uint32 {
+	switch e.structure.tag.type3() {
+	case typeInline:
+		return uint32(len(e.structure.data))
+	case typeCTZ:
+		if len(e.structure.data) == 8 {
+			return binary.LittleEndian.Uint32(e.structure.data[4:])
+		}
+	}
+	return 0
+}
+
+// pair returns the metadata pair of a directory.
+func (e *entry) pair() ([2]uint32, bool) {
+	s := e.structure
+	if s.tag.type3() != typeDirStruct || len(s.data) != 8 {
+		return nullPair, false
+	}
+	return decodePair(s.data), true
+}
+
+// gstate is the global state: the XOR of the deltas stored in all metadata
+// pairs. It holds the number of orphaned metadata pairs and an entry whose
+// move to another metadata pair is not complete.
+type gstate struct {
+	tag  tag
+	pair [2]uint32
+}
+
+func (g gstate) xor(o gstate) gstate {
+	return gstate{g.tag ^ o.tag, [2]uint32{g.pair[0] ^ o.pair[0], g.pair[1] ^ o.pair[1]}}
+}
+
+func (g gstate) zero() bool {
+	return g == gstate{}
+}
+
+func (g gstate) orphans() uint32 {
+	return g.tag.size() & 0x1FF
+}
+
+func (g gstate) hasMove() bool {
+	return g.tag.type1() != 0
+}
+
+func (g gstate) hasMoveHere(pair [2]uint32) bool {
+	return g.hasMove() && pairOverlap(g.pair, pair)
+}
+
+func (g gstate) encode() []byte {
+	b := make([]byte, 12)
+	binary.LittleEndian.PutUint32(b[0:], uint32(g.tag))
+	binary.LittleEndian.PutUint32(b[4:], g.pair[0])
+	binary.LittleEndian.PutUint32(b[8:], g.pair[1])
+	return b
+}
+
+// mdir is a metadata pair: two blocks holding a log of commits, one of
+// which is rewritten with the compacted log when the other is full.
+type mdir struct {
+	pair    [2]uint32 // the block with the last commit first
+	rev     uint32    // revision count of pair[0]
+	off     uint32    // end of the last commit in pair[0]
+	etag    tag       // tag the next commit starts from
+	erased  bool      // whether commits can be appended at off
+	entries []entry
+	tail    [2]uint32
+	split   bool   // whether tail continues the same directory
+	gstate  gstate // delta of the global state
+}
+
+func (d *mdir) clone() mdir {
+	c := *d
+	c.entries = append([]entry(nil), d.entries...)
+	return c
+}
+
+// apply updates the entries with a tag of a commit.
+func (d *mdir) apply(t tag, data []byte) {
+	id := int(t.id())
+	switch t.type1() {
+	case typeSplice:
+		switch t.chunk() {
+		case typeCreate & 0xFF:
+			d.grow(id)
+			d.entries = append(d.entries, entry{})
+			copy(d.entries[id+1:], d.entries[id:])
+			d.entries[id] = entry{}
+		case typeDelete & 0xFF:
+			if id < len(d.entries) {
+				d.entries = append(d.entries[:id], d.entries[id+1:]...)
+			}
+		}
+	case typeName:
+		if d.grow(id + 1) {
+			d.entries[id].name = attr{t.withID(0), data}
+		}
+	case typeStruct:
+		if d.grow(id + 1) {
+			d.entries[id].structure = attr{t.withID(0), data}
+		}
+	case typeUserAttr:
+		if !d.grow(id + 1) {
+			return
+		}
+		e := &d.entries[id]
+		var user []attr
+		for _, a := range e.user {
+			if a.tag.type3() != t.type3() {
+				user = append(user, a)
+			}
+		}
+		if !t.deleted() {
+			user = append(user, attr{t.withID(0), data})
+		}
+		e.user = user
+	case typeTail:
+		if len(data) == 8 {
+			d.tail = decodePair(data)
+			d.split = t.chunk()&1 != 0
+		}
+	case typeGlobals:
+		if t.type3() == typeMoveState && len(data) == 12 {
+			d.gstate = gstate{
+				tag(binary.LittleEndian.Uint32(data[0:])),
+				[2]uint32{binary.LittleEndian.Uint32(data[4:]), binary.LittleEndian.Uint32(data[8:])},
+			}
+		}
+	}
+}
+
+// grow makes sure there are n entries, unless n is too high for an id.
+func (d *mdir) grow(n int) bool {
+	if n > noID {
+		return false
+	}
+	for len(d.entries) < n {
+		d.entries = append(d.entries, entry{})
+	}
+	return true
+}
+
+// fetch reads a metadata pair, from the block with the highest revision
+// count that holds a valid commit.
+func (fs *FS) fetch(pair [2]uint32) (*mdir, error) {
+	if pair[0] >= fs.count || pair[1] >= fs.count {
+		return nil, ErrCorrupt
+	}
+	var revs [2]uint32
+	var buf [4]byte
+	for i := range pair {
+		if _, err := fs.dev.ReadAt(buf[:], int64(pair[i])*int64(fs.blockSize)); err != nil {
+			return nil, err
+		}
+		revs[i] = binary.LittleEndian.Uint32(buf[:])
+	}
+	r := 0
+	if int32(revs[1]-revs[0]) > 0 {
+		r = 1
+	}
+	for i := 0; i < 2; i++ {
+		d := &mdir{
+			pair: [2]uint32{pair[(r+i)%2], pair[(r+i+1)%2]},
+			rev:  revs[(r+i)%2],
+			tail: nullPair,
+		}
+		ok, err := fs.parse(d)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return d, nil
+		}
+	}
+	return nil, ErrCorrupt
+}
+
+// parse reads the commits of the first block of a metadata pair. The first
+// pass checks the CRCs to find the end of the last valid commit, the second
+// one applies the tags up to there.
+func (fs *FS) parse(d *mdir) (bool, error) {
+	buf := fs.buf
+	if _, err := fs.dev.ReadAt(buf, int64(d.pair[0])*int64(fs.blockSize)); err != nil {
+		return false, err
+	}
+	bs := fs.blockSize
+	ptag := tag(0xFFFFFFFF)
+	sum := crc(0xFFFFFFFF, buf[:4])
+	for off := uint32(0); ; {
+		off += ptag.dsize()
+		if off+4 > bs {
+			d.erased = false
+			break
+		}
+		sum = crc(sum, buf[off:off+4])
+		t := tag(binary.BigEndian.Uint32(buf[off:])) ^ ptag
+		if !t.valid() {
+			// the next commit has not been written
+			d.erased = ptag.type2() == typeCRC && d.off%fs.progSize == 0
+			break
+		}
+		if off+t.dsize() > bs {
+			d.erased = false
+			break
+		}
+		ptag = t
+		if t.type2() == typeCRC {
+			if t.deleted() || t.size() < 4 || binary.LittleEndian.Uint32(buf[off+4:]) != sum {
+				d.erased = false
+				break
+			}
+			// the valid bit of the next commit is inverted when its erased
+			// state would look valid
+			ptag ^= tag(t.chunk()&1) << 31
+			var b [4]byte
+			binary.LittleEndian.PutUint32(b[:], sum)
+			fs.seed = crc(fs.seed, b[:])
+			d.off = off + t.dsize()
+			d.etag = ptag
+			sum = 0xFFFFFFFF
+			continue
+		}
+		sum = crc(sum, buf[off+4:off+t.dsize()])
+	}
+	if d.off == 0 {
+		return false, nil
+	}
+
+	ptag = tag(0xFFFFFFFF)
+	for off := uint32(0); ; {
+		off += ptag.dsize()
+		if off >= d.off {
+			break
+		}
+		t := tag(binary.BigEndian.Uint32(buf[off:])) ^ ptag
+		ptag = t
+		if t.type2() == typeCRC {
+			ptag ^= tag(t.chunk()&1) << 31
+			continue
+		}
+		var data []byte
+		if !t.deleted() {
+			data = append([]byte(nil), buf[off+4:off+4+t.size()]...)
+		}
+		d.apply(t, data)
+	}
+	return true, nil
+}
+
+// commit writes attributes to a metadata pair, along with the changes of the
+// global state. They are appended to the log of the pair when they fit, or
+// the pair is compacted. A pair left without entries that continues a
+// directory is removed from its list instead.
+func (fs *FS) commit(d *mdir, attrs ...attr) error {
+	next := d.clone()
+	deleted := false
+	for _, a := range attrs {
+		next.apply(a.tag, a.data)
+		deleted = deleted || a.tag.type3() == typeDelete
+	}
+	if deleted && len(next.entries) == 0 {
+		pred, err := fs.pred(d.pair)
+		if err != nil {
+			return err
+		}
+		if pred != nil && pred.split {
+			return fs.drop(pred, d)
+		}
+	}
+
+	// the number of orphans is only kept in memory, the disk only tells
+	// whether there are some
+	delta := fs.gstate.xor(fs.gdisk).xor(fs.gdelta)
+	delta.tag &^= mktag(0, 0, 0x3FF)
+	if !delta.zero() {
+		next.gstate = d.gstate.xor(delta)
+		attrs = append(attrs, attr{mktag(typeMoveState, noID, 12), next.gstate.encode()})
+	}
+
+	if d.erased && len(next.entries) <= maxEntries {
+		size := uint32(0)
+		for _, a := range attrs {
+			size += a.tag.dsize()
+		}
+		if d.off+size+8 <= fs.blockSize {
+			c := commit{buf: fs.buf[:0], off: d.off, ptag: d.etag, crc: 0xFFFFFFFF}
+			for _, a := range attrs {
+				c.attr(a.tag, a.data)
+			}
+			if err := fs.finish(&c, d.pair[0]); err != nil {
+				return err
+			}
+			next.off, next.etag = c.end(), c.ptag
+			*d = next
+			fs.gdisk, fs.gdelta = fs.gstate, gstate{}
+			return nil
+		}
+	}
+	return fs.compact(d, &next)
+}
+
+// drop removes an empty metadata pair from the list, linking its
+// predecessor to its tail. Its part of the global state is moved to the
+// predecessor.
+func (fs *FS) drop(pred, d *mdir) error {
+	fs.gdelta = fs.gdelta.xor(d.gstate)
+	return fs.commit(pred, tailAttr(d.split, d.tail))
+}
+
+func tailAttr(split bool, pair [2]uint32) attr {
+	typ := uint16(typeSoftTail)
+	if split {
+		typ = typeHardTail
+	}
+	return attr{mktag(typ, noID, 8), encodePair(pair)}
+}
+
+// compact writes the entries of next into the other block of the pair. When
+// there are too many of them, the last ones are first moved to new
+// metadata pairs continuing the directory.
+func (fs *FS) compact(d, next *mdir) error {
+	limit := fs.blockSize - 40
+	if half := alignUp(fs.blockSize/2, fs.progSize); half < limit {
+		limit = half
+	}
+	end := len(next.entries)
+	for {
+		split := 0
+		for end-split > 1 {
+			if end-split < maxEntries && uint32(entriesSize(next.entries[split:end])) <= limit {
+				break
+			}
+			split += (end - split) / 2
+		}
+		if split == 0 {
+			break
+		}
+		tail := &mdir{
+			entries: next.entries[split:end],
+			tail:    next.tail,
+			split:   next.split,
+		}
+		if err := fs.create(tail); err != nil {
+			return err
+		}
+		next.tail, next.split = tail.pair, true
+		end = split
+	}
+	next.entries = next.entries[:end]
+
+	next.pair = [2]uint32{d.pair[1], d.pair[0]}
+	next.rev = d.rev + 1
+	if err := fs.dev.EraseBlocks(int64(next.pair[0]), 1); err != nil {
+		return err
+	}
+	if err := fs.write(next); err != nil {
+		return err
+	}
+	*d = *next
+	fs.gdisk, fs.gdelta = fs.gstate, gstate{}
+	return nil
+}
+
+// create allocates and erases the blocks of a new metadata pair and writes
+// its entries.
+func (fs *FS) create(d *mdir) error {
+	for i := range d.pair {
+		b, err := fs.alloc()
+		if err != nil {
+			return err
+		}
+		if err := fs.dev.EraseBlocks(int64(b), 1); err != nil {
+			return err
+		}
+		d.pair[i] = b
+	}
+	d.rev = 1
+	return fs.write(d)
+}
+
+// write writes all the entries of a metadata pair in a single commit to the
+// erased block pair[0].
+func (fs *FS) write(d *mdir) error {
+	size := 4 + uint32(entriesSize(d.entries)) + 8
+	if d.tail != nullPair {
+		size += 4 + 8
+	}
+	if !d.gstate.zero() {
+		size += 4 + 12
+	}
+	if size > fs.blockSize {
+		return ErrFull
+	}
+	c := commit{buf: fs.buf[:0], ptag: 0xFFFFFFFF}
+	c.buf = append(c.buf, byte(d.rev), byte(d.rev>>8), byte(d.rev>>16), byte(d.rev>>24))
+	c.crc = crc(0xFFFFFFFF, c.buf)
+	for i := range d.entries {
+		e := &d.entries[i]
+		id := uint16(i)
+		c.attr(e.name.tag.withID(id), e.name.data)
+		if e.structure.tag != 0 {
+			c.attr(e.structure.tag.withID(id), e.structure.data)
+		}
+		for _, a := range e.user {
+			c.attr(a.tag.withID(id), a.data)
+		}
+	}
+	if d.tail != nullPair {
+		a := tailAttr(d.split, d.tail)
+		c.attr(a.tag, a.data)
+	}
+	if !d.gstate.zero() {
+		c.attr(mktag(typeMoveState, noID, 12), d.gstate.encode())
+	}
+	if err := fs.finish(&c, d.pair[0]); err != nil {
+		return err
+	}
+	d.off, d.etag, d.erased = c.end(), c.ptag, true
+	return nil
+}
+
+// entriesSize returns the size on disk of entries.
+func entriesSize(entries []entry) int {
+	n := 0
+	for i := range entries {
+		e := &entries[i]
+		n += int(e.name.tag.dsize())
+		if e.structure.tag != 0 {
+			n += int(e.structure.tag.dsize())
+		}
+		for _, a := range e.user {
+			n += int(a.tag.dsize())
+		}
+	}
+	return n
+}
+
+// commit builds the tags of a commit in memory.
+type commit struct {
+	buf  []byte
+	off  uint32 // offset of buf in the block
+	ptag tag
+	crc  uint32
+}
+
+func (c *commit) end() uint32 {
+	return c.off + uint32(len(c.buf))
+}
+
+// attr adds a tag, XORed with the previous one, and its data.
+func (c *commit) attr(t tag, data []byte) {
+	start := len(c.buf)
+	raw := uint32(t&0x7FFFFFFF) ^ uint32(c.ptag)
+	c.buf = append(c.buf, byte(raw>>24), byte(raw>>16), byte(raw>>8), byte(raw))
+	if !t.deleted() {
+		c.buf = append(c.buf, data...)
+	}
+	c.crc = crc(c.crc, c.buf[start:])
+	c.ptag = t & 0x7FFFFFFF
+}
+
+// finish ends the commit with CRC tags padding it to the next program unit,
+// and writes it.
+func (fs *FS) finish(c *commit, block uint32) error {
+	end := alignUp(c.end()+8, fs.progSize)
+	for c.end() < end {
+		off := c.end() + 4
+		noff := off + min(end-off, 0x3FE)
+		if noff < end {
+			noff = min(noff, end-8)
+		}
+		// the erased state of the next program unit, whose top bit must
+		// read as invalid
+		next := [4]byte{0xFF, 0xFF, 0xFF, 0xFF}
+		if noff+4 <= fs.blockSize {
+			if _, err := fs.dev.ReadAt(next[:], int64(block)*int64(fs.blockSize)+int64(noff)); err != nil {
+				return err
+			}
+		}
+		reset := uint16(^next[0] >> 7)
+		t := mktag(typeCRC+reset, noID, noff-off)
+		raw := uint32(t) ^ uint32(c.ptag)
+		start := len(c.buf)
+		c.buf = append(c.buf, byte(raw>>24), byte(raw>>16), byte(raw>>8), byte(raw))
+		c.crc = crc(c.crc, c.buf[start:])
+		c.buf = append(c.buf, byte(c.crc), byte(c.crc>>8), byte(c.crc>>16), byte(c.crc>>24))
+		// the padding is not covered by the CRC and left erased
+		for c.end() < noff {
+			c.buf = append(c.buf, 0xFF)
+		}
+		c.ptag = t ^ tag(reset)<<31
+		c.crc = 0xFFFFFFFF
+	}
+	_, err := fs.dev.WriteAt(c.buf, int64(block)*int64(fs.blockSize)+int64(c.off))
+	return err
+}
+
+// crc continues the CRC-32 of LittleFS, which is the one of IEEE 802.3
+// without the final inversion.
+func crc(sum uint32, data []byte) uint32 {
+	return ^crc32.Update(^sum, crc32.IEEETable, data)
+}
+
+func decodePair(b []byte) [2]uint32 {
+	return [2]uint32{binary.LittleEndian.Uint32(b[0:]), binary.LittleEndian.Uint32(b[4:])}
+}
+
+func encodePair(pair [2]uint32) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint32(b[0:], pair[0])
+	binary.LittleEndian.PutUint32(b[4:], pair[1])
+	return b
+}
+
+// pairOverlap returns whether two metadata pairs share a block.
+func pairOverlap(a, b [2]uint32) bool {
+	return a[0] == b[0] || a[1] == b[1] || a[0] == b[1] || a[1] == b[0]
+}
+
+// pairSync returns whether two metadata pairs are the same blocks.
+func pairSync(a, b [2]uint32) bool {
+	return a == b || a[0] == b[1] && a[1] == b[0]
+}
+
+func alignUp(n, align uint32) uint32 {
+	return (n + align - 1) / align * align
+}
+
+func min(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -23,7 +23,8 @@ type Fix struct {
 	// Valid if the fix was valid.
 	Valid bool
 
-	// Time that the fix was taken, in UTC time.
+	// Time that the fix was taken, in UTC time. Only RMC sentences include
+	// the date.
 	Time time.Time
 
 	// Latitude is the decimal latitude. Negative numbers indicate S.
@@ -80,7 +81,7 @@ func (parser *Parser) Parse(sentence string) (fix Fix, err error) {
 
 		fix.Longitude = findLongitude(fields[5], fields[6])
 		fix.Latitude = findLatitude(fields[3], fields[4])
-		fix.Time = findTime(fields[1])
+		fix.Time = findDate(fields[9], findTime(fields[1]))
 		fix.Speed = findSpeed(fields[7])
 		fix.Heading = findHeading(fields[8])
 		fix.Valid = (len(fields[2]) > 0 && fields[2][0:1] == "A")
@@ -100,12 +101,36 @@ func findTime(val string) time.Time {
 	h, _ := strconv.ParseInt(val[0:2], 10, 8)
 	m, _ := strconv.ParseInt(val[2:4], 10, 8)
 	s, _ := strconv.ParseInt(val[4:6], 10, 8)
-	ms, _ := strconv.ParseInt(val[7:10], 10, 16)
-	t := time.Date(0, 0, 0, int(h), int(m), int(s), int(ms), time.UTC)
+	// receivers send from 0 to 3 decimals, scaled here to nanoseconds
+	var ns int64
+	if len(val) > 7 && val[6] == '.' {
+		frac := val[7:]
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		ns, _ = strconv.ParseInt(frac, 10, 32)
+		for i := len(frac); i < 9; i++ {
+			ns *= 10
+		}
+	}
+	t := time.Date(0, 0, 0, int(h), int(m), int(s), int(ns), time.UTC)
 
 	return t
 }
 
+// findDate adds the date from an RMC sentence to the time t:
+// $--RMC,,,,,,,,,ddmmyy,,,*xx
+func findDate(val string, t time.Time) time.Time {
+	if len(val) < 6 {
+		return t
+	}
+
+	d, _ := strconv.ParseInt(val[0:2], 10, 8)
+	m, _ := strconv.ParseInt(val[2:4], 10, 8)
+	y, _ := strconv.ParseInt(val[4:6], 10, 8)
+	return time.Date(2000+int(y), time.Month(m), int(d), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
 // findAltitude returns the altitude from an NMEA sentence:
 // $--GGA,,,,,,,,,25.8,,,,,*63
 func findAltitude(val string) int32 {
@@ -0,0 +1,37 @@
+package gps
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestParseTime(t *testing.T) {
+	c := qt.New(t)
+	parser := NewParser()
+	for _, test := range []struct {
+		sentence string
+		want     time.Duration
+	}{
+		{"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47", 0},
+		{"$GPGGA,123519.5,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47", 500 * time.Millisecond},
+		{"$GPGGA,123519.25,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47", 250 * time.Millisecond},
+		{"$GPGGA,123519.125,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47", 125 * time.Millisecond},
+		{"$GPGGA,123519.05,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47", 50 * time.Millisecond},
+	} {
+		fix, err := parser.Parse(test.sentence)
+		c.Assert(err, qt.IsNil)
+		want := time.Date(0, 0, 0, 12, 35, 19, 0, time.UTC).Add(test.want)
+		c.Assert(fix.Time, qt.Equals, want, qt.Commentf("%s", test.sentence))
+	}
+}
+
+func TestParseRMC(t *testing.T) {
+	c := qt.New(t)
+	parser := NewParser()
+	fix, err := parser.Parse("$GPRMC,225446.33,A,4916.45,N,12311.12,W,000.5,054.7,191121,020.3,E,A*68")
+	c.Assert(err, qt.IsNil)
+	c.Assert(fix.Valid, qt.IsTrue)
+	c.Assert(fix.Time, qt.Equals, time.Date(2021, 11, 19, 22, 54, 46, 330e6, time.UTC))
+}
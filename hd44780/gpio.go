@@ -3,28 +3,28 @@ package hd44780
 import (
 	"errors"
 
-	"machine"
+	"tinygo.org/x/drivers"
 )
 
 type GPIO struct {
-	dataPins []machine.Pin
-	en       machine.Pin
-	rw       machine.Pin
-	rs       machine.Pin
+	dataPins []drivers.Pin
+	en       drivers.Pin
+	rw       drivers.Pin
+	rs       drivers.Pin
 
 	write func(data byte)
 	read  func() byte
 }
 
-func newGPIO(dataPins []machine.Pin, en, rs, rw machine.Pin, mode byte) Device {
-	pins := make([]machine.Pin, len(dataPins))
+func newGPIO(dataPins []drivers.Pin, en, rs, rw drivers.Pin, mode byte) Device {
+	pins := make([]drivers.Pin, len(dataPins))
 	for i := 0; i < len(dataPins); i++ {
-		dataPins[i].Configure(machine.PinConfig{Mode: machine.PinOutput})
+		configurePin(dataPins[i], true)
 		pins[i] = dataPins[i]
 	}
-	en.Configure(machine.PinConfig{Mode: machine.PinOutput})
-	rs.Configure(machine.PinConfig{Mode: machine.PinOutput})
-	rw.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	configurePin(en, true)
+	configurePin(rs, true)
+	configurePin(rw, true)
 	rw.Low()
 
 	gpio := GPIO{
@@ -90,12 +90,12 @@ func (g *GPIO) Read(data []byte) (n int, err error) {
 		return 0, errors.New("length greater than 0 is required")
 	}
 	g.rw.High()
-	g.reconfigureGPIOMode(machine.PinInput)
+	g.reconfigureGPIOMode(false)
 	for i := 0; i < len(data); i++ {
 		data[i] = g.read()
 		n++
 	}
-	g.reconfigureGPIOMode(machine.PinInput)
+	g.reconfigureGPIOMode(true)
 	return n, nil
 }
 
@@ -116,12 +116,25 @@ func (g *GPIO) read8BitMode() byte {
 	return data
 }
 
-func (g *GPIO) reconfigureGPIOMode(mode machine.PinMode) {
+func (g *GPIO) reconfigureGPIOMode(output bool) {
 	for i := 0; i < len(g.dataPins); i++ {
-		g.dataPins[i].Configure(machine.PinConfig{Mode: mode})
+		configurePin(g.dataPins[i], output)
 	}
 }
 
+// configurePin makes a pin an output or an input, if it can be changed.
+func configurePin(pin drivers.Pin, output bool) {
+	if pin, ok := pin.(drivers.IOPin); ok {
+		if output {
+			pin.SetOutput()
+		} else {
+			pin.SetInput()
+		}
+		return
+	}
+	configureMachinePin(pin, output)
+}
+
 // setPins sets high or low state on all data pins depending on data
 func (g *GPIO) setPins(data byte) {
 	mask := byte(1)
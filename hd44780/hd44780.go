@@ -7,8 +7,9 @@ package hd44780 // import "tinygo.org/x/drivers/hd44780"
 import (
 	"errors"
 	"io"
-	"machine"
 	"time"
+
+	"tinygo.org/x/drivers"
 )
 
 type Buser interface {
@@ -43,7 +44,12 @@ type Config struct {
 }
 
 // NewGPIO4Bit returns 4bit data length HD44780 driver. Datapins are LCD DB pins starting from DB4 to DB7
-func NewGPIO4Bit(dataPins []machine.Pin, e, rs, rw machine.Pin) (Device, error) {
+//
+// The pins can be pins of an I/O expander. The machine.Pin pins are
+// configured by the driver; the data pins of other types are switched
+// between input and output when they implement drivers.IOPin, and the other
+// pins must already be configured as outputs.
+func NewGPIO4Bit(dataPins []drivers.Pin, e, rs, rw drivers.Pin) (Device, error) {
 	const fourBitMode = 4
 	if len(dataPins) != fourBitMode {
 		return Device{}, errors.New("4 pins are required in data slice (D4-D7) when HD44780 is used in 4 bit mode")
@@ -52,7 +58,9 @@ func NewGPIO4Bit(dataPins []machine.Pin, e, rs, rw machine.Pin) (Device, error)
 }
 
 // NewGPIO8Bit returns 8bit data length HD44780 driver. Datapins are LCD DB pins starting from DB0 to DB7
-func NewGPIO8Bit(dataPins []machine.Pin, e, rs, rw machine.Pin) (Device, error) {
+//
+// The pins are configured as by NewGPIO4Bit.
+func NewGPIO8Bit(dataPins []drivers.Pin, e, rs, rw drivers.Pin) (Device, error) {
 	const eightBitMode = 8
 	if len(dataPins) != eightBitMode {
 		return Device{}, errors.New("8 pins are required in data slice (D0-D7) when HD44780 is used in 8 bit mode")
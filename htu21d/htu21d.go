@@ -0,0 +1,221 @@
+// Package htu21d provides a driver for the HTU21D digital humidity sensor
+// by TE Connectivity, and the SHT21 by Sensirion, which has the same
+// interface.
+//
+// Datasheets:
+// https://www.te.com/commerce/DocumentDelivery/DDEController?Action=showdoc&DocId=Data+Sheet%7FHPC199_6%7FA6%7Fpdf%7FEnglish%7FENG_DS_HPC199_6_A6.pdf
+// https://sensirion.com/media/documents/120BBE4C/63500094/Sensirion_Datasheet_Humidity_Sensor_SHT21.pdf
+//
+package htu21d // import "tinygo.org/x/drivers/htu21d"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/internal/crc8"
+)
+
+var (
+	errChecksum = errors.New("htu21d: checksum mismatch")
+	errTimeout  = errors.New("htu21d: timeout waiting for the measurement")
+)
+
+var (
+	_ drivers.Thermometer = &Device{}
+	_ drivers.Hygrometer  = &Device{}
+)
+
+// Resolution is the resolution of the measurements: a higher one lowers the
+// noise at the cost of a longer measurement.
+type Resolution uint8
+
+// Config holds the settings of the sensor. The zero value selects the
+// highest resolution and the no hold master mode.
+type Config struct {
+	Resolution Resolution
+
+	// HoldMaster has the sensor hold the clock of the bus low during a
+	// measurement, which blocks the other devices on the bus but does not
+	// poll the sensor. The I2C bus must support clock stretching. In no
+	// hold master mode, the sensor does not acknowledge its address until
+	// the measurement is done.
+	HoldMaster bool
+
+	// Heater turns the heater on, which warms the sensor by a few degrees
+	// to check that it works or to dry it after condensation.
+	Heater bool
+}
+
+// Device wraps an I2C connection to an HTU21D device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	config Config
+
+	buf [3]byte
+}
+
+// New creates a new HTU21D connection. The I2C bus must already be
+// configured.
+//
+// This function only creates the Device object, it does not touch the
+// device, which measures at the highest resolution after power on.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Configure sets the resolution and the heater in the user register, whose
+// other bits are kept, and selects the mode of the measurements.
+func (d *Device) Configure(config Config) error {
+	user, err := d.UserRegister()
+	if err != nil {
+		return err
+	}
+	user &^= 0x81 | USER_HEATER
+	user |= byte(config.Resolution&2)<<6 | byte(config.Resolution&1)
+	if config.Heater {
+		user |= USER_HEATER
+	}
+	d.buf[0] = CMD_WRITE_USER
+	d.buf[1] = user
+	if err := d.bus.Tx(d.Address, d.buf[:2], nil); err != nil {
+		return err
+	}
+	d.config = config
+	return nil
+}
+
+// UserRegister returns the user register, which holds the resolution, the
+// heater and the end of battery flag, set when the supply is below 2.25V.
+func (d *Device) UserRegister() (byte, error) {
+	d.buf[0] = CMD_READ_USER
+	if err := d.bus.Tx(d.Address, d.buf[:1], d.buf[1:2]); err != nil {
+		return 0, err
+	}
+	return d.buf[1], nil
+}
+
+// Reset sends a soft reset, which restores the default user register. It is
+// required to call Configure afterwards for other settings.
+func (d *Device) Reset() error {
+	d.buf[0] = CMD_SOFT_RESET
+	if err := d.bus.Tx(d.Address, d.buf[:1], nil); err != nil {
+		return err
+	}
+	d.config = Config{}
+	time.Sleep(15 * time.Millisecond)
+	return nil
+}
+
+// ReadTemperature returns the temperature in celsius milli degrees (°C/1000).
+func (d *Device) ReadTemperature() (int32, error) {
+	cmd := byte(CMD_TEMPERATURE_NO_HOLD)
+	if d.config.HoldMaster {
+		cmd = CMD_TEMPERATURE_HOLD
+	}
+	// up to 50ms at 14 bits, 25ms, 13ms and 7ms at 13, 12 and 11 bits
+	duration := [4]time.Duration{50, 13, 25, 7}[d.config.Resolution&3] * time.Millisecond
+	raw, err := d.measure(cmd, duration)
+	if err != nil {
+		return 0, err
+	}
+	return int32(int64(raw)*175720>>16) - 46850, nil
+}
+
+// ReadHumidity returns the relative humidity in hundredths of a percent,
+// compensated for the temperature as advised by the datasheet, which takes
+// a measurement of both.
+func (d *Device) ReadHumidity() (int32, error) {
+	_, humidity, err := d.ReadTemperatureHumidity()
+	return humidity, err
+}
+
+// ReadTemperatureHumidity measures the temperature and the humidity, and
+// returns the temperature in celsius milli degrees and the relative humidity
+// in hundredths of a percent, compensated for the temperature.
+func (d *Device) ReadTemperatureHumidity() (temp int32, humidity int32, err error) {
+	temp, err = d.ReadTemperature()
+	if err != nil {
+		return
+	}
+	humidity, err = d.ReadRawHumidity()
+	if err != nil {
+		return
+	}
+	return temp, compensate(humidity, temp), nil
+}
+
+// ReadRawHumidity returns the relative humidity in hundredths of a percent,
+// without the compensation for the temperature, which is under 1% between
+// 15°C and 35°C.
+func (d *Device) ReadRawHumidity() (int32, error) {
+	cmd := byte(CMD_HUMIDITY_NO_HOLD)
+	if d.config.HoldMaster {
+		cmd = CMD_HUMIDITY_HOLD
+	}
+	// up to 16ms at 12 bits, 3ms, 5ms and 8ms at 8, 10 and 11 bits
+	duration := [4]time.Duration{16, 3, 5, 8}[d.config.Resolution&3] * time.Millisecond
+	raw, err := d.measure(cmd, duration)
+	if err != nil {
+		return 0, err
+	}
+	return limit(int32(int64(raw)*12500>>16) - 600), nil
+}
+
+// compensate applies the temperature coefficient of -0.15%/°C of the
+// datasheet, which is specified from 0°C to 80°C, to a humidity measured at
+// the temperature.
+func compensate(humidity, temp int32) int32 {
+	switch {
+	case temp < 0:
+		temp = 0
+	case temp > 80000:
+		temp = 80000
+	}
+	return limit(humidity - (25000-temp)*15/1000)
+}
+
+// limit limits a humidity to 0-100%.
+func limit(humidity int32) int32 {
+	switch {
+	case humidity < 0:
+		return 0
+	case humidity > 10000:
+		return 10000
+	}
+	return humidity
+}
+
+// measure sends a measurement command and returns the raw value, without
+// its status bits. In no hold master mode it waits for the duration of the
+// measurement, then polls the sensor until it answers.
+func (d *Device) measure(cmd byte, duration time.Duration) (uint16, error) {
+	d.buf[0] = cmd
+	data := d.buf[:3]
+	if d.config.HoldMaster {
+		if err := d.bus.Tx(d.Address, d.buf[:1], data); err != nil {
+			return 0, err
+		}
+	} else {
+		if err := d.bus.Tx(d.Address, d.buf[:1], nil); err != nil {
+			return 0, err
+		}
+		start := time.Now()
+		time.Sleep(duration)
+		for d.bus.Tx(d.Address, nil, data) != nil {
+			if time.Since(start) > 2*duration {
+				return 0, errTimeout
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if crc8.Update(0, crc8.PolySensirion, data[:2]...) != data[2] {
+		return 0, errChecksum
+	}
+	return (uint16(data[0])<<8 | uint16(data[1])) &^ 3, nil
+}
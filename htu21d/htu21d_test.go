@@ -0,0 +1,119 @@
+package htu21d
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers/tester"
+)
+
+// sensor is a simulated HTU21D, which answers with the examples of the
+// datasheet.
+type sensor struct {
+	user     byte
+	commands []byte
+	answer   []byte
+
+	// busy is the number of reads not acknowledged in no hold master mode
+	busy int
+	// held is the number of measurements in hold master mode
+	held int
+}
+
+func (s *sensor) tx(w, r []byte) error {
+	if len(w) > 0 {
+		s.commands = append(s.commands, w[0])
+		switch w[0] {
+		case CMD_TEMPERATURE_HOLD, CMD_TEMPERATURE_NO_HOLD:
+			s.answer = []byte{0x68, 0x3A, 0x7C}
+		case CMD_HUMIDITY_HOLD, CMD_HUMIDITY_NO_HOLD:
+			s.answer = []byte{0x4E, 0x85, 0x6B}
+		case CMD_READ_USER:
+			s.answer = []byte{s.user}
+		case CMD_WRITE_USER:
+			s.user = w[1]
+		case CMD_SOFT_RESET:
+			s.user = 0x02
+		}
+		if len(r) == 0 {
+			return nil
+		}
+		if w[0] == CMD_TEMPERATURE_HOLD || w[0] == CMD_HUMIDITY_HOLD {
+			s.held++
+		}
+	} else if s.busy > 0 {
+		s.busy--
+		return tester.ErrNack
+	}
+	copy(r, s.answer)
+	return nil
+}
+
+// bus returns an I2C bus with the sensor on it.
+func (s *sensor) bus(c *qt.C) *tester.I2CBus {
+	bus := tester.NewI2CBus(c)
+	bus.AddDevice(tester.NewI2CCommandDevice(c, Address, s.tx))
+	return bus
+}
+
+func TestNoHold(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{user: 0x02, busy: 2}
+	d := New(s.bus(c))
+
+	temp, err := d.ReadTemperature()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(24686))
+	c.Assert(s.busy, qt.Equals, 0)
+
+	humidity, err := d.ReadRawHumidity()
+	c.Assert(err, qt.IsNil)
+	c.Assert(humidity, qt.Equals, int32(3233))
+
+	temp, humidity, err = d.ReadTemperatureHumidity()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(24686))
+	c.Assert(humidity, qt.Equals, int32(3229))
+	c.Assert(s.held, qt.Equals, 0)
+
+	s.busy = 1000
+	_, err = d.ReadTemperature()
+	c.Assert(err, qt.Equals, errTimeout)
+}
+
+func TestHold(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{user: 0x02}
+	d := New(s.bus(c))
+	c.Assert(d.Configure(Config{HoldMaster: true}), qt.IsNil)
+	humidity, err := d.ReadHumidity()
+	c.Assert(err, qt.IsNil)
+	c.Assert(humidity, qt.Equals, int32(3229))
+	c.Assert(s.held, qt.Equals, 2)
+	c.Assert(s.commands[1:], qt.DeepEquals, []byte{CMD_WRITE_USER, CMD_TEMPERATURE_HOLD, CMD_HUMIDITY_HOLD})
+}
+
+func TestConfigure(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{user: 0x02 | USER_END_OF_BATTERY}
+	d := New(s.bus(c))
+	c.Assert(d.Configure(Config{Resolution: RESOLUTION_RH10_T13, Heater: true}), qt.IsNil)
+	c.Assert(s.user, qt.Equals, byte(0x80|USER_END_OF_BATTERY|USER_HEATER|0x02))
+	c.Assert(d.Configure(Config{Resolution: RESOLUTION_RH8_T12}), qt.IsNil)
+	c.Assert(s.user, qt.Equals, byte(0x01|USER_END_OF_BATTERY|0x02))
+
+	c.Assert(d.Reset(), qt.IsNil)
+	user, err := d.UserRegister()
+	c.Assert(err, qt.IsNil)
+	c.Assert(user, qt.Equals, byte(0x02))
+}
+
+func TestCompensate(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(compensate(5000, 25000), qt.Equals, int32(5000))
+	c.Assert(compensate(5000, 45000), qt.Equals, int32(5300))
+	// limited to the specified range of temperatures
+	c.Assert(compensate(5000, 100000), qt.Equals, int32(5825))
+	c.Assert(compensate(100, -20000), qt.Equals, int32(0))
+}
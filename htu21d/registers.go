@@ -0,0 +1,28 @@
+package htu21d
+
+// The I2C address of the device.
+const Address = 0x40
+
+// Commands. Names and values copied from the datasheet.
+const (
+	CMD_TEMPERATURE_HOLD    = 0xE3
+	CMD_HUMIDITY_HOLD       = 0xE5
+	CMD_TEMPERATURE_NO_HOLD = 0xF3
+	CMD_HUMIDITY_NO_HOLD    = 0xF5
+	CMD_WRITE_USER          = 0xE6
+	CMD_READ_USER           = 0xE7
+	CMD_SOFT_RESET          = 0xFE
+
+	// Bits of the user register, along with the resolution in bits 7 and
+	// 0.
+	USER_END_OF_BATTERY = 0x40
+	USER_HEATER         = 0x04
+)
+
+// Resolutions of the humidity and the temperature, in bits.
+const (
+	RESOLUTION_RH12_T14 Resolution = iota
+	RESOLUTION_RH8_T12
+	RESOLUTION_RH10_T13
+	RESOLUTION_RH11_T11
+)
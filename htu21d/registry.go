@@ -0,0 +1,22 @@
+package htu21d
+
+import (
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/registry"
+)
+
+func init() {
+	registry.Register(registry.Driver{
+		Name:      "htu21d",
+		Bus:       registry.I2C,
+		Addresses: []uint16{Address},
+		Probe: func(bus drivers.I2C, address uint16) bool {
+			d := New(bus)
+			d.Address = address
+			// the reserved bits 3 to 5 of the user register are 0, and
+			// the OTP reload bit is set after power on
+			user, err := d.UserRegister()
+			return err == nil && user&0x3A == 0x02
+		},
+	})
+}
@@ -0,0 +1,175 @@
+// Package humidity computes metrics derived from the temperature and the
+// relative humidity measured by hygrometers such as the DHT22, SHT3x or
+// BME280: the dew point, the heat index and the absolute humidity.
+//
+// Every function has an integer variant, which uses the units of the
+// drivers of this repository (milli-degrees Celsius and hundredths of a
+// percent) and does not need an FPU, and a floating point variant in
+// degrees Celsius and percent.
+//
+// Formulas:
+// https://en.wikipedia.org/wiki/Dew_point#Calculating_the_dew_point
+// https://www.wpc.ncep.noaa.gov/html/heatindex_equation.shtml
+package humidity // import "tinygo.org/x/drivers/humidity"
+
+import "math"
+
+// Magnus formula coefficients, in Q16 fixed point for the integer variants.
+const (
+	magnusB = 17.62
+	magnusC = 243.12
+
+	magnusBQ16 = 1154744  // 17.62
+	magnusCQ16 = 15933358 // 243.12
+)
+
+// DewPoint returns the dew point in milli-degrees Celsius, from the
+// temperature in milli-degrees Celsius and the relative humidity in
+// hundredths of a percent.
+func DewPoint(temperature int32, humidity int32) int32 {
+	if humidity <= 0 {
+		humidity = 1
+	}
+	t := int64(temperature) << 16 / 1000
+	gamma := ln(int64(humidity)<<16/10000) + magnusBQ16*t/(magnusCQ16+t)
+	return int32(magnusCQ16 * gamma / (magnusBQ16 - gamma) * 1000 >> 16)
+}
+
+// DewPointFloat returns the dew point in degrees Celsius, from the
+// temperature in degrees Celsius and the relative humidity in percent.
+func DewPointFloat(temperature, humidity float32) float32 {
+	if humidity <= 0 {
+		humidity = 0.01
+	}
+	t := float64(temperature)
+	gamma := math.Log(float64(humidity)/100) + magnusB*t/(magnusC+t)
+	return float32(magnusC * gamma / (magnusB - gamma))
+}
+
+// AbsoluteHumidity returns the mass of water vapor in the air in mg/m³,
+// from the temperature in milli-degrees Celsius and the relative humidity
+// in hundredths of a percent.
+func AbsoluteHumidity(temperature int32, humidity int32) int32 {
+	t := int64(temperature) << 16 / 1000
+	// saturation vapor pressure in hPa
+	es := 400556 * exp(1158021*t/(15958016+t)) >> 16 // 6.112 * exp(17.67t / (243.5+t))
+	return int32(es * int64(humidity) * 21674 / ((273150 + int64(temperature)) << 16))
+}
+
+// AbsoluteHumidityFloat returns the mass of water vapor in the air in g/m³,
+// from the temperature in degrees Celsius and the relative humidity in
+// percent.
+func AbsoluteHumidityFloat(temperature, humidity float32) float32 {
+	t := float64(temperature)
+	es := 6.112 * math.Exp(17.67*t/(243.5+t))
+	return float32(es * float64(humidity) * 2.1674 / (273.15 + t))
+}
+
+// HeatIndex returns the apparent temperature felt by humans in
+// milli-degrees Celsius, from the temperature in milli-degrees Celsius and
+// the relative humidity in hundredths of a percent. It uses the regression
+// of the US National Weather Service, which is meant for temperatures above
+// 27°C; below that it is close to the temperature.
+func HeatIndex(temperature int32, humidity int32) int32 {
+	// the formula works in hundredths of degrees Fahrenheit
+	t := int64(temperature)*9/50 + 3200
+	r := int64(humidity)
+	hi := (t + 6100 + (t-6800)*12/10 + r*94/1000) / 2
+	if (hi+t)/2 >= 8000 {
+		// coefficients scaled by 1e8
+		s := -4237900000 +
+			204901523*t/100 +
+			1014333127*r/100 -
+			22475541*t*r/10000 -
+			683783*t*t/10000 -
+			5481717*r*r/10000 +
+			122874*t*t*r/1000000 +
+			85282*t*r*r/1000000 -
+			199*(t*t*r*r/100000000)
+		hi = s / 1000000
+		switch {
+		case r < 1300 && t > 8000 && t < 11200:
+			d := t - 9500
+			if d < 0 {
+				d = -d
+			}
+			hi -= (1300 - r) * sqrt((1700-d)*1000000/1700) / 4000
+		case r > 8500 && t > 8000 && t < 8700:
+			hi += (r - 8500) * (8700 - t) / 5000
+		}
+	}
+	return int32((hi - 3200) * 50 / 9)
+}
+
+// HeatIndexFloat returns the apparent temperature felt by humans in degrees
+// Celsius, from the temperature in degrees Celsius and the relative
+// humidity in percent.
+func HeatIndexFloat(temperature, humidity float32) float32 {
+	t := float64(temperature)*9/5 + 32
+	r := float64(humidity)
+	hi := 0.5 * (t + 61 + (t-68)*1.2 + r*0.094)
+	if (hi+t)/2 >= 80 {
+		hi = -42.379 + 2.04901523*t + 10.14333127*r - 0.22475541*t*r -
+			0.00683783*t*t - 0.05481717*r*r + 0.00122874*t*t*r +
+			0.00085282*t*r*r - 0.00000199*t*t*r*r
+		switch {
+		case r < 13 && t > 80 && t < 112:
+			hi -= (13 - r) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+		case r > 85 && t > 80 && t < 87:
+			hi += (r - 85) / 10 * (87 - t) / 5
+		}
+	}
+	return float32((hi - 32) * 5 / 9)
+}
+
+// ln returns the natural logarithm of a positive Q16 number, in Q16.
+func ln(x int64) int64 {
+	// log2 of the integer part, then of the mantissa in [1, 2) bit by bit
+	n := int64(0)
+	for x >= 2<<16 {
+		x >>= 1
+		n++
+	}
+	for x < 1<<16 {
+		x <<= 1
+		n--
+	}
+	log2 := n << 16
+	for bit := int64(1 << 15); bit > 0; bit >>= 1 {
+		x = x * x >> 16
+		if x >= 2<<16 {
+			x >>= 1
+			log2 |= bit
+		}
+	}
+	return log2 * 45426 >> 16 // ln(2)
+}
+
+// exp returns e to the power of a Q16 number, in Q16.
+func exp(x int64) int64 {
+	y := x * 94548 >> 16 // log2(e)
+	n := y >> 16
+	f := y & 0xFFFF
+	// 2^f for f in [0, 1), from the series of e^(f ln 2)
+	p := int64(0)
+	for _, c := range [...]int64{10, 87, 630, 3638, 15744, 45426} {
+		p = (p + c) * f >> 16
+	}
+	p += 1 << 16
+	if n >= 0 {
+		return p << uint(n)
+	}
+	return p >> uint(-n)
+}
+
+// sqrt returns the integer square root of a non-negative number.
+func sqrt(x int64) int64 {
+	if x <= 0 {
+		return 0
+	}
+	r := x
+	for y := (r + 1) / 2; y < r; y = (r + x/r) / 2 {
+		r = y
+	}
+	return r
+}
@@ -0,0 +1,56 @@
+package humidity
+
+import (
+	"math"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+var conditions = []struct{ t, rh int32 }{
+	{-10000, 8000},
+	{0, 5000},
+	{21500, 4500},
+	{25000, 6000},
+	{28000, 9000},
+	{30000, 1000},
+	{35000, 500},
+	{40000, 3000},
+	{45000, 7500},
+}
+
+func assertNear(c *qt.C, got, want, tolerance float64, input ...interface{}) {
+	c.Helper()
+	c.Assert(math.Abs(got-want) <= tolerance, qt.IsTrue, qt.Commentf("got %v, want %v %v", got, want, input))
+}
+
+func TestDewPoint(t *testing.T) {
+	c := qt.New(t)
+	assertNear(c, float64(DewPointFloat(25, 60)), 16.7, 0.05)
+	assertNear(c, float64(DewPointFloat(10, 100)), 10, 0.01)
+	for _, tc := range conditions {
+		want := DewPointFloat(float32(tc.t)/1000, float32(tc.rh)/100)
+		assertNear(c, float64(DewPoint(tc.t, tc.rh)), float64(want)*1000, 50, tc)
+	}
+}
+
+func TestAbsoluteHumidity(t *testing.T) {
+	c := qt.New(t)
+	assertNear(c, float64(AbsoluteHumidityFloat(25, 60)), 13.8, 0.1)
+	for _, tc := range conditions {
+		want := float64(AbsoluteHumidityFloat(float32(tc.t)/1000, float32(tc.rh)/100)) * 1000
+		// within 0.5%
+		assertNear(c, float64(AbsoluteHumidity(tc.t, tc.rh)), want, 1+want/200, tc)
+	}
+}
+
+func TestHeatIndex(t *testing.T) {
+	c := qt.New(t)
+	// 90°F and 70% feel like 106°F in the table of the NWS
+	assertNear(c, float64(HeatIndexFloat(32.22, 70)), 41.1, 0.6)
+	assertNear(c, float64(HeatIndexFloat(20, 50)), 20, 1)
+	for _, tc := range conditions {
+		want := HeatIndexFloat(float32(tc.t)/1000, float32(tc.rh)/100)
+		assertNear(c, float64(HeatIndex(tc.t, tc.rh)), float64(want)*1000, 50, tc)
+	}
+}
@@ -0,0 +1,259 @@
+// Package i2csoft implements an I2C master by bit-banging two pins, for boards
+// whose hardware I2C pins are taken or that need a second bus. It implements
+// drivers.I2C, so it can be given to any I2C driver in this repository.
+//
+// Both lines need a pull-up resistor. The pins are only driven low, and are
+// inputs otherwise, so that a device can stretch the clock.
+//
+// Specification:
+// https://www.nxp.com/docs/en/user-guide/UM10204.pdf
+package i2csoft // import "tinygo.org/x/drivers/i2csoft"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	errNack    = errors.New("i2csoft: no acknowledge")
+	errStretch = errors.New("i2csoft: clock held low by a device")
+)
+
+var _ drivers.I2C = &I2C{}
+
+// I2C is an I2C bus on two pins.
+type I2C struct {
+	scl, sda drivers.IOPin
+
+	// half of a clock period
+	delay time.Duration
+
+	// StretchTimeout is the longest time a device may hold the clock low,
+	// 10ms by default.
+	StretchTimeout time.Duration
+
+	reg [1]byte
+}
+
+// Config holds the settings of the bus.
+type Config struct {
+	// Frequency of the clock in Hz, 100kHz if not set. The actual
+	// frequency is lower, because of the time spent toggling the pins.
+	Frequency uint32
+}
+
+// NewPins returns a bus on the pins, which can be any drivers.IOPin. New does
+// the same with two machine.Pin.
+func NewPins(scl, sda drivers.IOPin) *I2C {
+	return &I2C{
+		scl:            scl,
+		sda:            sda,
+		StretchTimeout: 10 * time.Millisecond,
+	}
+}
+
+// Configure sets the frequency of the clock and releases both lines.
+func (i2c *I2C) Configure(config Config) error {
+	if config.Frequency == 0 {
+		config.Frequency = 100000
+	}
+	i2c.delay = time.Second / time.Duration(2*config.Frequency)
+	i2c.scl.SetInput()
+	i2c.sda.SetInput()
+	return nil
+}
+
+// Tx writes the bytes of w to the device at the address, then reads len(r)
+// bytes after a repeated start. Either may be empty; with both empty, Tx
+// only checks that the device acknowledges its address.
+func (i2c *I2C) Tx(addr uint16, w, r []byte) error {
+	if err := i2c.start(); err != nil {
+		return err
+	}
+	err := i2c.tx(addr, w, r)
+	if stopErr := i2c.stop(); err == nil {
+		err = stopErr
+	}
+	return i2c.trace(uint8(addr), err)
+}
+
+func (i2c *I2C) tx(addr uint16, w, r []byte) error {
+	if len(w) > 0 || len(r) == 0 {
+		if err := i2c.writeByte(uint8(addr) << 1); err != nil {
+			return err
+		}
+		for _, b := range w {
+			if err := i2c.writeByte(b); err != nil {
+				return err
+			}
+		}
+		if len(r) == 0 {
+			return nil
+		}
+		// repeated start
+		if err := i2c.start(); err != nil {
+			return err
+		}
+	}
+	if err := i2c.writeByte(uint8(addr)<<1 | 1); err != nil {
+		return err
+	}
+	for i := range r {
+		b, err := i2c.readByte(i < len(r)-1)
+		if err != nil {
+			return err
+		}
+		r[i] = b
+	}
+	return nil
+}
+
+// ReadRegister reads len(buf) bytes starting at the register of the device.
+func (i2c *I2C) ReadRegister(addr uint8, r uint8, buf []byte) error {
+	i2c.reg[0] = r
+	return i2c.Tx(uint16(addr), i2c.reg[:], buf)
+}
+
+// WriteRegister writes buf starting at the register of the device.
+func (i2c *I2C) WriteRegister(addr uint8, r uint8, buf []byte) error {
+	if err := i2c.start(); err != nil {
+		return err
+	}
+	err := i2c.writeByte(addr << 1)
+	if err == nil {
+		err = i2c.writeByte(r)
+	}
+	for i := 0; i < len(buf) && err == nil; i++ {
+		err = i2c.writeByte(buf[i])
+	}
+	if stopErr := i2c.stop(); err == nil {
+		err = stopErr
+	}
+	return i2c.trace(addr, err)
+}
+
+// trace traces the errors of the bus with the address of the device: "nack"
+// when it did not acknowledge a byte and "stretch" when it held the clock
+// low for too long.
+func (i2c *I2C) trace(addr uint8, err error) error {
+	if drivers.Trace == nil {
+		return err
+	}
+	switch err {
+	case errNack:
+		drivers.Trace(drivers.TraceError, "i2csoft", "nack", int(addr))
+	case errStretch:
+		drivers.Trace(drivers.TraceError, "i2csoft", "stretch", int(addr))
+	}
+	return err
+}
+
+// start sends a start condition, or a repeated start in a transfer: SDA
+// falls while SCL is high.
+func (i2c *I2C) start() error {
+	i2c.sda.SetInput()
+	wait(i2c.delay)
+	if err := i2c.releaseClock(); err != nil {
+		return err
+	}
+	wait(i2c.delay)
+	low(i2c.sda)
+	wait(i2c.delay)
+	low(i2c.scl)
+	return nil
+}
+
+// stop sends a stop condition: SDA rises while SCL is high.
+func (i2c *I2C) stop() error {
+	low(i2c.sda)
+	wait(i2c.delay)
+	err := i2c.releaseClock()
+	wait(i2c.delay)
+	i2c.sda.SetInput()
+	wait(i2c.delay)
+	return err
+}
+
+// releaseClock releases SCL and waits for it to go high, as a device may
+// hold it low until it is ready.
+func (i2c *I2C) releaseClock() error {
+	i2c.scl.SetInput()
+	if i2c.scl.Get() {
+		return nil
+	}
+	start := time.Now()
+	for !i2c.scl.Get() {
+		if time.Since(start) > i2c.StretchTimeout {
+			return errStretch
+		}
+	}
+	return nil
+}
+
+// bit sends a clock pulse with SDA released for a 1 or low for a 0, and
+// returns the level of SDA at the rising edge.
+func (i2c *I2C) bit(b bool) (bool, error) {
+	if b {
+		i2c.sda.SetInput()
+	} else {
+		low(i2c.sda)
+	}
+	wait(i2c.delay)
+	if err := i2c.releaseClock(); err != nil {
+		return false, err
+	}
+	level := i2c.sda.Get()
+	wait(i2c.delay)
+	low(i2c.scl)
+	return level, nil
+}
+
+// writeByte sends a byte, most significant bit first, and returns errNack
+// if the device does not acknowledge it.
+func (i2c *I2C) writeByte(b byte) error {
+	for i := 7; i >= 0; i-- {
+		if _, err := i2c.bit(b&(1<<uint(i)) != 0); err != nil {
+			return err
+		}
+	}
+	nack, err := i2c.bit(true)
+	if err != nil {
+		return err
+	}
+	if nack {
+		return errNack
+	}
+	return nil
+}
+
+// readByte reads a byte and acknowledges it when more bytes are to be read.
+func (i2c *I2C) readByte(ack bool) (byte, error) {
+	var b byte
+	for i := 0; i < 8; i++ {
+		level, err := i2c.bit(true)
+		if err != nil {
+			return b, err
+		}
+		b <<= 1
+		if level {
+			b |= 1
+		}
+	}
+	_, err := i2c.bit(!ack)
+	return b, err
+}
+
+// low drives the line low.
+func low(p drivers.IOPin) {
+	p.Low()
+	p.SetOutput()
+}
+
+// wait waits with a busy loop, as time.Sleep is not precise enough.
+func wait(d time.Duration) {
+	start := time.Now()
+	for time.Since(start) < d {
+	}
+}
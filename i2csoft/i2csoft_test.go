@@ -0,0 +1,236 @@
+package i2csoft
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers"
+)
+
+// bus connects the master to a simulated device with a register pointer,
+// like an EEPROM, which follows the edges of the lines.
+type bus struct {
+	// levels driven by the master and the device, true when released
+	scl, sda, deviceSDA bool
+
+	// the device holds SCL low for this many reads after the next release
+	stretch, stretching int
+
+	address     uint8
+	mem         [16]byte
+	pointer     int
+	addressing  bool // the next byte is the address
+	selected    bool
+	reading     bool
+	sending     bool
+	first       bool // the next written byte is the register pointer
+	bits        int
+	shift, out  byte
+	masterAck   bool
+	transitions []string
+}
+
+func newBus(address uint8) *bus {
+	return &bus{scl: true, sda: true, deviceSDA: true, address: address}
+}
+
+func (b *bus) lineSDA() bool {
+	return b.sda && b.deviceSDA
+}
+
+// change updates a line driven by the master and runs the device.
+func (b *bus) change(scl bool, level bool) {
+	oldSCL, oldSDA := b.scl, b.lineSDA()
+	if scl {
+		if level && !b.scl && b.stretch > 0 {
+			b.stretching, b.stretch = b.stretch, 0
+		}
+		b.scl = level
+	} else {
+		b.sda = level
+	}
+	newSDA := b.lineSDA()
+	switch {
+	case b.scl && oldSCL && oldSDA && !newSDA:
+		b.transitions = append(b.transitions, "start")
+		b.addressing, b.selected, b.sending, b.first = true, false, false, true
+		// the falling edge of SCL after the start does not end a bit
+		b.bits, b.shift = -1, 0
+	case b.scl && oldSCL && !oldSDA && newSDA:
+		b.transitions = append(b.transitions, "stop")
+		b.addressing, b.selected, b.sending = false, false, false
+	case b.scl && !oldSCL:
+		b.rising()
+	case !b.scl && oldSCL:
+		b.falling()
+	}
+}
+
+func (b *bus) rising() {
+	switch {
+	case b.bits < 8 && !b.sending:
+		b.shift <<= 1
+		if b.lineSDA() {
+			b.shift |= 1
+		}
+	case b.bits == 8 && b.sending:
+		b.masterAck = !b.lineSDA()
+	}
+}
+
+func (b *bus) falling() {
+	b.bits++
+	switch {
+	case b.bits == 8 && b.sending:
+		// release SDA for the acknowledge of the master
+		b.deviceSDA = true
+	case b.bits == 8:
+		b.deviceSDA = !b.receive(b.shift)
+	case b.bits == 9:
+		b.bits, b.shift = 0, 0
+		b.deviceSDA = true
+		switch {
+		case b.sending && !b.masterAck:
+			b.sending = false
+		case b.sending:
+			b.pointer++
+			b.load()
+		case b.selected && b.reading:
+			b.sending = true
+			b.load()
+		}
+	case b.sending:
+		b.deviceSDA = b.out&(0x80>>uint(b.bits)) != 0
+	}
+}
+
+// load puts the first bit of the next byte to send on SDA.
+func (b *bus) load() {
+	b.out = b.mem[b.pointer%len(b.mem)]
+	b.deviceSDA = b.out&0x80 != 0
+}
+
+// receive handles a byte written by the master and returns whether it is
+// acknowledged.
+func (b *bus) receive(c byte) bool {
+	switch {
+	case b.addressing:
+		b.addressing = false
+		b.selected = c>>1 == b.address
+		b.reading = c&1 != 0
+	case !b.selected:
+	case b.first:
+		b.pointer = int(c)
+		b.first = false
+	default:
+		b.mem[b.pointer%len(b.mem)] = c
+		b.pointer++
+	}
+	return b.selected
+}
+
+type pin struct {
+	b             *bus
+	scl           bool
+	output, level bool
+}
+
+func (p *pin) Get() bool {
+	if !p.scl {
+		return p.b.lineSDA()
+	}
+	if p.b.stretching > 0 {
+		p.b.stretching--
+		return false
+	}
+	return p.b.scl
+}
+
+func (p *pin) Set(high bool) { p.level = high; p.update() }
+func (p *pin) High()         { p.Set(true) }
+func (p *pin) Low()          { p.Set(false) }
+func (p *pin) SetOutput()    { p.output = true; p.update() }
+func (p *pin) SetInput()     { p.output = false; p.update() }
+
+func (p *pin) update() {
+	p.b.change(p.scl, !p.output || p.level)
+}
+
+func newI2C(b *bus) *I2C {
+	i2c := NewPins(&pin{b: b, scl: true}, &pin{b: b})
+	i2c.Configure(Config{Frequency: 1000000})
+	return i2c
+}
+
+func TestRegisters(t *testing.T) {
+	c := qt.New(t)
+	b := newBus(0x50)
+	i2c := newI2C(b)
+	c.Assert(i2c.WriteRegister(0x50, 2, []byte{0xAA, 0x5B, 0x01}), qt.IsNil)
+	c.Assert(b.mem[2:5], qt.DeepEquals, []byte{0xAA, 0x5B, 0x01})
+	c.Assert(b.transitions, qt.DeepEquals, []string{"start", "stop"})
+
+	b.transitions = nil
+	buf := make([]byte, 3)
+	c.Assert(i2c.ReadRegister(0x50, 2, buf), qt.IsNil)
+	c.Assert(buf, qt.DeepEquals, []byte{0xAA, 0x5B, 0x01})
+	// with a repeated start between the write and the read
+	c.Assert(b.transitions, qt.DeepEquals, []string{"start", "start", "stop"})
+
+	// a read without register starts at the current pointer
+	b.transitions = nil
+	b.pointer = 2
+	c.Assert(i2c.Tx(0x50, nil, buf[:1]), qt.IsNil)
+	c.Assert(buf[0], qt.Equals, byte(0xAA))
+	c.Assert(b.transitions, qt.DeepEquals, []string{"start", "stop"})
+}
+
+func TestNack(t *testing.T) {
+	c := qt.New(t)
+	b := newBus(0x50)
+	i2c := newI2C(b)
+	c.Assert(i2c.Tx(0x50, nil, nil), qt.IsNil)
+	c.Assert(i2c.Tx(0x51, nil, nil), qt.Equals, errNack)
+	c.Assert(i2c.WriteRegister(0x51, 0, []byte{1}), qt.Equals, errNack)
+	// the bus is released after the error
+	c.Assert(b.scl && b.sda, qt.IsTrue)
+}
+
+func TestClockStretching(t *testing.T) {
+	c := qt.New(t)
+	b := newBus(0x50)
+	i2c := newI2C(b)
+	b.stretch = 100
+	c.Assert(i2c.WriteRegister(0x50, 0, []byte{0x42}), qt.IsNil)
+	c.Assert(b.mem[0], qt.Equals, byte(0x42))
+	c.Assert(b.stretching, qt.Equals, 0)
+
+	i2c.StretchTimeout = time.Millisecond
+	b.stretch = 1 << 30
+	c.Assert(i2c.WriteRegister(0x50, 0, []byte{0x43}), qt.Equals, errStretch)
+}
+
+func TestTrace(t *testing.T) {
+	c := qt.New(t)
+	var events []string
+	var args [][]int
+	drivers.Trace = func(level, driver, event string, a ...int) {
+		events = append(events, level+" "+driver+" "+event)
+		args = append(args, a)
+	}
+	defer func() { drivers.Trace = nil }()
+
+	b := newBus(0x50)
+	bus := drivers.TraceI2C(newI2C(b))
+	c.Assert(bus.WriteRegister(0x50, 2, []byte{1, 2}), qt.IsNil)
+	c.Assert(bus.Tx(0x51, []byte{0}, nil), qt.Equals, errNack)
+	c.Assert(events, qt.DeepEquals, []string{
+		"debug i2c write",
+		"debug i2c tx",
+		"error i2csoft nack",
+		"error i2c error",
+	})
+	c.Assert(args, qt.DeepEquals, [][]int{{0x50, 2, 2}, {0x51, 1, 0}, {0x51}, {0x51}})
+}
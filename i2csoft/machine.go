@@ -0,0 +1,37 @@
+// +build tinygo
+
+package i2csoft
+
+import "machine"
+
+// New returns a bus on two pins of the microcontroller.
+func New(scl, sda machine.Pin) *I2C {
+	return NewPins(machinePin(scl), machinePin(sda))
+}
+
+// machinePin implements drivers.IOPin.
+type machinePin machine.Pin
+
+func (p machinePin) Get() bool {
+	return machine.Pin(p).Get()
+}
+
+func (p machinePin) Set(high bool) {
+	machine.Pin(p).Set(high)
+}
+
+func (p machinePin) High() {
+	machine.Pin(p).High()
+}
+
+func (p machinePin) Low() {
+	machine.Pin(p).Low()
+}
+
+func (p machinePin) SetOutput() {
+	machine.Pin(p).Configure(machine.PinConfig{Mode: machine.PinOutput})
+}
+
+func (p machinePin) SetInput() {
+	machine.Pin(p).Configure(machine.PinConfig{Mode: machine.PinInput})
+}
@@ -0,0 +1,35 @@
+// Package crc8 computes the CRC-8 checksums used by the sensors to protect
+// their measurements on the bus.
+package crc8 // import "tinygo.org/x/drivers/internal/crc8"
+
+// Polynomials, without their x^8 term.
+const (
+	// PolySensirion is x^8 + x^5 + x^4 + 1, used by the Sensirion sensors
+	// and by humidity sensors of other manufacturers.
+	PolySensirion = 0x31
+
+	// PolySMBus is x^8 + x^2 + x + 1, used by the PEC of SMBus.
+	PolySMBus = 0x07
+)
+
+// Update continues the checksum crc over the bytes of data, most
+// significant bit first, with the polynomial.
+func Update(crc, poly byte, data ...byte) byte {
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ poly
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Sensirion returns the checksum of the words of the Sensirion sensors,
+// with an initial value of 0xFF.
+func Sensirion(data []byte) byte {
+	return Update(0xFF, PolySensirion, data...)
+}
@@ -0,0 +1,26 @@
+package crc8
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestSensirion(t *testing.T) {
+	c := qt.New(t)
+	// example of the SHT3x, SHT4x and SGP30 datasheets
+	c.Assert(Sensirion([]byte{0xBE, 0xEF}), qt.Equals, byte(0x92))
+}
+
+func TestUpdate(t *testing.T) {
+	c := qt.New(t)
+	// examples of the HTU21D datasheet, with an initial value of 0
+	c.Assert(Update(0, PolySensirion, 0xDC), qt.Equals, byte(0x79))
+	c.Assert(Update(0, PolySensirion, 0x68, 0x3A), qt.Equals, byte(0x7C))
+	c.Assert(Update(0, PolySensirion, 0x4E, 0x85), qt.Equals, byte(0x6B))
+	// examples of the PEC of the MLX90614 datasheet
+	c.Assert(Update(0, PolySMBus, 0xB4, 0x07, 0xB5, 0xD2, 0x3A), qt.Equals, byte(0x30))
+	c.Assert(Update(0, PolySMBus, 0xB4, 0x22, 0x07, 0xC8), qt.Equals, byte(0x48))
+	// continued over two calls
+	c.Assert(Update(Update(0, PolySMBus, 0xB4), PolySMBus, 0x22, 0x07, 0xC8), qt.Equals, byte(0x48))
+}
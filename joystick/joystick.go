@@ -0,0 +1,163 @@
+// Package joystick provides a driver for two-axis analog joysticks, such as
+// the common thumb joystick modules with two potentiometers and a push
+// button.
+//
+// The position of each axis is normalized to the range -1000 to 1000, with
+// 0 at the center found at calibration. The range of each axis is learned
+// while the joystick is used, so it reaches the full output range once it
+// was moved to its ends.
+package joystick // import "tinygo.org/x/drivers/joystick"
+
+import "tinygo.org/x/drivers"
+
+// Max is the output value at full deflection.
+const Max = 1000
+
+// Config contains the settings used by Configure.
+type Config struct {
+	// DeadZone around the center, in output units, in which the output is
+	// zero. It is 50 if not set; set it negative to disable it. The output
+	// is rescaled so that it still starts at zero at the edge of the dead
+	// zone.
+	DeadZone int16
+
+	// Expo softens the response around the center, from 0 for a linear
+	// response to 1000 for a cubic response.
+	Expo int16
+
+	// InvertX and InvertY reverse the direction of an axis.
+	InvertX bool
+	InvertY bool
+}
+
+type axis struct {
+	adc       drivers.ADC
+	center    int32
+	low, high int32 // learned ends of the range
+	raw       uint16
+	invert    bool
+}
+
+// Device is a two-axis analog joystick.
+type Device struct {
+	x, y   axis
+	button drivers.Pin
+	cfg    Config
+}
+
+// New returns a joystick on two analog inputs, which must already be
+// configured, and a button pin, which may be nil. The button connects to
+// ground; a machine.Pin is configured with a pull-up resistor by Configure.
+func New(x, y drivers.ADC, button drivers.Pin) *Device {
+	return &Device{
+		x:      axis{adc: x},
+		y:      axis{adc: y},
+		button: button,
+	}
+}
+
+// Configure sets up the button pin and calibrates the center, so the
+// joystick must not be touched while it is called.
+func (d *Device) Configure(cfg Config) {
+	if cfg.DeadZone == 0 {
+		cfg.DeadZone = 50
+	}
+	if cfg.DeadZone < 0 {
+		cfg.DeadZone = 0
+	} else if cfg.DeadZone >= Max {
+		cfg.DeadZone = Max - 1
+	}
+	if cfg.Expo < 0 {
+		cfg.Expo = 0
+	} else if cfg.Expo > Max {
+		cfg.Expo = Max
+	}
+	d.cfg = cfg
+	d.x.invert, d.y.invert = cfg.InvertX, cfg.InvertY
+	if d.button != nil {
+		configurePullup(d.button)
+	}
+	d.Calibrate()
+}
+
+// Calibrate sets the current position as the center, by averaging a number
+// of readings. The learned range is reset to a span slightly smaller than
+// the distance to the ends of the ADC range.
+func (d *Device) Calibrate() {
+	for _, a := range []*axis{&d.x, &d.y} {
+		var sum int32
+		for i := 0; i < 16; i++ {
+			sum += int32(a.adc.Get())
+		}
+		a.center = sum / 16
+		span := a.center
+		if 0xFFFF-a.center < span {
+			span = 0xFFFF - a.center
+		}
+		span = span * 9 / 10
+		a.low, a.high = a.center-span, a.center+span
+	}
+}
+
+// Read returns the normalized position of both axes. The output increases
+// with the ADC readings, unless the axis is inverted.
+func (d *Device) Read() (x, y int16) {
+	return d.read(&d.x), d.read(&d.y)
+}
+
+// Raw returns the last ADC readings of both axes.
+func (d *Device) Raw() (x, y uint16) {
+	return d.x.raw, d.y.raw
+}
+
+// Pressed returns whether the button is pressed. It is not debounced; use
+// the button package for button events.
+func (d *Device) Pressed() bool {
+	return d.button != nil && !d.button.Get()
+}
+
+func (d *Device) read(a *axis) int16 {
+	a.raw = a.adc.Get()
+	v := int32(a.raw)
+	if v < a.low {
+		a.low = v
+	}
+	if v > a.high {
+		a.high = v
+	}
+
+	var pos int32
+	if v >= a.center {
+		if a.high > a.center {
+			pos = (v - a.center) * Max / (a.high - a.center)
+		}
+	} else if a.center > a.low {
+		pos = (v - a.center) * Max / (a.center - a.low)
+	}
+	if a.invert {
+		pos = -pos
+	}
+	return shape(pos, int32(d.cfg.DeadZone), int32(d.cfg.Expo))
+}
+
+// shape applies the dead zone and the expo curve to a position.
+func shape(pos, deadZone, expo int32) int16 {
+	neg := pos < 0
+	if neg {
+		pos = -pos
+	}
+	if pos <= deadZone {
+		return 0
+	}
+	pos = (pos - deadZone) * Max / (Max - deadZone)
+	if pos > Max {
+		pos = Max
+	}
+	// blend of the linear and the cubic curve
+	cubic := pos * pos / Max * pos / Max
+	pos = (pos*(Max-expo) + cubic*expo) / Max
+	if neg {
+		pos = -pos
+	}
+	return int16(pos)
+}
@@ -0,0 +1,120 @@
+package joystick
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers/tester"
+)
+
+type fakeADC struct {
+	value uint16
+}
+
+func (a *fakeADC) Get() uint16 {
+	return a.value
+}
+
+// newStick returns a joystick calibrated with both axes at 0x8000, which
+// learns a range of 0x8000±29490.
+func newStick(cfg Config) (*Device, *fakeADC, *fakeADC) {
+	x, y := &fakeADC{0x8000}, &fakeADC{0x8000}
+	d := New(x, y, nil)
+	d.Configure(cfg)
+	return d, x, y
+}
+
+func TestRead(t *testing.T) {
+	c := qt.New(t)
+	d, x, y := newStick(Config{})
+	for _, test := range []struct {
+		x, y   uint16
+		wx, wy int16
+	}{
+		{0x8000, 0x8000, 0, 0},
+		// inside the 50 dead zone
+		{0x8000 + 1000, 0x8000 - 1000, 0, 0},
+		// the output starts from zero at the edge of the dead zone
+		{0x8000 + 29490/2, 0x8000 - 29490/2, 473, -473},
+		{0x8000 + 29490, 0x8000 - 29490, Max, -Max},
+	} {
+		x.value, y.value = test.x, test.y
+		gx, gy := d.Read()
+		c.Assert([]int16{gx, gy}, qt.DeepEquals, []int16{test.wx, test.wy}, qt.Commentf("%#x, %#x", test.x, test.y))
+	}
+	rx, ry := d.Raw()
+	c.Assert([]uint16{rx, ry}, qt.DeepEquals, []uint16{0x8000 + 29490, 0x8000 - 29490})
+}
+
+func TestLearnedRange(t *testing.T) {
+	c := qt.New(t)
+	d, x, _ := newStick(Config{DeadZone: -1})
+
+	x.value = 0xFFFF
+	gx, _ := d.Read()
+	c.Assert(gx, qt.Equals, int16(Max))
+
+	// the end of the initial range is no longer full deflection
+	x.value = 0x8000 + 29490
+	gx, _ = d.Read()
+	c.Assert(gx, qt.Equals, int16(29490*1000/0x7FFF))
+
+	// recalibration resets the range
+	d.Calibrate()
+	gx, _ = d.Read()
+	c.Assert(gx, qt.Equals, int16(0))
+}
+
+func TestCenter(t *testing.T) {
+	c := qt.New(t)
+	x, y := &fakeADC{0x7000}, &fakeADC{0xA000}
+	d := New(x, y, nil)
+	d.Configure(Config{DeadZone: -1})
+	gx, gy := d.Read()
+	c.Assert([]int16{gx, gy}, qt.DeepEquals, []int16{0, 0})
+
+	// the span is 90% of the distance to the nearest end of the ADC range
+	y.value = 0xA000 + 0x5FFF*9/10
+	_, gy = d.Read()
+	c.Assert(gy, qt.Equals, int16(Max))
+	y.value = 0xA000 - 0x5FFF*9/10
+	_, gy = d.Read()
+	c.Assert(gy, qt.Equals, int16(-Max))
+}
+
+func TestInvert(t *testing.T) {
+	c := qt.New(t)
+	d, x, y := newStick(Config{InvertY: true})
+	x.value, y.value = 0x8000+29490, 0x8000+29490
+	gx, gy := d.Read()
+	c.Assert([]int16{gx, gy}, qt.DeepEquals, []int16{Max, -Max})
+}
+
+func TestShape(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(shape(500, 0, 0), qt.Equals, int16(500))
+	c.Assert(shape(500, 0, Max), qt.Equals, int16(125))
+	c.Assert(shape(-500, 0, 500), qt.Equals, int16(-312))
+	c.Assert(shape(50, 50, 0), qt.Equals, int16(0))
+	c.Assert(shape(2000, 50, 0), qt.Equals, int16(Max))
+
+	// the dead zone and expo are limited to the output range
+	d, _, _ := newStick(Config{DeadZone: 5000, Expo: 5000})
+	c.Assert(d.cfg.DeadZone, qt.Equals, int16(Max-1))
+	c.Assert(d.cfg.Expo, qt.Equals, int16(Max))
+}
+
+func TestPressed(t *testing.T) {
+	c := qt.New(t)
+	d, _, _ := newStick(Config{})
+	c.Assert(d.Pressed(), qt.IsFalse)
+
+	button := &tester.Pin{}
+	button.High()
+	d = New(&fakeADC{}, &fakeADC{}, button)
+	d.Configure(Config{})
+	c.Assert(d.Pressed(), qt.IsFalse)
+	button.Low()
+	c.Assert(d.Pressed(), qt.IsTrue)
+}
@@ -0,0 +1,16 @@
+// +build tinygo
+
+package joystick
+
+import (
+	"machine"
+
+	"tinygo.org/x/drivers"
+)
+
+// configurePullup makes the button pin an input with a pull-up resistor.
+func configurePullup(pin drivers.Pin) {
+	if pin, ok := pin.(machine.Pin); ok {
+		pin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	}
+}
@@ -0,0 +1,279 @@
+// Package keypad provides a driver for matrix keypads, such as the common
+// 4x3 and 4x4 membrane keypads, connected directly to GPIO pins or through
+// a PCF8574 I2C I/O expander.
+//
+// The keypad is scanned by calling Scan regularly, every few milliseconds.
+// Every key is debounced on its own, and key down, key up and repeat events
+// are reported through a callback.
+package keypad // import "tinygo.org/x/drivers/keypad"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	errSize     = errors.New("keypad: at most 16 rows and columns supported")
+	errExpander = errors.New("keypad: the rows and columns do not fit in the 8 pins of the expander")
+)
+
+// EventType is the kind of a key event.
+type EventType uint8
+
+// Key event types.
+const (
+	KeyDown EventType = iota
+	KeyUp
+	KeyRepeat
+)
+
+// Event is a change of the state of a key.
+type Event struct {
+	Key      byte
+	Row, Col uint8
+	Type     EventType
+}
+
+// Config contains the settings used by Configure.
+type Config struct {
+	// Keys are the characters of the keys, row by row. The default is
+	// "123456789*0#" for a 4x3 keypad and "123A456B789C*0#D" for a 4x4
+	// keypad. For other sizes the default is the index of the key.
+	Keys string
+
+	// DebounceTime a key must be stable before a change is reported, 20ms
+	// if not set.
+	DebounceTime time.Duration
+
+	// RepeatDelay is the time a key is held before it repeats, and
+	// RepeatInterval the time between repeat events. Keys do not repeat
+	// if RepeatDelay is zero.
+	RepeatDelay    time.Duration
+	RepeatInterval time.Duration
+
+	// OnEvent is called from Scan for every event.
+	OnEvent func(Event)
+}
+
+// scanner reads the columns of one row of the matrix.
+type scanner interface {
+	configure() error
+	scan(row int) (uint16, error)
+}
+
+type key struct {
+	stable  bool // debounced state
+	raw     bool // state at the last scan
+	changed time.Time
+	repeat  time.Time // time of the next repeat event
+}
+
+// Device is a matrix keypad.
+type Device struct {
+	scanner
+	rows, cols int
+	keys       []byte
+	state      []key
+	ghosting   bool
+	cfg        Config
+	now        func() time.Time
+}
+
+// NewGPIO returns a keypad connected to GPIO pins, which may be pins of an
+// I/O expander. The rows are driven low one after the other and the columns
+// are read, so they need pull-up resistors. New does the same with the pins
+// of the microcontroller.
+//
+// This function only creates the Device object, it does not touch the device.
+func NewGPIO(rows []drivers.IOPin, cols []drivers.Pin) *Device {
+	return &Device{
+		scanner: &gpioScanner{rows, cols},
+		rows:    len(rows),
+		cols:    len(cols),
+		now:     time.Now,
+	}
+}
+
+// NewI2C returns a keypad connected to a PCF8574 I/O expander, with the
+// rows on the low pins (P0, P1, ...) followed by the columns, as on most
+// I2C keypad adapters. The I2C bus must already be configured. As the
+// expander has 8 pins, Configure returns an error when rows+cols is larger.
+//
+// This function only creates the Device object, it does not touch the device.
+func NewI2C(bus drivers.I2C, address uint8, rows, cols int) *Device {
+	return &Device{
+		scanner: &expanderScanner{bus: bus, address: uint16(address), rows: rows, cols: cols},
+		rows:    rows,
+		cols:    cols,
+		now:     time.Now,
+	}
+}
+
+// Configure sets up the pins and the key map.
+func (d *Device) Configure(cfg Config) error {
+	if d.rows > 16 || d.cols > 16 {
+		return errSize
+	}
+	if cfg.DebounceTime == 0 {
+		cfg.DebounceTime = 20 * time.Millisecond
+	}
+	if cfg.RepeatInterval == 0 {
+		cfg.RepeatInterval = 100 * time.Millisecond
+	}
+	d.cfg = cfg
+	d.state = make([]key, d.rows*d.cols)
+	d.keys = []byte(cfg.Keys)
+	if len(d.keys) < len(d.state) {
+		switch {
+		case d.rows == 4 && d.cols == 3:
+			d.keys = []byte("123456789*0#")
+		case d.rows == 4 && d.cols == 4:
+			d.keys = []byte("123A456B789C*0#D")
+		default:
+			d.keys = make([]byte, len(d.state))
+			for i := range d.keys {
+				d.keys[i] = byte(i)
+			}
+		}
+	}
+	return d.configure()
+}
+
+// Scan reads the keypad and reports events. It should be called every few
+// milliseconds.
+func (d *Device) Scan() error {
+	var pressed [16]uint16
+	for r := 0; r < d.rows; r++ {
+		cols, err := d.scan(r)
+		if err != nil {
+			return err
+		}
+		pressed[r] = cols
+	}
+
+	// Without a diode per key, when three corners of a rectangle are
+	// pressed the fourth one seems pressed too. Such scans are skipped.
+	d.ghosting = false
+	for r1 := 0; r1 < d.rows; r1++ {
+		for r2 := r1 + 1; r2 < d.rows; r2++ {
+			if common := pressed[r1] & pressed[r2]; common&(common-1) != 0 {
+				d.ghosting = true
+				return nil
+			}
+		}
+	}
+
+	now := d.now()
+	for r := 0; r < d.rows; r++ {
+		for c := 0; c < d.cols; c++ {
+			d.update(r, c, pressed[r]&(1<<uint(c)) != 0, now)
+		}
+	}
+	return nil
+}
+
+// update debounces one key and emits its events.
+func (d *Device) update(r, c int, raw bool, now time.Time) {
+	k := &d.state[r*d.cols+c]
+	if raw != k.raw {
+		k.raw, k.changed = raw, now
+	}
+	if k.raw != k.stable && now.Sub(k.changed) >= d.cfg.DebounceTime {
+		k.stable = k.raw
+		if k.stable {
+			k.repeat = now.Add(d.cfg.RepeatDelay)
+			d.emit(r, c, KeyDown)
+		} else {
+			d.emit(r, c, KeyUp)
+		}
+		return
+	}
+	if k.stable && d.cfg.RepeatDelay > 0 && !now.Before(k.repeat) {
+		k.repeat = now.Add(d.cfg.RepeatInterval)
+		d.emit(r, c, KeyRepeat)
+	}
+}
+
+func (d *Device) emit(r, c int, typ EventType) {
+	if d.cfg.OnEvent != nil {
+		d.cfg.OnEvent(Event{Key: d.keys[r*d.cols+c], Row: uint8(r), Col: uint8(c), Type: typ})
+	}
+}
+
+// Pressed returns whether the key with the given character is held down.
+func (d *Device) Pressed(key byte) bool {
+	for i, k := range d.keys[:len(d.state)] {
+		if k == key {
+			return d.state[i].stable
+		}
+	}
+	return false
+}
+
+// Ghosting returns whether the last scan was skipped because the pressed
+// keys could not be told apart.
+func (d *Device) Ghosting() bool {
+	return d.ghosting
+}
+
+type gpioScanner struct {
+	rows []drivers.IOPin
+	cols []drivers.Pin
+}
+
+func (s *gpioScanner) configure() error {
+	for _, pin := range s.rows {
+		pin.SetInput()
+	}
+	for _, pin := range s.cols {
+		configurePullup(pin)
+	}
+	return nil
+}
+
+func (s *gpioScanner) scan(row int) (uint16, error) {
+	// only the scanned row is driven, so that pressing keys in several
+	// rows does not short the outputs
+	pin := s.rows[row]
+	pin.SetOutput()
+	pin.Low()
+	time.Sleep(10 * time.Microsecond)
+	cols := uint16(0)
+	for c, col := range s.cols {
+		if !col.Get() {
+			cols |= 1 << uint(c)
+		}
+	}
+	pin.SetInput()
+	return cols, nil
+}
+
+type expanderScanner struct {
+	bus     drivers.I2C
+	address uint16
+	rows    int
+	cols    int
+	buf     [1]byte
+}
+
+func (s *expanderScanner) configure() error {
+	if s.rows+s.cols > 8 {
+		return errExpander
+	}
+	// the quasi-bidirectional pins are inputs with a weak pull-up when high
+	s.buf[0] = 0xff
+	return s.bus.Tx(s.address, s.buf[:], nil)
+}
+
+func (s *expanderScanner) scan(row int) (uint16, error) {
+	s.buf[0] = ^uint8(1 << uint(row))
+	if err := s.bus.Tx(s.address, s.buf[:], nil); err != nil {
+		return 0, err
+	}
+	if err := s.bus.Tx(s.address, nil, s.buf[:]); err != nil {
+		return 0, err
+	}
+	return uint16(^s.buf[0]) >> uint(s.rows), nil
+}
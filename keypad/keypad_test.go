@@ -0,0 +1,236 @@
+package keypad
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/tester"
+)
+
+// matrix simulates the keys of a keypad without diodes: a column reads low
+// when the pressed keys connect it to a row driven low, possibly through
+// other rows and columns.
+type matrix struct {
+	pressed map[[2]int]bool
+}
+
+// low returns the columns pulled low by the given rows.
+func (m *matrix) low(rows uint16) (cols uint16) {
+	for changed := true; changed; {
+		changed = false
+		for k, down := range m.pressed {
+			r, c := uint16(1)<<uint(k[0]), uint16(1)<<uint(k[1])
+			switch {
+			case !down:
+			case rows&r != 0 && cols&c == 0:
+				cols |= c
+				changed = true
+			case cols&c != 0 && rows&r == 0:
+				rows |= r
+				changed = true
+			}
+		}
+	}
+	return cols
+}
+
+type rowPin struct {
+	output, high bool
+}
+
+func (p *rowPin) Get() bool     { return p.high }
+func (p *rowPin) Set(high bool) { p.high = high }
+func (p *rowPin) High()         { p.high = true }
+func (p *rowPin) Low()          { p.high = false }
+func (p *rowPin) SetOutput()    { p.output = true }
+func (p *rowPin) SetInput()     { p.output = false }
+
+type colPin struct {
+	s   *sim
+	col int
+}
+
+func (p *colPin) Get() bool {
+	rows := uint16(0)
+	for r, pin := range p.s.rows {
+		if pin.output && !pin.high {
+			rows |= 1 << uint(r)
+		}
+	}
+	if rows&(rows-1) != 0 {
+		p.s.c.Fatalf("several rows driven at once: %#b", rows)
+	}
+	return p.s.m.low(rows)&(1<<uint(p.col)) == 0
+}
+
+func (p *colPin) Set(high bool) {}
+func (p *colPin) High()         {}
+func (p *colPin) Low()          {}
+
+// sim steps a keypad through time in 5ms scans and records its events.
+type sim struct {
+	c      *qt.C
+	d      *Device
+	m      matrix
+	rows   []*rowPin
+	clock  time.Time
+	events []Event
+}
+
+func newSim(c *qt.C, rows, cols int, cfg Config) *sim {
+	s := &sim{c: c, m: matrix{pressed: make(map[[2]int]bool)}, clock: time.Unix(0, 0)}
+	r := make([]drivers.IOPin, rows)
+	for i := range r {
+		s.rows = append(s.rows, &rowPin{})
+		r[i] = s.rows[i]
+	}
+	col := make([]drivers.Pin, cols)
+	for i := range col {
+		col[i] = &colPin{s: s, col: i}
+	}
+	s.configure(NewGPIO(r, col), cfg)
+	return s
+}
+
+func (s *sim) configure(d *Device, cfg Config) {
+	s.d = d
+	s.d.now = func() time.Time { return s.clock }
+	cfg.OnEvent = func(e Event) {
+		s.events = append(s.events, e)
+	}
+	s.c.Assert(s.d.Configure(cfg), qt.IsNil)
+}
+
+func (s *sim) run(d time.Duration) {
+	for end := s.clock.Add(d); s.clock.Before(end); s.clock = s.clock.Add(5 * time.Millisecond) {
+		s.c.Assert(s.d.Scan(), qt.IsNil)
+	}
+}
+
+func (s *sim) press(r, c int, down bool) {
+	s.m.pressed[[2]int{r, c}] = down
+}
+
+// take returns the events since the last call.
+func (s *sim) take() []Event {
+	events := s.events
+	s.events = nil
+	return events
+}
+
+func TestScan(t *testing.T) {
+	c := qt.New(t)
+	s := newSim(c, 4, 3, Config{})
+	for _, row := range s.rows {
+		c.Assert(row.output, qt.IsFalse)
+	}
+
+	s.press(1, 2, true)
+	s.run(100 * time.Millisecond)
+	c.Assert(s.take(), qt.DeepEquals, []Event{{Key: '6', Row: 1, Col: 2, Type: KeyDown}})
+	c.Assert(s.d.Pressed('6'), qt.IsTrue)
+	c.Assert(s.d.Pressed('5'), qt.IsFalse)
+
+	s.press(1, 2, false)
+	s.press(3, 0, true)
+	s.run(100 * time.Millisecond)
+	c.Assert(s.take(), qt.DeepEquals, []Event{
+		{Key: '6', Row: 1, Col: 2, Type: KeyUp},
+		{Key: '*', Row: 3, Col: 0, Type: KeyDown},
+	})
+	c.Assert(s.d.Pressed('6'), qt.IsFalse)
+}
+
+func TestDebounce(t *testing.T) {
+	c := qt.New(t)
+	s := newSim(c, 4, 4, Config{DebounceTime: 20 * time.Millisecond})
+
+	// bounces shorter than the debounce time are ignored
+	for i := 0; i < 6; i++ {
+		s.press(0, 3, i%2 == 0)
+		s.run(15 * time.Millisecond)
+	}
+	c.Assert(s.take(), qt.HasLen, 0)
+
+	s.press(0, 3, true)
+	s.run(15 * time.Millisecond)
+	c.Assert(s.take(), qt.HasLen, 0)
+	s.run(10 * time.Millisecond)
+	c.Assert(s.take(), qt.DeepEquals, []Event{{Key: 'A', Row: 0, Col: 3, Type: KeyDown}})
+}
+
+func TestRepeat(t *testing.T) {
+	c := qt.New(t)
+	s := newSim(c, 4, 3, Config{
+		RepeatDelay:    500 * time.Millisecond,
+		RepeatInterval: 100 * time.Millisecond,
+	})
+
+	s.press(0, 0, true)
+	s.run(25 * time.Millisecond)
+	c.Assert(s.take(), qt.HasLen, 1)
+	s.run(495 * time.Millisecond)
+	c.Assert(s.take(), qt.HasLen, 0)
+	s.run(300 * time.Millisecond)
+	c.Assert(s.take(), qt.DeepEquals, []Event{
+		{Key: '1', Type: KeyRepeat},
+		{Key: '1', Type: KeyRepeat},
+		{Key: '1', Type: KeyRepeat},
+	})
+}
+
+func TestGhosting(t *testing.T) {
+	c := qt.New(t)
+	s := newSim(c, 4, 3, Config{})
+	s.press(0, 0, true)
+	s.press(0, 1, true)
+	s.run(100 * time.Millisecond)
+	c.Assert(s.take(), qt.HasLen, 2)
+
+	// the third corner of a rectangle makes the fourth key look pressed
+	s.press(1, 0, true)
+	s.run(100 * time.Millisecond)
+	c.Assert(s.d.Ghosting(), qt.IsTrue)
+	c.Assert(s.take(), qt.HasLen, 0)
+	c.Assert(s.d.Pressed('5'), qt.IsFalse)
+
+	s.press(0, 1, false)
+	s.run(100 * time.Millisecond)
+	c.Assert(s.d.Ghosting(), qt.IsFalse)
+	c.Assert(s.take(), qt.DeepEquals, []Event{
+		{Key: '2', Row: 0, Col: 1, Type: KeyUp},
+		{Key: '4', Row: 1, Col: 0, Type: KeyDown},
+	})
+}
+
+func TestExpander(t *testing.T) {
+	c := qt.New(t)
+	s := &sim{c: c, m: matrix{pressed: make(map[[2]int]bool)}, clock: time.Unix(0, 0)}
+	// a PCF8574 with 4 rows on P0-P3 and 4 columns on P4-P7, whose pins
+	// read low when they are written low or pulled low by a key
+	var latch byte
+	var writes []byte
+	bus := tester.NewI2CBus(c)
+	bus.AddDevice(tester.NewI2CCommandDevice(c, 0x20, func(w, r []byte) error {
+		if len(w) == 1 {
+			latch = w[0]
+			writes = append(writes, w[0])
+		}
+		if len(r) == 1 {
+			r[0] = latch &^ byte(s.m.low(uint16(^latch&0x0F))<<4)
+		}
+		return nil
+	}))
+	s.configure(NewI2C(bus, 0x20, 4, 4), Config{})
+	c.Assert(writes, qt.DeepEquals, []byte{0xFF})
+
+	s.press(2, 1, true)
+	s.run(50 * time.Millisecond)
+	c.Assert(s.take(), qt.DeepEquals, []Event{{Key: '8', Row: 2, Col: 1, Type: KeyDown}})
+	c.Assert(writes[1:5], qt.DeepEquals, []byte{0xFE, 0xFD, 0xFB, 0xF7})
+
+	c.Assert(NewI2C(bus, 0x20, 4, 5).Configure(Config{}), qt.Equals, errExpander)
+}
@@ -0,0 +1,59 @@
+// +build tinygo
+
+package keypad
+
+import (
+	"machine"
+
+	"tinygo.org/x/drivers"
+)
+
+// New returns a keypad connected to GPIO pins of the microcontroller, like
+// NewGPIO. Configure enables the pull-up resistors of the columns.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(rows, cols []machine.Pin) *Device {
+	r := make([]drivers.IOPin, len(rows))
+	for i, pin := range rows {
+		r[i] = machinePin(pin)
+	}
+	c := make([]drivers.Pin, len(cols))
+	for i, pin := range cols {
+		c[i] = pin
+	}
+	return NewGPIO(r, c)
+}
+
+// configurePullup makes a column pin an input with a pull-up resistor.
+func configurePullup(pin drivers.Pin) {
+	if pin, ok := pin.(machine.Pin); ok {
+		pin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	}
+}
+
+// machinePin implements drivers.IOPin for the rows.
+type machinePin machine.Pin
+
+func (p machinePin) Get() bool {
+	return machine.Pin(p).Get()
+}
+
+func (p machinePin) Set(high bool) {
+	machine.Pin(p).Set(high)
+}
+
+func (p machinePin) High() {
+	machine.Pin(p).High()
+}
+
+func (p machinePin) Low() {
+	machine.Pin(p).Low()
+}
+
+func (p machinePin) SetOutput() {
+	machine.Pin(p).Configure(machine.PinConfig{Mode: machine.PinOutput})
+}
+
+func (p machinePin) SetInput() {
+	machine.Pin(p).Configure(machine.PinConfig{Mode: machine.PinInput})
+}
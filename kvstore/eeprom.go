@@ -0,0 +1,53 @@
+package kvstore
+
+import "tinygo.org/x/drivers"
+
+// memory is a byte addressable memory such as an EEPROM or FRAM.
+type memory interface {
+	ReadAt(buf []byte, off int64) (int, error)
+	WriteAt(buf []byte, off int64) (int, error)
+	Size() int64
+}
+
+// EEPROM adapts a memory without an erase operation, such as the at24cx and
+// mb85rc drivers, to a drivers.BlockDevice that can be used by Open.
+// Erasing fills a block with 0xff. The block size is the size of the
+// sectors of the store, at least four sectors are recommended.
+func EEPROM(dev memory, blockSize int64) drivers.BlockDevice {
+	return &eeprom{dev, blockSize}
+}
+
+type eeprom struct {
+	memory
+	blockSize int64
+}
+
+// Size returns the usable size, a multiple of the block size.
+func (e *eeprom) Size() int64 {
+	return e.memory.Size() / e.blockSize * e.blockSize
+}
+
+func (e *eeprom) WriteBlockSize() int64 {
+	return 1
+}
+
+func (e *eeprom) EraseBlockSize() int64 {
+	return e.blockSize
+}
+
+func (e *eeprom) EraseBlocks(start, len int64) error {
+	var buf [32]byte
+	for i := range buf {
+		buf[i] = 0xff
+	}
+	for off := start * e.blockSize; off < (start+len)*e.blockSize; off += int64(cap(buf)) {
+		chunk := buf[:]
+		if left := (start+len)*e.blockSize - off; left < int64(cap(buf)) {
+			chunk = chunk[:left]
+		}
+		if _, err := e.WriteAt(chunk, off); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,371 @@
+// Package kvstore implements a small key-value store for settings and
+// counters on flash memory, such as the internal flash of a microcontroller
+// (machine.Flash) or an external chip supported by the flash package. It can
+// also be used on an EEPROM or FRAM through the EEPROM adapter.
+//
+// The store is a log of records spread over the erase blocks (sectors) of
+// the device, which are used as a ring:
+//
+//   - Every record carries a CRC. Updating a key appends a new record and the
+//     latest valid record wins, so a power loss during an update leaves
+//     either the old or the new value, never a mix of both.
+//   - When a sector is full, writing continues in the next one. When only
+//     one free sector would be left, the live records of the oldest sector
+//     are copied to the newest one and the oldest sector is erased. This
+//     garbage collection also means every sector is erased equally often.
+//
+// Because a new record is written before the old one is dropped, the live
+// records and the record being written must fit in all sectors but one. An
+// index of all keys is kept in RAM, so the number of keys should be small.
+package kvstore // import "tinygo.org/x/drivers/kvstore"
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"sort"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	ErrNotFound    = errors.New("kvstore: key not found")
+	ErrFull        = errors.New("kvstore: no space left")
+	ErrTooLarge    = errors.New("kvstore: record too large for a sector")
+	ErrInvalidKey  = errors.New("kvstore: invalid key")
+	ErrShortBuffer = errors.New("kvstore: buffer too small for value")
+	ErrTooSmall    = errors.New("kvstore: device needs at least two sectors")
+)
+
+const (
+	magic        = 0x564b4754 // "TGKV"
+	sectorHeader = 12         // magic, sequence number, CRC
+	recordHeader = 8          // key length, kind, value length, CRC
+	maxKey       = 254        // 0xff marks erased memory
+
+	kindDelete = 0
+	kindValue  = 1
+)
+
+// location of the latest record of a key
+type location struct {
+	sector uint32
+	offset uint32
+	size   uint32 // including header and padding
+	length uint16 // of the value
+}
+
+// Store is an open key-value store.
+type Store struct {
+	dev        drivers.BlockDevice
+	sectorSize uint32
+	count      uint32
+	align      uint32
+	index      map[string]location
+
+	empty   bool   // no sector is in use yet
+	head    uint32 // oldest sector in use
+	tail    uint32 // sector records are appended to
+	seq     uint32 // sequence number of the tail sector
+	offset  uint32 // next write offset in the tail sector
+	damaged bool   // the tail sector contains an incomplete record
+}
+
+// Open opens the store on the given device and reads the index. A device
+// without a store is treated as an empty store; sectors are erased when
+// they are first used.
+func Open(dev drivers.BlockDevice) (*Store, error) {
+	s := &Store{
+		dev:        dev,
+		sectorSize: uint32(dev.EraseBlockSize()),
+		count:      uint32(dev.Size() / dev.EraseBlockSize()),
+		align:      uint32(dev.WriteBlockSize()),
+		index:      make(map[string]location),
+		empty:      true,
+	}
+	if s.count < 2 {
+		return nil, ErrTooSmall
+	}
+	if s.align == 0 {
+		s.align = 1
+	}
+
+	// the newest sector is the tail, the ring extends backwards from it
+	var seqs = make([]uint32, s.count)
+	var valid = make([]bool, s.count)
+	for i := uint32(0); i < s.count; i++ {
+		seq, ok, err := s.readHeader(i)
+		if err != nil {
+			return nil, err
+		}
+		seqs[i], valid[i] = seq, ok
+		if ok && (s.empty || int32(seq-s.seq) > 0) {
+			s.tail, s.seq, s.empty = i, seq, false
+		}
+	}
+	if s.empty {
+		return s, nil
+	}
+	s.head = s.tail
+	for k := uint32(1); k < s.count; k++ {
+		prev := (s.tail + s.count - k) % s.count
+		if !valid[prev] || seqs[prev] != s.seq-k {
+			break
+		}
+		s.head = prev
+	}
+
+	for sector := s.head; ; sector = (sector + 1) % s.count {
+		end, complete, err := s.replay(sector)
+		if err != nil {
+			return nil, err
+		}
+		if sector == s.tail {
+			s.offset, s.damaged = end, !complete
+			break
+		}
+	}
+	return s, nil
+}
+
+// readHeader reads the header of a sector and returns its sequence number
+// and whether it is valid.
+func (s *Store) readHeader(sector uint32) (uint32, bool, error) {
+	var buf [sectorHeader]byte
+	if _, err := s.dev.ReadAt(buf[:], int64(sector*s.sectorSize)); err != nil {
+		return 0, false, err
+	}
+	ok := binary.LittleEndian.Uint32(buf[0:]) == magic &&
+		binary.LittleEndian.Uint32(buf[8:]) == crc32.ChecksumIEEE(buf[:8])
+	return binary.LittleEndian.Uint32(buf[4:]), ok, nil
+}
+
+// replay adds the records of a sector to the index. It returns the offset
+// after the last valid record, and whether the sector ends in erased memory
+// rather than in a damaged record.
+func (s *Store) replay(sector uint32) (uint32, bool, error) {
+	base := int64(sector * s.sectorSize)
+	offset := s.roundUp(sectorHeader)
+	var hdr [recordHeader]byte
+	var buf [64]byte
+	for offset+recordHeader <= s.sectorSize {
+		if _, err := s.dev.ReadAt(hdr[:], base+int64(offset)); err != nil {
+			return 0, false, err
+		}
+		if hdr[0] == 0xff {
+			return offset, true, nil
+		}
+		keyLen := uint32(hdr[0])
+		valLen := uint32(binary.LittleEndian.Uint16(hdr[2:]))
+		size := s.roundUp(recordHeader + keyLen + valLen)
+		if keyLen == 0 || offset+size > s.sectorSize {
+			return offset, false, nil
+		}
+
+		// check the CRC and read the key
+		crc := crc32.ChecksumIEEE(hdr[:4])
+		key := make([]byte, keyLen)
+		for pos := uint32(0); pos < keyLen+valLen; {
+			chunk := buf[:]
+			if keyLen+valLen-pos < uint32(len(chunk)) {
+				chunk = chunk[:keyLen+valLen-pos]
+			}
+			if _, err := s.dev.ReadAt(chunk, base+int64(offset+recordHeader+pos)); err != nil {
+				return 0, false, err
+			}
+			if pos < keyLen {
+				copy(key[pos:], chunk)
+			}
+			crc = crc32.Update(crc, crc32.IEEETable, chunk)
+			pos += uint32(len(chunk))
+		}
+		if crc != binary.LittleEndian.Uint32(hdr[4:]) {
+			return offset, false, nil
+		}
+
+		switch hdr[1] {
+		case kindValue:
+			s.index[string(key)] = location{sector, offset, size, uint16(valLen)}
+		case kindDelete:
+			delete(s.index, string(key))
+		}
+		offset += size
+	}
+	return offset, true, nil
+}
+
+// Get reads the value of a key into buf and returns its length.
+func (s *Store) Get(key string, buf []byte) (int, error) {
+	loc, ok := s.index[key]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	if len(buf) < int(loc.length) {
+		return 0, ErrShortBuffer
+	}
+	addr := int64(loc.sector*s.sectorSize + loc.offset + recordHeader + uint32(len(key)))
+	return s.dev.ReadAt(buf[:loc.length], addr)
+}
+
+// Len returns the length of the value of a key.
+func (s *Store) Len(key string) (int, error) {
+	loc, ok := s.index[key]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return int(loc.length), nil
+}
+
+// Has returns whether the key exists.
+func (s *Store) Has(key string) bool {
+	_, ok := s.index[key]
+	return ok
+}
+
+// Keys returns all keys in sorted order.
+func (s *Store) Keys() []string {
+	keys := make([]string, 0, len(s.index))
+	for key := range s.index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Set stores the value of a key. The update is atomic: after a power loss
+// the key has either the old or the new value.
+func (s *Store) Set(key string, value []byte) error {
+	if len(key) == 0 || len(key) > maxKey {
+		return ErrInvalidKey
+	}
+	if len(value) > 0xffff {
+		return ErrTooLarge
+	}
+	loc, err := s.append(kindValue, key, value)
+	if err != nil {
+		return err
+	}
+	s.index[key] = loc
+	return nil
+}
+
+// Delete removes a key. It does nothing if the key does not exist.
+func (s *Store) Delete(key string) error {
+	if !s.Has(key) {
+		return nil
+	}
+	if _, err := s.append(kindDelete, key, nil); err != nil {
+		return err
+	}
+	delete(s.index, key)
+	return nil
+}
+
+// append writes a record to the tail sector.
+func (s *Store) append(kind uint8, key string, value []byte) (location, error) {
+	size := s.roundUp(recordHeader + uint32(len(key)+len(value)))
+	buf := make([]byte, size)
+	for i := range buf {
+		buf[i] = 0xff
+	}
+	buf[0], buf[1] = uint8(len(key)), kind
+	binary.LittleEndian.PutUint16(buf[2:], uint16(len(value)))
+	copy(buf[recordHeader:], key)
+	copy(buf[recordHeader+len(key):], value)
+	crc := crc32.ChecksumIEEE(buf[:4])
+	crc = crc32.Update(crc, crc32.IEEETable, buf[recordHeader:recordHeader+len(key)+len(value)])
+	binary.LittleEndian.PutUint32(buf[4:], crc)
+
+	if err := s.reserve(size); err != nil {
+		return location{}, err
+	}
+	return s.write(buf, uint16(len(value)))
+}
+
+// write writes an encoded record at the end of the tail sector.
+func (s *Store) write(record []byte, length uint16) (location, error) {
+	loc := location{s.tail, s.offset, uint32(len(record)), length}
+	if _, err := s.dev.WriteAt(record, int64(s.tail*s.sectorSize+s.offset)); err != nil {
+		s.damaged = true
+		return location{}, err
+	}
+	s.offset += uint32(len(record))
+	return loc, nil
+}
+
+// reserve makes sure there is room for size bytes in the tail sector,
+// moving on to the next sector when needed.
+func (s *Store) reserve(size uint32) error {
+	if size > s.sectorSize-s.roundUp(sectorHeader) {
+		return ErrTooLarge
+	}
+	for i := uint32(0); i < s.count; i++ {
+		if !s.empty && !s.damaged && s.offset+size <= s.sectorSize {
+			return nil
+		}
+		if err := s.advance(); err != nil {
+			return err
+		}
+	}
+	return ErrFull
+}
+
+// advance starts a new tail sector, and collects the oldest sector when the
+// new one was the last free sector but one.
+func (s *Store) advance() error {
+	next, seq := uint32(0), uint32(0)
+	if !s.empty {
+		next, seq = (s.tail+1)%s.count, s.seq+1
+	}
+	if err := s.dev.EraseBlocks(int64(next), 1); err != nil {
+		return err
+	}
+	var hdr [sectorHeader]byte
+	binary.LittleEndian.PutUint32(hdr[0:], magic)
+	binary.LittleEndian.PutUint32(hdr[4:], seq)
+	binary.LittleEndian.PutUint32(hdr[8:], crc32.ChecksumIEEE(hdr[:8]))
+	if _, err := s.dev.WriteAt(hdr[:], int64(next*s.sectorSize)); err != nil {
+		return err
+	}
+	if s.empty {
+		s.head, s.empty = next, false
+	}
+	s.tail, s.seq, s.offset, s.damaged = next, seq, s.roundUp(sectorHeader), false
+	if (s.tail+1)%s.count == s.head {
+		return s.collect()
+	}
+	return nil
+}
+
+// collect copies the live records of the oldest sector to the tail sector
+// and erases it. The store stays consistent if this is interrupted: the
+// copies are newer than the originals.
+func (s *Store) collect() error {
+	for key, loc := range s.index {
+		if loc.sector != s.head {
+			continue
+		}
+		if s.offset+loc.size > s.sectorSize {
+			return ErrFull
+		}
+		record := make([]byte, loc.size)
+		if _, err := s.dev.ReadAt(record, int64(loc.sector*s.sectorSize+loc.offset)); err != nil {
+			return err
+		}
+		moved, err := s.write(record, loc.length)
+		if err != nil {
+			return err
+		}
+		s.index[key] = moved
+	}
+	if err := s.dev.EraseBlocks(int64(s.head), 1); err != nil {
+		return err
+	}
+	s.head = (s.head + 1) % s.count
+	return nil
+}
+
+// roundUp rounds n up to a multiple of the write block size.
+func (s *Store) roundUp(n uint32) uint32 {
+	return (n + s.align - 1) / s.align * s.align
+}
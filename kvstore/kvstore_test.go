@@ -0,0 +1,194 @@
+package kvstore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+const testSectorSize = 256
+
+var errPowerLoss = errors.New("power loss")
+
+// norFlash emulates NOR flash with a write block size of 4 bytes: writing
+// can only clear bits and erasing sets a sector to 0xff. After budget
+// writes, it simulates a power loss by writing only half of the next buffer
+// and failing from then on.
+type norFlash struct {
+	mem     []byte
+	erases  []int
+	budget  int
+	limited bool
+}
+
+func newFlash(sectors int) *norFlash {
+	f := &norFlash{mem: make([]byte, sectors*testSectorSize), erases: make([]int, sectors)}
+	for i := range f.mem {
+		f.mem[i] = 0xff
+	}
+	return f
+}
+
+func (f *norFlash) ReadAt(buf []byte, off int64) (int, error) {
+	return copy(buf, f.mem[off:]), nil
+}
+
+func (f *norFlash) WriteAt(buf []byte, off int64) (int, error) {
+	if off%4 != 0 || len(buf)%4 != 0 {
+		panic("unaligned write")
+	}
+	if f.limited {
+		if f.budget == 0 {
+			buf = buf[:len(buf)/2]
+		}
+		if f.budget < 0 {
+			return 0, errPowerLoss
+		}
+		f.budget--
+	}
+	for i, b := range buf {
+		f.mem[off+int64(i)] &= b
+	}
+	if f.limited && f.budget < 0 {
+		return len(buf), errPowerLoss
+	}
+	return len(buf), nil
+}
+
+func (f *norFlash) Size() int64           { return int64(len(f.mem)) }
+func (f *norFlash) WriteBlockSize() int64 { return 4 }
+func (f *norFlash) EraseBlockSize() int64 { return testSectorSize }
+
+func (f *norFlash) EraseBlocks(start, n int64) error {
+	if f.limited && f.budget < 0 {
+		return errPowerLoss
+	}
+	for b := start; b < start+n; b++ {
+		f.erases[b]++
+		for i := b * testSectorSize; i < (b+1)*testSectorSize; i++ {
+			f.mem[i] = 0xff
+		}
+	}
+	return nil
+}
+
+func open(c *qt.C, dev *norFlash) *Store {
+	s, err := Open(dev)
+	c.Assert(err, qt.IsNil)
+	return s
+}
+
+func get(c *qt.C, s *Store, key string) string {
+	buf := make([]byte, 64)
+	n, err := s.Get(key, buf)
+	c.Assert(err, qt.IsNil)
+	return string(buf[:n])
+}
+
+func TestSetGetDelete(t *testing.T) {
+	c := qt.New(t)
+	dev := newFlash(4)
+	s := open(c, dev)
+	_, err := s.Get("ssid", nil)
+	c.Assert(err, qt.Equals, ErrNotFound)
+
+	c.Assert(s.Set("ssid", []byte("home")), qt.IsNil)
+	c.Assert(s.Set("pass", []byte("secret")), qt.IsNil)
+	c.Assert(s.Set("ssid", []byte("office")), qt.IsNil)
+	c.Assert(s.Delete("pass"), qt.IsNil)
+	c.Assert(s.Set("empty", nil), qt.IsNil)
+
+	_, err = s.Get("ssid", make([]byte, 2))
+	c.Assert(err, qt.Equals, ErrShortBuffer)
+
+	for _, s := range []*Store{s, open(c, dev)} {
+		c.Assert(s.Keys(), qt.DeepEquals, []string{"empty", "ssid"})
+		c.Assert(get(c, s, "ssid"), qt.Equals, "office")
+		c.Assert(get(c, s, "empty"), qt.Equals, "")
+		c.Assert(s.Has("pass"), qt.IsFalse)
+	}
+}
+
+func TestGarbageCollection(t *testing.T) {
+	c := qt.New(t)
+	dev := newFlash(4)
+	s := open(c, dev)
+	c.Assert(s.Set("name", []byte("sensor-1")), qt.IsNil)
+	for i := 0; i < 1000; i++ {
+		c.Assert(s.Set("counter", []byte(fmt.Sprint(i))), qt.IsNil)
+		if i%100 == 0 {
+			s = open(c, dev)
+		}
+	}
+	s = open(c, dev)
+	c.Assert(get(c, s, "counter"), qt.Equals, "999")
+	c.Assert(get(c, s, "name"), qt.Equals, "sensor-1")
+
+	// all sectors are worn evenly
+	for i := 1; i < len(dev.erases); i++ {
+		diff := dev.erases[i] - dev.erases[0]
+		c.Assert(diff >= -2 && diff <= 2, qt.IsTrue, qt.Commentf("erases: %v", dev.erases))
+	}
+}
+
+func TestFull(t *testing.T) {
+	c := qt.New(t)
+	s := open(c, newFlash(2))
+	c.Assert(s.Set("big", make([]byte, testSectorSize)), qt.Equals, ErrTooLarge)
+	c.Assert(s.Set("a", []byte("first")), qt.IsNil)
+	c.Assert(s.Set("b", make([]byte, 100)), qt.IsNil)
+	c.Assert(s.Set("c", make([]byte, 120)), qt.Equals, ErrFull)
+	c.Assert(s.Delete("b"), qt.IsNil)
+	c.Assert(s.Set("c", make([]byte, 120)), qt.IsNil)
+	c.Assert(open(c, s.dev.(*norFlash)).Keys(), qt.DeepEquals, []string{"a", "c"})
+	c.Assert(get(c, s, "a"), qt.Equals, "first")
+}
+
+func TestAtomicUpdate(t *testing.T) {
+	c := qt.New(t)
+	for budget := 0; budget < 8; budget++ {
+		dev := newFlash(3)
+		s := open(c, dev)
+		old := bytes.Repeat([]byte{'a'}, 60)
+		for i := 0; i < 3; i++ {
+			c.Assert(s.Set("key", old), qt.IsNil)
+			c.Assert(s.Set("other", []byte{byte(i)}), qt.IsNil)
+		}
+
+		// power fails at some point while updating, possibly during garbage
+		// collection
+		dev.limited, dev.budget = true, budget
+		s.Set("key", bytes.Repeat([]byte{'b'}, 60))
+		dev.limited = false
+
+		s = open(c, dev)
+		got := get(c, s, "key")
+		c.Assert(got == string(old) || got == string(bytes.Repeat([]byte{'b'}, 60)), qt.IsTrue, qt.Commentf("budget %d: %q", budget, got))
+		c.Assert(get(c, s, "other"), qt.Equals, "\x02")
+
+		// the store is still usable afterwards
+		c.Assert(s.Set("key", []byte("c")), qt.IsNil)
+		c.Assert(get(c, open(c, dev), "key"), qt.Equals, "c")
+	}
+}
+
+type ram []byte
+
+func (r ram) ReadAt(buf []byte, off int64) (int, error)  { return copy(buf, r[off:]), nil }
+func (r ram) WriteAt(buf []byte, off int64) (int, error) { return copy(r[off:], buf), nil }
+func (r ram) Size() int64                                { return int64(len(r)) }
+
+func TestEEPROM(t *testing.T) {
+	c := qt.New(t)
+	dev := EEPROM(make(ram, 1000), 128)
+	c.Assert(dev.Size(), qt.Equals, int64(896))
+	s, err := Open(dev)
+	c.Assert(err, qt.IsNil)
+	c.Assert(s.Set("x", []byte("1")), qt.IsNil)
+	s, err = Open(dev)
+	c.Assert(err, qt.IsNil)
+	c.Assert(get(c, s, "x"), qt.Equals, "1")
+}
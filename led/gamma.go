@@ -0,0 +1,31 @@
+package led
+
+// gammaTable holds 65535*(x/65535)^2.2 for x = 0, 2048, 4096, ..., 65535.
+// Values in between are linearly interpolated, which is well below the
+// resolution of the human eye. The last interval is one step shorter, so
+// that 0xffff is full brightness.
+var gammaTable = [33]uint16{
+	0, 32, 147, 359, 676, 1104, 1649, 2314, 3104, 4022, 5072, 6255, 7575,
+	9033, 10633, 12375, 14263, 16298, 18482, 20817, 23304, 25944, 28740,
+	31693, 34803, 38074, 41505, 45098, 48855, 52776, 56862, 61116, 65535,
+}
+
+// Gamma16 converts a perceived brightness (0-0xffff) to the PWM duty cycle
+// that produces it, using a gamma of 2.2.
+func Gamma16(level uint16) uint16 {
+	i := level >> 11
+	frac := uint32(level & 0x7ff)
+	lo := uint32(gammaTable[i])
+	hi := uint32(gammaTable[i+1])
+	width := uint32(2048)
+	if i == uint16(len(gammaTable)-2) {
+		width = 2047
+	}
+	return uint16(lo + (hi-lo)*frac/width)
+}
+
+// Gamma8 converts a perceived brightness (0-255) to the PWM duty cycle that
+// produces it, using a gamma of 2.2.
+func Gamma8(level uint8) uint16 {
+	return Gamma16(uint16(level) * 0x101)
+}
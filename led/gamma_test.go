@@ -0,0 +1,25 @@
+package led
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestGamma(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(Gamma16(0), qt.Equals, uint16(0))
+	c.Assert(Gamma16(0xffff), qt.Equals, uint16(0xffff))
+	c.Assert(Gamma8(0), qt.Equals, uint16(0))
+	c.Assert(Gamma8(0xff), qt.Equals, uint16(0xffff))
+	c.Assert(Gamma16(0x8000), qt.Equals, uint16(14263))
+
+	prev := uint16(0)
+	for level := 0; level <= 0xffff; level++ {
+		v := Gamma16(uint16(level))
+		if v < prev {
+			c.Fatalf("Gamma16(%#x) = %d, below %d", level, v, prev)
+		}
+		prev = v
+	}
+}
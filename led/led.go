@@ -0,0 +1,197 @@
+// Package led provides a driver for dimmable LEDs connected to a PWM output.
+//
+// Brightness levels are perceptual: they are gamma corrected before being
+// written to the PWM, so that a level of half the maximum also looks half
+// as bright. Fades and blink patterns are advanced by calling Update
+// periodically, typically from a timer goroutine started with Run:
+//
+//	l := led.New(machine.PWM{Pin: machine.LED})
+//	l.Configure()
+//	go led.Run(10*time.Millisecond, &l)
+//	l.FadeTo(led.Max, time.Second)
+//
+// Several LEDs can be grouped as one logical light, see RGB.
+package led // import "tinygo.org/x/drivers/led"
+
+import (
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+// Max is the maximum brightness level.
+const Max = 0xffff
+
+// Updater is implemented by the LEDs and groups of LEDs of this package.
+type Updater interface {
+	Update()
+}
+
+// Run calls Update on all given LEDs at the given interval. It never returns
+// and is meant to be started as a goroutine.
+func Run(interval time.Duration, leds ...Updater) {
+	for {
+		for _, l := range leds {
+			l.Update()
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Pattern is a blink pattern: the LED is switched on for On and off for Off,
+// Repeat times. A Repeat of 0 blinks forever.
+type Pattern struct {
+	On     time.Duration
+	Off    time.Duration
+	Repeat int
+}
+
+// Device is a single dimmable LED.
+type Device struct {
+	pwm   drivers.PWM
+	level uint16
+
+	// fade state
+	fading   bool
+	from     uint16
+	to       uint16
+	start    time.Time
+	duration time.Duration
+
+	// blink state
+	blinking bool
+	pattern  Pattern
+	onLevel  uint16
+	lit      bool
+	count    int
+	toggled  time.Time
+}
+
+// New returns a new LED driver given the PWM output it is connected to.
+func New(pwm drivers.PWM) Device {
+	return Device{
+		pwm: pwm,
+	}
+}
+
+// Configure configures the PWM output and switches the LED off.
+func (d *Device) Configure() {
+	d.pwm.Configure()
+	d.Set(0)
+}
+
+// Set sets the brightness level (0-Max) immediately, cancelling any fade
+// or blink pattern in progress.
+func (d *Device) Set(level uint16) {
+	d.fading = false
+	d.blinking = false
+	d.write(level)
+}
+
+// Set8 sets the brightness level using an 8-bit value (0-255).
+func (d *Device) Set8(level uint8) {
+	d.Set(uint16(level) * 0x101)
+}
+
+// Level returns the current brightness level.
+func (d *Device) Level() uint16 {
+	return d.level
+}
+
+// On switches the LED to full brightness.
+func (d *Device) On() {
+	d.Set(Max)
+}
+
+// Off switches the LED off.
+func (d *Device) Off() {
+	d.Set(0)
+}
+
+// FadeTo starts a linear fade from the current level to the given level
+// over the given duration.
+func (d *Device) FadeTo(level uint16, duration time.Duration) {
+	d.blinking = false
+	if duration <= 0 {
+		d.Set(level)
+		return
+	}
+	d.fading = true
+	d.from = d.level
+	d.to = level
+	d.start = time.Now()
+	d.duration = duration
+}
+
+// Fading returns whether a fade is in progress.
+func (d *Device) Fading() bool {
+	return d.fading
+}
+
+// Blink starts a blink pattern, where the LED is lit at the given level.
+func (d *Device) Blink(level uint16, pattern Pattern) {
+	d.fading = false
+	d.blinking = true
+	d.pattern = pattern
+	d.onLevel = level
+	d.count = 0
+	d.lit = true
+	d.toggled = time.Now()
+	d.write(level)
+}
+
+// Blinking returns whether a blink pattern is in progress.
+func (d *Device) Blinking() bool {
+	return d.blinking
+}
+
+// Update advances a fade or blink pattern in progress.
+func (d *Device) Update() {
+	now := time.Now()
+	if d.fading {
+		elapsed := now.Sub(d.start)
+		if elapsed >= d.duration {
+			d.fading = false
+			d.write(d.to)
+			return
+		}
+		d.write(interpolate(d.from, d.to, elapsed, d.duration))
+	}
+	if d.blinking {
+		wait := d.pattern.Off
+		if d.lit {
+			wait = d.pattern.On
+		}
+		if now.Sub(d.toggled) < wait {
+			return
+		}
+		d.toggled = now
+		if d.lit {
+			d.lit = false
+			d.write(0)
+			d.count++
+			if d.pattern.Repeat > 0 && d.count >= d.pattern.Repeat {
+				d.blinking = false
+			}
+		} else {
+			d.lit = true
+			d.write(d.onLevel)
+		}
+	}
+}
+
+func (d *Device) write(level uint16) {
+	d.level = level
+	d.pwm.Set(Gamma16(level))
+}
+
+// interpolate returns the level between from and to after elapsed out of
+// duration.
+func interpolate(from, to uint16, elapsed, duration time.Duration) uint16 {
+	// scale the durations down to avoid overflows in the multiplication
+	frac := uint64(elapsed/time.Microsecond) * 0x10000 / uint64(duration/time.Microsecond+1)
+	if to >= from {
+		return from + uint16(uint64(to-from)*frac>>16)
+	}
+	return from - uint16(uint64(from-to)*frac>>16)
+}
@@ -0,0 +1,116 @@
+package led
+
+import (
+	"image/color"
+	"time"
+)
+
+// RGB groups three LEDs with a red, green and blue color into a single
+// logical light.
+type RGB struct {
+	R, G, B *Device
+}
+
+// NewRGB returns a new RGB light given the three LEDs it consists of.
+func NewRGB(r, g, b *Device) RGB {
+	return RGB{R: r, G: g, B: b}
+}
+
+// Configure configures all three LEDs and switches them off.
+func (l *RGB) Configure() {
+	l.R.Configure()
+	l.G.Configure()
+	l.B.Configure()
+}
+
+// SetColor sets the color of the light immediately. The alpha channel is
+// used as overall brightness.
+func (l *RGB) SetColor(c color.RGBA) {
+	r, g, b := scale(c)
+	l.R.Set(r)
+	l.G.Set(g)
+	l.B.Set(b)
+}
+
+// FadeToColor fades the light to the given color over the given duration.
+func (l *RGB) FadeToColor(c color.RGBA, duration time.Duration) {
+	r, g, b := scale(c)
+	l.R.FadeTo(r, duration)
+	l.G.FadeTo(g, duration)
+	l.B.FadeTo(b, duration)
+}
+
+// SetHSV sets the color of the light from a hue (0-359 degrees), saturation
+// and value (both 0-255).
+func (l *RGB) SetHSV(h uint16, s, v uint8) {
+	l.SetColor(HSV(h, s, v))
+}
+
+// FadeToHSV fades the light to the color given as hue (0-359 degrees),
+// saturation and value (both 0-255).
+func (l *RGB) FadeToHSV(h uint16, s, v uint8, duration time.Duration) {
+	l.FadeToColor(HSV(h, s, v), duration)
+}
+
+// Blink blinks the light in the given color.
+func (l *RGB) Blink(c color.RGBA, pattern Pattern) {
+	r, g, b := scale(c)
+	l.R.Blink(r, pattern)
+	l.G.Blink(g, pattern)
+	l.B.Blink(b, pattern)
+}
+
+// Off switches all three LEDs off.
+func (l *RGB) Off() {
+	l.R.Off()
+	l.G.Off()
+	l.B.Off()
+}
+
+// Update advances fades and blink patterns of all three LEDs.
+func (l *RGB) Update() {
+	l.R.Update()
+	l.G.Update()
+	l.B.Update()
+}
+
+// HSV converts a hue (0-359 degrees), saturation and value (both 0-255) to
+// an opaque RGB color using integer math only.
+func HSV(h uint16, s, v uint8) color.RGBA {
+	h %= 360
+	if s == 0 {
+		return color.RGBA{v, v, v, 0xff}
+	}
+	region := h / 60
+	// position within the region, 0-255
+	rem := uint32(h-region*60) * 255 / 60
+	vv := uint32(v)
+	ss := uint32(s)
+	p := uint8(vv * (255 - ss) / 255)
+	q := uint8(vv * (255 - ss*rem/255) / 255)
+	t := uint8(vv * (255 - ss*(255-rem)/255) / 255)
+	switch region {
+	case 0:
+		return color.RGBA{v, t, p, 0xff}
+	case 1:
+		return color.RGBA{q, v, p, 0xff}
+	case 2:
+		return color.RGBA{p, v, t, 0xff}
+	case 3:
+		return color.RGBA{p, q, v, 0xff}
+	case 4:
+		return color.RGBA{t, p, v, 0xff}
+	default:
+		return color.RGBA{v, p, q, 0xff}
+	}
+}
+
+// scale converts the color to the three 16-bit LED levels, applying the
+// alpha channel as brightness.
+func scale(c color.RGBA) (r, g, b uint16) {
+	a := uint32(c.A)
+	r = uint16(uint32(c.R) * a * 0x101 / 255)
+	g = uint16(uint32(c.G) * a * 0x101 / 255)
+	b = uint16(uint32(c.B) * a * 0x101 / 255)
+	return
+}
@@ -0,0 +1,19 @@
+package lis2mdl
+
+import (
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/registry"
+)
+
+func init() {
+	registry.Register(registry.Driver{
+		Name:      "lis2mdl",
+		Bus:       registry.I2C,
+		Addresses: []uint16{ADDRESS},
+		Probe: func(bus drivers.I2C, address uint16) bool {
+			d := New(bus)
+			d.Address = uint8(address)
+			return d.Connected()
+		},
+	})
+}
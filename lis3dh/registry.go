@@ -0,0 +1,19 @@
+package lis3dh
+
+import (
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/registry"
+)
+
+func init() {
+	registry.Register(registry.Driver{
+		Name:      "lis3dh",
+		Bus:       registry.I2C,
+		Addresses: []uint16{Address0, Address1},
+		Probe: func(bus drivers.I2C, address uint16) bool {
+			d := New(bus)
+			d.Address = address
+			return d.Connected()
+		},
+	})
+}
@@ -0,0 +1,100 @@
+// Package max31855 provides a driver for the MAX31855 thermocouple to
+// digital converter by Maxim, which measures the temperature of a K, J, N,
+// T, S, R or E type thermocouple, compensated with the temperature of its
+// cold junction.
+//
+// Datasheet:
+// https://www.analog.com/media/en/technical-documentation/data-sheets/MAX31855.pdf
+//
+package max31855 // import "tinygo.org/x/drivers/max31855"
+
+import (
+	"strings"
+
+	"tinygo.org/x/drivers"
+)
+
+var _ drivers.Thermometer = &Device{}
+
+// Fault is a set of the faults of the thermocouple. It is returned when the
+// converter detects one.
+type Fault uint8
+
+// Faults of the thermocouple.
+const (
+	FAULT_OPEN      Fault = 0x01 // not connected
+	FAULT_SHORT_GND Fault = 0x02 // shorted to GND
+	FAULT_SHORT_VCC Fault = 0x04 // shorted to VCC
+)
+
+var faultNames = [...]string{
+	"thermocouple open",
+	"thermocouple shorted to GND",
+	"thermocouple shorted to VCC",
+}
+
+func (f Fault) Error() string {
+	var names []string
+	for i, name := range faultNames {
+		if f&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "max31855: unknown fault"
+	}
+	return "max31855: " + strings.Join(names, ", ")
+}
+
+// Device wraps a SPI connection to a MAX31855 device.
+type Device struct {
+	bus drivers.SPI
+	cs  drivers.Pin
+
+	buf [4]byte
+}
+
+// New creates a new MAX31855 connection on a SPI bus, in mode 0 and at up
+// to 5MHz, with the chip select pin, which must be configured as an output.
+// The bus must already be configured.
+//
+// This function only creates the Device object, it does not touch the
+// device, which converts continuously.
+func New(bus drivers.SPI, cs drivers.Pin) Device {
+	cs.High()
+	return Device{
+		bus: bus,
+		cs:  cs,
+	}
+}
+
+// ReadTemperature returns the temperature of the thermocouple in celsius
+// milli degrees (°C/1000), in steps of 0.25°C.
+func (d *Device) ReadTemperature() (int32, error) {
+	temp, _, err := d.Read()
+	return temp, err
+}
+
+// Read returns the temperatures of the thermocouple, in steps of 0.25°C, and
+// of the cold junction, the converter itself, in steps of 0.0625°C, both in
+// celsius milli degrees. It returns a Fault when the thermocouple is faulty,
+// along with the temperature of the cold junction, which is still valid.
+func (d *Device) Read() (thermocouple int32, coldJunction int32, err error) {
+	d.buf = [4]byte{}
+	d.cs.Low()
+	err = d.bus.Tx(d.buf[:], d.buf[:])
+	d.cs.High()
+	if err != nil {
+		return
+	}
+	frame := uint32(d.buf[0])<<24 | uint32(d.buf[1])<<16 | uint32(d.buf[2])<<8 | uint32(d.buf[3])
+	// both temperatures are signed, the thermocouple one on 14 bits in the
+	// top bits, the cold junction one on 12 bits above the faults, which are
+	// sign extended by the shifts
+	coldJunction = int32(int16(frame)>>4) * 125 / 2
+	if frame&0x10000 != 0 {
+		return 0, coldJunction, Fault(frame & 0x07)
+	}
+	thermocouple = int32(int32(frame)>>18) * 250
+	return thermocouple, coldJunction, nil
+}
@@ -0,0 +1,84 @@
+package max31855
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// converter is a simulated MAX31855 sending a frame.
+type converter struct {
+	frame    uint32
+	selected bool
+}
+
+func (c *converter) Tx(w, r []byte) error {
+	for i := range r {
+		r[i] = byte(c.frame >> (24 - 8*uint(i)))
+	}
+	return nil
+}
+
+func (c *converter) Transfer(b byte) (byte, error) { return 0, nil }
+
+func (c *converter) Get() bool     { return !c.selected }
+func (c *converter) Set(high bool) { c.selected = !high }
+func (c *converter) High()         { c.selected = false }
+func (c *converter) Low()          { c.selected = true }
+
+// frame builds a frame from the raw temperatures and the faults.
+func frame(thermocouple, coldJunction int16, faults uint32) uint32 {
+	f := uint32(uint16(thermocouple)&0x3FFF)<<18 | uint32(uint16(coldJunction)&0x0FFF)<<4 | faults
+	if faults != 0 {
+		f |= 0x10000
+	}
+	return f
+}
+
+func TestRead(t *testing.T) {
+	c := qt.New(t)
+	conv := &converter{}
+	d := New(conv, conv)
+	// thermocouple temperatures of the datasheet, in steps of 0.25°C, and cold
+	// junction temperatures in steps of 0.0625°C
+	for _, tc := range []struct {
+		rawTC, rawCJ int16
+		tc, cj       int32
+	}{
+		{6400, 1600, 1600000, 100000},
+		{100, 403, 25000, 25187},
+		{1, 1, 250, 62},
+		{0, 0, 0, 0},
+		{-1, -1, -250, -62},
+		{-1000, -800, -250000, -50000},
+	} {
+		conv.frame = frame(tc.rawTC, tc.rawCJ, 0)
+		thermocouple, coldJunction, err := d.Read()
+		c.Assert(err, qt.IsNil)
+		c.Assert(thermocouple, qt.Equals, tc.tc)
+		c.Assert(coldJunction, qt.Equals, tc.cj)
+	}
+	c.Assert(conv.selected, qt.IsFalse)
+
+	// the datasheet's frame for 25°C and 25.1875°C
+	conv.frame = 0x01901930
+	temp, err := d.ReadTemperature()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(25000))
+}
+
+func TestFault(t *testing.T) {
+	c := qt.New(t)
+	conv := &converter{}
+	d := New(conv, conv)
+	conv.frame = frame(0, 400, uint32(FAULT_OPEN))
+	_, coldJunction, err := d.Read()
+	c.Assert(err, qt.Equals, FAULT_OPEN)
+	c.Assert(err, qt.ErrorMatches, "max31855: thermocouple open")
+	c.Assert(coldJunction, qt.Equals, int32(25000))
+
+	conv.frame = frame(0, 400, uint32(FAULT_SHORT_GND|FAULT_SHORT_VCC))
+	_, err = d.ReadTemperature()
+	c.Assert(err, qt.Equals, FAULT_SHORT_GND|FAULT_SHORT_VCC)
+	c.Assert(err, qt.ErrorMatches, "max31855: thermocouple shorted to GND, thermocouple shorted to VCC")
+}
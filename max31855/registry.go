@@ -0,0 +1,10 @@
+package max31855
+
+import "tinygo.org/x/drivers/registry"
+
+func init() {
+	registry.Register(registry.Driver{
+		Name: "max31855",
+		Bus:  registry.SPI,
+	})
+}
@@ -0,0 +1,185 @@
+// Package mb85rc provides a driver for the MB85RC16/64/128/256 I2C
+// ferroelectric RAM (FRAM).
+//
+// Unlike an EEPROM, FRAM is written at bus speed without write cycles or
+// pages and endures about 10^12 writes per byte, which makes it a good
+// place to store frequently updated state. The storage API is the same as
+// the one of the at24cx EEPROM driver.
+//
+// Datasheet: https://www.fujitsu.com/uk/Images/MB85RC256V-DS501-00017-3v0-E.pdf
+package mb85rc // import "tinygo.org/x/drivers/mb85rc"
+
+import (
+	"errors"
+
+	"tinygo.org/x/drivers"
+)
+
+var errOutOfRange = errors.New("mb85rc: address out of range")
+
+// Model selects the capacity and the addressing scheme of the chip.
+type Model uint8
+
+// Supported models. The zero value is the MB85RC256V.
+const (
+	MB85RC256 Model = iota
+	MB85RC16
+	MB85RC64
+	MB85RC128
+)
+
+func (m Model) size() uint32 {
+	switch m {
+	case MB85RC16:
+		return 2048
+	case MB85RC64:
+		return 8192
+	case MB85RC128:
+		return 16384
+	default:
+		return 32768
+	}
+}
+
+// Device wraps an I2C connection to an MB85RC device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+	model   Model
+	size    uint32
+	offset  uint32
+	buf     [34]byte
+}
+
+// Config contains the settings used by Configure.
+type Config struct {
+	// Model of the chip, MB85RC256 if not set.
+	Model Model
+}
+
+// DeviceID is the identification read from the chip.
+type DeviceID struct {
+	Manufacturer uint16
+	Product      uint16
+}
+
+// New creates a new MB85RC connection. The I2C bus must already be
+// configured. For the MB85RC16 the low bits of the address select a block
+// of 256 bytes, so they must be zero.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Configure sets up the device for communication.
+func (d *Device) Configure(cfg Config) {
+	d.model = cfg.Model
+	d.size = cfg.Model.size()
+}
+
+// Size returns the capacity of the memory in bytes.
+func (d *Device) Size() int64 {
+	return int64(d.size)
+}
+
+// ReadDeviceID reads the manufacturer and product ID. It is supported by
+// most chips except the MB85RC16; Fujitsu chips report ManufacturerFujitsu.
+func (d *Device) ReadDeviceID() (DeviceID, error) {
+	var id [3]byte
+	err := d.bus.Tx(deviceIDAddress, []byte{uint8(d.Address << 1)}, id[:])
+	if err != nil {
+		return DeviceID{}, err
+	}
+	return DeviceID{
+		Manufacturer: uint16(id[0])<<4 | uint16(id[1])>>4,
+		Product:      uint16(id[1]&0x0F)<<8 | uint16(id[2]),
+	}, nil
+}
+
+// Connected returns whether a Fujitsu FRAM chip has been found.
+func (d *Device) Connected() bool {
+	id, err := d.ReadDeviceID()
+	return err == nil && id.Manufacturer == ManufacturerFujitsu
+}
+
+// WriteAt writes a byte array at the specified address. Any number of bytes
+// can be written at any address, there are no pages.
+func (d *Device) WriteAt(data []byte, offset int64) (n int, err error) {
+	if offset < 0 || offset+int64(len(data)) > int64(d.size) {
+		return 0, errOutOfRange
+	}
+	addr := uint32(offset)
+	for n < len(data) {
+		i2cAddr, header := d.address(addr)
+		c := copy(d.buf[header:], data[n:])
+		if err := d.bus.Tx(i2cAddr, d.buf[:header+c], nil); err != nil {
+			return n, err
+		}
+		n += c
+		addr += uint32(c)
+	}
+	return n, nil
+}
+
+// ReadAt reads the bytes at the specified address, in a single sequential
+// read.
+func (d *Device) ReadAt(data []byte, offset int64) (n int, err error) {
+	if offset < 0 || offset+int64(len(data)) > int64(d.size) {
+		return 0, errOutOfRange
+	}
+	i2cAddr, header := d.address(uint32(offset))
+	if err := d.bus.Tx(i2cAddr, d.buf[:header], data); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// address returns the I2C address and fills the word address bytes in the
+// buffer for the given memory address.
+func (d *Device) address(offset uint32) (uint16, int) {
+	if d.model == MB85RC16 {
+		d.buf[0] = uint8(offset)
+		return d.Address | uint16(offset>>8)&0x07, 1
+	}
+	d.buf[0] = uint8(offset >> 8)
+	d.buf[1] = uint8(offset)
+	return d.Address, 2
+}
+
+// Seek sets the offset for the next Read or Write, interpreted according to
+// whence: 0 means relative to the start of the memory, 1 means relative to
+// the current offset, and 2 means relative to the end.
+func (d *Device) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case 1:
+		offset += int64(d.offset)
+	case 2:
+		offset += int64(d.size)
+	case 0:
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 || offset > int64(d.size) {
+		return int64(d.offset), errOutOfRange
+	}
+	d.offset = uint32(offset)
+	return offset, nil
+}
+
+// Write writes len(data) bytes at the current offset.
+func (d *Device) Write(data []byte) (n int, err error) {
+	n, err = d.WriteAt(data, int64(d.offset))
+	d.offset += uint32(n)
+	return
+}
+
+// Read reads len(data) bytes from the current offset.
+func (d *Device) Read(data []byte) (n int, err error) {
+	n, err = d.ReadAt(data, int64(d.offset))
+	d.offset += uint32(n)
+	return
+}
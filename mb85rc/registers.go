@@ -0,0 +1,10 @@
+package mb85rc
+
+// The I2C address which this device listens to, with all address pins low.
+const Address = 0x50
+
+// deviceIDAddress is the reserved I2C address used to read the device ID.
+const deviceIDAddress = 0x7C
+
+// Manufacturer ID of Fujitsu.
+const ManufacturerFujitsu = 0x00A
@@ -0,0 +1,19 @@
+package mb85rc
+
+import (
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/registry"
+)
+
+func init() {
+	registry.Register(registry.Driver{
+		Name:      "mb85rc",
+		Bus:       registry.I2C,
+		Addresses: []uint16{Address, 0x51, 0x52, 0x53, 0x54, 0x55, 0x56, 0x57},
+		Probe: func(bus drivers.I2C, address uint16) bool {
+			d := New(bus)
+			d.Address = address
+			return d.Connected()
+		},
+	})
+}
@@ -0,0 +1,230 @@
+// Package mcp23017 provides a driver for the MCP23017 and MCP23S17 16-bit
+// I/O expanders, with an I2C and an SPI interface respectively.
+//
+// The pins of the expander implement drivers.Pin, so they can be used in
+// place of GPIO pins by other drivers.
+//
+// Datasheet:
+// https://ww1.microchip.com/downloads/en/devicedoc/20001952c.pdf
+package mcp23017 // import "tinygo.org/x/drivers/mcp23017"
+
+import (
+	"errors"
+
+	"tinygo.org/x/drivers"
+)
+
+var errPin = errors.New("mcp23017: invalid pin")
+
+// Pins is a mask of pins, with GPA0 to GPA7 in bits 0 to 7 and GPB0 to
+// GPB7 in bits 8 to 15.
+type Pins uint16
+
+// Config contains the settings used by Configure.
+type Config struct {
+	// Mirror connects both interrupt outputs together, so that INTA and
+	// INTB are both set by a change on any pin.
+	Mirror bool
+
+	// OpenDrain makes the interrupt outputs open drain, so that the outputs
+	// of several devices can be wired together. Otherwise they are push-
+	// pull outputs, active low unless ActiveHigh is set.
+	OpenDrain  bool
+	ActiveHigh bool
+}
+
+// transport reads and writes 16-bit register pairs.
+type transport interface {
+	read(reg uint8) (uint16, error)
+	write(reg uint8, value uint16) error
+}
+
+// Device is an MCP23017 or MCP23S17 I/O expander.
+type Device struct {
+	transport
+	iodir, gppu, ipol, olat uint16 // register caches
+}
+
+// NewI2C returns an MCP23017 on an I2C bus, at an address from 0x20 to
+// 0x27. The I2C bus must already be configured.
+//
+// This function only creates the Device object, it does not touch the
+// device.
+func NewI2C(bus drivers.I2C, address uint8) *Device {
+	return &Device{
+		transport: &i2cTransport{bus: bus, address: address},
+		iodir:     0xFFFF,
+	}
+}
+
+// NewSPI returns an MCP23S17 on an SPI bus, with address pins set to
+// address, from 0 to 7. Several devices can share the chip select pin, which
+// must already be configured as an output. The SPI bus must already be
+// configured, at up to 10MHz in mode 0.
+//
+// This function only creates the Device object, it does not touch the
+// device.
+func NewSPI(bus drivers.SPI, cs drivers.Pin, address uint8) *Device {
+	return &Device{
+		transport: &spiTransport{bus: bus, cs: cs, address: address},
+		iodir:     0xFFFF,
+	}
+}
+
+// Configure sets up the device, with all pins as inputs without pull-ups.
+func (d *Device) Configure(cfg Config) error {
+	var iocon uint8
+	if cfg.Mirror {
+		iocon |= iconMirror
+	}
+	if cfg.OpenDrain {
+		iocon |= iconOdr
+	}
+	if cfg.ActiveHigh {
+		iocon |= iconIntpol
+	}
+	if t, ok := d.transport.(*spiTransport); ok {
+		t.cs.High()
+
+		// the address pins are ignored until HAEN is set, so all devices on
+		// the chip select listen to address 0 until then
+		iocon |= iconHaen
+		addr := t.address
+		t.address = 0
+		err := t.write(IOCON, uint16(iocon)<<8|uint16(iocon))
+		t.address = addr
+		if err != nil {
+			return err
+		}
+	}
+	if err := d.write(IOCON, uint16(iocon)<<8|uint16(iocon)); err != nil {
+		return err
+	}
+	d.iodir, d.gppu, d.ipol, d.olat = 0xFFFF, 0, 0, 0
+	for _, reg := range []uint8{IPOL, GPINTEN, GPPU, OLAT} {
+		if err := d.write(reg, 0); err != nil {
+			return err
+		}
+	}
+	return d.write(IODIR, d.iodir)
+}
+
+// SetInputs makes the pins in mask inputs if inputs is set, or outputs.
+func (d *Device) SetInputs(mask Pins, inputs bool) error {
+	return d.update(IODIR, &d.iodir, mask, inputs)
+}
+
+// SetPullups enables or disables the 100kΩ pull-ups of the pins in mask.
+func (d *Device) SetPullups(mask Pins, enabled bool) error {
+	return d.update(GPPU, &d.gppu, mask, enabled)
+}
+
+// SetInverted inverts the level read from the input pins in mask.
+func (d *Device) SetInverted(mask Pins, inverted bool) error {
+	return d.update(IPOL, &d.ipol, mask, inverted)
+}
+
+// ReadPins returns the levels of all pins.
+func (d *Device) ReadPins() (Pins, error) {
+	v, err := d.read(GPIO)
+	return Pins(v), err
+}
+
+// WritePins sets the levels of all output pins.
+func (d *Device) WritePins(levels Pins) error {
+	d.olat = uint16(levels)
+	return d.write(OLAT, d.olat)
+}
+
+// SetPins sets the levels of the output pins in mask, keeping the others.
+func (d *Device) SetPins(mask Pins, high bool) error {
+	return d.update(OLAT, &d.olat, mask, high)
+}
+
+// SetInterruptOnChange enables an interrupt when any of the pins in mask
+// changes. Pins not in mask do not cause interrupts.
+func (d *Device) SetInterruptOnChange(mask Pins) error {
+	if err := d.write(INTCON, 0); err != nil {
+		return err
+	}
+	return d.write(GPINTEN, uint16(mask))
+}
+
+// SetInterruptOnCompare enables an interrupt while any of the pins in mask
+// differ from their level in defaults.
+func (d *Device) SetInterruptOnCompare(mask, defaults Pins) error {
+	if err := d.write(DEFVAL, uint16(defaults)); err != nil {
+		return err
+	}
+	if err := d.write(INTCON, uint16(mask)); err != nil {
+		return err
+	}
+	return d.write(GPINTEN, uint16(mask))
+}
+
+// ReadInterrupt returns the pins that caused an interrupt and the levels of
+// all pins at that moment. Reading them clears the interrupt.
+func (d *Device) ReadInterrupt() (flags, captured Pins, err error) {
+	f, err := d.read(INTF)
+	if err != nil {
+		return 0, 0, err
+	}
+	c, err := d.read(INTCAP)
+	return Pins(f), Pins(c), err
+}
+
+func (d *Device) update(reg uint8, cache *uint16, mask Pins, set bool) error {
+	v := *cache &^ uint16(mask)
+	if set {
+		v |= uint16(mask)
+	}
+	*cache = v
+	return d.write(reg, v)
+}
+
+// Pin returns a pin of the expander, from 0 (GPA0) to 15 (GPB7).
+func (d *Device) Pin(n int) Pin {
+	if n < 0 || n > 15 {
+		panic(errPin)
+	}
+	return Pin{d: d, mask: 1 << uint(n)}
+}
+
+type i2cTransport struct {
+	bus     drivers.I2C
+	address uint8
+	buf     [2]uint8
+}
+
+func (t *i2cTransport) read(reg uint8) (uint16, error) {
+	err := t.bus.ReadRegister(t.address, reg, t.buf[:])
+	return uint16(t.buf[1])<<8 | uint16(t.buf[0]), err
+}
+
+func (t *i2cTransport) write(reg uint8, value uint16) error {
+	t.buf[0], t.buf[1] = uint8(value), uint8(value>>8)
+	return t.bus.WriteRegister(t.address, reg, t.buf[:])
+}
+
+type spiTransport struct {
+	bus     drivers.SPI
+	cs      drivers.Pin
+	address uint8
+	tx, rx  [4]uint8
+}
+
+func (t *spiTransport) read(reg uint8) (uint16, error) {
+	t.tx = [4]uint8{spiRead | t.address<<1, reg, 0, 0}
+	t.cs.Low()
+	err := t.bus.Tx(t.tx[:], t.rx[:])
+	t.cs.High()
+	return uint16(t.rx[3])<<8 | uint16(t.rx[2]), err
+}
+
+func (t *spiTransport) write(reg uint8, value uint16) error {
+	t.tx = [4]uint8{spiWrite | t.address<<1, reg, uint8(value), uint8(value >> 8)}
+	t.cs.Low()
+	err := t.bus.Tx(t.tx[:], nil)
+	t.cs.High()
+	return err
+}
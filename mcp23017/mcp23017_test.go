@@ -0,0 +1,102 @@
+package mcp23017
+
+import (
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"tinygo.org/x/drivers/tester"
+)
+
+func newFake(c *qt.C) (*tester.I2CDevice, *Device) {
+	bus := tester.NewI2CBus(c)
+	fake := tester.NewI2CDevice(c, Address)
+	fake.SetupRegisters(make([]uint8, 0x16))
+	bus.AddDevice(fake)
+	return fake, NewI2C(bus, Address)
+}
+
+func TestConfigure(t *testing.T) {
+	c := qt.New(t)
+	fake, dev := newFake(c)
+	c.Assert(dev.Configure(Config{Mirror: true, OpenDrain: true}), qt.IsNil)
+	fake.AssertRegisters(c, IOCON, []uint8{0x44, 0x44})
+	fake.AssertRegisters(c, IODIR, []uint8{0xFF, 0xFF})
+}
+
+func TestPins(t *testing.T) {
+	c := qt.New(t)
+	fake, dev := newFake(c)
+	c.Assert(dev.Configure(Config{}), qt.IsNil)
+
+	led := dev.Pin(9)
+	c.Assert(led.Configure(Output), qt.IsNil)
+	btn := dev.Pin(2)
+	c.Assert(btn.Configure(InputPullup), qt.IsNil)
+	fake.AssertRegisters(c, IODIR, []uint8{0xFF, 0xFD})
+	fake.AssertRegisters(c, GPPU, []uint8{0x04, 0x00})
+
+	led.High()
+	dev.Pin(0).High()
+	fake.AssertRegisters(c, OLAT, []uint8{0x01, 0x02})
+	led.Low()
+	fake.AssertRegisters(c, OLAT, []uint8{0x01, 0x00})
+
+	fake.SetupRegister(GPIO, 0x04)
+	c.Assert(btn.Get(), qt.IsTrue)
+	fake.SetupRegister(GPIO, 0x00)
+	c.Assert(btn.Get(), qt.IsFalse)
+}
+
+func TestInterrupt(t *testing.T) {
+	c := qt.New(t)
+	fake, dev := newFake(c)
+	c.Assert(dev.SetInterruptOnCompare(0x0300, 0x0100), qt.IsNil)
+	fake.AssertRegisters(c, GPINTEN, []uint8{0x00, 0x03})
+	fake.AssertRegisters(c, DEFVAL, []uint8{0x00, 0x01})
+	fake.AssertRegisters(c, INTCON, []uint8{0x00, 0x03})
+	c.Assert(dev.SetInterruptOnChange(0x0001), qt.IsNil)
+	fake.AssertRegisters(c, INTCON, []uint8{0x00, 0x00})
+
+	fake.SetupRegister(INTF, 0x01)
+	fake.SetupRegister(INTCAP+1, 0x80)
+	flags, captured, err := dev.ReadInterrupt()
+	c.Assert(err, qt.IsNil)
+	c.Assert(flags, qt.Equals, Pins(0x0001))
+	c.Assert(captured, qt.Equals, Pins(0x8000))
+}
+
+// spiBus records the transfers of an MCP23S17 and its chip select.
+type spiBus struct {
+	cs        bool
+	transfers [][]byte
+}
+
+func (s *spiBus) Tx(w, r []byte) error {
+	if s.cs {
+		return errors.New("chip select is high")
+	}
+	s.transfers = append(s.transfers, append([]byte(nil), w...))
+	return nil
+}
+
+func (s *spiBus) Transfer(b byte) (byte, error) { return 0, nil }
+
+func (s *spiBus) Get() bool     { return s.cs }
+func (s *spiBus) Set(high bool) { s.cs = high }
+func (s *spiBus) High()         { s.cs = true }
+func (s *spiBus) Low()          { s.cs = false }
+
+func TestSPI(t *testing.T) {
+	c := qt.New(t)
+	bus := &spiBus{}
+	dev := NewSPI(bus, bus, 5)
+	c.Assert(dev.Configure(Config{}), qt.IsNil)
+	c.Assert(bus.cs, qt.IsTrue)
+	// the address pins are enabled by a write to address 0
+	c.Assert(bus.transfers[0], qt.DeepEquals, []byte{spiWrite, IOCON, iconHaen, iconHaen})
+	c.Assert(bus.transfers[1][0], qt.Equals, byte(spiWrite|5<<1))
+
+	c.Assert(dev.SetInputs(0x0001, false), qt.IsNil)
+	c.Assert(bus.transfers[len(bus.transfers)-1], qt.DeepEquals, []byte{spiWrite | 5<<1, IODIR, 0xFE, 0xFF})
+}
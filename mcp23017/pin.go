@@ -0,0 +1,63 @@
+package mcp23017
+
+import "tinygo.org/x/drivers"
+
+var _ drivers.Pin = Pin{}
+
+// PinMode is the mode of a single pin.
+type PinMode uint8
+
+// Pin modes.
+const (
+	Input PinMode = iota
+	InputPullup
+	Output
+)
+
+// Pin is a single pin of the expander. It implements drivers.Pin. Errors
+// on the bus are ignored by the methods of that interface; use Read and
+// Write to check them.
+type Pin struct {
+	d    *Device
+	mask Pins
+}
+
+// Configure sets the mode of the pin.
+func (p Pin) Configure(mode PinMode) error {
+	if err := p.d.SetPullups(p.mask, mode == InputPullup); err != nil {
+		return err
+	}
+	return p.d.SetInputs(p.mask, mode != Output)
+}
+
+// Read returns the level of the pin.
+func (p Pin) Read() (bool, error) {
+	v, err := p.d.ReadPins()
+	return v&p.mask != 0, err
+}
+
+// Write sets the level of an output pin.
+func (p Pin) Write(high bool) error {
+	return p.d.SetPins(p.mask, high)
+}
+
+// Get returns the level of the pin.
+func (p Pin) Get() bool {
+	v, _ := p.Read()
+	return v
+}
+
+// Set sets the level of an output pin.
+func (p Pin) Set(high bool) {
+	p.Write(high)
+}
+
+// High sets an output pin high.
+func (p Pin) High() {
+	p.Write(true)
+}
+
+// Low sets an output pin low.
+func (p Pin) Low() {
+	p.Write(false)
+}
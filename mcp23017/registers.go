@@ -0,0 +1,36 @@
+package mcp23017
+
+// The I2C address which this device listens to, with all address pins
+// low.
+const Address = 0x20
+
+// Registers, with IOCON.BANK = 0. The registers of port B follow those of
+// port A, so both are accessed in one sequential transfer.
+const (
+	IODIR   = 0x00
+	IPOL    = 0x02
+	GPINTEN = 0x04
+	DEFVAL  = 0x06
+	INTCON  = 0x08
+	IOCON   = 0x0A
+	GPPU    = 0x0C
+	INTF    = 0x0E
+	INTCAP  = 0x10
+	GPIO    = 0x12
+	OLAT    = 0x14
+)
+
+// IOCON bits
+const (
+	iconMirror = 1 << 6
+	iconSeqop  = 1 << 5
+	iconHaen   = 1 << 3
+	iconOdr    = 1 << 2
+	iconIntpol = 1 << 1
+)
+
+// SPI opcodes
+const (
+	spiWrite = 0x40
+	spiRead  = 0x41
+)
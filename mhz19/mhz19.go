@@ -0,0 +1,149 @@
+// Package mhz19 provides a driver for the MH-Z19B and MH-Z19C NDIR CO2
+// sensors by Winsen, over their serial port.
+//
+// The sensor answers commands of 9 bytes at 9600 baud. It calibrates itself
+// with its automatic baseline correction, which assumes that it sees fresh
+// air, at about 400ppm, at least once a day: it must be disabled for a
+// sensor that stays indoors.
+//
+// Datasheets:
+// https://www.winsen-sensor.com/d/files/infrared-gas-sensor/mh-z19b-co2-ver1_0.pdf
+// https://www.winsen-sensor.com/d/files/infrared-gas-sensor/mh-z19c-pins-type-co2-manual-ver1_0.pdf
+//
+package mhz19 // import "tinygo.org/x/drivers/mhz19"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	errChecksum = errors.New("mhz19: checksum mismatch")
+	errTimeout  = errors.New("mhz19: timeout waiting for the response")
+)
+
+// Device wraps a serial connection to an MH-Z19 device.
+type Device struct {
+	uart drivers.UART
+
+	// Timeout is the time to wait for a response, 100ms by default. A
+	// response takes about 10ms to send at 9600 baud.
+	Timeout time.Duration
+
+	buf [9]byte
+}
+
+// New creates a new MH-Z19 connection. The UART must already be configured
+// at BaudRate.
+//
+// This function only creates the Device object, it does not touch the
+// device, which needs 3 minutes to warm up after power on.
+func New(uart drivers.UART) Device {
+	return Device{
+		uart:    uart,
+		Timeout: 100 * time.Millisecond,
+	}
+}
+
+// ReadCO2 returns the CO2 concentration in ppm.
+func (d *Device) ReadCO2() (int32, error) {
+	if err := d.command(CMD_READ_CO2); err != nil {
+		return 0, err
+	}
+	if err := d.response(CMD_READ_CO2); err != nil {
+		return 0, err
+	}
+	return int32(d.buf[2])<<8 | int32(d.buf[3]), nil
+}
+
+// SetRange sets the detection range in ppm, usually RANGE_2000, RANGE_5000
+// or RANGE_10000. The accuracy is given for RANGE_5000 and below.
+func (d *Device) SetRange(ppm uint16) error {
+	return d.command(CMD_DETECTION_RANGE, 0, 0, 0, byte(ppm>>8), byte(ppm))
+}
+
+// SetAutoCalibration enables or disables the automatic baseline correction,
+// which is enabled after power on.
+func (d *Device) SetAutoCalibration(enabled bool) error {
+	var on byte
+	if enabled {
+		on = 0xA0
+	}
+	return d.command(CMD_ABC, on)
+}
+
+// CalibrateZero sets the current concentration as 400ppm. The sensor must
+// have been in fresh air for more than 20 minutes.
+func (d *Device) CalibrateZero() error {
+	return d.command(CMD_ZERO_POINT_CALIBRATION)
+}
+
+// CalibrateSpan sets the current concentration, in ppm, of a reference gas,
+// preferably 2000ppm. It must be done after CalibrateZero.
+func (d *Device) CalibrateSpan(ppm uint16) error {
+	return d.command(CMD_SPAN_POINT_CALIBRATION, byte(ppm>>8), byte(ppm))
+}
+
+// command discards the received bytes, such as the responses of the previous
+// commands, and sends a command with its data, padded with zeros.
+func (d *Device) command(cmd byte, data ...byte) error {
+	for d.uart.Buffered() > 0 {
+		if _, err := d.uart.ReadByte(); err != nil {
+			return err
+		}
+	}
+	d.buf[0] = 0xFF
+	d.buf[1] = 0x01 // sensor number
+	d.buf[2] = cmd
+	for i := 3; i < 8; i++ {
+		d.buf[i] = 0
+	}
+	copy(d.buf[3:8], data)
+	d.buf[8] = checksum(d.buf[:])
+	_, err := d.uart.Write(d.buf[:])
+	return err
+}
+
+// response reads the response of a command into the buffer, skipping the
+// bytes before its start, and checks the checksum.
+func (d *Device) response(cmd byte) error {
+	start := time.Now()
+	n := 0
+	for n < len(d.buf) {
+		if d.uart.Buffered() == 0 {
+			if time.Since(start) > d.Timeout {
+				return errTimeout
+			}
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		b, err := d.uart.ReadByte()
+		if err != nil {
+			return err
+		}
+		if n == 1 && b != cmd {
+			n = 0
+		}
+		if n == 0 && b != 0xFF {
+			continue
+		}
+		d.buf[n] = b
+		n++
+	}
+	if checksum(d.buf[:]) != d.buf[8] {
+		return errChecksum
+	}
+	return nil
+}
+
+// checksum computes the checksum of a frame: the negated sum of its bytes,
+// but the first and the checksum itself.
+func checksum(frame []byte) byte {
+	var sum byte
+	for _, b := range frame[1:8] {
+		sum += b
+	}
+	return -sum
+}
@@ -0,0 +1,94 @@
+package mhz19
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// sensor is a simulated MH-Z19, which measures 415ppm.
+type sensor struct {
+	frames [][]byte
+	rx     []byte
+
+	// noise is received before the next response
+	noise []byte
+	// silent makes the sensor ignore the commands
+	silent bool
+}
+
+func (s *sensor) Write(data []byte) (int, error) {
+	s.frames = append(s.frames, append([]byte(nil), data...))
+	if s.silent || data[2] != CMD_READ_CO2 {
+		return len(data), nil
+	}
+	response := []byte{0xFF, CMD_READ_CO2, 0x01, 0x9F, 0x45, 0x00, 0x00, 0x00, 0}
+	response[8] = checksum(response)
+	s.rx = append(append(s.rx, s.noise...), response...)
+	s.noise = nil
+	return len(data), nil
+}
+
+func (s *sensor) Buffered() int {
+	return len(s.rx)
+}
+
+func (s *sensor) ReadByte() (byte, error) {
+	b := s.rx[0]
+	s.rx = s.rx[1:]
+	return b, nil
+}
+
+func TestChecksum(t *testing.T) {
+	c := qt.New(t)
+	// read command of the datasheet
+	c.Assert(checksum([]byte{0xFF, 0x01, 0x86, 0, 0, 0, 0, 0, 0}), qt.Equals, byte(0x79))
+}
+
+func TestReadCO2(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{}
+	d := New(s)
+	co2, err := d.ReadCO2()
+	c.Assert(err, qt.IsNil)
+	c.Assert(co2, qt.Equals, int32(415))
+	c.Assert(s.frames, qt.DeepEquals, [][]byte{{0xFF, 0x01, 0x86, 0, 0, 0, 0, 0, 0x79}})
+
+	// a stale response is discarded and garbage is skipped
+	s.rx = []byte{0xFF, CMD_READ_CO2, 0x02}
+	s.noise = []byte{0x00, 0xFF, 0x99, 0xFF}
+	co2, err = d.ReadCO2()
+	c.Assert(err, qt.IsNil)
+	c.Assert(co2, qt.Equals, int32(415))
+	c.Assert(s.rx, qt.HasLen, 0)
+
+	s.noise = []byte{0xFF, CMD_READ_CO2, 0, 0, 0, 0, 0, 0, 0}
+	_, err = d.ReadCO2()
+	c.Assert(err, qt.Equals, errChecksum)
+
+	s.rx = nil
+	s.silent = true
+	d.Timeout = 10 * time.Millisecond
+	_, err = d.ReadCO2()
+	c.Assert(err, qt.Equals, errTimeout)
+}
+
+func TestCommands(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{}
+	d := New(s)
+	c.Assert(d.SetRange(RANGE_5000), qt.IsNil)
+	c.Assert(d.SetAutoCalibration(false), qt.IsNil)
+	c.Assert(d.SetAutoCalibration(true), qt.IsNil)
+	c.Assert(d.CalibrateZero(), qt.IsNil)
+	c.Assert(d.CalibrateSpan(2000), qt.IsNil)
+	// frames of the datasheet
+	c.Assert(s.frames, qt.DeepEquals, [][]byte{
+		{0xFF, 0x01, 0x99, 0x00, 0x00, 0x00, 0x13, 0x88, 0xCB},
+		{0xFF, 0x01, 0x79, 0x00, 0x00, 0x00, 0x00, 0x00, 0x86},
+		{0xFF, 0x01, 0x79, 0xA0, 0x00, 0x00, 0x00, 0x00, 0xE6},
+		{0xFF, 0x01, 0x87, 0x00, 0x00, 0x00, 0x00, 0x00, 0x78},
+		{0xFF, 0x01, 0x88, 0x07, 0xD0, 0x00, 0x00, 0x00, 0xA0},
+	})
+}
@@ -0,0 +1,20 @@
+package mhz19
+
+// Commands. Names copied from the datasheet.
+const (
+	CMD_READ_CO2               = 0x86
+	CMD_ZERO_POINT_CALIBRATION = 0x87
+	CMD_SPAN_POINT_CALIBRATION = 0x88
+	CMD_ABC                    = 0x79
+	CMD_DETECTION_RANGE        = 0x99
+)
+
+// Detection ranges, in ppm.
+const (
+	RANGE_2000  = 2000
+	RANGE_5000  = 5000
+	RANGE_10000 = 10000
+)
+
+// The baud rate of the serial port.
+const BaudRate = 9600
@@ -0,0 +1,10 @@
+package mhz19
+
+import "tinygo.org/x/drivers/registry"
+
+func init() {
+	registry.Register(registry.Driver{
+		Name: "mhz19",
+		Bus:  registry.UART,
+	})
+}
@@ -0,0 +1,173 @@
+// Package mlx90614 provides a driver for the MLX90614 infrared thermometer
+// by Melexis, which measures the temperature of an object without contact,
+// along with its own ambient temperature, over SMBus.
+//
+// The dual zone variants, such as the MLX90614xBx, have two thermopiles and
+// measure two objects.
+//
+// Datasheet:
+// https://www.melexis.com/-/media/files/documents/datasheets/mlx90614-datasheet-melexis.pdf
+//
+package mlx90614 // import "tinygo.org/x/drivers/mlx90614"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/internal/crc8"
+)
+
+var (
+	errPEC        = errors.New("mlx90614: PEC mismatch")
+	errMeasure    = errors.New("mlx90614: measurement error")
+	errEmissivity = errors.New("mlx90614: emissivity must be 100 to 1000")
+	errEEPROM     = errors.New("mlx90614: EEPROM write failed")
+)
+
+var _ drivers.Thermometer = &Device{}
+
+// Device wraps an SMBus connection to an MLX90614 device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	buf [5]byte
+}
+
+// New creates a new MLX90614 connection. The I2C bus must already be
+// configured, at up to 100kHz.
+//
+// This function only creates the Device object, it does not touch the
+// device.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Connected returns whether an MLX90614 has been found, from a read with a
+// valid PEC.
+func (d *Device) Connected() bool {
+	_, err := d.readWord(EEPROM_CONFIG_1)
+	return err == nil
+}
+
+// ReadTemperature returns the temperature of the object, the first one of
+// the dual zone variants, in celsius milli degrees (°C/1000).
+func (d *Device) ReadTemperature() (int32, error) {
+	return d.readTemperature(RAM_TOBJ1)
+}
+
+// ReadObjectTemperature2 returns the temperature of the second object of the
+// dual zone variants in celsius milli degrees.
+func (d *Device) ReadObjectTemperature2() (int32, error) {
+	return d.readTemperature(RAM_TOBJ2)
+}
+
+// ReadAmbientTemperature returns the temperature of the sensor itself in
+// celsius milli degrees.
+func (d *Device) ReadAmbientTemperature() (int32, error) {
+	return d.readTemperature(RAM_TA)
+}
+
+// DualZone returns whether the sensor measures two objects, from its
+// configuration.
+func (d *Device) DualZone() (bool, error) {
+	config, err := d.readWord(EEPROM_CONFIG_1)
+	return config&CONFIG_DUAL_ZONE != 0, err
+}
+
+// Emissivity returns the emissivity of the objects, in thousandths, that the
+// sensor compensates for.
+func (d *Device) Emissivity() (int32, error) {
+	e, err := d.readWord(EEPROM_EMISSIVITY)
+	return (int32(e)*1000 + 65535/2) / 65535, err
+}
+
+// SetEmissivity writes the emissivity of the objects, in thousandths from
+// 100 to 1000, to the EEPROM. It is kept after power off, and is 1000 when
+// the sensor leaves the factory.
+func (d *Device) SetEmissivity(emissivity int32) error {
+	if emissivity < 100 || emissivity > 1000 {
+		return errEmissivity
+	}
+	return d.writeEEPROM(EEPROM_EMISSIVITY, uint16((emissivity*65535+500)/1000))
+}
+
+// ID returns the 64-bit identification number of the sensor.
+func (d *Device) ID() (uint64, error) {
+	var id uint64
+	for i := byte(0); i < 4; i++ {
+		w, err := d.readWord(EEPROM_ID_1 + i)
+		if err != nil {
+			return 0, err
+		}
+		id = id<<16 | uint64(w)
+	}
+	return id, nil
+}
+
+// readTemperature reads a temperature register, in 0.02K with the error
+// flag in the top bit.
+func (d *Device) readTemperature(reg byte) (int32, error) {
+	raw, err := d.readWord(reg)
+	if err != nil {
+		return 0, err
+	}
+	if raw&0x8000 != 0 {
+		return 0, errMeasure
+	}
+	return int32(raw)*20 - 273150, nil
+}
+
+// writeEEPROM erases an EEPROM cell, writes it and reads it back.
+func (d *Device) writeEEPROM(reg byte, value uint16) error {
+	if err := d.writeWord(reg, 0); err != nil {
+		return err
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := d.writeWord(reg, value); err != nil {
+		return err
+	}
+	time.Sleep(10 * time.Millisecond)
+	read, err := d.readWord(reg)
+	if err != nil {
+		return err
+	}
+	if read != value {
+		return errEEPROM
+	}
+	return nil
+}
+
+// readWord reads a word of the RAM or of the EEPROM and checks its PEC,
+// computed over the whole transaction.
+func (d *Device) readWord(cmd byte) (uint16, error) {
+	d.buf[0] = cmd
+	data := d.buf[2:5]
+	if err := d.bus.Tx(d.Address, d.buf[:1], data); err != nil {
+		return 0, err
+	}
+	want := pec(0, byte(d.Address<<1), cmd, byte(d.Address<<1|1), data[0], data[1])
+	if want != data[2] {
+		return 0, errPEC
+	}
+	return uint16(data[1])<<8 | uint16(data[0]), nil
+}
+
+// writeWord writes a word, least significant byte first, followed by its
+// PEC.
+func (d *Device) writeWord(cmd byte, value uint16) error {
+	d.buf[0] = cmd
+	d.buf[1] = byte(value)
+	d.buf[2] = byte(value >> 8)
+	d.buf[3] = pec(pec(0, byte(d.Address<<1)), d.buf[:3]...)
+	return d.bus.Tx(d.Address, d.buf[:4], nil)
+}
+
+// pec continues the PEC of SMBus over the bytes.
+func pec(crc byte, data ...byte) byte {
+	return crc8.Update(crc, crc8.PolySMBus, data...)
+}
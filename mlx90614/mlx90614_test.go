@@ -0,0 +1,130 @@
+package mlx90614
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers/tester"
+)
+
+// sensor is a simulated MLX90614 on SMBus, with its RAM and EEPROM words.
+type sensor struct {
+	words map[byte]uint16
+	// writes are the EEPROM writes, in order
+	writes []uint16
+
+	// corrupt flips a bit of the next PEC
+	corrupt bool
+}
+
+func newSensor() *sensor {
+	return &sensor{words: map[byte]uint16{
+		RAM_TA:            0x3AD2, // 28.01°C, the read example of the datasheet
+		RAM_TOBJ1:         0x3C2B, // 34.91°C
+		RAM_TOBJ2:         0x8000, // error flag
+		EEPROM_EMISSIVITY: 0xFFFF,
+		EEPROM_CONFIG_1:   0x9FB4,
+		EEPROM_ID_1:       0x1234,
+		EEPROM_ID_1 + 1:   0x5678,
+		EEPROM_ID_1 + 2:   0x9ABC,
+		EEPROM_ID_1 + 3:   0xDEF0,
+	}}
+}
+
+func (s *sensor) tx(w, r []byte) error {
+	const addr = Address
+	if len(w) == 4 {
+		if pec(0, byte(addr<<1), w[0], w[1], w[2]) != w[3] {
+			return tester.ErrNack
+		}
+		value := uint16(w[2])<<8 | uint16(w[1])
+		// a cell that is not erased keeps its bits
+		if value != 0 && s.words[w[0]] != 0 {
+			value |= s.words[w[0]]
+		}
+		s.words[w[0]] = value
+		s.writes = append(s.writes, value)
+		return nil
+	}
+	if len(w) != 1 || len(r) != 3 {
+		return tester.ErrNack
+	}
+	value, ok := s.words[w[0]]
+	if !ok {
+		return tester.ErrNack
+	}
+	r[0] = byte(value)
+	r[1] = byte(value >> 8)
+	r[2] = pec(0, byte(addr<<1), w[0], byte(addr<<1|1), r[0], r[1])
+	if s.corrupt {
+		r[2] ^= 1
+		s.corrupt = false
+	}
+	return nil
+}
+
+// bus returns an SMBus with the sensor on it.
+func (s *sensor) bus(c *qt.C) *tester.I2CBus {
+	bus := tester.NewI2CBus(c)
+	bus.AddDevice(tester.NewI2CCommandDevice(c, Address, s.tx))
+	return bus
+}
+
+func TestRead(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s.bus(c))
+	c.Assert(d.Connected(), qt.IsTrue)
+
+	temp, err := d.ReadAmbientTemperature()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(28010))
+	temp, err = d.ReadTemperature()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(34910))
+
+	_, err = d.ReadObjectTemperature2()
+	c.Assert(err, qt.Equals, errMeasure)
+	dual, err := d.DualZone()
+	c.Assert(err, qt.IsNil)
+	c.Assert(dual, qt.IsFalse)
+	s.words[EEPROM_CONFIG_1] |= CONFIG_DUAL_ZONE
+	s.words[RAM_TOBJ2] = 0x3C2B
+	dual, err = d.DualZone()
+	c.Assert(err, qt.IsNil)
+	c.Assert(dual, qt.IsTrue)
+	temp, err = d.ReadObjectTemperature2()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(34910))
+
+	id, err := d.ID()
+	c.Assert(err, qt.IsNil)
+	c.Assert(id, qt.Equals, uint64(0x123456789ABCDEF0))
+
+	s.corrupt = true
+	_, err = d.ReadTemperature()
+	c.Assert(err, qt.Equals, errPEC)
+
+	// a device that does not answer
+	s.words = map[byte]uint16{}
+	c.Assert(d.Connected(), qt.IsFalse)
+}
+
+func TestEmissivity(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s.bus(c))
+	e, err := d.Emissivity()
+	c.Assert(err, qt.IsNil)
+	c.Assert(e, qt.Equals, int32(1000))
+
+	c.Assert(d.SetEmissivity(950), qt.IsNil)
+	c.Assert(s.writes, qt.DeepEquals, []uint16{0, 0xF332})
+	e, err = d.Emissivity()
+	c.Assert(err, qt.IsNil)
+	c.Assert(e, qt.Equals, int32(950))
+
+	c.Assert(d.SetEmissivity(50), qt.Equals, errEmissivity)
+	c.Assert(d.SetEmissivity(1001), qt.Equals, errEmissivity)
+}
@@ -0,0 +1,30 @@
+package mlx90614
+
+// The default I2C address of the device, which is stored in its EEPROM.
+const Address = 0x5A
+
+// RAM registers. Names and addresses copied from the datasheet.
+const (
+	RAM_RAW_IR1 = 0x04
+	RAM_RAW_IR2 = 0x05
+	RAM_TA      = 0x06
+	RAM_TOBJ1   = 0x07
+	RAM_TOBJ2   = 0x08
+)
+
+// EEPROM registers, with the EEPROM access bit of the commands.
+const (
+	EEPROM_TO_MAX     = 0x20
+	EEPROM_TO_MIN     = 0x21
+	EEPROM_PWMCTRL    = 0x22
+	EEPROM_TA_RANGE   = 0x23
+	EEPROM_EMISSIVITY = 0x24
+	EEPROM_CONFIG_1   = 0x25
+	EEPROM_SMBUS_ADDR = 0x2E
+	EEPROM_ID_1       = 0x3C
+)
+
+// Bits of the config register 1.
+const (
+	CONFIG_DUAL_ZONE = 0x0040
+)
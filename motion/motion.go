@@ -0,0 +1,211 @@
+// Package motion coordinates several stepper motor axes so that linear moves
+// start and arrive at the same time, as needed for plotters and small CNC
+// machines.
+//
+// The axis that has to travel furthest is the dominant axis: it follows a
+// trapezoidal speed profile with constant acceleration and deceleration.
+// The other axes are stepped in between using Bresenham's line algorithm,
+// so that every point of the move lies on the straight line between start
+// and target.
+package motion // import "tinygo.org/x/drivers/motion"
+
+import (
+	"errors"
+	"time"
+)
+
+var errAxisCount = errors.New("motion: number of coordinates does not match number of axes")
+
+// Axis is a single stepper motor. It is implemented by easystepper.Device.
+type Axis interface {
+	Step(forward bool)
+}
+
+// Planner drives a set of axes.
+type Planner struct {
+	axes []Axis
+	pos  []int32
+
+	// MaxSpeed is the speed of the dominant axis in steps per second.
+	MaxSpeed uint32
+
+	// Acceleration of the dominant axis in steps per second squared. Zero
+	// moves at MaxSpeed from the first step.
+	Acceleration uint32
+
+	// scratch buffers, so that moves do not allocate
+	delta []int32
+	err   []int32
+}
+
+// New returns a new planner for the given axes, all at position 0.
+func New(axes ...Axis) *Planner {
+	return &Planner{
+		axes:         axes,
+		pos:          make([]int32, len(axes)),
+		delta:        make([]int32, len(axes)),
+		err:          make([]int32, len(axes)),
+		MaxSpeed:     500,
+		Acceleration: 1000,
+	}
+}
+
+// Position returns the current position of the given axis in steps.
+func (p *Planner) Position(axis int) int32 {
+	return p.pos[axis]
+}
+
+// SetPosition redefines the current position of all axes, for example after
+// homing.
+func (p *Planner) SetPosition(pos ...int32) error {
+	if len(pos) != len(p.axes) {
+		return errAxisCount
+	}
+	copy(p.pos, pos)
+	return nil
+}
+
+// MoveTo moves all axes in a straight line to the given absolute position,
+// one coordinate per axis.
+func (p *Planner) MoveTo(target ...int32) error {
+	if len(target) != len(p.axes) {
+		return errAxisCount
+	}
+	for i := range target {
+		p.delta[i] = target[i] - p.pos[i]
+	}
+	p.run()
+	return nil
+}
+
+// Move moves all axes in a straight line by the given relative distances,
+// one per axis.
+func (p *Planner) Move(delta ...int32) error {
+	if len(delta) != len(p.axes) {
+		return errAxisCount
+	}
+	copy(p.delta, delta)
+	p.run()
+	return nil
+}
+
+// run executes the move stored in p.delta.
+func (p *Planner) run() {
+	var steps int32
+	for _, d := range p.delta {
+		if abs(d) > steps {
+			steps = abs(d)
+		}
+	}
+	if steps == 0 {
+		return
+	}
+	for i := range p.err {
+		p.err[i] = steps / 2
+	}
+
+	profile := newProfile(p.MaxSpeed, p.Acceleration, steps)
+	for n := int32(0); n < steps; n++ {
+		for i, d := range p.delta {
+			// Bresenham: step this axis whenever its error term overflows
+			p.err[i] -= abs(d)
+			if p.err[i] < 0 {
+				p.err[i] += steps
+				forward := d > 0
+				p.axes[i].Step(forward)
+				if forward {
+					p.pos[i]++
+				} else {
+					p.pos[i]--
+				}
+			}
+		}
+		time.Sleep(profile.next())
+	}
+}
+
+// profile computes the delay between the steps of a trapezoidal speed
+// profile, using the approximation by David Austin ("Generate stepper-motor
+// speed profiles in real time", 2005) which needs no square root per step.
+type profile struct {
+	min    uint64 // delay at full speed in nanoseconds
+	delay  uint64
+	n      int64 // step index within the ramp, negative when decelerating
+	step   int32
+	total  int32
+	ramp   int32 // number of steps needed to reach full speed
+	cruise bool
+}
+
+func newProfile(maxSpeed, accel uint32, total int32) *profile {
+	if maxSpeed == 0 {
+		maxSpeed = 1
+	}
+	p := &profile{
+		min:   uint64(time.Second) / uint64(maxSpeed),
+		total: total,
+	}
+	if accel == 0 {
+		p.delay = p.min
+		p.cruise = true
+		return p
+	}
+	// c0 = 0.676 * sqrt(2/a) seconds
+	p.delay = 676 * isqrt(2*uint64(time.Second)*uint64(time.Second)/uint64(accel)) / 1000
+	// steps to reach full speed: v²/2a
+	p.ramp = int32(uint64(maxSpeed) * uint64(maxSpeed) / (2 * uint64(accel)))
+	if p.ramp > total/2 {
+		// triangular profile, full speed is never reached
+		p.ramp = total / 2
+	}
+	return p
+}
+
+// next returns the delay to wait after the current step.
+func (p *profile) next() time.Duration {
+	delay := p.delay
+	p.step++
+	remaining := p.total - p.step
+	if p.cruise {
+		return time.Duration(delay)
+	}
+	switch {
+	case remaining < p.ramp:
+		// decelerating: run the ramp backwards
+		if p.n > 0 {
+			p.n = -p.n
+		}
+		p.n++
+		if p.n < 0 {
+			p.delay += 2 * p.delay / uint64(-4*p.n-1)
+		}
+	case p.step < p.ramp || p.delay > p.min:
+		p.n++
+		p.delay -= 2 * p.delay / uint64(4*p.n+1)
+		if p.delay < p.min {
+			p.delay = p.min
+		}
+	}
+	return time.Duration(delay)
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// isqrt returns the integer square root of v.
+func isqrt(v uint64) uint64 {
+	if v < 2 {
+		return v
+	}
+	x := v
+	y := (x + 1) / 2
+	for y < x {
+		x = y
+		y = (x + v/x) / 2
+	}
+	return x
+}
@@ -0,0 +1,15 @@
+// +build tinygo
+
+package mpr121
+
+import "machine"
+
+// UseIRQ makes Update read the device only after the IRQ pin signaled a
+// change. The pin is active low and needs a pull-up, which is enabled.
+func (d *Device) UseIRQ(pin machine.Pin) error {
+	pin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	d.useIRQ(pin.Get)
+	return pin.SetInterrupt(machine.PinFalling, func(machine.Pin) {
+		d.pending = true
+	})
+}
@@ -0,0 +1,255 @@
+// Package mpr121 provides a driver for the MPR121 12-channel capacitive
+// touch sensor controller.
+//
+// Datasheet:
+// https://www.nxp.com/docs/en/data-sheet/MPR121.pdf
+package mpr121 // import "tinygo.org/x/drivers/mpr121"
+
+import (
+	"errors"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	errNotFound    = errors.New("mpr121: device not found")
+	errElectrode   = errors.New("mpr121: invalid electrode")
+	errAutoConfig  = errors.New("mpr121: auto-configuration failed")
+	errOverCurrent = errors.New("mpr121: over current on the REXT pin")
+)
+
+// Proximity selects which electrodes are combined into the 13th,
+// proximity sensing electrode.
+type Proximity uint8
+
+// Proximity modes.
+const (
+	ProximityOff Proximity = iota
+	Proximity0to1
+	Proximity0to3
+	Proximity0to11
+)
+
+// ProximityElectrode is the number of the proximity electrode, for
+// FilteredData, Baseline and SetThresholds.
+const ProximityElectrode = 12
+
+// Config contains the settings used by Configure.
+type Config struct {
+	// Electrodes is the number of electrodes in use, starting at ELE0. All
+	// twelve are used if not set.
+	Electrodes uint8
+
+	// TouchThreshold and ReleaseThreshold of all electrodes, 12 and 6 if
+	// not set. A lower value is more sensitive.
+	TouchThreshold   uint8
+	ReleaseThreshold uint8
+
+	// Proximity enables the proximity electrode.
+	Proximity Proximity
+
+	// VDD is the supply voltage in millivolts, used to set the limits of
+	// the auto-configuration. It is 3300 if not set.
+	VDD uint16
+}
+
+// Device wraps an I2C connection to an MPR121 device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint8
+	ecr     uint8
+	irq     func() bool // level of the interrupt pin, nil without it
+	pending bool
+	touched uint16
+	buf     [2]uint8
+}
+
+// New creates a new MPR121 connection. The I2C bus must already be
+// configured.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Configure resets the device, sets the thresholds and filters and starts
+// it. The charge current and time of every electrode are found by the
+// auto-configuration of the chip.
+func (d *Device) Configure(cfg Config) error {
+	if cfg.Electrodes == 0 || cfg.Electrodes > 12 {
+		cfg.Electrodes = 12
+	}
+	if cfg.TouchThreshold == 0 {
+		cfg.TouchThreshold = 12
+	}
+	if cfg.ReleaseThreshold == 0 {
+		cfg.ReleaseThreshold = 6
+	}
+	if cfg.VDD == 0 {
+		cfg.VDD = 3300
+	}
+
+	if err := d.write(SOFTRESET, resetCommand); err != nil {
+		return err
+	}
+	if v, err := d.read(CONFIG2); err != nil || v != config2Default {
+		return errNotFound
+	}
+	if err := d.write(ECR, 0); err != nil {
+		return err
+	}
+	for e := 0; e <= ProximityElectrode; e++ {
+		if err := d.writeThresholds(e, cfg.TouchThreshold, cfg.ReleaseThreshold); err != nil {
+			return err
+		}
+	}
+
+	// baseline filter settings recommended by application note AN3944
+	settings := [...][2]uint8{
+		{MHDR, 0x01}, {NHDR, 0x01}, {NCLR, 0x0E}, {FDLR, 0x00},
+		{MHDF, 0x01}, {NHDF, 0x05}, {NCLF, 0x01}, {FDLF, 0x00},
+		{NHDT, 0x00}, {NCLT, 0x00}, {FDLT, 0x00},
+		{DEBOUNCE, 0x00},
+		{CONFIG1, 0x10}, // 16µA charge current, 6 samples for the first filter
+		{CONFIG2, 0x20}, // 0.5µs charge time, 4 samples, 1ms period
+	}
+	for _, s := range settings {
+		if err := d.write(s[0], s[1]); err != nil {
+			return err
+		}
+	}
+
+	// auto-configuration limits from the supply voltage, see AN3889
+	usl := uint8(uint32(cfg.VDD-700) * 256 / uint32(cfg.VDD))
+	limits := [...][2]uint8{
+		{UPLIMIT, usl},
+		{TARGETLIMIT, uint8(uint16(usl) * 9 / 10)},
+		{LOWLIMIT, uint8(uint16(usl) * 65 / 100)},
+		{AUTOCONFIG0, 0x0B}, // enable auto-configuration and reconfiguration
+	}
+	for _, s := range limits {
+		if err := d.write(s[0], s[1]); err != nil {
+			return err
+		}
+	}
+
+	// start with the baseline initialized from the first measurement
+	d.ecr = 0x80 | uint8(cfg.Proximity)<<4 | cfg.Electrodes
+	if err := d.write(ECR, d.ecr); err != nil {
+		return err
+	}
+	status, err := d.readStatus()
+	if err != nil {
+		return err
+	}
+	if status&(1<<(8+OVCF)) != 0 {
+		return errOverCurrent
+	}
+	oor, err := d.read(OORSTATUS_H)
+	if err != nil {
+		return err
+	}
+	if oor&(1<<ACFF|1<<ARFF) != 0 {
+		return errAutoConfig
+	}
+	return nil
+}
+
+// SetThresholds sets the touch and release thresholds of one electrode.
+// The device is stopped for a short moment, as required to change them.
+func (d *Device) SetThresholds(electrode int, touch, release uint8) error {
+	if electrode < 0 || electrode > ProximityElectrode {
+		return errElectrode
+	}
+	if err := d.write(ECR, 0); err != nil {
+		return err
+	}
+	if err := d.writeThresholds(electrode, touch, release); err != nil {
+		return err
+	}
+	return d.write(ECR, d.ecr)
+}
+
+func (d *Device) writeThresholds(electrode int, touch, release uint8) error {
+	d.buf[0], d.buf[1] = touch, release
+	return d.bus.WriteRegister(d.Address, TOUCHTH_0+uint8(2*electrode), d.buf[:2])
+}
+
+// Touched returns the touch state of all electrodes, one bit per electrode
+// with the proximity electrode as bit 12.
+func (d *Device) Touched() (uint16, error) {
+	status, err := d.readStatus()
+	return status & 0x1FFF, err
+}
+
+// IsTouched returns whether an electrode is touched.
+func (d *Device) IsTouched(electrode int) (bool, error) {
+	touched, err := d.Touched()
+	return touched&(1<<uint(electrode)) != 0, err
+}
+
+// FilteredData returns the 10-bit filtered measurement of an electrode,
+// which decreases when it is touched or approached.
+func (d *Device) FilteredData(electrode int) (uint16, error) {
+	if electrode < 0 || electrode > ProximityElectrode {
+		return 0, errElectrode
+	}
+	err := d.bus.ReadRegister(d.Address, FILTDATA_0L+uint8(2*electrode), d.buf[:2])
+	return uint16(d.buf[1]&0x03)<<8 | uint16(d.buf[0]), err
+}
+
+// Baseline returns the baseline value of an electrode on the same scale as
+// FilteredData. The baseline follows slow changes of the measurement; a
+// touch is detected when the filtered data drops below the baseline by
+// more than the touch threshold.
+func (d *Device) Baseline(electrode int) (uint16, error) {
+	if electrode < 0 || electrode > ProximityElectrode {
+		return 0, errElectrode
+	}
+	v, err := d.read(BASELINE_0 + uint8(electrode))
+	return uint16(v) << 2, err
+}
+
+// useIRQ makes Update read the device only after a change, signaled by a
+// call to the interrupt handler or by the level of the IRQ pin.
+func (d *Device) useIRQ(irq func() bool) {
+	d.irq, d.pending = irq, true
+}
+
+// Update returns the electrodes that were touched and released since the
+// last call. With an IRQ pin the device is only read after a change, so
+// Update can be called often.
+func (d *Device) Update() (touched, released uint16, err error) {
+	if d.irq != nil {
+		if !d.pending && d.irq() {
+			return 0, 0, nil
+		}
+		d.pending = false
+	}
+	// reading the status releases the IRQ pin
+	now, err := d.Touched()
+	if err != nil {
+		return 0, 0, err
+	}
+	touched, released = now&^d.touched, d.touched&^now
+	d.touched = now
+	return touched, released, nil
+}
+
+func (d *Device) readStatus() (uint16, error) {
+	err := d.bus.ReadRegister(d.Address, TOUCHSTATUS_L, d.buf[:2])
+	return uint16(d.buf[1])<<8 | uint16(d.buf[0]), err
+}
+
+func (d *Device) read(reg uint8) (uint8, error) {
+	err := d.bus.ReadRegister(d.Address, reg, d.buf[:1])
+	return d.buf[0], err
+}
+
+func (d *Device) write(reg, value uint8) error {
+	d.buf[0] = value
+	return d.bus.WriteRegister(d.Address, reg, d.buf[:1])
+}
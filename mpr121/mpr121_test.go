@@ -0,0 +1,106 @@
+package mpr121
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"tinygo.org/x/drivers/tester"
+)
+
+func newFake(c *qt.C) (*tester.I2CDevice, Device) {
+	bus := tester.NewI2CBus(c)
+	fake := tester.NewI2CDevice(c, Address)
+	fake.SetupRegisters(make([]uint8, 0x81))
+	fake.SetupRegister(CONFIG2, config2Default)
+	bus.AddDevice(fake)
+	return fake, New(bus)
+}
+
+func TestConfigure(t *testing.T) {
+	c := qt.New(t)
+	fake, dev := newFake(c)
+	c.Assert(dev.Configure(Config{Electrodes: 4, Proximity: Proximity0to3}), qt.IsNil)
+	fake.AssertRegisters(c, TOUCHTH_0, []uint8{12, 6, 12, 6})
+	fake.AssertRegisters(c, UPLIMIT, []uint8{201, 130, 180})
+	fake.AssertRegisters(c, ECR, []uint8{0xA4})
+
+	c.Assert(dev.SetThresholds(2, 20, 10), qt.IsNil)
+	fake.AssertRegisters(c, TOUCHTH_0+4, []uint8{20, 10})
+	fake.AssertRegisters(c, ECR, []uint8{0xA4})
+	c.Assert(dev.SetThresholds(13, 20, 10), qt.Equals, errElectrode)
+}
+
+func TestNotFound(t *testing.T) {
+	c := qt.New(t)
+	fake, dev := newFake(c)
+	fake.SetupRegister(CONFIG2, 0)
+	c.Assert(dev.Configure(Config{}), qt.Equals, errNotFound)
+}
+
+func TestStatus(t *testing.T) {
+	c := qt.New(t)
+	fake, dev := newFake(c)
+	fake.SetupRegister(OORSTATUS_H, 1<<ACFF)
+	c.Assert(dev.Configure(Config{}), qt.Equals, errAutoConfig)
+
+	fake, dev = newFake(c)
+	fake.SetupRegister(OORSTATUS_H, 1<<ARFF)
+	c.Assert(dev.Configure(Config{}), qt.Equals, errAutoConfig)
+
+	fake, dev = newFake(c)
+	fake.SetupRegister(TOUCHSTATUS_H, 1<<OVCF)
+	c.Assert(dev.Configure(Config{}), qt.Equals, errOverCurrent)
+
+	// out of range electrodes alone are not a failure
+	fake, dev = newFake(c)
+	fake.SetupRegister(OORSTATUS_H, 0x01)
+	c.Assert(dev.Configure(Config{}), qt.IsNil)
+}
+
+func TestData(t *testing.T) {
+	c := qt.New(t)
+	fake, dev := newFake(c)
+	fake.SetupRegister(FILTDATA_0L+2, 0x34)
+	fake.SetupRegister(FILTDATA_0L+3, 0x02)
+	fake.SetupRegister(BASELINE_0+1, 0x90)
+
+	v, err := dev.FilteredData(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(v, qt.Equals, uint16(0x234))
+	v, err = dev.Baseline(1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(v, qt.Equals, uint16(0x240))
+}
+
+func TestUpdate(t *testing.T) {
+	c := qt.New(t)
+	fake, dev := newFake(c)
+
+	fake.SetupRegister(TOUCHSTATUS_L, 0x05)
+	touched, released, err := dev.Update()
+	c.Assert(err, qt.IsNil)
+	c.Assert(touched, qt.Equals, uint16(0x05))
+	c.Assert(released, qt.Equals, uint16(0))
+
+	fake.SetupRegister(TOUCHSTATUS_L, 0x06)
+	fake.SetupRegister(TOUCHSTATUS_H, 0x10)
+	touched, released, err = dev.Update()
+	c.Assert(err, qt.IsNil)
+	c.Assert(touched, qt.Equals, uint16(0x1002))
+	c.Assert(released, qt.Equals, uint16(0x01))
+
+	ok, err := dev.IsTouched(12)
+	c.Assert(err, qt.IsNil)
+	c.Assert(ok, qt.IsTrue)
+
+	// with a released IRQ pin, only the first call reads the device
+	dev.useIRQ(func() bool { return true })
+	fake.SetupRegister(TOUCHSTATUS_H, 0)
+	_, released, err = dev.Update()
+	c.Assert(err, qt.IsNil)
+	c.Assert(released, qt.Equals, uint16(0x1000))
+	fake.SetupRegister(TOUCHSTATUS_L, 0)
+	_, released, err = dev.Update()
+	c.Assert(err, qt.IsNil)
+	c.Assert(released, qt.Equals, uint16(0))
+}
@@ -0,0 +1,52 @@
+package mpr121
+
+// The I2C address which this device listens to, with the ADDR pin connected
+// to ground. It is 0x5B, 0x5C or 0x5D with ADDR connected to VDD, SDA or
+// SCL.
+const Address = 0x5A
+
+// Registers
+const (
+	TOUCHSTATUS_L = 0x00
+	TOUCHSTATUS_H = 0x01
+	OORSTATUS_L   = 0x02
+	OORSTATUS_H   = 0x03
+	FILTDATA_0L   = 0x04
+	BASELINE_0    = 0x1E
+	MHDR          = 0x2B
+	NHDR          = 0x2C
+	NCLR          = 0x2D
+	FDLR          = 0x2E
+	MHDF          = 0x2F
+	NHDF          = 0x30
+	NCLF          = 0x31
+	FDLF          = 0x32
+	NHDT          = 0x33
+	NCLT          = 0x34
+	FDLT          = 0x35
+	TOUCHTH_0     = 0x41
+	RELEASETH_0   = 0x42
+	DEBOUNCE      = 0x5B
+	CONFIG1       = 0x5C
+	CONFIG2       = 0x5D
+	ECR           = 0x5E
+	CHARGECURR_0  = 0x5F
+	CHARGETIME_1  = 0x6C
+	AUTOCONFIG0   = 0x7B
+	AUTOCONFIG1   = 0x7C
+	UPLIMIT       = 0x7D
+	LOWLIMIT      = 0x7E
+	TARGETLIMIT   = 0x7F
+	SOFTRESET     = 0x80
+
+	// bits of TOUCHSTATUS_H and OORSTATUS_H
+	OVCF = 7 // over current on the REXT pin
+	ACFF = 7 // auto-configuration failed
+	ARFF = 6 // auto-reconfiguration failed
+
+	// value written to SOFTRESET
+	resetCommand = 0x63
+
+	// value of CONFIG2 after a reset
+	config2Default = 0x24
+)
@@ -0,0 +1,212 @@
+// Package nunchuk provides a driver for the Wii Nunchuk and Classic
+// Controller, and most of their clones, which are accessories with an I2C
+// interface.
+//
+// The controllers are initialized in the unencrypted mode, so the data does
+// not need to be decoded.
+//
+// Protocol description:
+// https://wiibrew.org/wiki/Wiimote/Extension_Controllers
+package nunchuk // import "tinygo.org/x/drivers/nunchuk"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+// Address of all extension controllers.
+const Address = 0x52
+
+var errUnknown = errors.New("nunchuk: unknown controller")
+
+// Kind is the type of a controller.
+type Kind uint8
+
+// Controller types.
+const (
+	Unknown Kind = iota
+	Nunchuk
+	ClassicController
+)
+
+// NunchukState is the state of the Nunchuk.
+type NunchukState struct {
+	// JoystickX and JoystickY are around 128 in the center and about 30 and
+	// 225 at the ends.
+	JoystickX uint8
+	JoystickY uint8
+
+	// AccelX, AccelY and AccelZ are the 10-bit accelerometer readings with
+	// the midpoint subtracted, so 0 means 0g. 1g is about 200.
+	AccelX int16
+	AccelY int16
+	AccelZ int16
+
+	C bool
+	Z bool
+}
+
+// Buttons of the Classic Controller.
+type Buttons uint16
+
+// Classic Controller buttons.
+const (
+	ButtonUp Buttons = 1 << iota
+	ButtonLeft
+	ButtonZR
+	ButtonX
+	ButtonA
+	ButtonY
+	ButtonB
+	ButtonZL
+	_
+	ButtonR
+	ButtonPlus
+	ButtonHome
+	ButtonMinus
+	ButtonL
+	ButtonDown
+	ButtonRight
+)
+
+// ClassicState is the state of the Classic Controller.
+type ClassicState struct {
+	// LeftX and LeftY are 6-bit values, from 0 to 63, and RightX and RightY
+	// 5-bit values, from 0 to 31. They are about half way in the center.
+	LeftX  uint8
+	LeftY  uint8
+	RightX uint8
+	RightY uint8
+
+	// LeftTrigger and RightTrigger are the analog trigger positions, from 0
+	// to 31. ButtonL and ButtonR are set when they are fully pressed.
+	LeftTrigger  uint8
+	RightTrigger uint8
+
+	Buttons Buttons
+}
+
+// Pressed returns whether all the given buttons are pressed.
+func (s ClassicState) Pressed(b Buttons) bool {
+	return s.Buttons&b == b
+}
+
+// Device wraps an I2C connection to an extension controller.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+	kind    Kind
+	data    [6]uint8
+	buf     [2]uint8
+}
+
+// New creates a new controller connection. The I2C bus must already be
+// configured, at 100kHz for most clones.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Configure initializes the controller in the unencrypted mode and reads
+// its type. It returns an error for controllers that are not supported.
+func (d *Device) Configure() error {
+	if err := d.write(0xF0, 0x55); err != nil {
+		return err
+	}
+	if err := d.write(0xFB, 0x00); err != nil {
+		return err
+	}
+	if err := d.read(0xFA, d.data[:]); err != nil {
+		return err
+	}
+	d.kind = identify(d.data)
+	if d.kind == Unknown {
+		return errUnknown
+	}
+	// the first report after the initialization is often stale
+	return d.Update()
+}
+
+// identify returns the controller type from its identifier.
+func identify(id [6]uint8) Kind {
+	if id[2] != 0xA4 || id[3] != 0x20 {
+		return Unknown
+	}
+	switch {
+	case id[4] == 0x00 && id[5] == 0x00:
+		return Nunchuk
+	case id[4] == 0x01 && id[5] == 0x01:
+		return ClassicController
+	}
+	return Unknown
+}
+
+// Kind returns the type of the controller found by Configure.
+func (d *Device) Kind() Kind {
+	return d.kind
+}
+
+// Update reads the current state of the controller.
+func (d *Device) Update() error {
+	return d.read(0x00, d.data[:])
+}
+
+// Nunchuk returns the state of a Nunchuk at the last Update.
+func (d *Device) Nunchuk() NunchukState {
+	return decodeNunchuk(d.data)
+}
+
+// Classic returns the state of a Classic Controller at the last Update.
+func (d *Device) Classic() ClassicState {
+	return decodeClassic(d.data)
+}
+
+func decodeNunchuk(b [6]uint8) NunchukState {
+	return NunchukState{
+		JoystickX: b[0],
+		JoystickY: b[1],
+		AccelX:    int16(b[2])<<2 | int16(b[5]>>2&0x03) - 512,
+		AccelY:    int16(b[3])<<2 | int16(b[5]>>4&0x03) - 512,
+		AccelZ:    int16(b[4])<<2 | int16(b[5]>>6&0x03) - 512,
+		// buttons are active low
+		Z: b[5]&0x01 == 0,
+		C: b[5]&0x02 == 0,
+	}
+}
+
+func decodeClassic(b [6]uint8) ClassicState {
+	return ClassicState{
+		LeftX:        b[0] & 0x3F,
+		LeftY:        b[1] & 0x3F,
+		RightX:       b[0]>>6<<3 | b[1]>>6<<1 | b[2]>>7,
+		RightY:       b[2] & 0x1F,
+		LeftTrigger:  b[2]>>5&0x03<<3 | b[3]>>5,
+		RightTrigger: b[3] & 0x1F,
+		// buttons are active low, the unused bit 0 of byte 4 reads high
+		Buttons: ^(Buttons(b[4])<<8 | Buttons(b[5])) &^ (1 << 8),
+	}
+}
+
+// read reads from a register. The controllers need a short pause between
+// setting the register and reading it, so it is done in two transactions.
+func (d *Device) read(reg uint8, data []uint8) error {
+	d.buf[0] = reg
+	if err := d.bus.Tx(d.Address, d.buf[:1], nil); err != nil {
+		return err
+	}
+	time.Sleep(200 * time.Microsecond)
+	return d.bus.Tx(d.Address, nil, data)
+}
+
+func (d *Device) write(reg, value uint8) error {
+	d.buf[0], d.buf[1] = reg, value
+	err := d.bus.Tx(d.Address, d.buf[:2], nil)
+	time.Sleep(time.Millisecond)
+	return err
+}
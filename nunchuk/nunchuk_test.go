@@ -0,0 +1,52 @@
+package nunchuk
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestIdentify(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(identify([6]uint8{0x00, 0x00, 0xA4, 0x20, 0x00, 0x00}), qt.Equals, Nunchuk)
+	c.Assert(identify([6]uint8{0x00, 0x00, 0xA4, 0x20, 0x01, 0x01}), qt.Equals, ClassicController)
+	c.Assert(identify([6]uint8{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}), qt.Equals, Unknown)
+}
+
+func TestDecodeNunchuk(t *testing.T) {
+	c := qt.New(t)
+	// joystick centered, lying flat and the C button pressed
+	s := decodeNunchuk([6]uint8{0x80, 0x7F, 0x80, 0x7F, 0xB2, 0b10_01_11_01})
+	c.Assert(s, qt.Equals, NunchukState{
+		JoystickX: 0x80,
+		JoystickY: 0x7F,
+		AccelX:    3,
+		AccelY:    -3,
+		AccelZ:    202,
+		C:         true,
+	})
+}
+
+func TestDecodeClassic(t *testing.T) {
+	c := qt.New(t)
+	// RX = 0b10110, RY = 17, LT = 0b01101, RT = 30, A, Home and Up pressed
+	s := decodeClassic([6]uint8{
+		0b10_100000,
+		0b11_011111,
+		0b0_01_10001,
+		0b101_11110,
+		0xFF &^ (1 << 3),
+		0xFF &^ (1<<4 | 1<<0),
+	})
+	c.Assert(s, qt.Equals, ClassicState{
+		LeftX:        32,
+		LeftY:        31,
+		RightX:       0b10110,
+		RightY:       17,
+		LeftTrigger:  0b01101,
+		RightTrigger: 30,
+		Buttons:      ButtonA | ButtonHome | ButtonUp,
+	})
+	c.Assert(s.Pressed(ButtonA|ButtonUp), qt.IsTrue)
+	c.Assert(s.Pressed(ButtonA|ButtonB), qt.IsFalse)
+}
@@ -0,0 +1,76 @@
+package onewire
+
+import (
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+// NewGPIO returns a bus bit-banged on the pin, which must have a pull-up
+// resistor, usually 4.7kΩ. The pin only drives the bus low, and is an input
+// otherwise. Interrupts are disabled during each time slot, for at most
+// 70µs, and during the 480µs of a reset pulse.
+func NewGPIO(p drivers.IOPin) *Bus {
+	p.SetInput()
+	return &Bus{line: &gpioLine{p: p}}
+}
+
+// gpioLine times the slots with busy waits, with the timings of the
+// standard speed of the application note 126.
+type gpioLine struct {
+	p drivers.IOPin
+}
+
+func (l *gpioLine) reset() (bool, error) {
+	state := disableInterrupts()
+	l.low()
+	delay(480 * time.Microsecond)
+	l.p.SetInput()
+	delay(70 * time.Microsecond)
+	present := !l.p.Get()
+	restoreInterrupts(state)
+	delay(410 * time.Microsecond)
+	return present, nil
+}
+
+func (l *gpioLine) bit(b bool) (bool, error) {
+	state := disableInterrupts()
+	defer restoreInterrupts(state)
+	l.low()
+	if !b {
+		delay(60 * time.Microsecond)
+		l.p.SetInput()
+		delay(10 * time.Microsecond)
+		return false, nil
+	}
+	delay(6 * time.Microsecond)
+	l.p.SetInput()
+	delay(9 * time.Microsecond)
+	level := l.p.Get()
+	delay(55 * time.Microsecond)
+	return level, nil
+}
+
+// low drives the bus low.
+func (l *gpioLine) pullUp(on bool) error {
+	if on {
+		l.p.High()
+		l.p.SetOutput()
+	} else {
+		l.p.SetInput()
+	}
+	return nil
+}
+
+func (l *gpioLine) low() {
+	l.p.Low()
+	l.p.SetOutput()
+}
+
+// delay waits with a busy loop, as time.Sleep is not precise enough for the
+// slots.
+func delay(d time.Duration) {
+	start := time.Now()
+	for time.Since(start) < d {
+	}
+}
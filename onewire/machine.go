@@ -0,0 +1,119 @@
+// +build tinygo
+
+package onewire
+
+import (
+	"errors"
+	"machine"
+	"runtime/interrupt"
+	"time"
+)
+
+var errNoEcho = errors.New("onewire: no echo on the UART, TX and RX must be connected to the bus")
+
+func disableInterrupts() uintptr {
+	return uintptr(interrupt.Disable())
+}
+
+func restoreInterrupts(state uintptr) {
+	interrupt.Restore(interrupt.State(state))
+}
+
+// New returns a bus bit-banged on the pin, like NewGPIO.
+func New(p machine.Pin) *Bus {
+	return NewGPIO(machinePin(p))
+}
+
+// machinePin implements drivers.IOPin.
+type machinePin machine.Pin
+
+func (p machinePin) Get() bool {
+	return machine.Pin(p).Get()
+}
+
+func (p machinePin) Set(high bool) {
+	machine.Pin(p).Set(high)
+}
+
+func (p machinePin) High() {
+	machine.Pin(p).High()
+}
+
+func (p machinePin) Low() {
+	machine.Pin(p).Low()
+}
+
+func (p machinePin) SetOutput() {
+	machine.Pin(p).Configure(machine.PinConfig{Mode: machine.PinOutput})
+}
+
+func (p machinePin) SetInput() {
+	machine.Pin(p).Configure(machine.PinConfig{Mode: machine.PinInput})
+}
+
+// NewUART returns a bus driven by the UART, whose TX pin must drive the bus
+// as an open drain output, for example through a diode or a transistor, and
+// whose RX pin is connected to the bus with a pull-up resistor. Each slot is
+// a character sent at 115200 baud, whose echo tells the level of the bus,
+// and a reset pulse is a character sent at 9600 baud.
+func NewUART(uart *machine.UART, tx, rx machine.Pin) *Bus {
+	l := &uartLine{uart: uart, tx: tx, rx: rx}
+	l.configure(115200)
+	return &Bus{line: l}
+}
+
+type uartLine struct {
+	uart   *machine.UART
+	tx, rx machine.Pin
+	baud   uint32
+}
+
+func (l *uartLine) configure(baud uint32) {
+	if l.baud != baud {
+		l.uart.Configure(machine.UARTConfig{BaudRate: baud, TX: l.tx, RX: l.rx})
+		l.baud = baud
+	}
+}
+
+// echo sends a character and returns the one read at the same time.
+func (l *uartLine) echo(c byte) (byte, error) {
+	for l.uart.Buffered() > 0 {
+		l.uart.ReadByte()
+	}
+	if err := l.uart.WriteByte(c); err != nil {
+		return 0, err
+	}
+	// a character lasts about 1ms at 9600 baud
+	deadline := time.Now().Add(5 * time.Millisecond)
+	for l.uart.Buffered() == 0 {
+		if time.Now().After(deadline) {
+			return 0, errNoEcho
+		}
+	}
+	return l.uart.ReadByte()
+}
+
+func (l *uartLine) reset() (bool, error) {
+	l.configure(9600)
+	// the start bit and the four low data bits are the 520µs reset pulse,
+	// and a presence pulse pulls some of the high data bits low
+	c, err := l.echo(0xF0)
+	l.configure(115200)
+	return c != 0xF0, err
+}
+
+func (l *uartLine) bit(b bool) (bool, error) {
+	// the start bit starts the slot, and for a 1 the data bits release
+	// the bus early, where a device may still hold it low to send a 0
+	out := byte(0x00)
+	if b {
+		out = 0xFF
+	}
+	c, err := l.echo(out)
+	return c == 0xFF, err
+}
+
+func (l *uartLine) pullUp(on bool) error {
+	// TX drives the bus high between the characters
+	return nil
+}
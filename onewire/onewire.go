@@ -0,0 +1,286 @@
+// Package onewire implements the 1-Wire bus of Maxim, used by the DS18B20
+// temperature sensor and the iButton keys: the reset and presence pulses, the
+// read and write time slots, the ROM commands including the search of the
+// devices on the bus, and the CRC of the ROM codes and the data.
+//
+// The bus can be driven by bit-banging a pin, or with a UART whose TX and RX
+// are both connected to the bus, which times the slots in hardware.
+//
+// Application note:
+// https://www.maximintegrated.com/en/design/technical-documents/app-notes/1/126.html
+package onewire // import "tinygo.org/x/drivers/onewire"
+
+import "errors"
+
+var (
+	ErrNoPresence = errors.New("onewire: no device answered the reset")
+	ErrCRC        = errors.New("onewire: CRC mismatch")
+	ErrSearch     = errors.New("onewire: no device answered the search")
+)
+
+// ROM commands.
+const (
+	cmdSearchROM = 0xF0
+	cmdReadROM   = 0x33
+	cmdMatchROM  = 0x55
+	cmdSkipROM   = 0xCC
+	cmdAlarm     = 0xEC
+)
+
+// ROM is the unique 64-bit code of a device: the family code, the serial
+// number and the CRC, in the order they are sent on the bus.
+type ROM [8]byte
+
+// Family returns the family code, such as 0x28 for the DS18B20.
+func (r ROM) Family() byte {
+	return r[0]
+}
+
+// Valid returns whether the CRC of the code matches.
+func (r ROM) Valid() bool {
+	return CRC8(r[:7]) == r[7]
+}
+
+// line sends and receives the slots on the bus.
+type line interface {
+	// reset sends a reset pulse and returns whether a device answered with
+	// a presence pulse.
+	reset() (bool, error)
+
+	// bit sends a write slot, or a read slot for a 1, and returns the level
+	// of the bus during the slot.
+	bit(b bool) (bool, error)
+
+	// pullUp drives the bus high, or releases it.
+	pullUp(on bool) error
+}
+
+// Bus is a 1-Wire bus.
+type Bus struct {
+	line line
+}
+
+// Reset sends a reset pulse, which starts every transaction, and returns
+// ErrNoPresence if no device answered it.
+func (b *Bus) Reset() error {
+	present, err := b.line.reset()
+	if err != nil {
+		return err
+	}
+	if !present {
+		return ErrNoPresence
+	}
+	return nil
+}
+
+// StrongPullUp drives the bus high to power the devices in parasite power
+// mode, such as a DS18B20 converting a temperature, which draw more current
+// than the pull-up resistor gives. It must follow the last slot of the
+// command within 10µs, and the bus must not be used until
+// StrongPullUp(false).
+func (b *Bus) StrongPullUp(on bool) error {
+	return b.line.pullUp(on)
+}
+
+// WriteBit sends a bit.
+func (b *Bus) WriteBit(bit bool) error {
+	_, err := b.line.bit(bit)
+	return err
+}
+
+// ReadBit reads a bit.
+func (b *Bus) ReadBit() (bool, error) {
+	return b.line.bit(true)
+}
+
+// WriteByte sends a byte, least significant bit first.
+func (b *Bus) WriteByte(c byte) error {
+	for i := uint(0); i < 8; i++ {
+		if _, err := b.line.bit(c&(1<<i) != 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadByte reads a byte, least significant bit first.
+func (b *Bus) ReadByte() (byte, error) {
+	var c byte
+	for i := uint(0); i < 8; i++ {
+		bit, err := b.line.bit(true)
+		if err != nil {
+			return c, err
+		}
+		if bit {
+			c |= 1 << i
+		}
+	}
+	return c, nil
+}
+
+// Write sends the bytes.
+func (b *Bus) Write(data []byte) (n int, err error) {
+	for ; n < len(data); n++ {
+		if err := b.WriteByte(data[n]); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Read reads len(data) bytes.
+func (b *Bus) Read(data []byte) (n int, err error) {
+	for ; n < len(data); n++ {
+		if data[n], err = b.ReadByte(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Select resets the bus and addresses the device with the ROM code, which
+// is the only one to handle the next command.
+func (b *Bus) Select(rom ROM) error {
+	if err := b.Reset(); err != nil {
+		return err
+	}
+	if err := b.WriteByte(cmdMatchROM); err != nil {
+		return err
+	}
+	_, err := b.Write(rom[:])
+	return err
+}
+
+// Skip resets the bus and addresses all the devices, for a command that all
+// of them handle at once, or when there is a single device.
+func (b *Bus) Skip() error {
+	if err := b.Reset(); err != nil {
+		return err
+	}
+	return b.WriteByte(cmdSkipROM)
+}
+
+// ReadROM returns the ROM code of the device, which must be alone on the
+// bus.
+func (b *Bus) ReadROM() (ROM, error) {
+	var rom ROM
+	if err := b.Reset(); err != nil {
+		return rom, err
+	}
+	if err := b.WriteByte(cmdReadROM); err != nil {
+		return rom, err
+	}
+	if _, err := b.Read(rom[:]); err != nil {
+		return rom, err
+	}
+	if !rom.Valid() {
+		return rom, ErrCRC
+	}
+	return rom, nil
+}
+
+// Search finds the devices on the bus and stores their ROM codes in roms. It
+// returns the number of devices found, at most len(roms).
+func (b *Bus) Search(roms []ROM) (int, error) {
+	return b.search(cmdSearchROM, roms)
+}
+
+// SearchAlarm is like Search, but only finds the devices in an alarm state,
+// such as a DS18B20 whose temperature is out of its limits.
+func (b *Bus) SearchAlarm(roms []ROM) (int, error) {
+	return b.search(cmdAlarm, roms)
+}
+
+// search runs the binary tree search of the application note 187. Each pass
+// finds one ROM code: at every bit where codes differ, the devices with the
+// chosen bit stay in the search, and the last such bit where 0 was chosen is
+// followed with 1 on the next pass.
+func (b *Bus) search(cmd byte, roms []ROM) (int, error) {
+	var rom ROM
+	last := -1
+	n := 0
+	for n < len(roms) {
+		present, err := b.line.reset()
+		if err != nil {
+			return n, err
+		}
+		if !present {
+			if cmd == cmdAlarm {
+				return n, nil
+			}
+			return n, ErrNoPresence
+		}
+		if err := b.WriteByte(cmd); err != nil {
+			return n, err
+		}
+		discrepancy := -1
+		for i := 0; i < 64; i++ {
+			bit, err := b.line.bit(true)
+			if err != nil {
+				return n, err
+			}
+			complement, err := b.line.bit(true)
+			if err != nil {
+				return n, err
+			}
+			var direction bool
+			switch {
+			case bit && complement:
+				if cmd == cmdAlarm && i == 0 {
+					// no device in alarm
+					return n, nil
+				}
+				return n, ErrSearch
+			case bit != complement:
+				// all the remaining devices have the same bit
+				direction = bit
+			case i == last:
+				direction = true
+			case i > last:
+				direction = false
+				discrepancy = i
+			default:
+				direction = rom[i/8]&(1<<uint(i%8)) != 0
+				if !direction {
+					discrepancy = i
+				}
+			}
+			if direction {
+				rom[i/8] |= 1 << uint(i%8)
+			} else {
+				rom[i/8] &^= 1 << uint(i%8)
+			}
+			if _, err := b.line.bit(direction); err != nil {
+				return n, err
+			}
+		}
+		if !rom.Valid() {
+			return n, ErrCRC
+		}
+		roms[n] = rom
+		n++
+		if discrepancy < 0 {
+			break
+		}
+		last = discrepancy
+	}
+	return n, nil
+}
+
+// CRC8 computes the CRC of the ROM codes and of the data of the devices,
+// with the polynomial x^8 + x^5 + x^4 + 1. The CRC of data followed by its
+// CRC is 0.
+func CRC8(data []byte) byte {
+	crc := byte(0)
+	for _, c := range data {
+		for i := 0; i < 8; i++ {
+			mix := (crc ^ c) & 1
+			crc >>= 1
+			if mix != 0 {
+				crc ^= 0x8C
+			}
+			c >>= 1
+		}
+	}
+	return crc
+}
@@ -0,0 +1,196 @@
+package onewire
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// fakeDevice follows the ROM commands at the level of the time slots.
+type fakeDevice struct {
+	rom   ROM
+	alarm bool
+
+	active bool
+	cmd    byte
+	bits   int // bits of the command received
+	pos    int // bit of the ROM code
+	phase  int // step of a search: bit, complement, direction
+}
+
+func (d *fakeDevice) romBit() bool {
+	return d.pos < 64 && d.rom[d.pos/8]&(1<<uint(d.pos%8)) != 0
+}
+
+// output returns the level the device leaves the bus at in the next slot.
+func (d *fakeDevice) output() bool {
+	if !d.active || d.bits < 8 || d.pos >= 64 {
+		return true
+	}
+	switch d.cmd {
+	case cmdReadROM:
+		return d.romBit()
+	case cmdSearchROM, cmdAlarm:
+		switch d.phase {
+		case 0:
+			return d.romBit()
+		case 1:
+			return !d.romBit()
+		}
+	}
+	return true
+}
+
+// observe handles the level of the bus during the slot.
+func (d *fakeDevice) observe(level bool) {
+	switch {
+	case !d.active:
+	case d.bits < 8:
+		if level {
+			d.cmd |= 1 << uint(d.bits)
+		}
+		d.bits++
+		if d.bits == 8 && (d.cmd == cmdSkipROM || d.cmd == cmdAlarm && !d.alarm) {
+			d.active = false
+		}
+	case d.pos >= 64:
+	case d.cmd == cmdReadROM:
+		d.pos++
+	case d.cmd == cmdMatchROM:
+		d.active = level == d.romBit()
+		d.pos++
+	case d.cmd == cmdSearchROM || d.cmd == cmdAlarm:
+		if d.phase == 2 {
+			d.active = level == d.romBit()
+			d.pos++
+		}
+		d.phase = (d.phase + 1) % 3
+	}
+}
+
+type fakeLine struct {
+	devices []*fakeDevice
+	slots   int
+	pulled  bool
+}
+
+func (l *fakeLine) reset() (bool, error) {
+	for _, d := range l.devices {
+		*d = fakeDevice{rom: d.rom, alarm: d.alarm, active: true}
+	}
+	return len(l.devices) > 0, nil
+}
+
+func (l *fakeLine) bit(b bool) (bool, error) {
+	l.slots++
+	level := b
+	for _, d := range l.devices {
+		if !d.output() {
+			level = false
+		}
+	}
+	for _, d := range l.devices {
+		d.observe(level)
+	}
+	return level, nil
+}
+
+func (l *fakeLine) pullUp(on bool) error {
+	l.pulled = on
+	return nil
+}
+
+// rom returns a valid ROM code of the family with the serial number.
+func rom(family byte, serial ...byte) ROM {
+	r := ROM{family}
+	copy(r[1:7], serial)
+	r[7] = CRC8(r[:7])
+	return r
+}
+
+func TestCRC8(t *testing.T) {
+	c := qt.New(t)
+	// example of the application note 27
+	code := ROM{0x02, 0x1C, 0xB8, 0x01, 0x00, 0x00, 0x00, 0xA2}
+	c.Assert(CRC8(code[:7]), qt.Equals, byte(0xA2))
+	c.Assert(CRC8(code[:]), qt.Equals, byte(0))
+	c.Assert(code.Valid(), qt.IsTrue)
+	code[3] ^= 0x10
+	c.Assert(code.Valid(), qt.IsFalse)
+}
+
+func TestSearch(t *testing.T) {
+	c := qt.New(t)
+	devices := []*fakeDevice{
+		{rom: rom(0x28, 0x01, 0x02, 0x03)},
+		{rom: rom(0x28, 0x01, 0x02, 0x04), alarm: true},
+		{rom: rom(0x01, 0xAA)},
+		{rom: rom(0x28, 0x81, 0x02, 0x03)},
+	}
+	bus := &Bus{line: &fakeLine{devices: devices}}
+	roms := make([]ROM, 8)
+	n, err := bus.Search(roms)
+	c.Assert(err, qt.IsNil)
+	c.Assert(n, qt.Equals, len(devices))
+	found := map[ROM]bool{}
+	for _, r := range roms[:n] {
+		found[r] = true
+	}
+	for _, d := range devices {
+		c.Assert(found[d.rom], qt.IsTrue, qt.Commentf("%x", d.rom))
+	}
+
+	// the search stops when the slice is full
+	n, err = bus.Search(roms[:2])
+	c.Assert(err, qt.IsNil)
+	c.Assert(n, qt.Equals, 2)
+
+	n, err = bus.SearchAlarm(roms)
+	c.Assert(err, qt.IsNil)
+	c.Assert(roms[:n], qt.DeepEquals, []ROM{devices[1].rom})
+}
+
+func TestSearchEmpty(t *testing.T) {
+	c := qt.New(t)
+	bus := &Bus{line: &fakeLine{}}
+	_, err := bus.Search(make([]ROM, 1))
+	c.Assert(err, qt.Equals, ErrNoPresence)
+	c.Assert(bus.Reset(), qt.Equals, ErrNoPresence)
+}
+
+func TestROMCommands(t *testing.T) {
+	c := qt.New(t)
+	d := &fakeDevice{rom: rom(0x28, 0x12, 0x34)}
+	other := &fakeDevice{rom: rom(0x28, 0x56)}
+	bus := &Bus{line: &fakeLine{devices: []*fakeDevice{d}}}
+	code, err := bus.ReadROM()
+	c.Assert(err, qt.IsNil)
+	c.Assert(code, qt.Equals, d.rom)
+	c.Assert(code.Family(), qt.Equals, byte(0x28))
+
+	bus.line.(*fakeLine).devices = append(bus.line.(*fakeLine).devices, other)
+	c.Assert(bus.Select(d.rom), qt.IsNil)
+	c.Assert(d.active, qt.IsTrue)
+	c.Assert(other.active, qt.IsFalse)
+
+	c.Assert(bus.Skip(), qt.IsNil)
+	c.Assert(d.cmd, qt.Equals, byte(cmdSkipROM))
+
+	// a read of a byte sends eight read slots
+	line := bus.line.(*fakeLine)
+	line.slots = 0
+	b, err := bus.ReadByte()
+	c.Assert(err, qt.IsNil)
+	c.Assert(b, qt.Equals, byte(0xFF))
+	c.Assert(line.slots, qt.Equals, 8)
+}
+
+func TestStrongPullUp(t *testing.T) {
+	c := qt.New(t)
+	line := &fakeLine{}
+	bus := &Bus{line: line}
+	c.Assert(bus.StrongPullUp(true), qt.IsNil)
+	c.Assert(line.pulled, qt.IsTrue)
+	c.Assert(bus.StrongPullUp(false), qt.IsNil)
+	c.Assert(line.pulled, qt.IsFalse)
+}
@@ -0,0 +1,18 @@
+// +build tinygo
+
+package pca95xx
+
+import "machine"
+
+// UseInterrupt makes Changed read the PCA9555 only after its INT pin
+// signaled a change. The pin is open drain and active low; its pull-up is
+// enabled. The PCA9536 has no interrupt output.
+func (d *Device) UseInterrupt(pin machine.Pin) error {
+	if err := d.useInterrupt(pin.Get); err != nil {
+		return err
+	}
+	pin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	return pin.SetInterrupt(machine.PinFalling, func(machine.Pin) {
+		d.pending = true
+	})
+}
@@ -0,0 +1,181 @@
+// Package pca95xx provides a driver for the PCA9536 4-bit and PCA9555
+// 16-bit I/O expanders. The PCA9535 and TCA9555 are compatible with the
+// PCA9555.
+//
+// The pins of the expander implement drivers.Pin, so they can be used in
+// place of GPIO pins by other drivers.
+//
+// Datasheets:
+// https://www.nxp.com/docs/en/data-sheet/PCA9536.pdf
+// https://www.nxp.com/docs/en/data-sheet/PCA9555.pdf
+package pca95xx // import "tinygo.org/x/drivers/pca95xx"
+
+import (
+	"errors"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	errPin          = errors.New("pca95xx: invalid pin")
+	errNoInterrupts = errors.New("pca95xx: no interrupt output")
+)
+
+// Model selects the chip.
+type Model uint8
+
+// Supported models.
+const (
+	PCA9555 Model = iota
+	PCA9536
+)
+
+// Config contains the settings used by Configure.
+type Config struct {
+	Model Model
+}
+
+// Device wraps an I2C connection to a PCA9536 or PCA9555 device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint8
+	model   Model
+	pins    int
+	config  uint16 // register caches
+	output  uint16
+	invert  uint16
+	last    uint16      // inputs at the last call to Changed
+	irq     func() bool // level of the interrupt pin, nil without it
+	pending bool
+	buf     [2]uint8
+}
+
+// New creates a new PCA95xx connection. The I2C bus must already be
+// configured.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.I2C) *Device {
+	return &Device{
+		bus:     bus,
+		Address: Address,
+		pins:    16,
+	}
+}
+
+// Configure sets all pins as inputs without inverted polarity, as after a
+// power-on reset. For the PCA9536 the address is set to its fixed address.
+func (d *Device) Configure(cfg Config) error {
+	d.model = cfg.Model
+	d.pins = 16
+	if d.model == PCA9536 {
+		d.pins = 4
+		d.Address = AddressPCA9536
+	}
+	d.config, d.output, d.invert = 0xFFFF, 0xFFFF, 0
+	if err := d.write(OUTPUT, d.output); err != nil {
+		return err
+	}
+	if err := d.write(POLARITY, d.invert); err != nil {
+		return err
+	}
+	if err := d.write(CONFIG, d.config); err != nil {
+		return err
+	}
+	v, err := d.ReadPins()
+	d.last = v
+	return err
+}
+
+// SetInputs makes the pins in mask inputs if inputs is set, or outputs.
+func (d *Device) SetInputs(mask uint16, inputs bool) error {
+	return d.update(CONFIG, &d.config, mask, inputs)
+}
+
+// SetInverted inverts the level read from the input pins in mask.
+func (d *Device) SetInverted(mask uint16, inverted bool) error {
+	return d.update(POLARITY, &d.invert, mask, inverted)
+}
+
+// ReadPins returns the levels of all pins, with port 1 of the PCA9555 in
+// the upper byte.
+func (d *Device) ReadPins() (uint16, error) {
+	return d.read(INPUT)
+}
+
+// WritePins sets the levels of all output pins.
+func (d *Device) WritePins(levels uint16) error {
+	d.output = levels
+	return d.write(OUTPUT, levels)
+}
+
+// SetPins sets the levels of the output pins in mask, keeping the others.
+func (d *Device) SetPins(mask uint16, high bool) error {
+	return d.update(OUTPUT, &d.output, mask, high)
+}
+
+// useInterrupt makes Changed read the device only after a change, signaled
+// by a call to the interrupt handler or by the level of the INT pin.
+func (d *Device) useInterrupt(irq func() bool) error {
+	if d.model == PCA9536 {
+		return errNoInterrupts
+	}
+	d.irq, d.pending = irq, true
+	return nil
+}
+
+// Changed returns the pins that changed since the last call. With an INT
+// pin the device is only read after a change, so Changed can be called
+// often.
+func (d *Device) Changed() (uint16, error) {
+	if d.irq != nil {
+		if !d.pending && d.irq() {
+			return 0, nil
+		}
+		d.pending = false
+	}
+	// reading the inputs releases the INT pin
+	v, err := d.ReadPins()
+	if err != nil {
+		return 0, err
+	}
+	changed := v ^ d.last
+	d.last = v
+	return changed, nil
+}
+
+// Pin returns a pin of the expander, from 0 to 3 for the PCA9536 and 0
+// (IO0_0) to 15 (IO1_7) for the PCA9555.
+func (d *Device) Pin(n int) Pin {
+	if n < 0 || n >= d.pins {
+		panic(errPin)
+	}
+	return Pin{d: d, mask: 1 << uint(n)}
+}
+
+func (d *Device) update(reg uint8, cache *uint16, mask uint16, set bool) error {
+	v := *cache &^ mask
+	if set {
+		v |= mask
+	}
+	*cache = v
+	return d.write(reg, v)
+}
+
+func (d *Device) read(reg uint8) (uint16, error) {
+	if d.model == PCA9536 {
+		err := d.bus.ReadRegister(d.Address, reg, d.buf[:1])
+		return uint16(d.buf[0] & 0x0F), err
+	}
+	err := d.bus.ReadRegister(d.Address, reg*2, d.buf[:2])
+	return uint16(d.buf[1])<<8 | uint16(d.buf[0]), err
+}
+
+func (d *Device) write(reg uint8, value uint16) error {
+	d.buf[0], d.buf[1] = uint8(value), uint8(value>>8)
+	if d.model == PCA9536 {
+		// the upper bits of the registers are unused and read as 1
+		d.buf[0] |= 0xF0
+		return d.bus.WriteRegister(d.Address, reg, d.buf[:1])
+	}
+	return d.bus.WriteRegister(d.Address, reg*2, d.buf[:2])
+}
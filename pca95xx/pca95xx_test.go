@@ -0,0 +1,69 @@
+package pca95xx
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"tinygo.org/x/drivers/tester"
+)
+
+func TestPCA9555(t *testing.T) {
+	c := qt.New(t)
+	bus := tester.NewI2CBus(c)
+	fake := tester.NewI2CDevice(c, Address)
+	fake.SetupRegisters(make([]uint8, 8))
+	bus.AddDevice(fake)
+
+	dev := New(bus)
+	c.Assert(dev.Configure(Config{}), qt.IsNil)
+	fake.AssertRegisters(c, 0x06, []uint8{0xFF, 0xFF})
+
+	relay := dev.Pin(12)
+	c.Assert(relay.Configure(Output), qt.IsNil)
+	fake.AssertRegisters(c, 0x06, []uint8{0xFF, 0xEF})
+	relay.Low()
+	fake.AssertRegisters(c, 0x02, []uint8{0xFF, 0xEF})
+	c.Assert(dev.SetInverted(0x0001, true), qt.IsNil)
+	fake.AssertRegisters(c, 0x04, []uint8{0x01, 0x00})
+
+	fake.SetupRegister(0x01, 0x80)
+	c.Assert(dev.Pin(15).Get(), qt.IsTrue)
+	changed, err := dev.Changed()
+	c.Assert(err, qt.IsNil)
+	c.Assert(changed, qt.Equals, uint16(0x8000))
+	changed, err = dev.Changed()
+	c.Assert(err, qt.IsNil)
+	c.Assert(changed, qt.Equals, uint16(0))
+
+	// with a released INT pin, only the first call reads the device
+	c.Assert(dev.useInterrupt(func() bool { return true }), qt.IsNil)
+	fake.SetupRegister(0x01, 0x00)
+	changed, err = dev.Changed()
+	c.Assert(err, qt.IsNil)
+	c.Assert(changed, qt.Equals, uint16(0x8000))
+	fake.SetupRegister(0x01, 0x80)
+	changed, err = dev.Changed()
+	c.Assert(err, qt.IsNil)
+	c.Assert(changed, qt.Equals, uint16(0))
+}
+
+func TestPCA9536(t *testing.T) {
+	c := qt.New(t)
+	bus := tester.NewI2CBus(c)
+	fake := tester.NewI2CDevice(c, AddressPCA9536)
+	fake.SetupRegisters(make([]uint8, 4))
+	bus.AddDevice(fake)
+
+	dev := New(bus)
+	c.Assert(dev.Configure(Config{Model: PCA9536}), qt.IsNil)
+	c.Assert(dev.Pin(0).Configure(Output), qt.IsNil)
+	dev.Pin(0).Low()
+	fake.AssertRegisters(c, CONFIG, []uint8{0xFE})
+	fake.AssertRegisters(c, OUTPUT, []uint8{0xFE})
+
+	fake.SetupRegister(INPUT, 0xF4)
+	v, err := dev.ReadPins()
+	c.Assert(err, qt.IsNil)
+	c.Assert(v, qt.Equals, uint16(0x04))
+	c.Assert(dev.useInterrupt(func() bool { return true }), qt.Equals, errNoInterrupts)
+}
@@ -0,0 +1,60 @@
+package pca95xx
+
+import "tinygo.org/x/drivers"
+
+var _ drivers.Pin = Pin{}
+
+// PinMode is the direction of a single pin.
+type PinMode uint8
+
+// Pin modes. The pull-ups of these chips, where present, cannot be
+// configured.
+const (
+	Input PinMode = iota
+	Output
+)
+
+// Pin is a single pin of the expander. It implements drivers.Pin. Errors
+// on the bus are ignored by the methods of that interface; use Read and
+// Write to check them.
+type Pin struct {
+	d    *Device
+	mask uint16
+}
+
+// Configure sets the direction of the pin.
+func (p Pin) Configure(mode PinMode) error {
+	return p.d.SetInputs(p.mask, mode == Input)
+}
+
+// Read returns the level of the pin.
+func (p Pin) Read() (bool, error) {
+	v, err := p.d.ReadPins()
+	return v&p.mask != 0, err
+}
+
+// Write sets the level of an output pin.
+func (p Pin) Write(high bool) error {
+	return p.d.SetPins(p.mask, high)
+}
+
+// Get returns the level of the pin.
+func (p Pin) Get() bool {
+	v, _ := p.Read()
+	return v
+}
+
+// Set sets the level of an output pin.
+func (p Pin) Set(high bool) {
+	p.Write(high)
+}
+
+// High sets an output pin high.
+func (p Pin) High() {
+	p.Write(true)
+}
+
+// Low sets an output pin low.
+func (p Pin) Low() {
+	p.Write(false)
+}
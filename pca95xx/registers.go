@@ -0,0 +1,17 @@
+package pca95xx
+
+// The I2C address of the PCA9555 with all address pins low. It ranges up
+// to 0x27. The PCA9536 has a fixed address.
+const (
+	Address        = 0x20
+	AddressPCA9536 = 0x41
+)
+
+// Registers of the PCA9536. The PCA9555 has two of each, one per port, at
+// twice these addresses.
+const (
+	INPUT    = 0x00
+	OUTPUT   = 0x01
+	POLARITY = 0x02
+	CONFIG   = 0x03
+)
@@ -0,0 +1,252 @@
+// Package pcf8523 provides a driver for the PCF8523 RTC, found on the
+// Adafruit Adalogger FeatherWing and many data logging shields.
+//
+// Datasheet:
+// https://www.nxp.com/docs/en/data-sheet/PCF8523.pdf
+package pcf8523 // import "tinygo.org/x/drivers/pcf8523"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	errPowerMode = errors.New("pcf8523: invalid power mode")
+	errYear      = errors.New("pcf8523: year out of the range 2000-2099")
+)
+
+// PowerMode selects how the chip switches between the main supply and the
+// backup battery, and whether the battery voltage is monitored.
+type PowerMode uint8
+
+// Power management modes, see section 8.5 of the datasheet.
+const (
+	// Switch to the battery when the supply drops below the battery
+	// voltage and below 2.5V.
+	SwitchoverStandard PowerMode = 0
+	// Switch to the battery as soon as the supply drops below the battery
+	// voltage.
+	SwitchoverDirect PowerMode = 1
+	// Only run from the main supply. This is the reset default, so a
+	// battery is ignored until the power mode is configured. The battery
+	// low detection is disabled too, as there is no battery to monitor.
+	SwitchoverDisabled PowerMode = 7
+
+	// NoBatteryLowDetection is a flag to OR with SwitchoverStandard or
+	// SwitchoverDirect, which disables the battery low detection.
+	NoBatteryLowDetection PowerMode = 4
+)
+
+// ClockOut is the frequency of the CLKOUT pin.
+type ClockOut uint8
+
+// CLKOUT frequencies.
+const (
+	ClockOut32768Hz ClockOut = iota
+	ClockOut16384Hz
+	ClockOut8192Hz
+	ClockOut4096Hz
+	ClockOut1024Hz
+	ClockOut32Hz
+	ClockOut1Hz
+	ClockOutOff
+)
+
+// TimerFrequency is the source clock of the countdown timer.
+type TimerFrequency uint8
+
+// Countdown timer source clocks.
+const (
+	Timer4096Hz TimerFrequency = iota
+	Timer64Hz
+	Timer1Hz
+	Timer1_60Hz   // one tick per minute
+	Timer1_3600Hz // one tick per hour
+)
+
+// Device wraps an I2C connection to a PCF8523 device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint8
+}
+
+// New creates a new PCF8523 connection. The I2C bus must already be
+// configured.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Configure sets up the device for communication. The battery switchover
+// is disabled after a power-on reset, so it enables the standard switchover
+// mode with battery low detection.
+func (d *Device) Configure() error {
+	return d.SetPowerMode(SwitchoverStandard)
+}
+
+// SetPowerMode configures the battery switchover and battery low detection.
+// It returns an error for other combinations than those documented with
+// the modes.
+func (d *Device) SetPowerMode(mode PowerMode) error {
+	if mode != SwitchoverDisabled && mode&^NoBatteryLowDetection > SwitchoverDirect {
+		return errPowerMode
+	}
+	return d.updateRegister(REG_CONTROL3, 0x07<<PM, uint8(mode)<<PM)
+}
+
+// IsBatteryLow returns whether the battery voltage is too low. It is only
+// reported when battery low detection is enabled.
+func (d *Device) IsBatteryLow() (bool, error) {
+	data := []uint8{0}
+	err := d.bus.ReadRegister(d.Address, REG_CONTROL3, data)
+	if err != nil {
+		return false, err
+	}
+	return data[0]&(1<<BLF) != 0, nil
+}
+
+// IsTimeValid returns whether the oscillator has kept running since the
+// time was set, so that the time can be trusted.
+func (d *Device) IsTimeValid() bool {
+	data := []uint8{0}
+	err := d.bus.ReadRegister(d.Address, REG_SECONDS, data)
+	if err != nil {
+		return false
+	}
+	return data[0]&(1<<OS) == 0
+}
+
+// IsRunning returns whether the clock is running.
+func (d *Device) IsRunning() bool {
+	data := []uint8{0}
+	err := d.bus.ReadRegister(d.Address, REG_CONTROL1, data)
+	if err != nil {
+		return false
+	}
+	return data[0]&(1<<STOP) == 0
+}
+
+// SetRunning starts or stops the clock.
+func (d *Device) SetRunning(running bool) error {
+	if running {
+		return d.updateRegister(REG_CONTROL1, 1<<STOP, 0)
+	}
+	return d.updateRegister(REG_CONTROL1, 0, 1<<STOP)
+}
+
+// SetTime sets the date and time, in the years 2000 to 2099. This also
+// clears the oscillator stop flag.
+func (d *Device) SetTime(t time.Time) error {
+	if t.Year() < 2000 || t.Year() > 2099 {
+		return errYear
+	}
+	data := []uint8{
+		uint8ToBCD(uint8(t.Second())),
+		uint8ToBCD(uint8(t.Minute())),
+		uint8ToBCD(uint8(t.Hour())),
+		uint8ToBCD(uint8(t.Day())),
+		uint8(t.Weekday()),
+		uint8ToBCD(uint8(t.Month())),
+		uint8ToBCD(uint8(t.Year() - 2000)),
+	}
+	return d.bus.WriteRegister(d.Address, REG_SECONDS, data)
+}
+
+// ReadTime returns the date and time.
+func (d *Device) ReadTime() (time.Time, error) {
+	data := make([]uint8, REG_TIMEDATE_LEN)
+	err := d.bus.ReadRegister(d.Address, REG_SECONDS, data)
+	if err != nil {
+		return time.Time{}, err
+	}
+	second := bcdToInt(data[0] & 0x7F)
+	minute := bcdToInt(data[1] & 0x7F)
+	hour := bcdToInt(data[2] & 0x3F)
+	day := bcdToInt(data[3] & 0x3F)
+	month := time.Month(bcdToInt(data[5] & 0x1F))
+	year := bcdToInt(data[6]) + 2000
+	return time.Date(year, month, day, hour, minute, second, 0, time.UTC), nil
+}
+
+// SetClockOut sets the frequency of the CLKOUT pin. The pin is the
+// interrupt output when the clock output is off.
+func (d *Device) SetClockOut(freq ClockOut) error {
+	return d.updateRegister(REG_TMR_CLKOUT, 0x07<<COF, uint8(freq)<<COF)
+}
+
+// StartTimer starts timer A as a countdown timer that fires after ticks
+// periods of the given frequency and then restarts. When interrupt is true
+// the INT1 pin is pulled low when the timer fires, until the flag is
+// cleared with ClearTimer.
+func (d *Device) StartTimer(ticks uint8, freq TimerFrequency, interrupt bool) error {
+	if err := d.StopTimer(); err != nil {
+		return err
+	}
+	err := d.bus.WriteRegister(d.Address, REG_TMR_A_FREQ, []uint8{uint8(freq), ticks})
+	if err != nil {
+		return err
+	}
+	if interrupt {
+		if err := d.updateRegister(REG_CONTROL2, 1<<CTAF, 1<<CTAIE); err != nil {
+			return err
+		}
+	}
+	// a permanent interrupt signal, instead of a short pulse
+	return d.updateRegister(REG_TMR_CLKOUT, 1<<TAM|0x03<<TAC, 0x01<<TAC)
+}
+
+// StopTimer stops timer A and disables its interrupt.
+func (d *Device) StopTimer() error {
+	if err := d.updateRegister(REG_TMR_CLKOUT, 0x03<<TAC, 0); err != nil {
+		return err
+	}
+	return d.updateRegister(REG_CONTROL2, 1<<CTAIE|1<<CTAF, 0)
+}
+
+// TimerFired returns whether timer A has fired since the flag was last
+// cleared.
+func (d *Device) TimerFired() (bool, error) {
+	data := []uint8{0}
+	err := d.bus.ReadRegister(d.Address, REG_CONTROL2, data)
+	if err != nil {
+		return false, err
+	}
+	return data[0]&(1<<CTAF) != 0, nil
+}
+
+// ClearTimer clears the flag of timer A, which releases the INT1 pin.
+func (d *Device) ClearTimer() error {
+	return d.updateRegister(REG_CONTROL2, 1<<CTAF, 0)
+}
+
+// updateRegister clears and then sets bits in a register. The flags in
+// Control_2 are cleared by writing a 0, so they are preserved unless they
+// are cleared.
+func (d *Device) updateRegister(reg, clear, set uint8) error {
+	data := []uint8{0}
+	err := d.bus.ReadRegister(d.Address, reg, data)
+	if err != nil {
+		return err
+	}
+	if reg == REG_CONTROL2 {
+		data[0] |= 1<<WTAF | 1<<CTAF | 1<<CTBF | 1<<SF | 1<<AF
+	}
+	data[0] = data[0]&^clear | set
+	return d.bus.WriteRegister(d.Address, reg, data)
+}
+
+// uint8ToBCD converts a byte to BCD for the PCF8523
+func uint8ToBCD(value uint8) uint8 {
+	return value + 6*(value/10)
+}
+
+// bcdToInt converts BCD from the PCF8523 to int
+func bcdToInt(value uint8) int {
+	return int(value - 6*(value>>4))
+}
@@ -0,0 +1,65 @@
+package pcf8523
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/tester"
+)
+
+var _ drivers.RTC = &Device{}
+
+func TestTime(t *testing.T) {
+	c := qt.New(t)
+	bus := tester.NewI2CBus(c)
+	fake := tester.NewI2CDevice(c, Address)
+	fake.SetupRegisters(make([]uint8, 0x14))
+	fake.SetupRegister(REG_SECONDS, 1<<OS)
+	bus.AddDevice(fake)
+
+	dev := New(bus)
+	c.Assert(dev.IsTimeValid(), qt.IsFalse)
+
+	date := time.Date(2021, 12, 31, 23, 59, 58, 0, time.UTC)
+	c.Assert(dev.SetTime(date), qt.IsNil)
+	fake.AssertRegisters(c, REG_SECONDS, []uint8{0x58, 0x59, 0x23, 0x31, 5, 0x12, 0x21})
+	c.Assert(dev.IsTimeValid(), qt.IsTrue)
+
+	got, err := dev.ReadTime()
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.Equals, date)
+
+	// the year register only holds two digits
+	c.Assert(dev.SetTime(time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC)), qt.Equals, errYear)
+	c.Assert(dev.SetTime(time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)), qt.Equals, errYear)
+	fake.AssertRegisters(c, REG_SECONDS+6, []uint8{0x21})
+}
+
+func TestPowerMode(t *testing.T) {
+	c := qt.New(t)
+	bus := tester.NewI2CBus(c)
+	fake := tester.NewI2CDevice(c, Address)
+	fake.SetupRegisters(make([]uint8, 0x14))
+	fake.SetupRegister(REG_CONTROL3, 0xE0|1<<BLF)
+	bus.AddDevice(fake)
+
+	dev := New(bus)
+	c.Assert(dev.Configure(), qt.IsNil)
+	fake.AssertRegisters(c, REG_CONTROL3, []uint8{1 << BLF})
+	low, err := dev.IsBatteryLow()
+	c.Assert(err, qt.IsNil)
+	c.Assert(low, qt.IsTrue)
+
+	c.Assert(dev.SetPowerMode(SwitchoverDirect|NoBatteryLowDetection), qt.IsNil)
+	fake.AssertRegisters(c, REG_CONTROL3, []uint8{5<<PM | 1<<BLF})
+	c.Assert(dev.SetPowerMode(SwitchoverDisabled), qt.IsNil)
+	fake.AssertRegisters(c, REG_CONTROL3, []uint8{7<<PM | 1<<BLF})
+
+	// the other values of the PM bits are not valid modes
+	for _, mode := range []PowerMode{2, 3, 6, 8} {
+		c.Assert(dev.SetPowerMode(mode), qt.Equals, errPowerMode, qt.Commentf("mode %d", mode))
+	}
+	fake.AssertRegisters(c, REG_CONTROL3, []uint8{7<<PM | 1<<BLF})
+}
@@ -0,0 +1,55 @@
+package pcf8523
+
+// The I2C address which this device listens to.
+const Address = 0x68
+
+// Registers
+const (
+	REG_CONTROL1     = 0x00
+	REG_CONTROL2     = 0x01
+	REG_CONTROL3     = 0x02
+	REG_SECONDS      = 0x03
+	REG_OFFSET       = 0x0E
+	REG_TMR_CLKOUT   = 0x0F
+	REG_TMR_A_FREQ   = 0x10
+	REG_TMR_A        = 0x11
+	REG_TMR_B_FREQ   = 0x12
+	REG_TMR_B        = 0x13
+	REG_TIMEDATE_LEN = 7
+
+	// Control_1 register bits
+	CIE     = 0
+	AIE     = 1
+	SIE     = 2
+	MODE12  = 3
+	SR      = 4
+	STOP    = 5
+	CAP_SEL = 7
+
+	// Control_2 register bits
+	CTBIE = 0
+	CTAIE = 1
+	WTAIE = 2
+	AF    = 3
+	SF    = 4
+	CTBF  = 5
+	CTAF  = 6
+	WTAF  = 7
+
+	// Control_3 register bits
+	BLIE = 0
+	BSIE = 1
+	BLF  = 2
+	BSF  = 3
+	PM   = 5
+
+	// Tmr_CLKOUT_ctrl register bits
+	TBC = 0
+	TAC = 1
+	COF = 3
+	TBM = 6
+	TAM = 7
+
+	// OS is the oscillator stop flag in the seconds register
+	OS = 7
+)
@@ -0,0 +1,215 @@
+// Package pcf8563 provides a driver for the PCF8563 RTC, also sold as the
+// BM8563 and HYM8563.
+//
+// Datasheet:
+// https://www.nxp.com/docs/en/data-sheet/PCF8563.pdf
+package pcf8563 // import "tinygo.org/x/drivers/pcf8563"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var errYear = errors.New("pcf8563: year out of the range 1900-2099")
+
+// ClockOut is the frequency of the CLKOUT pin.
+type ClockOut uint8
+
+// CLKOUT frequencies.
+const (
+	ClockOut32768Hz ClockOut = iota
+	ClockOut1024Hz
+	ClockOut32Hz
+	ClockOut1Hz
+	ClockOutOff
+)
+
+// TimerFrequency is the source clock of the countdown timer.
+type TimerFrequency uint8
+
+// Countdown timer source clocks.
+const (
+	Timer4096Hz TimerFrequency = iota
+	Timer64Hz
+	Timer1Hz
+	Timer1_60Hz // one tick per minute
+)
+
+// Device wraps an I2C connection to a PCF8563 device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint8
+}
+
+// New creates a new PCF8563 connection. The I2C bus must already be
+// configured.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Configure sets up the device for communication. It clears the test mode
+// bits, which are undefined after power-on.
+func (d *Device) Configure() error {
+	return d.updateRegister(REG_CONTROL1, ^uint8(1<<STOP), 0)
+}
+
+// IsTimeValid returns whether the time can be trusted. The PCF8563
+// switches to the battery automatically; the voltage low flag is set when
+// even the battery voltage dropped too low to keep the clock running, which
+// also indicates an empty battery.
+func (d *Device) IsTimeValid() bool {
+	data := []uint8{0}
+	err := d.bus.ReadRegister(d.Address, REG_SECONDS, data)
+	if err != nil {
+		return false
+	}
+	return data[0]&(1<<VL) == 0
+}
+
+// IsRunning returns whether the clock is running.
+func (d *Device) IsRunning() bool {
+	data := []uint8{0}
+	err := d.bus.ReadRegister(d.Address, REG_CONTROL1, data)
+	if err != nil {
+		return false
+	}
+	return data[0]&(1<<STOP) == 0
+}
+
+// SetRunning starts or stops the clock.
+func (d *Device) SetRunning(running bool) error {
+	if running {
+		return d.updateRegister(REG_CONTROL1, 1<<STOP, 0)
+	}
+	return d.updateRegister(REG_CONTROL1, 0, 1<<STOP)
+}
+
+// SetTime sets the date and time, in the years 1900 to 2099. This also
+// clears the voltage low flag.
+func (d *Device) SetTime(t time.Time) error {
+	if t.Year() < 1900 || t.Year() > 2099 {
+		return errYear
+	}
+	year := t.Year() - 2000
+	century := uint8(0)
+	if year < 0 {
+		// the century flag is set for the 1900s
+		year += 100
+		century = 1 << C
+	}
+	data := []uint8{
+		uint8ToBCD(uint8(t.Second())),
+		uint8ToBCD(uint8(t.Minute())),
+		uint8ToBCD(uint8(t.Hour())),
+		uint8ToBCD(uint8(t.Day())),
+		uint8(t.Weekday()),
+		uint8ToBCD(uint8(t.Month())) | century,
+		uint8ToBCD(uint8(year)),
+	}
+	return d.bus.WriteRegister(d.Address, REG_SECONDS, data)
+}
+
+// ReadTime returns the date and time.
+func (d *Device) ReadTime() (time.Time, error) {
+	data := make([]uint8, REG_TIMEDATE_LEN)
+	err := d.bus.ReadRegister(d.Address, REG_SECONDS, data)
+	if err != nil {
+		return time.Time{}, err
+	}
+	second := bcdToInt(data[0] & 0x7F)
+	minute := bcdToInt(data[1] & 0x7F)
+	hour := bcdToInt(data[2] & 0x3F)
+	day := bcdToInt(data[3] & 0x3F)
+	month := time.Month(bcdToInt(data[5] & 0x1F))
+	year := bcdToInt(data[6]) + 2000
+	if data[5]&(1<<C) != 0 {
+		year -= 100
+	}
+	return time.Date(year, month, day, hour, minute, second, 0, time.UTC), nil
+}
+
+// SetClockOut sets the frequency of the open-drain CLKOUT pin.
+func (d *Device) SetClockOut(freq ClockOut) error {
+	if freq == ClockOutOff {
+		return d.bus.WriteRegister(d.Address, REG_CLKOUT, []uint8{0})
+	}
+	return d.bus.WriteRegister(d.Address, REG_CLKOUT, []uint8{1<<FE | uint8(freq)})
+}
+
+// StartTimer starts the countdown timer, which fires after ticks periods of
+// the given frequency and then restarts. When interrupt is true the INT pin
+// is pulled low when the timer fires, until the flag is cleared with
+// ClearTimer.
+func (d *Device) StartTimer(ticks uint8, freq TimerFrequency, interrupt bool) error {
+	if err := d.StopTimer(); err != nil {
+		return err
+	}
+	err := d.bus.WriteRegister(d.Address, REG_TIMER, []uint8{ticks})
+	if err != nil {
+		return err
+	}
+	if interrupt {
+		if err := d.updateRegister(REG_CONTROL2, 1<<TF|1<<TI_TP, 1<<TIE); err != nil {
+			return err
+		}
+	}
+	return d.bus.WriteRegister(d.Address, REG_TIMER_CTRL, []uint8{1<<TE | uint8(freq)})
+}
+
+// StopTimer stops the countdown timer and disables its interrupt.
+func (d *Device) StopTimer() error {
+	err := d.bus.WriteRegister(d.Address, REG_TIMER_CTRL, []uint8{uint8(Timer1_60Hz)})
+	if err != nil {
+		return err
+	}
+	return d.updateRegister(REG_CONTROL2, 1<<TIE|1<<TF, 0)
+}
+
+// TimerFired returns whether the timer has fired since the flag was last
+// cleared.
+func (d *Device) TimerFired() (bool, error) {
+	data := []uint8{0}
+	err := d.bus.ReadRegister(d.Address, REG_CONTROL2, data)
+	if err != nil {
+		return false, err
+	}
+	return data[0]&(1<<TF) != 0, nil
+}
+
+// ClearTimer clears the timer flag, which releases the INT pin.
+func (d *Device) ClearTimer() error {
+	return d.updateRegister(REG_CONTROL2, 1<<TF, 0)
+}
+
+// updateRegister clears and then sets bits in a register. The flags in
+// Control_status_2 are cleared by writing a 0, so they are preserved unless
+// they are cleared.
+func (d *Device) updateRegister(reg, clear, set uint8) error {
+	data := []uint8{0}
+	err := d.bus.ReadRegister(d.Address, reg, data)
+	if err != nil {
+		return err
+	}
+	if reg == REG_CONTROL2 {
+		data[0] |= 1<<TF | 1<<AF
+	}
+	data[0] = data[0]&^clear | set
+	return d.bus.WriteRegister(d.Address, reg, data)
+}
+
+// uint8ToBCD converts a byte to BCD for the PCF8563
+func uint8ToBCD(value uint8) uint8 {
+	return value + 6*(value/10)
+}
+
+// bcdToInt converts BCD from the PCF8563 to int
+func bcdToInt(value uint8) int {
+	return int(value - 6*(value>>4))
+}
@@ -0,0 +1,52 @@
+package pcf8563
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/tester"
+)
+
+var _ drivers.RTC = &Device{}
+
+func TestTime(t *testing.T) {
+	c := qt.New(t)
+	bus := tester.NewI2CBus(c)
+	fake := tester.NewI2CDevice(c, Address)
+	fake.SetupRegisters(make([]uint8, 0x10))
+	fake.SetupRegister(REG_SECONDS, 1<<VL)
+	bus.AddDevice(fake)
+
+	dev := New(bus)
+	c.Assert(dev.IsTimeValid(), qt.IsFalse)
+
+	// the weekday register is not in BCD and comes before the month
+	date := time.Date(2021, 12, 31, 23, 59, 58, 0, time.UTC)
+	c.Assert(dev.SetTime(date), qt.IsNil)
+	fake.AssertRegisters(c, REG_SECONDS, []uint8{0x58, 0x59, 0x23, 0x31, 5, 0x12, 0x21})
+	c.Assert(dev.IsTimeValid(), qt.IsTrue)
+	got, err := dev.ReadTime()
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.Equals, date)
+
+	// the century flag is set in the month register for the 1900s
+	date = time.Date(1999, 10, 1, 12, 0, 0, 0, time.UTC)
+	c.Assert(dev.SetTime(date), qt.IsNil)
+	fake.AssertRegisters(c, REG_SECONDS+5, []uint8{0x90, 0x99})
+	got, err = dev.ReadTime()
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.Equals, date)
+
+	date = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.Assert(dev.SetTime(date), qt.IsNil)
+	fake.AssertRegisters(c, REG_SECONDS+5, []uint8{0x01, 0x00})
+	got, err = dev.ReadTime()
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.Equals, date)
+
+	c.Assert(dev.SetTime(time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC)), qt.Equals, errYear)
+	c.Assert(dev.SetTime(time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)), qt.Equals, errYear)
+	fake.AssertRegisters(c, REG_SECONDS+5, []uint8{0x01, 0x00})
+}
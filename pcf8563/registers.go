@@ -0,0 +1,35 @@
+package pcf8563
+
+// The I2C address which this device listens to.
+const Address = 0x51
+
+// Registers
+const (
+	REG_CONTROL1     = 0x00
+	REG_CONTROL2     = 0x01
+	REG_SECONDS      = 0x02
+	REG_CLKOUT       = 0x0D
+	REG_TIMER_CTRL   = 0x0E
+	REG_TIMER        = 0x0F
+	REG_TIMEDATE_LEN = 7
+
+	// Control_status_1 register bits
+	STOP = 5
+
+	// Control_status_2 register bits
+	TIE   = 0
+	AIE   = 1
+	TF    = 2
+	AF    = 3
+	TI_TP = 4
+
+	// CLKOUT_control and Timer_control register bits
+	FE = 7
+	TE = 7
+
+	// VL is the voltage low flag in the seconds register
+	VL = 7
+
+	// C is the century flag in the months register
+	C = 7
+)
@@ -0,0 +1,16 @@
+// +build tinygo
+
+package pcf8574
+
+import "machine"
+
+// UseInterrupt makes Changed read the device only after the INT pin
+// signaled a change. The pin is open drain and active low; its pull-up is
+// enabled.
+func (d *Device) UseInterrupt(pin machine.Pin) error {
+	d.useInterrupt(pin.Get)
+	pin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	return pin.SetInterrupt(machine.PinFalling, func(machine.Pin) {
+		d.pending = true
+	})
+}
@@ -0,0 +1,183 @@
+// Package pcf8574 provides a driver for the PCF8574 8-bit and PCF8575
+// 16-bit I/O expanders.
+//
+// The pins of these expanders are quasi-bidirectional: a pin written low
+// is driven low, a pin written high is only pulled up weakly and can be
+// used as an input. There are no direction registers, so the driver keeps
+// the written levels and changes single pins with a read-modify-write of
+// that state. The pins implement drivers.Pin.
+//
+// Datasheets:
+// https://www.ti.com/lit/ds/symlink/pcf8574.pdf
+// https://www.ti.com/lit/ds/symlink/pcf8575.pdf
+package pcf8574 // import "tinygo.org/x/drivers/pcf8574"
+
+import (
+	"errors"
+
+	"tinygo.org/x/drivers"
+)
+
+// The I2C address of the PCF8574 and PCF8575 with all address pins low.
+// The PCF8574A uses addresses from 0x38.
+const (
+	Address  = 0x20
+	AddressA = 0x38
+)
+
+var errPin = errors.New("pcf8574: invalid pin")
+
+// Model selects the number of pins.
+type Model uint8
+
+// Supported models.
+const (
+	PCF8574 Model = iota
+	PCF8575
+)
+
+// Config contains the settings used by Configure.
+type Config struct {
+	Model Model
+}
+
+// Device wraps an I2C connection to a PCF8574 or PCF8575 device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+	width   int
+	latch   uint16      // levels written to the pins
+	last    uint16      // levels at the last call to Changed
+	irq     func() bool // level of the interrupt pin, nil without it
+	pending bool
+	buf     [2]uint8
+}
+
+// New creates a new PCF8574 connection. The I2C bus must already be
+// configured.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.I2C) *Device {
+	return &Device{
+		bus:     bus,
+		Address: Address,
+		width:   1,
+		latch:   0xFFFF,
+	}
+}
+
+// Configure sets all pins high, so that they can be used as inputs.
+func (d *Device) Configure(cfg Config) error {
+	d.width = 1
+	if cfg.Model == PCF8575 {
+		d.width = 2
+	}
+	if err := d.WritePins(0xFFFF); err != nil {
+		return err
+	}
+	v, err := d.ReadPins()
+	d.last = v
+	return err
+}
+
+// ReadPins returns the levels of all pins, with P10 to P17 of the PCF8575
+// in the upper byte.
+func (d *Device) ReadPins() (uint16, error) {
+	d.buf[1] = 0
+	err := d.bus.Tx(d.Address, nil, d.buf[:d.width])
+	return uint16(d.buf[1])<<8 | uint16(d.buf[0]), err
+}
+
+// WritePins sets the levels of all pins. Pins used as inputs must be set
+// high.
+func (d *Device) WritePins(levels uint16) error {
+	d.latch = levels
+	d.buf[0], d.buf[1] = uint8(levels), uint8(levels>>8)
+	return d.bus.Tx(d.Address, d.buf[:d.width], nil)
+}
+
+// SetPins sets the levels of the pins in mask, keeping the others.
+func (d *Device) SetPins(mask uint16, high bool) error {
+	levels := d.latch &^ mask
+	if high {
+		levels |= mask
+	}
+	return d.WritePins(levels)
+}
+
+// useInterrupt makes Changed read the device only after a change, signaled
+// by a call to the interrupt handler or by the level of the INT pin.
+func (d *Device) useInterrupt(irq func() bool) {
+	d.irq, d.pending = irq, true
+}
+
+// Changed returns the pins that changed since the last call. With an INT
+// pin the device is only read after a change, so Changed can be called
+// often.
+func (d *Device) Changed() (uint16, error) {
+	if d.irq != nil {
+		if !d.pending && d.irq() {
+			return 0, nil
+		}
+		d.pending = false
+	}
+	// reading the pins releases the INT pin
+	v, err := d.ReadPins()
+	if err != nil {
+		return 0, err
+	}
+	changed := v ^ d.last
+	d.last = v
+	return changed, nil
+}
+
+// Pin returns a pin of the expander, from 0 to 7 for the PCF8574 and 0
+// (P00) to 15 (P17) for the PCF8575.
+func (d *Device) Pin(n int) Pin {
+	if n < 0 || n >= 8*d.width {
+		panic(errPin)
+	}
+	return Pin{d: d, mask: 1 << uint(n)}
+}
+
+var _ drivers.Pin = Pin{}
+
+// Pin is a single pin of the expander. It implements drivers.Pin. Errors
+// on the bus are ignored by the methods of that interface; use Read and
+// Write to check them.
+type Pin struct {
+	d    *Device
+	mask uint16
+}
+
+// Read returns the level of the pin.
+func (p Pin) Read() (bool, error) {
+	v, err := p.d.ReadPins()
+	return v&p.mask != 0, err
+}
+
+// Write sets the level of the pin. A pin used as an input must be high.
+func (p Pin) Write(high bool) error {
+	return p.d.SetPins(p.mask, high)
+}
+
+// Get returns the level of the pin.
+func (p Pin) Get() bool {
+	v, _ := p.Read()
+	return v
+}
+
+// Set sets the level of the pin.
+func (p Pin) Set(high bool) {
+	p.Write(high)
+}
+
+// High sets the pin high, which also makes it an input.
+func (p Pin) High() {
+	p.Write(true)
+}
+
+// Low drives the pin low.
+func (p Pin) Low() {
+	p.Write(false)
+}
@@ -0,0 +1,68 @@
+package pcf8574
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers/tester"
+)
+
+// expander simulates the pins of a PCF8575: inputs read low when they are
+// written low or pulled low externally.
+type expander struct {
+	latch  uint16
+	pulled uint16
+}
+
+func (e *expander) tx(w, r []byte) error {
+	if len(w) == 2 {
+		e.latch = uint16(w[1])<<8 | uint16(w[0])
+	}
+	if len(r) == 2 {
+		v := e.latch &^ e.pulled
+		r[0], r[1] = uint8(v), uint8(v>>8)
+	}
+	return nil
+}
+
+func TestChanged(t *testing.T) {
+	c := qt.New(t)
+	fake := &expander{}
+	bus := tester.NewI2CBus(c)
+	bus.AddDevice(tester.NewI2CCommandDevice(c, Address, fake.tx))
+
+	dev := New(bus)
+	c.Assert(dev.Configure(Config{Model: PCF8575}), qt.IsNil)
+	c.Assert(fake.latch, qt.Equals, uint16(0xFFFF))
+
+	dev.Pin(9).Low()
+	c.Assert(fake.latch, qt.Equals, uint16(0xFDFF))
+	changed, err := dev.Changed()
+	c.Assert(err, qt.IsNil)
+	c.Assert(changed, qt.Equals, uint16(0x0200))
+
+	fake.pulled = 0x0001
+	c.Assert(dev.Pin(0).Get(), qt.IsFalse)
+	changed, err = dev.Changed()
+	c.Assert(err, qt.IsNil)
+	c.Assert(changed, qt.Equals, uint16(0x0001))
+
+	// with a released INT pin, only the first call reads the device
+	irq := true
+	dev.useInterrupt(func() bool { return irq })
+	fake.pulled = 0
+	changed, err = dev.Changed()
+	c.Assert(err, qt.IsNil)
+	c.Assert(changed, qt.Equals, uint16(0x0001))
+	fake.pulled = 0x0001
+	changed, err = dev.Changed()
+	c.Assert(err, qt.IsNil)
+	c.Assert(changed, qt.Equals, uint16(0))
+
+	// until the device pulls it low
+	irq = false
+	changed, err = dev.Changed()
+	c.Assert(err, qt.IsNil)
+	c.Assert(changed, qt.Equals, uint16(0x0001))
+}
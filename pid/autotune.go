@@ -0,0 +1,120 @@
+package pid
+
+import "time"
+
+// AutoTuner finds PID gains using the relay method (Åström–Hägglund): the
+// output is switched between a high and a low value whenever the
+// measurement crosses the setpoint, which makes the process oscillate. The
+// period and amplitude of that oscillation give the ultimate gain and
+// period of the process, from which gains are derived with the classic
+// Ziegler–Nichols rules.
+type AutoTuner struct {
+	Setpoint int32
+
+	// OutLow and OutHigh are the two relay output levels.
+	OutLow, OutHigh int32
+
+	// Hysteresis is the band around the setpoint in which the relay does not
+	// switch, to reject measurement noise.
+	Hysteresis int32
+
+	// Cycles is the number of full oscillations to average, 3 if zero.
+	Cycles int
+
+	high     bool
+	started  bool
+	peakHigh int32
+	peakLow  int32
+	lastRise time.Time
+	cycles   int
+	sumAmp   int64
+	sumTime  time.Duration
+	done     bool
+}
+
+// Update feeds a new measurement to the tuner and returns the output to
+// apply. Once enough oscillations have been observed, done is true and
+// Gains returns the result.
+func (t *AutoTuner) Update(measurement int32, now time.Time) (output int32, done bool) {
+	if t.done {
+		return t.OutLow, true
+	}
+	if !t.started {
+		t.started = true
+		t.high = measurement < t.Setpoint
+		t.peakHigh = measurement
+		t.peakLow = measurement
+	}
+	if measurement > t.peakHigh {
+		t.peakHigh = measurement
+	}
+	if measurement < t.peakLow {
+		t.peakLow = measurement
+	}
+
+	if t.high && measurement > t.Setpoint+t.Hysteresis {
+		t.high = false
+	} else if !t.high && measurement < t.Setpoint-t.Hysteresis {
+		// a full oscillation ends at every switch from low to high
+		t.high = true
+		if !t.lastRise.IsZero() {
+			t.cycles++
+			t.sumAmp += int64(t.peakHigh-t.peakLow) / 2
+			t.sumTime += now.Sub(t.lastRise)
+		}
+		t.lastRise = now
+		t.peakHigh = measurement
+		t.peakLow = measurement
+		cycles := t.Cycles
+		if cycles == 0 {
+			cycles = 3
+		}
+		if t.cycles >= cycles {
+			t.done = true
+			return t.OutLow, true
+		}
+	}
+
+	if t.high {
+		return t.OutHigh, false
+	}
+	return t.OutLow, false
+}
+
+// Done returns whether tuning has finished.
+func (t *AutoTuner) Done() bool {
+	return t.done
+}
+
+// Period returns the measured oscillation period, the ultimate period of the
+// process.
+func (t *AutoTuner) Period() time.Duration {
+	if t.cycles == 0 {
+		return 0
+	}
+	return t.sumTime / time.Duration(t.cycles)
+}
+
+// Gains returns PID gains computed from the observed oscillation with the
+// Ziegler–Nichols rules, or zero gains if tuning has not finished.
+func (t *AutoTuner) Gains() Gains {
+	if t.cycles == 0 {
+		return Gains{}
+	}
+	amp := t.sumAmp / int64(t.cycles)
+	if amp == 0 {
+		amp = 1
+	}
+	periodMs := int64(t.Period() / time.Millisecond)
+	if periodMs == 0 {
+		periodMs = 1
+	}
+	// ultimate gain Ku = 4d/(πa), scaled by Scale
+	d := int64(t.OutHigh-t.OutLow) / 2
+	ku := 4 * d * Scale * 1000 / (3142 * amp)
+	return Gains{
+		Kp: int32(ku * 6 / 10),
+		Ki: int32(ku * 12 * 1000 / (10 * periodMs)),
+		Kd: int32(ku * 75 * periodMs / (1000 * 1000)),
+	}
+}
@@ -0,0 +1,110 @@
+// Package pid implements a fixed-point PID controller for closed-loop
+// control of heaters, Peltier elements, fans and motors, plus a relay
+// auto-tuner that finds suitable gains by itself.
+//
+// All values are integers so that the controller runs efficiently on
+// microcontrollers without an FPU. Inputs are expected in the integer units
+// used by the drivers in this repository, for example milli-degrees Celsius
+// for temperatures. Gains are expressed in thousandths: a Kp of 1500 means
+// that an error of 1000 units produces an output of 1500.
+//
+// See Thermal for a helper that connects a thermometer to a PWM output.
+package pid // import "tinygo.org/x/drivers/pid"
+
+import "time"
+
+// Scale is the fixed-point scale of the gains.
+const Scale = 1000
+
+// Gains are the tuning parameters of a controller, scaled by Scale. Ki is
+// per second and Kd is in seconds.
+type Gains struct {
+	Kp, Ki, Kd int32
+}
+
+// Controller is a PID controller with output clamping, integrator
+// anti-windup and a filtered derivative on the measurement.
+//
+// The zero value is not usable, create a controller with New.
+type Controller struct {
+	Gains
+
+	// OutMin and OutMax limit the output.
+	OutMin, OutMax int32
+
+	// DerivativeFilter is the weight (0-Scale) of the newest derivative
+	// sample in the first order low-pass filter applied to the derivative
+	// term. Scale disables filtering, lower values filter more.
+	DerivativeFilter int32
+
+	integral   int64 // sum of Ki*error*dt, scaled by Scale*Scale (ms)
+	derivative int32 // filtered rate of change of the measurement per second
+	last       int32
+	primed     bool
+}
+
+// New returns a new Controller with the given gains and output limits.
+func New(gains Gains, outMin, outMax int32) *Controller {
+	return &Controller{
+		Gains:            gains,
+		OutMin:           outMin,
+		OutMax:           outMax,
+		DerivativeFilter: Scale / 4,
+	}
+}
+
+// Reset clears the integrator and derivative state, for example after the
+// setpoint changed drastically or the output was disabled for a while.
+func (c *Controller) Reset() {
+	c.integral = 0
+	c.derivative = 0
+	c.primed = false
+}
+
+// Update computes a new output given the setpoint, the current measurement
+// and the time elapsed since the previous update.
+func (c *Controller) Update(setpoint, measurement int32, dt time.Duration) int32 {
+	ms := int64(dt / time.Millisecond)
+	if ms <= 0 {
+		ms = 1
+	}
+	e := int64(setpoint - measurement)
+
+	// Derivative on the measurement instead of on the error, so that
+	// setpoint changes do not cause a kick in the output.
+	if c.primed {
+		raw := int32(-int64(measurement-c.last) * 1000 / ms)
+		c.derivative += (raw - c.derivative) * c.DerivativeFilter / Scale
+	}
+	c.last = measurement
+	c.primed = true
+
+	p := int64(c.Kp) * e / Scale
+	d := int64(c.Kd) * int64(c.derivative) / Scale
+	integral := c.integral + int64(c.Ki)*e*ms
+	out := p + integral/(Scale*Scale) + d
+
+	// Anti-windup: only accept the new integral when the output is not
+	// saturated, or when integrating moves it away from saturation.
+	switch {
+	case out > int64(c.OutMax):
+		if integral < c.integral {
+			c.integral = integral
+		}
+		out = int64(c.OutMax)
+	case out < int64(c.OutMin):
+		if integral > c.integral {
+			c.integral = integral
+		}
+		out = int64(c.OutMin)
+	default:
+		c.integral = integral
+	}
+	return int32(out)
+}
+
+// Integral returns the current contribution of the integral term to the
+// output.
+func (c *Controller) Integral() int32 {
+	return int32(c.integral / (Scale * Scale))
+}
@@ -0,0 +1,85 @@
+package pid
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// plant is a simple first order thermal process with dead time: the
+// temperature moves towards ambient plus a gain times the heater power.
+type plant struct {
+	temp    int32
+	ambient int32
+	delay   []int32
+}
+
+func (p *plant) step(duty int32) int32 {
+	p.delay = append(p.delay, duty)
+	applied := p.delay[0]
+	if len(p.delay) > 3 {
+		p.delay = p.delay[1:]
+	}
+	// 100% duty heats up to 100 °C above ambient
+	target := p.ambient + int32(int64(applied)*100000/0xffff)
+	p.temp += (target - p.temp) / 20
+	return p.temp
+}
+
+func TestControllerConverges(t *testing.T) {
+	c := qt.New(t)
+	p := &plant{temp: 20000, ambient: 20000}
+	ctl := New(Gains{Kp: 2000, Ki: 200, Kd: 0}, 0, 0xffff)
+	temp := p.temp
+	for i := 0; i < 2000; i++ {
+		temp = p.step(ctl.Update(60000, temp, time.Second))
+	}
+	c.Assert(temp > 59000 && temp < 61000, qt.IsTrue, qt.Commentf("temperature %d", temp))
+}
+
+func TestOutputClamped(t *testing.T) {
+	c := qt.New(t)
+	ctl := New(Gains{Kp: 100000, Ki: 100000}, -100, 100)
+	c.Assert(ctl.Update(1000000, 0, time.Second), qt.Equals, int32(100))
+	c.Assert(ctl.Update(-1000000, 0, time.Second), qt.Equals, int32(-100))
+}
+
+func TestAntiWindup(t *testing.T) {
+	c := qt.New(t)
+	ctl := New(Gains{Kp: 0, Ki: 1000}, 0, 100)
+	// a large error for a long time saturates the output...
+	for i := 0; i < 1000; i++ {
+		ctl.Update(1000, 0, time.Second)
+	}
+	// ...but must not wind up the integrator beyond the output range
+	c.Assert(ctl.Integral() <= 100, qt.IsTrue, qt.Commentf("integral %d", ctl.Integral()))
+	// so the output drops as soon as the error changes sign
+	c.Assert(ctl.Update(0, 1000, time.Second) < 100, qt.IsTrue)
+}
+
+func TestAutoTuner(t *testing.T) {
+	c := qt.New(t)
+	p := &plant{temp: 20000, ambient: 20000}
+	tuner := &AutoTuner{
+		Setpoint:   60000,
+		OutLow:     0,
+		OutHigh:    0xffff,
+		Hysteresis: 200,
+	}
+	now := time.Unix(0, 0)
+	temp := p.temp
+	done := false
+	for i := 0; i < 10000 && !done; i++ {
+		var out int32
+		out, done = tuner.Update(temp, now)
+		temp = p.step(out)
+		now = now.Add(time.Second)
+	}
+	c.Assert(done, qt.IsTrue)
+	c.Assert(tuner.Period() > 0, qt.IsTrue)
+	g := tuner.Gains()
+	c.Assert(g.Kp > 0, qt.IsTrue)
+	c.Assert(g.Ki > 0, qt.IsTrue)
+	c.Assert(g.Kd > 0, qt.IsTrue)
+}
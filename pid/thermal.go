@@ -0,0 +1,92 @@
+package pid
+
+import (
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+// Thermal connects a thermometer to a PWM output driving a heater or cooler
+// through a PID controller, for example to build a reflow plate or a
+// fermentation chamber:
+//
+//	heater := pid.NewThermal(&sensor, machine.PWM{Pin: machine.D9}, gains)
+//	heater.Setpoint = 65000 // 65 °C
+//	for {
+//		heater.Update()
+//		time.Sleep(500 * time.Millisecond)
+//	}
+type Thermal struct {
+	Controller *Controller
+
+	// Setpoint is the target temperature in milli-degrees Celsius.
+	Setpoint int32
+
+	// Cooling reverses the action of the controller, for an output that
+	// cools when on like a Peltier element or a fan.
+	Cooling bool
+
+	sensor drivers.Thermometer
+	out    drivers.PWM
+	last   time.Time
+	temp   int32
+	duty   uint16
+}
+
+// NewThermal returns a new thermal controller. The PWM output is configured
+// and switched off.
+func NewThermal(sensor drivers.Thermometer, out drivers.PWM, gains Gains) *Thermal {
+	out.Configure()
+	out.Set(0)
+	return &Thermal{
+		Controller: New(gains, 0, 0xffff),
+		sensor:     sensor,
+		out:        out,
+	}
+}
+
+// Update reads the temperature, runs one iteration of the controller and
+// updates the output. It should be called at a regular interval. When the
+// sensor cannot be read, the output is switched off for safety and the
+// error is returned.
+func (t *Thermal) Update() error {
+	temp, err := t.sensor.ReadTemperature()
+	if err != nil {
+		t.Off()
+		return err
+	}
+	now := time.Now()
+	dt := now.Sub(t.last)
+	if t.last.IsZero() {
+		dt = 0
+	}
+	t.last = now
+	t.temp = temp
+
+	setpoint, measurement := t.Setpoint, temp
+	if t.Cooling {
+		setpoint, measurement = -setpoint, -measurement
+	}
+	t.duty = uint16(t.Controller.Update(setpoint, measurement, dt))
+	t.out.Set(t.duty)
+	return nil
+}
+
+// Off switches the output off and resets the controller.
+func (t *Thermal) Off() {
+	t.duty = 0
+	t.out.Set(0)
+	t.Controller.Reset()
+	t.last = time.Time{}
+}
+
+// Temperature returns the temperature read by the last call to Update in
+// milli-degrees Celsius.
+func (t *Thermal) Temperature() int32 {
+	return t.temp
+}
+
+// Duty returns the duty cycle currently applied to the output.
+func (t *Thermal) Duty() uint16 {
+	return t.duty
+}
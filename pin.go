@@ -0,0 +1,28 @@
+package drivers
+
+// Pin is a digital pin that can be read and written. It is notably
+// implemented by the machine.Pin type, and by the pins of I/O expanders,
+// so that drivers using it also work with pins on an expander.
+//
+// The pin must already be configured as an input or output.
+type Pin interface {
+	Get() bool
+	Set(high bool)
+	High()
+	Low()
+}
+
+// IOPin is a pin whose direction can be changed while it is used, as needed
+// by the bidirectional single-wire protocols of sensors such as the DHT22.
+// The drivers using it accept pins of I/O expanders and software pins in
+// tests as well as the pins of the microcontroller.
+type IOPin interface {
+	Pin
+
+	// SetOutput makes the pin drive its line.
+	SetOutput()
+
+	// SetInput releases the line to read it, leaving it high when it has a
+	// pull-up resistor.
+	SetInput()
+}
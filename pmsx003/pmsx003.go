@@ -0,0 +1,219 @@
+// Package pmsx003 provides a driver for the PMS5003 and PMS7003 particulate
+// matter sensors by Plantower, over their serial port.
+//
+// The sensors send a measurement every second or so in their default active
+// mode. In passive mode, they only measure when asked to, and they can sleep
+// with their fan stopped between two measurements to save power: the fan
+// must then run for 30 seconds before the measurements are stable.
+//
+// Datasheets:
+// https://www.aqmd.gov/docs/default-source/aq-spec/resources-page/plantower-pms5003-manual_v2-3.pdf
+// https://download.kamami.pl/p564008-PMS7003%20series%20data%20manua_English_V2.5.pdf
+//
+package pmsx003 // import "tinygo.org/x/drivers/pmsx003"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	errChecksum = errors.New("pmsx003: checksum mismatch")
+	errTimeout  = errors.New("pmsx003: timeout waiting for a measurement")
+)
+
+var _ drivers.PowerManaged = &Device{}
+
+// Concentrations are the mass concentrations in µg/m³ of the particles
+// smaller than 1.0µm, 2.5µm and 10µm.
+type Concentrations struct {
+	PM1_0 uint16
+	PM2_5 uint16
+	PM10  uint16
+}
+
+// Measurement is a measurement of the sensor.
+type Measurement struct {
+	// Standard are the concentrations with the calibration of the standard
+	// particle (CF=1), meant for factory environments.
+	Standard Concentrations
+
+	// Atmospheric are the concentrations under atmospheric environment, to
+	// measure the air quality.
+	Atmospheric Concentrations
+
+	// Particles are the numbers of particles in 0.1L of air larger than
+	// 0.3µm, 0.5µm, 1.0µm, 2.5µm, 5.0µm and 10µm.
+	Particles [6]uint16
+}
+
+// Device wraps a serial connection to a PMS5003 or PMS7003 device.
+type Device struct {
+	uart drivers.UART
+
+	// Timeout is the time to wait for a measurement, 3s by default. The
+	// sensor sends one every 2.3s at most in active mode.
+	Timeout time.Duration
+
+	passive bool
+
+	buf [32]byte
+}
+
+// New creates a new PMSx003 connection. The UART must already be configured
+// at BaudRate.
+//
+// This function only creates the Device object, it does not touch the
+// device, which starts in active mode.
+func New(uart drivers.UART) Device {
+	return Device{
+		uart:    uart,
+		Timeout: 3 * time.Second,
+	}
+}
+
+// SetPassive selects the passive mode, in which the sensor only measures
+// when Read asks it to, or the active mode, in which it sends its
+// measurements continuously.
+func (d *Device) SetPassive(passive bool) error {
+	var active byte = 1
+	if passive {
+		active = 0
+	}
+	if err := d.command(CMD_MODE, active); err != nil {
+		return err
+	}
+	d.passive = passive
+	return nil
+}
+
+// Sleep stops the fan and the laser of the sensor until Wake.
+func (d *Device) Sleep() error {
+	return d.command(CMD_SLEEP, 0)
+}
+
+// Wake wakes the sensor up from Sleep. The measurements are stable after 30
+// seconds.
+func (d *Device) Wake() error {
+	return d.command(CMD_SLEEP, 1)
+}
+
+// Reset wakes the sensor up and selects the active mode, as at power on.
+func (d *Device) Reset() error {
+	if err := d.Wake(); err != nil {
+		return err
+	}
+	return d.SetPassive(false)
+}
+
+// SupplyCurrent returns the largest currents of the datasheet: 200µA in
+// standby and 100mA while measuring.
+func (d *Device) SupplyCurrent() (sleeping, active uint32) {
+	return 200, 100000
+}
+
+// Read waits for the next measurement of the sensor, which it asks for in
+// passive mode, and returns it.
+func (d *Device) Read() (m Measurement, err error) {
+	if err = d.discard(); err != nil {
+		return
+	}
+	if d.passive {
+		if err = d.command(CMD_READ, 0); err != nil {
+			return
+		}
+	}
+	if err = d.frame(); err != nil {
+		return
+	}
+	m.Standard = Concentrations{d.word(0), d.word(1), d.word(2)}
+	m.Atmospheric = Concentrations{d.word(3), d.word(4), d.word(5)}
+	for i := range m.Particles {
+		m.Particles[i] = d.word(6 + i)
+	}
+	return m, nil
+}
+
+// word returns a word of the data of the frame, which follows its start and
+// its length.
+func (d *Device) word(i int) uint16 {
+	return uint16(d.buf[4+2*i])<<8 | uint16(d.buf[5+2*i])
+}
+
+// command sends a command with its data.
+func (d *Device) command(cmd, data byte) error {
+	frame := d.buf[:7]
+	frame[0] = START_1
+	frame[1] = START_2
+	frame[2] = cmd
+	frame[3] = 0
+	frame[4] = data
+	sum := checksum(frame[:5])
+	frame[5] = byte(sum >> 8)
+	frame[6] = byte(sum)
+	_, err := d.uart.Write(frame)
+	return err
+}
+
+// discard discards the received bytes, such as the answers to the commands
+// or an older measurement.
+func (d *Device) discard() error {
+	for d.uart.Buffered() > 0 {
+		if _, err := d.uart.ReadByte(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// frame reads the next measurement frame into the buffer and checks its
+// checksum. It skips the bytes before its start and the shorter frames
+// answering the commands.
+func (d *Device) frame() error {
+	start := time.Now()
+	n := 0
+	for n < len(d.buf) {
+		if d.uart.Buffered() == 0 {
+			if time.Since(start) > d.Timeout {
+				return errTimeout
+			}
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		b, err := d.uart.ReadByte()
+		if err != nil {
+			return err
+		}
+		d.buf[n] = b
+		n++
+		switch {
+		case n == 1 && b != START_1:
+			n = 0
+		case n == 2 && b != START_2:
+			n = 0
+			if b == START_1 {
+				d.buf[0] = b
+				n = 1
+			}
+		case n == 4 && (d.buf[2] != 0 || d.buf[3] != 28):
+			// not a measurement: the length of its content is another one
+			n = 0
+		}
+	}
+	sum := checksum(d.buf[:30])
+	if d.buf[30] != byte(sum>>8) || d.buf[31] != byte(sum) {
+		return errChecksum
+	}
+	return nil
+}
+
+// checksum returns the sum of the bytes of a frame.
+func checksum(data []byte) uint16 {
+	var sum uint16
+	for _, b := range data {
+		sum += uint16(b)
+	}
+	return sum
+}
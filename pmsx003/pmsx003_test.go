@@ -0,0 +1,140 @@
+package pmsx003
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// sensor is a simulated PMS5003, which sends a measurement in active mode
+// once the driver has seen that nothing was received.
+type sensor struct {
+	frames  [][]byte
+	rx      []byte
+	passive bool
+	asleep  bool
+	waited  bool
+
+	// corrupt flips a bit of the next measurement
+	corrupt bool
+}
+
+func (s *sensor) Write(data []byte) (int, error) {
+	s.frames = append(s.frames, append([]byte(nil), data...))
+	switch data[2] {
+	case CMD_MODE:
+		s.passive = data[4] == 0
+		s.answer(CMD_MODE, data[4])
+	case CMD_SLEEP:
+		s.asleep = data[4] == 0
+		if s.asleep {
+			s.answer(CMD_SLEEP, data[4])
+		}
+	case CMD_READ:
+		if s.passive && !s.asleep {
+			s.measure()
+		}
+	}
+	return len(data), nil
+}
+
+// answer queues the answer to a command, which the driver must skip.
+func (s *sensor) answer(cmd, data byte) {
+	frame := []byte{START_1, START_2, 0x00, 0x04, cmd, data}
+	sum := checksum(frame)
+	s.rx = append(s.rx, append(frame, byte(sum>>8), byte(sum))...)
+}
+
+func (s *sensor) measure() {
+	frame := []byte{START_1, START_2, 0x00, 28}
+	for _, w := range []uint16{5, 8, 9, 5, 8, 9, 1500, 420, 60, 4, 1, 0, 0x9700} {
+		frame = append(frame, byte(w>>8), byte(w))
+	}
+	sum := checksum(frame)
+	frame = append(frame, byte(sum>>8), byte(sum))
+	if s.corrupt {
+		frame[10] ^= 1
+		s.corrupt = false
+	}
+	s.rx = append(s.rx, frame...)
+}
+
+func (s *sensor) Buffered() int {
+	if len(s.rx) == 0 && !s.passive && !s.asleep {
+		if s.waited {
+			s.measure()
+		}
+		s.waited = !s.waited
+	}
+	return len(s.rx)
+}
+
+func (s *sensor) ReadByte() (byte, error) {
+	b := s.rx[0]
+	s.rx = s.rx[1:]
+	return b, nil
+}
+
+var measurement = Measurement{
+	Standard:    Concentrations{PM1_0: 5, PM2_5: 8, PM10: 9},
+	Atmospheric: Concentrations{PM1_0: 5, PM2_5: 8, PM10: 9},
+	Particles:   [6]uint16{1500, 420, 60, 4, 1, 0},
+}
+
+func TestActive(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{}
+	d := New(s)
+	m, err := d.Read()
+	c.Assert(err, qt.IsNil)
+	c.Assert(m, qt.Equals, measurement)
+	c.Assert(s.frames, qt.HasLen, 0)
+
+	// garbage before the frame
+	s.rx = []byte{0x00, START_1, START_1, 0x12}
+	s.passive = true
+	d.Timeout = 10 * time.Millisecond
+	_, err = d.Read()
+	c.Assert(err, qt.Equals, errTimeout)
+	s.passive = false
+
+	s.corrupt = true
+	_, err = d.Read()
+	c.Assert(err, qt.Equals, errChecksum)
+}
+
+func TestPassive(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{}
+	d := New(s)
+	c.Assert(d.SetPassive(true), qt.IsNil)
+	c.Assert(s.passive, qt.IsTrue)
+	// the answer to the mode command is discarded
+	m, err := d.Read()
+	c.Assert(err, qt.IsNil)
+	c.Assert(m, qt.Equals, measurement)
+
+	// an answer received before the measurement is skipped
+	s.answer(CMD_MODE, 0)
+	s.measure()
+	c.Assert(d.frame(), qt.IsNil)
+	c.Assert(d.word(1), qt.Equals, uint16(8))
+
+	c.Assert(d.Sleep(), qt.IsNil)
+	c.Assert(s.asleep, qt.IsTrue)
+	d.Timeout = 10 * time.Millisecond
+	_, err = d.Read()
+	c.Assert(err, qt.Equals, errTimeout)
+	c.Assert(d.Wake(), qt.IsNil)
+	c.Assert(s.asleep, qt.IsFalse)
+
+	// frames of the datasheet
+	c.Assert(s.frames, qt.DeepEquals, [][]byte{
+		{0x42, 0x4D, 0xE1, 0x00, 0x00, 0x01, 0x70},
+		{0x42, 0x4D, 0xE2, 0x00, 0x00, 0x01, 0x71},
+		{0x42, 0x4D, 0xE4, 0x00, 0x00, 0x01, 0x73},
+		{0x42, 0x4D, 0xE2, 0x00, 0x00, 0x01, 0x71},
+		{0x42, 0x4D, 0xE4, 0x00, 0x01, 0x01, 0x74},
+	})
+}
@@ -0,0 +1,17 @@
+package pmsx003
+
+// Commands. Names copied from the datasheet.
+const (
+	CMD_READ  = 0xE2 // read a measurement in passive mode
+	CMD_MODE  = 0xE1 // 0 for the passive mode, 1 for the active mode
+	CMD_SLEEP = 0xE4 // 0 to sleep, 1 to wake up
+)
+
+// The start of the frames.
+const (
+	START_1 = 0x42
+	START_2 = 0x4D
+)
+
+// The baud rate of the serial port.
+const BaudRate = 9600
@@ -0,0 +1,10 @@
+package pmsx003
+
+import "tinygo.org/x/drivers/registry"
+
+func init() {
+	registry.Register(registry.Driver{
+		Name: "pmsx003",
+		Bus:  registry.UART,
+	})
+}
@@ -0,0 +1,24 @@
+package drivers
+
+// PowerManaged is a device whose power consumption can be lowered while it is
+// not used, so that a board can put all its peripherals to sleep before the
+// microcontroller enters a deep sleep.
+//
+// It is implemented by ccs811, dht.PoweredDevice, pmsx003, sgp30 and sht4x.
+type PowerManaged interface {
+	// Sleep puts the device in its lowest power state. The configuration
+	// may be lost, depending on the device.
+	Sleep() error
+
+	// Wake brings the device back from Sleep. The device may need some time
+	// before its first measurement, which its driver then waits for.
+	Wake() error
+
+	// Reset puts the device back in its power-on state.
+	Reset() error
+
+	// SupplyCurrent returns the typical current drawn by the device while
+	// it sleeps and while it is active, in microamperes, to estimate the
+	// power budget of a board.
+	SupplyCurrent() (sleeping, active uint32)
+}
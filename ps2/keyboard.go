@@ -0,0 +1,475 @@
+// Package ps2 provides a driver for PS/2 keyboards.
+//
+// The keyboard drives the clock line and the driver reads the data line in
+// the falling edge interrupt of the clock pin, so both lines only need pull-
+// up resistors, which are usually present on the keyboard. Many keyboards
+// need 5V; the lines then need level shifters on 3.3V boards.
+//
+// Scan codes of scan code set 2, the default of all keyboards, are decoded
+// into key events with key codes, modifier state and characters for the US
+// layout.
+//
+// Protocol description:
+// https://www.burtonsys.com/ps2_chapweske.htm
+package ps2 // import "tinygo.org/x/drivers/ps2"
+
+import (
+	"errors"
+	"math/bits"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	errTimeout = errors.New("ps2: keyboard did not respond")
+	errResend  = errors.New("ps2: command rejected by keyboard")
+)
+
+// Commands and responses.
+const (
+	cmdSetLEDs = 0xED
+	cmdEcho    = 0xEE
+	cmdReset   = 0xFF
+	respAck    = 0xFA
+	respResend = 0xFE
+)
+
+// Modifiers is the state of the modifier keys and the lock keys.
+type Modifiers uint16
+
+// Modifier and lock bits.
+const (
+	ModLeftShift Modifiers = 1 << iota
+	ModRightShift
+	ModLeftCtrl
+	ModRightCtrl
+	ModLeftAlt
+	ModRightAlt
+	ModLeftGUI
+	ModRightGUI
+	ModCapsLock
+	ModNumLock
+	ModScrollLock
+
+	ModShift = ModLeftShift | ModRightShift
+	ModCtrl  = ModLeftCtrl | ModRightCtrl
+	ModAlt   = ModLeftAlt | ModRightAlt
+	ModGUI   = ModLeftGUI | ModRightGUI
+)
+
+// LEDs of the keyboard, for SetLEDs.
+const (
+	LEDScrollLock = 1 << iota
+	LEDNumLock
+	LEDCapsLock
+)
+
+// Event is a key being pressed, repeated or released.
+type Event struct {
+	Key Key
+
+	// Pressed is false when the key was released. Repeat is set for the
+	// repeated make codes the keyboard sends while a key is held.
+	Pressed bool
+	Repeat  bool
+
+	// Modifiers contains the state after the event.
+	Modifiers Modifiers
+
+	// Char is the character of the key in the US layout, taking the shift,
+	// control and lock state into account, or 0 for keys without a
+	// character and for key releases.
+	Char byte
+}
+
+// Device is a PS/2 keyboard.
+type Device struct {
+	clock, data drivers.IOPin
+	now         func() time.Time
+
+	// written by the interrupt
+	buf        [16]byte
+	head, tail uint8
+	overflow   bool
+	bit        uint8
+	frame      uint16
+	lastEdge   time.Time
+	sending    bool
+	armed      bool
+	out        uint16
+	acked      bool
+	resend     bool
+
+	// decoder state
+	extended  bool
+	breakCode bool
+	pause     uint8
+	mods      Modifiers
+	down      [64]uint8 // bitmap of held keys
+	events    [16]Event
+	first, n  int
+}
+
+// Configure sets up the pins and starts receiving from the keyboard.
+func (d *Device) Configure() error {
+	d.release(d.clock)
+	d.release(d.data)
+	return d.configureInterrupt()
+}
+
+// Reset resets the keyboard, which runs its self test and turns off the
+// LEDs. The lock state of the driver is cleared.
+func (d *Device) Reset() error {
+	d.mods = 0
+	return d.write(cmdReset)
+}
+
+// Echo checks whether a keyboard is connected.
+func (d *Device) Echo() error {
+	return d.write(cmdEcho)
+}
+
+// SetLEDs sets the keyboard LEDs, a combination of LEDScrollLock,
+// LEDNumLock and LEDCapsLock. Update sets them when a lock key is pressed,
+// so this is only needed to use them for something else.
+func (d *Device) SetLEDs(leds uint8) error {
+	if err := d.write(cmdSetLEDs); err != nil {
+		return err
+	}
+	return d.write(leds & 0x07)
+}
+
+// Modifiers returns the current state of the modifier and lock keys.
+func (d *Device) Modifiers() Modifiers {
+	return d.mods
+}
+
+// Overflow returns whether scan codes were lost because Update was not
+// called often enough, and clears the flag.
+func (d *Device) Overflow() bool {
+	o := d.overflow
+	d.overflow = false
+	return o
+}
+
+// Update decodes the received scan codes into events, which are read with
+// Next.
+func (d *Device) Update() {
+	for d.tail != d.head {
+		b := d.buf[d.tail%uint8(len(d.buf))]
+		d.tail++
+		d.decode(b)
+	}
+}
+
+// Next returns the oldest decoded event, or false if there are none. It
+// calls Update first.
+func (d *Device) Next() (Event, bool) {
+	d.Update()
+	if d.n == 0 {
+		return Event{}, false
+	}
+	e := d.events[d.first]
+	d.first = (d.first + 1) % len(d.events)
+	d.n--
+	return e, true
+}
+
+// decode handles one byte of scan code set 2.
+func (d *Device) decode(b byte) {
+	if d.pause > 0 {
+		// the pause key sends E1 14 77 E1 F0 14 F0 77
+		d.pause--
+		if d.pause == 0 {
+			d.push(Event{Key: KeyPause, Pressed: true, Modifiers: d.mods})
+		}
+		return
+	}
+	switch {
+	case b == 0xE0:
+		d.extended = true
+		return
+	case b == 0xE1:
+		d.pause = 7
+		return
+	case b == 0xF0:
+		d.breakCode = true
+		return
+	case b > 0x83:
+		// responses and errors
+		d.extended, d.breakCode = false, false
+		return
+	}
+
+	key := Key(b)
+	if d.extended {
+		key |= 0xE000
+	}
+	pressed := !d.breakCode
+	d.extended, d.breakCode = false, false
+	if key == 0xE012 || key == 0xE059 {
+		// fake shifts sent around some extended keys
+		return
+	}
+
+	i := uint16(key&0xFF) | uint16(key>>15)<<8
+	held := d.down[i/8]&(1<<(i%8)) != 0
+	if pressed {
+		d.down[i/8] |= 1 << (i % 8)
+	} else {
+		d.down[i/8] &^= 1 << (i % 8)
+	}
+
+	if mod := modifier(key); mod != 0 {
+		if pressed {
+			d.mods |= mod
+		} else {
+			d.mods &^= mod
+		}
+	} else if lock := lockKey(key); lock != 0 && pressed && !held {
+		d.mods ^= lock
+		// ignore errors, there is no one to report them to
+		d.SetLEDs(d.leds())
+	}
+
+	e := Event{Key: key, Pressed: pressed, Repeat: pressed && held, Modifiers: d.mods}
+	if pressed {
+		e.Char = d.char(key)
+	}
+	d.push(e)
+}
+
+func (d *Device) push(e Event) {
+	if d.n == len(d.events) {
+		d.overflow = true
+		return
+	}
+	d.events[(d.first+d.n)%len(d.events)] = e
+	d.n++
+}
+
+// char returns the character of a key in the current modifier state.
+func (d *Device) char(key Key) byte {
+	if key == KeyPadEnter {
+		return '\n'
+	}
+	if key == KeyPadSlash {
+		return '/'
+	}
+	if key == KeyDelete {
+		return 0x7F
+	}
+	if key > 0xFF {
+		return 0
+	}
+	if c, ok := keypadOperators[uint8(key)]; ok {
+		return c
+	}
+	if c, ok := keypad[uint8(key)]; ok {
+		if d.mods&ModNumLock != 0 {
+			return c
+		}
+		return 0
+	}
+	chars, ok := ascii[uint8(key)]
+	if !ok {
+		return 0
+	}
+	shift := d.mods&ModShift != 0
+	letter := chars[0] >= 'a' && chars[0] <= 'z'
+	if letter && d.mods&ModCapsLock != 0 {
+		shift = !shift
+	}
+	c := chars[0]
+	if shift {
+		c = chars[1]
+	}
+	if letter && d.mods&ModCtrl != 0 {
+		c &= 0x1F
+	}
+	return c
+}
+
+func (d *Device) leds() uint8 {
+	var leds uint8
+	if d.mods&ModScrollLock != 0 {
+		leds |= LEDScrollLock
+	}
+	if d.mods&ModNumLock != 0 {
+		leds |= LEDNumLock
+	}
+	if d.mods&ModCapsLock != 0 {
+		leds |= LEDCapsLock
+	}
+	return leds
+}
+
+func modifier(key Key) Modifiers {
+	switch key {
+	case KeyLeftShift:
+		return ModLeftShift
+	case KeyRightShift:
+		return ModRightShift
+	case KeyLeftCtrl:
+		return ModLeftCtrl
+	case KeyRightCtrl:
+		return ModRightCtrl
+	case KeyLeftAlt:
+		return ModLeftAlt
+	case KeyRightAlt:
+		return ModRightAlt
+	case KeyLeftGUI:
+		return ModLeftGUI
+	case KeyRightGUI:
+		return ModRightGUI
+	}
+	return 0
+}
+
+func lockKey(key Key) Modifiers {
+	switch key {
+	case KeyCapsLock:
+		return ModCapsLock
+	case KeyNumLock:
+		return ModNumLock
+	case KeyScrollLock:
+		return ModScrollLock
+	}
+	return 0
+}
+
+// interrupt is called on every falling edge of the clock.
+func (d *Device) interrupt() {
+	if d.sending {
+		d.sendBit()
+		return
+	}
+	// a gap in the clock means a new frame, which resynchronizes after
+	// noise
+	now := d.now()
+	if now.Sub(d.lastEdge) > 2*time.Millisecond {
+		d.bit = 0
+	}
+	d.lastEdge = now
+
+	v := d.data.Get()
+	switch {
+	case d.bit == 0:
+		if v {
+			return // not a start bit
+		}
+		d.frame = 0
+	case d.bit <= 9:
+		if v {
+			d.frame |= 1 << (d.bit - 1)
+		}
+	default:
+		d.bit = 0
+		b := uint8(d.frame)
+		if !v || bits.OnesCount16(d.frame)%2 != 1 {
+			return // framing or parity error
+		}
+		d.received(b)
+		return
+	}
+	d.bit++
+}
+
+func (d *Device) received(b byte) {
+	switch b {
+	case respAck, cmdEcho:
+		// the echo command is answered with an echo instead of an ack
+		d.acked = true
+		return
+	case respResend:
+		d.resend = true
+		return
+	}
+	if d.head-d.tail == uint8(len(d.buf)) {
+		d.overflow = true
+		return
+	}
+	d.buf[d.head%uint8(len(d.buf))] = b
+	d.head++
+}
+
+// sendBit outputs the next bit of a host to device frame.
+func (d *Device) sendBit() {
+	if !d.armed {
+		return // the edge caused by pulling the clock low
+	}
+	switch {
+	case d.bit < 9:
+		// data bits and parity
+		if d.out&(1<<d.bit) != 0 {
+			d.release(d.data)
+		} else {
+			d.pullLow(d.data)
+		}
+	case d.bit == 9:
+		d.release(d.data) // stop bit
+	default:
+		// the keyboard pulls data low to acknowledge the frame
+		d.sending, d.armed = false, false
+		d.bit = 0
+		return
+	}
+	d.bit++
+}
+
+// write sends one byte to the keyboard and waits for the acknowledgement.
+func (d *Device) write(b byte) error {
+	for try := 0; try < 3; try++ {
+		d.request(b)
+
+		deadline := time.Now().Add(20 * time.Millisecond)
+		for d.sending {
+			if time.Now().After(deadline) {
+				d.sending, d.armed = false, false
+				d.release(d.data)
+				return errTimeout
+			}
+		}
+
+		for {
+			if d.acked {
+				return nil
+			}
+			if d.resend {
+				break
+			}
+			if time.Now().After(deadline) {
+				return errTimeout
+			}
+		}
+	}
+	return errResend
+}
+
+// request starts a host to device frame, which the keyboard clocks once
+// the clock line is released.
+func (d *Device) request(b byte) {
+	parity := uint16(bits.OnesCount8(b)+1) & 1
+	d.out = uint16(b) | parity<<8
+	d.acked, d.resend = false, false
+	d.bit = 0
+
+	// request to send: inhibit the clock, then pull data low
+	d.sending = true
+	d.pullLow(d.clock)
+	time.Sleep(100 * time.Microsecond)
+	d.pullLow(d.data)
+	d.armed = true
+	d.release(d.clock)
+}
+
+func (d *Device) pullLow(pin drivers.IOPin) {
+	pin.SetOutput()
+	pin.Low()
+}
+
+// release releases an open drain line, which is pulled high.
+func (d *Device) release(pin drivers.IOPin) {
+	pin.SetInput()
+}
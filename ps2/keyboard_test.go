@@ -0,0 +1,223 @@
+package ps2
+
+import (
+	"math/bits"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// line is an open drain line with a pull-up resistor. The keyboard sets
+// its level while the host does not drive it.
+type line struct {
+	output bool
+	level  bool
+}
+
+func (l *line) Get() bool     { return l.level }
+func (l *line) Set(high bool) { l.level = high }
+func (l *line) High()         { l.level = true }
+func (l *line) Low()          { l.level = false }
+func (l *line) SetOutput()    { l.output = true }
+
+func (l *line) SetInput() {
+	l.output = false
+	l.level = true
+}
+
+// keyboard simulates the clock edges of a keyboard.
+type keyboard struct {
+	c           *qt.C
+	d           *Device
+	clock, data *line
+	now         time.Time
+}
+
+func newKeyboard(c *qt.C) *keyboard {
+	k := &keyboard{c: c, clock: &line{}, data: &line{}, now: time.Unix(0, 0)}
+	k.d = &Device{clock: k.clock, data: k.data, now: func() time.Time { return k.now }}
+	c.Assert(k.d.Configure(), qt.IsNil)
+	return k
+}
+
+// edge sets the data line and makes a falling edge of the clock, one clock
+// period of 80µs after the previous one.
+func (k *keyboard) edge(v bool) {
+	k.now = k.now.Add(80 * time.Microsecond)
+	k.data.level = v
+	k.d.interrupt()
+}
+
+// frame sends a device to host frame: a start bit, the data bits from the
+// least significant, the parity bit and the stop bit.
+func (k *keyboard) frame(b byte, parity, stop bool) {
+	k.edge(false)
+	for i := uint(0); i < 8; i++ {
+		k.edge(b&(1<<i) != 0)
+	}
+	k.edge(parity)
+	k.edge(stop)
+}
+
+// send sends valid frames with odd parity.
+func (k *keyboard) send(codes ...byte) {
+	for _, b := range codes {
+		k.frame(b, bits.OnesCount8(b)%2 == 0, true)
+	}
+}
+
+func (k *keyboard) events() []Event {
+	var events []Event
+	for {
+		e, ok := k.d.Next()
+		if !ok {
+			return events
+		}
+		events = append(events, e)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	c := qt.New(t)
+	k := newKeyboard(c)
+	c.Assert(k.clock.output, qt.IsFalse)
+	c.Assert(k.data.output, qt.IsFalse)
+
+	k.send(0x1C)
+	c.Assert(k.events(), qt.DeepEquals, []Event{{Key: 0x1C, Pressed: true, Char: 'a'}})
+
+	// frames with a parity or framing error are dropped: 0x1C has three
+	// ones, so its parity bit is 0
+	k.frame(0x1C, true, true)
+	k.frame(0x1B, true, false)
+	c.Assert(k.events(), qt.HasLen, 0)
+
+	// a high level is not a start bit
+	k.edge(true)
+	k.send(0x1B)
+	c.Assert(k.events(), qt.DeepEquals, []Event{{Key: 0x1B, Pressed: true, Char: 's'}})
+
+	// a gap in the clock restarts an interrupted frame
+	k.edge(false)
+	k.edge(true)
+	k.edge(false)
+	k.now = k.now.Add(3 * time.Millisecond)
+	k.send(0x23)
+	c.Assert(k.events(), qt.DeepEquals, []Event{{Key: 0x23, Pressed: true, Char: 'd'}})
+
+	// responses are not scan codes
+	k.send(respAck)
+	c.Assert(k.d.acked, qt.IsTrue)
+	k.send(respResend)
+	c.Assert(k.d.resend, qt.IsTrue)
+	c.Assert(k.events(), qt.HasLen, 0)
+}
+
+func TestOverflow(t *testing.T) {
+	c := qt.New(t)
+	k := newKeyboard(c)
+	for i := 0; i < 17; i++ {
+		k.send(0x1C)
+	}
+	c.Assert(k.d.Overflow(), qt.IsTrue)
+	c.Assert(k.d.Overflow(), qt.IsFalse)
+	c.Assert(k.events(), qt.HasLen, 16)
+}
+
+func TestDecode(t *testing.T) {
+	c := qt.New(t)
+	k := newKeyboard(c)
+
+	k.send(0x12, 0x1C, 0x1C, 0xF0, 0x1C, 0xF0, 0x12)
+	c.Assert(k.events(), qt.DeepEquals, []Event{
+		{Key: KeyLeftShift, Pressed: true, Modifiers: ModLeftShift},
+		{Key: 0x1C, Pressed: true, Modifiers: ModLeftShift, Char: 'A'},
+		{Key: 0x1C, Pressed: true, Repeat: true, Modifiers: ModLeftShift, Char: 'A'},
+		{Key: 0x1C, Modifiers: ModLeftShift},
+		{Key: KeyLeftShift},
+	})
+
+	// extended keys, with the fake shift some keyboards send around them
+	k.send(0xE0, 0x12, 0xE0, 0x75, 0xE0, 0xF0, 0x75, 0xE0, 0xF0, 0x12)
+	c.Assert(k.events(), qt.DeepEquals, []Event{
+		{Key: KeyUp, Pressed: true},
+		{Key: KeyUp},
+	})
+
+	k.send(0xE0, 0x14, 0x21, 0xF0, 0x21, 0xE0, 0xF0, 0x14)
+	c.Assert(k.events(), qt.DeepEquals, []Event{
+		{Key: KeyRightCtrl, Pressed: true, Modifiers: ModRightCtrl},
+		{Key: 0x21, Pressed: true, Modifiers: ModRightCtrl, Char: 0x03},
+		{Key: 0x21, Modifiers: ModRightCtrl},
+		{Key: KeyRightCtrl},
+	})
+
+	k.send(0xE1, 0x14, 0x77, 0xE1, 0xF0, 0x14, 0xF0, 0x77)
+	c.Assert(k.events(), qt.DeepEquals, []Event{{Key: KeyPause, Pressed: true}})
+
+	// the keypad only types digits with num lock on
+	k.send(0x69, 0xF0, 0x69, 0x7C)
+	c.Assert(k.events(), qt.DeepEquals, []Event{
+		{Key: 0x69, Pressed: true},
+		{Key: 0x69},
+		{Key: 0x7C, Pressed: true, Char: '*'},
+	})
+}
+
+func TestLocks(t *testing.T) {
+	c := qt.New(t)
+	k := newKeyboard(c)
+
+	// the LEDs are updated when a lock key is pressed, which times out
+	// without a keyboard to clock the command out
+	k.send(0x58, 0x58, 0xF0, 0x58)
+	c.Assert(k.events(), qt.HasLen, 3)
+	c.Assert(k.d.Modifiers(), qt.Equals, ModCapsLock)
+	c.Assert(k.d.out&0xFF, qt.Equals, uint16(cmdSetLEDs))
+
+	k.send(0x1C, 0x12, 0x1C, 0x16)
+	events := k.events()
+	c.Assert(events, qt.HasLen, 4)
+	c.Assert(events[0].Char, qt.Equals, byte('A'))
+	c.Assert(events[2].Char, qt.Equals, byte('a'))
+	c.Assert(events[3].Char, qt.Equals, byte('!'))
+	c.Assert(k.d.leds(), qt.Equals, uint8(LEDCapsLock))
+
+	k.send(0x77, 0xF0, 0x77, 0x69)
+	events = k.events()
+	c.Assert(events[2].Char, qt.Equals, byte('1'))
+	c.Assert(k.d.leds(), qt.Equals, uint8(LEDCapsLock|LEDNumLock))
+}
+
+func TestRequest(t *testing.T) {
+	c := qt.New(t)
+	k := newKeyboard(c)
+
+	// the host pulls data low as the start bit and releases the clock, and
+	// the keyboard then clocks in the data bits, the parity and the stop
+	// bit
+	k.d.request(cmdSetLEDs)
+	c.Assert(k.clock.output, qt.IsFalse)
+	c.Assert(k.data.output, qt.IsTrue)
+	c.Assert(k.data.level, qt.IsFalse)
+
+	var sent []bool
+	for i := 0; i < 10; i++ {
+		k.d.interrupt()
+		sent = append(sent, k.data.level)
+	}
+	c.Assert(sent, qt.DeepEquals, []bool{
+		true, false, true, true, false, true, true, true, // 0xED
+		true, // odd parity
+		true, // stop bit
+	})
+	c.Assert(k.data.output, qt.IsFalse)
+	c.Assert(k.d.sending, qt.IsTrue)
+
+	// the next edge is the acknowledgement by the keyboard
+	k.d.interrupt()
+	c.Assert(k.d.sending, qt.IsFalse)
+	k.send(respAck)
+	c.Assert(k.d.acked, qt.IsTrue)
+}
@@ -0,0 +1,52 @@
+// +build tinygo
+
+package ps2
+
+import (
+	"machine"
+	"time"
+)
+
+// New returns a keyboard on the given clock and data pins.
+func New(clock, data machine.Pin) *Device {
+	return &Device{clock: machinePin(clock), data: machinePin(data), now: time.Now}
+}
+
+// configureInterrupt calls interrupt on the falling edges of the clock.
+func (d *Device) configureInterrupt() error {
+	clock, ok := d.clock.(machinePin)
+	if !ok {
+		return nil
+	}
+	return machine.Pin(clock).SetInterrupt(machine.PinFalling, func(machine.Pin) {
+		d.interrupt()
+	})
+}
+
+// machinePin implements drivers.IOPin. As an input it enables the pull-up
+// resistor, so that the open drain lines are high when released.
+type machinePin machine.Pin
+
+func (p machinePin) Get() bool {
+	return machine.Pin(p).Get()
+}
+
+func (p machinePin) Set(high bool) {
+	machine.Pin(p).Set(high)
+}
+
+func (p machinePin) High() {
+	machine.Pin(p).High()
+}
+
+func (p machinePin) Low() {
+	machine.Pin(p).Low()
+}
+
+func (p machinePin) SetOutput() {
+	machine.Pin(p).Configure(machine.PinConfig{Mode: machine.PinOutput})
+}
+
+func (p machinePin) SetInput() {
+	machine.Pin(p).Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+}
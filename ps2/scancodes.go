@@ -0,0 +1,86 @@
+package ps2
+
+// Key is a key code. Keys with a single byte scan code in scan code set 2
+// use that scan code; extended keys, sent with an 0xE0 prefix, use the
+// scan code with 0xE000 added.
+type Key uint16
+
+// Key codes of keys that do not produce characters, and some others that
+// are useful to match on.
+const (
+	KeyEscape      Key = 0x76
+	KeyBackspace   Key = 0x66
+	KeyTab         Key = 0x0D
+	KeyEnter       Key = 0x5A
+	KeySpace       Key = 0x29
+	KeyLeftShift   Key = 0x12
+	KeyRightShift  Key = 0x59
+	KeyLeftCtrl    Key = 0x14
+	KeyRightCtrl   Key = 0xE014
+	KeyLeftAlt     Key = 0x11
+	KeyRightAlt    Key = 0xE011
+	KeyLeftGUI     Key = 0xE01F
+	KeyRightGUI    Key = 0xE027
+	KeyMenu        Key = 0xE02F
+	KeyCapsLock    Key = 0x58
+	KeyNumLock     Key = 0x77
+	KeyScrollLock  Key = 0x7E
+	KeyF1          Key = 0x05
+	KeyF2          Key = 0x06
+	KeyF3          Key = 0x04
+	KeyF4          Key = 0x0C
+	KeyF5          Key = 0x03
+	KeyF6          Key = 0x0B
+	KeyF7          Key = 0x83
+	KeyF8          Key = 0x0A
+	KeyF9          Key = 0x01
+	KeyF10         Key = 0x09
+	KeyF11         Key = 0x78
+	KeyF12         Key = 0x07
+	KeyUp          Key = 0xE075
+	KeyDown        Key = 0xE072
+	KeyLeft        Key = 0xE06B
+	KeyRight       Key = 0xE074
+	KeyHome        Key = 0xE06C
+	KeyEnd         Key = 0xE069
+	KeyPageUp      Key = 0xE07D
+	KeyPageDown    Key = 0xE07A
+	KeyInsert      Key = 0xE070
+	KeyDelete      Key = 0xE071
+	KeyPrintScreen Key = 0xE07C
+	KeyPadEnter    Key = 0xE05A
+	KeyPadSlash    Key = 0xE04A
+
+	// KeyPause has a scan code sequence of its own and is only reported
+	// as pressed.
+	KeyPause Key = 0xE100
+)
+
+// ascii maps the scan codes of the main keys of a US keyboard to their
+// unshifted and shifted characters.
+var ascii = map[uint8][2]byte{
+	0x0D: {'\t', '\t'}, 0x0E: {'`', '~'}, 0x15: {'q', 'Q'}, 0x16: {'1', '!'},
+	0x1A: {'z', 'Z'}, 0x1B: {'s', 'S'}, 0x1C: {'a', 'A'}, 0x1D: {'w', 'W'},
+	0x1E: {'2', '@'}, 0x21: {'c', 'C'}, 0x22: {'x', 'X'}, 0x23: {'d', 'D'},
+	0x24: {'e', 'E'}, 0x25: {'4', '$'}, 0x26: {'3', '#'}, 0x29: {' ', ' '},
+	0x2A: {'v', 'V'}, 0x2B: {'f', 'F'}, 0x2C: {'t', 'T'}, 0x2D: {'r', 'R'},
+	0x2E: {'5', '%'}, 0x31: {'n', 'N'}, 0x32: {'b', 'B'}, 0x33: {'h', 'H'},
+	0x34: {'g', 'G'}, 0x35: {'y', 'Y'}, 0x36: {'6', '^'}, 0x3A: {'m', 'M'},
+	0x3B: {'j', 'J'}, 0x3C: {'u', 'U'}, 0x3D: {'7', '&'}, 0x3E: {'8', '*'},
+	0x41: {',', '<'}, 0x42: {'k', 'K'}, 0x43: {'i', 'I'}, 0x44: {'o', 'O'},
+	0x45: {'0', ')'}, 0x46: {'9', '('}, 0x49: {'.', '>'}, 0x4A: {'/', '?'},
+	0x4B: {'l', 'L'}, 0x4C: {';', ':'}, 0x4D: {'p', 'P'}, 0x4E: {'-', '_'},
+	0x52: {'\'', '"'}, 0x54: {'[', '{'}, 0x55: {'=', '+'}, 0x5A: {'\n', '\n'},
+	0x5B: {']', '}'}, 0x5D: {'\\', '|'}, 0x66: {'\b', '\b'}, 0x76: {0x1B, 0x1B},
+}
+
+// keypad maps the scan codes of the numeric keypad to their characters
+// with num lock on. The operators do not depend on num lock.
+var keypad = map[uint8]byte{
+	0x70: '0', 0x69: '1', 0x72: '2', 0x7A: '3', 0x6B: '4', 0x73: '5',
+	0x74: '6', 0x6C: '7', 0x75: '8', 0x7D: '9', 0x71: '.',
+}
+
+var keypadOperators = map[uint8]byte{
+	0x7C: '*', 0x7B: '-', 0x79: '+',
+}
@@ -0,0 +1,9 @@
+package drivers
+
+// PWM represents a single PWM output. It is notably implemented by the
+// machine.PWM type, where Set takes a duty cycle from 0 (always low) to
+// 0xffff (always high).
+type PWM interface {
+	Configure()
+	Set(value uint16)
+}
@@ -0,0 +1,185 @@
+// Package registry lists the drivers that registered themselves, with the bus
+// they use and their default addresses, and finds which of the known devices
+// answer on an I2C bus. It is meant for bring-up tools and generic firmware
+// that report the connected hardware.
+//
+// A driver registers itself when its package is imported, so a program only
+// knows about the drivers it imports, if only for their side effect:
+//
+//	import (
+//		_ "tinygo.org/x/drivers/bme280"
+//		_ "tinygo.org/x/drivers/lis3dh"
+//	)
+package registry // import "tinygo.org/x/drivers/registry"
+
+import (
+	"strconv"
+
+	"tinygo.org/x/drivers"
+)
+
+// Bus is the kind of bus that connects a device.
+type Bus uint8
+
+// Buses used by the drivers.
+const (
+	I2C Bus = iota
+	SPI
+	GPIO
+	UART
+)
+
+func (b Bus) String() string {
+	switch b {
+	case I2C:
+		return "I2C"
+	case SPI:
+		return "SPI"
+	case GPIO:
+		return "GPIO"
+	default:
+		return "UART"
+	}
+}
+
+// Driver describes a driver of this repository.
+type Driver struct {
+	// Name is the name of the package of the driver, such as "bme280".
+	Name string
+
+	Bus Bus
+
+	// Addresses are the I2C addresses the device can be configured for,
+	// the default one first.
+	Addresses []uint16
+
+	// Probe returns whether the device answering at the address is one
+	// handled by the driver, usually by checking an identification
+	// register. When it is nil, any device answering at one of the
+	// addresses matches.
+	Probe func(bus drivers.I2C, address uint16) bool
+}
+
+var registered []Driver
+
+// Register adds a driver to the registry. It is called by the init function
+// of the drivers.
+func Register(driver Driver) {
+	registered = append(registered, driver)
+}
+
+// Drivers returns the registered drivers, in the order of registration.
+func Drivers() []Driver {
+	return append([]Driver(nil), registered...)
+}
+
+// Lookup returns the registered driver with the name.
+func Lookup(name string) (Driver, bool) {
+	for _, d := range registered {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return Driver{}, false
+}
+
+// Device is a device found by Scan.
+type Device struct {
+	// Driver is the name of the driver of the device.
+	Driver  string
+	Address uint16
+}
+
+// Scan probes the addresses of the registered I2C drivers on the bus, which
+// must already be configured, and returns the devices that answer. When
+// several drivers share an address, as the bme280 and the bmp280 do, their
+// Probe functions tell which one is connected.
+func Scan(bus drivers.I2C) []Device {
+	var found []Device
+	// answered and probed are bitmaps of the 7-bit addresses
+	var answered, probed [4]uint32
+	buf := []byte{0}
+	for _, d := range registered {
+		if d.Bus != I2C {
+			continue
+		}
+		for _, address := range d.Addresses {
+			if address > 0x7F {
+				continue
+			}
+			bit := uint32(1) << (address % 32)
+			if probed[address/32]&bit == 0 {
+				probed[address/32] |= bit
+				if bus.Tx(address, nil, buf) == nil {
+					answered[address/32] |= bit
+				}
+			}
+			if answered[address/32]&bit == 0 {
+				continue
+			}
+			if d.Probe == nil || d.Probe(bus, address) {
+				found = append(found, Device{Driver: d.Name, Address: address})
+			}
+		}
+	}
+	return found
+}
+
+// Result is an address that answered ScanAll.
+type Result struct {
+	Address uint16
+
+	// Drivers are the names of the registered drivers that identified the
+	// device: usually one, none for a device unknown to the imported
+	// drivers, and several when their Probe functions cannot tell the
+	// devices apart.
+	Drivers []string
+}
+
+// String formats the result for a bring-up tool, such as "0x76 bme280" or
+// "0x3c unknown".
+func (r Result) String() string {
+	s := "0x"
+	if r.Address < 0x10 {
+		s += "0"
+	}
+	s += strconv.FormatUint(uint64(r.Address), 16)
+	if len(r.Drivers) == 0 {
+		return s + " unknown"
+	}
+	for _, name := range r.Drivers {
+		s += " " + name
+	}
+	return s
+}
+
+// ScanAll probes every 7-bit address that is not reserved, from 0x08 to
+// 0x77, and returns those that answer, in order, with the registered drivers
+// that identify the device. Unlike Scan, it also lists the devices of the
+// drivers that are not imported, to check the wiring of a board.
+func ScanAll(bus drivers.I2C) []Result {
+	var results []Result
+	buf := []byte{0}
+	for address := uint16(0x08); address <= 0x77; address++ {
+		if bus.Tx(address, nil, buf) != nil {
+			continue
+		}
+		r := Result{Address: address}
+		for _, d := range registered {
+			if d.Bus == I2C && uses(d, address) && (d.Probe == nil || d.Probe(bus, address)) {
+				r.Drivers = append(r.Drivers, d.Name)
+			}
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+func uses(d Driver, address uint16) bool {
+	for _, a := range d.Addresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers"
+)
+
+// fakeBus answers at the addresses of the map, whose values are the content
+// of the identification register.
+type fakeBus struct {
+	ids   map[uint16]byte
+	reads int
+}
+
+var errNack = errors.New("no acknowledge")
+
+func (b *fakeBus) Tx(addr uint16, w, r []byte) error {
+	b.reads++
+	if _, ok := b.ids[addr]; !ok {
+		return errNack
+	}
+	return nil
+}
+
+func (b *fakeBus) ReadRegister(addr uint8, r uint8, buf []byte) error {
+	id, ok := b.ids[uint16(addr)]
+	if !ok {
+		return errNack
+	}
+	buf[0] = id
+	return nil
+}
+
+func (b *fakeBus) WriteRegister(addr uint8, r uint8, buf []byte) error {
+	return nil
+}
+
+func probeID(id byte) func(bus drivers.I2C, address uint16) bool {
+	return func(bus drivers.I2C, address uint16) bool {
+		buf := []byte{0}
+		return bus.ReadRegister(uint8(address), 0xD0, buf) == nil && buf[0] == id
+	}
+}
+
+func TestScan(t *testing.T) {
+	c := qt.New(t)
+	defer func(saved []Driver) { registered = saved }(registered)
+	registered = nil
+	Register(Driver{Name: "bme280", Bus: I2C, Addresses: []uint16{0x76, 0x77}, Probe: probeID(0x60)})
+	Register(Driver{Name: "bmp280", Bus: I2C, Addresses: []uint16{0x77, 0x76}, Probe: probeID(0x58)})
+	Register(Driver{Name: "ds3231", Bus: I2C, Addresses: []uint16{0x68}})
+	Register(Driver{Name: "dht", Bus: GPIO})
+
+	bus := &fakeBus{ids: map[uint16]byte{0x77: 0x58, 0x68: 0}}
+	c.Assert(Scan(bus), qt.DeepEquals, []Device{
+		{Driver: "bmp280", Address: 0x77},
+		{Driver: "ds3231", Address: 0x68},
+	})
+	// each address is tried once
+	c.Assert(bus.reads, qt.Equals, 3)
+
+	d, ok := Lookup("dht")
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(d.Bus, qt.Equals, GPIO)
+	_, ok = Lookup("unknown")
+	c.Assert(ok, qt.IsFalse)
+	c.Assert(Drivers(), qt.HasLen, 4)
+}
+
+func TestScanAll(t *testing.T) {
+	c := qt.New(t)
+	defer func(saved []Driver) { registered = saved }(registered)
+	registered = nil
+	Register(Driver{Name: "bme280", Bus: I2C, Addresses: []uint16{0x76, 0x77}, Probe: probeID(0x60)})
+	Register(Driver{Name: "bmp280", Bus: I2C, Addresses: []uint16{0x77, 0x76}, Probe: probeID(0x58)})
+	Register(Driver{Name: "ds1307", Bus: I2C, Addresses: []uint16{0x68}})
+	Register(Driver{Name: "ds3231", Bus: I2C, Addresses: []uint16{0x68}})
+
+	// 0x00 is the general call address, which is not probed
+	bus := &fakeBus{ids: map[uint16]byte{0x00: 0, 0x3C: 0, 0x68: 0, 0x76: 0x60}}
+	results := ScanAll(bus)
+	c.Assert(results, qt.DeepEquals, []Result{
+		{Address: 0x3C},
+		{Address: 0x68, Drivers: []string{"ds1307", "ds3231"}},
+		{Address: 0x76, Drivers: []string{"bme280"}},
+	})
+	c.Assert(bus.reads, qt.Equals, 0x70)
+	c.Assert(results[0].String(), qt.Equals, "0x3c unknown")
+	c.Assert(results[1].String(), qt.Equals, "0x68 ds1307 ds3231")
+	c.Assert(Result{Address: 0x0E}.String(), qt.Equals, "0x0e unknown")
+}
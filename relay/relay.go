@@ -0,0 +1,228 @@
+// Package relay provides a driver for relay modules driven from a GPIO pin.
+//
+// Besides simple on/off switching it protects the load and the relay
+// contacts: minimum on and off dwell times prevent chatter, relays can be
+// put in a Group so that at most one of them is on at a time (an interlock,
+// for example between the heating and cooling stage of an HVAC controller),
+// and a maximum on time turns a relay off automatically when the controlling
+// firmware stops calling Kick.
+package relay // import "tinygo.org/x/drivers/relay"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	// ErrDwell is returned when a relay is switched again before its minimum
+	// on or off time has passed.
+	ErrDwell = errors.New("relay: minimum dwell time not elapsed")
+
+	// ErrInterlocked is returned when a relay cannot be switched on because
+	// another relay of its group is on.
+	ErrInterlocked = errors.New("relay: another relay of the group is on")
+)
+
+// Device is a single relay connected to a GPIO pin, or to a pin of an I/O
+// expander.
+type Device struct {
+	pin       drivers.Pin
+	activeLow bool
+	minOn     time.Duration
+	minOff    time.Duration
+	maxOn     time.Duration
+
+	on         bool
+	lastChange time.Time
+	lastKick   time.Time
+	group      *Group
+	now        func() time.Time
+}
+
+// Config contains the settings used by Configure. All durations are
+// optional, zero disables the corresponding check.
+type Config struct {
+	// ActiveLow must be set for relay boards that switch on when the input
+	// is pulled low, which is the case for most optocoupled modules.
+	ActiveLow bool
+
+	// MinOnTime is the minimum time a relay stays on before Off succeeds.
+	MinOnTime time.Duration
+
+	// MinOffTime is the minimum time a relay stays off before On succeeds,
+	// for example to protect a compressor.
+	MinOffTime time.Duration
+
+	// MaxOnTime is the watchdog timeout. When a relay has been on for longer
+	// than MaxOnTime since the last call to On or Kick, Update switches it
+	// off.
+	MaxOnTime time.Duration
+}
+
+// New returns a new relay driver given the pin it is connected to. A
+// machine.Pin is configured as an output by Configure, other pins must
+// already be configured.
+func New(pin drivers.Pin) Device {
+	return Device{
+		pin: pin,
+		now: time.Now,
+	}
+}
+
+// Configure configures a machine.Pin and switches the relay off.
+func (d *Device) Configure(cfg Config) {
+	d.activeLow = cfg.ActiveLow
+	d.minOn = cfg.MinOnTime
+	d.minOff = cfg.MinOffTime
+	d.maxOn = cfg.MaxOnTime
+
+	configureOutput(d.pin)
+	d.write(false)
+}
+
+// On switches the relay on. It returns ErrInterlocked if another relay of
+// the same group is on, or ErrDwell if the relay was switched off less than
+// MinOffTime ago. Switching on a relay that is already on only feeds the
+// watchdog.
+func (d *Device) On() error {
+	if d.on {
+		d.Kick()
+		return nil
+	}
+	if d.group != nil && d.group.active() != nil {
+		return ErrInterlocked
+	}
+	if d.minOff > 0 && !d.lastChange.IsZero() && d.now().Sub(d.lastChange) < d.minOff {
+		return ErrDwell
+	}
+	d.write(true)
+	return nil
+}
+
+// Off switches the relay off. It returns ErrDwell if the relay was switched
+// on less than MinOnTime ago.
+func (d *Device) Off() error {
+	if !d.on {
+		return nil
+	}
+	if d.minOn > 0 && d.now().Sub(d.lastChange) < d.minOn {
+		return ErrDwell
+	}
+	d.write(false)
+	return nil
+}
+
+// ForceOff switches the relay off immediately, ignoring the minimum on
+// time. It is meant for fault handling.
+func (d *Device) ForceOff() {
+	if d.on {
+		d.write(false)
+	}
+}
+
+// Set switches the relay on or off.
+func (d *Device) Set(on bool) error {
+	if on {
+		return d.On()
+	}
+	return d.Off()
+}
+
+// IsOn returns whether the relay is currently on.
+func (d *Device) IsOn() bool {
+	return d.on
+}
+
+// Kick feeds the watchdog of a relay that is on.
+func (d *Device) Kick() {
+	d.lastKick = d.now()
+}
+
+// Update must be called periodically when MaxOnTime is used. It switches the
+// relay off when the watchdog expired and reports whether it did so.
+func (d *Device) Update() bool {
+	if d.on && d.maxOn > 0 && d.now().Sub(d.lastKick) > d.maxOn {
+		d.write(false)
+		return true
+	}
+	return false
+}
+
+// write sets the output pin, taking the polarity of the module into account.
+func (d *Device) write(on bool) {
+	d.pin.Set(on != d.activeLow)
+	now := d.now()
+	if on != d.on {
+		d.lastChange = now
+	}
+	d.on = on
+	d.lastKick = now
+}
+
+// Group is a set of mutually exclusive relays: at most one relay of a group
+// can be on at any time.
+type Group struct {
+	relays []*Device
+}
+
+// NewGroup creates an interlock between the given relays. A relay can only
+// be a member of a single group.
+func NewGroup(relays ...*Device) *Group {
+	g := &Group{relays: relays}
+	for _, r := range relays {
+		r.group = g
+	}
+	return g
+}
+
+// Select switches on the relay with the given index after switching off the
+// relay that is currently on (break before make). An index outside the
+// group only switches everything off. The first error encountered, for
+// example ErrDwell, is returned and leaves the outputs in a safe state.
+func (g *Group) Select(index int) error {
+	for i, r := range g.relays {
+		if i != index {
+			if err := r.Off(); err != nil {
+				return err
+			}
+		}
+	}
+	if index < 0 || index >= len(g.relays) {
+		return nil
+	}
+	return g.relays[index].On()
+}
+
+// Off switches off all relays of the group.
+func (g *Group) Off() error {
+	return g.Select(-1)
+}
+
+// Active returns the index of the relay that is on, or -1 if all relays of
+// the group are off.
+func (g *Group) Active() int {
+	for i, r := range g.relays {
+		if r.on {
+			return i
+		}
+	}
+	return -1
+}
+
+// Update calls Update on all relays of the group.
+func (g *Group) Update() {
+	for _, r := range g.relays {
+		r.Update()
+	}
+}
+
+func (g *Group) active() *Device {
+	for _, r := range g.relays {
+		if r.on {
+			return r
+		}
+	}
+	return nil
+}
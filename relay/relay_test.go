@@ -0,0 +1,129 @@
+package relay
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// fakePin stands for a pin of an I/O expander.
+type fakePin struct {
+	level bool
+}
+
+func (p *fakePin) Get() bool     { return p.level }
+func (p *fakePin) Set(high bool) { p.level = high }
+func (p *fakePin) High()         { p.level = true }
+func (p *fakePin) Low()          { p.level = false }
+
+func TestGroup(t *testing.T) {
+	c := qt.New(t)
+	heaterPin, coolerPin := &fakePin{}, &fakePin{}
+	heater, cooler := New(heaterPin), New(coolerPin)
+	heater.Configure(Config{ActiveLow: true})
+	cooler.Configure(Config{})
+	c.Assert(heaterPin.level, qt.IsTrue)
+	c.Assert(coolerPin.level, qt.IsFalse)
+
+	stages := NewGroup(&heater, &cooler)
+	c.Assert(heater.On(), qt.IsNil)
+	c.Assert(heaterPin.level, qt.IsFalse)
+	c.Assert(cooler.On(), qt.Equals, ErrInterlocked)
+	c.Assert(stages.Select(1), qt.IsNil)
+	c.Assert(heaterPin.level, qt.IsTrue)
+	c.Assert(coolerPin.level, qt.IsTrue)
+	c.Assert(stages.Off(), qt.IsNil)
+	c.Assert(cooler.IsOn(), qt.IsFalse)
+}
+
+// newRelay returns a relay whose time only advances with the returned
+// function.
+func newRelay(cfg Config) (*Device, *fakePin, func(time.Duration)) {
+	pin := &fakePin{}
+	d := New(pin)
+	clock := time.Unix(1000, 0)
+	d.now = func() time.Time { return clock }
+	d.Configure(cfg)
+	return &d, pin, func(dt time.Duration) { clock = clock.Add(dt) }
+}
+
+func TestDwell(t *testing.T) {
+	c := qt.New(t)
+	d, pin, advance := newRelay(Config{MinOnTime: time.Second, MinOffTime: 5 * time.Second})
+
+	// the first switch on is not delayed by the off time of Configure
+	c.Assert(d.On(), qt.IsNil)
+	c.Assert(pin.level, qt.IsTrue)
+
+	advance(999 * time.Millisecond)
+	c.Assert(d.Off(), qt.Equals, ErrDwell)
+	c.Assert(d.IsOn(), qt.IsTrue)
+	c.Assert(pin.level, qt.IsTrue)
+	advance(time.Millisecond)
+	c.Assert(d.Off(), qt.IsNil)
+	c.Assert(pin.level, qt.IsFalse)
+
+	advance(4 * time.Second)
+	c.Assert(d.Set(true), qt.Equals, ErrDwell)
+	c.Assert(pin.level, qt.IsFalse)
+	advance(time.Second)
+	c.Assert(d.Set(true), qt.IsNil)
+	c.Assert(pin.level, qt.IsTrue)
+
+	// ForceOff ignores the minimum on time, but still starts the minimum
+	// off time
+	advance(500 * time.Millisecond)
+	c.Assert(d.Off(), qt.Equals, ErrDwell)
+	d.ForceOff()
+	c.Assert(pin.level, qt.IsFalse)
+	c.Assert(d.On(), qt.Equals, ErrDwell)
+}
+
+func TestGroupDwell(t *testing.T) {
+	c := qt.New(t)
+	heater, heaterPin, advance := newRelay(Config{MinOnTime: time.Minute})
+	cooler, coolerPin, _ := newRelay(Config{})
+	stages := NewGroup(heater, cooler)
+
+	c.Assert(stages.Select(0), qt.IsNil)
+	advance(30 * time.Second)
+	// break before make: the cooler is not switched on while the heater
+	// must stay on
+	c.Assert(stages.Select(1), qt.Equals, ErrDwell)
+	c.Assert(heaterPin.level, qt.IsTrue)
+	c.Assert(coolerPin.level, qt.IsFalse)
+	c.Assert(stages.Active(), qt.Equals, 0)
+	advance(30 * time.Second)
+	c.Assert(stages.Select(1), qt.IsNil)
+	c.Assert(stages.Active(), qt.Equals, 1)
+}
+
+func TestWatchdog(t *testing.T) {
+	c := qt.New(t)
+	d, pin, advance := newRelay(Config{MaxOnTime: 10 * time.Second})
+	c.Assert(d.On(), qt.IsNil)
+
+	advance(10 * time.Second)
+	c.Assert(d.Update(), qt.IsFalse)
+	c.Assert(pin.level, qt.IsTrue)
+
+	// both Kick and On feed the watchdog
+	d.Kick()
+	advance(8 * time.Second)
+	c.Assert(d.On(), qt.IsNil)
+	advance(10 * time.Second)
+	c.Assert(d.Update(), qt.IsFalse)
+	c.Assert(d.IsOn(), qt.IsTrue)
+
+	advance(time.Millisecond)
+	c.Assert(d.Update(), qt.IsTrue)
+	c.Assert(d.IsOn(), qt.IsFalse)
+	c.Assert(pin.level, qt.IsFalse)
+	c.Assert(d.Update(), qt.IsFalse)
+
+	// a relay that is off is never switched by the watchdog
+	advance(time.Minute)
+	c.Assert(d.Update(), qt.IsFalse)
+	c.Assert(pin.level, qt.IsFalse)
+}
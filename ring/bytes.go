@@ -0,0 +1,62 @@
+package ring
+
+// Bytes is a ring buffer of bytes, for one producer and one consumer.
+type Bytes struct {
+	q   Queue
+	buf []byte
+}
+
+// NewBytes returns a buffer of the given capacity, which must be positive.
+func NewBytes(capacity int, policy Policy) *Bytes {
+	return &Bytes{q: *New(capacity, policy), buf: make([]byte, capacity)}
+}
+
+// Put adds a byte, and returns false if it was rejected because the buffer
+// is full and the policy is DropNewest.
+func (b *Bytes) Put(c byte) bool {
+	i, ok := b.q.Reserve()
+	if !ok {
+		return false
+	}
+	b.buf[i] = c
+	b.q.Commit()
+	return true
+}
+
+// Get removes and returns the oldest byte, or false if the buffer is empty.
+func (b *Bytes) Get() (byte, bool) {
+	for {
+		i, ok := b.q.Front()
+		if !ok {
+			return 0, false
+		}
+		c := b.buf[i]
+		if b.q.Release() {
+			return c, true
+		}
+	}
+}
+
+// Read removes the oldest bytes into p and returns their number. It does not
+// wait for new bytes.
+func (b *Bytes) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		c, ok := b.Get()
+		if !ok {
+			break
+		}
+		p[n] = c
+		n++
+	}
+	return n, nil
+}
+
+// Len returns the number of bytes in the buffer.
+func (b *Bytes) Len() int {
+	return b.q.Len()
+}
+
+// Dropped returns the number of bytes lost to an overflow.
+func (b *Bytes) Dropped() uint32 {
+	return b.q.Dropped()
+}
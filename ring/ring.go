@@ -0,0 +1,145 @@
+// Package ring provides fixed capacity ring buffers for one producer and one
+// consumer, such as an interrupt handler filling a buffer that the main loop
+// drains. They are lock-free and do not allocate after they are created.
+//
+// This module targets Go 1.15, which has no type parameters, so Queue only
+// manages the indexes of a ring and the elements are kept in an array of any
+// type owned by the caller:
+//
+//	var samples [32][3]int32
+//	q := ring.New(len(samples), ring.DropOldest)
+//
+//	// producer
+//	if i, ok := q.Reserve(); ok {
+//		samples[i] = [3]int32{x, y, z}
+//		q.Commit()
+//	}
+//
+//	// consumer
+//	for {
+//		i, ok := q.Front()
+//		if !ok {
+//			break
+//		}
+//		s := samples[i]
+//		if q.Release() {
+//			use(s)
+//		}
+//	}
+//
+// Bytes is a ready to use buffer of bytes, for the drivers of UART devices.
+package ring // import "tinygo.org/x/drivers/ring"
+
+import "sync/atomic"
+
+// Policy selects what happens to an element added to a full queue.
+type Policy uint8
+
+// Overflow policies.
+const (
+	// DropNewest rejects the new element, keeping the oldest ones.
+	DropNewest Policy = iota
+
+	// DropOldest discards the oldest element to make room for the new one,
+	// to keep the latest samples of a sensor.
+	DropOldest
+)
+
+// Queue holds the indexes of a ring of elements. The producer only calls
+// Reserve and Commit, the consumer only calls Front and Release, and both
+// may call Len and Dropped.
+//
+// The indexes run from 0 to twice the capacity, which tells a full ring from
+// an empty one without wasting an element. The slot of an index is the index
+// modulo the capacity.
+type Queue struct {
+	head, tail uint32
+	size       uint32
+	policy     Policy
+	dropped    uint32
+
+	// tail seen by the last call to Front, only used by the consumer
+	front uint32
+}
+
+// New returns a queue of the given capacity, which must be positive.
+func New(capacity int, policy Policy) *Queue {
+	if capacity <= 0 {
+		panic("ring: capacity must be positive")
+	}
+	return &Queue{size: uint32(capacity), policy: policy}
+}
+
+// Cap returns the capacity of the queue.
+func (q *Queue) Cap() int {
+	return int(q.size)
+}
+
+// Len returns the number of elements in the queue.
+func (q *Queue) Len() int {
+	return int(q.count(atomic.LoadUint32(&q.head), atomic.LoadUint32(&q.tail)))
+}
+
+// Dropped returns the number of elements lost to an overflow: rejected with
+// DropNewest, or discarded with DropOldest.
+func (q *Queue) Dropped() uint32 {
+	return atomic.LoadUint32(&q.dropped)
+}
+
+// Reserve returns the slot where the producer writes the next element, which
+// is added by Commit. It returns false when the queue is full and the policy
+// is DropNewest.
+func (q *Queue) Reserve() (slot int, ok bool) {
+	head := atomic.LoadUint32(&q.head)
+	tail := atomic.LoadUint32(&q.tail)
+	if q.count(head, tail) == q.size {
+		if q.policy == DropNewest {
+			atomic.AddUint32(&q.dropped, 1)
+			return 0, false
+		}
+		// when this fails the consumer released the element by itself
+		if atomic.CompareAndSwapUint32(&q.tail, tail, q.next(tail)) {
+			atomic.AddUint32(&q.dropped, 1)
+		}
+	}
+	return int(head % q.size), true
+}
+
+// Commit adds the element written in the slot returned by Reserve.
+func (q *Queue) Commit() {
+	atomic.StoreUint32(&q.head, q.next(atomic.LoadUint32(&q.head)))
+}
+
+// Front returns the slot of the oldest element, or false if the queue is
+// empty. The element stays in the queue until Release.
+func (q *Queue) Front() (slot int, ok bool) {
+	tail := atomic.LoadUint32(&q.tail)
+	if tail == atomic.LoadUint32(&q.head) {
+		return 0, false
+	}
+	q.front = tail
+	return int(tail % q.size), true
+}
+
+// Release removes the element returned by the last call to Front. It
+// returns false when the producer discarded the element in the meantime with
+// DropOldest, in which case what the consumer read may be torn and must be
+// ignored.
+func (q *Queue) Release() bool {
+	return atomic.CompareAndSwapUint32(&q.tail, q.front, q.next(q.front))
+}
+
+func (q *Queue) next(i uint32) uint32 {
+	i++
+	if i == 2*q.size {
+		i = 0
+	}
+	return i
+}
+
+func (q *Queue) count(head, tail uint32) uint32 {
+	if head >= tail {
+		return head - tail
+	}
+	return head + 2*q.size - tail
+}
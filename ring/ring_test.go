@@ -0,0 +1,107 @@
+package ring
+
+import (
+	"runtime"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestQueueWraparound(t *testing.T) {
+	c := qt.New(t)
+	var values [3]int
+	q := New(len(values), DropNewest)
+	next := 0
+	// the indexes go several times around the ring and past twice the
+	// capacity
+	for round := 0; round < 10; round++ {
+		for i := 0; i < round%3+1; i++ {
+			slot, ok := q.Reserve()
+			c.Assert(ok, qt.IsTrue)
+			values[slot] = next + i
+			q.Commit()
+		}
+		c.Assert(q.Len(), qt.Equals, round%3+1)
+		for i := 0; i < round%3+1; i++ {
+			slot, ok := q.Front()
+			c.Assert(ok, qt.IsTrue)
+			c.Assert(values[slot], qt.Equals, next)
+			c.Assert(q.Release(), qt.IsTrue)
+			next++
+		}
+		_, ok := q.Front()
+		c.Assert(ok, qt.IsFalse)
+	}
+	c.Assert(q.Dropped(), qt.Equals, uint32(0))
+}
+
+func TestDropNewest(t *testing.T) {
+	c := qt.New(t)
+	b := NewBytes(4, DropNewest)
+	for i := byte(0); i < 6; i++ {
+		c.Assert(b.Put(i), qt.Equals, i < 4)
+	}
+	c.Assert(b.Len(), qt.Equals, 4)
+	c.Assert(b.Dropped(), qt.Equals, uint32(2))
+	buf := make([]byte, 8)
+	n, err := b.Read(buf)
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf[:n], qt.DeepEquals, []byte{0, 1, 2, 3})
+}
+
+func TestDropOldest(t *testing.T) {
+	c := qt.New(t)
+	b := NewBytes(4, DropOldest)
+	for i := byte(0); i < 6; i++ {
+		c.Assert(b.Put(i), qt.IsTrue)
+	}
+	c.Assert(b.Len(), qt.Equals, 4)
+	c.Assert(b.Dropped(), qt.Equals, uint32(2))
+	buf := make([]byte, 8)
+	n, _ := b.Read(buf)
+	c.Assert(buf[:n], qt.DeepEquals, []byte{2, 3, 4, 5})
+}
+
+func TestDropOldestWhileReading(t *testing.T) {
+	c := qt.New(t)
+	var values [2]int
+	q := New(len(values), DropOldest)
+	for i := 0; i < 2; i++ {
+		slot, _ := q.Reserve()
+		values[slot] = i
+		q.Commit()
+	}
+	slot, _ := q.Front()
+	c.Assert(values[slot], qt.Equals, 0)
+	// the producer overwrites the element being read
+	slot, _ = q.Reserve()
+	values[slot] = 2
+	q.Commit()
+	c.Assert(q.Release(), qt.IsFalse)
+	slot, _ = q.Front()
+	c.Assert(values[slot], qt.Equals, 1)
+	c.Assert(q.Release(), qt.IsTrue)
+}
+
+func TestConcurrent(t *testing.T) {
+	c := qt.New(t)
+	b := NewBytes(16, DropNewest)
+	const n = 10000
+	go func() {
+		for i := 0; i < n; {
+			if b.Put(byte(i)) {
+				i++
+			} else {
+				runtime.Gosched()
+			}
+		}
+	}()
+	for i := 0; i < n; {
+		if v, ok := b.Get(); ok {
+			c.Assert(v, qt.Equals, byte(i))
+			i++
+		} else {
+			runtime.Gosched()
+		}
+	}
+}
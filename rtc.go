@@ -0,0 +1,15 @@
+package drivers
+
+import "time"
+
+// RTC is a real time clock that keeps the date and time, usually on battery
+// power. It is implemented by the ds1307, ds3231, pcf8523 and pcf8563
+// drivers.
+type RTC interface {
+	// ReadTime returns the current date and time, in UTC unless the clock
+	// was set in another time zone.
+	ReadTime() (time.Time, error)
+
+	// SetTime sets the date and time of the clock.
+	SetTime(t time.Time) error
+}
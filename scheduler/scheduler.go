@@ -0,0 +1,120 @@
+// Package scheduler polls the sensors of a program from a single loop, each
+// at its own interval, instead of every driver keeping the time of its last
+// read. A slow sensor such as a DHT22, which must not be read more than once
+// every 2s, and a fast accelerometer then share the main loop:
+//
+//	s := scheduler.New()
+//	s.Add(dht.PollTask(sensor))
+//	s.Add(scheduler.Task{Name: "lis3dh", Poll: readAccel, Interval: 100 * time.Millisecond, Priority: 1})
+//	s.Run()
+package scheduler // import "tinygo.org/x/drivers/scheduler"
+
+import "time"
+
+// Task is a function polled at a regular interval.
+type Task struct {
+	// Name identifies the task for OnError.
+	Name string
+
+	// Poll reads the device, and the values read are kept by the caller or
+	// the driver.
+	Poll func() error
+
+	// Interval is the time between the starts of two polls, which is never
+	// shorter, as for the minimum interval between two measurements of a
+	// sensor. With 0 the task is polled by every call to Scheduler.Poll.
+	Interval time.Duration
+
+	// Jitter, when set, delays each poll by a random time up to Jitter, so
+	// that tasks with the same interval do not always run together.
+	Jitter time.Duration
+
+	// Priority orders the tasks that are due at the same time, the highest
+	// first. Tasks of the same priority run in the order they were added.
+	Priority int
+}
+
+// Scheduler polls tasks. It is not safe for concurrent use.
+type Scheduler struct {
+	tasks []entry
+
+	// OnError, when set, is called with the tasks whose Poll failed.
+	OnError func(name string, err error)
+
+	now  func() time.Time
+	seed uint64
+}
+
+type entry struct {
+	Task
+	next time.Time
+}
+
+// New returns a scheduler without tasks.
+func New() *Scheduler {
+	return &Scheduler{now: time.Now, seed: 0x9E3779B97F4A7C15}
+}
+
+// Add adds a task, which is first polled by the next call to Poll.
+func (s *Scheduler) Add(task Task) {
+	e := entry{Task: task, next: s.now().Add(s.jitter(task.Jitter))}
+	// keep the tasks sorted by priority
+	i := len(s.tasks)
+	for i > 0 && s.tasks[i-1].Priority < task.Priority {
+		i--
+	}
+	s.tasks = append(s.tasks, entry{})
+	copy(s.tasks[i+1:], s.tasks[i:])
+	s.tasks[i] = e
+}
+
+// Len returns the number of tasks.
+func (s *Scheduler) Len() int {
+	return len(s.tasks)
+}
+
+// Poll runs the tasks that are due, by priority, and returns the time when
+// the next one is due, to run Poll from a loop that does other work. It
+// returns the zero time when there is no task.
+func (s *Scheduler) Poll() time.Time {
+	var next time.Time
+	for i := range s.tasks {
+		e := &s.tasks[i]
+		if start := s.now(); !start.Before(e.next) {
+			if err := e.Poll(); err != nil && s.OnError != nil {
+				s.OnError(e.Name, err)
+			}
+			e.next = start.Add(e.Interval + s.jitter(e.Jitter))
+		}
+		if next.IsZero() || e.next.Before(next) {
+			next = e.next
+		}
+	}
+	return next
+}
+
+// Run polls the tasks forever, sleeping until the next one is due. It
+// returns at once when there is no task.
+func (s *Scheduler) Run() {
+	for {
+		next := s.Poll()
+		if next.IsZero() {
+			return
+		}
+		if d := next.Sub(s.now()); d > 0 {
+			time.Sleep(d)
+		}
+	}
+}
+
+// jitter returns a random duration below max, with a xorshift generator
+// that does not allocate.
+func (s *Scheduler) jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	s.seed ^= s.seed << 13
+	s.seed ^= s.seed >> 7
+	s.seed ^= s.seed << 17
+	return time.Duration(s.seed % uint64(max))
+}
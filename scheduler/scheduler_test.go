@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// clock is a fake time, advanced by the tasks.
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func newScheduler(c *clock) *Scheduler {
+	s := New()
+	s.now = c.now
+	return s
+}
+
+func TestPoll(t *testing.T) {
+	c := qt.New(t)
+	clk := &clock{t: time.Unix(1000, 0)}
+	s := newScheduler(clk)
+	var polls []string
+	cost := 10 * time.Millisecond
+	task := func(name string, interval time.Duration, priority int) Task {
+		return Task{
+			Name:     name,
+			Interval: interval,
+			Priority: priority,
+			Poll: func() error {
+				polls = append(polls, name)
+				clk.t = clk.t.Add(cost)
+				return nil
+			},
+		}
+	}
+	s.Add(task("dht", 2*time.Second, 0))
+	s.Add(task("lis3dh", 500*time.Millisecond, 1))
+	s.Add(task("bme280", time.Second, 0))
+	c.Assert(s.Len(), qt.Equals, 3)
+
+	// all the tasks are due at first, by priority then in order
+	next := s.Poll()
+	c.Assert(polls, qt.DeepEquals, []string{"lis3dh", "dht", "bme280"})
+	c.Assert(next, qt.Equals, time.Unix(1000, int64(500*time.Millisecond)))
+
+	polls = nil
+	clk.t = next
+	s.Poll()
+	c.Assert(polls, qt.DeepEquals, []string{"lis3dh"})
+
+	// the interval starts with the poll, which started 10ms late for the dht
+	cost = 0
+	polls = nil
+	clk.t = time.Unix(1002, 0)
+	s.Poll()
+	c.Assert(polls, qt.DeepEquals, []string{"lis3dh", "bme280"})
+	clk.t = time.Unix(1002, int64(10*time.Millisecond))
+	s.Poll()
+	c.Assert(polls, qt.DeepEquals, []string{"lis3dh", "bme280", "dht"})
+}
+
+func TestErrors(t *testing.T) {
+	c := qt.New(t)
+	clk := &clock{t: time.Unix(1000, 0)}
+	s := newScheduler(clk)
+	errRead := errors.New("read failed")
+	s.Add(Task{Name: "failing", Interval: time.Second, Poll: func() error { return errRead }})
+	s.Add(Task{Name: "working", Poll: func() error { return nil }})
+
+	var failed []string
+	s.OnError = func(name string, err error) {
+		c.Check(err, qt.Equals, errRead)
+		failed = append(failed, name)
+	}
+	s.Poll()
+	s.Poll()
+	c.Assert(failed, qt.DeepEquals, []string{"failing"})
+}
+
+func TestJitter(t *testing.T) {
+	c := qt.New(t)
+	clk := &clock{t: time.Unix(1000, 0)}
+	s := newScheduler(clk)
+	var times []time.Time
+	s.Add(Task{
+		Interval: time.Second,
+		Jitter:   100 * time.Millisecond,
+		Poll: func() error {
+			times = append(times, clk.t)
+			return nil
+		},
+	})
+	for i := 0; i < 1000; i++ {
+		clk.t = s.Poll()
+	}
+	delays := map[time.Duration]bool{}
+	for i := 1; i < len(times); i++ {
+		d := times[i].Sub(times[i-1])
+		c.Assert(d >= time.Second && d < 1100*time.Millisecond, qt.IsTrue, qt.Commentf("interval %v", d))
+		delays[d] = true
+	}
+	c.Assert(len(delays) > 100, qt.IsTrue)
+}
+
+func TestRun(t *testing.T) {
+	c := qt.New(t)
+	s := New()
+	s.Run()
+
+	n := 0
+	s.Add(Task{Interval: time.Millisecond, Poll: func() error {
+		n++
+		if n == 3 {
+			s.tasks = nil
+		}
+		return nil
+	}})
+	start := time.Now()
+	s.Run()
+	c.Assert(n, qt.Equals, 3)
+	c.Assert(time.Since(start) >= 2*time.Millisecond, qt.IsTrue)
+}
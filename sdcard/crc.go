@@ -0,0 +1,30 @@
+package sdcard
+
+// crc7 returns the 7-bit CRC (polynomial x^7 + x^3 + 1) protecting commands.
+func crc7(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if (b^crc)&0x80 != 0 {
+				crc ^= 0x09
+			}
+			b <<= 1
+		}
+	}
+	return crc & 0x7F
+}
+
+// crc16 returns the CRC-16-CCITT (polynomial x^16 + x^12 + x^5 + 1)
+// protecting data blocks.
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc = crc>>8 | crc<<8
+		crc ^= uint16(b)
+		crc ^= (crc & 0xFF) >> 4
+		crc ^= crc << 12
+		crc ^= (crc & 0xFF) << 5
+	}
+	return crc
+}
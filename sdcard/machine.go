@@ -0,0 +1,36 @@
+// +build tinygo
+
+package sdcard
+
+import (
+	"machine"
+
+	"tinygo.org/x/drivers"
+)
+
+// configureBus sets the clock of a machine.SPI bus, which is the only kind
+// of bus that can be reconfigured.
+func (d *Device) configureBus(cfg Config, frequency uint32) {
+	bus, ok := d.bus.(interface {
+		Configure(machine.SPIConfig) error
+	})
+	if !ok {
+		return
+	}
+	bus.Configure(machine.SPIConfig{
+		Frequency: frequency,
+		SCK:       machinePin(cfg.SCK),
+		SDO:       machinePin(cfg.SDO),
+		SDI:       machinePin(cfg.SDI),
+		Mode:      0,
+	})
+}
+
+// machinePin returns pin as a machine.Pin, or the zero pin that selects the
+// default bus pins if it is not set.
+func machinePin(pin drivers.Pin) machine.Pin {
+	if pin, ok := pin.(machine.Pin); ok {
+		return pin
+	}
+	return 0
+}
@@ -0,0 +1,59 @@
+package sdcard
+
+// Commands used in SPI mode.
+const (
+	CMD0_GO_IDLE_STATE        = 0
+	CMD8_SEND_IF_COND         = 8
+	CMD9_SEND_CSD             = 9
+	CMD10_SEND_CID            = 10
+	CMD12_STOP_TRANSMISSION   = 12
+	CMD13_SEND_STATUS         = 13
+	CMD16_SET_BLOCKLEN        = 16
+	CMD17_READ_SINGLE_BLOCK   = 17
+	CMD18_READ_MULTIPLE_BLOCK = 18
+	CMD24_WRITE_BLOCK         = 24
+	CMD25_WRITE_MULTIPLE      = 25
+	CMD32_ERASE_START         = 32
+	CMD33_ERASE_END           = 33
+	CMD38_ERASE               = 38
+	CMD55_APP_CMD             = 55
+	CMD58_READ_OCR            = 58
+	CMD59_CRC_ON_OFF          = 59
+
+	ACMD41_SD_SEND_OP_COND = 41
+)
+
+// R1 response bits.
+const (
+	R1_IDLE_STATE    = 0x01
+	R1_ILLEGAL_CMD   = 0x04
+	R1_CRC_ERROR     = 0x08
+	R1_ADDRESS_ERROR = 0x20
+)
+
+// Data tokens.
+const (
+	TOKEN_START_BLOCK    = 0xFE
+	TOKEN_START_MULTIPLE = 0xFC
+	TOKEN_STOP_TRAN      = 0xFD
+
+	DATA_RES_MASK     = 0x1F
+	DATA_RES_ACCEPTED = 0x05
+	DATA_RES_CRC_ERR  = 0x0B
+)
+
+const (
+	// BlockSize is the size of a block in bytes. Cards are always accessed in
+	// blocks of this size.
+	BlockSize = 512
+
+	// OCR_CCS is the card capacity status bit in the OCR register, set for
+	// high capacity (SDHC/SDXC) cards.
+	OCR_CCS = 1 << 30
+
+	// ACMD41_HCS tells the card that the host supports high capacity cards.
+	ACMD41_HCS = 1 << 30
+
+	// CMD8_CHECK is the CMD8 argument: 2.7-3.6V and a check pattern.
+	CMD8_CHECK = 0x1AA
+)
@@ -0,0 +1,511 @@
+// Package sdcard implements a driver for SD and SDHC memory cards in SPI
+// mode.
+//
+// The card is accessed in blocks of 512 bytes. Device implements the
+// drivers.BlockDevice interface, so that it can be used by filesystem
+// packages.
+//
+// Specification: https://www.sdcard.org/downloads/pls/ (Physical Layer
+// Simplified Specification, chapter 7 "SPI Mode")
+package sdcard // import "tinygo.org/x/drivers/sdcard"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	ErrNoCard      = errors.New("sdcard: no card detected")
+	ErrUnsupported = errors.New("sdcard: unsupported card")
+	ErrTimeout     = errors.New("sdcard: timeout")
+	ErrCRC         = errors.New("sdcard: CRC mismatch")
+	ErrCommand     = errors.New("sdcard: command failed")
+	ErrWrite       = errors.New("sdcard: write rejected")
+	ErrOutOfRange  = errors.New("sdcard: block out of range")
+)
+
+// Config contains the settings used by Configure.
+type Config struct {
+	// Frequency of the SPI bus after initialization, 4MHz if zero.
+	// Initialization itself always runs at 250kHz.
+	Frequency uint32
+
+	// SCK, SDO and SDI are the pins of the bus, used to change its clock
+	// when it is a machine.SPI. Other buses keep the frequency they were
+	// configured with, which must not exceed 400kHz during initialization.
+	SCK, SDO, SDI drivers.Pin
+}
+
+// Device is an SD card connected to an SPI bus.
+type Device struct {
+	bus    drivers.SPI
+	cs     drivers.Pin
+	sdhc   bool
+	blocks int64
+	cmd    [6]byte
+	buf    [BlockSize]byte
+}
+
+// New returns a new SD card driver given the SPI bus and the chip select
+// pin, which must be configured as an output. A machine.SPI bus is
+// configured by Configure, as initialization needs a slow clock.
+func New(bus drivers.SPI, cs drivers.Pin) *Device {
+	return &Device{
+		bus: bus,
+		cs:  cs,
+	}
+}
+
+// Configure initializes the card. It detects the card type and capacity
+// and enables CRC checking of all transfers.
+func (d *Device) Configure(cfg Config) error {
+	if cfg.Frequency == 0 {
+		cfg.Frequency = 4000000
+	}
+	d.cs.High()
+	d.configureBus(cfg, 250000)
+
+	// at least 74 clocks with CS high to enter native mode
+	for i := 0; i < 10; i++ {
+		d.bus.Transfer(0xFF)
+	}
+
+	var r1 byte
+	for i := 0; ; i++ {
+		r1 = d.command(CMD0_GO_IDLE_STATE, 0)
+		d.deselect()
+		if r1 == R1_IDLE_STATE {
+			break
+		}
+		if i == 10 {
+			return ErrNoCard
+		}
+	}
+
+	if r1 = d.command(CMD59_CRC_ON_OFF, 1); r1&^R1_IDLE_STATE != 0 {
+		d.deselect()
+		return ErrCommand
+	}
+	d.deselect()
+
+	// CMD8 is only supported by version 2.00 cards and later
+	v2 := false
+	r1 = d.command(CMD8_SEND_IF_COND, CMD8_CHECK)
+	if r1&R1_ILLEGAL_CMD == 0 {
+		d.readBytes(d.cmd[:4])
+		if d.cmd[2]&0x0F != 0x01 || d.cmd[3] != 0xAA {
+			d.deselect()
+			return ErrUnsupported
+		}
+		v2 = true
+	}
+	d.deselect()
+
+	var arg uint32
+	if v2 {
+		arg = ACMD41_HCS
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		r1 = d.appCommand(ACMD41_SD_SEND_OP_COND, arg)
+		d.deselect()
+		if r1 == 0 {
+			break
+		}
+		if r1&^R1_IDLE_STATE != 0 {
+			return ErrUnsupported
+		}
+		if time.Now().After(deadline) {
+			return ErrTimeout
+		}
+	}
+
+	if v2 {
+		if r1 = d.command(CMD58_READ_OCR, 0); r1 != 0 {
+			d.deselect()
+			return ErrCommand
+		}
+		d.readBytes(d.cmd[:4])
+		d.deselect()
+		ocr := uint32(d.cmd[0])<<24 | uint32(d.cmd[1])<<16 | uint32(d.cmd[2])<<8 | uint32(d.cmd[3])
+		d.sdhc = ocr&OCR_CCS != 0
+	}
+	if !d.sdhc {
+		if r1 = d.command(CMD16_SET_BLOCKLEN, BlockSize); r1 != 0 {
+			d.deselect()
+			return ErrCommand
+		}
+		d.deselect()
+	}
+
+	var csd [16]byte
+	if err := d.ReadCSD(csd[:]); err != nil {
+		return err
+	}
+	d.blocks = csdBlocks(csd[:])
+
+	d.configureBus(cfg, cfg.Frequency)
+	return nil
+}
+
+// SDHC returns whether the card is a high capacity (SDHC or SDXC) card.
+func (d *Device) SDHC() bool {
+	return d.sdhc
+}
+
+// Blocks returns the number of 512-byte blocks on the card.
+func (d *Device) Blocks() int64 {
+	return d.blocks
+}
+
+// ReadCSD reads the 16 byte card specific data register into buf.
+func (d *Device) ReadCSD(buf []byte) error {
+	return d.readRegister(CMD9_SEND_CSD, buf[:16])
+}
+
+// ReadCID reads the 16 byte card identification register into buf, which
+// contains the manufacturer, product name and serial number.
+func (d *Device) ReadCID(buf []byte) error {
+	return d.readRegister(CMD10_SEND_CID, buf[:16])
+}
+
+// ReadBlocks reads len(dst)/512 consecutive blocks starting at the given
+// block number. The length of dst must be a multiple of 512.
+func (d *Device) ReadBlocks(dst []byte, start int64) error {
+	count := int64(len(dst) / BlockSize)
+	if count == 0 {
+		return nil
+	}
+	if start < 0 || start+count > d.blocks {
+		return ErrOutOfRange
+	}
+	if count == 1 {
+		if r1 := d.command(CMD17_READ_SINGLE_BLOCK, d.address(start)); r1 != 0 {
+			d.deselect()
+			return ErrCommand
+		}
+		err := d.readData(dst[:BlockSize])
+		d.deselect()
+		return err
+	}
+
+	if r1 := d.command(CMD18_READ_MULTIPLE_BLOCK, d.address(start)); r1 != 0 {
+		d.deselect()
+		return ErrCommand
+	}
+	var err error
+	for i := int64(0); i < count && err == nil; i++ {
+		err = d.readData(dst[i*BlockSize : (i+1)*BlockSize])
+	}
+	d.writeCommand(CMD12_STOP_TRANSMISSION, 0)
+	d.bus.Transfer(0xFF) // stuff byte
+	d.response()
+	if werr := d.waitReady(100 * time.Millisecond); err == nil {
+		err = werr
+	}
+	d.deselect()
+	return err
+}
+
+// WriteBlocks writes len(src)/512 consecutive blocks starting at the given
+// block number. The length of src must be a multiple of 512.
+func (d *Device) WriteBlocks(src []byte, start int64) error {
+	count := int64(len(src) / BlockSize)
+	if count == 0 {
+		return nil
+	}
+	if start < 0 || start+count > d.blocks {
+		return ErrOutOfRange
+	}
+	if count == 1 {
+		if r1 := d.command(CMD24_WRITE_BLOCK, d.address(start)); r1 != 0 {
+			d.deselect()
+			return ErrCommand
+		}
+		err := d.writeData(TOKEN_START_BLOCK, src[:BlockSize])
+		d.deselect()
+		if err != nil {
+			return err
+		}
+		return d.checkStatus()
+	}
+
+	if r1 := d.command(CMD25_WRITE_MULTIPLE, d.address(start)); r1 != 0 {
+		d.deselect()
+		return ErrCommand
+	}
+	var err error
+	for i := int64(0); i < count && err == nil; i++ {
+		err = d.writeData(TOKEN_START_MULTIPLE, src[i*BlockSize:(i+1)*BlockSize])
+	}
+	d.bus.Transfer(TOKEN_STOP_TRAN)
+	d.bus.Transfer(0xFF)
+	if werr := d.waitReady(500 * time.Millisecond); err == nil {
+		err = werr
+	}
+	d.deselect()
+	if err != nil {
+		return err
+	}
+	return d.checkStatus()
+}
+
+// Size returns the capacity of the card in bytes.
+func (d *Device) Size() int64 {
+	return d.blocks * BlockSize
+}
+
+// ReadAt satisfies the io.ReaderAt interface. Reads that are not aligned
+// to blocks are supported, but slower.
+func (d *Device) ReadAt(buf []byte, off int64) (int, error) {
+	n := 0
+	for len(buf) > 0 {
+		block, offset := off/BlockSize, int(off%BlockSize)
+		if offset == 0 && len(buf) >= BlockSize {
+			// read as many whole blocks as possible directly into buf
+			whole := len(buf) / BlockSize * BlockSize
+			if err := d.ReadBlocks(buf[:whole], block); err != nil {
+				return n, err
+			}
+			buf, off, n = buf[whole:], off+int64(whole), n+whole
+			continue
+		}
+		if err := d.ReadBlocks(d.buf[:], block); err != nil {
+			return n, err
+		}
+		c := copy(buf, d.buf[offset:])
+		buf, off, n = buf[c:], off+int64(c), n+c
+	}
+	return n, nil
+}
+
+// WriteAt satisfies the io.WriterAt interface. Writes that are not aligned
+// to blocks read the affected blocks first.
+func (d *Device) WriteAt(buf []byte, off int64) (int, error) {
+	n := 0
+	for len(buf) > 0 {
+		block, offset := off/BlockSize, int(off%BlockSize)
+		if offset == 0 && len(buf) >= BlockSize {
+			whole := len(buf) / BlockSize * BlockSize
+			if err := d.WriteBlocks(buf[:whole], block); err != nil {
+				return n, err
+			}
+			buf, off, n = buf[whole:], off+int64(whole), n+whole
+			continue
+		}
+		if err := d.ReadBlocks(d.buf[:], block); err != nil {
+			return n, err
+		}
+		c := copy(d.buf[offset:], buf)
+		if err := d.WriteBlocks(d.buf[:], block); err != nil {
+			return n, err
+		}
+		buf, off, n = buf[c:], off+int64(c), n+c
+	}
+	return n, nil
+}
+
+// WriteBlockSize returns the block size in which data can be written, which
+// is always 512 bytes.
+func (d *Device) WriteBlockSize() int64 {
+	return BlockSize
+}
+
+// EraseBlockSize returns the size of the unit used by EraseBlocks, which is
+// always 512 bytes. SD cards do not need to be erased before writing.
+func (d *Device) EraseBlockSize() int64 {
+	return BlockSize
+}
+
+// EraseBlocks erases the given number of blocks. Depending on the card the
+// erased blocks read as all zeros or all ones.
+func (d *Device) EraseBlocks(start, len int64) error {
+	if len <= 0 {
+		return nil
+	}
+	if start < 0 || start+len > d.blocks {
+		return ErrOutOfRange
+	}
+	cmds := [...]struct {
+		cmd byte
+		arg uint32
+	}{
+		{CMD32_ERASE_START, d.address(start)},
+		{CMD33_ERASE_END, d.address(start + len - 1)},
+		{CMD38_ERASE, 0},
+	}
+	for _, c := range cmds {
+		if r1 := d.command(c.cmd, c.arg); r1 != 0 {
+			d.deselect()
+			return ErrCommand
+		}
+		d.deselect()
+	}
+	d.cs.Low()
+	err := d.waitReady(30 * time.Second)
+	d.deselect()
+	return err
+}
+
+// address returns the command argument for the given block number, which
+// is a byte offset for standard capacity cards.
+func (d *Device) address(block int64) uint32 {
+	if d.sdhc {
+		return uint32(block)
+	}
+	return uint32(block * BlockSize)
+}
+
+// command selects the card, sends a command and returns the R1 response.
+// The card stays selected so that the caller can read the rest of the
+// response; it must call deselect afterwards.
+func (d *Device) command(cmd byte, arg uint32) byte {
+	d.cs.Low()
+	if cmd != CMD0_GO_IDLE_STATE {
+		d.waitReady(300 * time.Millisecond)
+	}
+	d.writeCommand(cmd, arg)
+	return d.response()
+}
+
+// appCommand sends an application specific command, prefixed by CMD55.
+func (d *Device) appCommand(cmd byte, arg uint32) byte {
+	d.command(CMD55_APP_CMD, 0)
+	d.deselect()
+	return d.command(cmd, arg)
+}
+
+func (d *Device) writeCommand(cmd byte, arg uint32) {
+	d.cmd[0] = 0x40 | cmd
+	d.cmd[1] = byte(arg >> 24)
+	d.cmd[2] = byte(arg >> 16)
+	d.cmd[3] = byte(arg >> 8)
+	d.cmd[4] = byte(arg)
+	d.cmd[5] = crc7(d.cmd[:5])<<1 | 1
+	d.bus.Tx(d.cmd[:], nil)
+}
+
+// response waits for an R1 response, which has the top bit cleared.
+func (d *Device) response() byte {
+	for i := 0; i < 10; i++ {
+		r, _ := d.bus.Transfer(0xFF)
+		if r&0x80 == 0 {
+			return r
+		}
+	}
+	return 0xFF
+}
+
+func (d *Device) deselect() {
+	d.cs.High()
+	// an extra byte releases the data out line
+	d.bus.Transfer(0xFF)
+}
+
+// waitReady waits until the card stops signaling busy by holding its data
+// out line low.
+func (d *Device) waitReady(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if r, _ := d.bus.Transfer(0xFF); r == 0xFF {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrTimeout
+		}
+	}
+}
+
+// readBytes reads len(buf) bytes while sending all ones, as the card
+// expects.
+func (d *Device) readBytes(buf []byte) {
+	for i := range buf {
+		buf[i] = 0xFF
+	}
+	d.bus.Tx(buf, buf)
+}
+
+// readData waits for a data token and reads a data block and its CRC.
+func (d *Device) readData(buf []byte) error {
+	deadline := time.Now().Add(100 * time.Millisecond)
+	for {
+		r, _ := d.bus.Transfer(0xFF)
+		if r == TOKEN_START_BLOCK {
+			break
+		}
+		if r != 0xFF {
+			// data error token
+			return ErrCommand
+		}
+		if time.Now().After(deadline) {
+			return ErrTimeout
+		}
+	}
+	d.readBytes(buf)
+	var crc [2]byte
+	d.readBytes(crc[:])
+	if uint16(crc[0])<<8|uint16(crc[1]) != crc16(buf) {
+		return ErrCRC
+	}
+	return nil
+}
+
+// writeData sends a data block with its CRC and waits until it has been
+// written.
+func (d *Device) writeData(token byte, buf []byte) error {
+	d.bus.Transfer(token)
+	d.bus.Tx(buf, nil)
+	crc := crc16(buf)
+	d.bus.Transfer(byte(crc >> 8))
+	d.bus.Transfer(byte(crc))
+	r, _ := d.bus.Transfer(0xFF)
+	switch r & DATA_RES_MASK {
+	case DATA_RES_ACCEPTED:
+	case DATA_RES_CRC_ERR:
+		return ErrCRC
+	default:
+		return ErrWrite
+	}
+	return d.waitReady(500 * time.Millisecond)
+}
+
+// checkStatus reads the card status after a write, which reports errors
+// that only show up once the data has been programmed.
+func (d *Device) checkStatus() error {
+	r1 := d.command(CMD13_SEND_STATUS, 0)
+	r2, _ := d.bus.Transfer(0xFF)
+	d.deselect()
+	if r1 != 0 || r2 != 0 {
+		return ErrWrite
+	}
+	return nil
+}
+
+func (d *Device) readRegister(cmd byte, buf []byte) error {
+	if r1 := d.command(cmd, 0); r1 != 0 {
+		d.deselect()
+		return ErrCommand
+	}
+	err := d.readData(buf)
+	d.deselect()
+	return err
+}
+
+// csdBlocks returns the number of 512-byte blocks given the CSD register.
+func csdBlocks(csd []byte) int64 {
+	switch csd[0] >> 6 {
+	case 0:
+		// CSD version 1.0: standard capacity
+		size := int64(csd[6]&0x03)<<10 | int64(csd[7])<<2 | int64(csd[8])>>6
+		mult := uint(csd[9]&0x03)<<1 | uint(csd[10])>>7
+		readBlLen := uint(csd[5] & 0x0F)
+		return (size + 1) << (mult + 2) << readBlLen / BlockSize
+	case 1:
+		// CSD version 2.0: high capacity, in units of 512kB
+		size := int64(csd[7]&0x3F)<<16 | int64(csd[8])<<8 | int64(csd[9])
+		return (size + 1) * 1024
+	}
+	return 0
+}
@@ -0,0 +1,328 @@
+package sdcard
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers/tester"
+)
+
+// card simulates an SD card in SPI mode. It answers the commands it receives
+// while its chip select pin is low, and rejects them if their CRC is wrong.
+type card struct {
+	c  *qt.C
+	cs *tester.Pin
+
+	v1       bool // no CMD8, standard capacity
+	sdhc     bool
+	badCheck bool // wrong CMD8 check pattern
+	busy     int  // number of ACMD41 answered as still idle
+	badCRC   bool // corrupt the CRC of the data blocks sent
+	reject   bool // reject the data blocks received with a CRC error
+
+	csd    [16]byte
+	blocks map[int64][]byte
+
+	idle     bool
+	app      bool
+	commands []byte
+	args     []uint32
+	cmd      []byte
+	out      []byte
+	write    int64 // block being written, -1 if none
+	data     []byte
+}
+
+func newCard(c *qt.C) *card {
+	return &card{
+		c:      c,
+		cs:     &tester.Pin{},
+		blocks: make(map[int64][]byte),
+		write:  -1,
+	}
+}
+
+func (s *card) bus() *tester.SPIBus {
+	bus := tester.NewSPIBus(s.c)
+	bus.Handler = func(w, r []byte) error {
+		for i := range w {
+			r[i] = s.transfer(w[i])
+		}
+		return nil
+	}
+	return bus
+}
+
+// transfer returns the next byte sent by the card, then handles the byte it
+// receives at the same time.
+func (s *card) transfer(b byte) byte {
+	if s.cs.Get() {
+		s.cmd, s.out, s.write, s.data = nil, nil, -1, nil
+		return 0xFF
+	}
+	r := byte(0xFF)
+	if len(s.out) > 0 {
+		r, s.out = s.out[0], s.out[1:]
+	}
+	switch {
+	case s.data != nil:
+		s.data = append(s.data, b)
+		if len(s.data) == BlockSize+2 {
+			s.received()
+		}
+	case s.write >= 0:
+		if b == TOKEN_START_BLOCK {
+			s.data = []byte{}
+		}
+	case len(s.cmd) > 0 || b&0xC0 == 0x40:
+		s.cmd = append(s.cmd, b)
+		if len(s.cmd) == 6 {
+			s.command()
+			s.cmd = nil
+		}
+	}
+	return r
+}
+
+func (s *card) command() {
+	cmd := s.cmd[0] & 0x3F
+	arg := uint32(s.cmd[1])<<24 | uint32(s.cmd[2])<<16 | uint32(s.cmd[3])<<8 | uint32(s.cmd[4])
+	s.commands = append(s.commands, cmd)
+	s.args = append(s.args, arg)
+	app := s.app
+	s.app = false
+
+	// a response comes after one byte of wait
+	s.out = []byte{0xFF}
+	if s.cmd[5] != crc7(s.cmd[:5])<<1|1 {
+		s.out = append(s.out, s.r1()|R1_CRC_ERROR)
+		return
+	}
+	switch {
+	case cmd == CMD0_GO_IDLE_STATE:
+		s.idle = true
+		s.out = append(s.out, s.r1())
+	case cmd == CMD59_CRC_ON_OFF, cmd == CMD16_SET_BLOCKLEN:
+		s.out = append(s.out, s.r1())
+	case cmd == CMD8_SEND_IF_COND && !s.v1:
+		check := byte(arg)
+		if s.badCheck {
+			check = 0x55
+		}
+		s.out = append(s.out, s.r1(), 0, 0, byte(arg>>8)&0x0F, check)
+	case cmd == CMD55_APP_CMD:
+		s.app = true
+		s.out = append(s.out, s.r1())
+	case cmd == ACMD41_SD_SEND_OP_COND && app:
+		if s.busy > 0 {
+			s.busy--
+		} else {
+			s.idle = false
+		}
+		s.out = append(s.out, s.r1())
+	case cmd == CMD58_READ_OCR && !s.v1:
+		ocr := byte(0x80)
+		if s.sdhc {
+			ocr |= 0x40
+		}
+		s.out = append(s.out, s.r1(), ocr, 0xFF, 0x80, 0x00)
+	case cmd == CMD9_SEND_CSD:
+		s.out = append(s.out, s.r1())
+		s.send(s.csd[:])
+	case cmd == CMD17_READ_SINGLE_BLOCK:
+		s.out = append(s.out, s.r1())
+		s.send(s.block(s.index(arg)))
+	case cmd == CMD24_WRITE_BLOCK:
+		s.out = append(s.out, s.r1())
+		s.write = s.index(arg)
+	case cmd == CMD13_SEND_STATUS:
+		s.out = append(s.out, s.r1(), 0)
+	default:
+		s.out = append(s.out, s.r1()|R1_ILLEGAL_CMD)
+	}
+}
+
+func (s *card) r1() byte {
+	if s.idle {
+		return R1_IDLE_STATE
+	}
+	return 0
+}
+
+// index returns the block number addressed by a command argument.
+func (s *card) index(arg uint32) int64 {
+	if s.sdhc {
+		return int64(arg)
+	}
+	return int64(arg) / BlockSize
+}
+
+func (s *card) block(index int64) []byte {
+	if s.blocks[index] == nil {
+		s.blocks[index] = make([]byte, BlockSize)
+	}
+	return s.blocks[index]
+}
+
+// send queues a data block with its token and CRC.
+func (s *card) send(data []byte) {
+	crc := crc16(data)
+	if s.badCRC {
+		crc ^= 1
+	}
+	s.out = append(s.out, 0xFF, TOKEN_START_BLOCK)
+	s.out = append(s.out, data...)
+	s.out = append(s.out, byte(crc>>8), byte(crc))
+}
+
+// received stores a data block and queues the data response and a busy
+// byte.
+func (s *card) received() {
+	data := s.data[:BlockSize]
+	crc := uint16(s.data[BlockSize])<<8 | uint16(s.data[BlockSize+1])
+	index := s.write
+	s.write, s.data = -1, nil
+	if s.reject || crc != crc16(data) {
+		s.out = append(s.out, DATA_RES_CRC_ERR)
+		return
+	}
+	copy(s.block(index), data)
+	s.out = append(s.out, DATA_RES_ACCEPTED, 0x00)
+}
+
+// csdV2 returns a version 2.0 CSD register with the given C_SIZE.
+func csdV2(size uint32) (csd [16]byte) {
+	csd[0] = 0x40
+	csd[7] = byte(size>>16) & 0x3F
+	csd[8] = byte(size >> 8)
+	csd[9] = byte(size)
+	return csd
+}
+
+func TestCRC(t *testing.T) {
+	c := qt.New(t)
+	// values from the command examples of the specification
+	c.Assert(crc7([]byte{0x40, 0, 0, 0, 0})<<1|1, qt.Equals, byte(0x95))
+	c.Assert(crc7([]byte{0x48, 0, 0, 0x01, 0xAA})<<1|1, qt.Equals, byte(0x87))
+	c.Assert(crc7([]byte{0x51, 0, 0, 0, 0})<<1|1, qt.Equals, byte(0x55))
+	c.Assert(crc16(bytes.Repeat([]byte{0xFF}, BlockSize)), qt.Equals, uint16(0x7FA1))
+}
+
+func TestConfigureSDHC(t *testing.T) {
+	c := qt.New(t)
+	s := newCard(c)
+	s.sdhc = true
+	s.busy = 2
+	s.csd = csdV2(0x3B37)
+	d := New(s.bus(), s.cs)
+
+	c.Assert(d.Configure(Config{}), qt.IsNil)
+	c.Assert(s.commands, qt.DeepEquals, []byte{
+		CMD0_GO_IDLE_STATE, CMD59_CRC_ON_OFF, CMD8_SEND_IF_COND,
+		CMD55_APP_CMD, ACMD41_SD_SEND_OP_COND,
+		CMD55_APP_CMD, ACMD41_SD_SEND_OP_COND,
+		CMD55_APP_CMD, ACMD41_SD_SEND_OP_COND,
+		CMD58_READ_OCR, CMD9_SEND_CSD,
+	})
+	c.Assert(s.args[1], qt.Equals, uint32(1))
+	c.Assert(s.args[2], qt.Equals, uint32(CMD8_CHECK))
+	c.Assert(s.args[4], qt.Equals, uint32(ACMD41_HCS))
+	c.Assert(s.cs.Get(), qt.IsTrue)
+	c.Assert(d.SDHC(), qt.IsTrue)
+	c.Assert(d.Blocks(), qt.Equals, int64(0x3B38*1024))
+}
+
+func TestConfigureStandard(t *testing.T) {
+	c := qt.New(t)
+	s := newCard(c)
+	s.v1 = true
+	// C_SIZE 1000, C_SIZE_MULT 7, READ_BL_LEN 9
+	s.csd[5] = 0x09
+	s.csd[7] = 0xFA
+	s.csd[9] = 0x03
+	s.csd[10] = 0x80
+	d := New(s.bus(), s.cs)
+
+	c.Assert(d.Configure(Config{}), qt.IsNil)
+	c.Assert(s.commands, qt.DeepEquals, []byte{
+		CMD0_GO_IDLE_STATE, CMD59_CRC_ON_OFF, CMD8_SEND_IF_COND,
+		CMD55_APP_CMD, ACMD41_SD_SEND_OP_COND,
+		CMD16_SET_BLOCKLEN, CMD9_SEND_CSD,
+	})
+	// a version 1 card must not be asked for high capacity
+	c.Assert(s.args[4], qt.Equals, uint32(0))
+	c.Assert(s.args[5], qt.Equals, uint32(BlockSize))
+	c.Assert(d.SDHC(), qt.IsFalse)
+	c.Assert(d.Blocks(), qt.Equals, int64(1001*512))
+}
+
+func TestConfigureNoCard(t *testing.T) {
+	c := qt.New(t)
+	bus := tester.NewSPIBus(c)
+	bus.Handler = func(w, r []byte) error {
+		for i := range r {
+			r[i] = 0xFF
+		}
+		return nil
+	}
+	d := New(bus, &tester.Pin{})
+	c.Assert(d.Configure(Config{}), qt.Equals, ErrNoCard)
+}
+
+func TestConfigureUnsupported(t *testing.T) {
+	c := qt.New(t)
+	s := newCard(c)
+	s.badCheck = true
+	d := New(s.bus(), s.cs)
+	c.Assert(d.Configure(Config{}), qt.Equals, ErrUnsupported)
+}
+
+func TestReadBlocks(t *testing.T) {
+	c := qt.New(t)
+	s := newCard(c)
+	s.v1 = true
+	s.csd[5], s.csd[7], s.csd[9], s.csd[10] = 0x09, 0xFA, 0x03, 0x80
+	d := New(s.bus(), s.cs)
+	c.Assert(d.Configure(Config{}), qt.IsNil)
+
+	want := s.block(3)
+	for i := range want {
+		want[i] = byte(i * 7)
+	}
+	buf := make([]byte, BlockSize)
+	c.Assert(d.ReadBlocks(buf, 3), qt.IsNil)
+	c.Assert(buf, qt.DeepEquals, want)
+	// standard capacity cards are addressed in bytes
+	c.Assert(s.args[len(s.args)-1], qt.Equals, uint32(3*BlockSize))
+
+	s.badCRC = true
+	c.Assert(d.ReadBlocks(buf, 3), qt.Equals, ErrCRC)
+	c.Assert(d.ReadBlocks(buf, 1001*512), qt.Equals, ErrOutOfRange)
+}
+
+func TestWriteBlocks(t *testing.T) {
+	c := qt.New(t)
+	s := newCard(c)
+	s.sdhc = true
+	s.csd = csdV2(15)
+	d := New(s.bus(), s.cs)
+	c.Assert(d.Configure(Config{}), qt.IsNil)
+
+	data := make([]byte, BlockSize)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	c.Assert(d.WriteBlocks(data, 5), qt.IsNil)
+	c.Assert(s.blocks[5], qt.DeepEquals, data)
+	// high capacity cards are addressed in blocks, and the status is
+	// checked after the write
+	n := len(s.commands)
+	c.Assert(s.commands[n-2:], qt.DeepEquals, []byte{CMD24_WRITE_BLOCK, CMD13_SEND_STATUS})
+	c.Assert(s.args[n-2], qt.Equals, uint32(5))
+
+	s.reject = true
+	c.Assert(d.WriteBlocks(data, 6), qt.Equals, ErrCRC)
+	c.Assert(s.blocks[6], qt.IsNil)
+}
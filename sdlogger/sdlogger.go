@@ -0,0 +1,313 @@
+// Package sdlogger implements a buffered logger that streams lines of text,
+// such as CSV measurements, to files on a FAT formatted SD card.
+//
+// Write only copies data into one of two RAM buffers and never touches the
+// card, so it can be called from a sampling loop. The buffers are written
+// to the card by Update, either when a buffer is full or after the flush
+// interval, in large aligned writes that the SD card driver turns into
+// multi-block transfers. Update can be called from the main loop or from a
+// separate goroutine.
+//
+// Files are rotated when they reach a maximum size and, when a clock is
+// available, every day. If the card is removed, the data stays in the
+// buffers and the card is mounted again once it is back; only data that
+// does not fit in the buffers is dropped.
+package sdlogger // import "tinygo.org/x/drivers/sdlogger"
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"tinygo.org/x/drivers/fatfs"
+)
+
+var (
+	// ErrOverflow is returned by Write when the buffers are full.
+	ErrOverflow = errors.New("sdlogger: buffers full, data dropped")
+
+	// ErrNoCard is returned while waiting to mount the card again.
+	ErrNoCard = errors.New("sdlogger: card not mounted")
+)
+
+const blockSize = 512
+
+// Config contains the settings of a Logger.
+type Config struct {
+	// BufferSize is the size of each of the two buffers, rounded up to a
+	// multiple of 512 bytes. The default is 4096 bytes.
+	BufferSize int
+
+	// FlushInterval is the longest time data stays in RAM. The default is
+	// five seconds.
+	FlushInterval time.Duration
+
+	// MaxFileSize starts a new file before a file grows larger than this
+	// size in bytes. Files are not limited if it is zero.
+	MaxFileSize int64
+
+	// Dir is the directory for the log files, which must exist. The default
+	// is the root directory.
+	Dir string
+
+	// Extension of the log files, "LOG" by default.
+	Extension string
+
+	// Header is written at the start of every file, for example the column
+	// names of a CSV file.
+	Header []byte
+
+	// Now returns the current date for daily files named YYMMDDnn, where nn
+	// counts the files of the day. Without it files are named LOGnnnnn.
+	Now func() time.Time
+
+	// RetryInterval is the time between attempts to mount the card after an
+	// error. The default is one second.
+	RetryInterval time.Duration
+}
+
+// Logger writes buffered data to log files.
+type Logger struct {
+	mount func() (*fatfs.FS, error)
+	cfg   Config
+
+	mu      sync.Mutex
+	active  []byte // filled by Write
+	pending []byte // being written to the card
+	dropped int
+
+	fs        *fatfs.FS
+	file      *fatfs.File
+	name      string
+	day       int // day of the current file
+	seq       int
+	lastFlush time.Time
+	lastMount time.Time
+}
+
+// New creates a logger. The mount function is called to mount the
+// filesystem on the card, initially and after errors, for example:
+//
+//	func() (*fatfs.FS, error) {
+//	    if err := card.Configure(sdcard.Config{}); err != nil {
+//	        return nil, err
+//	    }
+//	    return fatfs.Mount(card)
+//	}
+func New(mount func() (*fatfs.FS, error), cfg Config) *Logger {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 4096
+	}
+	cfg.BufferSize = (cfg.BufferSize + blockSize - 1) / blockSize * blockSize
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+	if cfg.Extension == "" {
+		cfg.Extension = "LOG"
+	}
+	if cfg.RetryInterval == 0 {
+		cfg.RetryInterval = time.Second
+	}
+	return &Logger{
+		mount:     mount,
+		cfg:       cfg,
+		active:    make([]byte, 0, cfg.BufferSize),
+		pending:   make([]byte, 0, cfg.BufferSize),
+		lastFlush: time.Now(),
+	}
+}
+
+// Write adds data to the buffer. The data is either buffered completely or
+// dropped, so a line is never split by an overflow.
+func (l *Logger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.reserve(len(p)) {
+		return 0, ErrOverflow
+	}
+	l.active = append(l.active, p...)
+	return len(p), nil
+}
+
+// WriteString adds a string to the buffer, see Write.
+func (l *Logger) WriteString(s string) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.reserve(len(s)) {
+		return 0, ErrOverflow
+	}
+	l.active = append(l.active, s...)
+	return len(s), nil
+}
+
+// reserve makes room for n bytes in the active buffer, handing a full
+// buffer over to be written if possible.
+func (l *Logger) reserve(n int) bool {
+	if n > cap(l.active)-len(l.active) && len(l.pending) == 0 {
+		l.active, l.pending = l.pending, l.active
+	}
+	if n > cap(l.active)-len(l.active) {
+		l.dropped++
+		return false
+	}
+	return true
+}
+
+// Dropped returns the number of writes that were dropped because the
+// buffers were full.
+func (l *Logger) Dropped() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dropped
+}
+
+// Name returns the path of the current log file, or an empty string if no
+// file is open.
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// Update writes a full buffer to the card, and all buffered data when the
+// flush interval has passed. It should be called regularly.
+func (l *Logger) Update() error {
+	l.mu.Lock()
+	full := len(l.pending) > 0
+	due := len(l.active) > 0 && time.Since(l.lastFlush) >= l.cfg.FlushInterval
+	l.mu.Unlock()
+	if full {
+		if err := l.writePending(); err != nil {
+			return err
+		}
+	}
+	if due {
+		return l.Flush()
+	}
+	return nil
+}
+
+// Flush writes all buffered data to the card and syncs the file.
+func (l *Logger) Flush() error {
+	if err := l.writePending(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.active, l.pending = l.pending, l.active
+	l.mu.Unlock()
+	if err := l.writePending(); err != nil {
+		return err
+	}
+	l.lastFlush = time.Now()
+	if l.file == nil {
+		return nil
+	}
+	return l.fail(l.file.Sync())
+}
+
+// writePending writes the pending buffer to the current file, rotating and
+// mounting as needed. On errors the buffer is kept for the next attempt.
+func (l *Logger) writePending() error {
+	if len(l.pending) == 0 {
+		return nil
+	}
+	if l.fs == nil {
+		if !l.lastMount.IsZero() && time.Since(l.lastMount) < l.cfg.RetryInterval {
+			return ErrNoCard
+		}
+		l.lastMount = time.Now()
+		fs, err := l.mount()
+		if err != nil {
+			return err
+		}
+		l.fs = fs
+	}
+	if l.file == nil || l.needsRotation(len(l.pending)) {
+		if err := l.rotate(); err != nil {
+			return l.fail(err)
+		}
+	}
+	if _, err := l.file.Write(l.pending); err != nil {
+		return l.fail(err)
+	}
+	l.mu.Lock()
+	l.pending = l.pending[:0]
+	l.mu.Unlock()
+	return nil
+}
+
+// needsRotation returns whether n more bytes should go to a new file.
+func (l *Logger) needsRotation(n int) bool {
+	if l.cfg.MaxFileSize > 0 && l.file.Size()+int64(n) > l.cfg.MaxFileSize && l.file.Size() > int64(len(l.cfg.Header)) {
+		return true
+	}
+	return l.cfg.Now != nil && l.cfg.Now().YearDay() != l.day
+}
+
+// rotate closes the current file and starts the next one.
+func (l *Logger) rotate() error {
+	if l.file != nil {
+		err := l.file.Close()
+		l.file = nil
+		if err != nil {
+			return err
+		}
+	}
+	prefix := "LOG"
+	digits := 5
+	if l.cfg.Now != nil {
+		now := l.cfg.Now()
+		if now.YearDay() != l.day {
+			l.day, l.seq = now.YearDay(), 0
+		}
+		prefix = pad(now.Year()%100, 2) + pad(int(now.Month()), 2) + pad(now.Day(), 2)
+		digits = 2
+	}
+	for {
+		name := l.cfg.Dir + "/" + prefix + pad(l.seq, digits) + "." + l.cfg.Extension
+		f, err := l.fs.OpenFile(name, fatfs.O_WRONLY|fatfs.O_CREATE|fatfs.O_EXCL)
+		if err == fatfs.ErrExist {
+			// continue after the files written before a restart
+			l.seq++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		l.file, l.name = f, name
+		l.seq++
+		_, err = f.Write(l.cfg.Header)
+		return err
+	}
+}
+
+// fail drops the card after an error, so that it is mounted again by the
+// next flush.
+func (l *Logger) fail(err error) error {
+	if err == nil || err == fatfs.ErrFull {
+		return err
+	}
+	l.fs, l.file, l.name = nil, nil, ""
+	return err
+}
+
+// Close flushes the buffers and closes the current file.
+func (l *Logger) Close() error {
+	if err := l.Flush(); err != nil {
+		return err
+	}
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file, l.name = nil, ""
+	return err
+}
+
+// pad formats n with leading zeros.
+func pad(n, digits int) string {
+	b := make([]byte, digits)
+	for i := digits - 1; i >= 0; i-- {
+		b[i] = byte('0' + n%10)
+		n /= 10
+	}
+	return string(b)
+}
@@ -0,0 +1,169 @@
+package sdlogger
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"tinygo.org/x/drivers/fatfs"
+)
+
+var errRemoved = errors.New("card removed")
+
+// card is a sparse in-memory SD card that can be removed.
+type card struct {
+	blocks  map[int64]*[blockSize]byte
+	removed bool
+	writes  []int
+}
+
+func newCard() *card {
+	return &card{blocks: make(map[int64]*[blockSize]byte)}
+}
+
+func (c *card) ReadAt(buf []byte, off int64) (int, error) {
+	if c.removed {
+		return 0, errRemoved
+	}
+	for i := range buf {
+		if b := c.blocks[(off+int64(i))/blockSize]; b != nil {
+			buf[i] = b[(off+int64(i))%blockSize]
+		} else {
+			buf[i] = 0
+		}
+	}
+	return len(buf), nil
+}
+
+func (c *card) WriteAt(buf []byte, off int64) (int, error) {
+	if c.removed {
+		return 0, errRemoved
+	}
+	c.writes = append(c.writes, len(buf))
+	for i, v := range buf {
+		a := off + int64(i)
+		b := c.blocks[a/blockSize]
+		if b == nil {
+			b = new([blockSize]byte)
+			c.blocks[a/blockSize] = b
+		}
+		b[a%blockSize] = v
+	}
+	return len(buf), nil
+}
+
+func (c *card) Size() int64                      { return 64 << 20 }
+func (c *card) WriteBlockSize() int64            { return blockSize }
+func (c *card) EraseBlockSize() int64            { return blockSize }
+func (c *card) EraseBlocks(start, n int64) error { return nil }
+
+func (c *card) mount() (*fatfs.FS, error) {
+	if c.removed {
+		return nil, errRemoved
+	}
+	return fatfs.Mount(c)
+}
+
+func newLogger(qc *qt.C, cfg Config) (*card, *Logger) {
+	sd := newCard()
+	qc.Assert(fatfs.Format(sd, "LOGGER"), qt.IsNil)
+	return sd, New(sd.mount, cfg)
+}
+
+func readFile(qc *qt.C, sd *card, name string) string {
+	fs, err := fatfs.Mount(sd)
+	qc.Assert(err, qt.IsNil)
+	f, err := fs.Open(name)
+	qc.Assert(err, qt.IsNil)
+	data, err := ioutil.ReadAll(f)
+	qc.Assert(err, qt.IsNil)
+	return string(data)
+}
+
+func TestBuffering(t *testing.T) {
+	qc := qt.New(t)
+	sd, l := newLogger(qc, Config{BufferSize: 2048, Header: []byte("time,value\n")})
+	line := "1234567,42.5\n"
+	for i := 0; i < 200; i++ {
+		_, err := l.WriteString(line)
+		qc.Assert(err, qt.IsNil)
+		if i == 50 {
+			// nothing is written until a buffer is full
+			formatted := len(sd.writes)
+			qc.Assert(l.Update(), qt.IsNil)
+			qc.Assert(len(sd.writes), qt.Equals, formatted)
+		}
+	}
+	// a full buffer is written in a multi-block write after the header
+	sd.writes = nil
+	qc.Assert(l.Update(), qt.IsNil)
+	largest := 0
+	for _, n := range sd.writes {
+		if n > largest {
+			largest = n
+		}
+	}
+	qc.Assert(largest >= 2*blockSize, qt.IsTrue, qt.Commentf("writes: %v", sd.writes))
+	qc.Assert(l.Close(), qt.IsNil)
+	qc.Assert(readFile(qc, sd, "/LOG00000.LOG"), qt.Equals, "time,value\n"+strings.Repeat(line, 200))
+	qc.Assert(l.Dropped(), qt.Equals, 0)
+}
+
+func TestRotation(t *testing.T) {
+	qc := qt.New(t)
+	now := time.Date(2021, 3, 1, 23, 59, 0, 0, time.UTC)
+	sd, l := newLogger(qc, Config{
+		BufferSize:  512,
+		MaxFileSize: 1000,
+		Now:         func() time.Time { return now },
+	})
+	line := strings.Repeat("x", 99) + "\n"
+	for i := 0; i < 15; i++ {
+		l.WriteString(line)
+		qc.Assert(l.Update(), qt.IsNil)
+	}
+	qc.Assert(l.Flush(), qt.IsNil)
+	qc.Assert(l.Name(), qt.Equals, "/21030101.LOG")
+
+	now = now.Add(time.Hour)
+	l.WriteString(line)
+	qc.Assert(l.Close(), qt.IsNil)
+	qc.Assert(readFile(qc, sd, "/21030100.LOG"), qt.Equals, strings.Repeat(line, 10))
+	qc.Assert(readFile(qc, sd, "/21030101.LOG"), qt.Equals, strings.Repeat(line, 5))
+	qc.Assert(readFile(qc, sd, "/21030200.LOG"), qt.Equals, line)
+}
+
+func TestCardRemoval(t *testing.T) {
+	qc := qt.New(t)
+	sd, l := newLogger(qc, Config{BufferSize: 512, RetryInterval: time.Millisecond})
+	l.WriteString("before\n")
+	qc.Assert(l.Flush(), qt.IsNil)
+
+	sd.removed = true
+	l.WriteString("during\n")
+	qc.Assert(l.Flush(), qt.Equals, errRemoved)
+	qc.Assert(l.Flush(), qt.Equals, ErrNoCard)
+
+	sd.removed = false
+	time.Sleep(2 * time.Millisecond)
+	qc.Assert(l.Flush(), qt.IsNil)
+	qc.Assert(l.Close(), qt.IsNil)
+	qc.Assert(readFile(qc, sd, "/LOG00000.LOG"), qt.Equals, "before\n")
+	qc.Assert(readFile(qc, sd, "/LOG00001.LOG"), qt.Equals, "during\n")
+}
+
+func TestOverflow(t *testing.T) {
+	qc := qt.New(t)
+	_, l := newLogger(qc, Config{BufferSize: 512})
+	line := strings.Repeat("y", 200)
+	for i := 0; i < 4; i++ {
+		_, err := l.WriteString(line)
+		qc.Assert(err, qt.IsNil)
+	}
+	_, err := l.WriteString(line)
+	qc.Assert(err, qt.Equals, ErrOverflow)
+	qc.Assert(l.Dropped(), qt.Equals, 1)
+}
@@ -0,0 +1,41 @@
+package seesaw
+
+import "tinygo.org/x/drivers"
+
+var _ drivers.ADC = ADC{}
+
+// ReadAnalog returns the 10 bit conversion of an analog pin. Pin numbers are
+// those of the chip, such as 2, 3, 4 and 5 on the ATSAMD09 breakout.
+func (d *Device) ReadAnalog(pin uint8) (uint16, error) {
+	return d.read16(moduleADC, adcChannel+pin, analogDelay)
+}
+
+// SetPWM sets the duty cycle of a PWM pin, from 0 to 0xFFFF. The firmware
+// scales it to the resolution of its timer.
+func (d *Device) SetPWM(pin uint8, value uint16) error {
+	return d.write(moduleTimer, timerPWM, pin, uint8(value>>8), uint8(value))
+}
+
+// SetPWMFrequency sets the frequency of a PWM pin in Hz. Pins that share a
+// timer also share their frequency.
+func (d *Device) SetPWMFrequency(pin uint8, hz uint16) error {
+	return d.write(moduleTimer, timerFreq, pin, uint8(hz>>8), uint8(hz))
+}
+
+// ADC returns an analog pin of the chip.
+func (d *Device) ADC(pin uint8) ADC {
+	return ADC{d, pin}
+}
+
+// ADC is an analog pin of the chip. It implements drivers.ADC, which
+// ignores errors on the bus; use ReadAnalog to check them.
+type ADC struct {
+	d   *Device
+	pin uint8
+}
+
+// Get returns the value of the pin, scaled from 10 to 16 bits.
+func (a ADC) Get() uint16 {
+	v, _ := a.d.ReadAnalog(a.pin)
+	return v << 6
+}
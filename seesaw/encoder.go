@@ -0,0 +1,33 @@
+package seesaw
+
+// The rotary encoder boards run a firmware with an encoder module. The
+// push button of the encoder is a GPIO pin, pin 24 on the Adafruit board,
+// which must be configured as InputPullup.
+
+// Position returns the position of an encoder, counted since the last reset
+// of the chip. Boards with a single encoder use encoder 0.
+func (d *Device) Position(encoder uint8) (int32, error) {
+	v, err := d.read32(moduleEncoder, encoderPosition+encoder)
+	return int32(v), err
+}
+
+// SetPosition changes the position of an encoder.
+func (d *Device) SetPosition(encoder uint8, position int32) error {
+	return d.write32(moduleEncoder, encoderPosition+encoder, uint32(position))
+}
+
+// Delta returns the change of the position of an encoder since the last
+// call.
+func (d *Device) Delta(encoder uint8) (int32, error) {
+	v, err := d.read32(moduleEncoder, encoderDelta+encoder)
+	return int32(v), err
+}
+
+// SetEncoderInterrupt enables or disables the interrupt of an encoder. The
+// INT pin of the board goes low when it turns.
+func (d *Device) SetEncoderInterrupt(encoder uint8, enabled bool) error {
+	if enabled {
+		return d.write(moduleEncoder, encoderIntSet+encoder, 0x01)
+	}
+	return d.write(moduleEncoder, encoderIntClear+encoder, 0x01)
+}
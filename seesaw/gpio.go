@@ -0,0 +1,126 @@
+package seesaw
+
+import "tinygo.org/x/drivers"
+
+var _ drivers.Pin = Pin{}
+
+// PinMode is the configuration of a GPIO pin.
+type PinMode uint8
+
+// Pin modes.
+const (
+	Input PinMode = iota
+	InputPullup
+	InputPulldown
+	Output
+)
+
+// SetPinMode configures all pins set in the mask. Bit n of the mask is pin
+// n of the chip.
+func (d *Device) SetPinMode(pins uint32, mode PinMode) error {
+	if mode == Output {
+		return d.write32(moduleGPIO, gpioDirSet, pins)
+	}
+	if err := d.write32(moduleGPIO, gpioDirClear, pins); err != nil {
+		return err
+	}
+	switch mode {
+	case InputPullup:
+		if err := d.write32(moduleGPIO, gpioPullSet, pins); err != nil {
+			return err
+		}
+		// the output latch selects the direction of the pull resistor
+		return d.write32(moduleGPIO, gpioBulkSet, pins)
+	case InputPulldown:
+		if err := d.write32(moduleGPIO, gpioPullSet, pins); err != nil {
+			return err
+		}
+		return d.write32(moduleGPIO, gpioBulkClear, pins)
+	default:
+		return d.write32(moduleGPIO, gpioPullClear, pins)
+	}
+}
+
+// ReadPins returns the level of all pins set in the mask.
+func (d *Device) ReadPins(pins uint32) (uint32, error) {
+	v, err := d.read32(moduleGPIO, gpioBulk)
+	return v & pins, err
+}
+
+// SetPins sets the output pins of the mask high or low.
+func (d *Device) SetPins(pins uint32, high bool) error {
+	if high {
+		return d.write32(moduleGPIO, gpioBulkSet, pins)
+	}
+	return d.write32(moduleGPIO, gpioBulkClear, pins)
+}
+
+// TogglePins inverts the output pins of the mask.
+func (d *Device) TogglePins(pins uint32) error {
+	return d.write32(moduleGPIO, gpioBulkToggle, pins)
+}
+
+// SetInterrupts enables or disables the interrupt on change of the pins of
+// the mask. The INT pin of the board goes low when one of them changes.
+func (d *Device) SetInterrupts(pins uint32, enabled bool) error {
+	if enabled {
+		return d.write32(moduleGPIO, gpioIntSet, pins)
+	}
+	return d.write32(moduleGPIO, gpioIntClear, pins)
+}
+
+// ReadInterrupts returns the pins that changed since the last call, which
+// also releases the INT pin.
+func (d *Device) ReadInterrupts() (uint32, error) {
+	return d.read32(moduleGPIO, gpioIntFlag)
+}
+
+// Pin returns a single GPIO pin of the chip.
+func (d *Device) Pin(n uint8) Pin {
+	return Pin{d, 1 << n}
+}
+
+// Pin is a single GPIO pin of the chip. It implements drivers.Pin. Errors
+// on the bus are ignored by the methods of that interface; use Read and
+// Write to check them.
+type Pin struct {
+	d    *Device
+	mask uint32
+}
+
+// Configure sets the mode of the pin.
+func (p Pin) Configure(mode PinMode) error {
+	return p.d.SetPinMode(p.mask, mode)
+}
+
+// Read returns the level of the pin.
+func (p Pin) Read() (bool, error) {
+	v, err := p.d.ReadPins(p.mask)
+	return v != 0, err
+}
+
+// Write sets the level of an output pin.
+func (p Pin) Write(high bool) error {
+	return p.d.SetPins(p.mask, high)
+}
+
+// Get returns the level of the pin.
+func (p Pin) Get() bool {
+	v, _ := p.Read()
+	return v
+}
+
+// Set sets the level of an output pin.
+func (p Pin) Set(high bool) {
+	p.Write(high)
+}
+
+// High sets an output pin high.
+func (p Pin) High() {
+	p.Write(true)
+}
+
+// Low sets an output pin low.
+func (p Pin) Low() {
+	p.Write(false)
+}
@@ -0,0 +1,58 @@
+package seesaw
+
+import (
+	"errors"
+	"image/color"
+)
+
+var errTooManyPixels = errors.New("seesaw: too many pixels")
+
+// neoPixelChunk is the number of color bytes sent in one transfer, which
+// must fit in the 32 byte receive buffer of the chip with the headers.
+const neoPixelChunk = 24
+
+// ConfigureNeoPixel sets the pin of a strip of WS2812 LEDs driven by the
+// chip and the number of LEDs. The buffer of the chip holds 63 LEDs on the
+// ATSAMD09 and up to 250 on the ATtiny parts.
+func (d *Device) ConfigureNeoPixel(pin uint8, count int) error {
+	n := count * 3
+	if n > 0xFFFF {
+		return errTooManyPixels
+	}
+	// 800kHz, the speed of all current LEDs
+	if err := d.write(moduleNeoPixel, neoPixelSpeed, 1); err != nil {
+		return err
+	}
+	if err := d.write(moduleNeoPixel, neoPixelBufLength, uint8(n>>8), uint8(n)); err != nil {
+		return err
+	}
+	return d.write(moduleNeoPixel, neoPixelPin, pin)
+}
+
+// WriteColors copies the colors to the buffer of the chip, starting with
+// the first LED, and shows them.
+func (d *Device) WriteColors(colors []color.RGBA) error {
+	var chunk [2 + neoPixelChunk]uint8
+	offset, n := 0, 0
+	for i, c := range colors {
+		chunk[2+n] = c.G
+		chunk[3+n] = c.R
+		chunk[4+n] = c.B
+		n += 3
+		if n == neoPixelChunk || i == len(colors)-1 {
+			chunk[0] = uint8(offset >> 8)
+			chunk[1] = uint8(offset)
+			if err := d.write(moduleNeoPixel, neoPixelBuf, chunk[:2+n]...); err != nil {
+				return err
+			}
+			offset += n
+			n = 0
+		}
+	}
+	return d.Show()
+}
+
+// Show latches the buffer of the chip to the LEDs.
+func (d *Device) Show() error {
+	return d.write(moduleNeoPixel, neoPixelShow)
+}
@@ -0,0 +1,90 @@
+package seesaw
+
+// Default I2C addresses. Most breakouts use Address; the soil sensor and the
+// rotary encoder boards use AddressSoil and AddressEncoder. The low address
+// bits can be changed with solder jumpers.
+const (
+	Address        = 0x49
+	AddressSoil    = 0x36
+	AddressEncoder = 0x36
+)
+
+// Module base addresses.
+const (
+	moduleStatus   = 0x00
+	moduleGPIO     = 0x01
+	moduleTimer    = 0x08
+	moduleADC      = 0x09
+	moduleNeoPixel = 0x0E
+	moduleTouch    = 0x0F
+	moduleEncoder  = 0x11
+)
+
+// Status module functions.
+const (
+	statusHWID    = 0x01
+	statusVersion = 0x02
+	statusOptions = 0x03
+	statusTemp    = 0x04
+	statusSWRST   = 0x7F
+)
+
+// Hardware ID codes returned by the status module.
+const (
+	hwIDSAMD09   = 0x55
+	hwIDTiny806  = 0x84
+	hwIDTiny807  = 0x85
+	hwIDTiny816  = 0x86
+	hwIDTiny817  = 0x87
+	hwIDTiny1616 = 0x88
+	hwIDTiny1617 = 0x89
+)
+
+// GPIO module functions. All of them take a 32 bit big endian pin mask.
+const (
+	gpioDirSet     = 0x02
+	gpioDirClear   = 0x03
+	gpioBulk       = 0x04
+	gpioBulkSet    = 0x05
+	gpioBulkClear  = 0x06
+	gpioBulkToggle = 0x07
+	gpioIntSet     = 0x08
+	gpioIntClear   = 0x09
+	gpioIntFlag    = 0x0A
+	gpioPullSet    = 0x0B
+	gpioPullClear  = 0x0C
+)
+
+// Timer module functions.
+const (
+	timerPWM  = 0x01
+	timerFreq = 0x02
+)
+
+// ADC module functions. A channel is read at adcChannel plus its pin number.
+const (
+	adcChannel = 0x07
+)
+
+// NeoPixel module functions.
+const (
+	neoPixelPin       = 0x01
+	neoPixelSpeed     = 0x02
+	neoPixelBufLength = 0x03
+	neoPixelBuf       = 0x04
+	neoPixelShow      = 0x05
+)
+
+// Touch module functions. A channel is read at touchChannel plus its
+// number.
+const (
+	touchChannel = 0x10
+)
+
+// Encoder module functions. The encoder number is added to the function.
+const (
+	encoderIntSet   = 0x10
+	encoderIntClear = 0x20
+	encoderPosition = 0x30
+	encoderDelta    = 0x40
+)
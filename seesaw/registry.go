@@ -0,0 +1,20 @@
+package seesaw
+
+import (
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/registry"
+)
+
+func init() {
+	registry.Register(registry.Driver{
+		Name:      "seesaw",
+		Bus:       registry.I2C,
+		Addresses: []uint16{Address, AddressSoil},
+		Probe: func(bus drivers.I2C, address uint16) bool {
+			d := New(bus)
+			d.Address = address
+			id, err := d.HardwareID()
+			return err == nil && knownHardwareID(id)
+		},
+	})
+}
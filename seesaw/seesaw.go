@@ -0,0 +1,136 @@
+// Package seesaw provides a driver for the Adafruit seesaw, a firmware for
+// small ATSAMD09 and ATtiny microcontrollers that turns them into I2C
+// peripherals with GPIO, ADC, PWM and NeoPixel modules. It is used on many
+// breakout boards, among them the I2C rotary encoder and the capacitive
+// soil moisture sensor.
+//
+// Every register is addressed by a module base and a function. The chip
+// needs some time to prepare the answer to a read, so reads are done as a
+// write of the register followed by a delay and a separate read.
+//
+// Datasheet:
+// https://learn.adafruit.com/adafruit-seesaw-atsamd09-breakout/reading-and-writing-data
+package seesaw // import "tinygo.org/x/drivers/seesaw"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var errNotFound = errors.New("seesaw: unknown hardware ID")
+
+// Time the chip needs to prepare the answer to a read. Conversions take
+// longer than register reads.
+const (
+	readDelay   = 250 * time.Microsecond
+	analogDelay = 500 * time.Microsecond
+	touchDelay  = 3 * time.Millisecond
+)
+
+// Device is a seesaw chip on an I2C bus.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+	buf     [32]byte
+}
+
+// New returns a seesaw device. The I2C bus must already be configured.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.I2C) *Device {
+	return &Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Configure resets the chip and checks that it runs the seesaw firmware.
+func (d *Device) Configure() error {
+	if err := d.write(moduleStatus, statusSWRST, 0xFF); err != nil {
+		return err
+	}
+	time.Sleep(10 * time.Millisecond)
+	id, err := d.HardwareID()
+	if err != nil {
+		return err
+	}
+	if !knownHardwareID(id) {
+		return errNotFound
+	}
+	return nil
+}
+
+// knownHardwareID returns whether the code returned by HardwareID is one of
+// a microcontroller running the seesaw firmware.
+func knownHardwareID(id uint8) bool {
+	switch id {
+	case hwIDSAMD09, hwIDTiny806, hwIDTiny807, hwIDTiny816, hwIDTiny817, hwIDTiny1616, hwIDTiny1617:
+		return true
+	}
+	return false
+}
+
+// HardwareID returns the code of the microcontroller: 0x55 for the
+// ATSAMD09 and 0x84 to 0x89 for the ATtiny parts.
+func (d *Device) HardwareID() (uint8, error) {
+	err := d.read(moduleStatus, statusHWID, d.buf[:1], readDelay)
+	return d.buf[0], err
+}
+
+// ProductCode returns the Adafruit product number of the board, such as
+// 4991 for the rotary encoder.
+func (d *Device) ProductCode() (uint16, error) {
+	v, err := d.read32(moduleStatus, statusVersion)
+	return uint16(v >> 16), err
+}
+
+// Options returns the modules compiled into the firmware, one bit per
+// module base.
+func (d *Device) Options() (uint32, error) {
+	return d.read32(moduleStatus, statusOptions)
+}
+
+// ReadTemperature returns the temperature of the chip in milli-degrees
+// Celsius. It is only available on the ATSAMD09.
+func (d *Device) ReadTemperature() (int32, error) {
+	v, err := d.read32(moduleStatus, statusTemp)
+	// the value is a 16.16 fixed point number
+	return int32(int64(v&0x3FFFFFFF) * 1000 >> 16), err
+}
+
+// write sends data to a function of a module.
+func (d *Device) write(module, function uint8, data ...uint8) error {
+	d.buf[0] = module
+	d.buf[1] = function
+	n := copy(d.buf[2:], data)
+	return d.bus.Tx(d.Address, d.buf[:2+n], nil)
+}
+
+// write32 sends a big endian value to a function of a module.
+func (d *Device) write32(module, function uint8, v uint32) error {
+	return d.write(module, function, uint8(v>>24), uint8(v>>16), uint8(v>>8), uint8(v))
+}
+
+// read selects a function of a module, waits for the chip and reads the
+// answer in buf.
+func (d *Device) read(module, function uint8, buf []byte, delay time.Duration) error {
+	if err := d.bus.Tx(d.Address, []byte{module, function}, nil); err != nil {
+		return err
+	}
+	time.Sleep(delay)
+	return d.bus.Tx(d.Address, nil, buf)
+}
+
+// read16 reads a big endian 16 bit value.
+func (d *Device) read16(module, function uint8, delay time.Duration) (uint16, error) {
+	err := d.read(module, function, d.buf[:2], delay)
+	return uint16(d.buf[0])<<8 | uint16(d.buf[1]), err
+}
+
+// read32 reads a big endian 32 bit value.
+func (d *Device) read32(module, function uint8) (uint32, error) {
+	err := d.read(module, function, d.buf[:4], readDelay)
+	return uint32(d.buf[0])<<24 | uint32(d.buf[1])<<16 | uint32(d.buf[2])<<8 | uint32(d.buf[3]), err
+}
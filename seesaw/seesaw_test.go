@@ -0,0 +1,112 @@
+package seesaw
+
+import (
+	"image/color"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// fakeBus records the writes to the chip and answers reads from a table of
+// registers keyed by module and function.
+type fakeBus struct {
+	regs     map[[2]uint8][]byte
+	writes   [][]byte
+	selected [2]uint8
+}
+
+func newFakeBus() *fakeBus {
+	return &fakeBus{regs: map[[2]uint8][]byte{
+		{moduleStatus, statusHWID}: {hwIDSAMD09},
+	}}
+}
+
+func (b *fakeBus) ReadRegister(addr uint8, r uint8, buf []byte) error  { return nil }
+func (b *fakeBus) WriteRegister(addr uint8, r uint8, buf []byte) error { return nil }
+
+func (b *fakeBus) Tx(addr uint16, w, r []byte) error {
+	if len(w) >= 2 {
+		b.selected = [2]uint8{w[0], w[1]}
+		if len(w) > 2 {
+			b.writes = append(b.writes, append([]byte(nil), w...))
+		}
+	}
+	if len(r) > 0 {
+		copy(r, b.regs[b.selected])
+	}
+	return nil
+}
+
+func TestConfigure(t *testing.T) {
+	c := qt.New(t)
+	bus := newFakeBus()
+	d := New(bus)
+	c.Assert(d.Configure(), qt.IsNil)
+	c.Assert(bus.writes, qt.DeepEquals, [][]byte{{moduleStatus, statusSWRST, 0xFF}})
+
+	bus.regs[[2]uint8{moduleStatus, statusHWID}] = []byte{0x12}
+	c.Assert(d.Configure(), qt.Equals, errNotFound)
+}
+
+func TestGPIO(t *testing.T) {
+	c := qt.New(t)
+	bus := newFakeBus()
+	d := New(bus)
+	c.Assert(d.Pin(24).Configure(InputPullup), qt.IsNil)
+	c.Assert(bus.writes, qt.DeepEquals, [][]byte{
+		{moduleGPIO, gpioDirClear, 0x01, 0, 0, 0},
+		{moduleGPIO, gpioPullSet, 0x01, 0, 0, 0},
+		{moduleGPIO, gpioBulkSet, 0x01, 0, 0, 0},
+	})
+
+	bus.regs[[2]uint8{moduleGPIO, gpioBulk}] = []byte{0x01, 0, 0, 0x04}
+	c.Assert(d.Pin(24).Get(), qt.IsTrue)
+	c.Assert(d.Pin(1).Get(), qt.IsFalse)
+	v, err := d.ReadPins(0xFFFF)
+	c.Assert(err, qt.IsNil)
+	c.Assert(v, qt.Equals, uint32(0x04))
+}
+
+func TestReadings(t *testing.T) {
+	c := qt.New(t)
+	bus := newFakeBus()
+	d := New(bus)
+
+	bus.regs[[2]uint8{moduleStatus, statusTemp}] = []byte{0, 0x19, 0x80, 0}
+	temp, err := d.ReadTemperature()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(25500))
+
+	bus.regs[[2]uint8{moduleEncoder, encoderPosition}] = []byte{0xFF, 0xFF, 0xFF, 0xFD}
+	pos, err := d.Position(0)
+	c.Assert(err, qt.IsNil)
+	c.Assert(pos, qt.Equals, int32(-3))
+
+	bus.regs[[2]uint8{moduleADC, adcChannel + 2}] = []byte{0x03, 0xFF}
+	c.Assert(d.ADC(2).Get(), qt.Equals, uint16(0xFFC0))
+
+	bus.regs[[2]uint8{moduleTouch, touchChannel}] = []byte{0xFF, 0xFF}
+	_, err = d.ReadMoisture()
+	c.Assert(err, qt.Equals, errNoReading)
+	bus.regs[[2]uint8{moduleTouch, touchChannel}] = []byte{0x01, 0x2C}
+	moisture, err := d.ReadMoisture()
+	c.Assert(err, qt.IsNil)
+	c.Assert(moisture, qt.Equals, uint16(300))
+}
+
+func TestWriteColors(t *testing.T) {
+	c := qt.New(t)
+	bus := newFakeBus()
+	d := New(bus)
+	colors := make([]color.RGBA, 10)
+	for i := range colors {
+		colors[i] = color.RGBA{R: uint8(i), G: 0x10, B: 0x20}
+	}
+	c.Assert(d.WriteColors(colors), qt.IsNil)
+	c.Assert(bus.writes, qt.HasLen, 2)
+	c.Assert(bus.writes[0][:7], qt.DeepEquals, []byte{moduleNeoPixel, neoPixelBuf, 0, 0, 0x10, 0, 0x20})
+	c.Assert(bus.writes[0], qt.HasLen, 4+24)
+	c.Assert(bus.writes[1], qt.DeepEquals, []byte{moduleNeoPixel, neoPixelBuf, 0, 24, 0x10, 8, 0x20, 0x10, 9, 0x20})
+	// show has no data, so it is not recorded as a write
+	c.Assert(bus.selected, qt.Equals, [2]uint8{moduleNeoPixel, neoPixelShow})
+}
@@ -0,0 +1,31 @@
+package seesaw
+
+import (
+	"errors"
+	"time"
+)
+
+var errNoReading = errors.New("seesaw: no touch reading")
+
+// ReadMoisture returns the capacitance measured by the probe of the soil
+// sensor, from about 200 in dry air to 2000 in water. The chip answers
+// 0xFFFF while the measurement is still running, which is retried a few
+// times. The temperature of the soil is given by ReadTemperature.
+func (d *Device) ReadMoisture() (uint16, error) {
+	return d.ReadTouch(0)
+}
+
+// ReadTouch returns the value of a capacitive touch channel.
+func (d *Device) ReadTouch(channel uint8) (uint16, error) {
+	for i := 0; i < 3; i++ {
+		v, err := d.read16(moduleTouch, touchChannel+channel, touchDelay)
+		if err != nil {
+			return 0, err
+		}
+		if v != 0xFFFF {
+			return v, nil
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return 0, errNoReading
+}
@@ -0,0 +1,32 @@
+package drivers
+
+// Thermometer measures a temperature. It is implemented by many sensors, such
+// as the bme280, bmp180, bmp280, tmp102, adt7410, sht3x and dht drivers.
+type Thermometer interface {
+	// ReadTemperature returns the temperature in milli-degrees Celsius.
+	ReadTemperature() (int32, error)
+}
+
+// Hygrometer measures the relative humidity of the air. It is implemented by
+// the aht20, bme280, dht, htu21d, sht4x and si7021 drivers, and by the
+// Hygrometer method of the sht3x driver.
+type Hygrometer interface {
+	// ReadHumidity returns the relative humidity in hundredths of a percent.
+	ReadHumidity() (int32, error)
+}
+
+// Barometer measures the atmospheric pressure. It is implemented by the
+// bme280, bmp180, bmp280 and bmp388 drivers.
+type Barometer interface {
+	// ReadPressure returns the pressure in milli-pascals.
+	ReadPressure() (int32, error)
+}
+
+// Accelerometer measures the acceleration on three axes. It is implemented
+// by the adxl345, lis3dh and mma8653 drivers.
+type Accelerometer interface {
+	// ReadAcceleration returns the acceleration in micro-gravity (µg), so
+	// that an axis pointing straight to the ground of a still sensor reads
+	// around 1000000.
+	ReadAcceleration() (x, y, z int32, err error)
+}
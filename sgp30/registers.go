@@ -0,0 +1,23 @@
+package sgp30
+
+// The I2C address of the device.
+const Address = 0x58
+
+// Commands. Names copied from the datasheet.
+const (
+	CMD_IAQ_INIT              = 0x2003
+	CMD_MEASURE_IAQ           = 0x2008
+	CMD_GET_IAQ_BASELINE      = 0x2015
+	CMD_SET_IAQ_BASELINE      = 0x201E
+	CMD_SET_ABSOLUTE_HUMIDITY = 0x2061
+	CMD_MEASURE_TEST          = 0x2032
+	CMD_GET_FEATURE_SET       = 0x202F
+	CMD_MEASURE_RAW           = 0x2050
+	CMD_GET_SERIAL_ID         = 0x3682
+)
+
+// The soft reset, sent to the general call address 0.
+const GENERAL_CALL_RESET = 0x06
+
+// The result of a successful CMD_MEASURE_TEST.
+const TEST_OK = 0xD400
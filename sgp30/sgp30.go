@@ -0,0 +1,285 @@
+// Package sgp30 provides a driver for the SGP30 gas sensor by Sensirion,
+// which measures the total volatile organic compounds (TVOC) and derives an
+// equivalent CO2 concentration (eCO2) from them.
+//
+// The sensor adjusts its baseline, the signal of clean air, dynamically: the
+// air quality must be measured every second, which PollTask does with a
+// scheduler.Scheduler. The baseline is valid after 12 hours of operation,
+// and can be saved with the calibration package and restored after a power
+// cycle to skip this adjustment:
+//
+//	var baseline sgp30.Baseline
+//	if calibration.Load(store, "sgp30", 1, &baseline) == nil {
+//		sensor.SetBaseline(baseline)
+//	}
+//	// every hour
+//	if baseline, err := sensor.Baseline(); err == nil {
+//		calibration.Save(store, "sgp30", 1, baseline)
+//	}
+//
+// Datasheet:
+// https://sensirion.com/media/documents/984E0DD5/61644B8B/Sensirion_Gas_Sensors_Datasheet_SGP30.pdf
+//
+package sgp30 // import "tinygo.org/x/drivers/sgp30"
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/internal/crc8"
+)
+
+var (
+	errChecksum     = errors.New("sgp30: checksum mismatch")
+	errSelfTest     = errors.New("sgp30: self-test failed")
+	errBaselineData = errors.New("sgp30: invalid baseline data")
+)
+
+var _ drivers.PowerManaged = &Device{}
+
+// Baseline is the baseline of the two air quality signals.
+type Baseline struct {
+	ECO2 uint16
+	TVOC uint16
+}
+
+// MarshalBinary encodes the baseline in 4 bytes, to store it with the
+// calibration package.
+func (b Baseline) MarshalBinary() ([]byte, error) {
+	return []byte{byte(b.ECO2), byte(b.ECO2 >> 8), byte(b.TVOC), byte(b.TVOC >> 8)}, nil
+}
+
+// UnmarshalBinary decodes a baseline encoded by MarshalBinary.
+func (b *Baseline) UnmarshalBinary(data []byte) error {
+	if len(data) != 4 {
+		return errBaselineData
+	}
+	b.ECO2 = uint16(data[0]) | uint16(data[1])<<8
+	b.TVOC = uint16(data[2]) | uint16(data[3])<<8
+	return nil
+}
+
+// Device wraps an I2C connection to an SGP30 device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	eco2 uint16
+	tvoc uint16
+
+	buf [9]byte
+}
+
+// New creates a new SGP30 connection. The I2C bus must already be
+// configured.
+//
+// This function only creates the Device object, it does not touch the
+// device.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Connected returns whether an SGP30 has been found, from the product type
+// of its feature set.
+func (d *Device) Connected() bool {
+	if err := d.command(CMD_GET_FEATURE_SET, 10*time.Millisecond); err != nil {
+		return false
+	}
+	if err := d.read(1); err != nil {
+		return false
+	}
+	return d.buf[0]>>4 == 0
+}
+
+// Configure starts the air quality measurements, which return 400ppm and
+// 0ppb for the first 15 seconds. The baseline is reset and can be restored
+// with SetBaseline afterwards.
+func (d *Device) Configure() error {
+	return d.command(CMD_IAQ_INIT, 10*time.Millisecond)
+}
+
+// Reset sends a soft reset, after which the sensor is in sleep mode as at
+// power on. It is sent to the general call address, so it also resets the
+// other devices of the bus that support it.
+func (d *Device) Reset() error {
+	d.buf[0] = GENERAL_CALL_RESET
+	if err := d.bus.Tx(0, d.buf[:1], nil); err != nil {
+		return err
+	}
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+// Sleep stops the measurements with Reset, which is the only way back to
+// sleep mode. The baseline is lost and should be saved with Baseline
+// first.
+func (d *Device) Sleep() error {
+	return d.Reset()
+}
+
+// Wake starts the measurements again like Configure.
+func (d *Device) Wake() error {
+	return d.Configure()
+}
+
+// SupplyCurrent returns the typical currents of the datasheet: 2µA in sleep
+// mode and 48mA while measuring.
+func (d *Device) SupplyCurrent() (sleeping, active uint32) {
+	return 2, 48000
+}
+
+// MeasureAirQuality measures and returns the equivalent CO2 concentration in
+// ppm, from 400ppm to 60000ppm, and the TVOC concentration in ppb, from 0ppb
+// to 60000ppb. It must be called every second for the dynamic baseline
+// compensation.
+func (d *Device) MeasureAirQuality() (eco2 uint16, tvoc uint16, err error) {
+	if err = d.command(CMD_MEASURE_IAQ, 12*time.Millisecond); err != nil {
+		return
+	}
+	if err = d.read(2); err != nil {
+		return
+	}
+	d.eco2, d.tvoc = d.word(0), d.word(1)
+	return d.eco2, d.tvoc, nil
+}
+
+// ECO2 returns the equivalent CO2 concentration in ppm of the last
+// measurement.
+func (d *Device) ECO2() uint16 {
+	return d.eco2
+}
+
+// TVOC returns the TVOC concentration in ppb of the last measurement.
+func (d *Device) TVOC() uint16 {
+	return d.tvoc
+}
+
+// ReadRaw returns the raw signals of the H2 and ethanol gas sensors, which
+// the air quality is computed from.
+func (d *Device) ReadRaw() (h2 uint16, ethanol uint16, err error) {
+	if err = d.command(CMD_MEASURE_RAW, 25*time.Millisecond); err != nil {
+		return
+	}
+	if err = d.read(2); err != nil {
+		return
+	}
+	return d.word(0), d.word(1), nil
+}
+
+// Baseline returns the current baseline, to restore it with SetBaseline
+// after a power cycle. It should be saved every hour once the sensor has run
+// for 12 hours, and is not valid after a week without running.
+func (d *Device) Baseline() (Baseline, error) {
+	if err := d.command(CMD_GET_IAQ_BASELINE, 10*time.Millisecond); err != nil {
+		return Baseline{}, err
+	}
+	if err := d.read(2); err != nil {
+		return Baseline{}, err
+	}
+	return Baseline{ECO2: d.word(0), TVOC: d.word(1)}, nil
+}
+
+// SetBaseline restores a baseline returned by Baseline. It must be called
+// after Configure.
+func (d *Device) SetBaseline(baseline Baseline) error {
+	// in the reverse order of Baseline
+	return d.command(CMD_SET_IAQ_BASELINE, 10*time.Millisecond, baseline.TVOC, baseline.ECO2)
+}
+
+// SetAbsoluteHumidity sets the absolute humidity of the air in mg/m³, such
+// as the one returned by AbsoluteHumidity, which the sensor compensates for.
+// 0 disables the compensation, which is disabled after Configure.
+func (d *Device) SetAbsoluteHumidity(humidity int32) error {
+	// in g/m³, in 8.8 fixed point
+	h := int64(humidity) * 256 / 1000
+	switch {
+	case h < 0:
+		h = 0
+	case h > 0xFFFF:
+		h = 0xFFFF
+	case h == 0 && humidity > 0:
+		// 0 would disable the compensation
+		h = 1
+	}
+	return d.command(CMD_SET_ABSOLUTE_HUMIDITY, 10*time.Millisecond, uint16(h))
+}
+
+// AbsoluteHumidity returns the absolute humidity in mg/m³ from a relative
+// humidity in hundredths of a percent and a temperature in celsius milli
+// degrees, the units of another sensor such as a bme280 or a dht.
+func AbsoluteHumidity(humidity int32, temp int32) int32 {
+	t := float64(temp) / 1000
+	rh := float64(humidity) / 100
+	// formula of the datasheet
+	ah := 216.7 * (rh / 100 * 6.112 * math.Exp(17.62*t/(243.12+t)) / (273.15 + t))
+	return int32(ah * 1000)
+}
+
+// SelfTest runs the on-chip self-test, which resets the baseline: it must be
+// followed by Configure.
+func (d *Device) SelfTest() error {
+	if err := d.command(CMD_MEASURE_TEST, 220*time.Millisecond); err != nil {
+		return err
+	}
+	if err := d.read(1); err != nil {
+		return err
+	}
+	if d.word(0) != TEST_OK {
+		return errSelfTest
+	}
+	return nil
+}
+
+// SerialNumber returns the 48-bit serial number of the sensor.
+func (d *Device) SerialNumber() (uint64, error) {
+	if err := d.command(CMD_GET_SERIAL_ID, time.Millisecond); err != nil {
+		return 0, err
+	}
+	if err := d.read(3); err != nil {
+		return 0, err
+	}
+	return uint64(d.word(0))<<32 | uint64(d.word(1))<<16 | uint64(d.word(2)), nil
+}
+
+// command sends a command with its parameters, each followed by its
+// checksum, and waits for its execution.
+func (d *Device) command(cmd uint16, wait time.Duration, params ...uint16) error {
+	d.buf[0] = byte(cmd >> 8)
+	d.buf[1] = byte(cmd)
+	n := 2
+	for _, p := range params {
+		d.buf[n] = byte(p >> 8)
+		d.buf[n+1] = byte(p)
+		d.buf[n+2] = crc8.Sensirion(d.buf[n : n+2])
+		n += 3
+	}
+	if err := d.bus.Tx(d.Address, d.buf[:n], nil); err != nil {
+		return err
+	}
+	time.Sleep(wait)
+	return nil
+}
+
+// read reads the words of an answer and checks their checksums.
+func (d *Device) read(words int) error {
+	data := d.buf[:3*words]
+	if err := d.bus.Tx(d.Address, nil, data); err != nil {
+		return err
+	}
+	for i := 0; i < len(data); i += 3 {
+		if crc8.Sensirion(data[i:i+2]) != data[i+2] {
+			return errChecksum
+		}
+	}
+	return nil
+}
+
+// word returns a word read by read.
+func (d *Device) word(i int) uint16 {
+	return uint16(d.buf[3*i])<<8 | uint16(d.buf[3*i+1])
+}
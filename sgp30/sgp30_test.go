@@ -0,0 +1,143 @@
+package sgp30
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers/internal/crc8"
+	"tinygo.org/x/drivers/tester"
+)
+
+// sensor is a simulated SGP30, whose measurement is 450ppm and 12ppb.
+type sensor struct {
+	commands []uint16
+	params   [][]uint16
+	answer   []uint16
+	baseline [2]uint16
+	humidity uint16
+}
+
+func (s *sensor) tx(w, r []byte) error {
+	if len(w) >= 2 {
+		cmd := uint16(w[0])<<8 | uint16(w[1])
+		var params []uint16
+		for i := 2; i+3 <= len(w); i += 3 {
+			if crc8.Sensirion(w[i:i+2]) != w[i+2] {
+				return tester.ErrNack
+			}
+			params = append(params, uint16(w[i])<<8|uint16(w[i+1]))
+		}
+		s.commands = append(s.commands, cmd)
+		s.params = append(s.params, params)
+		s.command(cmd, params)
+	}
+	if len(r) > 0 {
+		if len(r) != 3*len(s.answer) {
+			return tester.ErrNack
+		}
+		for i, w := range s.answer {
+			r[3*i] = byte(w >> 8)
+			r[3*i+1] = byte(w)
+			r[3*i+2] = crc8.Sensirion(r[3*i : 3*i+2])
+		}
+		s.answer = nil
+	}
+	return nil
+}
+
+// bus returns an I2C bus with the sensor on it.
+func (s *sensor) bus(c *qt.C) *tester.I2CBus {
+	bus := tester.NewI2CBus(c)
+	bus.AddDevice(tester.NewI2CCommandDevice(c, Address, s.tx))
+	return bus
+}
+
+func (s *sensor) command(cmd uint16, params []uint16) {
+	switch cmd {
+	case CMD_GET_FEATURE_SET:
+		s.answer = []uint16{0x0022}
+	case CMD_IAQ_INIT:
+		s.baseline = [2]uint16{}
+	case CMD_MEASURE_IAQ:
+		s.answer = []uint16{450, 12}
+	case CMD_MEASURE_RAW:
+		s.answer = []uint16{13600, 19000}
+	case CMD_GET_IAQ_BASELINE:
+		s.answer = s.baseline[:]
+	case CMD_SET_IAQ_BASELINE:
+		s.baseline = [2]uint16{params[1], params[0]}
+	case CMD_SET_ABSOLUTE_HUMIDITY:
+		s.humidity = params[0]
+	case CMD_MEASURE_TEST:
+		s.answer = []uint16{TEST_OK}
+	case CMD_GET_SERIAL_ID:
+		s.answer = []uint16{0x0000, 0x0123, 0x4567}
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{}
+	d := New(s.bus(c))
+	c.Assert(d.Connected(), qt.IsTrue)
+	c.Assert(d.Configure(), qt.IsNil)
+
+	eco2, tvoc, err := d.MeasureAirQuality()
+	c.Assert(err, qt.IsNil)
+	c.Assert(eco2, qt.Equals, uint16(450))
+	c.Assert(tvoc, qt.Equals, uint16(12))
+	c.Assert(s.commands, qt.DeepEquals, []uint16{CMD_GET_FEATURE_SET, CMD_IAQ_INIT, CMD_MEASURE_IAQ})
+
+	task := PollTask(&d)
+	d.eco2, d.tvoc = 0, 0
+	c.Assert(task.Poll(), qt.IsNil)
+	c.Assert(d.ECO2(), qt.Equals, uint16(450))
+	c.Assert(d.TVOC(), qt.Equals, uint16(12))
+
+	h2, ethanol, err := d.ReadRaw()
+	c.Assert(err, qt.IsNil)
+	c.Assert(h2, qt.Equals, uint16(13600))
+	c.Assert(ethanol, qt.Equals, uint16(19000))
+
+	c.Assert(d.SelfTest(), qt.IsNil)
+	serial, err := d.SerialNumber()
+	c.Assert(err, qt.IsNil)
+	c.Assert(serial, qt.Equals, uint64(0x01234567))
+}
+
+func TestBaseline(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{}
+	d := New(s.bus(c))
+	c.Assert(d.SetBaseline(Baseline{ECO2: 0x8973, TVOC: 0x8AAE}), qt.IsNil)
+	c.Assert(s.params[0], qt.DeepEquals, []uint16{0x8AAE, 0x8973})
+	baseline, err := d.Baseline()
+	c.Assert(err, qt.IsNil)
+	c.Assert(baseline, qt.Equals, Baseline{ECO2: 0x8973, TVOC: 0x8AAE})
+
+	data, err := baseline.MarshalBinary()
+	c.Assert(err, qt.IsNil)
+	var decoded Baseline
+	c.Assert(decoded.UnmarshalBinary(data), qt.IsNil)
+	c.Assert(decoded, qt.Equals, baseline)
+	c.Assert(decoded.UnmarshalBinary(data[:3]), qt.Equals, errBaselineData)
+}
+
+func TestHumidity(t *testing.T) {
+	c := qt.New(t)
+	// 50% at 25°C
+	c.Assert(AbsoluteHumidity(5000, 25000), qt.Equals, int32(11483))
+
+	s := &sensor{}
+	d := New(s.bus(c))
+	// 11.757g/m³ in 8.8 fixed point
+	c.Assert(d.SetAbsoluteHumidity(11757), qt.IsNil)
+	c.Assert(s.humidity, qt.Equals, uint16(0x0BC1))
+	c.Assert(d.SetAbsoluteHumidity(0), qt.IsNil)
+	c.Assert(s.humidity, qt.Equals, uint16(0))
+	c.Assert(d.SetAbsoluteHumidity(1), qt.IsNil)
+	c.Assert(s.humidity, qt.Equals, uint16(1))
+	c.Assert(d.SetAbsoluteHumidity(300000), qt.IsNil)
+	c.Assert(s.humidity, qt.Equals, uint16(0xFFFF))
+}
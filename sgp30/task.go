@@ -0,0 +1,22 @@
+package sgp30
+
+import (
+	"time"
+
+	"tinygo.org/x/drivers/scheduler"
+)
+
+// PollTask returns a task that measures the air quality every second, as
+// the dynamic baseline compensation requires, for a scheduler.Scheduler.
+// The ECO2 and TVOC methods of the device then return the last values
+// measured.
+func PollTask(d *Device) scheduler.Task {
+	return scheduler.Task{
+		Name: "sgp30",
+		Poll: func() error {
+			_, _, err := d.MeasureAirQuality()
+			return err
+		},
+		Interval: time.Second,
+	}
+}
@@ -0,0 +1,20 @@
+// +build tinygo
+
+package shifter
+
+import (
+	"machine"
+
+	"tinygo.org/x/drivers"
+)
+
+// configurePin makes a GPIO pin an output or an input.
+func configurePin(pin drivers.Pin, output bool) {
+	if pin, ok := pin.(machine.Pin); ok {
+		mode := machine.PinInput
+		if output {
+			mode = machine.PinOutput
+		}
+		pin.Configure(machine.PinConfig{Mode: mode})
+	}
+}
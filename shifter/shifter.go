@@ -1,9 +1,16 @@
 // Package shifter is for 8bit shift register, most common are 74HC165 and 74165
+//
+// Registers can be chained to read any number of inputs over three pins, by
+// connecting the serial output of each register to the serial input of the
+// previous one. Inputs such as buttons and limit switches can be debounced
+// by calling Scan regularly.
 package shifter // import "tinygo.org/x/drivers/shifter"
 
 import (
 	"errors"
-	"machine"
+	"time"
+
+	"tinygo.org/x/drivers"
 )
 
 const (
@@ -16,22 +23,34 @@ type NumberBit int8
 
 // Device holds the Pins.
 type Device struct {
-	latch machine.Pin
-	clk   machine.Pin
-	out   machine.Pin
+	latch drivers.Pin
+	clk   drivers.Pin
+	out   drivers.Pin
 	Pins  []ShiftPin
 	bits  NumberBit
+
+	// Debounce is the time an input must be stable before Scan changes its
+	// state.
+	Debounce time.Duration
+
+	// OnChange is called by Scan when the debounced state of an input
+	// changes.
+	OnChange func(pin int, state bool)
 }
 
 // ShiftPin is the implementation of the ShiftPin interface.
 type ShiftPin struct {
-	pin     machine.Pin
+	pin     int
 	d       *Device
 	pressed bool
+	raw     bool // level at the last scan
+	changed time.Time
 }
 
-// New returns a new shifter driver given the correct pins.
-func New(numBits NumberBit, latch, clk, out machine.Pin) Device {
+// New returns a new shifter driver given the correct pins. The pins can be
+// pins of an I/O expander; machine.Pin pins are configured by Configure,
+// others must already be configured.
+func New(numBits NumberBit, latch, clk, out drivers.Pin) Device {
 	return Device{
 		latch: latch,
 		clk:   clk,
@@ -41,11 +60,20 @@ func New(numBits NumberBit, latch, clk, out machine.Pin) Device {
 	}
 }
 
+// NewChain returns a new shifter driver for a chain of 8 bit registers.
+// The register connected to the out pin is read first and has the highest
+// inputs, with its input H as the last one, the next register in the chain
+// has the 8 inputs below, and so on: inputs 0 to 7 are the inputs A to H of
+// the last register of the chain. At most 15 registers are supported.
+func NewChain(registers int, latch, clk, out drivers.Pin) Device {
+	return New(NumberBit(registers*8), latch, clk, out)
+}
+
 // Configure here just for interface compatibility.
 func (d *Device) Configure() {
-	d.latch.Configure(machine.PinConfig{Mode: machine.PinOutput})
-	d.clk.Configure(machine.PinConfig{Mode: machine.PinOutput})
-	d.out.Configure(machine.PinConfig{Mode: machine.PinInput})
+	configurePin(d.latch, true)
+	configurePin(d.clk, true)
+	configurePin(d.out, false)
 	for i := 0; i < int(d.bits); i++ {
 		d.Pins[i] = d.GetShiftPin(i)
 	}
@@ -53,7 +81,7 @@ func (d *Device) Configure() {
 
 // GetShiftPin returns an ShiftPin for a specific input.
 func (d *Device) GetShiftPin(input int) ShiftPin {
-	return ShiftPin{pin: machine.Pin(input), d: d}
+	return ShiftPin{pin: input, d: d}
 }
 
 // Read8Input updates the internal pins' states and returns it as an uint8.
@@ -61,7 +89,7 @@ func (d *Device) Read8Input() (uint8, error) {
 	if d.bits != EIGHT_BITS {
 		return 0, errors.New("wrong amount of registers")
 	}
-	return uint8(d.readInput(EIGHT_BITS)), nil
+	return uint8(d.readInput(true)), nil
 }
 
 // Read16Input updates the internal pins' states and returns it as an uint16.
@@ -69,7 +97,7 @@ func (d *Device) Read16Input() (uint16, error) {
 	if d.bits != SIXTEEN_BITS {
 		return 0, errors.New("wrong amount of registers")
 	}
-	return uint16(d.readInput(SIXTEEN_BITS)), nil
+	return uint16(d.readInput(true)), nil
 }
 
 // Read32Input updates the internal pins' states and returns it as an uint32.
@@ -77,7 +105,7 @@ func (d *Device) Read32Input() (uint32, error) {
 	if d.bits != THIRTYTWO_BITS {
 		return 0, errors.New("wrong amount of registers")
 	}
-	return d.readInput(THIRTYTWO_BITS), nil
+	return d.readInput(true), nil
 }
 
 // Get the pin's state for a specific ShiftPin.
@@ -91,17 +119,42 @@ func (p ShiftPin) Get() bool {
 func (p ShiftPin) Configure() {
 }
 
-// readInput reads howMany bits from the shift register and updates the internal pins' states.
-func (d *Device) readInput(howMany NumberBit) uint32 {
+// Scan reads all inputs and updates their debounced states, calling
+// OnChange for every change. It must be called regularly, much more often
+// than the debounce time.
+func (d *Device) Scan() {
+	now := time.Now()
+	d.readInput(false)
+	for i := range d.Pins {
+		p := &d.Pins[i]
+		if p.raw != p.pressed && now.Sub(p.changed) >= d.Debounce {
+			p.pressed = p.raw
+			if d.OnChange != nil {
+				d.OnChange(i, p.pressed)
+			}
+		}
+	}
+}
+
+// readInput reads all bits from the shift registers and returns the first
+// 32 of them. With update set, the pins' states are updated directly,
+// otherwise only the levels used by Scan are.
+func (d *Device) readInput(update bool) uint32 {
+	now := time.Now()
 	d.latch.High()
 	var data uint32
-	for i := howMany - 1; i >= 0; i-- {
+	for i := int(d.bits) - 1; i >= 0; i-- {
 		d.clk.Low()
-		if d.out.Get() {
-			data |= 1 << i
-			d.Pins[i].pressed = true
-		} else {
-			d.Pins[i].pressed = false
+		v := d.out.Get()
+		if v && i < 32 {
+			data |= 1 << uint(i)
+		}
+		p := &d.Pins[i]
+		if v != p.raw {
+			p.raw, p.changed = v, now
+		}
+		if update {
+			p.pressed = v
 		}
 		d.clk.High()
 	}
@@ -0,0 +1,81 @@
+package shifter
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// chain simulates 74HC165 registers daisy chained to the out pin. inputs[r]
+// holds the inputs A to H of register r, counted from the out pin.
+type chain struct {
+	inputs [][8]bool
+	line   []bool // shifted bits, the first one on the out pin
+	latch  bool
+	clock  bool
+}
+
+type pin struct {
+	level *bool
+	edge  func(high bool)
+}
+
+func (p pin) Get() bool { return *p.level }
+
+func (p pin) Set(high bool) {
+	changed := high != *p.level
+	*p.level = high
+	if changed && p.edge != nil {
+		p.edge(high)
+	}
+}
+
+func (p pin) High() { p.Set(true) }
+func (p pin) Low()  { p.Set(false) }
+
+func (ch *chain) load() {
+	ch.line = ch.line[:0]
+	for _, r := range ch.inputs {
+		for q := 7; q >= 0; q-- {
+			ch.line = append(ch.line, r[q])
+		}
+	}
+}
+
+func (ch *chain) Get() bool     { return len(ch.line) > 0 && ch.line[0] }
+func (ch *chain) Set(high bool) {}
+func (ch *chain) High()         {}
+func (ch *chain) Low()          {}
+
+func newChain(registers int) (*chain, Device) {
+	ch := &chain{inputs: make([][8]bool, registers), latch: true}
+	d := NewChain(registers,
+		pin{&ch.latch, func(high bool) {
+			if !high {
+				ch.load()
+			}
+		}},
+		pin{&ch.clock, func(high bool) {
+			if high && len(ch.line) > 0 {
+				ch.line = ch.line[1:]
+			}
+		}},
+		ch,
+	)
+	d.Configure()
+	return ch, d
+}
+
+func TestBitOrder(t *testing.T) {
+	c := qt.New(t)
+	ch, d := newChain(2)
+	ch.inputs[1][0] = true // A of the last register
+	ch.inputs[0][0] = true // A of the register on the out pin
+	ch.inputs[0][7] = true // H of the register on the out pin
+	ch.load()
+	v, err := d.Read16Input()
+	c.Assert(err, qt.IsNil)
+	c.Assert(v, qt.Equals, uint16(1<<15|1<<8|1<<0))
+	c.Assert(d.Pins[15].Get(), qt.IsTrue)
+	c.Assert(d.Pins[7].Get(), qt.IsFalse)
+}
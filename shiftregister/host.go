@@ -0,0 +1,10 @@
+// +build !tinygo
+
+package shiftregister
+
+import "tinygo.org/x/drivers"
+
+// The clock, latch and data pins of the host tests only record their
+// levels, so there is no output mode to set.
+
+func configureOutput(pin drivers.Pin) {}
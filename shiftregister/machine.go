@@ -0,0 +1,16 @@
+// +build tinygo
+
+package shiftregister
+
+import (
+	"machine"
+
+	"tinygo.org/x/drivers"
+)
+
+// configureOutput makes a GPIO pin an output.
+func configureOutput(pin drivers.Pin) {
+	if pin, ok := pin.(machine.Pin); ok {
+		pin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	}
+}
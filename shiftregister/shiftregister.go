@@ -1,8 +1,14 @@
 // Package shiftregister is for 8bit shift output register using 3 GPIO pins like SN74ALS164A, SN74AHC594, SN74AHC595, ...
+//
+// Registers can be chained to any length by connecting the serial output
+// of one register to the data input of the next one. With latched
+// registers such as the 74HC595, all outputs change at once when the latch
+// pin goes high, and the output enable pin can be driven by a PWM to dim
+// LEDs.
 package shiftregister
 
 import (
-	"machine"
+	"tinygo.org/x/drivers"
 )
 
 type NumberBit int8
@@ -16,70 +22,130 @@ const (
 
 // Device holds pin number
 type Device struct {
-	latch, clock, out machine.Pin // IC wiring
-	bits              NumberBit   // Pin number
-	mask              uint32      // keep all pins state
+	latch, clock, out drivers.Pin // IC wiring
+	bits              int         // Pin number
+	state             []uint8     // keep all pins state, pin 0 in bit 0
+	oe                drivers.PWM
 }
 
+var _ drivers.Pin = ShiftPin{}
+
 // ShiftPin is the implementation of the ShiftPin interface.
 // ShiftPin provide an interface like regular machine.Pin
 type ShiftPin struct {
-	mask uint32  // Bit representing the pin
-	d    *Device // Reference to the register
+	pin int     // Bit representing the pin
+	d   *Device // Reference to the register
 }
 
 // New returns a new shift output register device
-func New(Bits NumberBit, Latch, Clock, Out machine.Pin) *Device {
+func New(Bits NumberBit, Latch, Clock, Out drivers.Pin) *Device {
+	return NewChain(int(Bits)/8, Latch, Clock, Out)
+}
+
+// NewChain returns a chain of 8 bit shift registers. Pin 0 is shifted out
+// first, so it ends up on the last output (QH) of the register farthest
+// from the data pin, and pin 7 on its first output (QA). The next pins go
+// to the registers closer to the data pin, so the register connected to
+// the data pin has the highest pins, with the last one on its QA output.
+// The pins can be pins of an I/O expander; machine.Pin pins are configured
+// by Configure, others must already be outputs.
+func NewChain(registers int, latch, clock, out drivers.Pin) *Device {
 	return &Device{
-		latch: Latch,
-		clock: Clock,
-		out:   Out,
-		bits:  Bits,
+		latch: latch,
+		clock: clock,
+		out:   out,
+		bits:  registers * 8,
+		state: make([]uint8, registers),
 	}
 }
 
 // Configure set hardware configuration
 func (d *Device) Configure() {
-	d.latch.Configure(machine.PinConfig{Mode: machine.PinOutput})
-	d.clock.Configure(machine.PinConfig{Mode: machine.PinOutput})
-	d.out.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	configureOutput(d.latch)
+	configureOutput(d.clock)
+	configureOutput(d.out)
 	d.latch.High()
 }
 
 // WriteMask applies mask's bits to register's outputs pin
 // mask's MSB set Q1, LSB set Q8 (for 8 bits mask)
 func (d *Device) WriteMask(mask uint32) {
-	d.mask = mask // Keep the mask for individual addressing
+	for i := range d.state {
+		if i < 4 {
+			d.state[i] = uint8(mask >> (8 * uint(i)))
+		} else {
+			d.state[i] = 0
+		}
+	}
+	d.Flush()
+}
+
+// Set changes the state of a pin without updating the outputs, so that
+// several pins can be changed at once by a call to Flush.
+func (d *Device) Set(pin int, value bool) {
+	if pin < 0 || pin >= d.bits {
+		panic("invalid pin number")
+	}
+	if value {
+		d.state[pin/8] |= 1 << uint(pin%8)
+	} else {
+		d.state[pin/8] &^= 1 << uint(pin%8)
+	}
+}
+
+// Get returns the state of a pin.
+func (d *Device) Get(pin int) bool {
+	return d.state[pin/8]&(1<<uint(pin%8)) != 0
+}
+
+// Flush shifts the state of all pins into the registers and latches it, so
+// all outputs change at the same time.
+func (d *Device) Flush() {
 	d.latch.Low()
-	for i := 0; i < int(d.bits); i++ {
+	for i := 0; i < d.bits; i++ {
 		d.clock.Low()
-		d.out.Set(mask&1 != 0)
-		mask = mask >> 1
+		d.out.Set(d.state[i/8]&(1<<uint(i%8)) != 0)
 		d.clock.High()
 	}
 	d.latch.High()
 }
 
+// UseOutputEnable sets the PWM connected to the active low output enable
+// pin, for SetBrightness. The PWM must already be configured.
+func (d *Device) UseOutputEnable(oe drivers.PWM) {
+	d.oe = oe
+	d.SetBrightness(0xFFFF)
+}
+
+// SetBrightness dims all outputs with the output enable PWM, from 0 for
+// off to 0xFFFF for always on.
+func (d *Device) SetBrightness(brightness uint16) {
+	if d.oe != nil {
+		d.oe.Set(0xFFFF - brightness)
+	}
+}
+
 // GetShiftPin return an individually addressable pin
 func (d *Device) GetShiftPin(pin int) *ShiftPin {
-	if pin < 0 || pin > int(d.bits) {
+	if pin < 0 || pin >= d.bits {
 		panic("invalid pin number")
 	}
 	return &ShiftPin{
-		mask: 1 << pin,
-		d:    d,
+		pin: pin,
+		d:   d,
 	}
 
 }
 
 // Set changes the value of this register pin.
 func (p ShiftPin) Set(value bool) {
-	d := p.d
-	if value {
-		d.WriteMask(d.mask | p.mask)
-	} else {
-		d.WriteMask(d.mask & ^p.mask)
-	}
+	p.d.Set(p.pin, value)
+	p.d.Flush()
+}
+
+// Get returns the value this register pin was set to.
+func (p ShiftPin) Get() bool {
+	return p.d.Get(p.pin)
 }
 
 // High sets this shift register pin to high.
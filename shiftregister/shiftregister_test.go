@@ -0,0 +1,69 @@
+package shiftregister
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// chain simulates 74HC595 registers daisy chained from the data pin: stage
+// 8*r+q is output q (QA to QH) of register r, counted from the data pin.
+type chain struct {
+	data    bool
+	clock   bool
+	stages  []bool
+	outputs []bool
+}
+
+type pin struct {
+	level *bool
+	edge  func()
+}
+
+func (p pin) Get() bool { return *p.level }
+
+func (p pin) Set(high bool) {
+	rising := high && !*p.level
+	*p.level = high
+	if rising && p.edge != nil {
+		p.edge()
+	}
+}
+
+func (p pin) High() { p.Set(true) }
+func (p pin) Low()  { p.Set(false) }
+
+func newChain(registers int) (*chain, *Device) {
+	ch := &chain{stages: make([]bool, 8*registers), outputs: make([]bool, 8*registers)}
+	var latch bool
+	d := NewChain(registers,
+		pin{&latch, func() { copy(ch.outputs, ch.stages) }},
+		pin{&ch.clock, func() {
+			copy(ch.stages[1:], ch.stages)
+			ch.stages[0] = ch.data
+		}},
+		pin{level: &ch.data},
+	)
+	d.Configure()
+	return ch, d
+}
+
+func TestBitOrder(t *testing.T) {
+	c := qt.New(t)
+	ch, d := newChain(1)
+	d.WriteMask(0x01)
+	// pin 0 is QH, the last output
+	c.Assert(ch.outputs, qt.DeepEquals, []bool{false, false, false, false, false, false, false, true})
+
+	ch, d = newChain(2)
+	d.GetShiftPin(0).High()
+	c.Assert(ch.outputs[15], qt.IsTrue)
+	d.Set(0, false)
+	d.Set(10, true)
+	d.Set(15, true)
+	d.Flush()
+	want := make([]bool, 16)
+	// the register on the data pin has pins 8 to 15, from QH to QA
+	want[5], want[0] = true, true
+	c.Assert(ch.outputs, qt.DeepEquals, want)
+}
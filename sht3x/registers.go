@@ -13,3 +13,65 @@ const (
 	MEASUREMENT_COMMAND_MSB = 0x24
 	MEASUREMENT_COMMAND_LSB = 0x00
 )
+
+// Commands, without clock stretching. Names and values copied from the
+// datasheet.
+const (
+	CMD_FETCH_DATA     = 0xE000
+	CMD_BREAK          = 0x3093
+	CMD_SOFT_RESET     = 0x30A2
+	CMD_HEATER_ENABLE  = 0x306D
+	CMD_HEATER_DISABLE = 0x3066
+	CMD_READ_STATUS    = 0xF32D
+	CMD_CLEAR_STATUS   = 0x3041
+
+	// Reads and writes of the alert limits.
+	CMD_READ_HIGH_SET   = 0xE11F
+	CMD_READ_HIGH_CLEAR = 0xE114
+	CMD_READ_LOW_CLEAR  = 0xE109
+	CMD_READ_LOW_SET    = 0xE102
+
+	CMD_WRITE_HIGH_SET   = 0x611D
+	CMD_WRITE_HIGH_CLEAR = 0x6116
+	CMD_WRITE_LOW_CLEAR  = 0x610B
+	CMD_WRITE_LOW_SET    = 0x6100
+)
+
+// Bits of the status register.
+const (
+	STATUS_ALERT_PENDING  = 0x8000
+	STATUS_HEATER         = 0x2000
+	STATUS_RH_ALERT       = 0x0800
+	STATUS_T_ALERT        = 0x0400
+	STATUS_RESET          = 0x0010
+	STATUS_COMMAND_ERROR  = 0x0002
+	STATUS_CHECKSUM_ERROR = 0x0001
+)
+
+const (
+	REPEATABILITY_HIGH Repeatability = iota
+	REPEATABILITY_MEDIUM
+	REPEATABILITY_LOW
+)
+
+const (
+	SINGLE_SHOT Rate = iota
+	PERIODIC_0_5MPS
+	PERIODIC_1MPS
+	PERIODIC_2MPS
+	PERIODIC_4MPS
+	PERIODIC_10MPS
+)
+
+// singleShotCommands are the single shot commands of each repeatability.
+var singleShotCommands = [3]uint16{0x2400, 0x240B, 0x2416}
+
+// periodicCommands are the commands starting the periodic measurements, by
+// rate and repeatability.
+var periodicCommands = [5][3]uint16{
+	{0x2032, 0x2024, 0x202F},
+	{0x2130, 0x2126, 0x212D},
+	{0x2236, 0x2220, 0x222B},
+	{0x2334, 0x2322, 0x2329},
+	{0x2737, 0x2721, 0x272A},
+}
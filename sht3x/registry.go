@@ -0,0 +1,20 @@
+package sht3x
+
+import (
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/registry"
+)
+
+func init() {
+	registry.Register(registry.Driver{
+		Name:      "sht3x",
+		Bus:       registry.I2C,
+		Addresses: []uint16{AddressA, AddressB},
+		Probe: func(bus drivers.I2C, address uint16) bool {
+			d := New(bus)
+			d.Address = address
+			_, err := d.Status()
+			return err == nil
+		},
+	})
+}
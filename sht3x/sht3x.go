@@ -1,28 +1,96 @@
 // Package sht3x provides a driver for the SHT3x digital humidity sensor
 // series by Sensirion.
 //
+// The sensor measures on demand, in single shot mode, or periodically, in
+// which case the reads return the last measurement. Besides the method of
+// drivers.Thermometer, and drivers.Hygrometer through the Hygrometer method,
+// it has accessors named after those of the dht package (ReadMeasurements,
+// Temperature, Humidity...) with the same units, so that the code of a DHT22
+// can move to an SHT31 with little change.
+//
 // Datasheet:
 // https://www.sensirion.com/fileadmin/user_upload/customers/sensirion/Dokumente/0_Datasheets/Humidity/Sensirion_Humidity_Sensors_SHT3x_Datasheet_digital.pdf
 //
+// Alert mode:
+// https://www.sensirion.com/fileadmin/user_upload/customers/sensirion/Dokumente/2_Humidity_Sensors/Application_Notes/Sensirion_Humidity_Sensors_SHT3x_Application_Note_Alert_Mode_DIS.pdf
+//
 package sht3x // import "tinygo.org/x/drivers/sht3x"
 
 import (
+	"errors"
 	"time"
 
 	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/internal/crc8"
+)
+
+var (
+	errChecksum = errors.New("sht3x: checksum mismatch")
+	errConfig   = errors.New("sht3x: invalid repeatability or rate")
+)
+
+var (
+	_ drivers.Thermometer = &Device{}
+	_ drivers.Hygrometer  = hygrometer{}
 )
 
+// Repeatability is the precision of the measurements: a higher one lowers
+// the noise at the cost of a longer measurement.
+type Repeatability uint8
+
+// Rate is the number of measurements per second in periodic mode, or
+// SINGLE_SHOT for a measurement started by each read.
+type Rate uint8
+
+// Config holds the measurement settings. The zero value selects single shot
+// measurements of high repeatability.
+type Config struct {
+	Repeatability Repeatability
+	Rate          Rate
+}
+
+// Limit is a threshold of the alert, which compares both the temperature
+// and the humidity.
+type Limit struct {
+	// Temperature in celsius milli degrees (°C/1000).
+	Temperature int32
+
+	// Humidity in hundredths of a percent.
+	Humidity int32
+}
+
+// AlertLimits are the thresholds of the alert pin. It is raised when the
+// temperature or the humidity goes above HighSet or below LowSet, and
+// lowered when both come back between HighClear and LowClear. The limits
+// are stored with a precision of about 0.3°C and 0.8%.
+type AlertLimits struct {
+	HighSet   Limit
+	HighClear Limit
+	LowClear  Limit
+	LowSet    Limit
+}
+
 // Device wraps an I2C connection to a SHT31 device.
 type Device struct {
 	bus     drivers.I2C
 	Address uint16
+
+	config Config
+
+	// last measurements, in tenths of a degree and of a percent
+	temperature int16
+	humidity    uint16
+	lastUpdate  time.Time
+
+	buf [6]byte
 }
 
 // New creates a new SHT31 connection. The I2C bus must already be
 // configured.
 //
-// This function only creates the Device object, it does not initialize the device.
-// You must call Configure() first in order to use the device itself.
+// This function only creates the Device object, it does not touch the
+// device, which is ready to use in single shot mode. Configure selects the
+// other modes.
 func New(bus drivers.I2C) Device {
 	return Device{
 		bus:     bus,
@@ -30,6 +98,38 @@ func New(bus drivers.I2C) Device {
 	}
 }
 
+// Configure sets the repeatability and starts or stops the periodic
+// measurements. The first periodic measurement is ready after the period of
+// the rate.
+func (d *Device) Configure(config Config) error {
+	if config.Repeatability > REPEATABILITY_LOW || config.Rate > PERIODIC_10MPS {
+		return errConfig
+	}
+	if d.config.Rate != SINGLE_SHOT {
+		// the sensor takes 1ms to stop the periodic measurements
+		if err := d.command(CMD_BREAK); err != nil {
+			return err
+		}
+		time.Sleep(time.Millisecond)
+	}
+	d.config = config
+	if config.Rate == SINGLE_SHOT {
+		return nil
+	}
+	return d.command(periodicCommands[config.Rate-1][config.Repeatability])
+}
+
+// Reset sends a soft reset, which stops the periodic measurements and the
+// heater and restores the default alert limits.
+func (d *Device) Reset() error {
+	d.config = Config{}
+	if err := d.command(CMD_SOFT_RESET); err != nil {
+		return err
+	}
+	time.Sleep(2 * time.Millisecond)
+	return nil
+}
+
 // Read returns the temperature in celsius milli degrees (°C/1000).
 func (d *Device) ReadTemperature() (tempMilliCelsius int32, err error) {
 	tempMilliCelsius, _, err = d.ReadTemperatureHumidity()
@@ -42,6 +142,22 @@ func (d *Device) ReadHumidity() (relativeHumidity int16, err error) {
 	return relativeHumidity, err
 }
 
+// Hygrometer returns the sensor as a drivers.Hygrometer, whose ReadHumidity
+// returns an int32.
+func (d *Device) Hygrometer() drivers.Hygrometer {
+	return hygrometer{d}
+}
+
+// hygrometer implements drivers.Hygrometer.
+type hygrometer struct {
+	d *Device
+}
+
+func (h hygrometer) ReadHumidity() (int32, error) {
+	humidity, err := h.d.ReadHumidity()
+	return int32(humidity), err
+}
+
 // Read returns both the temperature and relative humidity.
 func (d *Device) ReadTemperatureHumidity() (tempMilliCelsius int32, relativeHumidity int16, err error) {
 	var rawTemp, rawHum, errx = d.rawReadings()
@@ -49,22 +165,216 @@ func (d *Device) ReadTemperatureHumidity() (tempMilliCelsius int32, relativeHumi
 		err = errx
 		return
 	}
-	tempMilliCelsius = (35000 * int32(rawTemp) / 13107) - 45000
-	relativeHumidity = int16(2000 * int32(rawHum) / 13107)
+	tempMilliCelsius = temperature(rawTemp)
+	relativeHumidity = int16(humidity(rawHum))
+	d.temperature = int16(tempMilliCelsius / 100)
+	d.humidity = uint16(relativeHumidity / 10)
+	d.lastUpdate = time.Now()
 	return tempMilliCelsius, relativeHumidity, err
 }
 
+// ReadMeasurements reads the temperature and the humidity, which are then
+// returned by Temperature and Humidity.
+func (d *Device) ReadMeasurements() error {
+	_, _, err := d.ReadTemperatureHumidity()
+	return err
+}
+
+// Temperature returns the last temperature read, in tenths of a degree
+// Celsius.
+func (d *Device) Temperature() int16 {
+	return d.temperature
+}
+
+// TemperatureFloat returns the last temperature read, in degrees Celsius.
+func (d *Device) TemperatureFloat() float32 {
+	return float32(d.temperature) / 10
+}
+
+// Humidity returns the last relative humidity read, in tenths of a percent.
+func (d *Device) Humidity() uint16 {
+	return d.humidity
+}
+
+// HumidityFloat returns the last relative humidity read, in percent.
+func (d *Device) HumidityFloat() float32 {
+	return float32(d.humidity) / 10
+}
+
+// LastUpdate returns the time of the last successful read, or the zero time
+// before the first one.
+func (d *Device) LastUpdate() time.Time {
+	return d.lastUpdate
+}
+
+// SetHeater turns the heater on or off. It warms the sensor by a few
+// degrees, to check that it works or to dry it after condensation; the
+// measurements are wrong while it is on.
+func (d *Device) SetHeater(on bool) error {
+	if on {
+		return d.command(CMD_HEATER_ENABLE)
+	}
+	return d.command(CMD_HEATER_DISABLE)
+}
+
+// Status returns the status register, whose bits are the STATUS_ constants.
+func (d *Device) Status() (uint16, error) {
+	return d.readWord(CMD_READ_STATUS)
+}
+
+// ClearStatus clears the alert and reset bits of the status register.
+func (d *Device) ClearStatus() error {
+	return d.command(CMD_CLEAR_STATUS)
+}
+
+// SetAlertLimits sets the thresholds of the alert pin.
+func (d *Device) SetAlertLimits(limits AlertLimits) error {
+	if err := d.writeWord(CMD_WRITE_HIGH_SET, encodeLimit(limits.HighSet)); err != nil {
+		return err
+	}
+	if err := d.writeWord(CMD_WRITE_HIGH_CLEAR, encodeLimit(limits.HighClear)); err != nil {
+		return err
+	}
+	if err := d.writeWord(CMD_WRITE_LOW_CLEAR, encodeLimit(limits.LowClear)); err != nil {
+		return err
+	}
+	return d.writeWord(CMD_WRITE_LOW_SET, encodeLimit(limits.LowSet))
+}
+
+// AlertLimits returns the thresholds of the alert pin, rounded down to the
+// precision they are stored with.
+func (d *Device) AlertLimits() (limits AlertLimits, err error) {
+	commands := [4]uint16{CMD_READ_HIGH_SET, CMD_READ_HIGH_CLEAR, CMD_READ_LOW_CLEAR, CMD_READ_LOW_SET}
+	values := [4]*Limit{&limits.HighSet, &limits.HighClear, &limits.LowClear, &limits.LowSet}
+	for i, cmd := range commands {
+		word, err := d.readWord(cmd)
+		if err != nil {
+			return limits, err
+		}
+		*values[i] = decodeLimit(word)
+	}
+	return limits, nil
+}
+
+// encodeLimit packs a limit in a word: the 7 most significant bits of the
+// raw humidity followed by the 9 most significant bits of the raw
+// temperature.
+func encodeLimit(l Limit) uint16 {
+	return rawHumidity(l.Humidity)&0xFE00 | rawTemperature(l.Temperature)>>7
+}
+
+func decodeLimit(word uint16) Limit {
+	rawTemp := word << 7
+	rawHum := word & 0xFE00
+	return Limit{
+		Temperature: temperature(rawTemp),
+		Humidity:    humidity(rawHum),
+	}
+}
+
+// temperature converts a raw value of the sensor to milli degrees. It is
+// computed in int64, as 175000 times the raw value does not fit in an int32.
+func temperature(raw uint16) int32 {
+	return int32(int64(raw)*175000/65535) - 45000
+}
+
+// humidity converts a raw value of the sensor to hundredths of a percent.
+func humidity(raw uint16) int32 {
+	return int32(int64(raw) * 10000 / 65535)
+}
+
+// rawTemperature converts a temperature in milli degrees to the raw value of
+// the sensor, limited to its range.
+func rawTemperature(t int32) uint16 {
+	switch {
+	case t <= -45000:
+		return 0
+	case t >= 130000:
+		return 0xFFFF
+	}
+	return uint16((int64(t) + 45000) * 65535 / 175000)
+}
+
+// rawHumidity converts a humidity in hundredths of a percent to the raw
+// value of the sensor, limited to its range.
+func rawHumidity(h int32) uint16 {
+	switch {
+	case h <= 0:
+		return 0
+	case h >= 10000:
+		return 0xFFFF
+	}
+	return uint16(int64(h) * 65535 / 10000)
+}
+
 // rawReadings returns the sensor's raw values of the temperature and humidity
 func (d *Device) rawReadings() (uint16, uint16, error) {
-	d.bus.Tx(d.Address, []byte{MEASUREMENT_COMMAND_MSB, MEASUREMENT_COMMAND_LSB}, nil)
+	if d.config.Rate == SINGLE_SHOT {
+		if err := d.command(singleShotCommands[d.config.Repeatability]); err != nil {
+			return 0, 0, err
+		}
+		time.Sleep(measurementDuration(d.config.Repeatability))
+	} else {
+		// the sensor does not acknowledge the read until the first
+		// measurement is ready
+		if err := d.command(CMD_FETCH_DATA); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	data := d.buf[:6]
+	if err := d.bus.Tx(d.Address, nil, data); err != nil {
+		return 0, 0, err
+	}
+	if crc8.Sensirion(data[0:2]) != data[2] || crc8.Sensirion(data[3:5]) != data[5] {
+		return 0, 0, errChecksum
+	}
+	return readUint(data[0], data[1]), readUint(data[3], data[4]), nil
+}
 
-	time.Sleep(17 * time.Millisecond)
+// measurementDuration returns the longest duration of a single shot
+// measurement.
+func measurementDuration(r Repeatability) time.Duration {
+	switch r {
+	case REPEATABILITY_MEDIUM:
+		return 7 * time.Millisecond
+	case REPEATABILITY_LOW:
+		return 5 * time.Millisecond
+	default:
+		return 16 * time.Millisecond
+	}
+}
 
-	var data [5]byte
-	d.bus.Tx(d.Address, []byte{}, data[:])
-	// ignore crc for now
+// command sends a command without data.
+func (d *Device) command(cmd uint16) error {
+	d.buf[0] = byte(cmd >> 8)
+	d.buf[1] = byte(cmd)
+	return d.bus.Tx(d.Address, d.buf[:2], nil)
+}
 
-	return readUint(data[0], data[1]), readUint(data[3], data[4]), nil
+// readWord sends a command and reads the word of its answer.
+func (d *Device) readWord(cmd uint16) (uint16, error) {
+	if err := d.command(cmd); err != nil {
+		return 0, err
+	}
+	data := d.buf[:3]
+	if err := d.bus.Tx(d.Address, nil, data); err != nil {
+		return 0, err
+	}
+	if crc8.Sensirion(data[0:2]) != data[2] {
+		return 0, errChecksum
+	}
+	return readUint(data[0], data[1]), nil
+}
+
+// writeWord sends a command followed by a word and its checksum.
+func (d *Device) writeWord(cmd, word uint16) error {
+	d.buf[0] = byte(cmd >> 8)
+	d.buf[1] = byte(cmd)
+	d.buf[2] = byte(word >> 8)
+	d.buf[3] = byte(word)
+	d.buf[4] = crc8.Sensirion(d.buf[2:4])
+	return d.bus.Tx(d.Address, d.buf[:5], nil)
 }
 
 // readUint converts two bytes to uint16
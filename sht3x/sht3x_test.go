@@ -0,0 +1,214 @@
+package sht3x
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers/internal/crc8"
+	"tinygo.org/x/drivers/tester"
+)
+
+// sensor is a simulated SHT3x, whose measurement is 25°C and 50%.
+type sensor struct {
+	commands []uint16
+	answer   []byte
+	periodic bool
+	measured bool
+	limits   map[uint16]uint16
+	status   uint16
+
+	// corrupt flips a bit of the next answer
+	corrupt bool
+}
+
+func newSensor() *sensor {
+	return &sensor{limits: map[uint16]uint16{}}
+}
+
+func (s *sensor) tx(w, r []byte) error {
+	if len(w) >= 2 {
+		cmd := readUint(w[0], w[1])
+		s.commands = append(s.commands, cmd)
+		s.command(cmd, w[2:])
+	}
+	if len(r) > 0 {
+		if len(s.answer) < len(r) {
+			return tester.ErrNack
+		}
+		copy(r, s.answer)
+		if s.corrupt {
+			r[0] ^= 1
+			s.corrupt = false
+		}
+		s.answer = nil
+	}
+	return nil
+}
+
+// bus returns an I2C bus with the sensor on it.
+func (s *sensor) bus(c *qt.C) *tester.I2CBus {
+	bus := tester.NewI2CBus(c)
+	bus.AddDevice(tester.NewI2CCommandDevice(c, AddressA, s.tx))
+	return bus
+}
+
+func (s *sensor) command(cmd uint16, data []byte) {
+	switch cmd {
+	case 0x2400, 0x240B, 0x2416:
+		s.answer = []byte{0x66, 0x66, crc8.Sensirion([]byte{0x66, 0x66}), 0x80, 0x00, crc8.Sensirion([]byte{0x80, 0x00})}
+	case CMD_FETCH_DATA:
+		if s.periodic && s.measured {
+			s.answer = []byte{0x66, 0x66, crc8.Sensirion([]byte{0x66, 0x66}), 0x80, 0x00, crc8.Sensirion([]byte{0x80, 0x00})}
+		}
+	case CMD_BREAK:
+		s.periodic = false
+	case CMD_HEATER_ENABLE:
+		s.status |= STATUS_HEATER
+	case CMD_HEATER_DISABLE:
+		s.status &^= STATUS_HEATER
+	case CMD_READ_STATUS:
+		s.answer = s.word(s.status)
+	case CMD_WRITE_HIGH_SET, CMD_WRITE_HIGH_CLEAR, CMD_WRITE_LOW_CLEAR, CMD_WRITE_LOW_SET:
+		if len(data) == 3 && crc8.Sensirion(data[:2]) == data[2] {
+			s.limits[cmd] = readUint(data[0], data[1])
+		} else {
+			s.status |= STATUS_CHECKSUM_ERROR
+		}
+	case CMD_READ_HIGH_SET, CMD_READ_HIGH_CLEAR, CMD_READ_LOW_CLEAR, CMD_READ_LOW_SET:
+		s.answer = s.word(s.limits[writeCommands[cmd]])
+	default:
+		for _, rates := range periodicCommands {
+			for _, c := range rates {
+				if c == cmd {
+					s.periodic = true
+				}
+			}
+		}
+	}
+}
+
+// writeCommands are the write commands of the limits, by read command.
+var writeCommands = map[uint16]uint16{
+	CMD_READ_HIGH_SET:   CMD_WRITE_HIGH_SET,
+	CMD_READ_HIGH_CLEAR: CMD_WRITE_HIGH_CLEAR,
+	CMD_READ_LOW_CLEAR:  CMD_WRITE_LOW_CLEAR,
+	CMD_READ_LOW_SET:    CMD_WRITE_LOW_SET,
+}
+
+func (s *sensor) word(w uint16) []byte {
+	b := []byte{byte(w >> 8), byte(w)}
+	return append(b, crc8.Sensirion(b))
+}
+
+func TestSingleShot(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s.bus(c))
+
+	temp, humidity, err := d.ReadTemperatureHumidity()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(25000))
+	c.Assert(humidity, qt.Equals, int16(5000))
+	c.Assert(s.commands, qt.DeepEquals, []uint16{0x2400})
+
+	c.Assert(d.Configure(Config{Repeatability: REPEATABILITY_LOW}), qt.IsNil)
+	c.Assert(d.ReadMeasurements(), qt.IsNil)
+	c.Assert(s.commands[1:], qt.DeepEquals, []uint16{0x2416})
+	c.Assert(d.Temperature(), qt.Equals, int16(250))
+	c.Assert(d.Humidity(), qt.Equals, uint16(500))
+	c.Assert(d.TemperatureFloat(), qt.Equals, float32(25))
+	c.Assert(d.HumidityFloat(), qt.Equals, float32(50))
+	c.Assert(d.LastUpdate().IsZero(), qt.IsFalse)
+
+	h, err := d.ReadHumidity()
+	c.Assert(err, qt.IsNil)
+	c.Assert(h, qt.Equals, int16(5000))
+	h32, err := d.Hygrometer().ReadHumidity()
+	c.Assert(err, qt.IsNil)
+	c.Assert(h32, qt.Equals, int32(5000))
+
+	s.corrupt = true
+	_, err = d.ReadTemperature()
+	c.Assert(err, qt.Equals, errChecksum)
+
+	c.Assert(d.Configure(Config{Rate: 6}), qt.Equals, errConfig)
+}
+
+func TestPeriodic(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s.bus(c))
+	c.Assert(d.Configure(Config{Rate: PERIODIC_1MPS}), qt.IsNil)
+	c.Assert(s.commands, qt.DeepEquals, []uint16{0x2130})
+	c.Assert(s.periodic, qt.IsTrue)
+
+	// no measurement yet
+	_, err := d.ReadTemperature()
+	c.Assert(err, qt.Equals, tester.ErrNack)
+
+	s.measured = true
+	temp, err := d.ReadTemperature()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(25000))
+
+	c.Assert(d.Configure(Config{Rate: PERIODIC_10MPS, Repeatability: REPEATABILITY_MEDIUM}), qt.IsNil)
+	c.Assert(s.commands[3:], qt.DeepEquals, []uint16{CMD_BREAK, 0x2721})
+
+	c.Assert(d.Configure(Config{}), qt.IsNil)
+	c.Assert(s.periodic, qt.IsFalse)
+}
+
+func TestHeater(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s.bus(c))
+	c.Assert(d.SetHeater(true), qt.IsNil)
+	status, err := d.Status()
+	c.Assert(err, qt.IsNil)
+	c.Assert(status&STATUS_HEATER, qt.Not(qt.Equals), uint16(0))
+
+	c.Assert(d.SetHeater(false), qt.IsNil)
+	status, err = d.Status()
+	c.Assert(err, qt.IsNil)
+	c.Assert(status, qt.Equals, uint16(0))
+}
+
+func TestAlertLimits(t *testing.T) {
+	c := qt.New(t)
+	s := newSensor()
+	d := New(s.bus(c))
+	// defaults of the application note
+	limits := AlertLimits{
+		HighSet:   Limit{Temperature: 60000, Humidity: 8000},
+		HighClear: Limit{Temperature: 58000, Humidity: 7900},
+		LowClear:  Limit{Temperature: -9000, Humidity: 2200},
+		LowSet:    Limit{Temperature: -10000, Humidity: 2000},
+	}
+	c.Assert(d.SetAlertLimits(limits), qt.IsNil)
+	c.Assert(s.limits[CMD_WRITE_HIGH_SET], qt.Equals, uint16(0xCD33))
+	c.Assert(s.limits[CMD_WRITE_LOW_CLEAR], qt.Equals, uint16(0x3869))
+	c.Assert(s.status, qt.Equals, uint16(0))
+
+	read, err := d.AlertLimits()
+	c.Assert(err, qt.IsNil)
+	c.Assert(read.HighSet, qt.Equals, Limit{Temperature: 59933, Humidity: 7968})
+	for _, l := range [][2]Limit{
+		{limits.HighSet, read.HighSet},
+		{limits.HighClear, read.HighClear},
+		{limits.LowClear, read.LowClear},
+		{limits.LowSet, read.LowSet},
+	} {
+		c.Assert(l[0].Temperature-l[1].Temperature < 350, qt.IsTrue, qt.Commentf("%v", l))
+		c.Assert(l[0].Humidity-l[1].Humidity < 80, qt.IsTrue, qt.Commentf("%v", l))
+	}
+
+	// the top of the range of the sensor
+	high := decodeLimit(encodeLimit(Limit{Temperature: 125000, Humidity: 10000}))
+	c.Assert(125000-high.Temperature < 350, qt.IsTrue, qt.Commentf("%v", high))
+	c.Assert(high.Temperature <= 125000, qt.IsTrue, qt.Commentf("%v", high))
+
+	// limits out of the range of the sensor
+	c.Assert(encodeLimit(Limit{Temperature: -50000, Humidity: -1}), qt.Equals, uint16(0))
+	c.Assert(encodeLimit(Limit{Temperature: 150000, Humidity: 12000}), qt.Equals, uint16(0xFFFF))
+}
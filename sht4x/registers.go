@@ -0,0 +1,31 @@
+package sht4x
+
+// The I2C address of the SHT40-AD1B, SHT41-AD1B and SHT45-AD1B. The BD1B
+// variants answer at 0x45 and the SHT40-CD1B at 0x46.
+const Address = 0x44
+
+// Commands. Names and values copied from the datasheet.
+const (
+	CMD_MEASURE_HIGH   = 0xFD
+	CMD_MEASURE_MEDIUM = 0xF6
+	CMD_MEASURE_LOW    = 0xE0
+	CMD_SERIAL_NUMBER  = 0x89
+	CMD_SOFT_RESET     = 0x94
+)
+
+const (
+	PRECISION_HIGH Precision = iota
+	PRECISION_MEDIUM
+	PRECISION_LOW
+)
+
+// Heater commands, by power and duration. Each ends with a measurement of
+// high precision.
+const (
+	HEATER_200MW_1S    Heater = 0x39
+	HEATER_200MW_100MS Heater = 0x32
+	HEATER_110MW_1S    Heater = 0x2F
+	HEATER_110MW_100MS Heater = 0x24
+	HEATER_20MW_1S     Heater = 0x1E
+	HEATER_20MW_100MS  Heater = 0x15
+)
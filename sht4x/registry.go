@@ -0,0 +1,20 @@
+package sht4x
+
+import (
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/registry"
+)
+
+func init() {
+	registry.Register(registry.Driver{
+		Name:      "sht4x",
+		Bus:       registry.I2C,
+		Addresses: []uint16{Address, 0x45, 0x46},
+		Probe: func(bus drivers.I2C, address uint16) bool {
+			d := New(bus)
+			d.Address = address
+			_, err := d.SerialNumber()
+			return err == nil
+		},
+	})
+}
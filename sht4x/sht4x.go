@@ -0,0 +1,175 @@
+// Package sht4x provides a driver for the SHT40, SHT41 and SHT45 digital
+// humidity sensors by Sensirion.
+//
+// The sensors measure on demand, with three levels of precision, and have a
+// heater to drive off condensation or to check that the sensor works, which
+// runs at one of three powers for 0.1s or 1s.
+//
+// Datasheet:
+// https://sensirion.com/media/documents/33FD6951/624C4357/Datasheet_SHT4x.pdf
+//
+package sht4x // import "tinygo.org/x/drivers/sht4x"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/internal/crc8"
+)
+
+var errChecksum = errors.New("sht4x: checksum mismatch")
+
+var (
+	_ drivers.Thermometer  = &Device{}
+	_ drivers.Hygrometer   = &Device{}
+	_ drivers.PowerManaged = &Device{}
+)
+
+// Precision is the repeatability of the measurements: a higher one lowers
+// the noise at the cost of a longer measurement.
+type Precision uint8
+
+// Heater is a heater command, with its power and duration.
+type Heater uint8
+
+// Device wraps an I2C connection to a SHT4x device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	// Precision of the measurements, high by default.
+	Precision Precision
+
+	buf [6]byte
+}
+
+// New creates a new SHT4x connection. The I2C bus must already be
+// configured.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Reset sends a soft reset.
+func (d *Device) Reset() error {
+	if err := d.command(CMD_SOFT_RESET); err != nil {
+		return err
+	}
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+// Sleep does nothing: the sensor goes idle by itself after each
+// measurement.
+func (d *Device) Sleep() error {
+	return nil
+}
+
+// Wake does nothing, the sensor measures from idle.
+func (d *Device) Wake() error {
+	return nil
+}
+
+// SupplyCurrent returns the typical currents of the datasheet: 0.08µA when
+// idle, rounded up to 1µA, and 320µA while measuring.
+func (d *Device) SupplyCurrent() (sleeping, active uint32) {
+	return 1, 320
+}
+
+// SerialNumber returns the unique serial number of the sensor.
+func (d *Device) SerialNumber() (uint32, error) {
+	if err := d.command(CMD_SERIAL_NUMBER); err != nil {
+		return 0, err
+	}
+	time.Sleep(time.Millisecond)
+	msw, lsw, err := d.readWords()
+	return uint32(msw)<<16 | uint32(lsw), err
+}
+
+// ReadTemperature returns the temperature in celsius milli degrees (°C/1000).
+func (d *Device) ReadTemperature() (int32, error) {
+	temp, _, err := d.ReadTemperatureHumidity()
+	return temp, err
+}
+
+// ReadHumidity returns the relative humidity in hundredths of a percent.
+func (d *Device) ReadHumidity() (int32, error) {
+	_, humidity, err := d.ReadTemperatureHumidity()
+	return humidity, err
+}
+
+// ReadTemperatureHumidity returns both the temperature in celsius milli
+// degrees and the relative humidity in hundredths of a percent, from a
+// single measurement.
+func (d *Device) ReadTemperatureHumidity() (temp int32, humidity int32, err error) {
+	cmd := byte(CMD_MEASURE_HIGH)
+	wait := 9 * time.Millisecond
+	switch d.Precision {
+	case PRECISION_MEDIUM:
+		cmd = CMD_MEASURE_MEDIUM
+		wait = 5 * time.Millisecond
+	case PRECISION_LOW:
+		cmd = CMD_MEASURE_LOW
+		wait = 2 * time.Millisecond
+	}
+	return d.measure(cmd, wait)
+}
+
+// Heat runs the heater, then returns the temperature and the humidity
+// measured at the end of the heating, which are those of the heated sensor.
+// The heater must not run for more than a tenth of the time, so that the
+// sensor does not overheat.
+func (d *Device) Heat(heater Heater) (temp int32, humidity int32, err error) {
+	wait := 1100 * time.Millisecond
+	switch heater {
+	case HEATER_200MW_100MS, HEATER_110MW_100MS, HEATER_20MW_100MS:
+		wait = 110 * time.Millisecond
+	}
+	return d.measure(byte(heater), wait)
+}
+
+// measure sends a measurement command, waits for its end and returns the
+// converted measurements, limiting the humidity to 0-100% as advised by the
+// datasheet.
+func (d *Device) measure(cmd byte, wait time.Duration) (temp int32, humidity int32, err error) {
+	if err = d.command(cmd); err != nil {
+		return
+	}
+	time.Sleep(wait)
+	rawTemp, rawHum, err := d.readWords()
+	if err != nil {
+		return
+	}
+	temp = int32(int64(rawTemp)*175000/0xFFFF) - 45000
+	humidity = 2500*int32(rawHum)/13107 - 600
+	switch {
+	case humidity < 0:
+		humidity = 0
+	case humidity > 10000:
+		humidity = 10000
+	}
+	return temp, humidity, nil
+}
+
+// command sends a command of one byte.
+func (d *Device) command(cmd byte) error {
+	d.buf[0] = cmd
+	return d.bus.Tx(d.Address, d.buf[:1], nil)
+}
+
+// readWords reads the two words of an answer and checks their checksums.
+func (d *Device) readWords() (uint16, uint16, error) {
+	data := d.buf[:6]
+	if err := d.bus.Tx(d.Address, nil, data); err != nil {
+		return 0, 0, err
+	}
+	if crc8.Sensirion(data[0:2]) != data[2] || crc8.Sensirion(data[3:5]) != data[5] {
+		return 0, 0, errChecksum
+	}
+	return uint16(data[0])<<8 | uint16(data[1]), uint16(data[3])<<8 | uint16(data[4]), nil
+}
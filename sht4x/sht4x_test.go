@@ -0,0 +1,136 @@
+package sht4x
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers/internal/crc8"
+	"tinygo.org/x/drivers/tester"
+)
+
+// sensor is a simulated SHT4x, whose measurement is 25°C and 50%, or 26°C
+// after a heating.
+type sensor struct {
+	commands []byte
+	answer   []byte
+
+	// corrupt flips a bit of the next answer
+	corrupt bool
+}
+
+func (s *sensor) tx(w, r []byte) error {
+	if len(w) == 1 {
+		s.commands = append(s.commands, w[0])
+		switch Heater(w[0]) {
+		case CMD_MEASURE_HIGH, CMD_MEASURE_MEDIUM, CMD_MEASURE_LOW:
+			s.answer = words(0x6666, 0x6666)
+		case HEATER_200MW_1S, HEATER_200MW_100MS, HEATER_110MW_1S, HEATER_110MW_100MS, HEATER_20MW_1S, HEATER_20MW_100MS:
+			s.answer = words(0x67DD, 0x6666)
+		case CMD_SERIAL_NUMBER:
+			s.answer = words(0x0F0B, 0x1234)
+		}
+	}
+	if len(r) > 0 {
+		if len(s.answer) < len(r) {
+			return tester.ErrNack
+		}
+		copy(r, s.answer)
+		if s.corrupt {
+			r[4] ^= 1
+			s.corrupt = false
+		}
+		s.answer = nil
+	}
+	return nil
+}
+
+// bus returns an I2C bus with the sensor on it.
+func (s *sensor) bus(c *qt.C) *tester.I2CBus {
+	bus := tester.NewI2CBus(c)
+	bus.AddDevice(tester.NewI2CCommandDevice(c, Address, s.tx))
+	return bus
+}
+
+func words(a, b uint16) []byte {
+	w := []byte{byte(a >> 8), byte(a), 0, byte(b >> 8), byte(b), 0}
+	w[2] = crc8.Sensirion(w[0:2])
+	w[5] = crc8.Sensirion(w[3:5])
+	return w
+}
+
+func TestRead(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{}
+	d := New(s.bus(c))
+
+	temp, humidity, err := d.ReadTemperatureHumidity()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(25000))
+	c.Assert(humidity, qt.Equals, int32(4400))
+
+	d.Precision = PRECISION_LOW
+	humidity, err = d.ReadHumidity()
+	c.Assert(err, qt.IsNil)
+	c.Assert(humidity, qt.Equals, int32(4400))
+	d.Precision = PRECISION_MEDIUM
+	_, err = d.ReadTemperature()
+	c.Assert(err, qt.IsNil)
+	c.Assert(s.commands, qt.DeepEquals, []byte{CMD_MEASURE_HIGH, CMD_MEASURE_LOW, CMD_MEASURE_MEDIUM})
+
+	s.corrupt = true
+	_, err = d.ReadTemperature()
+	c.Assert(err, qt.Equals, errChecksum)
+}
+
+func TestHumidityRange(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{}
+	d := New(s.bus(c))
+	s.answer = words(0x6666, 0)
+	_, humidity, err := d.measure(0, 0)
+	c.Assert(err, qt.IsNil)
+	c.Assert(humidity, qt.Equals, int32(0))
+	s.answer = words(0x6666, 0xFFFF)
+	_, humidity, err = d.measure(0, 0)
+	c.Assert(err, qt.IsNil)
+	c.Assert(humidity, qt.Equals, int32(10000))
+}
+
+func TestTemperatureRange(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{}
+	d := New(s.bus(c))
+	for _, tc := range []struct {
+		raw  uint16
+		temp int32
+	}{
+		{0, -45000},
+		{0xF15C, 119994},
+		{0xFFFF, 130000},
+	} {
+		s.answer = words(tc.raw, 0x6666)
+		temp, _, err := d.measure(0, 0)
+		c.Assert(err, qt.IsNil)
+		c.Assert(temp, qt.Equals, tc.temp, qt.Commentf("raw %#x", tc.raw))
+	}
+}
+
+func TestHeat(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{}
+	d := New(s.bus(c))
+	temp, _, err := d.Heat(HEATER_20MW_100MS)
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(26001))
+	c.Assert(s.commands, qt.DeepEquals, []byte{0x15})
+}
+
+func TestSerialNumber(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{}
+	d := New(s.bus(c))
+	serial, err := d.SerialNumber()
+	c.Assert(err, qt.IsNil)
+	c.Assert(serial, qt.Equals, uint32(0x0F0B1234))
+}
@@ -0,0 +1,38 @@
+package si5351
+
+// The I2C address which this device listens to.
+const Address = 0x60
+
+// Registers
+const (
+	DEVICE_STATUS   = 0
+	OUTPUT_ENABLE   = 3
+	CLK0_CONTROL    = 16
+	MSNA_PARAMETERS = 26
+	MSNB_PARAMETERS = 34
+	MS0_PARAMETERS  = 42
+	CLK0_PHASE      = 165
+	PLL_RESET       = 177
+	CRYSTAL_LOAD    = 183
+)
+
+// CLKx_CONTROL bits
+const (
+	clkPowerDown   = 1 << 7
+	clkIntegerMode = 1 << 6
+	clkSourcePLLB  = 1 << 5
+	clkMultisynth  = 3 << 2
+)
+
+// DEVICE_STATUS bits
+const (
+	statusSysInit = 1 << 7
+	statusLossB   = 1 << 6
+	statusLossA   = 1 << 5
+)
+
+// PLL_RESET bits
+const (
+	resetPLLA = 1 << 5
+	resetPLLB = 1 << 7
+)
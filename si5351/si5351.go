@@ -0,0 +1,343 @@
+// Package si5351 provides a driver for the Si5351A clock generator with
+// three outputs.
+//
+// Each output is driven by one of the two PLLs through a MultiSynth
+// divider. The driver uses an even integer divider for every output and
+// sets the PLL to the fractional multiple of the crystal frequency that
+// gives the requested frequency, which has the lowest jitter. As a PLL is
+// set for every frequency, the outputs running from the same PLL cannot
+// have independent frequencies: use PLL A for CLK0 and PLL B for CLK1, or
+// the quadrature mode for two related outputs.
+//
+// Datasheet and register map:
+// https://www.skyworksinc.com/-/media/Skyworks/SL/documents/public/data-sheets/Si5351-B.pdf
+// https://www.skyworksinc.com/-/media/Skyworks/SL/documents/public/application-notes/AN619.pdf
+package si5351 // import "tinygo.org/x/drivers/si5351"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	errRange      = errors.New("si5351: frequency out of range")
+	errOutput     = errors.New("si5351: invalid output")
+	errNotReady   = errors.New("si5351: device not ready")
+	errQuadrature = errors.New("si5351: frequency too low for quadrature outputs")
+)
+
+// Frequency limits of the outputs, and of the PLL VCO.
+const (
+	MinFrequency  = 8000
+	MaxFrequency  = 150000000
+	minMultisynth = 500000
+	maxVCO        = 900000000
+)
+
+// PLL selects one of the two PLLs.
+type PLL uint8
+
+// PLLs.
+const (
+	PLLA PLL = iota
+	PLLB
+)
+
+// Drive is the output current of an output.
+type Drive uint8
+
+// Output drive strengths.
+const (
+	Drive2mA Drive = iota
+	Drive4mA
+	Drive6mA
+	Drive8mA
+)
+
+// Load is the internal load capacitance for the crystal.
+type Load uint8
+
+// Crystal load capacitances.
+const (
+	Load10pF Load = 3
+	Load8pF  Load = 2
+	Load6pF  Load = 1
+)
+
+// Config contains the settings used by Configure.
+type Config struct {
+	// Crystal is the crystal frequency in Hz, 25MHz if not set.
+	Crystal uint32
+
+	// Load is the load capacitance of the crystal, 10pF if not set.
+	Load Load
+}
+
+// Device wraps an I2C connection to an Si5351A device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint8
+	crystal uint32
+	control [3]uint8 // CLKx_CONTROL
+	enabled uint8    // outputs enabled, one bit per output
+	buf     [8]uint8
+}
+
+// New creates a new Si5351 connection. The I2C bus must already be
+// configured.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Configure waits for the device to start and disables and powers down all
+// outputs.
+func (d *Device) Configure(cfg Config) error {
+	d.crystal = cfg.Crystal
+	if d.crystal == 0 {
+		d.crystal = 25000000
+	}
+	if cfg.Load == 0 {
+		cfg.Load = Load10pF
+	}
+
+	for i := 0; ; i++ {
+		status, err := d.read(DEVICE_STATUS)
+		if err == nil && status&statusSysInit == 0 {
+			break
+		}
+		if i == 10 {
+			return errNotReady
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	d.enabled = 0
+	if err := d.write(OUTPUT_ENABLE, 0xFF); err != nil {
+		return err
+	}
+	for i := range d.control {
+		d.control[i] = clkPowerDown | clkMultisynth
+		if err := d.write(CLK0_CONTROL+uint8(i), d.control[i]); err != nil {
+			return err
+		}
+	}
+	// the lower bits are reserved and must be written as 010010
+	return d.write(CRYSTAL_LOAD, uint8(cfg.Load)<<6|0x12)
+}
+
+// SetFrequency sets the frequency of an output, from 0 to 2, in Hz, from
+// 8kHz to 150MHz, using and reconfiguring the given PLL. The output is
+// powered up, but must be enabled with SetEnabled.
+func (d *Device) SetFrequency(output int, pll PLL, hz uint32) error {
+	if output < 0 || output > 2 {
+		return errOutput
+	}
+	if hz < MinFrequency || hz > MaxFrequency {
+		return errRange
+	}
+
+	// the R divider divides frequencies below the MultiSynth range
+	var rdiv uint8
+	for hz<<rdiv < minMultisynth {
+		rdiv++
+	}
+	div := divider(hz << rdiv)
+	if err := d.setPLL(pll, uint64(hz<<rdiv)*uint64(div)); err != nil {
+		return err
+	}
+	if err := d.setMultisynth(output, div, rdiv); err != nil {
+		return err
+	}
+	if err := d.setSource(output, pll); err != nil {
+		return err
+	}
+	// clear a phase offset left by SetQuadrature
+	if err := d.write(CLK0_PHASE+uint8(output), 0); err != nil {
+		return err
+	}
+	return d.resetPLL(pll)
+}
+
+// SetQuadrature sets CLK0 and CLK1 to the same frequency from PLL A, with
+// CLK1 lagging by 90 degrees, as needed for the local oscillator of I/Q
+// mixers. The lowest frequency is about 4.8MHz.
+func (d *Device) SetQuadrature(hz uint32) error {
+	if hz < MinFrequency || hz > MaxFrequency {
+		return errRange
+	}
+	div := divider(hz)
+	if div > 127 || hz < minMultisynth {
+		// the phase offset register has 7 bits
+		div = 126
+		if uint64(hz)*uint64(div) < 600000000 {
+			return errQuadrature
+		}
+	}
+	if err := d.setPLL(PLLA, uint64(hz)*uint64(div)); err != nil {
+		return err
+	}
+	for output := 0; output < 2; output++ {
+		if err := d.setMultisynth(output, div, 0); err != nil {
+			return err
+		}
+		if err := d.setSource(output, PLLA); err != nil {
+			return err
+		}
+	}
+	// the phase offset is set in quarter periods of the VCO, so an offset
+	// of the divider is a quarter period of the output
+	if err := d.write(CLK0_PHASE, 0); err != nil {
+		return err
+	}
+	if err := d.write(CLK0_PHASE+1, uint8(div)); err != nil {
+		return err
+	}
+	// the outputs are only aligned after a PLL reset
+	return d.resetPLL(PLLA)
+}
+
+// SetEnabled enables or disables an output. A disabled output is low.
+func (d *Device) SetEnabled(output int, enabled bool) error {
+	if output < 0 || output > 2 {
+		return errOutput
+	}
+	if enabled {
+		d.enabled |= 1 << uint(output)
+	} else {
+		d.enabled &^= 1 << uint(output)
+	}
+	return d.write(OUTPUT_ENABLE, ^d.enabled)
+}
+
+// SetDrive sets the drive strength of an output.
+func (d *Device) SetDrive(output int, drive Drive) error {
+	if output < 0 || output > 2 {
+		return errOutput
+	}
+	d.control[output] = d.control[output]&^0x03 | uint8(drive)
+	return d.write(CLK0_CONTROL+uint8(output), d.control[output])
+}
+
+// SetPowered powers an output up or down. Outputs are powered up by
+// SetFrequency.
+func (d *Device) SetPowered(output int, powered bool) error {
+	if output < 0 || output > 2 {
+		return errOutput
+	}
+	d.control[output] &^= clkPowerDown
+	if !powered {
+		d.control[output] |= clkPowerDown
+	}
+	return d.write(CLK0_CONTROL+uint8(output), d.control[output])
+}
+
+// Locked returns whether a PLL is locked to the crystal.
+func (d *Device) Locked(pll PLL) (bool, error) {
+	status, err := d.read(DEVICE_STATUS)
+	mask := uint8(statusLossA)
+	if pll == PLLB {
+		mask = statusLossB
+	}
+	return status&mask == 0, err
+}
+
+// divider returns the largest even MultiSynth divider that keeps the VCO
+// within its range for a frequency.
+func divider(hz uint32) uint32 {
+	div := maxVCO / hz
+	div &^= 1
+	if div > 2048 {
+		div = 2048
+	}
+	return div
+}
+
+// setPLL sets the multiplier of a PLL for a VCO frequency.
+func (d *Device) setPLL(pll PLL, vco uint64) error {
+	xtal := uint64(d.crystal)
+	a := vco / xtal
+	b, c := vco%xtal, xtal
+	// reduce the fraction, and limit the denominator to 20 bits
+	g := gcd(b, c)
+	b, c = b/g, c/g
+	if c > 0xFFFFF {
+		b = b * 0xFFFFF / c
+		c = 0xFFFFF
+	}
+	reg := uint8(MSNA_PARAMETERS)
+	if pll == PLLB {
+		reg = MSNB_PARAMETERS
+	}
+	return d.writeParameters(reg, uint32(a), uint32(b), uint32(c), 0)
+}
+
+// setMultisynth sets the integer divider of an output and its R divider,
+// given as a power of two.
+func (d *Device) setMultisynth(output int, div uint32, rdiv uint8) error {
+	reg := MS0_PARAMETERS + 8*uint8(output)
+	if err := d.writeParameters(reg, div, 0, 1, rdiv); err != nil {
+		return err
+	}
+	d.control[output] |= clkIntegerMode
+	return nil
+}
+
+// setSource powers up an output and selects its PLL.
+func (d *Device) setSource(output int, pll PLL) error {
+	d.control[output] &^= clkPowerDown | clkSourcePLLB
+	if pll == PLLB {
+		d.control[output] |= clkSourcePLLB
+	}
+	return d.write(CLK0_CONTROL+uint8(output), d.control[output])
+}
+
+func (d *Device) resetPLL(pll PLL) error {
+	if pll == PLLB {
+		return d.write(PLL_RESET, resetPLLB)
+	}
+	return d.write(PLL_RESET, resetPLLA)
+}
+
+// writeParameters writes the parameters of a PLL or MultiSynth for the
+// ratio a + b/c, as described in AN619.
+func (d *Device) writeParameters(reg uint8, a, b, c uint32, rdiv uint8) error {
+	f := 128 * b / c
+	p1 := 128*a + f - 512
+	p2 := 128*b - c*f
+	p3 := c
+	d.buf = [8]uint8{
+		uint8(p3 >> 8),
+		uint8(p3),
+		rdiv<<4 | uint8(p1>>16)&0x03,
+		uint8(p1 >> 8),
+		uint8(p1),
+		uint8(p3>>16)<<4 | uint8(p2>>16)&0x0F,
+		uint8(p2 >> 8),
+		uint8(p2),
+	}
+	return d.bus.WriteRegister(d.Address, reg, d.buf[:])
+}
+
+func (d *Device) read(reg uint8) (uint8, error) {
+	err := d.bus.ReadRegister(d.Address, reg, d.buf[:1])
+	return d.buf[0], err
+}
+
+func (d *Device) write(reg, value uint8) error {
+	d.buf[0] = value
+	return d.bus.WriteRegister(d.Address, reg, d.buf[:1])
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,77 @@
+package si5351
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"tinygo.org/x/drivers/tester"
+)
+
+func newFake(c *qt.C) (*tester.I2CDevice, Device) {
+	bus := tester.NewI2CBus(c)
+	fake := tester.NewI2CDevice(c, Address)
+	fake.SetupRegisters(make([]uint8, 184))
+	bus.AddDevice(fake)
+	dev := New(bus)
+	c.Assert(dev.Configure(Config{}), qt.IsNil)
+	return fake, dev
+}
+
+func TestConfigure(t *testing.T) {
+	c := qt.New(t)
+	fake, _ := newFake(c)
+	fake.AssertRegisters(c, OUTPUT_ENABLE, []uint8{0xFF})
+	fake.AssertRegisters(c, CLK0_CONTROL, []uint8{0x8C, 0x8C, 0x8C})
+	fake.AssertRegisters(c, CRYSTAL_LOAD, []uint8{0xD2})
+}
+
+func TestIntegerFrequency(t *testing.T) {
+	c := qt.New(t)
+	fake, dev := newFake(c)
+
+	// 10MHz: divider 90, VCO 900MHz = 36 * 25MHz
+	c.Assert(dev.SetFrequency(0, PLLA, 10000000), qt.IsNil)
+	// P1 = 128 * 36 - 512
+	fake.AssertRegisters(c, MSNA_PARAMETERS, []uint8{0, 1, 0x00, 0x10, 0x00, 0, 0, 0})
+	// P1 = 128 * 90 - 512
+	fake.AssertRegisters(c, MS0_PARAMETERS, []uint8{0, 1, 0x00, 0x2B, 0x00, 0, 0, 0})
+	fake.AssertRegisters(c, CLK0_CONTROL, []uint8{0x4C})
+	fake.AssertRegisters(c, PLL_RESET, []uint8{resetPLLA})
+
+	c.Assert(dev.SetDrive(0, Drive8mA), qt.IsNil)
+	c.Assert(dev.SetEnabled(0, true), qt.IsNil)
+	fake.AssertRegisters(c, CLK0_CONTROL, []uint8{0x4F})
+	fake.AssertRegisters(c, OUTPUT_ENABLE, []uint8{0xFE})
+}
+
+func TestFractionalFrequency(t *testing.T) {
+	c := qt.New(t)
+	fake, dev := newFake(c)
+
+	// 10kHz on CLK1: R divider 64 for 640kHz, divider 1406, VCO 899.84MHz
+	// = (35 + 621/625) * 25MHz
+	c.Assert(dev.SetFrequency(1, PLLB, 10000), qt.IsNil)
+	// P1 = 128 * 35 + 127 - 512 = 4095, P2 = 128 * 621 - 625 * 127 = 113
+	fake.AssertRegisters(c, MSNB_PARAMETERS, []uint8{0x02, 0x71, 0x00, 0x0F, 0xFF, 0x00, 0x00, 0x71})
+	// P1 = 128 * 1406 - 512 = 179456, R = 2^6
+	fake.AssertRegisters(c, MS0_PARAMETERS+8, []uint8{0, 1, 0x62, 0xBD, 0x00, 0, 0, 0})
+	fake.AssertRegisters(c, CLK0_CONTROL+1, []uint8{0x6C})
+	fake.AssertRegisters(c, PLL_RESET, []uint8{resetPLLB})
+
+	c.Assert(dev.SetFrequency(2, PLLA, 200000000), qt.Equals, errRange)
+	c.Assert(dev.SetFrequency(3, PLLA, 1000000), qt.Equals, errOutput)
+}
+
+func TestQuadrature(t *testing.T) {
+	c := qt.New(t)
+	fake, dev := newFake(c)
+
+	// 7MHz: divider 126, with CLK1 delayed by 126 quarter VCO periods
+	c.Assert(dev.SetQuadrature(7000000), qt.IsNil)
+	fake.AssertRegisters(c, CLK0_PHASE, []uint8{0, 126})
+	fake.AssertRegisters(c, CLK0_CONTROL, []uint8{0x4C, 0x4C})
+	c.Assert(dev.SetQuadrature(4000000), qt.Equals, errQuadrature)
+	c.Assert(dev.SetQuadrature(0), qt.Equals, errRange)
+	c.Assert(dev.SetQuadrature(MinFrequency), qt.Equals, errQuadrature)
+	c.Assert(dev.SetQuadrature(MaxFrequency+1), qt.Equals, errRange)
+}
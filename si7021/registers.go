@@ -0,0 +1,41 @@
+package si7021
+
+// The I2C address of the device.
+const Address = 0x40
+
+// Commands. Names and values copied from the datasheet.
+const (
+	CMD_MEASURE_RH_NO_HOLD = 0xF5
+	CMD_MEASURE_T_NO_HOLD  = 0xF3
+	CMD_READ_T_FROM_RH     = 0xE0
+	CMD_RESET              = 0xFE
+	CMD_WRITE_USER_1       = 0xE6
+	CMD_READ_USER_1        = 0xE7
+	CMD_WRITE_HEATER       = 0x51
+	CMD_READ_HEATER        = 0x11
+	CMD_READ_ID_1          = 0xFA0F
+	CMD_READ_ID_2          = 0xFCC9
+	CMD_FIRMWARE_REVISION  = 0x84B8
+
+	// Bits of the user register 1, along with the resolution in bits 7 and
+	// 0.
+	USER_VDD_LOW = 0x40
+	USER_HEATER  = 0x04
+
+	// Firmware revisions.
+	FIRMWARE_1_0 = 0xFF
+	FIRMWARE_2_0 = 0x20
+
+	// Device identifiers, in the byte SNB_3 of the serial number.
+	ID_SI7013 = 0x0D
+	ID_SI7020 = 0x14
+	ID_SI7021 = 0x15
+)
+
+// Resolutions of the humidity and the temperature, in bits.
+const (
+	RESOLUTION_RH12_T14 Resolution = iota
+	RESOLUTION_RH8_T12
+	RESOLUTION_RH10_T13
+	RESOLUTION_RH11_T11
+)
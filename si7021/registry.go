@@ -0,0 +1,20 @@
+package si7021
+
+import (
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/registry"
+)
+
+func init() {
+	registry.Register(registry.Driver{
+		Name:      "si7021",
+		Bus:       registry.I2C,
+		Addresses: []uint16{Address},
+		Probe: func(bus drivers.I2C, address uint16) bool {
+			d := New(bus)
+			d.Address = address
+			revision, err := d.FirmwareRevision()
+			return err == nil && (revision == FIRMWARE_1_0 || revision == FIRMWARE_2_0)
+		},
+	})
+}
@@ -0,0 +1,264 @@
+// Package si7021 provides a driver for the Si7021 digital humidity sensor
+// by Silicon Labs, and the Si7013 and Si7020 of the same family.
+//
+// Many modules sold as Si7021 carry a clone or an HTU21D, which measure the
+// same way but do not answer the electronic ID and firmware revision
+// commands, or answer them with other values: SerialNumber and
+// FirmwareRevision tell them apart.
+//
+// Datasheet:
+// https://www.silabs.com/documents/public/data-sheets/Si7021-A20.pdf
+//
+package si7021 // import "tinygo.org/x/drivers/si7021"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/internal/crc8"
+)
+
+var (
+	errChecksum = errors.New("si7021: checksum mismatch")
+	errTimeout  = errors.New("si7021: timeout waiting for the measurement")
+	errHeater   = errors.New("si7021: heater current must be 0 to 15")
+)
+
+var (
+	_ drivers.Thermometer = &Device{}
+	_ drivers.Hygrometer  = &Device{}
+)
+
+// Resolution is the resolution of the measurements: a higher one lowers the
+// noise at the cost of a longer measurement.
+type Resolution uint8
+
+// Config holds the settings of the sensor. The zero value selects the
+// highest resolution with the heater off.
+type Config struct {
+	Resolution Resolution
+
+	// Heater turns the heater on, which warms the sensor to check that it
+	// works or to dry it after condensation.
+	Heater bool
+
+	// HeaterCurrent is the current of the heater, from 3.09mA for 0 to
+	// 94.20mA for 15, in steps of about 6.07mA.
+	HeaterCurrent uint8
+}
+
+// Device wraps an I2C connection to an Si7021 device.
+type Device struct {
+	bus     drivers.I2C
+	Address uint16
+
+	resolution Resolution
+
+	cmd [2]byte
+	buf [8]byte
+}
+
+// New creates a new Si7021 connection. The I2C bus must already be
+// configured.
+//
+// This function only creates the Device object, it does not touch the
+// device, which measures at the highest resolution after power on.
+func New(bus drivers.I2C) Device {
+	return Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Configure sets the resolution, the heater and its current. The other bits
+// of the registers are kept.
+func (d *Device) Configure(config Config) error {
+	if config.HeaterCurrent > 15 {
+		return errHeater
+	}
+	user, err := d.readByte(CMD_READ_USER_1)
+	if err != nil {
+		return err
+	}
+	user &^= 0x81 | USER_HEATER
+	user |= byte(config.Resolution&2)<<6 | byte(config.Resolution&1)
+	if config.Heater {
+		user |= USER_HEATER
+	}
+	if err := d.writeByte(CMD_WRITE_USER_1, user); err != nil {
+		return err
+	}
+	heater, err := d.readByte(CMD_READ_HEATER)
+	if err != nil {
+		return err
+	}
+	if err := d.writeByte(CMD_WRITE_HEATER, heater&^0x0F|config.HeaterCurrent); err != nil {
+		return err
+	}
+	d.resolution = config.Resolution
+	return nil
+}
+
+// Reset sends a soft reset, which restores the default settings.
+func (d *Device) Reset() error {
+	d.buf[0] = CMD_RESET
+	if err := d.bus.Tx(d.Address, d.buf[:1], nil); err != nil {
+		return err
+	}
+	d.resolution = RESOLUTION_RH12_T14
+	time.Sleep(15 * time.Millisecond)
+	return nil
+}
+
+// ReadTemperature returns the temperature in celsius milli degrees (°C/1000).
+func (d *Device) ReadTemperature() (int32, error) {
+	// up to 10.8ms at 14 bits, 6.2ms, 3.8ms and 2.4ms at 13, 12 and 11 bits
+	duration := [4]time.Duration{11, 4, 7, 3}[d.resolution&3] * time.Millisecond
+	raw, err := d.measure(CMD_MEASURE_T_NO_HOLD, duration)
+	if err != nil {
+		return 0, err
+	}
+	return temperature(raw), nil
+}
+
+// ReadHumidity returns the relative humidity in hundredths of a percent.
+func (d *Device) ReadHumidity() (int32, error) {
+	_, humidity, err := d.ReadTemperatureHumidity()
+	return humidity, err
+}
+
+// ReadTemperatureHumidity measures the humidity and returns it in hundredths
+// of a percent, along with the temperature in celsius milli degrees, which
+// the sensor measures to compensate the humidity.
+func (d *Device) ReadTemperatureHumidity() (temp int32, humidity int32, err error) {
+	// up to 12ms at 12 bits, 2.6ms, 3.1ms and 4.5ms at 8, 10 and 11 bits,
+	// plus the temperature
+	duration := [4]time.Duration{23, 7, 10, 8}[d.resolution&3] * time.Millisecond
+	raw, err := d.measure(CMD_MEASURE_RH_NO_HOLD, duration)
+	if err != nil {
+		return
+	}
+	humidity = int32(int64(raw)*12500>>16) - 600
+	switch {
+	case humidity < 0:
+		humidity = 0
+	case humidity > 10000:
+		humidity = 10000
+	}
+	// the temperature of the humidity measurement has no checksum
+	d.buf[0] = CMD_READ_T_FROM_RH
+	if err = d.bus.Tx(d.Address, d.buf[:1], d.buf[1:3]); err != nil {
+		return
+	}
+	return temperature(uint16(d.buf[1])<<8 | uint16(d.buf[2])), humidity, nil
+}
+
+// ReadTemperatureFloat returns the temperature in degrees Celsius.
+func (d *Device) ReadTemperatureFloat() (float32, error) {
+	temp, err := d.ReadTemperature()
+	return float32(temp) / 1000, err
+}
+
+// ReadHumidityFloat returns the relative humidity in percent.
+func (d *Device) ReadHumidityFloat() (float32, error) {
+	humidity, err := d.ReadHumidity()
+	return float32(humidity) / 100, err
+}
+
+// SerialNumber returns the 64-bit electronic ID of the sensor. Its bits 31
+// to 24 identify the device: ID_SI7013, ID_SI7020 or ID_SI7021, while
+// 0x00 and 0xFF mark engineering samples. Clones return other values, or an
+// error.
+func (d *Device) SerialNumber() (uint64, error) {
+	// SNA_3 to SNA_0, each followed by a checksum of the bytes so far
+	data := d.buf[:8]
+	if err := d.read(CMD_READ_ID_1, data); err != nil {
+		return 0, err
+	}
+	var sn [8]byte
+	for i := 0; i < 4; i++ {
+		sn[i] = data[2*i]
+		if checksum(sn[:i+1]) != data[2*i+1] {
+			return 0, errChecksum
+		}
+	}
+	// SNB_3 to SNB_0, with a checksum after each pair of bytes
+	data = d.buf[:6]
+	if err := d.read(CMD_READ_ID_2, data); err != nil {
+		return 0, err
+	}
+	sn[4], sn[5], sn[6], sn[7] = data[0], data[1], data[3], data[4]
+	if checksum(sn[4:6]) != data[2] || checksum(sn[4:8]) != data[5] {
+		return 0, errChecksum
+	}
+	var serial uint64
+	for _, b := range sn {
+		serial = serial<<8 | uint64(b)
+	}
+	return serial, nil
+}
+
+// FirmwareRevision returns the revision of the firmware: FIRMWARE_1_0 or
+// FIRMWARE_2_0.
+func (d *Device) FirmwareRevision() (byte, error) {
+	if err := d.read(CMD_FIRMWARE_REVISION, d.buf[:1]); err != nil {
+		return 0, err
+	}
+	return d.buf[0], nil
+}
+
+// temperature converts a raw temperature to milli degrees.
+func temperature(raw uint16) int32 {
+	return int32(int64(raw)*175720>>16) - 46850
+}
+
+// measure sends a measurement command, waits for the duration of the
+// measurement, then polls the sensor, which does not acknowledge its
+// address until it is done. It returns the raw value.
+func (d *Device) measure(cmd byte, duration time.Duration) (uint16, error) {
+	d.buf[0] = cmd
+	if err := d.bus.Tx(d.Address, d.buf[:1], nil); err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	time.Sleep(duration)
+	data := d.buf[:3]
+	for d.bus.Tx(d.Address, nil, data) != nil {
+		if time.Since(start) > 2*duration {
+			return 0, errTimeout
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if checksum(data[:2]) != data[2] {
+		return 0, errChecksum
+	}
+	return uint16(data[0])<<8 | uint16(data[1]), nil
+}
+
+// read sends a command of two bytes and reads its answer.
+func (d *Device) read(cmd uint16, data []byte) error {
+	d.cmd[0] = byte(cmd >> 8)
+	d.cmd[1] = byte(cmd)
+	return d.bus.Tx(d.Address, d.cmd[:], data)
+}
+
+func (d *Device) readByte(cmd byte) (byte, error) {
+	d.buf[0] = cmd
+	if err := d.bus.Tx(d.Address, d.buf[:1], d.buf[1:2]); err != nil {
+		return 0, err
+	}
+	return d.buf[1], nil
+}
+
+func (d *Device) writeByte(cmd, value byte) error {
+	d.buf[0] = cmd
+	d.buf[1] = value
+	return d.bus.Tx(d.Address, d.buf[:2], nil)
+}
+
+// checksum computes the CRC-8 of the measurements and of the serial number,
+// with an initial value of 0.
+func checksum(data []byte) byte {
+	return crc8.Update(0, crc8.PolySensirion, data...)
+}
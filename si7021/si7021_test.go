@@ -0,0 +1,130 @@
+package si7021
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"tinygo.org/x/drivers/tester"
+)
+
+// sensor is a simulated Si7021.
+type sensor struct {
+	user, heater byte
+	answer       []byte
+
+	// busy is the number of reads not acknowledged after a measurement
+	busy int
+}
+
+// serial number of the sensor, whose SNB_3 is ID_SI7021
+var serial = [8]byte{0x12, 0x34, 0x56, 0x78, ID_SI7021, 0xFF, 0xAB, 0xCD}
+
+func (s *sensor) tx(w, r []byte) error {
+	if len(w) == 0 {
+		if s.busy > 0 {
+			s.busy--
+			return tester.ErrNack
+		}
+		copy(r, s.answer)
+		return nil
+	}
+	cmd := uint16(w[0])
+	if len(w) == 2 && (w[0] == 0xFA || w[0] == 0xFC || w[0] == 0x84) {
+		cmd = uint16(w[0])<<8 | uint16(w[1])
+	}
+	switch cmd {
+	case CMD_MEASURE_T_NO_HOLD:
+		s.answer = []byte{0x68, 0x3A, 0x7C}
+	case CMD_MEASURE_RH_NO_HOLD:
+		s.answer = []byte{0x4E, 0x85, 0x6B}
+	case CMD_READ_T_FROM_RH:
+		copy(r, []byte{0x68, 0x3A})
+	case CMD_READ_USER_1:
+		r[0] = s.user
+	case CMD_WRITE_USER_1:
+		s.user = w[1]
+	case CMD_READ_HEATER:
+		r[0] = s.heater
+	case CMD_WRITE_HEATER:
+		s.heater = w[1]
+	case CMD_FIRMWARE_REVISION:
+		r[0] = FIRMWARE_2_0
+	case CMD_READ_ID_1:
+		for i := 0; i < 4; i++ {
+			r[2*i] = serial[i]
+			r[2*i+1] = checksum(serial[:i+1])
+		}
+	case CMD_READ_ID_2:
+		copy(r, []byte{serial[4], serial[5], checksum(serial[4:6]), serial[6], serial[7], checksum(serial[4:8])})
+	}
+	return nil
+}
+
+// bus returns an I2C bus with the sensor on it.
+func (s *sensor) bus(c *qt.C) *tester.I2CBus {
+	bus := tester.NewI2CBus(c)
+	bus.AddDevice(tester.NewI2CCommandDevice(c, Address, s.tx))
+	return bus
+}
+
+func TestRead(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{busy: 2}
+	d := New(s.bus(c))
+
+	temp, err := d.ReadTemperature()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(24691))
+	c.Assert(s.busy, qt.Equals, 0)
+
+	temp, humidity, err := d.ReadTemperatureHumidity()
+	c.Assert(err, qt.IsNil)
+	c.Assert(temp, qt.Equals, int32(24691))
+	c.Assert(humidity, qt.Equals, int32(3233))
+
+	f, err := d.ReadHumidityFloat()
+	c.Assert(err, qt.IsNil)
+	c.Assert(f, qt.Equals, float32(32.33))
+	f, err = d.ReadTemperatureFloat()
+	c.Assert(err, qt.IsNil)
+	c.Assert(f, qt.Equals, float32(24.691))
+
+	s.answer = []byte{0x68, 0x3A, 0x7D}
+	_, err = d.measure(0, 0)
+	c.Assert(err, qt.Equals, errChecksum)
+
+	s.busy = 1000
+	_, err = d.ReadTemperature()
+	c.Assert(err, qt.Equals, errTimeout)
+}
+
+func TestConfigure(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{user: 0x3A, heater: 0x00}
+	d := New(s.bus(c))
+	err := d.Configure(Config{Resolution: RESOLUTION_RH11_T11, Heater: true, HeaterCurrent: 15})
+	c.Assert(err, qt.IsNil)
+	c.Assert(s.user, qt.Equals, byte(0xBF))
+	c.Assert(s.heater, qt.Equals, byte(0x0F))
+
+	c.Assert(d.Configure(Config{}), qt.IsNil)
+	c.Assert(s.user, qt.Equals, byte(0x3A))
+	c.Assert(s.heater, qt.Equals, byte(0x00))
+
+	c.Assert(d.Configure(Config{HeaterCurrent: 16}), qt.Equals, errHeater)
+}
+
+func TestIdentify(t *testing.T) {
+	c := qt.New(t)
+	s := &sensor{}
+	d := New(s.bus(c))
+	sn, err := d.SerialNumber()
+	c.Assert(err, qt.IsNil)
+	c.Assert(sn, qt.Equals, uint64(0x12345678_15FFABCD))
+	c.Assert(byte(sn>>24), qt.Equals, byte(ID_SI7021))
+
+	revision, err := d.FirmwareRevision()
+	c.Assert(err, qt.IsNil)
+	c.Assert(revision, qt.Equals, byte(FIRMWARE_2_0))
+}
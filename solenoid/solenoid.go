@@ -0,0 +1,133 @@
+// Package solenoid provides a driver for solenoids and solenoid valves that
+// are switched by a transistor or MOSFET on a PWM output.
+//
+// A solenoid needs its full rated current only to pull in the plunger; once
+// it is pulled in, a much lower current is enough to hold it. This driver
+// applies full duty for the pull-in time and then drops to a lower PWM hold
+// duty, which reduces heat and power consumption considerably. A maximum on
+// time protects coils that are not rated for continuous operation.
+//
+// The transitions are performed by Update, which must be called
+// periodically while the solenoid is on.
+package solenoid // import "tinygo.org/x/drivers/solenoid"
+
+import (
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+// Device is a solenoid driven from a PWM output.
+type Device struct {
+	pwm    drivers.PWM
+	pullIn time.Duration
+	hold   uint16
+	maxOn  time.Duration
+
+	on      bool
+	holding bool
+	since   time.Time
+	tripped bool
+}
+
+// Config contains the settings used by Configure.
+type Config struct {
+	// PullInTime is the time full duty is applied after switching on, 100ms
+	// if left at zero.
+	PullInTime time.Duration
+
+	// HoldDuty is the PWM duty cycle (0-0xffff) applied after the pull-in
+	// time, 1/3 of the maximum if left at zero.
+	HoldDuty uint16
+
+	// MaxOnTime is the safety cutoff: the solenoid is switched off when it
+	// has been on longer than this. Zero disables the cutoff.
+	MaxOnTime time.Duration
+}
+
+// New returns a new solenoid driver given the PWM output it is connected to.
+func New(pwm drivers.PWM) Device {
+	return Device{
+		pwm: pwm,
+	}
+}
+
+// Configure configures the PWM output and switches the solenoid off.
+func (d *Device) Configure(cfg Config) {
+	d.pullIn = cfg.PullInTime
+	if d.pullIn == 0 {
+		d.pullIn = 100 * time.Millisecond
+	}
+	d.hold = cfg.HoldDuty
+	if d.hold == 0 {
+		d.hold = 0xffff / 3
+	}
+	d.maxOn = cfg.MaxOnTime
+
+	d.pwm.Configure()
+	d.Off()
+}
+
+// On energizes the solenoid with full duty. Calling On on a solenoid that
+// is already on has no effect.
+func (d *Device) On() {
+	if d.on {
+		return
+	}
+	d.on = true
+	d.holding = false
+	d.tripped = false
+	d.since = time.Now()
+	d.pwm.Set(0xffff)
+}
+
+// Off de-energizes the solenoid.
+func (d *Device) Off() {
+	d.on = false
+	d.holding = false
+	d.pwm.Set(0)
+}
+
+// Set switches the solenoid on or off.
+func (d *Device) Set(on bool) {
+	if on {
+		d.On()
+	} else {
+		d.Off()
+	}
+}
+
+// IsOn returns whether the solenoid is energized.
+func (d *Device) IsOn() bool {
+	return d.on
+}
+
+// Holding returns whether the solenoid is on and has dropped to the hold
+// duty cycle.
+func (d *Device) Holding() bool {
+	return d.holding
+}
+
+// Tripped returns whether the solenoid was switched off by the safety
+// cutoff since the last call to On.
+func (d *Device) Tripped() bool {
+	return d.tripped
+}
+
+// Update drops the duty cycle to the hold level after the pull-in time and
+// switches the solenoid off when the maximum on time is exceeded.
+func (d *Device) Update() {
+	if !d.on {
+		return
+	}
+	elapsed := time.Since(d.since)
+	if d.maxOn > 0 && elapsed > d.maxOn {
+		d.Off()
+		d.tripped = true
+		return
+	}
+	if !d.holding && elapsed >= d.pullIn {
+		d.holding = true
+		d.pwm.Set(d.hold)
+	}
+}
@@ -0,0 +1,63 @@
+package drivers
+
+// SPI represents a SPI bus. It is notably implemented by the machine.SPI
+// type, which must be configured first.
+type SPI interface {
+	// Tx sends the bytes of w while reading into r. w or r may be nil, in
+	// which case zeros are sent or the read bytes are discarded.
+	Tx(w, r []byte) error
+
+	// Transfer sends a byte and returns the byte read at the same time.
+	Transfer(b byte) (byte, error)
+}
+
+// AsyncSPI is a SPI bus that transfers buffers in the background, usually
+// with DMA, so that a driver such as a display can prepare the next buffer
+// while the current one is sent.
+type AsyncSPI interface {
+	SPI
+
+	// StartTx starts sending w while reading into r, like Tx, and returns
+	// without waiting for the end of the transfer. The buffers must not be
+	// used until then. A transfer started while another one runs waits for
+	// it first.
+	StartTx(w, r []byte) error
+
+	// Done returns whether the last transfer started has finished.
+	Done() bool
+
+	// Wait waits for the end of the last transfer and returns its error.
+	Wait() error
+}
+
+// NewAsyncSPI returns the bus itself when it implements AsyncSPI, and
+// otherwise wraps it so that StartTx does a blocking Tx. Drivers call it on
+// the bus they are given, and use the asynchronous transfers on every
+// target.
+func NewAsyncSPI(bus SPI) AsyncSPI {
+	if async, ok := bus.(AsyncSPI); ok {
+		return async
+	}
+	return &blockingSPI{SPI: bus}
+}
+
+// blockingSPI implements AsyncSPI for buses without DMA.
+type blockingSPI struct {
+	SPI
+	err error
+}
+
+func (spi *blockingSPI) StartTx(w, r []byte) error {
+	spi.err = spi.Tx(w, r)
+	return nil
+}
+
+func (spi *blockingSPI) Done() bool {
+	return true
+}
+
+func (spi *blockingSPI) Wait() error {
+	err := spi.err
+	spi.err = nil
+	return err
+}
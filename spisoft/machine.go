@@ -0,0 +1,51 @@
+// +build tinygo
+
+package spisoft
+
+import "machine"
+
+// New returns a 4-wire bus on pins of the microcontroller, and configures
+// them. sdi may be machine.NoPin for a device that is only written.
+func New(sck, sdo, sdi machine.Pin) *SPI {
+	sck.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	sdo.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	if sdi == machine.NoPin {
+		return NewPins(sck, sdo, nil)
+	}
+	sdi.Configure(machine.PinConfig{Mode: machine.PinInput})
+	return NewPins(sck, sdo, sdi)
+}
+
+// NewThreeWire returns a 3-wire bus on pins of the microcontroller, and
+// configures the clock pin.
+func NewThreeWire(sck, sdio machine.Pin) *SPI {
+	sck.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	return NewThreeWirePins(sck, machinePin(sdio))
+}
+
+// machinePin implements drivers.IOPin.
+type machinePin machine.Pin
+
+func (p machinePin) Get() bool {
+	return machine.Pin(p).Get()
+}
+
+func (p machinePin) Set(high bool) {
+	machine.Pin(p).Set(high)
+}
+
+func (p machinePin) High() {
+	machine.Pin(p).High()
+}
+
+func (p machinePin) Low() {
+	machine.Pin(p).Low()
+}
+
+func (p machinePin) SetOutput() {
+	machine.Pin(p).Configure(machine.PinConfig{Mode: machine.PinOutput})
+}
+
+func (p machinePin) SetInput() {
+	machine.Pin(p).Configure(machine.PinConfig{Mode: machine.PinInput})
+}
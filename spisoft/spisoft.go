@@ -0,0 +1,180 @@
+// Package spisoft implements a SPI master by bit-banging pins, for boards
+// without a free SPI peripheral. It implements drivers.SPI, in the four SPI
+// modes and with either bit order, and has a 3-wire variant where a single
+// pin carries the data in both directions, as used by some display
+// controllers.
+package spisoft // import "tinygo.org/x/drivers/spisoft"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var (
+	errMode   = errors.New("spisoft: mode must be 0 to 3")
+	errLength = errors.New("spisoft: read and write buffers of different lengths")
+)
+
+var _ drivers.SPI = &SPI{}
+
+// SPI is a SPI bus on pins, without chip select, which is driven by the
+// drivers of the devices.
+type SPI struct {
+	sck, sdo, sdi drivers.Pin
+
+	// sdio is the data pin of the 3-wire bus, nil for a 4-wire bus
+	sdio drivers.IOPin
+
+	// clock polarity and phase
+	cpol, cpha bool
+	lsbFirst   bool
+
+	// half of a clock period
+	delay time.Duration
+}
+
+// Config holds the settings of the bus.
+type Config struct {
+	// Frequency of the clock in Hz. With 0 the pins are toggled as fast
+	// as possible. The actual frequency is lower, because of the time spent
+	// toggling the pins.
+	Frequency uint32
+
+	// Mode is the SPI mode, 0 to 3: bit 1 is the clock polarity and bit 0
+	// the clock phase.
+	Mode uint8
+
+	// LSBFirst sends the least significant bit of the bytes first.
+	LSBFirst bool
+}
+
+// NewPins returns a 4-wire bus on the pins, which must already be
+// configured: SCK and SDO as outputs and SDI as an input. sdi may be nil for
+// a device that is only written. New does the same with machine.Pin.
+func NewPins(sck, sdo, sdi drivers.Pin) *SPI {
+	return &SPI{sck: sck, sdo: sdo, sdi: sdi}
+}
+
+// NewThreeWirePins returns a 3-wire bus, whose data pin is an output while
+// the bytes are written and an input while they are read. Transfers are
+// half-duplex: Tx writes w, then reads r. SCK must already be configured as
+// an output.
+func NewThreeWirePins(sck drivers.Pin, sdio drivers.IOPin) *SPI {
+	return &SPI{sck: sck, sdo: sdio, sdi: sdio, sdio: sdio}
+}
+
+// Configure sets the mode, the bit order and the frequency, and sets the
+// clock to its idle level.
+func (spi *SPI) Configure(config Config) error {
+	if config.Mode > 3 {
+		return errMode
+	}
+	spi.cpol = config.Mode&2 != 0
+	spi.cpha = config.Mode&1 != 0
+	spi.lsbFirst = config.LSBFirst
+	spi.delay = 0
+	if config.Frequency != 0 {
+		spi.delay = time.Second / time.Duration(2*config.Frequency)
+	}
+	spi.sck.Set(spi.cpol)
+	if spi.sdio != nil {
+		spi.sdio.SetOutput()
+	}
+	return nil
+}
+
+// Transfer sends a byte and returns the byte read at the same time. On a
+// 3-wire bus it only sends the byte, and returns 0.
+func (spi *SPI) Transfer(b byte) (byte, error) {
+	if spi.sdio != nil {
+		spi.sdio.SetOutput()
+		spi.transfer(b, false)
+		return 0, nil
+	}
+	return spi.transfer(b, spi.sdi != nil), nil
+}
+
+// Tx sends w while reading into r. Either may be nil, in which case zeros
+// are sent or the read bytes are discarded; otherwise they must have the
+// same length. On a 3-wire bus, w is sent first, then r is read.
+func (spi *SPI) Tx(w, r []byte) error {
+	if spi.sdio != nil {
+		spi.sdio.SetOutput()
+		for _, b := range w {
+			spi.transfer(b, false)
+		}
+		if len(r) > 0 {
+			spi.sdio.SetInput()
+			for i := range r {
+				r[i] = spi.transfer(0, true)
+			}
+			spi.sdio.SetOutput()
+		}
+		return nil
+	}
+	if w != nil && r != nil && len(w) != len(r) {
+		return errLength
+	}
+	read := r != nil && spi.sdi != nil
+	n := len(w)
+	if w == nil {
+		n = len(r)
+	}
+	for i := 0; i < n; i++ {
+		var b byte
+		if w != nil {
+			b = w[i]
+		}
+		c := spi.transfer(b, read)
+		if r != nil {
+			r[i] = c
+		}
+	}
+	return nil
+}
+
+// transfer clocks a byte out on SDO and, when read is set, in from SDI. With
+// a clock phase of 0 the data is set before the leading edge of the clock
+// and sampled on it, with a phase of 1 it is set on the leading edge and
+// sampled on the trailing edge.
+func (spi *SPI) transfer(b byte, read bool) byte {
+	var in byte
+	for i := uint(0); i < 8; i++ {
+		mask := byte(0x80) >> i
+		if spi.lsbFirst {
+			mask = 1 << i
+		}
+		if !spi.cpha {
+			spi.sdo.Set(b&mask != 0)
+			wait(spi.delay)
+			spi.sck.Set(!spi.cpol)
+			if read && spi.sdi.Get() {
+				in |= mask
+			}
+			wait(spi.delay)
+			spi.sck.Set(spi.cpol)
+		} else {
+			spi.sck.Set(!spi.cpol)
+			spi.sdo.Set(b&mask != 0)
+			wait(spi.delay)
+			spi.sck.Set(spi.cpol)
+			if read && spi.sdi.Get() {
+				in |= mask
+			}
+			wait(spi.delay)
+		}
+	}
+	return in
+}
+
+// wait waits with a busy loop, as time.Sleep is not precise enough.
+func wait(d time.Duration) {
+	if d == 0 {
+		return
+	}
+	start := time.Now()
+	for time.Since(start) < d {
+	}
+}
@@ -0,0 +1,204 @@
+package spisoft
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// device is a simulated SPI device, which samples SDO and shifts its reply
+// out on the edges of the clock that the mode prescribes.
+type device struct {
+	cpol, cpha bool
+	lsbFirst   bool
+
+	sck, mosi, miso bool
+
+	received []byte
+	in       byte
+	inBits   int
+
+	reply  []byte
+	outBit int
+
+	// for the 3-wire bus, whether the master drives the data line: the
+	// device only samples it then, and only shifts its reply otherwise
+	threeWire, output bool
+}
+
+func newDevice(mode uint8, lsbFirst bool, reply ...byte) *device {
+	d := &device{cpol: mode&2 != 0, cpha: mode&1 != 0, lsbFirst: lsbFirst, reply: reply}
+	d.sck = d.cpol
+	if !d.cpha {
+		// the first bit is on the line before the first edge
+		d.miso = d.bit(0)
+	}
+	return d
+}
+
+func (d *device) bit(n int) bool {
+	if n/8 >= len(d.reply) {
+		return false
+	}
+	i := uint(n % 8)
+	if !d.lsbFirst {
+		i = 7 - i
+	}
+	return d.reply[n/8]&(1<<i) != 0
+}
+
+func (d *device) clock(level bool) {
+	if level == d.sck {
+		return
+	}
+	d.sck = level
+	leading := level != d.cpol
+	if leading == !d.cpha && !(d.threeWire && !d.output) {
+		// sampling edge
+		i := uint(d.inBits)
+		if !d.lsbFirst {
+			i = 7 - i
+		}
+		if d.mosi {
+			d.in |= 1 << i
+		}
+		d.inBits++
+		if d.inBits == 8 {
+			d.received = append(d.received, d.in)
+			d.in, d.inBits = 0, 0
+		}
+	}
+	if leading == d.cpha && !(d.threeWire && d.output) {
+		// shifting edge
+		if !d.cpha {
+			d.outBit++
+			d.miso = d.bit(d.outBit)
+		} else {
+			d.miso = d.bit(d.outBit)
+			d.outBit++
+		}
+	}
+}
+
+type sckPin struct{ d *device }
+
+func (p sckPin) Get() bool      { return p.d.sck }
+func (p sckPin) Set(level bool) { p.d.clock(level) }
+func (p sckPin) High()          { p.Set(true) }
+func (p sckPin) Low()           { p.Set(false) }
+
+type sdoPin struct{ d *device }
+
+func (p sdoPin) Get() bool      { return p.d.mosi }
+func (p sdoPin) Set(level bool) { p.d.mosi = level }
+func (p sdoPin) High()          { p.Set(true) }
+func (p sdoPin) Low()           { p.Set(false) }
+
+type sdiPin struct{ d *device }
+
+func (p sdiPin) Get() bool      { return p.d.miso }
+func (p sdiPin) Set(level bool) {}
+func (p sdiPin) High()          {}
+func (p sdiPin) Low()           {}
+
+// sdioPin is the shared data line of the 3-wire bus.
+type sdioPin struct{ d *device }
+
+func (p sdioPin) Get() bool {
+	if p.d.output {
+		return p.d.mosi
+	}
+	return p.d.miso
+}
+
+func (p sdioPin) Set(level bool) {
+	if p.d.output {
+		p.d.mosi = level
+	}
+}
+
+func (p sdioPin) High()      { p.Set(true) }
+func (p sdioPin) Low()       { p.Set(false) }
+func (p sdioPin) SetOutput() { p.d.output = true }
+
+func (p sdioPin) SetInput() {
+	p.d.output = false
+	if !p.d.cpha {
+		p.d.miso = p.d.bit(p.d.outBit)
+	}
+}
+
+func TestModes(t *testing.T) {
+	c := qt.New(t)
+	for mode := uint8(0); mode < 4; mode++ {
+		for _, lsbFirst := range []bool{false, true} {
+			d := newDevice(mode, lsbFirst, 0x96, 0x0F, 0x81)
+			spi := NewPins(sckPin{d}, sdoPin{d}, sdiPin{d})
+			c.Assert(spi.Configure(Config{Mode: mode, LSBFirst: lsbFirst}), qt.IsNil)
+
+			r := make([]byte, 2)
+			c.Assert(spi.Tx([]byte{0xA5, 0x3C}, r), qt.IsNil)
+			b, err := spi.Transfer(0x01)
+			c.Assert(err, qt.IsNil)
+
+			c.Check(d.received, qt.DeepEquals, []byte{0xA5, 0x3C, 0x01}, qt.Commentf("mode %d, LSB first %v", mode, lsbFirst))
+			c.Check(r, qt.DeepEquals, []byte{0x96, 0x0F}, qt.Commentf("mode %d, LSB first %v", mode, lsbFirst))
+			c.Check(b, qt.Equals, byte(0x81), qt.Commentf("mode %d, LSB first %v", mode, lsbFirst))
+			c.Check(d.sck, qt.Equals, d.cpol, qt.Commentf("clock left at its idle level"))
+		}
+	}
+}
+
+func TestTx(t *testing.T) {
+	c := qt.New(t)
+	d := newDevice(0, false, 0x12, 0x34)
+	spi := NewPins(sckPin{d}, sdoPin{d}, sdiPin{d})
+	c.Assert(spi.Configure(Config{Frequency: 1000000}), qt.IsNil)
+
+	// a nil w sends zeros
+	r := make([]byte, 2)
+	c.Assert(spi.Tx(nil, r), qt.IsNil)
+	c.Assert(r, qt.DeepEquals, []byte{0x12, 0x34})
+	c.Assert(d.received, qt.DeepEquals, []byte{0, 0})
+
+	// a nil r discards the bytes read
+	c.Assert(spi.Tx([]byte{0xFF}, nil), qt.IsNil)
+	c.Assert(d.received, qt.DeepEquals, []byte{0, 0, 0xFF})
+
+	c.Assert(spi.Tx([]byte{1, 2}, make([]byte, 1)), qt.Equals, errLength)
+	c.Assert(spi.Configure(Config{Mode: 4}), qt.Equals, errMode)
+}
+
+func TestWriteOnly(t *testing.T) {
+	c := qt.New(t)
+	d := newDevice(0, false)
+	spi := NewPins(sckPin{d}, sdoPin{d}, nil)
+	c.Assert(spi.Configure(Config{}), qt.IsNil)
+
+	r := make([]byte, 1)
+	c.Assert(spi.Tx([]byte{0x5A}, r), qt.IsNil)
+	c.Assert(r, qt.DeepEquals, []byte{0})
+	c.Assert(d.received, qt.DeepEquals, []byte{0x5A})
+}
+
+func TestThreeWire(t *testing.T) {
+	c := qt.New(t)
+	for _, mode := range []uint8{0, 3} {
+		d := newDevice(mode, false, 0x85, 0x52)
+		d.threeWire = true
+		spi := NewThreeWirePins(sckPin{d}, sdioPin{d})
+		c.Assert(spi.Configure(Config{Mode: mode}), qt.IsNil)
+		c.Assert(d.output, qt.IsTrue)
+
+		b, err := spi.Transfer(0x04)
+		c.Assert(err, qt.IsNil)
+		c.Assert(b, qt.Equals, byte(0))
+
+		// the command byte is written, then the answer read on the same pin
+		r := make([]byte, 2)
+		c.Assert(spi.Tx([]byte{0xDA}, r), qt.IsNil)
+		c.Assert(d.received, qt.DeepEquals, []byte{0x04, 0xDA}, qt.Commentf("mode %d", mode))
+		c.Assert(r, qt.DeepEquals, []byte{0x85, 0x52}, qt.Commentf("mode %d", mode))
+		c.Assert(d.output, qt.IsTrue, qt.Commentf("data pin back to an output"))
+	}
+}
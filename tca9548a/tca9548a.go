@@ -0,0 +1,112 @@
+// Package tca9548a provides a driver for the TCA9548A 8-channel I2C
+// multiplexer.
+//
+// Every downstream channel is available as a drivers.I2C bus, which selects
+// the channel before each transaction. This makes it possible to use
+// several devices with the same address, each on its own channel, with
+// their usual drivers.
+//
+// Datasheet:
+// https://www.ti.com/lit/ds/symlink/tca9548a.pdf
+package tca9548a // import "tinygo.org/x/drivers/tca9548a"
+
+import (
+	"errors"
+
+	"tinygo.org/x/drivers"
+)
+
+// The I2C address which this device listens to, with all address pins
+// low. It ranges up to 0x77.
+const Address = 0x70
+
+var errChannel = errors.New("tca9548a: invalid channel")
+
+// Device wraps an I2C connection to a TCA9548A device.
+type Device struct {
+	bus      drivers.I2C
+	Address  uint16
+	selected uint8
+	known    bool // whether selected is the state of the device
+	buf      [1]uint8
+}
+
+// New creates a new TCA9548A connection. The I2C bus must already be
+// configured.
+//
+// This function only creates the Device object, it does not touch the device.
+func New(bus drivers.I2C) *Device {
+	return &Device{
+		bus:     bus,
+		Address: Address,
+	}
+}
+
+// Configure disconnects all channels.
+func (d *Device) Configure() error {
+	d.known = false
+	return d.Select(0)
+}
+
+// Select connects the channels in mask, one bit per channel, to the main
+// bus. It does nothing if these channels are already selected.
+func (d *Device) Select(mask uint8) error {
+	if d.known && d.selected == mask {
+		return nil
+	}
+	d.buf[0] = mask
+	if err := d.bus.Tx(d.Address, d.buf[:], nil); err != nil {
+		// the state of the device is unknown after a failed write
+		d.known = false
+		return err
+	}
+	d.selected, d.known = mask, true
+	return nil
+}
+
+// Selected returns the mask of the selected channels.
+func (d *Device) Selected() uint8 {
+	return d.selected
+}
+
+// Channel returns the bus of a channel, from 0 to 7.
+func (d *Device) Channel(n int) *Channel {
+	if n < 0 || n > 7 {
+		panic(errChannel)
+	}
+	return &Channel{d: d, mask: 1 << uint(n)}
+}
+
+var _ drivers.I2C = &Channel{}
+
+// Channel is a downstream bus of the multiplexer.
+type Channel struct {
+	d    *Device
+	mask uint8
+}
+
+// ReadRegister selects the channel and reads from a register of a device on
+// it.
+func (c *Channel) ReadRegister(addr uint8, r uint8, buf []byte) error {
+	if err := c.d.Select(c.mask); err != nil {
+		return err
+	}
+	return c.d.bus.ReadRegister(addr, r, buf)
+}
+
+// WriteRegister selects the channel and writes to a register of a device on
+// it.
+func (c *Channel) WriteRegister(addr uint8, r uint8, buf []byte) error {
+	if err := c.d.Select(c.mask); err != nil {
+		return err
+	}
+	return c.d.bus.WriteRegister(addr, r, buf)
+}
+
+// Tx selects the channel and performs a transaction with a device on it.
+func (c *Channel) Tx(addr uint16, w, r []byte) error {
+	if err := c.d.Select(c.mask); err != nil {
+		return err
+	}
+	return c.d.bus.Tx(addr, w, r)
+}
@@ -0,0 +1,80 @@
+package tca9548a
+
+import (
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// fakeBus stands for the multiplexer and one device with a single register
+// on each channel.
+type fakeBus struct {
+	selected uint8
+	selects  int
+	regs     [8]uint8
+	err      error
+}
+
+func (b *fakeBus) channel() int {
+	for i := 0; i < 8; i++ {
+		if b.selected == 1<<uint(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (b *fakeBus) ReadRegister(addr uint8, r uint8, buf []byte) error {
+	if addr != 0x29 || b.channel() < 0 {
+		return errors.New("no device")
+	}
+	buf[0] = b.regs[b.channel()]
+	return nil
+}
+
+func (b *fakeBus) WriteRegister(addr uint8, r uint8, buf []byte) error {
+	if addr != 0x29 || b.channel() < 0 {
+		return errors.New("no device")
+	}
+	b.regs[b.channel()] = buf[0]
+	return nil
+}
+
+func (b *fakeBus) Tx(addr uint16, w, r []byte) error {
+	if b.err != nil {
+		return b.err
+	}
+	if addr == Address && len(w) == 1 {
+		b.selected = w[0]
+		b.selects++
+	}
+	return nil
+}
+
+func TestChannels(t *testing.T) {
+	c := qt.New(t)
+	bus := &fakeBus{}
+	mux := New(bus)
+	c.Assert(mux.Configure(), qt.IsNil)
+	c.Assert(bus.selects, qt.Equals, 1)
+
+	ch2, ch5 := mux.Channel(2), mux.Channel(5)
+	c.Assert(ch2.WriteRegister(0x29, 0, []byte{22}), qt.IsNil)
+	c.Assert(ch5.WriteRegister(0x29, 0, []byte{55}), qt.IsNil)
+
+	buf := make([]byte, 1)
+	c.Assert(ch2.ReadRegister(0x29, 0, buf), qt.IsNil)
+	c.Assert(buf[0], qt.Equals, uint8(22))
+	c.Assert(ch2.ReadRegister(0x29, 0, buf), qt.IsNil)
+	c.Assert(mux.Selected(), qt.Equals, uint8(1<<2))
+	// the channel is only selected when it changes
+	c.Assert(bus.selects, qt.Equals, 4)
+
+	// a failed selection is retried on the next transaction
+	bus.err = errors.New("nack")
+	c.Assert(ch5.ReadRegister(0x29, 0, buf), qt.Equals, bus.err)
+	bus.err = nil
+	c.Assert(ch5.ReadRegister(0x29, 0, buf), qt.IsNil)
+	c.Assert(buf[0], qt.Equals, uint8(55))
+}
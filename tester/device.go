@@ -1,5 +1,11 @@
 package tester
 
+import "errors"
+
+// ErrNack is returned by a Handler for a transaction that the simulated
+// device does not acknowledge.
+var ErrNack = errors.New("tester: no acknowledge")
+
 // MaxRegisters is the maximum number of registers supported for a Device.
 const MaxRegisters = 200
 
@@ -14,6 +20,10 @@ type I2CDevice struct {
 	// If Err is non-nil, it will be returned as the error from the
 	// I2C methods.
 	Err error
+	// Handler, if set, handles the transactions of Tx, for the devices
+	// that are driven by commands instead of registers. It receives the
+	// bytes written and fills the bytes read.
+	Handler func(w, r []byte) error
 }
 
 // NewI2CDevice returns a new mock I2C device.
@@ -24,6 +34,14 @@ func NewI2CDevice(c Failer, addr uint8) *I2CDevice {
 	}
 }
 
+// NewI2CCommandDevice returns a new mock I2C device whose transactions are
+// handled by handler.
+func NewI2CCommandDevice(c Failer, addr uint8, handler func(w, r []byte) error) *I2CDevice {
+	d := NewI2CDevice(c, addr)
+	d.Handler = handler
+	return d
+}
+
 // Addr returns the Device address.
 func (d *I2CDevice) Addr() uint8 {
 	return d.addr
@@ -71,6 +89,25 @@ func (d *I2CDevice) WriteRegister(r uint8, buf []byte) error {
 	return nil
 }
 
+// Tx implements I2C.Tx. Without a Handler, the first byte written selects
+// a register: the following bytes are written from it, and the bytes read
+// are read from it.
+func (d *I2CDevice) Tx(w, r []byte) error {
+	if d.Err != nil {
+		return d.Err
+	}
+	if d.Handler != nil {
+		return d.Handler(w, r)
+	}
+	if len(w) == 0 {
+		d.c.Fatalf("register read without register")
+	}
+	if err := d.WriteRegister(w[0], w[1:]); err != nil {
+		return err
+	}
+	return d.ReadRegister(w[0], r)
+}
+
 // AssertRegisterRange asserts that reading or writing the given
 // register and subsequent registers is in range of the available registers.
 func (d *I2CDevice) AssertRegisterRange(r uint8, buf []byte) {
@@ -81,3 +118,16 @@ func (d *I2CDevice) AssertRegisterRange(r uint8, buf []byte) {
 		d.c.Fatalf("register read/write [%#x, %#x] end out of range", r, int(r)+len(buf))
 	}
 }
+
+// AssertRegisters asserts that the registers starting at start hold the
+// values of want.
+func (d *I2CDevice) AssertRegisters(t Failer, start uint8, want []uint8) {
+	d.AssertRegisterRange(start, want)
+	got := d.registers[int(start) : int(start)+len(want)]
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("registers [%#x, %#x]: got %#v, want %#v", start, int(start)+len(want), got, want)
+			return
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package tester
+
+// Pin implements the Pin interface in memory for testing. Its zero value is
+// low.
+type Pin struct {
+	level bool
+}
+
+// Get implements Pin.Get.
+func (p *Pin) Get() bool {
+	return p.level
+}
+
+// Set implements Pin.Set.
+func (p *Pin) Set(high bool) {
+	p.level = high
+}
+
+// High implements Pin.High.
+func (p *Pin) High() {
+	p.level = true
+}
+
+// Low implements Pin.Low.
+func (p *Pin) Low() {
+	p.level = false
+}
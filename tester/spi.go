@@ -0,0 +1,50 @@
+package tester
+
+// SPIBus implements the SPI interface in memory for testing. It records the
+// bytes written, and checks that the chip select pin, if any, is low during
+// the transfers.
+type SPIBus struct {
+	c Failer
+	// CS, if set, is the chip select pin of the device, active low.
+	CS *Pin
+	// Handler, if set, answers the transfers. It receives the bytes
+	// written, zeros when Tx is given no bytes to write, and fills the
+	// bytes read.
+	Handler func(w, r []byte) error
+	// Transfers holds the bytes written by each Tx, and by each Transfer
+	// as a single byte.
+	Transfers [][]byte
+}
+
+// NewSPIBus returns a mock SPI bus that uses c to flag errors.
+func NewSPIBus(c Failer) *SPIBus {
+	return &SPIBus{c: c}
+}
+
+// Tx implements SPI.Tx.
+func (bus *SPIBus) Tx(w, r []byte) error {
+	if bus.CS != nil && bus.CS.Get() {
+		bus.c.Fatalf("spi transfer with chip select high")
+	}
+	if w == nil {
+		w = make([]byte, len(r))
+	}
+	if r != nil && len(r) != len(w) {
+		bus.c.Fatalf("spi transfer with %d bytes to write and %d to read", len(w), len(r))
+	}
+	bus.Transfers = append(bus.Transfers, append([]byte(nil), w...))
+	if bus.Handler == nil {
+		return nil
+	}
+	if r == nil {
+		r = make([]byte, len(w))
+	}
+	return bus.Handler(w, r)
+}
+
+// Transfer implements SPI.Transfer.
+func (bus *SPIBus) Transfer(b byte) (byte, error) {
+	var r [1]byte
+	err := bus.Tx([]byte{b}, r[:])
+	return r[0], err
+}
@@ -1,4 +1,5 @@
-// Package tester contains mock structs to make it easier to test I2C devices.
+// Package tester contains mock structs to make it easier to test I2C and SPI
+// devices.
 //
 // TODO: info on how to use this.
 //
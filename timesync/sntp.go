@@ -0,0 +1,68 @@
+package timesync
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers/net"
+)
+
+var errSNTPTimeout = errors.New("timesync: no response from SNTP server")
+
+// ntpEpoch is the start of the NTP era 0, 1900-01-01, in Unix time.
+const ntpEpoch = -2208988800
+
+// SyncSNTP queries an SNTP server, such as "pool.ntp.org", through the
+// active network device and syncs the clock. The round trip time is taken
+// into account.
+func (c *Clock) SyncSNTP(server string) error {
+	raddr, err := net.ResolveUDPAddr("udp", server+":123")
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialUDP("udp", &net.UDPAddr{Port: 123}, raddr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var packet [48]byte
+	packet[0] = 0x23 // version 4, client mode
+	sent := c.Monotonic()
+	if _, err := conn.Write(packet[:]); err != nil {
+		return err
+	}
+
+	// the network device buffers the response, poll for it
+	n := 0
+	for n < len(packet) {
+		if c.Monotonic()-sent > 2*time.Second {
+			return errSNTPTimeout
+		}
+		m, err := conn.Read(packet[n:])
+		if err != nil {
+			return err
+		}
+		n += m
+		if m == 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	received := c.Monotonic()
+
+	// the server time at the middle of the round trip, not counting the
+	// processing time of the server
+	rx := ntpTime(packet[32:])
+	tx := ntpTime(packet[40:])
+	roundTrip := received - sent - tx.Sub(rx)
+	c.setAt(tx.Add(roundTrip/2), received, SourceSNTP)
+	return nil
+}
+
+// ntpTime decodes a 64-bit NTP timestamp.
+func ntpTime(b []byte) time.Time {
+	secs := int64(binary.BigEndian.Uint32(b))
+	frac := int64(binary.BigEndian.Uint32(b[4:]))
+	return time.Unix(secs+ntpEpoch, frac*1e9>>32).UTC()
+}
@@ -0,0 +1,201 @@
+// Package timesync provides a wall clock that is kept in sync with external
+// time sources: a battery-backed RTC such as the DS3231 or PCF8523, a GPS
+// receiver with or without a PPS output, or an SNTP server reached through a
+// network driver such as espat.
+//
+// Between syncs the clock is advanced with the monotonic time of the
+// runtime, corrected for the drift of the microcontroller oscillator. The
+// drift is estimated from consecutive syncs with a precise source (GPS or
+// SNTP) that are far enough apart.
+//
+// The Now method can be used wherever a time function is expected, for
+// example as the Now field of a fatfs.FS for file timestamps.
+package timesync // import "tinygo.org/x/drivers/timesync"
+
+import (
+	"errors"
+	"time"
+
+	"tinygo.org/x/drivers"
+)
+
+var errNotSynced = errors.New("timesync: clock not synced")
+
+// Source identifies where a time sync came from. Sources are ordered by
+// precision.
+type Source uint8
+
+// Time sources.
+const (
+	SourceNone Source = iota
+	SourceManual
+	SourceRTC
+	SourceSNTP
+	SourceGPS
+)
+
+const (
+	// minimum time between two precise syncs to estimate the drift
+	minDriftInterval = 10 * time.Minute
+
+	// largest drift correction, in parts per billion
+	maxDrift = 1000 * 1000
+)
+
+// boot is the reference for the default monotonic time.
+var boot = time.Now()
+
+// Clock is a wall clock disciplined by external time sources.
+type Clock struct {
+	// Monotonic returns the time elapsed since an arbitrary point, such as
+	// the start of the program. It defaults to the monotonic time of the
+	// runtime.
+	Monotonic func() time.Duration
+
+	// SetSystemTime is called with the new time after every sync, if set.
+	// It can be used to set the time of another component, for example the
+	// time offset of the runtime.
+	SetSystemTime func(t time.Time)
+
+	// HoldOver is how long the time of a sync is preferred over syncs from
+	// less precise sources. It defaults to one hour.
+	HoldOver time.Duration
+
+	base   time.Time     // wall time at mono
+	mono   time.Duration // monotonic time of the last sync
+	drift  int32         // oscillator error in parts per billion, positive if too slow
+	source Source
+
+	// last precise sync, used to estimate the drift
+	refTime time.Time
+	refMono time.Duration
+
+	pps    time.Duration // monotonic time of the last PPS pulse
+	hasPPS bool
+}
+
+// New returns a clock that has not been synced yet.
+func New() *Clock {
+	return &Clock{
+		Monotonic: func() time.Duration { return time.Since(boot) },
+		HoldOver:  time.Hour,
+	}
+}
+
+// Synced returns whether the clock has been synced at least once.
+func (c *Clock) Synced() bool {
+	return c.source != SourceNone
+}
+
+// Source returns the source of the last accepted sync.
+func (c *Clock) Source() Source {
+	return c.source
+}
+
+// Drift returns the estimated error of the local oscillator in parts per
+// billion. A positive value means the oscillator runs slow.
+func (c *Clock) Drift() int32 {
+	return c.drift
+}
+
+// Now returns the current wall clock time. It returns the zero time if the
+// clock has not been synced.
+func (c *Clock) Now() time.Time {
+	if !c.Synced() {
+		return time.Time{}
+	}
+	return c.at(c.Monotonic())
+}
+
+// at returns the wall clock time at the given monotonic time.
+func (c *Clock) at(mono time.Duration) time.Time {
+	elapsed := mono - c.mono
+	return c.base.Add(elapsed + elapsed/1e3*time.Duration(c.drift)/1e6)
+}
+
+// Set syncs the clock to t from the given source. The sync is ignored, and
+// false is returned, when a more precise source synced the clock within the
+// hold-over time.
+func (c *Clock) Set(t time.Time, source Source) bool {
+	return c.setAt(t, c.Monotonic(), source)
+}
+
+// setAt syncs the clock so that it was t at the monotonic time mono.
+func (c *Clock) setAt(t time.Time, mono time.Duration, source Source) bool {
+	if source < c.source && mono-c.mono < c.HoldOver {
+		return false
+	}
+	if source >= SourceSNTP {
+		c.estimateDrift(t, mono)
+	}
+	c.base, c.mono, c.source = t, mono, source
+	if c.SetSystemTime != nil {
+		c.SetSystemTime(c.at(c.Monotonic()))
+	}
+	return true
+}
+
+// estimateDrift compares the time elapsed between two precise syncs with the
+// monotonic time elapsed in between, and averages it with the previous
+// estimate.
+func (c *Clock) estimateDrift(t time.Time, mono time.Duration) {
+	if c.refTime.IsZero() {
+		c.refTime, c.refMono = t, mono
+		return
+	}
+	elapsed := mono - c.refMono
+	if elapsed < minDriftInterval {
+		return
+	}
+	drift := int64(t.Sub(c.refTime)-elapsed) * 1e9 / int64(elapsed)
+	if drift > maxDrift || drift < -maxDrift {
+		// probably a time step rather than drift, start over
+		c.refTime, c.refMono = t, mono
+		return
+	}
+	if c.drift == 0 {
+		c.drift = int32(drift)
+	} else {
+		c.drift = int32((int64(c.drift) + drift) / 2)
+	}
+	c.refTime, c.refMono = t, mono
+}
+
+// SyncRTC syncs the clock from a real time clock.
+func (c *Clock) SyncRTC(rtc drivers.RTC) error {
+	t, err := rtc.ReadTime()
+	if err != nil {
+		return err
+	}
+	c.Set(t, SourceRTC)
+	return nil
+}
+
+// UpdateRTC sets a real time clock to the current time, for example after a
+// sync with GPS or SNTP so that the time is kept on battery power.
+func (c *Clock) UpdateRTC(rtc drivers.RTC) error {
+	if !c.Synced() {
+		return errNotSynced
+	}
+	return rtc.SetTime(c.Now())
+}
+
+// PPS records a pulse of the PPS output of a GPS receiver, which marks the
+// start of a second. It should be called as soon as possible on the rising
+// edge, usually from a pin interrupt.
+func (c *Clock) PPS() {
+	c.pps, c.hasPPS = c.Monotonic(), true
+}
+
+// SyncGPS syncs the clock from the time of a GPS fix, which must include the
+// date, as RMC sentences do. GPS receivers send the time some hundred
+// milliseconds after the start of the second; when PPS is used the time is
+// aligned to the last pulse, which is precise to a microsecond.
+func (c *Clock) SyncGPS(t time.Time) bool {
+	now := c.Monotonic()
+	if c.hasPPS && now-c.pps < time.Second {
+		c.hasPPS = false
+		return c.setAt(t.Truncate(time.Second), c.pps, SourceGPS)
+	}
+	return c.setAt(t, now, SourceGPS)
+}
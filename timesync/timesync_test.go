@@ -0,0 +1,85 @@
+package timesync
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// newClock returns a clock with a monotonic time that is advanced by hand.
+func newClock() (*Clock, *time.Duration) {
+	mono := new(time.Duration)
+	c := New()
+	c.Monotonic = func() time.Duration { return *mono }
+	return c, mono
+}
+
+var start = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestSet(t *testing.T) {
+	c := qt.New(t)
+	clock, mono := newClock()
+	c.Assert(clock.Synced(), qt.IsFalse)
+	c.Assert(clock.Now().IsZero(), qt.IsTrue)
+
+	var system time.Time
+	clock.SetSystemTime = func(t time.Time) { system = t }
+	c.Assert(clock.Set(start, SourceRTC), qt.IsTrue)
+	c.Assert(system, qt.Equals, start)
+
+	*mono += 90 * time.Second
+	c.Assert(clock.Now(), qt.Equals, start.Add(90*time.Second))
+}
+
+func TestHoldOver(t *testing.T) {
+	c := qt.New(t)
+	clock, mono := newClock()
+	c.Assert(clock.SyncGPS(start), qt.IsTrue)
+
+	*mono += time.Minute
+	c.Assert(clock.Set(start, SourceRTC), qt.IsFalse)
+	c.Assert(clock.Source(), qt.Equals, SourceGPS)
+	c.Assert(clock.Now(), qt.Equals, start.Add(time.Minute))
+
+	*mono += 2 * time.Hour
+	c.Assert(clock.Set(start, SourceRTC), qt.IsTrue)
+	c.Assert(clock.Source(), qt.Equals, SourceRTC)
+}
+
+func TestDrift(t *testing.T) {
+	c := qt.New(t)
+	clock, mono := newClock()
+	clock.SyncGPS(start)
+
+	// the local oscillator runs 50ppm slow
+	for i := 1; i <= 4; i++ {
+		*mono += time.Hour - 180*time.Millisecond
+		clock.SyncGPS(start.Add(time.Duration(i) * time.Hour))
+	}
+	c.Assert(clock.Drift(), qt.Equals, int32(50002))
+
+	*mono += time.Hour - 180*time.Millisecond
+	diff := clock.Now().Sub(start.Add(5 * time.Hour))
+	c.Assert(diff > -time.Millisecond && diff < time.Millisecond, qt.IsTrue, qt.Commentf("off by %v", diff))
+}
+
+func TestPPS(t *testing.T) {
+	c := qt.New(t)
+	clock, mono := newClock()
+	*mono = 10 * time.Second
+	clock.PPS()
+
+	// the NMEA sentence arrives later and the time includes the delay of
+	// the receiver
+	*mono += 400 * time.Millisecond
+	clock.SyncGPS(start.Add(300 * time.Millisecond))
+	c.Assert(clock.Now(), qt.Equals, start.Add(400*time.Millisecond))
+}
+
+func TestNTPTime(t *testing.T) {
+	c := qt.New(t)
+	// 2021-03-01 12:00:00.5 UTC
+	b := []byte{0xe3, 0xe7, 0x55, 0xc0, 0x80, 0, 0, 0}
+	c.Assert(ntpTime(b), qt.Equals, start.Add(500*time.Millisecond))
+}
@@ -0,0 +1,19 @@
+package tmp102
+
+import (
+	"tinygo.org/x/drivers"
+	"tinygo.org/x/drivers/registry"
+)
+
+func init() {
+	registry.Register(registry.Driver{
+		Name:      "tmp102",
+		Bus:       registry.I2C,
+		Addresses: []uint16{Address, 0x49, 0x4A, 0x4B},
+		Probe: func(bus drivers.I2C, address uint16) bool {
+			d := New(bus)
+			d.Configure(Config{Address: uint8(address)})
+			return d.Connected()
+		},
+	})
+}
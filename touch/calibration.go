@@ -0,0 +1,84 @@
+package touch
+
+import "errors"
+
+var (
+	errCollinear       = errors.New("touch: calibration points are on a line")
+	errCalibrationData = errors.New("touch: invalid calibration data")
+)
+
+// Calibration maps raw touch coordinates to display coordinates with an
+// affine transformation, which corrects for the scale, offset and rotation
+// of the touch panel relative to the display as well as for swapped axes.
+// It is computed from three points that are not on a line, as described in
+// https://www.ti.com/lit/an/slyt277/slyt277.pdf
+type Calibration struct {
+	a, b, c int64
+	d, e, f int64
+	div     int64
+}
+
+// NewCalibration computes the calibration from three raw touch points and
+// the display coordinates that were touched to get them. The points should
+// be far apart, for example near three corners of the display.
+func NewCalibration(raw, display [3]Point) (Calibration, error) {
+	xr0, xr1, xr2 := int64(raw[0].X), int64(raw[1].X), int64(raw[2].X)
+	yr0, yr1, yr2 := int64(raw[0].Y), int64(raw[1].Y), int64(raw[2].Y)
+	xs0, xs1, xs2 := int64(display[0].X), int64(display[1].X), int64(display[2].X)
+	ys0, ys1, ys2 := int64(display[0].Y), int64(display[1].Y), int64(display[2].Y)
+
+	div := (xr0-xr2)*(yr1-yr2) - (xr1-xr2)*(yr0-yr2)
+	if div == 0 {
+		return Calibration{}, errCollinear
+	}
+	return Calibration{
+		a:   (xs0-xs2)*(yr1-yr2) - (xs1-xs2)*(yr0-yr2),
+		b:   (xr0-xr2)*(xs1-xs2) - (xs0-xs2)*(xr1-xr2),
+		c:   yr0*(xr2*xs1-xr1*xs2) + yr1*(xr0*xs2-xr2*xs0) + yr2*(xr1*xs0-xr0*xs1),
+		d:   (ys0-ys2)*(yr1-yr2) - (ys1-ys2)*(yr0-yr2),
+		e:   (xr0-xr2)*(ys1-ys2) - (ys0-ys2)*(xr1-xr2),
+		f:   yr0*(xr2*ys1-xr1*ys2) + yr1*(xr0*ys2-xr2*ys0) + yr2*(xr1*ys0-xr0*ys1),
+		div: div,
+	}, nil
+}
+
+// Transform maps a raw touch point to display coordinates. Z is not
+// changed. The zero Calibration returns the point as it is.
+func (c Calibration) Transform(p Point) Point {
+	if c.div == 0 {
+		return p
+	}
+	x, y := int64(p.X), int64(p.Y)
+	return Point{
+		X: int((c.a*x + c.b*y + c.c) / c.div),
+		Y: int((c.d*x + c.e*y + c.f) / c.div),
+		Z: p.Z,
+	}
+}
+
+// MarshalBinary encodes the calibration in 56 bytes, to store it with the
+// calibration package.
+func (c Calibration) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 0, 56)
+	for _, v := range [7]int64{c.a, c.b, c.c, c.d, c.e, c.f, c.div} {
+		for i := uint(0); i < 64; i += 8 {
+			data = append(data, byte(v>>i))
+		}
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a calibration encoded by MarshalBinary.
+func (c *Calibration) UnmarshalBinary(data []byte) error {
+	if len(data) != 56 {
+		return errCalibrationData
+	}
+	var v [7]int64
+	for i := range v {
+		for j := uint(0); j < 8; j++ {
+			v[i] |= int64(data[i*8+int(j)]) << (j * 8)
+		}
+	}
+	c.a, c.b, c.c, c.d, c.e, c.f, c.div = v[0], v[1], v[2], v[3], v[4], v[5], v[6]
+	return nil
+}
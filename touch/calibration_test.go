@@ -0,0 +1,28 @@
+package touch
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestCalibration(t *testing.T) {
+	c := qt.New(t)
+
+	// a panel with swapped axes and the raw x axis reversed, on a 240x320
+	// display
+	raw := func(p Point) Point {
+		return Point{X: 60000 - p.Y*150, Y: 4000 + p.X*200, Z: p.Z}
+	}
+	display := [3]Point{{20, 20, 0}, {220, 160, 0}, {120, 300, 0}}
+	cal, err := NewCalibration([3]Point{raw(display[0]), raw(display[1]), raw(display[2])}, display)
+	c.Assert(err, qt.IsNil)
+
+	for _, p := range []Point{{0, 0, 1}, {239, 319, 2}, {100, 50, 3}} {
+		c.Assert(cal.Transform(raw(p)), qt.Equals, p)
+	}
+
+	_, err = NewCalibration([3]Point{{0, 0, 0}, {1, 1, 0}, {2, 2, 0}}, display)
+	c.Assert(err, qt.Equals, errCollinear)
+	c.Assert(Calibration{}.Transform(Point{1, 2, 3}), qt.Equals, Point{1, 2, 3})
+}
@@ -15,8 +15,14 @@ type FourWire struct {
 	xm machine.ADC
 
 	readSamples int
+	median      bool
+	xPlate      uint32
+	samples     [maxSamples]uint16
 }
 
+// maxSamples is the maximum number of samples used for the median filter.
+const maxSamples = 16
+
 // FourWireConfig is passed to the Configure method. All of the pins must be
 // specified for this to be a valid configuration. ReadSamples is optional, and
 // if not set with default to 2.
@@ -38,6 +44,14 @@ type FourWireConfig struct {
 	// and average them.  This can help smooth out spurious readings, for example
 	// ones that result from the capacitance of a TFT under the touchscreen
 	ReadSamples int
+
+	// If set, the median of the samples is used instead of the average,
+	// which rejects single spikes entirely. At most 16 samples are used.
+	Median bool
+
+	// XPlateResistance is the resistance in ohms between X+ and X-, used by
+	// ReadTouchResistance. It can be measured with a multimeter.
+	XPlateResistance uint32
 }
 
 // Configure should be called once before starting to read the device
@@ -53,6 +67,11 @@ func (res *FourWire) Configure(config *FourWireConfig) error {
 	} else {
 		res.readSamples = config.ReadSamples
 	}
+	res.median = config.Median
+	if res.median && res.readSamples > maxSamples {
+		res.readSamples = maxSamples
+	}
+	res.xPlate = config.XPlateResistance
 
 	return nil
 }
@@ -61,12 +80,31 @@ func (res *FourWire) Configure(config *FourWireConfig) error {
 // was configured with ReadSamples > 1, each value will be sampled that many
 // times and averaged to smooth over spurious results of the analog reads.
 func (res *FourWire) ReadTouchPoint() (p touch.Point) {
-	p.X = int(sample(res.ReadX, res.readSamples))
-	p.Y = int(sample(res.ReadY, res.readSamples))
-	p.Z = int(sample(res.ReadZ, res.readSamples))
+	p.X = int(res.sample(res.ReadX))
+	p.Y = int(res.sample(res.ReadY))
+	p.Z = int(res.sample(res.ReadZ))
 	return
 }
 
+// sample reads a value several times and returns the average or the
+// median, depending on the configuration.
+func (res *FourWire) sample(fn func() uint16) uint16 {
+	if !res.median {
+		return sample(fn, res.readSamples)
+	}
+	// insertion sort, the number of samples is small
+	s := res.samples[:res.readSamples]
+	for n := range s {
+		v := fn()
+		i := n
+		for ; i > 0 && s[i-1] > v; i-- {
+			s[i] = s[i-1]
+		}
+		s[i] = v
+	}
+	return s[len(s)/2]
+}
+
 // sample the results of the provided function and average the results
 func sample(fn func() uint16, numSamples int) (v uint16) {
 	sum := 0
@@ -122,3 +160,31 @@ func (res *FourWire) ReadZ() uint16 {
 
 	return 0xFFFF - (z2 - z1)
 }
+
+// ReadTouchResistance estimates the pressure of a touch as the resistance
+// between the two layers in ohms, which decreases as the panel is pressed
+// harder. It returns 0 when the panel is not touched. The X plate
+// resistance must be configured.
+func (res *FourWire) ReadTouchResistance() uint32 {
+	x := uint64(res.sample(res.ReadX))
+
+	res.xp.Pin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	res.xp.Pin.Low()
+	res.ym.Pin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	res.ym.Pin.High()
+	res.xm.Configure()
+	res.yp.Configure()
+	z1 := uint64(res.sample(res.xm.Get))
+	z2 := uint64(res.sample(res.yp.Get))
+	if z1 == 0 || z2 <= z1 {
+		return 0
+	}
+
+	// Rtouch = Rx * X / 2^16 * (Z2 / Z1 - 1), where X is the fraction of
+	// the X plate between the touch and X+, which is the grounded end here
+	r := uint64(res.xPlate) * x >> 16 * (z2 - z1) / z1
+	if r > 0xFFFFFFFF {
+		r = 0xFFFFFFFF
+	}
+	return uint32(r)
+}
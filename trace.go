@@ -0,0 +1,99 @@
+package drivers
+
+// TraceFunc receives the events traced by the drivers: the level, the name of
+// the driver package, the event, such as "read" or "retry", and integer
+// arguments whose meaning depends on the event.
+type TraceFunc func(level, driver, event string, args ...int)
+
+// Levels of the traced events.
+const (
+	TraceError = "error"
+	TraceInfo  = "info"
+	TraceDebug = "debug"
+)
+
+// Trace is called by the drivers at key points, such as the start of a
+// transaction, a retry or an error, to debug them in the field. It is nil by
+// default, which disables tracing. The drivers check it before calling it, so
+// that a disabled trace costs a comparison and no allocation:
+//
+//	if drivers.Trace != nil {
+//		drivers.Trace(drivers.TraceDebug, "dht", "retry", attempt)
+//	}
+//
+// The buses wrapped by TraceI2C and TraceSPI trace every transaction, for
+// the drivers that do not trace themselves.
+//
+// Set it at the start of the program, before the drivers are used.
+var Trace TraceFunc
+
+// TraceI2C wraps an I2C bus to trace its transactions under the driver name
+// "i2c": an event per transaction, "tx", "read" or "write", with the address
+// and the lengths or the register, and an "error" event with the address
+// when the transaction fails. It traces the buses of all the drivers, such
+// as a machine.I2C, which do not call Trace themselves:
+//
+//	bus := drivers.TraceI2C(machine.I2C0)
+//	sensor := sht3x.New(bus)
+func TraceI2C(bus I2C) I2C {
+	return tracedI2C{bus}
+}
+
+type tracedI2C struct {
+	bus I2C
+}
+
+func (t tracedI2C) ReadRegister(addr uint8, r uint8, buf []byte) error {
+	if Trace != nil {
+		Trace(TraceDebug, "i2c", "read", int(addr), int(r), len(buf))
+	}
+	return traceError("i2c", t.bus.ReadRegister(addr, r, buf), int(addr))
+}
+
+func (t tracedI2C) WriteRegister(addr uint8, r uint8, buf []byte) error {
+	if Trace != nil {
+		Trace(TraceDebug, "i2c", "write", int(addr), int(r), len(buf))
+	}
+	return traceError("i2c", t.bus.WriteRegister(addr, r, buf), int(addr))
+}
+
+func (t tracedI2C) Tx(addr uint16, w, r []byte) error {
+	if Trace != nil {
+		Trace(TraceDebug, "i2c", "tx", int(addr), len(w), len(r))
+	}
+	return traceError("i2c", t.bus.Tx(addr, w, r), int(addr))
+}
+
+// TraceSPI wraps a SPI bus to trace its transfers under the driver name
+// "spi": a "tx" event with the lengths of the buffers, a "transfer" event
+// with the byte sent, and an "error" event when a transfer fails.
+func TraceSPI(bus SPI) SPI {
+	return tracedSPI{bus}
+}
+
+type tracedSPI struct {
+	bus SPI
+}
+
+func (t tracedSPI) Tx(w, r []byte) error {
+	if Trace != nil {
+		Trace(TraceDebug, "spi", "tx", len(w), len(r))
+	}
+	return traceError("spi", t.bus.Tx(w, r))
+}
+
+func (t tracedSPI) Transfer(b byte) (byte, error) {
+	if Trace != nil {
+		Trace(TraceDebug, "spi", "transfer", int(b))
+	}
+	c, err := t.bus.Transfer(b)
+	return c, traceError("spi", err)
+}
+
+// traceError traces a failed transaction, and returns its error.
+func traceError(driver string, err error, args ...int) error {
+	if err != nil && Trace != nil {
+		Trace(TraceError, driver, "error", args...)
+	}
+	return err
+}
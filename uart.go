@@ -0,0 +1,16 @@
+package drivers
+
+import "io"
+
+// UART represents a serial port. It is notably implemented by the
+// machine.UART type, which must be configured first with the baud rate of
+// the device.
+type UART interface {
+	io.Writer
+
+	// Buffered returns the number of bytes received and not read yet.
+	Buffered() int
+
+	// ReadByte reads a received byte.
+	ReadByte() (byte, error)
+}
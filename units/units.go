@@ -0,0 +1,149 @@
+// Package units defines types for the physical quantities measured by the
+// sensors, so that a value carries its unit: a temperature read from a dht
+// and one read from a bme280 have the same type and scale, and cannot be
+// mixed up with a humidity.
+//
+// The types are integers in the units the drivers already use, to avoid
+// floating point arithmetic on the microcontrollers without an FPU. Their
+// String methods format them with their unit symbol:
+//
+//	t, err := units.ReadTemperature(sensor)
+//	println(t.String()) // 21.5°C
+package units // import "tinygo.org/x/drivers/units"
+
+import (
+	"strconv"
+
+	"tinygo.org/x/drivers"
+)
+
+// MilliCelsius is a temperature in thousandths of a degree Celsius.
+type MilliCelsius int32
+
+// Celsius returns the temperature in degrees Celsius.
+func (t MilliCelsius) Celsius() float32 {
+	return float32(t) / 1000
+}
+
+// MilliFahrenheit returns the temperature in thousandths of a degree
+// Fahrenheit.
+func (t MilliCelsius) MilliFahrenheit() int32 {
+	return int32(t)*9/5 + 32000
+}
+
+// MilliKelvin returns the temperature in thousandths of a kelvin.
+func (t MilliCelsius) MilliKelvin() int32 {
+	return int32(t) + 273150
+}
+
+func (t MilliCelsius) String() string {
+	return format(int64(t), 3, "°C")
+}
+
+// MilliRH is a relative humidity in thousandths of a percent.
+type MilliRH int32
+
+// Percent returns the relative humidity in percent.
+func (h MilliRH) Percent() float32 {
+	return float32(h) / 1000
+}
+
+func (h MilliRH) String() string {
+	return format(int64(h), 3, "%RH")
+}
+
+// Pascal is a pressure in pascals.
+type Pascal int32
+
+// Hectopascals returns the pressure in hectopascals, or millibars, the unit
+// of the weather reports.
+func (p Pascal) Hectopascals() float32 {
+	return float32(p) / 100
+}
+
+func (p Pascal) String() string {
+	return format(int64(p), 0, "Pa")
+}
+
+// Lux is an illuminance in lux.
+type Lux int32
+
+func (l Lux) String() string {
+	return format(int64(l), 0, "lx")
+}
+
+// MicroTesla is a magnetic flux density in microteslas, the earth field
+// being around 25 to 65µT.
+type MicroTesla int32
+
+// MilliGauss returns the flux density in milligauss.
+func (b MicroTesla) MilliGauss() int32 {
+	return int32(b) * 10
+}
+
+func (b MicroTesla) String() string {
+	return format(int64(b), 0, "µT")
+}
+
+// MilliG is an acceleration in thousandths of the standard gravity.
+type MilliG int32
+
+// MetersPerSecond2 returns the acceleration in m/s².
+func (a MilliG) MetersPerSecond2() float32 {
+	return float32(a) * 9.80665 / 1000
+}
+
+func (a MilliG) String() string {
+	return format(int64(a), 3, "g")
+}
+
+// ReadTemperature reads a temperature from a sensor.
+func ReadTemperature(t drivers.Thermometer) (MilliCelsius, error) {
+	v, err := t.ReadTemperature()
+	return MilliCelsius(v), err
+}
+
+// ReadHumidity reads a relative humidity from a sensor, which returns it in
+// hundredths of a percent.
+func ReadHumidity(h drivers.Hygrometer) (MilliRH, error) {
+	v, err := h.ReadHumidity()
+	return MilliRH(v * 10), err
+}
+
+// ReadPressure reads a pressure from a sensor, which returns it in
+// milli-pascals.
+func ReadPressure(b drivers.Barometer) (Pascal, error) {
+	v, err := b.ReadPressure()
+	return Pascal(v / 1000), err
+}
+
+// ReadAcceleration reads the acceleration on three axes from a sensor, which
+// returns it in micro-gravity.
+func ReadAcceleration(a drivers.Accelerometer) (x, y, z MilliG, err error) {
+	ux, uy, uz, err := a.ReadAcceleration()
+	return MilliG(ux / 1000), MilliG(uy / 1000), MilliG(uz / 1000), err
+}
+
+// format formats a value with the given number of decimals, without the
+// trailing zeros of the fraction, followed by the unit.
+func format(v int64, decimals int, unit string) string {
+	var buf [24]byte
+	b := buf[:0]
+	if v < 0 {
+		b = append(b, '-')
+		v = -v
+	}
+	div := int64(1)
+	for i := 0; i < decimals; i++ {
+		div *= 10
+	}
+	b = strconv.AppendInt(b, v/div, 10)
+	if frac := v % div; frac != 0 {
+		b = append(b, '.')
+		for div /= 10; frac != 0; div /= 10 {
+			b = append(b, byte('0'+frac/div))
+			frac %= div
+		}
+	}
+	return string(append(b, unit...))
+}
@@ -0,0 +1,69 @@
+package units
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestString(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range []struct {
+		v    interface{ String() string }
+		want string
+	}{
+		{MilliCelsius(21500), "21.5°C"},
+		{MilliCelsius(-250), "-0.25°C"},
+		{MilliCelsius(-12005), "-12.005°C"},
+		{MilliCelsius(0), "0°C"},
+		{MilliRH(45200), "45.2%RH"},
+		{Pascal(101325), "101325Pa"},
+		{Lux(320), "320lx"},
+		{MicroTesla(-48), "-48µT"},
+		{MilliG(981), "0.981g"},
+		{MilliG(-1000), "-1g"},
+	} {
+		c.Check(test.v.String(), qt.Equals, test.want)
+	}
+}
+
+func TestConversions(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(MilliCelsius(25000).Celsius(), qt.Equals, float32(25))
+	c.Assert(MilliCelsius(25000).MilliFahrenheit(), qt.Equals, int32(77000))
+	c.Assert(MilliCelsius(-40000).MilliFahrenheit(), qt.Equals, int32(-40000))
+	c.Assert(MilliCelsius(0).MilliKelvin(), qt.Equals, int32(273150))
+	c.Assert(MilliRH(45500).Percent(), qt.Equals, float32(45.5))
+	c.Assert(Pascal(101325).Hectopascals(), qt.Equals, float32(1013.25))
+	c.Assert(MicroTesla(50).MilliGauss(), qt.Equals, int32(500))
+	c.Assert(MilliG(1000).MetersPerSecond2(), qt.Equals, float32(9.80665))
+}
+
+type sensor struct{}
+
+func (sensor) ReadTemperature() (int32, error) { return 21375, nil }
+func (sensor) ReadHumidity() (int32, error)    { return 4520, nil }
+func (sensor) ReadPressure() (int32, error)    { return 101325000, nil }
+
+func (sensor) ReadAcceleration() (x, y, z int32, err error) {
+	return 12000, -3000, 1001000, nil
+}
+
+func TestRead(t *testing.T) {
+	c := qt.New(t)
+	temperature, err := ReadTemperature(sensor{})
+	c.Assert(err, qt.IsNil)
+	c.Assert(temperature, qt.Equals, MilliCelsius(21375))
+
+	humidity, err := ReadHumidity(sensor{})
+	c.Assert(err, qt.IsNil)
+	c.Assert(humidity, qt.Equals, MilliRH(45200))
+
+	pressure, err := ReadPressure(sensor{})
+	c.Assert(err, qt.IsNil)
+	c.Assert(pressure, qt.Equals, Pascal(101325))
+
+	x, y, z, err := ReadAcceleration(sensor{})
+	c.Assert(err, qt.IsNil)
+	c.Assert([]MilliG{x, y, z}, qt.DeepEquals, []MilliG{12, -3, 1001})
+}